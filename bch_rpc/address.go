@@ -0,0 +1,190 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+)
+
+// cashAddressNets are the networks whose addresses are recognized when encoding
+// an address as cashaddr.  Legacy testnet and regtest addresses can not be told
+// apart, so they are encoded for testnet.
+var cashAddressNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet3Params,
+	&chaincfg.RegressionNetParams,
+	&chaincfg.SimNetParams,
+}
+
+// encodeCashAddress returns the passed address in cashaddr format including
+// its network prefix, such as "bitcoincash:qp...", converting legacy addresses
+// as needed.  Addresses which have no cashaddr form, or whose network is not
+// known, are encoded as they are.
+func encodeCashAddress(addr bchutil.Address) string {
+	for _, net := range cashAddressNets {
+		if !addr.IsForNet(net) {
+			continue
+		}
+
+		var cashAddr bchutil.Address
+		var err error
+		switch a := addr.(type) {
+		case *bchutil.AddressPubKeyHash, *bchutil.AddressScriptHash:
+			cashAddr = a
+		case *bchutil.LegacyAddressPubKeyHash:
+			cashAddr, err = bchutil.NewAddressPubKeyHash(a.ScriptAddress(), net)
+		case *bchutil.LegacyAddressScriptHash:
+			cashAddr, err = bchutil.NewAddressScriptHashFromHash(a.ScriptAddress(), net)
+		case *bchutil.AddressPubKey:
+			cashAddr, err = bchutil.NewAddressPubKeyHash(bchutil.Hash160(a.ScriptAddress()), net)
+		default:
+			return addr.EncodeAddress()
+		}
+		if err != nil {
+			return addr.EncodeAddress()
+		}
+		return net.CashAddressPrefix + ":" + cashAddr.EncodeAddress()
+	}
+	return addr.EncodeAddress()
+}
+
+// encodeLegacyAddress returns the passed address of the passed network in
+// legacy format, converting cashaddr addresses as needed.  It returns an empty
+// string for addresses which have no legacy form.
+func encodeLegacyAddress(addr bchutil.Address, net *chaincfg.Params) string {
+	var legacyAddr bchutil.Address
+	var err error
+	switch a := addr.(type) {
+	case *bchutil.LegacyAddressPubKeyHash, *bchutil.LegacyAddressScriptHash:
+		legacyAddr = a
+	case *bchutil.AddressPubKeyHash:
+		legacyAddr, err = bchutil.NewLegacyAddressPubKeyHash(a.ScriptAddress(), net)
+	case *bchutil.AddressScriptHash:
+		legacyAddr, err = bchutil.NewLegacyAddressScriptHashFromHash(a.ScriptAddress(), net)
+	default:
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	return legacyAddr.EncodeAddress()
+}
+
+// addressForms returns the cashaddr and legacy forms of the passed address, or
+// empty strings when it is not an address of any known network.
+func addressForms(addr string) (cashAddr, legacyAddr string) {
+	for _, net := range cashAddressNets {
+		a, err := bchutil.DecodeAddress(addr, net)
+		if err != nil || !a.IsForNet(net) {
+			continue
+		}
+		return encodeCashAddress(a), encodeLegacyAddress(a, net)
+	}
+	return "", ""
+}
+
+// decodeAddress decodes an address returned by a node, which is in cashaddr
+// format for any of the known networks, or in legacy format for older nodes.
+func decodeAddress(addr string) (bchutil.Address, error) {
+	for _, net := range cashAddressNets {
+		a, err := bchutil.DecodeAddress(addr, net)
+		if err == nil && a.IsForNet(net) {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid address %q", addr)
+}
+
+// ValidateAddressResult models the data returned from the validateaddress
+// command, along with both formats of the address.
+type ValidateAddressResult struct {
+	btcjson.ValidateAddressChainResult
+
+	// ScriptPubKey is the hex-encoded output script paying to the
+	// address.  Only newer nodes return it.
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+
+	// IsScript is set for pay-to-script-hash addresses.  Only newer nodes
+	// return it.
+	IsScript bool `json:"isscript,omitempty"`
+
+	// CashAddress and LegacyAddress are the address returned by the node
+	// in cashaddr format, including its network prefix, and in legacy
+	// format.  They are empty when the address is not valid.
+	CashAddress   string `json:"-"`
+	LegacyAddress string `json:"-"`
+}
+
+// FutureValidateAddressResult is a future promise to deliver the result of a
+// ValidateAddressAsync or ValidateAddressStringAsync RPC invocation (or an
+// applicable error).
+type FutureValidateAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns
+// information about the given address.  Addresses the node rejects as
+// invalid are reported with IsValid unset rather than as an error.
+func (r FutureValidateAddressResult) Receive() (*ValidateAddressResult, error) {
+	res, err := receiveFuture(r)
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey {
+		return &ValidateAddressResult{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a validateaddress result object.
+	var addrResult ValidateAddressResult
+	err = json.Unmarshal(res, &addrResult)
+	if err != nil {
+		return nil, err
+	}
+
+	if addrResult.IsValid {
+		addrResult.CashAddress, addrResult.LegacyAddress =
+			addressForms(addrResult.Address)
+	}
+	return &addrResult, nil
+}
+
+// ValidateAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ValidateAddress for the blocking version and more details.
+func (c *Client) ValidateAddressAsync(ctx context.Context, addr bchutil.Address) FutureValidateAddressResult {
+	return c.ValidateAddressStringAsync(ctx, encodeCashAddress(addr))
+}
+
+// ValidateAddress returns information about the given address.  The address is
+// sent to the node in cashaddr format, so legacy addresses are accepted as
+// well.
+func (c *Client) ValidateAddress(ctx context.Context, addr bchutil.Address) (*ValidateAddressResult, error) {
+	return c.ValidateAddressAsync(ctx, addr).Receive()
+}
+
+// ValidateAddressStringAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ValidateAddressString for the blocking version and more details.
+func (c *Client) ValidateAddressStringAsync(ctx context.Context, addr string) FutureValidateAddressResult {
+	cmd := btcjson.NewValidateAddressCmd(addr)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ValidateAddressString returns information about the given address as
+// received from a user, in either cashaddr or legacy format.  Malformed
+// addresses are reported with IsValid unset rather than as an error.
+func (c *Client) ValidateAddressString(ctx context.Context, addr string) (*ValidateAddressResult, error) {
+	return c.ValidateAddressStringAsync(ctx, addr).Receive()
+}
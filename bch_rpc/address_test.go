@@ -0,0 +1,132 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+)
+
+// validateAddressHandler answers validateaddress like a BCHN node, which
+// reports addresses in cashaddr format, for the address pairs it knows.
+func validateAddressHandler(req *testRequest) (interface{}, *btcjson.RPCError) {
+	var addr string
+	json.Unmarshal(req.Params[0], &addr)
+	switch addr {
+	case "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a":
+		return map[string]interface{}{
+			"isvalid":      true,
+			"address":      addr,
+			"scriptPubKey": "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac",
+			"isscript":     false,
+		}, nil
+	case "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC":
+		return map[string]interface{}{
+			"isvalid":      true,
+			"address":      "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq",
+			"scriptPubKey": "a91476a04053bda0a88bda5177b86a15c3b29f55987387",
+			"isscript":     true,
+		}, nil
+	case "":
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidAddressOrKey,
+			"Invalid address")
+	}
+	return map[string]interface{}{"isvalid": false}, nil
+}
+
+func TestValidateAddress(t *testing.T) {
+	server := newTestServer(t, validateAddressHandler)
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// A legacy P2PKH address is sent in cashaddr format.
+	addr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	result, err := client.ValidateAddress(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("ValidateAddress: %v", err)
+	}
+	if !result.IsValid || result.IsScript ||
+		result.ScriptPubKey != "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac" ||
+		result.CashAddress != "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a" ||
+		result.LegacyAddress != "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu" {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestValidateAddressString(t *testing.T) {
+	server := newTestServer(t, validateAddressHandler)
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	tests := []struct {
+		addr   string
+		valid  bool
+		script bool
+		cash   string
+		legacy string
+	}{{
+		addr:   "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		valid:  true,
+		cash:   "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		legacy: "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+	}, {
+		addr:   "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC",
+		valid:  true,
+		script: true,
+		cash:   "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq",
+		legacy: "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC",
+	}, {
+		addr: "not an address",
+	}, {
+		// Nodes rejecting the address with an RPC error are reported
+		// the same way.
+		addr: "",
+	}}
+	for _, test := range tests {
+		result, err := client.ValidateAddressString(context.Background(), test.addr)
+		if err != nil {
+			t.Fatalf("%q: ValidateAddressString: %v", test.addr, err)
+		}
+		if result.IsValid != test.valid || result.IsScript != test.script ||
+			result.CashAddress != test.cash ||
+			result.LegacyAddress != test.legacy {
+
+			t.Fatalf("%q: unexpected result %+v", test.addr, result)
+		}
+	}
+}
+
+func TestEncodeCashAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		net  *chaincfg.Params
+		want string
+	}{
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", &chaincfg.MainNetParams,
+			"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", &chaincfg.MainNetParams,
+			"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"mrLC19Je2BuWQDkWSTriGYPyQJXKkkBmCx", &chaincfg.TestNet3Params,
+			"bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvqcw003ap"},
+	}
+	for _, test := range tests {
+		addr, err := bchutil.DecodeAddress(test.addr, test.net)
+		if err != nil {
+			t.Fatalf("DecodeAddress(%s): %v", test.addr, err)
+		}
+		if got := encodeCashAddress(addr); got != test.want {
+			t.Errorf("encodeCashAddress(%s) = %s, want %s", test.addr, got,
+				test.want)
+		}
+	}
+}
@@ -10,10 +10,14 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"sort"
 
 	"github.com/gcash/bchd/btcjson"
 	"github.com/gcash/bchd/chaincfg/chainhash"
 	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil/gcs"
+	"github.com/gcash/bchutil/gcs/builder"
 )
 
 // FutureGetBestBlockHashResult is a future promise to deliver the result of a
@@ -252,19 +256,82 @@ func (c *Client) GetDifficulty(ctx context.Context) (float64, error) {
 	return c.GetDifficultyAsync(ctx).Receive()
 }
 
+// GetBlockChainInfoResult models the data returned from the getblockchaininfo
+// command.  The softforks field is kept raw, since bchd and older nodes report
+// an array of soft forks while BCHN reports an object keyed by their names.
+// Use ActiveSoftForks to read it regardless of its shape.
+type GetBlockChainInfoResult struct {
+	btcjson.GetBlockChainInfoResult
+
+	// InitialBlockDownload is whether the node is still catching up with
+	// the chain, in which case its results may be outdated.  Only BCHN
+	// and Bitcoin ABC nodes report it.
+	InitialBlockDownload bool `json:"initialblockdownload"`
+
+	// SizeOnDisk is the size of the block and undo files in bytes.
+	SizeOnDisk int64 `json:"size_on_disk"`
+
+	// Warnings lists any network and blockchain warnings.
+	Warnings string `json:"warnings"`
+
+	// SoftForks is the raw softforks field as returned by the node.
+	SoftForks json.RawMessage `json:"softforks"`
+}
+
+// ActiveSoftForks returns the names of the soft forks the node reports as
+// active, in the order the node reports them for arrays and sorted for
+// objects.
+func (r *GetBlockChainInfoResult) ActiveSoftForks() ([]string, error) {
+	raw := bytes.TrimSpace(r.SoftForks)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	// bchd and older nodes report an array of soft fork descriptions.
+	if raw[0] == '[' {
+		var forks []btcjson.SoftForkDescription
+		if err := json.Unmarshal(raw, &forks); err != nil {
+			return nil, err
+		}
+		var active []string
+		for _, fork := range forks {
+			if fork.Reject.Status {
+				active = append(active, fork.ID)
+			}
+		}
+		return active, nil
+	}
+
+	// BCHN reports an object keyed by the soft fork names.
+	var forks map[string]struct {
+		Active bool `json:"active"`
+	}
+	if err := json.Unmarshal(raw, &forks); err != nil {
+		return nil, err
+	}
+	var active []string
+	for name, fork := range forks {
+		if fork.Active {
+			active = append(active, name)
+		}
+	}
+	sort.Strings(active)
+	return active, nil
+}
+
 // FutureGetBlockChainInfoResult is a promise to deliver the result of a
 // GetBlockChainInfoAsync RPC invocation (or an applicable error).
 type FutureGetBlockChainInfoResult chan *response
 
 // Receive waits for the response promised by the future and returns chain info
 // result provided by the server.
-func (r FutureGetBlockChainInfoResult) Receive() (*btcjson.GetBlockChainInfoResult, error) {
+func (r FutureGetBlockChainInfoResult) Receive() (*GetBlockChainInfoResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
 		return nil, err
 	}
 
-	var chainInfo btcjson.GetBlockChainInfoResult
+	var chainInfo GetBlockChainInfoResult
 	if err := json.Unmarshal(res, &chainInfo); err != nil {
 		return nil, err
 	}
@@ -283,8 +350,9 @@ func (c *Client) GetBlockChainInfoAsync(ctx context.Context) FutureGetBlockChain
 
 // GetBlockChainInfo returns information related to the processing state of
 // various chain-specific details such as the current difficulty from the tip
-// of the main chain.
-func (c *Client) GetBlockChainInfo(ctx context.Context) (*btcjson.GetBlockChainInfoResult, error) {
+// of the main chain.  Check InitialBlockDownload and Pruned before trusting
+// results which depend on the node having the whole chain.
+func (c *Client) GetBlockChainInfo(ctx context.Context) (*GetBlockChainInfoResult, error) {
 	return c.GetBlockChainInfoAsync(ctx).Receive()
 }
 
@@ -440,14 +508,35 @@ func (r FutureGetMempoolEntryResult) Receive() (*btcjson.GetMempoolEntryResult,
 		return nil, err
 	}
 
-	// Unmarshal the result as an array of strings.
-	var mempoolEntryResult btcjson.GetMempoolEntryResult
+	// Unmarshal the result as a getmempoolentry result object, along with
+	// the fees newer nodes only report in a separate object.
+	var mempoolEntryResult struct {
+		btcjson.GetMempoolEntryResult
+		Fees *mempoolFees `json:"fees"`
+	}
 	err = json.Unmarshal(res, &mempoolEntryResult)
 	if err != nil {
 		return nil, err
 	}
 
-	return &mempoolEntryResult, nil
+	entry := mempoolEntryResult.GetMempoolEntryResult
+	if fees := mempoolEntryResult.Fees; fees != nil {
+		entry.Fee = fees.Base
+		entry.ModifiedFee = fees.Modified
+		entry.AncestorFees = fees.Ancestor
+		entry.DescendantFees = fees.Descendant
+	}
+	return &entry, nil
+}
+
+// mempoolFees models the fees object of mempool entries.  BCHN moved the fees
+// of mempool entries from the entry itself into this object, so they are
+// copied to where older nodes report them.
+type mempoolFees struct {
+	Base       float64 `json:"base"`
+	Modified   float64 `json:"modified"`
+	Ancestor   float64 `json:"ancestor"`
+	Descendant float64 `json:"descendant"`
 }
 
 // GetMempoolEntryAsync returns an instance of a type that can be used to get the
@@ -530,12 +619,25 @@ func (r FutureGetRawMempoolVerboseResult) Receive() (map[string]btcjson.GetRawMe
 	}
 
 	// Unmarshal the result as a map of strings (tx shas) to their detailed
-	// results.
-	var mempoolItems map[string]btcjson.GetRawMempoolVerboseResult
-	err = json.Unmarshal(res, &mempoolItems)
+	// results, along with the fees newer nodes only report in a separate
+	// object.
+	var rawItems map[string]struct {
+		btcjson.GetRawMempoolVerboseResult
+		Fees *mempoolFees `json:"fees"`
+	}
+	err = json.Unmarshal(res, &rawItems)
 	if err != nil {
 		return nil, err
 	}
+
+	mempoolItems := make(map[string]btcjson.GetRawMempoolVerboseResult,
+		len(rawItems))
+	for txHash, item := range rawItems {
+		if item.Fees != nil {
+			item.Fee = item.Fees.Base
+		}
+		mempoolItems[txHash] = item.GetRawMempoolVerboseResult
+	}
 	return mempoolItems, nil
 }
 
@@ -558,6 +660,44 @@ func (c *Client) GetRawMempoolVerbose(ctx context.Context) (map[string]btcjson.G
 	return c.GetRawMempoolVerboseAsync(ctx).Receive()
 }
 
+// FutureGetMempoolInfoResult is a future promise to deliver the result of a
+// GetMempoolInfoAsync RPC invocation (or an applicable error).
+type FutureGetMempoolInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns a data
+// structure with information about the state of the memory pool.
+func (r FutureGetMempoolInfoResult) Receive() (*btcjson.GetMempoolInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result as a getmempoolinfo result object.
+	var mempoolInfoResult btcjson.GetMempoolInfoResult
+	err = json.Unmarshal(res, &mempoolInfoResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mempoolInfoResult, nil
+}
+
+// GetMempoolInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetMempoolInfo for the blocking version and more details.
+func (c *Client) GetMempoolInfoAsync(ctx context.Context) FutureGetMempoolInfoResult {
+	cmd := btcjson.NewGetMempoolInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetMempoolInfo returns a data structure with information about the state of
+// the memory pool, such as the number of transactions in it and their size.
+func (c *Client) GetMempoolInfo(ctx context.Context) (*btcjson.GetMempoolInfoResult, error) {
+	return c.GetMempoolInfoAsync(ctx).Receive()
+}
+
 // FutureEstimateFeeResult is a future promise to deliver the result of a
 // EstimateFeeAsync RPC invocation (or an applicable error).
 type FutureEstimateFeeResult chan *response
@@ -846,8 +986,8 @@ func (r FutureGetCFilterResult) Receive() (*wire.MsgCFilter, error) {
 	}
 
 	// Assign the filter bytes to the correct field of the wire message.
-	// We aren't going to set the block hash or extended flag, since we
-	// don't actually get that back in the RPC response.
+	// The block hash and filter type are not part of the RPC response, so
+	// they are left for GetCFilter to fill in.
 	var msgCFilter wire.MsgCFilter
 	msgCFilter.Data = serializedFilter
 	return &msgCFilter, nil
@@ -869,10 +1009,52 @@ func (c *Client) GetCFilterAsync(ctx context.Context, blockHash *chainhash.Hash,
 	return c.sendCmd(ctx, cmd)
 }
 
-// GetCFilter returns a raw filter from the server given its block hash.
+// GetCFilter returns a raw filter from the server given its block hash.  The
+// returned message carries the block hash and filter type of the request, so
+// it can be passed straight to MatchAny.
 func (c *Client) GetCFilter(ctx context.Context, blockHash *chainhash.Hash,
 	filterType wire.FilterType) (*wire.MsgCFilter, error) {
-	return c.GetCFilterAsync(ctx, blockHash, filterType).Receive()
+	msgCFilter, err := c.GetCFilterAsync(ctx, blockHash, filterType).Receive()
+	if err != nil {
+		return nil, err
+	}
+
+	if blockHash != nil {
+		msgCFilter.BlockHash = *blockHash
+	}
+	msgCFilter.FilterType = filterType
+	return msgCFilter, nil
+}
+
+// MatchAny reports whether any of the passed scripts, usually the output
+// scripts paying to the caller's addresses, is a member of the passed basic
+// compact filter.  The filter's BlockHash must be set since the filter key is
+// derived from it, which GetCFilter takes care of.
+//
+// Like every compact filter match, a true result may be a false positive, so
+// the block has to be fetched to confirm it.  A false result is definitive.
+func MatchAny(filter *wire.MsgCFilter, scripts [][]byte) (bool, error) {
+	if filter.FilterType != wire.GCSFilterRegular {
+		return false, fmt.Errorf("unsupported filter type %d",
+			filter.FilterType)
+	}
+	if len(scripts) == 0 {
+		return false, nil
+	}
+
+	gcsFilter, err := gcs.FromNBytes(builder.DefaultP, builder.DefaultM,
+		filter.Data)
+	if err != nil {
+		return false, err
+	}
+
+	// An empty filter has no members, and gcs refuses to match against it.
+	if gcsFilter.N() == 0 {
+		return false, nil
+	}
+
+	key := builder.DeriveKey(&filter.BlockHash)
+	return gcsFilter.MatchAny(key, scripts)
 }
 
 // FutureGetCFilterHeaderResult is a future promise to deliver the result of a
@@ -928,3 +1110,170 @@ func (c *Client) GetCFilterHeader(ctx context.Context, blockHash *chainhash.Hash
 	filterType wire.FilterType) (*wire.MsgCFHeaders, error) {
 	return c.GetCFilterHeaderAsync(ctx, blockHash, filterType).Receive()
 }
+
+// ChainTipStatus classifies the status of a chain tip reported by
+// getchaintips.
+type ChainTipStatus int
+
+// These constants describe the possible statuses of a chain tip.
+const (
+	// ChainTipUnknown indicates a status this package does not know.
+	ChainTipUnknown ChainTipStatus = iota
+
+	// ChainTipActive indicates the tip of the best chain, which is valid.
+	ChainTipActive
+
+	// ChainTipValidFork indicates a fully validated branch which is not
+	// part of the best chain.
+	ChainTipValidFork
+
+	// ChainTipValidHeaders indicates a branch whose blocks are all
+	// available but were never fully validated.
+	ChainTipValidHeaders
+
+	// ChainTipHeadersOnly indicates a branch with valid headers for which
+	// not all blocks are available.
+	ChainTipHeadersOnly
+
+	// ChainTipInvalid indicates a branch containing at least one invalid
+	// block.
+	ChainTipInvalid
+
+	// ChainTipParked indicates a branch the node refuses to reorganize to
+	// unless it is explicitly unparked.  Only Bitcoin Cash Node reports
+	// it.
+	ChainTipParked
+)
+
+// Map of the status strings reported by nodes to their ChainTipStatus.
+var chainTipStatuses = map[string]ChainTipStatus{
+	"active":        ChainTipActive,
+	"valid-fork":    ChainTipValidFork,
+	"valid-headers": ChainTipValidHeaders,
+	"headers-only":  ChainTipHeadersOnly,
+	"invalid":       ChainTipInvalid,
+	"parked":        ChainTipParked,
+}
+
+// String returns the ChainTipStatus as reported by nodes.
+func (s ChainTipStatus) String() string {
+	for str, status := range chainTipStatuses {
+		if status == s {
+			return str
+		}
+	}
+	return fmt.Sprintf("Unknown ChainTipStatus (%d)", int(s))
+}
+
+// GetChainTipsResult models a chain tip returned from the getchaintips
+// command.
+type GetChainTipsResult struct {
+	Height    int32  `json:"height"`
+	Hash      string `json:"hash"`
+	BranchLen int32  `json:"branchlen"`
+	Status    string `json:"status"`
+}
+
+// TipStatus returns the status of the chain tip as a ChainTipStatus.  Statuses
+// this package does not know are returned as ChainTipUnknown.
+func (r *GetChainTipsResult) TipStatus() ChainTipStatus {
+	return chainTipStatuses[r.Status]
+}
+
+// FutureGetChainTipsResult is a future promise to deliver the result of a
+// GetChainTipsAsync RPC invocation (or an applicable error).
+type FutureGetChainTipsResult chan *response
+
+// Receive waits for the response promised by the future and returns the tips
+// of all branches known to the server.
+func (r FutureGetChainTipsResult) Receive() ([]GetChainTipsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getchaintips result objects.
+	var chainTips []GetChainTipsResult
+	err = json.Unmarshal(res, &chainTips)
+	if err != nil {
+		return nil, err
+	}
+
+	return chainTips, nil
+}
+
+// GetChainTipsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetChainTips for the blocking version and more details.
+func (c *Client) GetChainTipsAsync(ctx context.Context) FutureGetChainTipsResult {
+	cmd := btcjson.NewGetChainTipsCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetChainTips returns the tips of all branches of the block tree known to the
+// server, including the tip of the best chain.  A ChainTipValidFork tip close
+// to the active one signals a competing chain.
+func (c *Client) GetChainTips(ctx context.Context) ([]GetChainTipsResult, error) {
+	return c.GetChainTipsAsync(ctx).Receive()
+}
+
+// GetIndexInfoResult models the state of an optional index returned from the
+// getindexinfo command.
+type GetIndexInfoResult struct {
+	Synced          bool  `json:"synced"`
+	BestBlockHeight int32 `json:"best_block_height"`
+}
+
+// FutureGetIndexInfoResult is a future promise to deliver the result of a
+// GetIndexInfoAsync RPC invocation (or an applicable error).
+type FutureGetIndexInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the state
+// of the optional indexes of the server by name.
+func (r FutureGetIndexInfoResult) Receive() (map[string]GetIndexInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an object of getindexinfo results by index name.
+	var indexInfo map[string]GetIndexInfoResult
+	err = json.Unmarshal(res, &indexInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexInfo, nil
+}
+
+// GetIndexInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetIndexInfo for the blocking version and more details.
+func (c *Client) GetIndexInfoAsync(ctx context.Context, indexName string) FutureGetIndexInfoResult {
+	var params []interface{}
+	if indexName != "" {
+		params = append(params, indexName)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetIndexInfoResult(c.RawRequestAsync(ctx, "getindexinfo",
+		rawParams))
+}
+
+// GetIndexInfo returns the state of the optional indexes of the server, such as
+// whether txindex is built, keyed by index name.  An empty indexName returns
+// all indexes, otherwise only the named one is returned.  Indexes which are not
+// enabled are left out of the result, so a missing entry means the index is
+// not available.
+func (c *Client) GetIndexInfo(ctx context.Context, indexName string) (map[string]GetIndexInfoResult, error) {
+	return c.GetIndexInfoAsync(ctx, indexName).Receive()
+}
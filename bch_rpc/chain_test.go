@@ -0,0 +1,397 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/wire"
+)
+
+// The mempool fixtures describe the same transaction as reported by bchd,
+// which has the fees in the entry itself, and by newer BCHN releases, which
+// moved them into a separate fees object.
+const (
+	mempoolTxHash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+
+	bchdMempoolEntry = `{
+		"size": 226,
+		"fee": 0.00000226,
+		"modifiedfee": 0.00000300,
+		"time": 1600000000,
+		"height": 650000,
+		"startingpriority": 0,
+		"currentpriority": 0,
+		"descendantcount": 1,
+		"descendantsize": 226,
+		"descendantfees": 226,
+		"ancestorcount": 1,
+		"ancestorsize": 226,
+		"ancestorfees": 226,
+		"depends": []
+	}`
+
+	bchnMempoolEntry = `{
+		"fees": {
+			"base": 0.00000226,
+			"modified": 0.00000300,
+			"ancestor": 226,
+			"descendant": 226
+		},
+		"size": 226,
+		"time": 1600000000,
+		"height": 650000,
+		"descendantcount": 1,
+		"descendantsize": 226,
+		"ancestorcount": 1,
+		"ancestorsize": 226,
+		"depends": []
+	}`
+)
+
+func TestGetRawMempool(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if string(req.Params[0]) != "false" {
+			t.Errorf("unexpected verbose flag %s", req.Params[0])
+		}
+		return []string{mempoolTxHash}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	hashes, err := client.GetRawMempool(context.Background())
+	if err != nil {
+		t.Fatalf("GetRawMempool: %v", err)
+	}
+	if len(hashes) != 1 || hashes[0].String() != mempoolTxHash {
+		t.Fatalf("unexpected hashes %v", hashes)
+	}
+}
+
+func TestGetRawMempoolVerbose(t *testing.T) {
+	for _, entry := range []string{bchdMempoolEntry, bchnMempoolEntry} {
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			return json.RawMessage(`{"` + mempoolTxHash + `":` + entry + `}`), nil
+		})
+		client := newTestClient(t, server)
+		items, err := client.GetRawMempoolVerbose(context.Background())
+		stopClient(client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("GetRawMempoolVerbose: %v", err)
+		}
+
+		item, ok := items[mempoolTxHash]
+		if !ok || len(items) != 1 {
+			t.Fatalf("unexpected items %v", items)
+		}
+		if item.Fee != 0.00000226 || item.Size != 226 || item.Height != 650000 {
+			t.Fatalf("unexpected item %+v", item)
+		}
+	}
+}
+
+func TestGetMempoolEntry(t *testing.T) {
+	for _, entry := range []string{bchdMempoolEntry, bchnMempoolEntry} {
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			return json.RawMessage(entry), nil
+		})
+		client := newTestClient(t, server)
+		result, err := client.GetMempoolEntry(context.Background(), mempoolTxHash)
+		stopClient(client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("GetMempoolEntry: %v", err)
+		}
+
+		if result.Fee != 0.00000226 || result.ModifiedFee != 0.000003 ||
+			result.AncestorFees != 226 || result.DescendantFees != 226 ||
+			result.AncestorCount != 1 || result.Time != 1600000000 {
+
+			t.Fatalf("unexpected entry %+v", result)
+		}
+	}
+}
+
+func TestGetMempoolInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getmempoolinfo" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		return json.RawMessage(`{"loaded":true,"size":12,"bytes":4096,` +
+			`"usage":20000,"maxmempool":300000000,"mempoolminfee":0.00001}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetMempoolInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetMempoolInfo: %v", err)
+	}
+	if info.Size != 12 || info.Bytes != 4096 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
+
+// The blockchain info fixtures are reported by bchd, with an array of soft
+// forks, and by BCHN, with an object of soft forks keyed by their names.
+const (
+	bchdBlockChainInfo = `{
+		"chain": "mainnet",
+		"blocks": 650000,
+		"headers": 650000,
+		"bestblockhash": "0000000000000000029a2e04e5af1df9b4c72e1e4a3f5e6d2cb1b6c4ea14f8d0",
+		"difficulty": 288811326734.58,
+		"mediantime": 1600000000,
+		"pruned": false,
+		"chainwork": "0000000000000000000000000000000000000000012b1e0e7e2b3c9d1b6a8f00",
+		"softforks": [
+			{"id": "bip34", "version": 2, "reject": {"status": true}},
+			{"id": "bip66", "version": 3, "reject": {"status": true}},
+			{"id": "bip65", "version": 4, "reject": {"status": false}}
+		],
+		"bip9_softforks": {
+			"csv": {"status": "active", "bit": 0, "startTime": 1462060800, "timeout": 1493596800, "since": 419328}
+		}
+	}`
+
+	bchnBlockChainInfo = `{
+		"chain": "main",
+		"blocks": 650000,
+		"headers": 650100,
+		"bestblockhash": "0000000000000000029a2e04e5af1df9b4c72e1e4a3f5e6d2cb1b6c4ea14f8d0",
+		"difficulty": 288811326734.58,
+		"mediantime": 1600000000,
+		"verificationprogress": 0.9999,
+		"initialblockdownload": true,
+		"chainwork": "0000000000000000000000000000000000000000012b1e0e7e2b3c9d1b6a8f00",
+		"size_on_disk": 190000000000,
+		"pruned": true,
+		"pruneheight": 600000,
+		"automatic_pruning": true,
+		"prune_target_size": 5242880000,
+		"softforks": {
+			"bip34": {"type": "buried", "active": true, "height": 227931},
+			"csv": {"type": "buried", "active": true, "height": 419328},
+			"bip66": {"type": "buried", "active": true, "height": 363725},
+			"testdummy": {"type": "bip9", "bip9": {"status": "defined"}, "active": false}
+		},
+		"warnings": ""
+	}`
+)
+
+func TestGetBlockChainInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		reply       string
+		ibd         bool
+		pruned      bool
+		activeForks []string
+	}{
+		{"bchd", bchdBlockChainInfo, false, false, []string{"bip34", "bip66"}},
+		{"BCHN", bchnBlockChainInfo, true, true, []string{"bip34", "bip66", "csv"}},
+	}
+	for _, test := range tests {
+		reply := test.reply
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			return json.RawMessage(reply), nil
+		})
+		client := newTestClient(t, server)
+
+		info, err := client.GetBlockChainInfo(context.Background())
+		stopClient(client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: GetBlockChainInfo: %v", test.name, err)
+		}
+		if info.Blocks != 650000 || info.InitialBlockDownload != test.ibd ||
+			info.Pruned != test.pruned {
+
+			t.Fatalf("%s: unexpected info %+v", test.name, info)
+		}
+		active, err := info.ActiveSoftForks()
+		if err != nil {
+			t.Fatalf("%s: ActiveSoftForks: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(active, test.activeForks) {
+			t.Fatalf("%s: active soft forks %v, want %v", test.name,
+				active, test.activeForks)
+		}
+	}
+}
+
+func TestGetCFilter(t *testing.T) {
+	// The testnet3 genesis block and its basic filter are the first test
+	// vector of BIP158.  The filter holds the coinbase output script only.
+	genesis := chaincfg.TestNet3Params.GenesisBlock
+	genesisHash := chaincfg.TestNet3Params.GenesisHash
+	const filterHeader = "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750"
+
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		want := `["` + genesisHash.String() + `",0]`
+		if got, _ := json.Marshal(req.Params); string(got) != want {
+			t.Errorf("unexpected params %s", got)
+		}
+		switch req.Method {
+		case "getcfilter":
+			return "019dfca8", nil
+		case "getcfilterheader":
+			return filterHeader, nil
+		}
+		t.Errorf("unexpected method %s", req.Method)
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	filter, err := client.GetCFilter(context.Background(), genesisHash,
+		wire.GCSFilterRegular)
+	if err != nil {
+		t.Fatalf("GetCFilter: %v", err)
+	}
+	if filter.BlockHash != *genesisHash ||
+		filter.FilterType != wire.GCSFilterRegular {
+
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+
+	coinbaseScript := genesis.Transactions[0].TxOut[0].PkScript
+	otherScript := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	otherScript = append(otherScript, 0x88, 0xac)
+	tests := []struct {
+		name    string
+		scripts [][]byte
+		want    bool
+	}{
+		{"coinbase", [][]byte{coinbaseScript}, true},
+		{"other and coinbase", [][]byte{otherScript, coinbaseScript}, true},
+		{"other", [][]byte{otherScript}, false},
+		{"none", nil, false},
+	}
+	for _, test := range tests {
+		matched, err := MatchAny(filter, test.scripts)
+		if err != nil {
+			t.Fatalf("%s: MatchAny: %v", test.name, err)
+		}
+		if matched != test.want {
+			t.Errorf("%s: got match %v, want %v", test.name, matched,
+				test.want)
+		}
+	}
+
+	header, err := client.GetCFilterHeader(context.Background(), genesisHash,
+		wire.GCSFilterRegular)
+	if err != nil {
+		t.Fatalf("GetCFilterHeader: %v", err)
+	}
+	if header.PrevFilterHeader.String() != filterHeader {
+		t.Fatalf("got filter header %v", header.PrevFilterHeader)
+	}
+}
+
+func TestGetChainTips(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getchaintips" || len(req.Params) != 0 {
+			t.Errorf("unexpected request %s %s", req.Method, req.Params)
+		}
+		return json.RawMessage(`[
+			{
+				"height": 650010,
+				"hash": "000000000000000001b6ce6b7b2bbe4d4e8abb8bbce5b98e8b1f1b5b0a9a2e10",
+				"branchlen": 0,
+				"status": "active"
+			},
+			{
+				"height": 650009,
+				"hash": "00000000000000000208e7c2a5b1e4e9a1f1e4c0c5a4b0d7b6e3f2a1c9d8e7f6",
+				"branchlen": 1,
+				"status": "valid-fork"
+			},
+			{
+				"height": 640000,
+				"hash": "0000000000000000035fd0c6e2b0a4d5c9e1f7a3b2c4d6e8f0a1b3c5d7e9f1a3",
+				"branchlen": 3,
+				"status": "parked"
+			},
+			{
+				"height": 600000,
+				"hash": "00000000000000000148d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1",
+				"branchlen": 2,
+				"status": "some-new-status"
+			}
+		]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	tips, err := client.GetChainTips(context.Background())
+	if err != nil {
+		t.Fatalf("GetChainTips: %v", err)
+	}
+	want := []ChainTipStatus{ChainTipActive, ChainTipValidFork,
+		ChainTipParked, ChainTipUnknown}
+	if len(tips) != len(want) {
+		t.Fatalf("%d tips, want %d", len(tips), len(want))
+	}
+	for i := range tips {
+		if status := tips[i].TipStatus(); status != want[i] {
+			t.Errorf("tip %d has status %v, want %v", i, status, want[i])
+		}
+	}
+	if tips[1].Height != 650009 || tips[1].BranchLen != 1 {
+		t.Errorf("unexpected fork tip %+v", tips[1])
+	}
+	if got := ChainTipValidFork.String(); got != "valid-fork" {
+		t.Errorf("ChainTipValidFork prints as %q", got)
+	}
+}
+
+func TestGetIndexInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getindexinfo" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		if len(req.Params) == 0 {
+			return json.RawMessage(`{
+				"txindex": {"synced": true, "best_block_height": 650010},
+				"basic block filter index": {"synced": false, "best_block_height": 420000}
+			}`), nil
+		}
+		if got, _ := json.Marshal(req.Params); string(got) != `["txindex"]` {
+			t.Errorf("unexpected params %s", got)
+		}
+		return json.RawMessage(`{
+			"txindex": {"synced": true, "best_block_height": 650010}
+		}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	indexes, err := client.GetIndexInfo(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetIndexInfo: %v", err)
+	}
+	want := map[string]GetIndexInfoResult{
+		"txindex":                  {Synced: true, BestBlockHeight: 650010},
+		"basic block filter index": {Synced: false, BestBlockHeight: 420000},
+	}
+	if !reflect.DeepEqual(indexes, want) {
+		t.Fatalf("got indexes %+v, want %+v", indexes, want)
+	}
+
+	indexes, err = client.GetIndexInfo(context.Background(), "txindex")
+	if err != nil {
+		t.Fatalf("GetIndexInfo: %v", err)
+	}
+	if len(indexes) != 1 || !indexes["txindex"].Synced {
+		t.Fatalf("unexpected indexes %+v", indexes)
+	}
+}
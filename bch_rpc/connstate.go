@@ -0,0 +1,164 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"sync"
+)
+
+// connEvent is a change of the connection state waiting to be passed to the
+// OnClientConnected or OnClientDisconnected callbacks.
+type connEvent struct {
+	connected bool
+	retries   int
+	err       error
+}
+
+// connNotifier passes connection state changes to the callbacks configured in
+// ConnConfig and to the OnClientConnected notification handler.  The changes are queued and delivered in order by a dedicated
+// goroutine, so the callbacks never run while the client holds any of its
+// locks and may safely call back into the client.
+type connNotifier struct {
+	onConnected    func(retries int)
+	onDisconnected func(err error)
+
+	// onClientConnected is the OnClientConnected notification handler,
+	// which is only invoked in websocket mode.
+	onClientConnected func()
+
+	// mtx protects the fields below.
+	mtx    sync.Mutex
+	queue  []connEvent
+	signal chan struct{}
+
+	// connected and failures track the connection state in HTTP POST
+	// mode, where there is no connection to watch.  failures is the
+	// number of consecutive transport failures.
+	connected bool
+	failures  int
+}
+
+// newConnNotifier returns a notifier for the callbacks in the passed
+// configuration and notification handlers, or nil when none are configured.
+func newConnNotifier(config *ConnConfig, ntfnHandlers *NotificationHandlers) *connNotifier {
+	var onClientConnected func()
+	if ntfnHandlers != nil && !config.HTTPPostMode {
+		onClientConnected = ntfnHandlers.OnClientConnected
+	}
+	if config.OnClientConnected == nil && config.OnClientDisconnected == nil &&
+		onClientConnected == nil {
+		return nil
+	}
+	return &connNotifier{
+		onConnected:       config.OnClientConnected,
+		onDisconnected:    config.OnClientDisconnected,
+		onClientConnected: onClientConnected,
+		signal:            make(chan struct{}, 1),
+	}
+}
+
+// notify queues the passed event for delivery.  It must be called with the
+// notifier mutex held so events are delivered in the order they happened.
+func (n *connNotifier) notify(event connEvent) {
+	n.queue = append(n.queue, event)
+	select {
+	case n.signal <- struct{}{}:
+	default:
+	}
+}
+
+// notifyConnected queues a connected event carrying the passed number of
+// reconnect attempts it took.  Every connect and reconnect is reported through
+// here, which delivers it to both the OnClientConnected callback and the
+// OnClientConnected notification handler.  It does nothing on a nil notifier.
+func (n *connNotifier) notifyConnected(retries int) {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	n.notify(connEvent{connected: true, retries: retries})
+	n.mtx.Unlock()
+}
+
+// notifyDisconnected queues a disconnected event carrying the error which
+// caused it, if any.  It does nothing on a nil notifier.
+func (n *connNotifier) notifyDisconnected(err error) {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	n.notify(connEvent{err: err})
+	n.mtx.Unlock()
+}
+
+// reportPost derives the connection state in HTTP POST mode from the outcome
+// of a request.  The client counts as connected once the server answers a
+// request, and as disconnected after the passed threshold of consecutive
+// transport failures.  The retry count passed to OnClientConnected after a
+// disconnect is the number of failed requests in between.  It does nothing on
+// a nil notifier.
+func (n *connNotifier) reportPost(threshold int, err error) {
+	if n == nil {
+		return
+	}
+	failed, ok := transportFailure(err)
+	if !ok {
+		return
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if !failed {
+		if !n.connected {
+			n.connected = true
+			n.notify(connEvent{connected: true, retries: n.failures})
+		}
+		n.failures = 0
+		return
+	}
+	n.failures++
+	if n.connected && n.failures >= threshold {
+		n.connected = false
+		n.notify(connEvent{err: err})
+	}
+}
+
+// connEventHandler delivers queued connection events to the callbacks until
+// the client shuts down.
+//
+// This must be run as a goroutine.
+func (c *Client) connEventHandler() {
+	n := c.connNotifier
+out:
+	for {
+		select {
+		case <-n.signal:
+		case <-c.shutdown:
+			break out
+		}
+
+		n.mtx.Lock()
+		events := n.queue
+		n.queue = nil
+		n.mtx.Unlock()
+
+		for _, event := range events {
+			if !event.connected {
+				if n.onDisconnected != nil {
+					n.onDisconnected(event.err)
+				}
+				continue
+			}
+			if n.onClientConnected != nil {
+				n.onClientConnected()
+			}
+			if n.onConnected != nil {
+				n.onConnected(event.retries)
+			}
+		}
+	}
+	c.wg.Done()
+}
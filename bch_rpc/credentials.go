@@ -0,0 +1,118 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// credentialsCacheTTL is how long credentials returned by a
+// CredentialsProvider are used before the provider is asked again.
+const credentialsCacheTTL = 30 * time.Second
+
+// CredentialsProvider returns the username and password to authenticate to
+// the RPC server with, for example after fetching them from a secret store
+// which rotates them.  It is called with the context of the request being
+// sent.
+type CredentialsProvider func(ctx context.Context) (user, pass string, err error)
+
+// ErrCredentialsProvider describes the condition where the configured
+// CredentialsProvider failed to return credentials, so the request was not
+// sent.
+type ErrCredentialsProvider struct {
+	// Err is the error returned by the provider.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrCredentialsProvider) Error() string {
+	return fmt.Sprintf("credentials provider failed: %v", e.Err)
+}
+
+// Unwrap returns the error returned by the provider.
+func (e *ErrCredentialsProvider) Unwrap() error {
+	return e.Err
+}
+
+// credentialsCache caches the credentials returned by a CredentialsProvider
+// for credentialsCacheTTL.
+type credentialsCache struct {
+	provider CredentialsProvider
+
+	mtx     sync.Mutex
+	user    string
+	pass    string
+	expires time.Time
+}
+
+// newCredentialsCache returns a cache for the passed provider, or nil when
+// there is no provider.
+func newCredentialsCache(provider CredentialsProvider) *credentialsCache {
+	if provider == nil {
+		return nil
+	}
+	return &credentialsCache{provider: provider}
+}
+
+// get returns the cached credentials, asking the provider for new ones when
+// they have expired.
+func (c *credentialsCache) get(ctx context.Context) (user, pass string, err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if time.Now().Before(c.expires) {
+		return c.user, c.pass, nil
+	}
+	user, pass, err = c.provider(ctx)
+	if err != nil {
+		return "", "", &ErrCredentialsProvider{Err: err}
+	}
+	c.user, c.pass = user, pass
+	c.expires = time.Now().Add(credentialsCacheTTL)
+	return user, pass, nil
+}
+
+// invalidate drops the passed credentials, which the server rejected, from
+// the cache so the next request asks the provider again.  Credentials which
+// were already replaced are left alone, so requests rejected at the same time
+// do not ask the provider more than once.
+func (c *credentialsCache) invalidate(user, pass string) {
+	c.mtx.Lock()
+	if c.user == user && c.pass == pass {
+		c.expires = time.Time{}
+	}
+	c.mtx.Unlock()
+}
+
+// setCredentials sets the basic authorization of the passed request to the
+// credentials returned by the configured CredentialsProvider.  It does nothing
+// when there is no provider.
+func (c *Client) setCredentials(httpReq *http.Request) error {
+	if c.credentials == nil {
+		return nil
+	}
+	user, pass, err := c.credentials.get(httpReq.Context())
+	if err != nil {
+		return err
+	}
+	httpReq.SetBasicAuth(user, pass)
+	return nil
+}
+
+// refreshCredentials reports whether a request rejected with ErrInvalidAuth
+// should be sent again with fresh credentials, after dropping the rejected
+// ones from the cache.
+func (c *Client) refreshCredentials(httpReq *http.Request, err error) bool {
+	if c.credentials == nil || err != ErrInvalidAuth {
+		return false
+	}
+	user, pass, _ := httpReq.BasicAuth()
+	c.credentials.invalidate(user, pass)
+	return true
+}
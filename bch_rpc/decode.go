@@ -0,0 +1,119 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+// DecodeTransactionLocal returns information about a transaction given its
+// serialized bytes, like DecodeRawTransaction, without asking a node.  The
+// addresses of the outputs are those of the passed network in cashaddr format,
+// including the network prefix.
+//
+// Output scripts which are not of any standard type are reported as
+// "nonstandard" without addresses.  DecodeRawTransaction still provides the
+// interpretation of the node for those.
+func DecodeTransactionLocal(serializedTx []byte, params *chaincfg.Params) (*btcjson.TxRawResult, error) {
+	var mtx wire.MsgTx
+	if err := mtx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+
+	txid := mtx.TxHash().String()
+	return &btcjson.TxRawResult{
+		Txid:     txid,
+		Hash:     txid,
+		Size:     int32(len(serializedTx)),
+		Version:  mtx.Version,
+		LockTime: mtx.LockTime,
+		Vin:      decodeVinList(&mtx),
+		Vout:     decodeVoutList(&mtx, params),
+	}, nil
+}
+
+// isCoinBaseTx returns whether the passed transaction is a coinbase
+// transaction, which has a single input spending no previous output.
+func isCoinBaseTx(mtx *wire.MsgTx) bool {
+	if len(mtx.TxIn) != 1 {
+		return false
+	}
+	prevOut := &mtx.TxIn[0].PreviousOutPoint
+	return prevOut.Index == math.MaxUint32 && prevOut.Hash == chainhash.Hash{}
+}
+
+// decodeVinList returns the inputs of the passed transaction as reported by
+// the decoderawtransaction command.
+func decodeVinList(mtx *wire.MsgTx) []btcjson.Vin {
+	// Coinbase transactions only have a single txin by definition.
+	vinList := make([]btcjson.Vin, len(mtx.TxIn))
+	if isCoinBaseTx(mtx) {
+		txIn := mtx.TxIn[0]
+		vinList[0].Coinbase = hex.EncodeToString(txIn.SignatureScript)
+		vinList[0].Sequence = txIn.Sequence
+		return vinList
+	}
+
+	for i, txIn := range mtx.TxIn {
+		// The disassembled string will contain [error] inline if the
+		// script doesn't fully parse, so ignore the error here.
+		disbuf, _ := txscript.DisasmString(txIn.SignatureScript)
+
+		vinEntry := &vinList[i]
+		vinEntry.Txid = txIn.PreviousOutPoint.Hash.String()
+		vinEntry.Vout = txIn.PreviousOutPoint.Index
+		vinEntry.Sequence = txIn.Sequence
+		vinEntry.ScriptSig = &btcjson.ScriptSig{
+			Asm: disbuf,
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		}
+	}
+
+	return vinList
+}
+
+// decodeVoutList returns the outputs of the passed transaction as reported by
+// the decoderawtransaction command.
+func decodeVoutList(mtx *wire.MsgTx, params *chaincfg.Params) []btcjson.Vout {
+	voutList := make([]btcjson.Vout, 0, len(mtx.TxOut))
+	for i, v := range mtx.TxOut {
+		// The disassembled string will contain [error] inline if the
+		// script doesn't fully parse, so ignore the error here.
+		disbuf, _ := txscript.DisasmString(v.PkScript)
+
+		// Ignore the error here since an error means the script
+		// couldn't parse and there is no additional information about
+		// it anyways.
+		scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+			v.PkScript, params)
+
+		var encodedAddrs []string
+		for _, addr := range addrs {
+			encodedAddrs = append(encodedAddrs, encodeCashAddress(addr))
+		}
+
+		var vout btcjson.Vout
+		vout.N = uint32(i)
+		vout.Value = bchutil.Amount(v.Value).ToBCH()
+		vout.ScriptPubKey.Addresses = encodedAddrs
+		vout.ScriptPubKey.Asm = disbuf
+		vout.ScriptPubKey.Hex = hex.EncodeToString(v.PkScript)
+		vout.ScriptPubKey.Type = scriptClass.String()
+		vout.ScriptPubKey.ReqSigs = int32(reqSigs)
+
+		voutList = append(voutList, vout)
+	}
+
+	return voutList
+}
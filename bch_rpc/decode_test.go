@@ -0,0 +1,197 @@
+package bch_rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+)
+
+// decodeGoldenTests pairs serialized transactions with the decoderawtransaction
+// result expected for them.
+var decodeGoldenTests = []struct {
+	name   string
+	params *chaincfg.Params
+	tx     string
+	want   string
+}{
+	{
+		name:   "genesis coinbase",
+		params: &chaincfg.MainNetParams,
+		tx:     genesisCoinbaseTx,
+		want: `{
+			"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			"hash": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			"size": 204,
+			"version": 1,
+			"locktime": 0,
+			"vin": [{
+				"coinbase": "04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73",
+				"sequence": 4294967295
+			}],
+			"vout": [{
+				"value": 50,
+				"n": 0,
+				"scriptPubKey": {
+					"asm": "04678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5f OP_CHECKSIG",
+					"hex": "4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac",
+					"reqSigs": 1,
+					"type": "pubkey",
+					"addresses": ["bitcoincash:qp3wjpa3tjlj042z2wv7hahsldgwhwy0rq9sywjpyy"]
+				}
+			}]
+		}`,
+	},
+	{
+		name:   "pubkeyhash, scripthash and data outputs",
+		params: &chaincfg.MainNetParams,
+		tx: "02000000013ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9f" +
+			"b8aa4b1e5e4a000000006a473044022051a4e2b1f1e7a55c32b0cbb0da4c1e" +
+			"7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d" +
+			"6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41210279be667ef9dcbbac" +
+			"55a06295ce870b07029bfcdb2dce28d959f2815b16f81798feffffff03f0ca" +
+			"052a010000001976a914751e76e8199196d454941c45d1b3a323f1433bd688" +
+			"ac102700000000000017a914da1745e9b549bd0bfa1a569971c77eba30cd5a" +
+			"4b8700000000000000000a6a08736563746f6b656e10eb0900",
+		want: `{
+			"txid": "a9e548da487a0ba7475e8cd4fefeab48680869678af30c05136ac0f13cfb8083",
+			"hash": "a9e548da487a0ba7475e8cd4fefeab48680869678af30c05136ac0f13cfb8083",
+			"size": 242,
+			"version": 2,
+			"locktime": 650000,
+			"vin": [{
+				"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+				"vout": 0,
+				"scriptSig": {
+					"asm": "3044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41 0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+					"hex": "473044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+				},
+				"sequence": 4294967294
+			}],
+			"vout": [{
+				"value": 49.9999,
+				"n": 0,
+				"scriptPubKey": {
+					"asm": "OP_DUP OP_HASH160 751e76e8199196d454941c45d1b3a323f1433bd6 OP_EQUALVERIFY OP_CHECKSIG",
+					"hex": "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac",
+					"reqSigs": 1,
+					"type": "pubkeyhash",
+					"addresses": ["bitcoincash:qp63uahgrxged4z5jswyt5dn5v3lzsem6cy4spdc2h"]
+				}
+			}, {
+				"value": 0.0001,
+				"n": 1,
+				"scriptPubKey": {
+					"asm": "OP_HASH160 da1745e9b549bd0bfa1a569971c77eba30cd5a4b OP_EQUAL",
+					"hex": "a914da1745e9b549bd0bfa1a569971c77eba30cd5a4b87",
+					"reqSigs": 1,
+					"type": "scripthash",
+					"addresses": ["bitcoincash:prdpw30fk4ym6zl6rftfjuw806arpn26fv8cp7wyl3"]
+				}
+			}, {
+				"value": 0,
+				"n": 2,
+				"scriptPubKey": {
+					"asm": "OP_RETURN 736563746f6b656e",
+					"hex": "6a08736563746f6b656e",
+					"type": "nulldata"
+				}
+			}]
+		}`,
+	},
+	{
+		name:   "testnet with nonstandard output",
+		params: &chaincfg.TestNet3Params,
+		tx: "01000000021f1e1d1c1b1a191817161514131211100f0e0d0c0b0a090807060504" +
+			"03020100010000006a473044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f" +
+			"3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3" +
+			"e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41210279be667ef9dcbbac55a06295ce" +
+			"870b07029bfcdb2dce28d959f2815b16f81798ffffffff3f3e3d3c3b3a393837" +
+			"363534333231302f2e2d2c2b2a29282726252423222120030000006a47304402" +
+			"2051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f" +
+			"1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c" +
+			"6d2a3b41210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2" +
+			"815b16f81798ffffffff0215cd5b07000000001976a914751e76e8199196d454" +
+			"941c45d1b3a323f1433bd688ac2202000000000000015100000000",
+		want: `{
+			"txid": "6e9d799192a27463c02b98685feba7d9f8a03cb21ae3a60d617413d00fa6ac56",
+			"hash": "6e9d799192a27463c02b98685feba7d9f8a03cb21ae3a60d617413d00fa6ac56",
+			"size": 348,
+			"version": 1,
+			"locktime": 0,
+			"vin": [{
+				"txid": "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+				"vout": 1,
+				"scriptSig": {
+					"asm": "3044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41 0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+					"hex": "473044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+				},
+				"sequence": 4294967295
+			}, {
+				"txid": "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+				"vout": 3,
+				"scriptSig": {
+					"asm": "3044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41 0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+					"hex": "473044022051a4e2b1f1e7a55c32b0cbb0da4c1e7f8d5f3a6a0b6fe55e6a0d6c1e3a0b9f1e02203a7bd14e33ea5e1b5f0a1c7d6a0a54c3e5f4b2e69a3e0c2d1f7b8e4f1c6d2a3b41210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+				},
+				"sequence": 4294967295
+			}],
+			"vout": [{
+				"value": 1.23456789,
+				"n": 0,
+				"scriptPubKey": {
+					"asm": "OP_DUP OP_HASH160 751e76e8199196d454941c45d1b3a323f1433bd6 OP_EQUALVERIFY OP_CHECKSIG",
+					"hex": "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac",
+					"reqSigs": 1,
+					"type": "pubkeyhash",
+					"addresses": ["bchtest:qp63uahgrxged4z5jswyt5dn5v3lzsem6cq85x00dt"]
+				}
+			}, {
+				"value": 0.00000546,
+				"n": 1,
+				"scriptPubKey": {
+					"asm": "1",
+					"hex": "51",
+					"type": "nonstandard"
+				}
+			}]
+		}`,
+	},
+}
+
+func TestDecodeTransactionLocal(t *testing.T) {
+	for _, test := range decodeGoldenTests {
+		serializedTx, err := hex.DecodeString(test.tx)
+		if err != nil {
+			t.Fatalf("%s: DecodeString: %v", test.name, err)
+		}
+		var want btcjson.TxRawResult
+		if err := json.Unmarshal([]byte(test.want), &want); err != nil {
+			t.Fatalf("%s: Unmarshal: %v", test.name, err)
+		}
+
+		got, err := DecodeTransactionLocal(serializedTx, test.params)
+		if err != nil {
+			t.Fatalf("%s: DecodeTransactionLocal: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, &want) {
+			gotJSON, _ := json.MarshalIndent(got, "", "\t")
+			t.Errorf("%s: got\n%s", test.name, gotJSON)
+		}
+	}
+}
+
+func TestDecodeTransactionLocalMalformed(t *testing.T) {
+	serializedTx, err := hex.DecodeString(genesisCoinbaseTx)
+	if err != nil {
+		t.Fatalf("DecodeString: %v", err)
+	}
+	_, err = DecodeTransactionLocal(serializedTx[:len(serializedTx)-10],
+		&chaincfg.MainNetParams)
+	if err == nil {
+		t.Fatal("truncated transaction decoded without error")
+	}
+}
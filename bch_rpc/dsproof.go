@@ -0,0 +1,186 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// DSProof verbosity levels accepted by GetDSProof and GetDSProofList.  Each
+// level includes the fields of the levels below it.
+const (
+	// DSProofVerbosityHex only returns the serialized proofs, along with
+	// the transactions they are for in GetDSProof.  GetDSProofList
+	// returns only the ids of the proofs.
+	DSProofVerbosityHex = 0
+
+	// DSProofVerbosityOutpoint adds the ids and sizes of the proofs and
+	// the outpoints double spent.
+	DSProofVerbosityOutpoint = 1
+
+	// DSProofVerbositySpenders adds the two conflicting spenders of the
+	// outpoint to the proofs.
+	DSProofVerbositySpenders = 2
+)
+
+// DSProofOutpoint is the outpoint a double-spend proof shows to be spent twice.
+type DSProofOutpoint struct {
+	TxID string `json:"txid"`
+	Vout uint32 `json:"vout"`
+}
+
+// DSProofPushData is the signature pushed by a spender of a double-spend
+// proof.
+type DSProofPushData struct {
+	Asm string `json:"asm"`
+	Hex string `json:"hex"`
+}
+
+// DSProofSpender describes one of the two conflicting spenders of a
+// double-spend proof, with the parts of the transaction its signature commits
+// to.
+type DSProofSpender struct {
+	TxVersion       int32           `json:"txversion"`
+	Sequence        uint32          `json:"sequence"`
+	LockTime        uint32          `json:"locktime"`
+	HashPrevOutputs string          `json:"hashprevoutputs"`
+	HashSequence    string          `json:"hashsequence"`
+	HashOutputs     string          `json:"hashoutputs"`
+	PushData        DSProofPushData `json:"pushdata"`
+}
+
+// DSProof models a double-spend proof returned by the getdsproof and
+// getdsprooflist commands.  Which fields are set depends on the requested
+// verbosity, see the DSProofVerbosity constants.
+type DSProof struct {
+	// DspID is the id of the proof.
+	DspID string `json:"dspid,omitempty"`
+
+	// Hex is the serialized proof.
+	Hex string `json:"hex,omitempty"`
+
+	// TxID is the transaction in the mempool the proof is for.  It is
+	// empty for orphan proofs, whose transaction is not known.
+	TxID string `json:"txid,omitempty"`
+
+	// Size is the size of the serialized proof in bytes.
+	Size int `json:"size,omitempty"`
+
+	// Outpoint is the outpoint spent twice.
+	Outpoint *DSProofOutpoint `json:"outpoint,omitempty"`
+
+	// Spenders are the two conflicting spenders of the outpoint.
+	Spenders []DSProofSpender `json:"spenders,omitempty"`
+
+	// Path lists the ids of the transactions from the one looked up by
+	// GetDSProof to the one the proof is for, when they differ.
+	Path []string `json:"path,omitempty"`
+}
+
+// FutureGetDSProofResult is a future promise to deliver the result of a
+// GetDSProofAsync RPC invocation (or an applicable error).
+type FutureGetDSProofResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// double-spend proof.
+func (r FutureGetDSProofResult) Receive() (*DSProof, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getdsproof result object.
+	var proof DSProof
+	err = json.Unmarshal(res, &proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proof, nil
+}
+
+// GetDSProofAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetDSProof for the blocking version and more details.
+func (c *Client) GetDSProofAsync(ctx context.Context, id string, verbosity int) FutureGetDSProofResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	rawParams, err := marshalParams([]interface{}{id, verbosity})
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureGetDSProofResult(c.RawRequestAsync(ctx, "getdsproof",
+		rawParams))
+}
+
+// GetDSProof returns the double-spend proof of a transaction in the mempool,
+// as supported by Bitcoin Cash Node.  The id may be the id of the proof, the
+// id of the transaction, or an outpoint spent by it in the "txid:vout" form.
+// Transactions without a proof yield an RPC error.  See the DSProofVerbosity
+// constants for the meaning of verbosity.
+func (c *Client) GetDSProof(ctx context.Context, id string, verbosity int) (*DSProof, error) {
+	return c.GetDSProofAsync(ctx, id, verbosity).Receive()
+}
+
+// FutureGetDSProofListResult is a future promise to deliver the result of a
+// GetDSProofListAsync RPC invocation (or an applicable error).
+type FutureGetDSProofListResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// double-spend proofs known to the node.
+func (r FutureGetDSProofListResult) Receive() ([]DSProof, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of proofs, which are only their ids at
+	// the lowest verbosity.
+	var raw []json.RawMessage
+	err = json.Unmarshal(res, &raw)
+	if err != nil {
+		return nil, err
+	}
+	proofs := make([]DSProof, len(raw))
+	for i, r := range raw {
+		if len(r) != 0 && r[0] == '"' {
+			err = json.Unmarshal(r, &proofs[i].DspID)
+		} else {
+			err = json.Unmarshal(r, &proofs[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return proofs, nil
+}
+
+// GetDSProofListAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetDSProofList for the blocking version and more details.
+func (c *Client) GetDSProofListAsync(ctx context.Context, verbosity int, includeOrphans bool) FutureGetDSProofListResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	rawParams, err := marshalParams([]interface{}{verbosity, includeOrphans})
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureGetDSProofListResult(c.RawRequestAsync(ctx,
+		"getdsprooflist", rawParams))
+}
+
+// GetDSProofList returns the double-spend proofs known to the node, as
+// supported by Bitcoin Cash Node.  Orphan proofs, whose transaction the node
+// does not know, are only included when includeOrphans is set.  See the
+// DSProofVerbosity constants for the meaning of verbosity.
+func (c *Client) GetDSProofList(ctx context.Context, verbosity int, includeOrphans bool) ([]DSProof, error) {
+	return c.GetDSProofListAsync(ctx, verbosity, includeOrphans).Receive()
+}
@@ -0,0 +1,157 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+const (
+	dspID       = "d4d3b5b8bc4e7d2f6c0dbd0e1a1d2e9a4c7f2b3e8c9d0a1b2c3d4e5f6a7b8c9d"
+	dspTxID     = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	dspHex      = "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098"
+	dspOutpoint = `{"txid": "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098", "vout": 1}`
+	dspSpender  = `{
+		"txversion": 2,
+		"sequence": 4294967295,
+		"locktime": 0,
+		"hashprevoutputs": "a1b2",
+		"hashsequence": "c3d4",
+		"hashoutputs": "e5f6",
+		"pushdata": {"asm": "3044[ALL|FORKID]", "hex": "3044"}
+	}`
+)
+
+// dsProofFixtures are the replies of getdsproof at each verbosity.
+var dsProofFixtures = []string{
+	`{"hex": "` + dspHex + `", "txid": "` + dspTxID + `"}`,
+	`{"dspid": "` + dspID + `", "txid": "` + dspTxID + `", "size": 32, "outpoint": ` +
+		dspOutpoint + `}`,
+	`{"dspid": "` + dspID + `", "txid": "` + dspTxID + `", "size": 32, "outpoint": ` +
+		dspOutpoint + `, "spenders": [` + dspSpender + `, ` + dspSpender + `]}`,
+}
+
+// checkDSProof checks the fields of the passed proof expected at the passed
+// verbosity.
+func checkDSProof(t *testing.T, proof *DSProof, verbosity int) {
+	t.Helper()
+
+	if proof.TxID != dspTxID {
+		t.Fatalf("verbosity %d: unexpected txid %s", verbosity, proof.TxID)
+	}
+	if verbosity == DSProofVerbosityHex {
+		if proof.Hex != dspHex || proof.Outpoint != nil {
+			t.Fatalf("verbosity %d: unexpected proof %+v", verbosity, proof)
+		}
+		return
+	}
+	if proof.DspID != dspID || proof.Size != 32 || proof.Outpoint == nil ||
+		proof.Outpoint.Vout != 1 {
+
+		t.Fatalf("verbosity %d: unexpected proof %+v", verbosity, proof)
+	}
+	if verbosity == DSProofVerbosityOutpoint {
+		if len(proof.Spenders) != 0 {
+			t.Fatalf("verbosity %d: unexpected spenders", verbosity)
+		}
+		return
+	}
+	if len(proof.Spenders) != 2 || proof.Spenders[0].Sequence != 4294967295 ||
+		proof.Spenders[1].PushData.Hex != "3044" {
+
+		t.Fatalf("verbosity %d: unexpected spenders %+v", verbosity,
+			proof.Spenders)
+	}
+}
+
+func TestGetDSProof(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+
+		var verbosity int
+		if err := json.Unmarshal(req.Params[1], &verbosity); err != nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidParameter, err.Error())
+		}
+		return json.RawMessage(dsProofFixtures[verbosity]), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	for verbosity := range dsProofFixtures {
+		proof, err := client.GetDSProof(context.Background(), dspTxID, verbosity)
+		if err != nil {
+			t.Fatalf("GetDSProof: %v", err)
+		}
+		checkDSProof(t, proof, verbosity)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	for i, p := range params {
+		if want := fmt.Sprintf(`["%s",%d]`, dspTxID, i); p != want {
+			t.Errorf("call %d sent params %s, want %s", i, p, want)
+		}
+	}
+}
+
+func TestGetDSProofList(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+
+		var verbosity int
+		if err := json.Unmarshal(req.Params[0], &verbosity); err != nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidParameter, err.Error())
+		}
+		if verbosity == DSProofVerbosityHex {
+			return json.RawMessage(`["` + dspID + `"]`), nil
+		}
+		return json.RawMessage(`[` + dsProofFixtures[verbosity] + `]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// The lowest verbosity only lists the ids of the proofs.
+	proofs, err := client.GetDSProofList(context.Background(), DSProofVerbosityHex, true)
+	if err != nil {
+		t.Fatalf("GetDSProofList: %v", err)
+	}
+	if len(proofs) != 1 || proofs[0].DspID != dspID || proofs[0].TxID != "" {
+		t.Fatalf("unexpected proofs %+v", proofs)
+	}
+
+	for _, verbosity := range []int{DSProofVerbosityOutpoint, DSProofVerbositySpenders} {
+		proofs, err := client.GetDSProofList(context.Background(), verbosity, false)
+		if err != nil {
+			t.Fatalf("GetDSProofList: %v", err)
+		}
+		if len(proofs) != 1 {
+			t.Fatalf("verbosity %d: %d proofs, want 1", verbosity, len(proofs))
+		}
+		checkDSProof(t, &proofs[0], verbosity)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{`[0,true]`, `[1,false]`, `[2,false]`}
+	for i, p := range params {
+		if p != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, p, want[i])
+		}
+	}
+}
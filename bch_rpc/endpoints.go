@@ -0,0 +1,215 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+const (
+	// defaultFailoverThreshold is the number of consecutive transport
+	// failures after which an endpoint is considered unhealthy when the
+	// connection configuration does not specify one.
+	defaultFailoverThreshold = 3
+
+	// defaultFailoverCooldown is how long an unhealthy endpoint is avoided
+	// when the connection configuration does not specify it.
+	defaultFailoverCooldown = 30 * time.Second
+)
+
+// EndpointStatus describes the health of one of the RPC servers a client is
+// configured with.
+type EndpointStatus struct {
+	// Host is the address of the RPC server.
+	Host string
+
+	// Active is set for the endpoint requests are currently sent to.
+	Active bool
+
+	// Healthy is cleared once the endpoint failed too many times in a row
+	// and is set again when its cooldown has passed.
+	Healthy bool
+
+	// ConsecutiveFailures is the number of transport failures since the
+	// last successful request to the endpoint.
+	ConsecutiveFailures int
+
+	// LastError is the error of the most recent failure, if any.
+	LastError error
+
+	// RetryAt is the time an unhealthy endpoint is tried again.
+	RetryAt time.Time
+}
+
+// endpoint tracks the health of a single RPC server.
+type endpoint struct {
+	host      string
+	failures  int
+	lastErr   error
+	downUntil time.Time
+}
+
+// healthy returns whether the endpoint may be used at the passed time.
+func (e *endpoint) healthy(now time.Time) bool {
+	return !now.Before(e.downUntil)
+}
+
+// endpointSet picks which of the configured RPC servers requests are sent to.
+// Requests stick to the active endpoint until it fails threshold times in a
+// row, after which the next healthy endpoint becomes active and the failed
+// one is avoided until its cooldown has passed.
+type endpointSet struct {
+	mtx       sync.Mutex
+	endpoints []*endpoint
+	active    int
+	threshold int
+	cooldown  time.Duration
+}
+
+// newEndpointSet returns the endpoints from the passed connection
+// configuration.  Hosts takes precedence over Host when both are set.
+func newEndpointSet(config *ConnConfig) *endpointSet {
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{config.Host}
+	}
+	s := &endpointSet{
+		threshold: config.FailoverThreshold,
+		cooldown:  config.FailoverCooldown,
+	}
+	if s.threshold <= 0 {
+		s.threshold = defaultFailoverThreshold
+	}
+	if s.cooldown <= 0 {
+		s.cooldown = defaultFailoverCooldown
+	}
+	for _, host := range hosts {
+		s.endpoints = append(s.endpoints, &endpoint{host: host})
+	}
+	return s
+}
+
+// pick returns the host the next request should be sent to.  When the active
+// endpoint is unhealthy, the next healthy endpoint becomes active.  When no
+// endpoint is healthy, the one whose cooldown ends first is used so requests
+// are still attempted.
+func (s *endpointSet) pick() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	next := s.active
+	for i := range s.endpoints {
+		j := (s.active + i) % len(s.endpoints)
+		e := s.endpoints[j]
+		if e.healthy(now) {
+			next = j
+			break
+		}
+		if e.downUntil.Before(s.endpoints[next].downUntil) {
+			next = j
+		}
+	}
+	s.active = next
+	return s.endpoints[next].host
+}
+
+// report records the outcome of a request sent to the passed host.  Transport
+// failures count against the endpoint while replies from the server, including
+// RPC errors, mark it healthy again.  Other errors, such as the caller giving
+// up, say nothing about the endpoint and are ignored.  It returns whether the
+// endpoint was just marked unhealthy.
+func (s *endpointSet) report(host string, err error) bool {
+	failed, ok := transportFailure(err)
+	if !ok {
+		return false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, e := range s.endpoints {
+		if e.host != host {
+			continue
+		}
+		if !failed {
+			e.failures = 0
+			e.lastErr = nil
+			e.downUntil = time.Time{}
+			return false
+		}
+		e.failures++
+		e.lastErr = err
+		if e.failures < s.threshold {
+			return false
+		}
+		e.downUntil = time.Now().Add(s.cooldown)
+		return e.failures == s.threshold
+	}
+	return false
+}
+
+// transportFailure classifies the outcome of a request.  It returns whether
+// the request failed to reach the server, and whether the outcome says
+// anything about that at all: transport failures and replies from the server,
+// including RPC errors, do, while other errors, such as the caller giving up,
+// do not.
+func transportFailure(err error) (failed, ok bool) {
+	if IsTransient(err) {
+		return true, true
+	}
+	var rpcErr *btcjson.RPCError
+	return false, err == nil || errors.As(err, &rpcErr)
+}
+
+// status returns the health of every endpoint.
+func (s *endpointSet) status() []EndpointStatus {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	status := make([]EndpointStatus, 0, len(s.endpoints))
+	for i, e := range s.endpoints {
+		status = append(status, EndpointStatus{
+			Host:                e.host,
+			Active:              i == s.active,
+			Healthy:             e.healthy(now),
+			ConsecutiveFailures: e.failures,
+			LastError:           e.lastErr,
+			RetryAt:             e.downUntil,
+		})
+	}
+	return status
+}
+
+// Endpoints returns the health of every RPC server the client is configured
+// with, in the order they were configured.
+func (c *Client) Endpoints() []EndpointStatus {
+	return c.endpoints.status()
+}
+
+// ActiveEndpoint returns the host of the RPC server requests are currently
+// sent to.
+func (c *Client) ActiveEndpoint() string {
+	c.endpoints.mtx.Lock()
+	defer c.endpoints.mtx.Unlock()
+
+	return c.endpoints.endpoints[c.endpoints.active].host
+}
+
+// reportEndpoint records the outcome of a request sent to the passed host,
+// logs when the host is marked unhealthy and updates the connection state
+// passed to the connection callbacks.
+func (c *Client) reportEndpoint(host string, err error) {
+	if c.endpoints.report(host, err) {
+		c.log.Warnf("RPC endpoint %s is unhealthy after %d consecutive "+
+			"failures: %v", host, c.endpoints.threshold, err)
+	}
+	c.connNotifier.reportPost(c.endpoints.threshold, err)
+}
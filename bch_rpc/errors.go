@@ -0,0 +1,138 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+// HTTPError describes an HTTP POST mode reply which did not carry a JSON-RPC
+// response, such as an error page from a proxy in front of the RPC server.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the reply.
+	StatusCode int
+
+	// Body is the raw body of the reply.
+	Body string
+}
+
+// Error satisfies the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status code: %d, response: %q", e.StatusCode, e.Body)
+}
+
+// The reject reasons below are reported by the various node implementations
+// when a transaction is not accepted.  Bitcoin Core and its forks report the
+// short reject reason codes while btcd and bchd report descriptive messages.
+var (
+	alreadyInMempoolReasons = []string{
+		"txn-already-in-mempool",
+		"txn-already-known",
+		"already have transaction",
+	}
+
+	missingInputsReasons = []string{
+		"missing inputs",
+		"bad-txns-inputs-missingorspent",
+		"unknown or fully-spent transaction",
+	}
+
+	insufficientFundsReasons = []string{
+		"insufficient funds",
+	}
+
+	// notANumberReasons are reported by nodes for a parameter which is
+	// not the number they expect, such as the allowhighfees flag passed
+	// to nodes which take a numeric maxfeerate instead.
+	notANumberReasons = []string{
+		"is not a number as expected",
+		"expected type number",
+	}
+
+	// addrIndexDisabledReasons are reported by nodes running without the
+	// optional address index for address based queries.
+	addrIndexDisabledReasons = []string{
+		"address index must be enabled",
+		"addrindex",
+	}
+
+	insufficientFeeReasons = []string{
+		"insufficient fee",
+		"min relay fee not met",
+		"mempool min fee not met",
+		"insufficient priority",
+		"under the required amount",
+	}
+)
+
+// rpcErrorContains returns whether the passed error is an RPC error returned
+// by the server whose message contains any of the passed reasons, ignoring
+// case.
+func rpcErrorContains(err error, reasons []string) bool {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, reason := range reasons {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAlreadyInMempool returns whether the passed error is the server rejecting
+// a transaction because it is already in its memory pool.
+func IsAlreadyInMempool(err error) bool {
+	return rpcErrorContains(err, alreadyInMempoolReasons)
+}
+
+// IsMissingInputs returns whether the passed error is the server rejecting a
+// transaction because it spends outputs which are unknown or already spent.
+func IsMissingInputs(err error) bool {
+	return rpcErrorContains(err, missingInputsReasons)
+}
+
+// IsInsufficientFee returns whether the passed error is the server rejecting a
+// transaction because its fee is below what the server requires to relay it.
+func IsInsufficientFee(err error) bool {
+	return rpcErrorContains(err, insufficientFeeReasons)
+}
+
+// IsInsufficientFunds returns whether the passed error is the wallet of the
+// server failing to fund a transaction because its balance is too low.
+func IsInsufficientFunds(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) &&
+		rpcErr.Code == btcjson.ErrRPCWalletInsufficientFunds {
+
+		return true
+	}
+	return rpcErrorContains(err, insufficientFundsReasons)
+}
+
+// isNotANumber returns whether the passed error is the server rejecting a
+// parameter because it expected a number in its place.
+func isNotANumber(err error) bool {
+	return rpcErrorContains(err, notANumberReasons)
+}
+
+// isAddrIndexDisabled returns whether the passed error is the server refusing
+// an address based query because it runs without an address index.
+func isAddrIndexDisabled(err error) bool {
+	return rpcErrorContains(err, addrIndexDisabledReasons)
+}
+
+// isNoTxInfo returns whether the passed error is the server reporting that it
+// has no information about the transactions asked for.
+func isNoTxInfo(err error) bool {
+	var rpcErr *btcjson.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCNoTxInfo
+}
@@ -16,7 +16,7 @@ func TestNew(t *testing.T) {
 	}
 	// Notice the notification parameter is nil since notifications are
 	// not supported in HTTP POST mode.
-	client, err := New(connCfg)
+	client, err := New(connCfg, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -11,7 +11,6 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 
 	"github.com/gcash/bchd/btcjson"
@@ -408,7 +407,7 @@ func (r FutureSessionResult) Receive() (*btcjson.SessionResult, error) {
 func (c *Client) SessionAsync(ctx context.Context) FutureSessionResult {
 	// Not supported in HTTP POST mode.
 	if c.config.HTTPPostMode {
-		return newFutureError(errors.New("ErrWebsocketsRequired"))
+		return newFutureError(ErrWebsocketsRequired)
 	}
 
 	cmd := btcjson.NewSessionCmd()
@@ -0,0 +1,128 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gcash/bchutil"
+)
+
+// EstimateMode selects how conservative the fee rate returned by
+// EstimateSmartFee is.
+type EstimateMode string
+
+// Constants used to indicate the estimate mode for EstimateSmartFee.
+const (
+	// EstimateModeUnset leaves the estimate mode to the node.  It is not
+	// sent at all, so it also works with nodes which do not know of any
+	// estimate modes.
+	EstimateModeUnset EstimateMode = ""
+
+	// EstimateModeEconomical asks for a fee rate which is lower but more
+	// likely to be outbid by later transactions.
+	EstimateModeEconomical EstimateMode = "ECONOMICAL"
+
+	// EstimateModeConservative asks for a fee rate which is higher but
+	// more likely to get the transaction confirmed in time.
+	EstimateModeConservative EstimateMode = "CONSERVATIVE"
+)
+
+// String returns the EstimateMode in human-readable form.
+func (m EstimateMode) String() string {
+	return string(m)
+}
+
+// EstimateSmartFeeResult models the data returned from the estimatesmartfee
+// command.  Nodes lacking the data for an estimate omit the fee rate and
+// explain why in Errors instead.
+type EstimateSmartFeeResult struct {
+	FeeRate *float64 `json:"feerate,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+	Blocks  int64    `json:"blocks"`
+}
+
+// SatPerByte returns the estimated fee rate in satoshis per byte.  See
+// FeeRatePerByte for details.
+func (r *EstimateSmartFeeResult) SatPerByte() (bchutil.Amount, error) {
+	if r.FeeRate == nil {
+		if len(r.Errors) == 0 {
+			return 0, errors.New("no fee rate estimate")
+		}
+		return 0, fmt.Errorf("no fee rate estimate: %s",
+			strings.Join(r.Errors, "; "))
+	}
+	return FeeRatePerByte(*r.FeeRate)
+}
+
+// FeeRatePerByte converts a fee rate in BCH per kilobyte, as returned by
+// EstimateFee and EstimateSmartFee, to satoshis per byte.  Fractions of a
+// satoshi are rounded up so the fee rate is never underestimated.  Negative
+// fee rates, which legacy nodes return when they have no estimate, are
+// rejected.
+func FeeRatePerByte(bchPerKB float64) (bchutil.Amount, error) {
+	if bchPerKB < 0 {
+		return 0, errors.New("no fee rate estimate")
+	}
+	perKB, err := bchutil.NewAmount(bchPerKB)
+	if err != nil {
+		return 0, err
+	}
+	return (perKB + 999) / 1000, nil
+}
+
+// FutureEstimateSmartFeeResult is a future promise to deliver the result of a
+// EstimateSmartFeeAsync RPC invocation (or an applicable error).
+type FutureEstimateSmartFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the fee
+// estimate provided by the server.
+func (r FutureEstimateSmartFeeResult) Receive() (*EstimateSmartFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an estimatesmartfee result object.
+	var feeResult EstimateSmartFeeResult
+	err = json.Unmarshal(res, &feeResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &feeResult, nil
+}
+
+// EstimateSmartFeeAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See EstimateSmartFee for the blocking version and more details.
+func (c *Client) EstimateSmartFeeAsync(ctx context.Context, confTarget int64, mode EstimateMode) FutureEstimateSmartFeeResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	params := []interface{}{confTarget}
+	if mode != EstimateModeUnset {
+		params = append(params, mode)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureEstimateSmartFeeResult(c.RawRequestAsync(ctx,
+		"estimatesmartfee", rawParams))
+}
+
+// EstimateSmartFee returns the fee rate in BCH per kilobyte the node estimates
+// a transaction needs to begin confirmation within confTarget blocks, as
+// supported by Bitcoin ABC and BCHN nodes.  Use SatPerByte to convert the
+// result to satoshis per byte.
+func (c *Client) EstimateSmartFee(ctx context.Context, confTarget int64, mode EstimateMode) (*EstimateSmartFeeResult, error) {
+	return c.EstimateSmartFeeAsync(ctx, confTarget, mode).Receive()
+}
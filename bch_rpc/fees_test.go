@@ -0,0 +1,125 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchutil"
+)
+
+// newFeeServer starts a test server answering every request with the passed
+// reply and recording the params it was sent.
+func newFeeServer(t *testing.T, reply string) (*Client, func() []json.RawMessage, func()) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return json.RawMessage(reply), nil
+	})
+	client := newTestClient(t, server)
+	sent := func() []json.RawMessage {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return params
+	}
+	return client, sent, func() {
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		fee   float64
+		rate  bchutil.Amount
+		valid bool
+	}{
+		{"bchd", `0.00001`, 0.00001, 1, true},
+		{"fraction", `0.00001234`, 0.00001234, 2, true},
+		{"no estimate", `-1`, -1, 0, false},
+	}
+	for _, test := range tests {
+		client, sent, stop := newFeeServer(t, test.reply)
+		fee, err := client.EstimateFee(context.Background(), 6)
+		stop()
+		if err != nil {
+			t.Fatalf("%s: EstimateFee: %v", test.name, err)
+		}
+		if fee != test.fee {
+			t.Fatalf("%s: fee %v, want %v", test.name, fee, test.fee)
+		}
+		if got, _ := json.Marshal(sent()); string(got) != `[6]` {
+			t.Fatalf("%s: sent params %s", test.name, got)
+		}
+
+		rate, err := FeeRatePerByte(fee)
+		if (err == nil) != test.valid || rate != test.rate {
+			t.Fatalf("%s: FeeRatePerByte = %v, %v, want %v", test.name,
+				rate, err, test.rate)
+		}
+	}
+}
+
+func TestEstimateSmartFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  string
+		mode   EstimateMode
+		params string
+		blocks int64
+		rate   bchutil.Amount
+		valid  bool
+	}{{
+		name:   "abc",
+		reply:  `{"feerate":0.00002,"blocks":2}`,
+		mode:   EstimateModeConservative,
+		params: `[2,"CONSERVATIVE"]`,
+		blocks: 2,
+		rate:   2,
+		valid:  true,
+	}, {
+		name:   "abc without data",
+		reply:  `{"errors":["Insufficient data or no feerate found"],"blocks":0}`,
+		mode:   EstimateModeEconomical,
+		params: `[2,"ECONOMICAL"]`,
+	}, {
+		name:   "bchn",
+		reply:  `{"feerate":0.00001000}`,
+		mode:   EstimateModeUnset,
+		params: `[2]`,
+		rate:   1,
+		valid:  true,
+	}}
+	for _, test := range tests {
+		client, sent, stop := newFeeServer(t, test.reply)
+		result, err := client.EstimateSmartFee(context.Background(), 2, test.mode)
+		stop()
+		if err != nil {
+			t.Fatalf("%s: EstimateSmartFee: %v", test.name, err)
+		}
+		if got, _ := json.Marshal(sent()); string(got) != test.params {
+			t.Fatalf("%s: sent params %s, want %s", test.name, got,
+				test.params)
+		}
+		if result.Blocks != test.blocks {
+			t.Fatalf("%s: blocks %d, want %d", test.name, result.Blocks,
+				test.blocks)
+		}
+
+		rate, err := result.SatPerByte()
+		if (err == nil) != test.valid || rate != test.rate {
+			t.Fatalf("%s: SatPerByte = %v, %v, want %v", test.name, rate,
+				err, test.rate)
+		}
+		if !test.valid && len(result.Errors) != 1 {
+			t.Fatalf("%s: errors %q not decoded", test.name, result.Errors)
+		}
+	}
+}
@@ -3,6 +3,9 @@ module github.com/sectoken-dev/tools/bch_rpc
 go 1.13
 
 require (
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
+	github.com/btcsuite/websocket v0.0.0-20150119174127-31079b680792
 	github.com/gcash/bchd v0.15.2
 	github.com/gcash/bchutil v0.0.0-20191012211144-98e73ec336ba
+	github.com/sectoken-dev/tools/chainclient v0.1.0
 )
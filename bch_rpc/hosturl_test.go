@@ -0,0 +1,151 @@
+package bch_rpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPostURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		host       string
+		disableTLS bool
+		wallet     string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name: "bare host",
+			host: "127.0.0.1:8332",
+			want: "https://127.0.0.1:8332",
+		},
+		{
+			name:       "bare host without tls",
+			host:       "127.0.0.1:8332",
+			disableTLS: true,
+			want:       "http://127.0.0.1:8332",
+		},
+		{
+			name: "full url",
+			host: "https://rpc.example.com/btc/mainnet",
+			want: "https://rpc.example.com/btc/mainnet",
+		},
+		{
+			name:       "full url without tls",
+			host:       "http://10.0.0.1:8332",
+			disableTLS: true,
+			want:       "http://10.0.0.1:8332",
+		},
+		{
+			name:   "bare host wallet",
+			host:   "127.0.0.1:8332",
+			wallet: "hot",
+			want:   "https://127.0.0.1:8332/wallet/hot",
+		},
+		{
+			name:   "full url wallet",
+			host:   "https://rpc.example.com/btc/mainnet/",
+			wallet: "cold storage",
+			want:   "https://rpc.example.com/btc/mainnet/wallet/cold%20storage",
+		},
+		{
+			name:    "http scheme with tls",
+			host:    "http://rpc.example.com",
+			wantErr: true,
+		},
+		{
+			name:       "https scheme without tls",
+			host:       "https://rpc.example.com",
+			disableTLS: true,
+			wantErr:    true,
+		},
+		{
+			name:    "unsupported scheme",
+			host:    "ftp://rpc.example.com",
+			wantErr: true,
+		},
+		{
+			name:    "missing host",
+			host:    "https:///btc",
+			wantErr: true,
+		},
+		{
+			name:    "query",
+			host:    "https://rpc.example.com/?key=secret",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		config := &ConnConfig{DisableTLS: test.disableTLS, Wallet: test.wallet}
+		got, err := postURL(test.host, config)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", test.name, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("%s: got %s, %v, want %s", test.name, got, err,
+				test.want)
+		}
+	}
+}
+
+func TestHostURLPath(t *testing.T) {
+	var mtx sync.Mutex
+	var paths []string
+	server := newUnstartedTestServer(t, blockCountHandler)
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		paths = append(paths, r.URL.Path)
+		mtx.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	for _, wallet := range []string{"", "hot"} {
+		config := testConnConfig(server)
+		config.Host = server.URL + "/btc/mainnet"
+		config.Wallet = wallet
+		client, err := New(config, nil)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, err := client.GetBlockCount(context.Background()); err != nil {
+			t.Fatalf("GetBlockCount: %v", err)
+		}
+		stopClient(client)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{"/btc/mainnet", "/btc/mainnet/wallet/hot"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Fatalf("requests sent to %q, want %q", paths, want)
+	}
+}
+
+func TestHostURLConflict(t *testing.T) {
+	config := &ConnConfig{
+		Host:         "http://rpc.example.com/btc",
+		HTTPPostMode: true,
+	}
+	if _, err := New(config, nil); err == nil {
+		t.Fatal("New accepted an http:// host with TLS enabled")
+	}
+
+	// Every failover host is checked.
+	config = &ConnConfig{
+		Hosts:        []string{"127.0.0.1:8332", "http://rpc.example.com"},
+		HTTPPostMode: true,
+	}
+	if _, err := New(config, nil); err == nil {
+		t.Fatal("New accepted an http:// failover host with TLS enabled")
+	}
+}
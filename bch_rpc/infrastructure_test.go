@@ -0,0 +1,778 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+// testRequest is the decoded form of a JSON-RPC request received by a mock
+// server.
+type testRequest struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	ID      interface{}       `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// testHandler answers a single decoded request with either a result or an
+// RPC error.
+type testHandler func(req *testRequest) (interface{}, *btcjson.RPCError)
+
+// newTestServer starts an HTTP server that decodes each JSON-RPC request and
+// replies with whatever the passed handler returns.
+func newTestServer(t testing.TB, handler testHandler) *httptest.Server {
+	t.Helper()
+
+	server := newUnstartedTestServer(t, handler)
+	server.Start()
+	return server
+}
+
+// newUnstartedTestServer returns a test server which has not been started yet
+// so its configuration can still be changed.
+func newUnstartedTestServer(t testing.TB, handler testHandler) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req testRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, rpcErr := handler(&req)
+		reply := map[string]interface{}{
+			"id":     req.ID,
+			"result": result,
+			"error":  rpcErr,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reply)
+	}))
+}
+
+// testConnConfig returns an HTTP POST mode connection configuration for the
+// passed test server.
+func testConnConfig(server *httptest.Server) *ConnConfig {
+	return &ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}
+}
+
+// newTestClient returns an HTTP POST mode client talking to the passed test
+// server.  Callers are responsible for shutting the client down.
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+
+	client, err := New(testConnConfig(server), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+// stopClient shuts the client down and waits for its goroutines to exit.
+func stopClient(client *Client) {
+	client.Shutdown()
+	client.WaitForShutdown()
+}
+
+func TestRawRequest(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		switch req.Method {
+		case "getzmqnotifications":
+			if len(req.Params) != 0 {
+				t.Errorf("unexpected params %s", req.Params)
+			}
+			return []map[string]string{{"type": "pubrawtx"}}, nil
+		case "echo":
+			return req.Params, nil
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	res, err := client.RawRequest(ctx, "getzmqnotifications", nil)
+	if err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	var ntfns []struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(res, &ntfns); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if len(ntfns) != 1 || ntfns[0].Type != "pubrawtx" {
+		t.Fatalf("unexpected result %s", res)
+	}
+
+	params := []json.RawMessage{json.RawMessage(`"a"`), json.RawMessage(`1`)}
+	res, err = client.RawRequestAsync(ctx, "echo", params).Receive()
+	if err != nil {
+		t.Fatalf("RawRequestAsync: %v", err)
+	}
+	if string(res) != `["a",1]` {
+		t.Fatalf("unexpected echo result %s", res)
+	}
+
+	_, err = client.RawRequest(ctx, "nosuchmethod", nil)
+	rpcErr, ok := err.(*btcjson.RPCError)
+	if !ok || rpcErr.Code != btcjson.ErrRPCMethodNotFound.Code {
+		t.Fatalf("expected method not found RPC error, got %v", err)
+	}
+
+	if _, err := client.RawRequest(ctx, "", nil); err == nil {
+		t.Fatal("expected error for empty method")
+	}
+}
+
+func TestRawRequestNotConnected(t *testing.T) {
+	client, err := New(&ConnConfig{Host: "127.0.0.1:1", DisableConnectOnNew: true}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	_, err = client.RawRequest(context.Background(), "getblockcount", nil)
+	if err != ErrClientNotConnected {
+		t.Fatalf("expected ErrClientNotConnected, got %v", err)
+	}
+}
+
+// newSlowServer returns a test server which does not answer any request until
+// the returned release function is called.
+func newSlowServer() (*httptest.Server, func()) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"id":1,"result":0,"error":null}`))
+	}))
+	var once sync.Once
+	return server, func() { once.Do(func() { close(release) }) }
+}
+
+func TestReceiveContextDeadline(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetBlockCount(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("call returned after %v", elapsed)
+	}
+}
+
+func TestReceiveContextQueued(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// Occupy the HTTP POST handler with a request that is never answered
+	// so the next request stays queued behind it.
+	blocked := client.GetBlockCountAsync(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	future := client.GetBlockCountAsync(ctx)
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := future.Receive()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued request did not observe context cancellation")
+	}
+
+	release()
+	if _, err := blocked.Receive(); err != nil {
+		t.Fatalf("blocked request: %v", err)
+	}
+}
+
+func TestReceiveContextRemovesRequest(t *testing.T) {
+	client, err := New(&ConnConfig{Host: "127.0.0.1:1", HTTPPostMode: true}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// Register a request directly as the websocket transport would and
+	// make sure an expired context removes it from the request map.
+	jReq := &jsonRequest{
+		id:           client.NextID(),
+		method:       "getblockcount",
+		responseChan: make(chan *response, 1),
+	}
+	if err := client.addRequest(jReq); err != nil {
+		t.Fatalf("addRequest: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	client.watchContext(ctx, jReq)
+	cancel()
+
+	if _, err := receiveFuture(jReq.responseChan); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if client.removeRequest(jReq.id) != nil {
+		t.Fatal("request was not removed from the request map")
+	}
+
+	// A late reply must be discarded rather than blocking the sender.
+	jReq.respond(&response{result: []byte("1")})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	config := testConnConfig(server)
+	config.RequestTimeout = 50 * time.Millisecond
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	start := time.Now()
+	_, err = client.GetBlockCount(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("call returned after %v", elapsed)
+	}
+
+	// A deadline supplied by the caller takes precedence.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	future := client.GetBlockCountAsync(ctx)
+	select {
+	case <-future:
+		t.Fatal("request with caller deadline was bounded by RequestTimeout")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	config := testConnConfig(server)
+	config.ResponseHeaderTimeout = 50 * time.Millisecond
+	config.DialTimeout = time.Second
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	transport := client.httpClient.Transport.(*http.Transport)
+	if transport.DialContext == nil {
+		t.Fatal("DialTimeout was not applied to the transport")
+	}
+
+	start := time.Now()
+	if _, err := client.GetBlockCount(context.Background()); err == nil {
+		t.Fatal("expected response header timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("call returned after %v", elapsed)
+	}
+}
+
+func TestRequestHeaders(t *testing.T) {
+	var mtx sync.Mutex
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		headers = append(headers, r.Header.Clone())
+		mtx.Unlock()
+		w.Write([]byte(`{"id":1,"result":0,"error":null}`))
+	}))
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.BearerToken = "token"
+	config.ExtraHeaders = map[string]string{"X-Api-Key": "key"}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetBlockCount(context.Background()); err != nil {
+			t.Fatalf("GetBlockCount: %v", err)
+		}
+	}
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(headers) != 4 {
+		t.Fatalf("server saw %d requests, want 4", len(headers))
+	}
+	for i, header := range headers {
+		if got := header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("request %d: Authorization %q", i, got)
+		}
+		if got := header.Get("X-Api-Key"); got != "key" {
+			t.Errorf("request %d: X-Api-Key %q", i, got)
+		}
+	}
+
+	// Basic authorization is used when no bearer token is configured.
+	header := make(http.Header)
+	setHeaders(header, &ConnConfig{User: "user", Pass: "pass"})
+	if got := header.Get("Authorization"); got != "Basic dXNlcjpwYXNz" {
+		t.Fatalf("unexpected basic Authorization %q", got)
+	}
+}
+
+// newConnCountingServer starts a test server which answers requests with the
+// passed handler and counts the connections it accepts and closes.
+func newConnCountingServer(t testing.TB, handler testHandler) (server *httptest.Server, opened, closed func() int) {
+	var mtx sync.Mutex
+	var numOpened, numClosed int
+	server = newUnstartedTestServer(t, handler)
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mtx.Lock()
+		switch state {
+		case http.StateNew:
+			numOpened++
+		case http.StateClosed, http.StateHijacked:
+			numClosed++
+		}
+		mtx.Unlock()
+	}
+	server.Start()
+
+	count := func(n *int) func() int {
+		return func() int {
+			mtx.Lock()
+			defer mtx.Unlock()
+			return *n
+		}
+	}
+	return server, count(&numOpened), count(&numClosed)
+}
+
+// blockCountHandler answers every request with a block count of 100.
+func blockCountHandler(req *testRequest) (interface{}, *btcjson.RPCError) {
+	return 100, nil
+}
+
+func TestConnectionReuse(t *testing.T) {
+	tests := []struct {
+		name    string
+		disable bool
+		opened  int
+	}{
+		{"reuse", false, 1},
+		{"no reuse", true, 5},
+	}
+	for _, test := range tests {
+		server, opened, closed := newConnCountingServer(t, blockCountHandler)
+
+		config := testConnConfig(server)
+		config.DisableConnectionReuse = test.disable
+		client, err := New(config, nil)
+		if err != nil {
+			t.Fatalf("%s: New: %v", test.name, err)
+		}
+		for i := 0; i < 5; i++ {
+			if _, err := client.GetBlockCount(context.Background()); err != nil {
+				t.Fatalf("%s: GetBlockCount: %v", test.name, err)
+			}
+		}
+		if got := opened(); got != test.opened {
+			t.Errorf("%s: server accepted %d connections, want %d",
+				test.name, got, test.opened)
+		}
+
+		// Idle connections must be closed once the client shuts down.
+		stopClient(client)
+		deadline := time.Now().Add(2 * time.Second)
+		for closed() != opened() && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if closed() != opened() {
+			t.Errorf("%s: %d of %d connections left open after shutdown",
+				test.name, opened()-closed(), opened())
+		}
+		server.Close()
+	}
+}
+
+func BenchmarkConnectionReuse(b *testing.B) {
+	for _, disable := range []bool{false, true} {
+		name := "reuse"
+		if disable {
+			name = "no reuse"
+		}
+		b.Run(name, func(b *testing.B) {
+			server, _, _ := newConnCountingServer(b, blockCountHandler)
+			defer server.Close()
+
+			config := testConnConfig(server)
+			config.DisableConnectionReuse = disable
+			client, err := New(config, nil)
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := client.GetBlockCount(context.Background()); err != nil {
+					b.Fatalf("GetBlockCount: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	server, opened, _ := newConnCountingServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblock" {
+			return strings.Repeat("00", 1000), nil
+		}
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.MaxResponseBytes = 1000
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	_, err = client.RawRequest(context.Background(), "getblock", nil)
+	var tooLarge *ErrResponseTooLarge
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+	if tooLarge.Method != "getblock" || tooLarge.Limit != 1000 {
+		t.Fatalf("unexpected error fields %+v", tooLarge)
+	}
+
+	// The oversized response is drained, so the connection is reused.
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if got := opened(); got != 1 {
+		t.Fatalf("server accepted %d connections, want 1", got)
+	}
+}
+
+func TestMaxResponseBytesNotDrained(t *testing.T) {
+	server, opened, _ := newConnCountingServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblock" {
+			return strings.Repeat("00", 4*maxDrainBytes), nil
+		}
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.MaxResponseBytes = 1000
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	_, err = client.RawRequest(context.Background(), "getblock", nil)
+	var tooLarge *ErrResponseTooLarge
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	// Too much of the response is left to drain, so the connection is
+	// closed and the next request opens another one.
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if got := opened(); got != 2 {
+		t.Fatalf("server accepted %d connections, want 2", got)
+	}
+}
+
+func TestHTTPPostWorkers(t *testing.T) {
+	const workers = 4
+	var mtx sync.Mutex
+	var inFlight, maxInFlight int
+	release := make(chan struct{})
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		if inFlight == workers {
+			close(release)
+		}
+		mtx.Unlock()
+
+		// Hold every request until all workers sent one.
+		select {
+		case <-release:
+		case <-time.After(2 * time.Second):
+		}
+
+		mtx.Lock()
+		inFlight--
+		mtx.Unlock()
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = workers
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2*workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetBlockCount(context.Background()); err != nil {
+				t.Errorf("GetBlockCount: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if maxInFlight != workers {
+		t.Fatalf("%d requests in flight at once, want %d", maxInFlight,
+			workers)
+	}
+}
+
+func TestShutdownQueuedRequests(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(time.Millisecond)
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 2
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Keep issuing calls from many goroutines while shutting down, so
+	// calls race every stage of the request lifecycle.  Every call must
+	// return, either with a result or with ErrClientShutdown.
+	const callers = 200
+	done := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			for {
+				_, err := client.GetBlockCount(context.Background())
+				if err != nil {
+					done <- err
+					return
+				}
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	client.Shutdown()
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < callers; i++ {
+		select {
+		case err := <-done:
+			if err != ErrClientShutdown {
+				t.Fatalf("unexpected error %v", err)
+			}
+		case <-timeout:
+			t.Fatalf("%d calls still blocked after shutdown", callers-i)
+		}
+	}
+	client.WaitForShutdown()
+}
+
+func TestShutdownSingleDelivery(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(time.Millisecond)
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 4
+	config.OrderedMethods = []string{"getblockcount"}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Issue asynchronous calls from many goroutines, some of which are
+	// cancelled, while shutting down.  Every future must receive exactly
+	// one response, so none is left in the channel once it was received.
+	const callers = 50
+	var mtx sync.Mutex
+	var futures []chan *response
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ctx, cancel := context.WithCancel(context.Background())
+				var future chan *response
+				if (i+j)%2 == 0 {
+					future = client.GetBlockCountAsync(ctx)
+				} else {
+					future = client.RawRequestAsync(ctx, "uptime", nil)
+				}
+				if j%3 == 0 {
+					cancel()
+				}
+				mtx.Lock()
+				futures = append(futures, future)
+				mtx.Unlock()
+				defer cancel()
+			}
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	client.Shutdown()
+	wg.Wait()
+	client.WaitForShutdown()
+
+	for i, future := range futures {
+		select {
+		case <-future:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("call %d never answered", i)
+		}
+	}
+
+	// Give any duplicate response blocked on a full channel the chance
+	// to be delivered.
+	time.Sleep(10 * time.Millisecond)
+	for i, future := range futures {
+		if n := len(future); n != 0 {
+			t.Fatalf("call %d answered %d more times", i, n)
+		}
+	}
+}
+
+func TestShutdownAbortsInFlight(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 2
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// The server never answers, so both workers are stuck in a round
+	// trip.
+	futures := []FutureGetBlockCountResult{
+		client.GetBlockCountAsync(context.Background()),
+		client.GetBlockCountAsync(context.Background()),
+	}
+	waitPending(t, client, 2)
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	client.Shutdown()
+	client.WaitForShutdown()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("shutdown took %v", elapsed)
+	}
+	for _, future := range futures {
+		if _, err := future.Receive(); err != ErrClientShutdown {
+			t.Fatalf("expected ErrClientShutdown, got %v", err)
+		}
+	}
+}
+
+func TestShutdownRespondsToQueued(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	defer client.WaitForShutdown()
+	defer close(release)
+
+	// The only worker is stuck on the first request, so the second one
+	// stays queued.
+	first := client.GetBlockCountAsync(context.Background())
+	<-arrived
+	queued := client.GetBlockCountAsync(context.Background())
+	client.Shutdown()
+
+	select {
+	case resp := <-queued:
+		if resp.err != ErrClientShutdown {
+			t.Fatalf("expected ErrClientShutdown, got %v", resp.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not answered by Shutdown")
+	}
+	select {
+	case resp := <-first:
+		if resp.err != ErrClientShutdown {
+			t.Fatalf("expected ErrClientShutdown, got %v", resp.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request was not answered by Shutdown")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Instrumentation is implemented by types which want to observe the JSON-RPC
+// requests issued by a client, for example to export request counts, error
+// rates and latencies to a metrics system.
+//
+// RequestStarted is called when a request is handed to the transport and
+// RequestFinished exactly once when its result, or error, is delivered to the
+// caller.  Both are called without any client locks held, but they are called
+// synchronously from the goroutines issuing and answering requests, so
+// implementations must be safe for concurrent use and should return quickly.
+type Instrumentation interface {
+	RequestStarted(method string)
+	RequestFinished(method string, duration time.Duration, err error)
+}
+
+// latencyBuckets are the upper bounds of the latency histogram buckets
+// maintained by ExpvarInstrumentation.
+var latencyBuckets = []struct {
+	name  string
+	bound time.Duration
+}{
+	{"le_1ms", time.Millisecond},
+	{"le_10ms", 10 * time.Millisecond},
+	{"le_100ms", 100 * time.Millisecond},
+	{"le_1s", time.Second},
+	{"le_10s", 10 * time.Second},
+	{"le_inf", time.Duration(1<<63 - 1)},
+}
+
+// ExpvarInstrumentation is an Instrumentation which records per-method
+// request counts, error counts, retry counts, in-flight requests, the total
+// latency in nanoseconds and a cumulative latency histogram in an expvar.Map.
+// Each method has its own nested map, so the published variable looks like:
+//
+//	{"getblockcount": {"requests": 3, "errors": 0, "inflight": 1, ...}}
+type ExpvarInstrumentation struct {
+	// Vars holds the recorded metrics keyed by method.
+	Vars *expvar.Map
+
+	mtx     sync.Mutex
+	methods map[string]*expvar.Map
+}
+
+// NewExpvarInstrumentation returns an ExpvarInstrumentation whose metrics are
+// published under the passed expvar name.  An empty name leaves the metrics
+// unpublished.  As with expvar.Publish, publishing the same name twice
+// panics.
+func NewExpvarInstrumentation(name string) *ExpvarInstrumentation {
+	vars := new(expvar.Map).Init()
+	if name != "" {
+		expvar.Publish(name, vars)
+	}
+	return &ExpvarInstrumentation{
+		Vars:    vars,
+		methods: make(map[string]*expvar.Map),
+	}
+}
+
+// method returns the metrics map for the passed method, creating it if
+// needed.
+func (e *ExpvarInstrumentation) method(method string) *expvar.Map {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	m, ok := e.methods[method]
+	if !ok {
+		m = new(expvar.Map).Init()
+		e.methods[method] = m
+		e.Vars.Set(method, m)
+	}
+	return m
+}
+
+// RequestStarted counts the request and marks it in flight.
+//
+// This is part of the Instrumentation interface.
+func (e *ExpvarInstrumentation) RequestStarted(method string) {
+	m := e.method(method)
+	m.Add("requests", 1)
+	m.Add("inflight", 1)
+}
+
+// RequestFinished records the latency and outcome of the request.
+//
+// This is part of the Instrumentation interface.
+func (e *ExpvarInstrumentation) RequestFinished(method string, duration time.Duration, err error) {
+	m := e.method(method)
+	m.Add("inflight", -1)
+	if err != nil {
+		m.Add("errors", 1)
+	}
+	m.Add("latency_ns", int64(duration))
+	for _, bucket := range latencyBuckets {
+		if duration <= bucket.bound {
+			m.Add(bucket.name, 1)
+		}
+	}
+}
+
+// RequestRetried counts the retry.
+//
+// This is part of the RetryInstrumentation interface.
+func (e *ExpvarInstrumentation) RequestRetried(method string, attempt int, err error) {
+	e.method(method).Add("retries", 1)
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"fmt"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+// defaultJSONRPCVersion is the JSON-RPC version requests are sent with unless
+// the connection configuration asks for another one.
+const defaultJSONRPCVersion = "1.0"
+
+// checkJSONRPCVersion returns an error when the passed JSON-RPC version is not
+// supported.  An empty version means the default.
+func checkJSONRPCVersion(version string) error {
+	switch version {
+	case "", "1.0", "2.0":
+		return nil
+	}
+	return fmt.Errorf("unsupported JSON-RPC version %q", version)
+}
+
+// jsonRPCVersion returns the JSON-RPC version requests are sent with.
+func (c *Client) jsonRPCVersion() string {
+	if c.config.JSONRPCVersion == "" {
+		return defaultJSONRPCVersion
+	}
+	return c.config.JSONRPCVersion
+}
+
+// marshalCmd marshals the passed command into a JSON-RPC request with the
+// passed id using the configured JSON-RPC version.
+func (c *Client) marshalCmd(id uint64, cmd interface{}) ([]byte, error) {
+	return btcjson.MarshalCmd(c.jsonRPCVersion(), id, cmd)
+}
@@ -0,0 +1,137 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+// newCannedServer starts a test server which records the decoded requests it
+// receives and answers each with the passed canned reply, in which %s is
+// replaced by the request id.
+func newCannedServer(t *testing.T, reply string) (*httptest.Server, func() []testRequest) {
+	requests := make(chan testRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req testRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		requests <- req
+		id, _ := json.Marshal(req.ID)
+		fmt.Fprintf(w, reply, id)
+	}))
+	return server, func() []testRequest {
+		var got []testRequest
+		for {
+			select {
+			case req := <-requests:
+				got = append(got, req)
+			default:
+				return got
+			}
+		}
+	}
+}
+
+func TestJSONRPCVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		reply   string
+		wantErr *btcjson.RPCError
+	}{
+		{
+			name:  "default",
+			reply: `{"result":100,"error":null,"id":%s}`,
+		},
+		{
+			name:    "1.0",
+			version: "1.0",
+			reply:   `{"result":100,"error":null,"id":%s}`,
+		},
+		{
+			name:    "2.0",
+			version: "2.0",
+			reply:   `{"jsonrpc":"2.0","result":100,"id":%s}`,
+		},
+		{
+			name:    "2.0 error",
+			version: "2.0",
+			reply: `{"jsonrpc":"2.0","error":{"code":-32601,` +
+				`"message":"Method not found","data":"getblockcount"},"id":%s}`,
+			wantErr: &btcjson.RPCError{
+				Code:    -32601,
+				Message: "Method not found",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := newCannedServer(t, test.reply)
+			defer server.Close()
+
+			config := testConnConfig(server)
+			config.JSONRPCVersion = test.version
+			client, err := New(config, nil)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			count, err := client.GetBlockCount(context.Background())
+			if test.wantErr != nil {
+				var rpcErr *btcjson.RPCError
+				if !errors.As(err, &rpcErr) ||
+					rpcErr.Code != test.wantErr.Code ||
+					rpcErr.Message != test.wantErr.Message {
+
+					t.Fatalf("expected %v, got %v", test.wantErr, err)
+				}
+			} else if err != nil || count != 100 {
+				t.Fatalf("GetBlockCount returned %d, %v", count, err)
+			}
+			if _, err := client.RawRequest(context.Background(),
+				"getblockcount", nil); test.wantErr == nil && err != nil {
+
+				t.Fatalf("RawRequest: %v", err)
+			}
+
+			wantVersion := test.version
+			if wantVersion == "" {
+				wantVersion = "1.0"
+			}
+			got := requests()
+			if len(got) != 2 {
+				t.Fatalf("server received %d requests, want 2", len(got))
+			}
+			for _, req := range got {
+				if req.Jsonrpc != wantVersion || req.Method != "getblockcount" {
+					t.Fatalf("request sent as %q %s, want %q",
+						req.Jsonrpc, req.Method, wantVersion)
+				}
+				if _, ok := req.ID.(float64); !ok {
+					t.Fatalf("request id %v is not a number", req.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONRPCVersionInvalid(t *testing.T) {
+	config := &ConnConfig{
+		Host:           "localhost:8332",
+		HTTPPostMode:   true,
+		JSONRPCVersion: "3.0",
+	}
+	if _, err := New(config, nil); err == nil {
+		t.Fatal("New accepted an unsupported JSON-RPC version")
+	}
+}
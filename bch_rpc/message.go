@@ -0,0 +1,150 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/gcash/bchd/bchec"
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+// messageMagic is prepended to messages before they are hashed for signing, so
+// a signed message can never be a valid transaction signature.  Bitcoin Cash
+// nodes kept the prefix of Bitcoin.
+const messageMagic = "Bitcoin Signed Message:\n"
+
+// FutureSignMessageResult is a future promise to deliver the result of a
+// SignMessageAsync RPC invocation (or an applicable error).
+type FutureSignMessageResult chan *response
+
+// Receive waits for the response promised by the future and returns the message
+// signed with the private key of the specified address.
+func (r FutureSignMessageResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Unmarshal result as a string.
+	var b64 string
+	err = json.Unmarshal(res, &b64)
+	if err != nil {
+		return "", err
+	}
+
+	return b64, nil
+}
+
+// SignMessageAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SignMessage for the blocking version and more details.
+func (c *Client) SignMessageAsync(ctx context.Context, address bchutil.Address, message string) FutureSignMessageResult {
+	cmd := btcjson.NewSignMessageCmd(encodeCashAddress(address), message)
+	return c.sendCmd(ctx, cmd)
+}
+
+// SignMessage signs a message with the private key of the specified address
+// and returns the base64-encoded compact signature.  The address must be a
+// pay-to-pubkey-hash address of the wallet, in legacy or cashaddr format.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) SignMessage(ctx context.Context, address bchutil.Address, message string) (string, error) {
+	return c.SignMessageAsync(ctx, address, message).Receive()
+}
+
+// FutureVerifyMessageResult is a future promise to deliver the result of a
+// VerifyMessageAsync RPC invocation (or an applicable error).
+type FutureVerifyMessageResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// or not the message was successfully verified.
+func (r FutureVerifyMessageResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal result as a boolean.
+	var verified bool
+	err = json.Unmarshal(res, &verified)
+	if err != nil {
+		return false, err
+	}
+
+	return verified, nil
+}
+
+// VerifyMessageAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See VerifyMessage for the blocking version and more details.
+func (c *Client) VerifyMessageAsync(ctx context.Context, address bchutil.Address, signature, message string) FutureVerifyMessageResult {
+	cmd := btcjson.NewVerifyMessageCmd(encodeCashAddress(address), signature,
+		message)
+	return c.sendCmd(ctx, cmd)
+}
+
+// VerifyMessage verifies a signed message with the node.
+//
+// See VerifyMessageLocal to verify a message without a node.
+func (c *Client) VerifyMessage(ctx context.Context, address bchutil.Address, signature, message string) (bool, error) {
+	return c.VerifyMessageAsync(ctx, address, signature, message).Receive()
+}
+
+// VerifyMessageLocal verifies a message signed by SignMessage, or any wallet
+// following the same scheme, without asking a node.  It recovers the public key
+// from the base64-encoded compact signature and reports whether it hashes to
+// the passed pay-to-pubkey-hash address, which may be in legacy or cashaddr
+// format.
+//
+// An error is returned for signatures which are not valid base64 and for
+// addresses other than pay-to-pubkey-hash addresses, which can not have signed
+// a message.
+func VerifyMessageLocal(address bchutil.Address, signature, message string) (bool, error) {
+	switch address.(type) {
+	case *bchutil.AddressPubKeyHash, *bchutil.LegacyAddressPubKeyHash:
+	default:
+		return false, errors.New("address does not refer to a key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, err
+	}
+
+	var buf bytes.Buffer
+	if err := wire.WriteVarString(&buf, 0, messageMagic); err != nil {
+		return false, err
+	}
+	if err := wire.WriteVarString(&buf, 0, message); err != nil {
+		return false, err
+	}
+	hash := chainhash.DoubleHashB(buf.Bytes())
+
+	// A signature which no public key can be recovered from simply does
+	// not verify.
+	pubKey, wasCompressed, err := bchec.RecoverCompact(bchec.S256(), sig, hash)
+	if err != nil {
+		return false, nil
+	}
+	var serializedPubKey []byte
+	if wasCompressed {
+		serializedPubKey = pubKey.SerializeCompressed()
+	} else {
+		serializedPubKey = pubKey.SerializeUncompressed()
+	}
+	return bytes.Equal(bchutil.Hash160(serializedPubKey), address.ScriptAddress()), nil
+}
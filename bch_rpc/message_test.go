@@ -0,0 +1,141 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchutil"
+)
+
+// The message vectors are signed with the private key sha256("sectoken"),
+// once with its compressed and once with its uncompressed public key.
+const (
+	compressedKeyCashAddr   = "bitcoincash:qqzkxrjkjfpv7r9ascwyk8wv8dfaskmxhq4my83jjh"
+	compressedKeyLegacy     = "1VV3VVfaFjc87YNkMcfstuT7B1G3wgPmT"
+	uncompressedKeyCashAddr = "bitcoincash:qq6srhxnqemtplc3x05z3k0vcsf9pd6u45wljemxly"
+	uncompressedKeyLegacy   = "15qH3an8WVj7tdyGFE5oKJqb7HBnbvAuhN"
+
+	onboardingMessage = "sectoken merchant onboarding"
+)
+
+func TestVerifyMessageLocal(t *testing.T) {
+	tests := []struct {
+		addrs     []string
+		message   string
+		signature string
+	}{
+		{
+			addrs:     []string{compressedKeyCashAddr, compressedKeyLegacy},
+			message:   onboardingMessage,
+			signature: "H6OC5TSNNFhcDTgTGSHpb8idhaSHF+jDKG7lBzbJzSZeJbERMcUvRqIg4yNDymweZM53Zbo6dOt1WeFup6vAad4=",
+		},
+		{
+			addrs:     []string{compressedKeyCashAddr, compressedKeyLegacy},
+			message:   "",
+			signature: "ILeBVQhMYRn98tX2dTh2kZEx8xyZv/FsEk5kn63qRXrjfs39Sh/RgLwPZvKlJTage/sQWDJ/m01A59I1GpTe7qk=",
+		},
+		{
+			addrs:     []string{uncompressedKeyCashAddr, uncompressedKeyLegacy},
+			message:   onboardingMessage,
+			signature: "G6OC5TSNNFhcDTgTGSHpb8idhaSHF+jDKG7lBzbJzSZeJbERMcUvRqIg4yNDymweZM53Zbo6dOt1WeFup6vAad4=",
+		},
+		{
+			addrs:     []string{uncompressedKeyCashAddr, uncompressedKeyLegacy},
+			message:   "",
+			signature: "HLeBVQhMYRn98tX2dTh2kZEx8xyZv/FsEk5kn63qRXrjfs39Sh/RgLwPZvKlJTage/sQWDJ/m01A59I1GpTe7qk=",
+		},
+	}
+	for _, test := range tests {
+		for _, s := range test.addrs {
+			addr, err := bchutil.DecodeAddress(s, &chaincfg.MainNetParams)
+			if err != nil {
+				t.Fatalf("DecodeAddress(%s): %v", s, err)
+			}
+
+			ok, err := VerifyMessageLocal(addr, test.signature, test.message)
+			if err != nil || !ok {
+				t.Errorf("%s %q: VerifyMessageLocal = %v, %v", s,
+					test.message, ok, err)
+			}
+
+			// The signature does not verify another message.
+			ok, err = VerifyMessageLocal(addr, test.signature, test.message+".")
+			if err != nil || ok {
+				t.Errorf("%s %q: altered message verified", s, test.message)
+			}
+		}
+	}
+
+	compressed, err := bchutil.DecodeAddress(compressedKeyCashAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	// Nor does it verify the key in its other serialization.
+	ok, err := VerifyMessageLocal(compressed, tests[2].signature, onboardingMessage)
+	if err != nil || ok {
+		t.Errorf("signature of the uncompressed key verified for the compressed key")
+	}
+
+	if _, err := VerifyMessageLocal(compressed, "not base64!", onboardingMessage); err == nil {
+		t.Error("expected an error for a malformed signature")
+	}
+	p2sh, err := bchutil.DecodeAddress("3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	if _, err := VerifyMessageLocal(p2sh, tests[0].signature, onboardingMessage); err == nil {
+		t.Error("expected an error for a script hash address")
+	}
+}
+
+func TestSignVerifyMessage(t *testing.T) {
+	const signature = "H6OC5TSNNFhcDTgTGSHpb8idhaSHF+jDKG7lBzbJzSZeJbERMcUvRqIg4yNDymweZM53Zbo6dOt1WeFup6vAad4="
+	var mtx sync.Mutex
+	var requests []*testRequest
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests = append(requests, req)
+		mtx.Unlock()
+		if req.Method == "signmessage" {
+			return signature, nil
+		}
+		return true, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// Legacy addresses are sent in cashaddr format.
+	addr, err := bchutil.DecodeAddress(compressedKeyLegacy, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	sig, err := client.SignMessage(context.Background(), addr, onboardingMessage)
+	if err != nil || sig != signature {
+		t.Fatalf("SignMessage = %s, %v", sig, err)
+	}
+	ok, err := client.VerifyMessage(context.Background(), addr, sig, onboardingMessage)
+	if err != nil || !ok {
+		t.Fatalf("VerifyMessage = %v, %v", ok, err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`["` + compressedKeyCashAddr + `","` + onboardingMessage + `"]`,
+		`["` + compressedKeyCashAddr + `","` + signature + `","` + onboardingMessage + `"]`,
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("%d requests, want %d", len(requests), len(want))
+	}
+	for i, req := range requests {
+		if got, _ := json.Marshal(req.Params); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"sync/atomic"
+)
+
+// UnparsableReason describes why a message from the server could not be
+// handled.
+type UnparsableReason int
+
+const (
+	// ReasonInvalidJSON means the message is not a valid JSON-RPC
+	// request or response.
+	ReasonInvalidJSON UnparsableReason = iota
+
+	// ReasonMissingMethod means a notification has no method.
+	ReasonMissingMethod
+
+	// ReasonMissingParams means a notification has no params.
+	ReasonMissingParams
+
+	// ReasonInvalidID means the id of a response is not a non-negative
+	// integer.
+	ReasonInvalidID
+
+	// ReasonMissingResult means a response has neither a result nor an
+	// error.
+	ReasonMissingResult
+)
+
+// reasonStrings maps every UnparsableReason to a human-readable string.
+var reasonStrings = map[UnparsableReason]string{
+	ReasonInvalidJSON:   "invalid JSON",
+	ReasonMissingMethod: "missing method",
+	ReasonMissingParams: "missing params",
+	ReasonInvalidID:     "invalid id",
+	ReasonMissingResult: "missing result and error",
+}
+
+// String returns the UnparsableReason as a human-readable string.
+func (r UnparsableReason) String() string {
+	if s, ok := reasonStrings[r]; ok {
+		return s
+	}
+	return "unknown reason"
+}
+
+// unparsableMessage passes a message which could not be handled to the
+// configured OnUnparsableMessage hook, if any.
+func (c *Client) unparsableMessage(msg []byte, reason UnparsableReason) {
+	if c.config.OnUnparsableMessage != nil {
+		c.config.OnUnparsableMessage(msg, reason)
+	}
+}
+
+// orphanResponse counts a response which does not belong to any pending
+// request and passes it to the configured OnOrphanResponse hook, if any.
+func (c *Client) orphanResponse(msg []byte, id uint64) {
+	atomic.AddUint64(&c.orphanResponses, 1)
+	if c.config.OnOrphanResponse != nil {
+		c.config.OnOrphanResponse(msg, id)
+	}
+}
@@ -0,0 +1,106 @@
+package bch_rpc
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUnparsableMessageHook(t *testing.T) {
+	var mtx sync.Mutex
+	var reasons []UnparsableReason
+	var msgs []string
+	var orphans []uint64
+	client, err := New(&ConnConfig{
+		Host:         "127.0.0.1:1",
+		HTTPPostMode: true,
+		OnUnparsableMessage: func(msg []byte, reason UnparsableReason) {
+			mtx.Lock()
+			msgs = append(msgs, string(msg))
+			reasons = append(reasons, reason)
+			mtx.Unlock()
+		},
+		OnOrphanResponse: func(msg []byte, id uint64) {
+			mtx.Lock()
+			orphans = append(orphans, id)
+			mtx.Unlock()
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	tests := []struct {
+		msg  string
+		want UnparsableReason
+	}{
+		{`{`, ReasonInvalidJSON},
+		{`[1,2,3]`, ReasonInvalidJSON},
+		{`{"id":null,"params":[]}`, ReasonMissingMethod},
+		{`{"params":[]}`, ReasonMissingMethod},
+		{`{"id":null,"method":"blockconnected"}`, ReasonMissingParams},
+		{`{"id":1.5,"result":1}`, ReasonInvalidID},
+		{`{"id":-1,"result":1}`, ReasonInvalidID},
+		{`{"id":"abc","result":1}`, ReasonInvalidID},
+		{`{"id":true,"result":1}`, ReasonInvalidID},
+		{`{"id":18446744073709551616,"result":1}`, ReasonInvalidID},
+	}
+	for i, test := range tests {
+		client.handleMessage([]byte(test.msg))
+
+		mtx.Lock()
+		if len(reasons) != i+1 || reasons[i] != test.want ||
+			msgs[i] != test.msg {
+
+			t.Fatalf("message %s: hook got %v, want %v", test.msg,
+				reasons, test.want)
+		}
+		mtx.Unlock()
+	}
+
+	// Responses to unknown ids are passed to the orphan hook and counted.
+	client.handleMessage([]byte(`{"id":99,"result":1,"error":null}`))
+	client.handleMessage([]byte(`{"id":100,"result":null,"error":null}`))
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(orphans) != 2 || orphans[0] != 99 || orphans[1] != 100 {
+		t.Fatalf("orphan hook got ids %v, want [99 100]", orphans)
+	}
+	if len(reasons) != len(tests) {
+		t.Fatalf("orphan responses passed to the unparsable hook: %v",
+			reasons)
+	}
+	if n := client.Stats().OrphanResponses; n != 2 {
+		t.Fatalf("%d orphan responses counted, want 2", n)
+	}
+}
+
+func TestUnparsableMessageNoHook(t *testing.T) {
+	// Without hooks broken messages are only logged, and orphan responses
+	// are still counted.
+	client, err := New(&ConnConfig{
+		Host:         "127.0.0.1:1",
+		HTTPPostMode: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	client.handleMessage([]byte(`{`))
+	client.handleMessage([]byte(`{"id":7,"result":1,"error":null}`))
+	if n := client.Stats().OrphanResponses; n != 1 {
+		t.Fatalf("%d orphan responses counted, want 1", n)
+	}
+}
+
+func TestUnparsableReasonString(t *testing.T) {
+	for reason := ReasonInvalidJSON; reason <= ReasonMissingResult; reason++ {
+		if reason.String() == "unknown reason" {
+			t.Errorf("reason %d has no string", reason)
+		}
+	}
+	if s := UnparsableReason(100).String(); s != "unknown reason" {
+		t.Errorf("unexpected string %q for an unknown reason", s)
+	}
+}
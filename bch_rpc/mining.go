@@ -0,0 +1,262 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchutil"
+)
+
+var (
+	// ErrBlockDuplicate is returned by SubmitBlock when the node already
+	// knows the submitted block.
+	ErrBlockDuplicate = errors.New("block already known")
+
+	// ErrBlockInconclusive is returned by SubmitBlock when the node
+	// accepted the submitted block but it is not on the best chain, so its
+	// validity could not be fully established.
+	ErrBlockInconclusive = errors.New("block accepted but not on the " +
+		"best chain")
+)
+
+// ErrBlockRejected describes the condition where the node rejected a block
+// submitted with SubmitBlock for a reason other than ErrBlockDuplicate or
+// ErrBlockInconclusive.
+type ErrBlockRejected struct {
+	// Reason is the reject reason reported by the node, such as
+	// "high-hash" or "bad-txnmrklroot".
+	Reason string
+}
+
+// Error satisfies the error interface.
+func (e *ErrBlockRejected) Error() string {
+	return fmt.Sprintf("block rejected: %s", e.Reason)
+}
+
+// FutureGetBlockTemplateResult is a future promise to deliver the result of a
+// GetBlockTemplateAsync RPC invocation (or an applicable error).
+type FutureGetBlockTemplateResult chan *response
+
+// Receive waits for the response promised by the future and returns a block
+// template to mine on.
+func (r FutureGetBlockTemplateResult) Receive() (*btcjson.GetBlockTemplateResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getblocktemplate result object.
+	var template btcjson.GetBlockTemplateResult
+	err = json.Unmarshal(res, &template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+// GetBlockTemplateAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetBlockTemplate for the blocking version and more details.
+func (c *Client) GetBlockTemplateAsync(ctx context.Context, req *btcjson.TemplateRequest) FutureGetBlockTemplateResult {
+	cmd := btcjson.NewGetBlockTemplateCmd(req)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetBlockTemplate returns a block template to mine on, shaped by the passed
+// template request, which may be nil for the default template.
+func (c *Client) GetBlockTemplate(ctx context.Context, req *btcjson.TemplateRequest) (*btcjson.GetBlockTemplateResult, error) {
+	return c.GetBlockTemplateAsync(ctx, req).Receive()
+}
+
+// FutureSubmitBlockResult is a future promise to deliver the result of a
+// SubmitBlockAsync RPC invocation (or an applicable error).
+type FutureSubmitBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when submitting the block.
+func (r FutureSubmitBlockResult) Receive() error {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return err
+	}
+
+	// Nodes reply with null for accepted blocks and with the reject reason
+	// otherwise.
+	if string(res) == "null" {
+		return nil
+	}
+	var reason string
+	err = json.Unmarshal(res, &reason)
+	if err != nil {
+		return err
+	}
+
+	switch reason {
+	case "":
+		return nil
+	case "duplicate":
+		return ErrBlockDuplicate
+	case "inconclusive":
+		return ErrBlockInconclusive
+	}
+	return &ErrBlockRejected{Reason: reason}
+}
+
+// SubmitBlockAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SubmitBlock for the blocking version and more details.
+func (c *Client) SubmitBlockAsync(ctx context.Context, block *bchutil.Block, opts *btcjson.SubmitBlockOptions) FutureSubmitBlockResult {
+	blockHex := ""
+	if block != nil {
+		blockBytes, err := block.Bytes()
+		if err != nil {
+			return newFutureError(err)
+		}
+
+		blockHex = hex.EncodeToString(blockBytes)
+	}
+
+	cmd := btcjson.NewSubmitBlockCmd(blockHex, opts)
+	return c.sendCmd(ctx, cmd)
+}
+
+// SubmitBlock attempts to submit a new block into the network.  Blocks the
+// node does not accept fail with ErrBlockDuplicate, ErrBlockInconclusive or an
+// ErrBlockRejected carrying the reject reason.
+func (c *Client) SubmitBlock(ctx context.Context, block *bchutil.Block, opts *btcjson.SubmitBlockOptions) error {
+	return c.SubmitBlockAsync(ctx, block, opts).Receive()
+}
+
+// FutureGetMiningInfoResult is a future promise to deliver the result of a
+// GetMiningInfoAsync RPC invocation (or an applicable error).
+type FutureGetMiningInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the mining
+// information.
+func (r FutureGetMiningInfoResult) Receive() (*btcjson.GetMiningInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getmininginfo result object.
+	var infoResult btcjson.GetMiningInfoResult
+	err = json.Unmarshal(res, &infoResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &infoResult, nil
+}
+
+// GetMiningInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetMiningInfo for the blocking version and more details.
+func (c *Client) GetMiningInfoAsync(ctx context.Context) FutureGetMiningInfoResult {
+	cmd := btcjson.NewGetMiningInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetMiningInfo returns mining information.
+func (c *Client) GetMiningInfo(ctx context.Context) (*btcjson.GetMiningInfoResult, error) {
+	return c.GetMiningInfoAsync(ctx).Receive()
+}
+
+// FutureGetNetworkHashPS is a future promise to deliver the result of a
+// GetNetworkHashPSAsync RPC invocation (or an applicable error).
+type FutureGetNetworkHashPS chan *response
+
+// Receive waits for the response promised by the future and returns the
+// estimated network hashes per second for the block heights provided by the
+// parameters.
+func (r FutureGetNetworkHashPS) Receive() (float64, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	// Unmarshal result as a float64, which BCHN reports and which also
+	// holds the integers of bchd.
+	var result float64
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// GetNetworkHashPSAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetNetworkHashPS for the blocking version and more details.
+func (c *Client) GetNetworkHashPSAsync(ctx context.Context) FutureGetNetworkHashPS {
+	cmd := btcjson.NewGetNetworkHashPSCmd(nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetNetworkHashPS returns the estimated network hashes per second using the
+// default number of blocks and the most recent block height.
+//
+// See GetNetworkHashPS2 to override the number of blocks to use and
+// GetNetworkHashPS3 to override the height at which to calculate the estimate.
+func (c *Client) GetNetworkHashPS(ctx context.Context) (float64, error) {
+	return c.GetNetworkHashPSAsync(ctx).Receive()
+}
+
+// GetNetworkHashPS2Async returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetNetworkHashPS2 for the blocking version and more details.
+func (c *Client) GetNetworkHashPS2Async(ctx context.Context, blocks int) FutureGetNetworkHashPS {
+	cmd := btcjson.NewGetNetworkHashPSCmd(&blocks, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetNetworkHashPS2 returns the estimated network hashes per second for the
+// specified previous number of blocks working backwards from the most recent
+// block height.  The blocks parameter can also be -1 in which case the number
+// of blocks since the last difficulty change will be used.
+//
+// See GetNetworkHashPS to use defaults and GetNetworkHashPS3 to override the
+// height at which to calculate the estimate.
+func (c *Client) GetNetworkHashPS2(ctx context.Context, blocks int) (float64, error) {
+	return c.GetNetworkHashPS2Async(ctx, blocks).Receive()
+}
+
+// GetNetworkHashPS3Async returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetNetworkHashPS3 for the blocking version and more details.
+func (c *Client) GetNetworkHashPS3Async(ctx context.Context, blocks, height int) FutureGetNetworkHashPS {
+	cmd := btcjson.NewGetNetworkHashPSCmd(&blocks, &height)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetNetworkHashPS3 returns the estimated network hashes per second for the
+// specified previous number of blocks working backwards from the specified
+// block height.  The blocks parameter can also be -1 in which case the number
+// of blocks since the last difficulty change will be used.
+//
+// See GetNetworkHashPS and GetNetworkHashPS2 to use defaults.
+func (c *Client) GetNetworkHashPS3(ctx context.Context, blocks, height int) (float64, error) {
+	return c.GetNetworkHashPS3Async(ctx, blocks, height).Receive()
+}
@@ -0,0 +1,180 @@
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+func TestSubmitBlock(t *testing.T) {
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	genesisHash := chaincfg.RegressionNetParams.GenesisHash
+
+	var (
+		mu     sync.Mutex
+		reason interface{}
+	)
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "submitblock" {
+			t.Errorf("unexpected method %s", req.Method)
+			return nil, nil
+		}
+
+		var blockHex string
+		if err := json.Unmarshal(req.Params[0], &blockHex); err != nil {
+			t.Errorf("block param: %v", err)
+			return nil, nil
+		}
+		serialized, err := hex.DecodeString(blockHex)
+		if err != nil {
+			t.Errorf("block hex: %v", err)
+			return nil, nil
+		}
+		var block wire.MsgBlock
+		if err := block.Deserialize(bytes.NewReader(serialized)); err != nil {
+			t.Errorf("block deserialize: %v", err)
+			return nil, nil
+		}
+		if block.BlockHash() != *genesisHash ||
+			len(block.Transactions) != len(genesis.Transactions) {
+
+			t.Errorf("unexpected block %v with %d transactions",
+				block.BlockHash(), len(block.Transactions))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return reason, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	block := bchutil.NewBlock(genesis)
+	if err := client.SubmitBlock(context.Background(), block, nil); err != nil {
+		t.Fatalf("SubmitBlock: %v", err)
+	}
+
+	tests := []struct {
+		reason string
+		want   error
+	}{
+		{"duplicate", ErrBlockDuplicate},
+		{"inconclusive", ErrBlockInconclusive},
+	}
+	for _, test := range tests {
+		mu.Lock()
+		reason = test.reason
+		mu.Unlock()
+
+		err := client.SubmitBlock(context.Background(), block, nil)
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got error %v, want %v", test.reason, err, test.want)
+		}
+	}
+
+	mu.Lock()
+	reason = "high-hash"
+	mu.Unlock()
+	err := client.SubmitBlock(context.Background(), block, nil)
+	var rejected *ErrBlockRejected
+	if !errors.As(err, &rejected) || rejected.Reason != "high-hash" {
+		t.Fatalf("got error %v, want rejection for high-hash", err)
+	}
+}
+
+func TestGetBlockTemplate(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getblocktemplate" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		if got, _ := json.Marshal(req.Params); string(got) !=
+			`[{"mode":"template","capabilities":["coinbasetxn"]}]` {
+
+			t.Errorf("unexpected params %s", got)
+		}
+		return json.RawMessage(`{
+			"version": 536870912,
+			"previousblockhash": "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206",
+			"transactions": [],
+			"coinbasevalue": 5000000000,
+			"target": "7fffff0000000000000000000000000000000000000000000000000000000000",
+			"mintime": 1296688603,
+			"curtime": 1600000000,
+			"bits": "207fffff",
+			"height": 1
+		}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	template, err := client.GetBlockTemplate(context.Background(),
+		&btcjson.TemplateRequest{
+			Mode:         "template",
+			Capabilities: []string{"coinbasetxn"},
+		})
+	if err != nil {
+		t.Fatalf("GetBlockTemplate: %v", err)
+	}
+	if template.Height != 1 || template.Bits != "207fffff" ||
+		*template.CoinbaseValue != 5000000000 {
+
+		t.Fatalf("unexpected template %+v", template)
+	}
+}
+
+func TestGetMiningInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		switch req.Method {
+		case "getmininginfo":
+			return json.RawMessage(`{
+				"blocks": 650000,
+				"currentblocksize": 0,
+				"currentblocktx": 0,
+				"difficulty": 225832872179.4512,
+				"networkhashps": 1.6174622011839e+18,
+				"pooledtx": 12,
+				"chain": "main",
+				"warnings": ""
+			}`), nil
+		case "getnetworkhashps":
+			if got, _ := json.Marshal(req.Params); string(got) != `[120,649000]` {
+				t.Errorf("unexpected params %s", got)
+			}
+			return json.RawMessage(`1.6174622011839e+18`), nil
+		}
+		t.Errorf("unexpected method %s", req.Method)
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetMiningInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetMiningInfo: %v", err)
+	}
+	if info.Blocks != 650000 || info.PooledTx != 12 ||
+		info.NetworkHashPS != 1617462201183900000 {
+
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	hashPS, err := client.GetNetworkHashPS3(context.Background(), 120, 649000)
+	if err != nil {
+		t.Fatalf("GetNetworkHashPS3: %v", err)
+	}
+	if hashPS != 1.6174622011839e+18 {
+		t.Fatalf("got %v hashes per second", hashPS)
+	}
+}
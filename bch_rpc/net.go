@@ -0,0 +1,195 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+// NetworkInfoNetwork describes a network type, such as ipv4 or onion, in the
+// result of the getnetworkinfo command.
+type NetworkInfoNetwork struct {
+	Name                      string `json:"name"`
+	Limited                   bool   `json:"limited"`
+	Reachable                 bool   `json:"reachable"`
+	Proxy                     string `json:"proxy"`
+	ProxyRandomizeCredentials bool   `json:"proxy_randomize_credentials"`
+}
+
+// NetworkInfoLocalAddress describes an address the node listens on in the
+// result of the getnetworkinfo command.
+type NetworkInfoLocalAddress struct {
+	Address string `json:"address"`
+	Port    uint16 `json:"port"`
+	Score   int32  `json:"score"`
+}
+
+// GetNetworkInfoResult models the data returned from the getnetworkinfo
+// command.
+type GetNetworkInfoResult struct {
+	Version         int32                     `json:"version"`
+	SubVersion      string                    `json:"subversion"`
+	ProtocolVersion int32                     `json:"protocolversion"`
+	LocalServices   string                    `json:"localservices"`
+	LocalRelay      bool                      `json:"localrelay"`
+	TimeOffset      int64                     `json:"timeoffset"`
+	NetworkActive   bool                      `json:"networkactive"`
+	Connections     int32                     `json:"connections"`
+	Networks        []NetworkInfoNetwork      `json:"networks"`
+	RelayFee        float64                   `json:"relayfee"`
+	LocalAddresses  []NetworkInfoLocalAddress `json:"localaddresses"`
+	Warnings        string                    `json:"warnings"`
+}
+
+// FutureGetNetworkInfoResult is a future promise to deliver the result of a
+// GetNetworkInfoAsync RPC invocation (or an applicable error).
+type FutureGetNetworkInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns data about
+// the current network.
+func (r FutureGetNetworkInfoResult) Receive() (*GetNetworkInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getnetworkinfo result object.
+	var networkInfo GetNetworkInfoResult
+	err = json.Unmarshal(res, &networkInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &networkInfo, nil
+}
+
+// GetNetworkInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetNetworkInfo for the blocking version and more details.
+func (c *Client) GetNetworkInfoAsync(ctx context.Context) FutureGetNetworkInfoResult {
+	cmd := btcjson.NewGetNetworkInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetNetworkInfo returns data about the current network, such as the version
+// of the node, its connections and the minimum relay fee in BCH per kilobyte.
+func (c *Client) GetNetworkInfo(ctx context.Context) (*GetNetworkInfoResult, error) {
+	return c.GetNetworkInfoAsync(ctx).Receive()
+}
+
+// FutureGetPeerInfoResult is a future promise to deliver the result of a
+// GetPeerInfoAsync RPC invocation (or an applicable error).
+type FutureGetPeerInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns data about
+// each connected network peer.
+func (r FutureGetPeerInfoResult) Receive() ([]btcjson.GetPeerInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getpeerinfo result objects.
+	var peerInfo []btcjson.GetPeerInfoResult
+	err = json.Unmarshal(res, &peerInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return peerInfo, nil
+}
+
+// GetPeerInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetPeerInfo for the blocking version and more details.
+func (c *Client) GetPeerInfoAsync(ctx context.Context) FutureGetPeerInfoResult {
+	cmd := btcjson.NewGetPeerInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetPeerInfo returns data about each connected network peer.
+func (c *Client) GetPeerInfo(ctx context.Context) ([]btcjson.GetPeerInfoResult, error) {
+	return c.GetPeerInfoAsync(ctx).Receive()
+}
+
+// FutureGetConnectionCountResult is a future promise to deliver the result
+// of a GetConnectionCountAsync RPC invocation (or an applicable error).
+type FutureGetConnectionCountResult chan *response
+
+// Receive waits for the response promised by the future and returns the number
+// of active connections to other peers.
+func (r FutureGetConnectionCountResult) Receive() (int64, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	// Unmarshal result as an int64.
+	var count int64
+	err = json.Unmarshal(res, &count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetConnectionCountAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetConnectionCount for the blocking version and more details.
+func (c *Client) GetConnectionCountAsync(ctx context.Context) FutureGetConnectionCountResult {
+	cmd := btcjson.NewGetConnectionCountCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetConnectionCount returns the number of active connections to other peers.
+func (c *Client) GetConnectionCount(ctx context.Context) (int64, error) {
+	return c.GetConnectionCountAsync(ctx).Receive()
+}
+
+// FutureUptimeResult is a future promise to deliver the result of an
+// UptimeAsync RPC invocation (or an applicable error).
+type FutureUptimeResult chan *response
+
+// Receive waits for the response promised by the future and returns the number
+// of seconds the server has been running.
+func (r FutureUptimeResult) Receive() (int64, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	// Unmarshal result as an int64.
+	var uptime int64
+	err = json.Unmarshal(res, &uptime)
+	if err != nil {
+		return 0, err
+	}
+
+	return uptime, nil
+}
+
+// UptimeAsync returns an instance of a type that can be used to get the result
+// of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See Uptime for the blocking version and more details.
+func (c *Client) UptimeAsync(ctx context.Context) FutureUptimeResult {
+	return FutureUptimeResult(c.RawRequestAsync(ctx, "uptime", nil))
+}
+
+// Uptime returns the number of seconds the server has been running.
+func (c *Client) Uptime(ctx context.Context) (int64, error) {
+	return c.UptimeAsync(ctx).Receive()
+}
@@ -0,0 +1,120 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+func TestGetNetworkInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getnetworkinfo" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		return json.RawMessage(`{
+			"version": 22000000,
+			"subversion": "/Bitcoin Cash Node:22.0.0(EB32.0)/",
+			"protocolversion": 70015,
+			"localservices": "0000000000000425",
+			"localrelay": true,
+			"timeoffset": 0,
+			"networkactive": true,
+			"connections": 8,
+			"networks": [
+				{"name": "ipv4", "limited": false, "reachable": true,
+					"proxy": "", "proxy_randomize_credentials": false},
+				{"name": "onion", "limited": true, "reachable": false,
+					"proxy": "", "proxy_randomize_credentials": false}
+			],
+			"relayfee": 0.00001,
+			"excessutxocharge": 0,
+			"localaddresses": [],
+			"warnings": ""
+		}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetNetworkInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetNetworkInfo: %v", err)
+	}
+	if info.Version != 22000000 || info.Connections != 8 ||
+		len(info.Networks) != 2 || !info.Networks[0].Reachable {
+
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if relayFee, err := FeeRatePerByte(info.RelayFee); err != nil || relayFee != 1 {
+		t.Fatalf("relay fee %v, %v", relayFee, err)
+	}
+}
+
+func TestGetPeerInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		switch req.Method {
+		case "getpeerinfo":
+			return json.RawMessage(`[{
+				"id": 3,
+				"addr": "203.0.113.7:8333",
+				"services": "0000000000000425",
+				"relaytxes": true,
+				"lastsend": 1600000000,
+				"lastrecv": 1600000001,
+				"bytessent": 1024,
+				"bytesrecv": 2048,
+				"conntime": 1599990000,
+				"timeoffset": -1,
+				"pingtime": 0.05,
+				"version": 70015,
+				"subver": "/Bitcoin Cash Node:22.0.0(EB32.0)/",
+				"inbound": false,
+				"addnode": false,
+				"startingheight": 650000,
+				"banscore": 0,
+				"synced_headers": 650000,
+				"synced_blocks": 650000
+			}]`), nil
+		case "getconnectioncount":
+			return 1, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	peers, err := client.GetPeerInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetPeerInfo: %v", err)
+	}
+	if len(peers) != 1 || peers[0].ID != 3 || peers[0].Addr != "203.0.113.7:8333" ||
+		peers[0].StartingHeight != 650000 {
+
+		t.Fatalf("unexpected peers %+v", peers)
+	}
+
+	count, err := client.GetConnectionCount(context.Background())
+	if err != nil || count != 1 {
+		t.Fatalf("GetConnectionCount = %d, %v", count, err)
+	}
+}
+
+func TestUptime(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "uptime" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		return 86400, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	uptime, err := client.Uptime(context.Background())
+	if err != nil || uptime != 86400 {
+		t.Fatalf("Uptime = %d, %v", uptime, err)
+	}
+}
@@ -0,0 +1,975 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Copyright (c) 2015-2017 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+var (
+	// ErrWebsocketsRequired is an error to describe the condition where the
+	// caller is trying to use a websocket-only feature, such as requesting
+	// notifications or other websocket requests when the client is
+	// configured to run in HTTP POST mode.
+	ErrWebsocketsRequired = errors.New("a websocket connection is required " +
+		"to use this feature")
+)
+
+// notificationState is used to track the current state of successfully
+// registered notification so the state can be automatically re-established on
+// reconnect.
+type notificationState struct {
+	notifyBlocks       bool
+	notifyNewTx        bool
+	notifyNewTxVerbose bool
+	notifySpent        map[btcjson.OutPoint]struct{}
+
+	// The transaction filter is tracked as the union of everything loaded
+	// since the last reload, so it can be loaded again in one go.
+	txFilterLoaded    bool
+	txFilterAddrs     map[string]struct{}
+	txFilterOutPoints map[btcjson.OutPoint]struct{}
+}
+
+// Copy returns a deep copy of the receiver.
+func (s *notificationState) Copy() *notificationState {
+	stateCopy := *s
+	stateCopy.notifySpent = copyOutPointSet(s.notifySpent)
+	stateCopy.txFilterOutPoints = copyOutPointSet(s.txFilterOutPoints)
+	stateCopy.txFilterAddrs = make(map[string]struct{}, len(s.txFilterAddrs))
+	for addr := range s.txFilterAddrs {
+		stateCopy.txFilterAddrs[addr] = struct{}{}
+	}
+	return &stateCopy
+}
+
+// resetTxFilter forgets the tracked transaction filter contents, as done by a
+// reload of the filter.
+func (s *notificationState) resetTxFilter() {
+	s.txFilterAddrs = make(map[string]struct{})
+	s.txFilterOutPoints = make(map[btcjson.OutPoint]struct{})
+}
+
+// copyOutPointSet returns a copy of the passed outpoint set.
+func copyOutPointSet(set map[btcjson.OutPoint]struct{}) map[btcjson.OutPoint]struct{} {
+	setCopy := make(map[btcjson.OutPoint]struct{}, len(set))
+	for op := range set {
+		setCopy[op] = struct{}{}
+	}
+	return setCopy
+}
+
+// newNotificationState returns a new notification state ready to be populated.
+func newNotificationState() *notificationState {
+	s := &notificationState{
+		notifySpent: make(map[btcjson.OutPoint]struct{}),
+	}
+	s.resetTxFilter()
+	return s
+}
+
+// newNilFutureResult returns a new future result channel that already has the
+// result waiting on the channel with the reply set to nil.  This is useful
+// to ignore things such as notifications when the caller didn't specify any
+// notification handlers.
+func newNilFutureResult() chan *response {
+	responseChan := make(chan *response, 1)
+	responseChan <- &response{result: nil, err: nil}
+	return responseChan
+}
+
+// NotificationHandlers defines callback function pointers to invoke with
+// notifications.  Since all of the functions are nil by default, all
+// notifications are effectively ignored until their handlers are set to a
+// concrete callback.
+//
+// The handlers are invoked one at a time, in the order the notifications were
+// received, from a goroutine dedicated to notification delivery.  A slow
+// handler therefore delays later notifications but never the responses to
+// outstanding requests, so handlers may safely issue blocking calls on the
+// client.
+type NotificationHandlers struct {
+	// OnClientConnected is invoked when the client connects or reconnects
+	// to the RPC server in websocket mode.  It is delivered along with, and
+	// just before, the ConnConfig OnClientConnected callback, async with the
+	// rest of the notification handlers, and is safe for blocking client
+	// requests.
+	OnClientConnected func()
+
+	// OnBlockConnected is invoked when a block is connected to the longest
+	// (best) chain.  It will only be invoked if a preceding call to
+	// NotifyBlocks has been made to register for the notification and the
+	// function is non-nil.
+	OnBlockConnected func(hash *chainhash.Hash, height int32, t time.Time)
+
+	// OnBlockDisconnected is invoked when a block is disconnected from the
+	// longest (best) chain.  It will only be invoked if a preceding call to
+	// NotifyBlocks has been made to register for the notification and the
+	// function is non-nil.
+	OnBlockDisconnected func(hash *chainhash.Hash, height int32, t time.Time)
+
+	// OnFilteredBlockConnected is invoked when a block is connected to the
+	// longest (best) chain.  It will only be invoked if a preceding call
+	// to NotifyBlocks has been made to register for the notification and
+	// the function is non-nil.  Its parameters differ from
+	// OnBlockConnected: it receives the block's height, header, and
+	// relevant transactions, which are those passing the client's
+	// transaction filter loaded with LoadTxFilter.
+	OnFilteredBlockConnected func(height int32, header *wire.BlockHeader,
+		txs []*bchutil.Tx)
+
+	// OnFilteredBlockDisconnected is invoked when a block is disconnected
+	// from the longest (best) chain.  It will only be invoked if a
+	// preceding NotifyBlocks has been made to register for the
+	// notification and the function is non-nil.  Its parameters differ
+	// from OnBlockDisconnected: it receives the block's height and header.
+	OnFilteredBlockDisconnected func(height int32, header *wire.BlockHeader)
+
+	// OnRelevantTxAccepted is invoked when an unmined transaction passes
+	// the client's transaction filter.  The transaction is passed in its
+	// serialized form.
+	//
+	// NOTE: This is a bchd extension ported from
+	// github.com/decred/dcrrpcclient.
+	OnRelevantTxAccepted func(transaction []byte)
+
+	// OnRedeemingTx is invoked when a transaction that spends a registered
+	// outpoint is received into the memory pool and also connected to the
+	// longest (best) chain.  It will only be invoked if a preceding call
+	// to NotifySpent has been made to register for the notification and
+	// the function is non-nil.  The details are nil for transactions which
+	// are not mined yet.
+	OnRedeemingTx func(transaction *bchutil.Tx, details *btcjson.BlockDetails)
+
+	// OnTxAccepted is invoked when a transaction is accepted into the
+	// memory pool.  It will only be invoked if a preceding call to
+	// NotifyNewTransactions with the verbose flag set to false has been
+	// made to register for the notification and the function is non-nil.
+	OnTxAccepted func(hash *chainhash.Hash, amount bchutil.Amount)
+
+	// OnTxAcceptedVerbose is invoked when a transaction is accepted into
+	// the memory pool.  It will only be invoked if a preceding call to
+	// NotifyNewTransactions with the verbose flag set to true has been
+	// made to register for the notification and the function is non-nil.
+	OnTxAcceptedVerbose func(txDetails *btcjson.TxRawResult)
+
+	// OnUnknownNotification is invoked when an unrecognized notification
+	// is received.  This typically means the notification handling code
+	// for this package needs to be updated for a new notification type or
+	// the caller is using a custom notification this package does not know
+	// about.
+	OnUnknownNotification func(method string, params []json.RawMessage)
+}
+
+// queueNotification hands the passed notification to the notification
+// handler goroutine.  Notifications are dropped when the client has no
+// notification handlers.
+func (c *Client) queueNotification(ntfn *rawNotification) {
+	if c.ntfnHandlers == nil {
+		return
+	}
+
+	select {
+	case c.ntfnQueue <- ntfn:
+	case <-c.shutdown:
+	}
+}
+
+// startNtfnHandlers starts the goroutines which deliver queued notifications
+// to the notification handlers.
+func (c *Client) startNtfnHandlers() {
+	ntfns := make(chan *rawNotification)
+	c.wg.Add(2)
+	go c.ntfnQueueHandler(ntfns)
+	go c.ntfnHandler(ntfns)
+}
+
+// ntfnQueueHandler buffers notifications read from the websocket connection
+// in an unbounded queue and forwards them to the passed channel in the order
+// they were received.  This keeps the input handler from ever blocking on a
+// slow notification handler.  It must be run as a goroutine.
+func (c *Client) ntfnQueueHandler(out chan<- *rawNotification) {
+	pending := list.New()
+out:
+	for {
+		// Only offer the next notification when one is pending.
+		var next *rawNotification
+		var send chan<- *rawNotification
+		if e := pending.Front(); e != nil {
+			next = e.Value.(*rawNotification)
+			send = out
+		}
+
+		select {
+		case ntfn := <-c.ntfnQueue:
+			pending.PushBack(ntfn)
+
+		case send <- next:
+			pending.Remove(pending.Front())
+
+		case <-c.shutdown:
+			break out
+		}
+	}
+	c.wg.Done()
+}
+
+// ntfnHandler delivers notifications received on the passed channel to the
+// notification handlers associated with the client.  It must be run as a
+// goroutine.
+func (c *Client) ntfnHandler(in <-chan *rawNotification) {
+out:
+	for {
+		select {
+		case ntfn := <-in:
+			c.handleNotification(ntfn)
+
+		case <-c.shutdown:
+			break out
+		}
+	}
+	c.wg.Done()
+}
+
+// handleNotification examines the passed notification type, performs
+// conversions to get the raw notification types into higher level types and
+// delivers the notification to the appropriate On<X> handler registered with
+// the client.
+func (c *Client) handleNotification(ntfn *rawNotification) {
+	// Ignore the notification if the client is not interested in any
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return
+	}
+
+	switch ntfn.Method {
+	// OnBlockConnected
+	case btcjson.BlockConnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnBlockConnected == nil {
+			return
+		}
+
+		blockHash, blockHeight, blockTime, err := parseChainNtfnParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid block connected "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnBlockConnected(blockHash, blockHeight, blockTime)
+
+	// OnBlockDisconnected
+	case btcjson.BlockDisconnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnBlockDisconnected == nil {
+			return
+		}
+
+		blockHash, blockHeight, blockTime, err := parseChainNtfnParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid block disconnected "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnBlockDisconnected(blockHash, blockHeight, blockTime)
+
+	// OnFilteredBlockConnected
+	case btcjson.FilteredBlockConnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnFilteredBlockConnected == nil {
+			return
+		}
+
+		blockHeight, blockHeader, transactions, err :=
+			parseFilteredBlockConnectedParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid filtered block "+
+				"connected notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnFilteredBlockConnected(blockHeight,
+			blockHeader, transactions)
+
+	// OnFilteredBlockDisconnected
+	case btcjson.FilteredBlockDisconnectedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnFilteredBlockDisconnected == nil {
+			return
+		}
+
+		blockHeight, blockHeader, err :=
+			parseFilteredBlockDisconnectedParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid filtered block "+
+				"disconnected notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnFilteredBlockDisconnected(blockHeight,
+			blockHeader)
+
+	// OnRedeemingTx
+	case btcjson.RedeemingTxNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnRedeemingTx == nil {
+			return
+		}
+
+		tx, block, err := parseRedeemingTxNtfnParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid redeemingtx "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnRedeemingTx(tx, block)
+
+	// OnRelevantTxAccepted
+	case btcjson.RelevantTxAcceptedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnRelevantTxAccepted == nil {
+			return
+		}
+
+		transaction, err := parseRelevantTxAcceptedParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid relevanttxaccepted "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnRelevantTxAccepted(transaction)
+
+	// OnTxAccepted
+	case btcjson.TxAcceptedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxAccepted == nil {
+			return
+		}
+
+		hash, amt, err := parseTxAcceptedNtfnParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid tx accepted "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxAccepted(hash, amt)
+
+	// OnTxAcceptedVerbose
+	case btcjson.TxAcceptedVerboseNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxAcceptedVerbose == nil {
+			return
+		}
+
+		rawTx, err := parseTxAcceptedVerboseNtfnParams(ntfn.Params)
+		if err != nil {
+			c.log.Warnf("Received invalid tx accepted verbose "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnTxAcceptedVerbose(rawTx)
+
+	// OnUnknownNotification
+	default:
+		if c.ntfnHandlers.OnUnknownNotification == nil {
+			c.log.Warnf("Received unknown notification [%s]",
+				ntfn.Method)
+			return
+		}
+
+		c.ntfnHandlers.OnUnknownNotification(ntfn.Method, ntfn.Params)
+	}
+}
+
+// wrongNumParams is an error type describing an unparseable JSON-RPC
+// notificiation due to an incorrect number of parameters for the
+// expected notification type.  The value is the number of parameters
+// of the invalid notification.
+type wrongNumParams int
+
+// Error satisifies the builtin error interface.
+func (e wrongNumParams) Error() string {
+	return fmt.Sprintf("wrong number of parameters (%d)", e)
+}
+
+// parseChainNtfnParams parses out the block hash and height from the parameters
+// of blockconnected and blockdisconnected notifications.
+func parseChainNtfnParams(params []json.RawMessage) (*chainhash.Hash,
+	int32, time.Time, error) {
+
+	if len(params) != 3 {
+		return nil, 0, time.Time{}, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var blockHashStr string
+	err := json.Unmarshal(params[0], &blockHashStr)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	// Unmarshal second parameter as an integer.
+	var blockHeight int32
+	err = json.Unmarshal(params[1], &blockHeight)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	// Unmarshal third parameter as unix time.
+	var blockTimeUnix int64
+	err = json.Unmarshal(params[2], &blockTimeUnix)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	// Create hash from block hash string.
+	blockHash, err := chainhash.NewHashFromStr(blockHashStr)
+	if err != nil {
+		return nil, 0, time.Time{}, err
+	}
+
+	// Create time.Time from unix time.
+	blockTime := time.Unix(blockTimeUnix, 0)
+
+	return blockHash, blockHeight, blockTime, nil
+}
+
+// parseHexParam unmarshals the passed parameter as a hex-encoded string and
+// returns the decoded bytes.
+func parseHexParam(param json.RawMessage) ([]byte, error) {
+	var s string
+	err := json.Unmarshal(param, &s)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(s)
+}
+
+// parseFilteredBlockConnectedParams parses out the parameters included in a
+// filteredblockconnected notification.
+func parseFilteredBlockConnectedParams(params []json.RawMessage) (int32,
+	*wire.BlockHeader, []*bchutil.Tx, error) {
+
+	if len(params) < 3 {
+		return 0, nil, nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as an integer.
+	var blockHeight int32
+	err := json.Unmarshal(params[0], &blockHeight)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Unmarshal second parameter as a slice of bytes.
+	blockHeaderBytes, err := parseHexParam(params[1])
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Deserialize block header from slice of bytes.
+	var blockHeader wire.BlockHeader
+	err = blockHeader.Deserialize(bytes.NewReader(blockHeaderBytes))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Unmarshal third parameter as a slice of hex-encoded strings.
+	var hexTransactions []string
+	err = json.Unmarshal(params[2], &hexTransactions)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	// Create slice of transactions from slice of strings by hex-decoding.
+	transactions := make([]*bchutil.Tx, len(hexTransactions))
+	for i, hexTx := range hexTransactions {
+		transaction, err := hex.DecodeString(hexTx)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+
+		transactions[i], err = bchutil.NewTxFromBytes(transaction)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	return blockHeight, &blockHeader, transactions, nil
+}
+
+// parseFilteredBlockDisconnectedParams parses out the parameters included in
+// a filteredblockdisconnected notification.
+func parseFilteredBlockDisconnectedParams(params []json.RawMessage) (int32,
+	*wire.BlockHeader, error) {
+
+	if len(params) < 2 {
+		return 0, nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as an integer.
+	var blockHeight int32
+	err := json.Unmarshal(params[0], &blockHeight)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Unmarshal second parameter as a slice of bytes.
+	blockHeaderBytes, err := parseHexParam(params[1])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Deserialize block header from slice of bytes.
+	var blockHeader wire.BlockHeader
+	err = blockHeader.Deserialize(bytes.NewReader(blockHeaderBytes))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return blockHeight, &blockHeader, nil
+}
+
+// parseRedeemingTxNtfnParams parses out the transaction and optional details
+// about the block it's mined in from the parameters of a redeemingtx
+// notification.
+func parseRedeemingTxNtfnParams(params []json.RawMessage) (*bchutil.Tx,
+	*btcjson.BlockDetails, error) {
+
+	if len(params) == 0 || len(params) > 2 {
+		return nil, nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string and decode the transaction.
+	serializedTx, err := parseHexParam(params[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := bchutil.NewTxFromBytes(serializedTx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If present, unmarshal second optional parameter as the block details
+	// JSON object.
+	var block *btcjson.BlockDetails
+	if len(params) > 1 {
+		err = json.Unmarshal(params[1], &block)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return tx, block, nil
+}
+
+// parseRelevantTxAcceptedParams parses out the parameter included in a
+// relevanttxaccepted notification.
+func parseRelevantTxAcceptedParams(params []json.RawMessage) (transaction []byte, err error) {
+	if len(params) < 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	return parseHexParam(params[0])
+}
+
+// parseTxAcceptedNtfnParams parses out the transaction hash and total amount
+// from the parameters of a txaccepted notification.
+func parseTxAcceptedNtfnParams(params []json.RawMessage) (*chainhash.Hash,
+	bchutil.Amount, error) {
+
+	if len(params) != 2 {
+		return nil, 0, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a string.
+	var txHashStr string
+	err := json.Unmarshal(params[0], &txHashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Unmarshal second parameter as a floating point number.
+	var famt float64
+	err = json.Unmarshal(params[1], &famt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Bounds check amount.
+	amt, err := bchutil.NewAmount(famt)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Decode string encoding of transaction sha.
+	txHash, err := chainhash.NewHashFromStr(txHashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return txHash, amt, nil
+}
+
+// parseTxAcceptedVerboseNtfnParams parses out details about a raw transaction
+// from the parameters of a txacceptedverbose notification.
+func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*btcjson.TxRawResult,
+	error) {
+
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+
+	// Unmarshal first parameter as a raw transaction result object.
+	var rawTx btcjson.TxRawResult
+	err := json.Unmarshal(params[0], &rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawTx, nil
+}
+
+// reregisterNtfns creates and sends commands needed to re-establish the current
+// notification state associated with the client.  It should only be called on
+// on reconnect by the resendRequests function.
+func (c *Client) reregisterNtfns(ctx context.Context) error {
+	// Nothing to do if the caller is not interested in notifications.
+	if c.ntfnHandlers == nil {
+		return nil
+	}
+
+	// In order to avoid holding the lock on the notification state for the
+	// entire time of the potentially long running RPCs issued below, make a
+	// copy of it and work from that.
+	//
+	// Also, other commands will be running concurrently which could modify
+	// the notification state (while not under the lock of course) which
+	// also register it with the remote RPC server, so this prevents double
+	// registrations.
+	c.ntfnStateLock.Lock()
+	stateCopy := c.ntfnState.Copy()
+	c.ntfnStateLock.Unlock()
+
+	// Reregister notifyblocks if needed.
+	if stateCopy.notifyBlocks {
+		c.log.Debugf("Reregistering [notifyblocks]")
+		if err := c.NotifyBlocks(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Reregister notifynewtransactions if needed.  The verbose and
+	// non-verbose forms are tracked separately since both may have been
+	// requested.
+	if stateCopy.notifyNewTx {
+		c.log.Debugf("Reregistering [notifynewtransactions]")
+		if err := c.NotifyNewTransactions(ctx, false); err != nil {
+			return err
+		}
+	}
+	if stateCopy.notifyNewTxVerbose {
+		c.log.Debugf("Reregistering [notifynewtransactions] (verbose)")
+		if err := c.NotifyNewTransactions(ctx, true); err != nil {
+			return err
+		}
+	}
+
+	// Reregister the combination of all previously registered notifyspent
+	// outpoints in one command if needed.
+	if len(stateCopy.notifySpent) > 0 {
+		outpoints := make([]btcjson.OutPoint, 0, len(stateCopy.notifySpent))
+		for op := range stateCopy.notifySpent {
+			outpoints = append(outpoints, op)
+		}
+		c.log.Debugf("Reregistering [notifyspent] outpoints: %v", outpoints)
+		err := c.notifySpentInternal(ctx, outpoints).Receive()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Reload the transaction filter with everything loaded into it so far,
+	// since the server forgets it along with the connection.
+	if stateCopy.txFilterLoaded {
+		addrs := make([]string, 0, len(stateCopy.txFilterAddrs))
+		for addr := range stateCopy.txFilterAddrs {
+			addrs = append(addrs, addr)
+		}
+		outpoints := make([]btcjson.OutPoint, 0,
+			len(stateCopy.txFilterOutPoints))
+		for op := range stateCopy.txFilterOutPoints {
+			outpoints = append(outpoints, op)
+		}
+		c.log.Debugf("Reloading [loadtxfilter] with %d addresses and "+
+			"%d outpoints", len(addrs), len(outpoints))
+		err := c.loadTxFilterInternal(ctx, true, addrs, outpoints).Receive()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FutureNotifyBlocksResult is a future promise to deliver the result of a
+// NotifyBlocksAsync RPC invocation (or an applicable error).
+type FutureNotifyBlocksResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyBlocksResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyBlocksAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyBlocks for the blocking version and more details.
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifyBlocksAsync(ctx context.Context) FutureNotifyBlocksResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifyBlocksCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// NotifyBlocks registers the client to receive notifications when blocks are
+// connected and disconnected from the main chain.  The notifications are
+// delivered to the notification handlers associated with the client.  Calling
+// this function has no effect if there are no notification handlers and will
+// result in an error if the client is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via one of
+// OnBlockConnected or OnBlockDisconnected.
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifyBlocks(ctx context.Context) error {
+	return c.NotifyBlocksAsync(ctx).Receive()
+}
+
+// FutureNotifyNewTransactionsResult is a future promise to deliver the result
+// of a NotifyNewTransactionsAsync RPC invocation (or an applicable error).
+type FutureNotifyNewTransactionsResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyNewTransactionsResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifyNewTransactionsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See NotifyNewTransactions for the blocking version and more details.
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifyNewTransactionsAsync(ctx context.Context, verbose bool) FutureNotifyNewTransactionsResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifyNewTransactionsCmd(&verbose)
+	return c.sendCmd(ctx, cmd)
+}
+
+// NotifyNewTransactions registers the client to receive notifications every
+// time a new transaction is accepted to the memory pool.  The notifications are
+// delivered to the notification handlers associated with the client.  Calling
+// this function has no effect if there are no notification handlers and will
+// result in an error if the client is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via one of
+// OnTxAccepted (when verbose is false) or OnTxAcceptedVerbose (when verbose is
+// true).
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifyNewTransactions(ctx context.Context, verbose bool) error {
+	return c.NotifyNewTransactionsAsync(ctx, verbose).Receive()
+}
+
+// FutureLoadTxFilterResult is a future promise to deliver the result
+// of a LoadTxFilterAsync RPC invocation (or an applicable error).
+//
+// NOTE: This is a bchd extension ported from github.com/decred/dcrrpcclient
+// and requires a websocket connection.
+type FutureLoadTxFilterResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+//
+// NOTE: This is a bchd extension ported from github.com/decred/dcrrpcclient
+// and requires a websocket connection.
+func (r FutureLoadTxFilterResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// LoadTxFilterAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See LoadTxFilter for the blocking version and more details.
+//
+// NOTE: This is a bchd extension ported from github.com/decred/dcrrpcclient
+// and requires a websocket connection.
+func (c *Client) LoadTxFilterAsync(ctx context.Context, reload bool,
+	addresses []bchutil.Address, outPoints []wire.OutPoint) FutureLoadTxFilterResult {
+
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	addrStrs := make([]string, len(addresses))
+	for i, a := range addresses {
+		addrStrs[i] = a.EncodeAddress()
+	}
+
+	return c.loadTxFilterInternal(ctx, reload, addrStrs,
+		newOutPointsFromWire(outPoints))
+}
+
+// loadTxFilterInternal is the same as LoadTxFilterAsync except it accepts the
+// addresses and outpoints in their JSON form.  This allows reregisterNtfns to
+// reload the filter from the tracked notification state.
+func (c *Client) loadTxFilterInternal(ctx context.Context, reload bool,
+	addresses []string, outPoints []btcjson.OutPoint) FutureLoadTxFilterResult {
+
+	cmd := btcjson.NewLoadTxFilterCmd(reload, addresses, outPoints)
+	return c.sendCmd(ctx, cmd)
+}
+
+// newOutPointsFromWire converts the passed wire outpoints to their JSON form.
+func newOutPointsFromWire(outPoints []wire.OutPoint) []btcjson.OutPoint {
+	outPointObjects := make([]btcjson.OutPoint, len(outPoints))
+	for i := range outPoints {
+		outPointObjects[i] = btcjson.OutPoint{
+			Hash:  outPoints[i].Hash.String(),
+			Index: outPoints[i].Index,
+		}
+	}
+	return outPointObjects
+}
+
+// LoadTxFilter loads, reloads, or adds data to a websocket client's transaction
+// filter.  The filter is consistently updated based on inspected transactions
+// during mempool acceptance, block acceptance, and for all rescanned blocks.
+// Transactions matching the filter are delivered to OnRelevantTxAccepted, and
+// to OnFilteredBlockConnected once mined.
+//
+// The filter is loaded again automatically after the client reconnects, with
+// everything added to it since the last reload.
+//
+// NOTE: This is a bchd extension ported from github.com/decred/dcrrpcclient
+// and requires a websocket connection.
+func (c *Client) LoadTxFilter(ctx context.Context, reload bool,
+	addresses []bchutil.Address, outPoints []wire.OutPoint) error {
+
+	return c.LoadTxFilterAsync(ctx, reload, addresses, outPoints).Receive()
+}
+
+// FutureNotifySpentResult is a future promise to deliver the result of a
+// NotifySpentAsync RPC invocation (or an applicable error).
+type FutureNotifySpentResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifySpentResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// notifySpentInternal is the same as NotifySpentAsync except it accepts
+// the converted outpoints as a parameter so the client can more efficiently
+// recreate the previous notification state on reconnect.
+func (c *Client) notifySpentInternal(ctx context.Context, outpoints []btcjson.OutPoint) FutureNotifySpentResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifySpentCmd(outpoints)
+	return c.sendCmd(ctx, cmd)
+}
+
+// NotifySpentAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifySpent for the blocking version and more details.
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifySpentAsync(ctx context.Context, outpoints []wire.OutPoint) FutureNotifySpentResult {
+	return c.notifySpentInternal(ctx, newOutPointsFromWire(outpoints))
+}
+
+// NotifySpent registers the client to receive notifications when the passed
+// transaction outputs are spent.  The notifications are delivered to the
+// notification handlers associated with the client.  Calling this function has
+// no effect if there are no notification handlers and will result in an error
+// if the client is configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via
+// OnRedeemingTx.
+//
+// NOTE: This is a bchd extension and requires a websocket connection.
+func (c *Client) NotifySpent(ctx context.Context, outpoints []wire.OutPoint) error {
+	return c.NotifySpentAsync(ctx, outpoints).Receive()
+}
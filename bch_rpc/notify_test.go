@@ -0,0 +1,308 @@
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+func TestNotificationDispatch(t *testing.T) {
+	release := make(chan struct{})
+	blocks := make(chan int32, 2)
+	txs := make(chan bchutil.Amount, 1)
+	unknown := make(chan string, 1)
+	handlers := &NotificationHandlers{
+		OnBlockConnected: func(hash *chainhash.Hash, height int32, t time.Time) {
+			// Stall the first notification to make sure the input
+			// handler is not held up by slow callbacks.
+			if height == 1 {
+				<-release
+			}
+			blocks <- height
+		},
+		OnTxAccepted: func(hash *chainhash.Hash, amount bchutil.Amount) {
+			txs <- amount
+		},
+		OnUnknownNotification: func(method string, params []json.RawMessage) {
+			unknown <- method
+		},
+	}
+	client, err := New(&ConnConfig{DisableConnectOnNew: true}, handlers)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	hash := strings.Repeat("00", 32)
+	msgs := []string{
+		`{"jsonrpc":"1.0","method":"blockconnected","params":["` + hash + `",1,1500000000],"id":null}`,
+		`{"jsonrpc":"1.0","method":"blockconnected","params":["` + hash + `",2,1500000600],"id":null}`,
+		`{"jsonrpc":"1.0","method":"txaccepted","params":["` + hash + `",0.5],"id":null}`,
+		`{"jsonrpc":"1.0","method":"nosuchntfn","params":[],"id":null}`,
+		`{"jsonrpc":"1.0","method":"blockconnected","id":null}`,
+	}
+	done := make(chan struct{})
+	go func() {
+		for _, msg := range msgs {
+			client.handleMessage([]byte(msg))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handleMessage blocked on a slow notification handler")
+	}
+	close(release)
+
+	for _, want := range []int32{1, 2} {
+		select {
+		case height := <-blocks:
+			if height != want {
+				t.Fatalf("block height %d, want %d", height, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("block %d was not delivered", want)
+		}
+	}
+	select {
+	case amount := <-txs:
+		if amount != bchutil.Amount(50000000) {
+			t.Fatalf("unexpected tx amount %v", amount)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("txaccepted was not delivered")
+	}
+	select {
+	case method := <-unknown:
+		if method != "nosuchntfn" {
+			t.Fatalf("unexpected unknown notification %q", method)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("unknown notification was not delivered")
+	}
+}
+
+func TestNotifyRequiresWebsockets(t *testing.T) {
+	client, err := New(&ConnConfig{Host: "127.0.0.1:1", HTTPPostMode: true},
+		&NotificationHandlers{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	ctx := context.Background()
+	if err := client.NotifyBlocks(ctx); err != ErrWebsocketsRequired {
+		t.Fatalf("expected ErrWebsocketsRequired, got %v", err)
+	}
+	if err := client.NotifyNewTransactions(ctx, true); err != ErrWebsocketsRequired {
+		t.Fatalf("expected ErrWebsocketsRequired, got %v", err)
+	}
+	if err := client.LoadTxFilter(ctx, true, nil, nil); err != ErrWebsocketsRequired {
+		t.Fatalf("expected ErrWebsocketsRequired, got %v", err)
+	}
+}
+
+func TestNotifyReregisterOnReconnect(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	client, err := New(server.connConfig(), &NotificationHandlers{})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if err := client.NotifyBlocks(context.Background()); err != nil {
+		t.Fatalf("NotifyBlocks: %v", err)
+	}
+	if method := <-server.methods; method != "notifyblocks" {
+		t.Fatalf("unexpected method %q", method)
+	}
+
+	// Drop the connection from the server side and wait for the client to
+	// reconnect and register for block notifications again.
+	server.dropConn(0)
+	select {
+	case method := <-server.methods:
+		if method != "notifyblocks" {
+			t.Fatalf("unexpected method %q after reconnect", method)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifications were not re-registered after reconnect")
+	}
+}
+
+// nextMethod waits for the next request the websocket test server receives.
+func nextMethod(t *testing.T, server *testWSServer) string {
+	t.Helper()
+
+	select {
+	case method := <-server.methods:
+		return method
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+		return ""
+	}
+}
+
+func TestTxFilterNotifications(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	type filteredBlock struct {
+		height int32
+		header *wire.BlockHeader
+		txs    []*bchutil.Tx
+	}
+	blocks := make(chan filteredBlock, 1)
+	redeemed := make(chan *bchutil.Tx, 1)
+	handlers := &NotificationHandlers{
+		OnFilteredBlockConnected: func(height int32, header *wire.BlockHeader,
+			txs []*bchutil.Tx) {
+
+			blocks <- filteredBlock{height, header, txs}
+		},
+		OnRedeemingTx: func(tx *bchutil.Tx, details *btcjson.BlockDetails) {
+			if details != nil {
+				t.Errorf("unexpected block details %+v", details)
+			}
+			redeemed <- tx
+		},
+	}
+	client, err := New(server.connConfig(), handlers)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	var addrs []bchutil.Address
+	for _, encoded := range []string{
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		"bitcoincash:qqzkxrjkjfpv7r9ascwyk8wv8dfaskmxhq4my83jjh",
+	} {
+		addr, err := bchutil.DecodeAddress(encoded, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("DecodeAddress: %v", err)
+		}
+		addrs = append(addrs, addr)
+	}
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	coinbase := bchutil.NewTx(genesis.Transactions[0])
+	filterOutPoint := wire.OutPoint{Hash: *coinbase.Hash(), Index: 0}
+	spentOutPoint := wire.OutPoint{Hash: *coinbase.Hash(), Index: 1}
+
+	// Build the filter up in two steps and register a spent outpoint.
+	ctx := context.Background()
+	err = client.LoadTxFilter(ctx, true, addrs[:1], []wire.OutPoint{filterOutPoint})
+	if err != nil {
+		t.Fatalf("LoadTxFilter: %v", err)
+	}
+	if method := nextMethod(t, server); method != "loadtxfilter" {
+		t.Fatalf("unexpected method %q", method)
+	}
+	if err := client.LoadTxFilter(ctx, false, addrs[1:], nil); err != nil {
+		t.Fatalf("LoadTxFilter: %v", err)
+	}
+	if method := nextMethod(t, server); method != "loadtxfilter" {
+		t.Fatalf("unexpected method %q", method)
+	}
+	if err := client.NotifySpent(ctx, []wire.OutPoint{spentOutPoint}); err != nil {
+		t.Fatalf("NotifySpent: %v", err)
+	}
+	if method := nextMethod(t, server); method != "notifyspent" {
+		t.Fatalf("unexpected method %q", method)
+	}
+
+	var header bytes.Buffer
+	if err := genesis.Header.Serialize(&header); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var tx bytes.Buffer
+	if err := coinbase.MsgTx().Serialize(&tx); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	txHex := hex.EncodeToString(tx.Bytes())
+
+	err = server.notify(0, "filteredblockconnected", 1,
+		hex.EncodeToString(header.Bytes()), []string{txHex})
+	if err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	select {
+	case block := <-blocks:
+		if block.height != 1 ||
+			block.header.BlockHash() != *chaincfg.RegressionNetParams.GenesisHash ||
+			len(block.txs) != 1 || *block.txs[0].Hash() != *coinbase.Hash() {
+
+			t.Fatalf("unexpected filtered block %+v", block)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("filteredblockconnected was not delivered")
+	}
+
+	if err := server.notify(0, "redeemingtx", txHex); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+	select {
+	case redeemingTx := <-redeemed:
+		if *redeemingTx.Hash() != *coinbase.Hash() {
+			t.Fatalf("unexpected redeeming tx %v", redeemingTx.Hash())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("redeemingtx was not delivered")
+	}
+
+	// After a reconnect the spent outpoint is registered again and the
+	// whole filter is reloaded in a single request.
+	server.dropConn(0)
+	if method := nextMethod(t, server); method != "notifyspent" {
+		t.Fatalf("unexpected method %q after reconnect", method)
+	}
+	if method := nextMethod(t, server); method != "loadtxfilter" {
+		t.Fatalf("unexpected method %q after reconnect", method)
+	}
+
+	var reload struct {
+		Reload    bool
+		Addresses []string
+		OutPoints []btcjson.OutPoint
+	}
+	var params []json.RawMessage
+	if err := json.Unmarshal([]byte(server.lastParams("loadtxfilter")), &params); err != nil {
+		t.Fatalf("params: %v", err)
+	}
+	if len(params) != 3 {
+		t.Fatalf("unexpected params %s", server.lastParams("loadtxfilter"))
+	}
+	for i, field := range []interface{}{&reload.Reload, &reload.Addresses, &reload.OutPoints} {
+		if err := json.Unmarshal(params[i], field); err != nil {
+			t.Fatalf("param %d: %v", i, err)
+		}
+	}
+	sort.Strings(reload.Addresses)
+	wantAddrs := []string{addrs[0].EncodeAddress(), addrs[1].EncodeAddress()}
+	sort.Strings(wantAddrs)
+	if !reload.Reload || strings.Join(reload.Addresses, ",") != strings.Join(wantAddrs, ",") ||
+		len(reload.OutPoints) != 1 || reload.OutPoints[0].Index != 0 ||
+		reload.OutPoints[0].Hash != coinbase.Hash().String() {
+
+		t.Fatalf("unexpected filter reload %+v", reload)
+	}
+	if got, want := server.lastParams("notifyspent"),
+		`[[{"hash":"`+coinbase.Hash().String()+`","index":1}]]`; got != want {
+
+		t.Fatalf("notifyspent params %s, want %s", got, want)
+	}
+}
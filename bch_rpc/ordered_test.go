@@ -0,0 +1,84 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+func TestOrderedMethods(t *testing.T) {
+	var mtx sync.Mutex
+	var order []string
+	inFlight, maxInFlight := 0, 0
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblockcount" {
+			return 100, nil
+		}
+		mtx.Lock()
+		order = append(order, req.Method+" "+string(req.Params[0]))
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mtx.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mtx.Lock()
+		inFlight--
+		mtx.Unlock()
+		return nil, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 4
+	config.OrderedMethods = []string{"walletpassphrase", "walletlock"}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// Interleave the ordered methods with others, which may overtake
+	// them.
+	const rounds = 20
+	var futures []FutureRawResult
+	var want []string
+	for i := 0; i < rounds; i++ {
+		param, _ := json.Marshal(i)
+		for _, method := range config.OrderedMethods {
+			futures = append(futures, client.RawRequestAsync(
+				context.Background(), method,
+				[]json.RawMessage{param}))
+			want = append(want, method+" "+string(param))
+		}
+		futures = append(futures, client.RawRequestAsync(
+			context.Background(), "getblockcount", nil))
+	}
+	for _, future := range futures {
+		if _, err := future.Receive(); err != nil {
+			t.Fatalf("RawRequest: %v", err)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if maxInFlight != 1 {
+		t.Fatalf("%d ordered requests in flight at once, want 1",
+			maxInFlight)
+	}
+	if len(order) != len(want) {
+		t.Fatalf("server received %d ordered requests, want %d",
+			len(order), len(want))
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("request %d was %q, want %q", i, order[i], want[i])
+		}
+	}
+}
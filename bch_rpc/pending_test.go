@@ -0,0 +1,119 @@
+package bch_rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitPending waits until the client has the passed number of pending
+// requests.
+func waitPending(t *testing.T, client *Client, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.PendingRequests() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests pending, want %d",
+				client.PendingRequests(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMaxPendingRequestsFailFast(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.MaxPendingRequests = 3
+	config.FailFast = true
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	defer release()
+
+	var futures []FutureGetBlockCountResult
+	for i := 0; i < 3; i++ {
+		futures = append(futures, client.GetBlockCountAsync(context.Background()))
+	}
+	waitPending(t, client, 3)
+
+	// The server is saturated, so further requests fail right away.
+	start := time.Now()
+	_, err = client.GetBlockCountAsync(context.Background()).Receive()
+	var overloaded *ErrClientOverloaded
+	if !errors.As(err, &overloaded) || overloaded.Limit != 3 {
+		t.Fatalf("expected ErrClientOverloaded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("overloaded request failed after %v", elapsed)
+	}
+	if n := client.PendingRequests(); n != 3 {
+		t.Fatalf("%d requests pending after a rejected request, want 3", n)
+	}
+
+	release()
+	for _, future := range futures {
+		if _, err := future.Receive(); err != nil {
+			t.Fatalf("GetBlockCount: %v", err)
+		}
+	}
+	waitPending(t, client, 0)
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount after the backlog cleared: %v", err)
+	}
+}
+
+func TestMaxPendingRequestsBlock(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.MaxPendingRequests = 1
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	defer release()
+
+	first := client.GetBlockCountAsync(context.Background())
+	waitPending(t, client, 1)
+
+	// A request at the limit waits up to its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetBlockCount(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("request at the limit returned after %v", elapsed)
+	}
+
+	// A request without a deadline waits until the pending one is
+	// answered.
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetBlockCount(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("request at the limit returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	if _, err := first.Receive(); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	waitPending(t, client, 0)
+}
@@ -9,9 +9,15 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/gcash/bchd/btcjson"
 	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/txscript"
 	"github.com/gcash/bchd/wire"
 	"github.com/gcash/bchutil"
 )
@@ -115,6 +121,87 @@ func (c *Client) GetRawTransaction(ctx context.Context, txHash *chainhash.Hash)
 	return c.GetRawTransactionAsync(ctx, txHash).Receive()
 }
 
+// FailedTransaction identifies a transaction GetRawTransactions could not
+// fetch.
+type FailedTransaction struct {
+	// Index is the position of the transaction in the passed hashes.
+	Index int
+
+	// Hash is the hash of the transaction.
+	Hash *chainhash.Hash
+
+	// Err is the error fetching the transaction failed with.
+	Err error
+}
+
+// ErrPartialTransactions describes the condition where GetRawTransactions
+// could only fetch some of the requested transactions.
+type ErrPartialTransactions struct {
+	// Failed lists the transactions which could not be fetched, in the
+	// order they were requested.
+	Failed []FailedTransaction
+}
+
+// Error satisfies the error interface.
+func (e *ErrPartialTransactions) Error() string {
+	first := e.Failed[0]
+	return fmt.Sprintf("%d transactions could not be fetched, first %v: %v",
+		len(e.Failed), first.Hash, first.Err)
+}
+
+// GetRawTransactions returns the transactions given their hashes, in the order
+// of the hashes, such as all transactions of a block.  Up to concurrency
+// requests are outstanding at a time, so the round trips overlap rather than
+// adding up.  Values below one mean one.  How many requests are sent to the
+// server at once in HTTP POST mode is further limited by HTTPPostWorkers.
+//
+// When some of the transactions can not be fetched, the returned error is an
+// ErrPartialTransactions identifying them, and their entries in the returned
+// slice are nil while the others are filled in.
+func (c *Client) GetRawTransactions(ctx context.Context, hashes []*chainhash.Hash, concurrency int) ([]*bchutil.Tx, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(hashes) {
+		concurrency = len(hashes)
+	}
+
+	txs := make([]*bchutil.Tx, len(hashes))
+	errs := make([]error, len(hashes))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				txs[i], errs[i] = c.GetRawTransactionAsync(ctx,
+					hashes[i]).Receive()
+			}
+		}()
+	}
+	for i := range hashes {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var failed []FailedTransaction
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, FailedTransaction{
+				Index: i,
+				Hash:  hashes[i],
+				Err:   err,
+			})
+		}
+	}
+	if len(failed) != 0 {
+		return txs, &ErrPartialTransactions{Failed: failed}
+	}
+	return txs, nil
+}
+
 // FutureGetRawTransactionVerboseResult is a future promise to deliver the
 // result of a GetRawTransactionVerboseAsync RPC invocation (or an applicable
 // error).
@@ -258,6 +345,89 @@ func (c *Client) CreateRawTransaction(ctx context.Context, inputs []btcjson.Tran
 	return c.CreateRawTransactionAsync(ctx, inputs, amounts, lockTime).Receive()
 }
 
+// MaxNullDataScriptSize is the maximum size of the OP_RETURN output script of
+// a transaction for nodes to relay it, opcodes and push prefixes included.
+const MaxNullDataScriptSize = 223
+
+// ErrNullDataTooLarge describes data passed to CreateRawTransactionWithData
+// whose OP_RETURN script exceeds the MaxNullDataScriptSize standardness limit
+// of the nodes.
+type ErrNullDataTooLarge struct {
+	// Size is the size of the OP_RETURN script carrying the data.
+	Size int
+}
+
+// Error satisfies the error interface.
+func (e *ErrNullDataTooLarge) Error() string {
+	return fmt.Sprintf("OP_RETURN script of %d bytes exceeds the standard "+
+		"limit of %d bytes", e.Size, MaxNullDataScriptSize)
+}
+
+// nullDataScript returns an OP_RETURN script pushing each entry of data in
+// turn.  Every entry is pushed as data, empty ones with OP_PUSHDATA1, as SLP
+// requires.  txscript.ScriptBuilder is not used since it pushes small values
+// with OP_0 to OP_16 instead.
+func nullDataScript(data [][]byte) ([]byte, error) {
+	size := 1
+	for _, d := range data {
+		switch {
+		case len(d) > 0 && len(d) < txscript.OP_PUSHDATA1:
+			size++
+		case len(d) <= 0xff:
+			size += 2
+		case len(d) <= 0xffff:
+			size += 3
+		default:
+			size += 5
+		}
+		size += len(d)
+	}
+	if size > MaxNullDataScriptSize {
+		return nil, &ErrNullDataTooLarge{Size: size}
+	}
+
+	// The size limit leaves no room for pushes of more than 0xff bytes.
+	script := make([]byte, 0, size)
+	script = append(script, txscript.OP_RETURN)
+	for _, d := range data {
+		if len(d) > 0 && len(d) < txscript.OP_PUSHDATA1 {
+			script = append(script, byte(len(d)))
+		} else {
+			script = append(script, txscript.OP_PUSHDATA1, byte(len(d)))
+		}
+		script = append(script, d...)
+	}
+	return script, nil
+}
+
+// CreateRawTransactionWithData returns a new transaction spending the provided
+// inputs and sending to the provided addresses like CreateRawTransaction, with
+// a single OP_RETURN output pushing each entry of data in turn, as used by SLP
+// and memo-style protocols.  The data output is built locally and inserted as
+// the first output, where SLP expects it, ahead of the outputs created by the
+// node, so it also works with nodes whose createrawtransaction knows no data
+// outputs.  Data whose script exceeds MaxNullDataScriptSize bytes is rejected
+// with an ErrNullDataTooLarge, as nodes would not relay the transaction.  No
+// data output is added when data is empty.
+func (c *Client) CreateRawTransactionWithData(ctx context.Context, inputs []btcjson.TransactionInput,
+	amounts map[bchutil.Address]bchutil.Amount, data [][]byte, lockTime *int64) (*wire.MsgTx, error) {
+
+	if len(data) == 0 {
+		return c.CreateRawTransaction(ctx, inputs, amounts, lockTime)
+	}
+	script, err := nullDataScript(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := c.CreateRawTransaction(ctx, inputs, amounts, lockTime)
+	if err != nil {
+		return nil, err
+	}
+	tx.TxOut = append([]*wire.TxOut{wire.NewTxOut(0, script)}, tx.TxOut...)
+	return tx, nil
+}
+
 // FutureSendRawTransactionResult is a future promise to deliver the result
 // of a SendRawTransactionAsync RPC invocation (or an applicable error).
 type FutureSendRawTransactionResult chan *response
@@ -297,16 +467,119 @@ func (c *Client) SendRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, al
 		txHex = hex.EncodeToString(buf.Bytes())
 	}
 
+	return c.sendRawTransactionHexAsync(ctx, txHex, allowHighFees)
+}
+
+// Forms of the second sendrawtransaction parameter recorded in
+// Client.sendRawTxForm.
+const (
+	// sendRawTxFormUnknown means no transaction was sent yet.
+	sendRawTxFormUnknown int32 = iota
+
+	// sendRawTxFormFlag means the server takes the allowhighfees flag.
+	sendRawTxFormFlag
+
+	// sendRawTxFormMaxFeeRate means the server takes a numeric
+	// maxfeerate and rejects the flag.
+	sendRawTxFormMaxFeeRate
+)
+
+// sendRawTransactionHexAsync sends the hex-serialized transaction in the form
+// the server accepts.  Until the form is known the transaction is sent with the
+// allowhighfees flag.  Nodes which expect a numeric maxfeerate in its place
+// reject the flag with a type error, in which case the form is recorded and
+// the transaction is sent again as by sendRawTransactionMaxFeeRateAsync.  Later
+// transactions are sent in the recorded form right away.
+func (c *Client) sendRawTransactionHexAsync(ctx context.Context, txHex string, allowHighFees bool) FutureSendRawTransactionResult {
 	cmd := btcjson.NewSendRawTransactionCmd(txHex, &allowHighFees)
-	return c.sendCmd(ctx, cmd)
+	switch atomic.LoadInt32(&c.sendRawTxForm) {
+	case sendRawTxFormFlag:
+		return c.sendCmd(ctx, cmd)
+	case sendRawTxFormMaxFeeRate:
+		return c.sendRawTransactionMaxFeeRateAsync(ctx, txHex, allowHighFees)
+	}
+
+	first := c.sendCmd(ctx, cmd)
+	f := make(chan *response, 1)
+	go func() {
+		resp := <-first
+		switch {
+		case isNotANumber(resp.err):
+			atomic.StoreInt32(&c.sendRawTxForm, sendRawTxFormMaxFeeRate)
+			resp = <-c.sendRawTransactionMaxFeeRateAsync(ctx, txHex,
+				allowHighFees)
+		case resp.err == nil:
+			atomic.CompareAndSwapInt32(&c.sendRawTxForm,
+				sendRawTxFormUnknown, sendRawTxFormFlag)
+		}
+		f <- resp
+	}()
+	return f
+}
+
+// sendRawTransactionMaxFeeRateAsync sends the hex-serialized transaction to a
+// node which takes a numeric maxfeerate, without a fee rate limit when high
+// fees are allowed, or with the default limit of the node otherwise.
+func (c *Client) sendRawTransactionMaxFeeRateAsync(ctx context.Context, txHex string, allowHighFees bool) FutureSendRawTransactionResult {
+	params := []interface{}{txHex}
+	if allowHighFees {
+		params = append(params, 0)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
 }
 
 // SendRawTransaction submits the encoded transaction to the server which will
 // then relay it to the network.
+//
+// Nodes which replaced the allowhighfees flag with a numeric maxfeerate are
+// detected by the error they return for the flag on the first send, and the
+// transaction is sent again with a maxfeerate of 0, meaning no limit, when
+// allowHighFees is set or with the default maxfeerate of the node otherwise.
+// Later transactions are sent in that form right away.  Use
+// SendRawTransactionWithMaxFeeRate to pass a fee rate limit to those nodes.
 func (c *Client) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
 	return c.SendRawTransactionAsync(ctx, tx, allowHighFees).Receive()
 }
 
+// SendRawTransactionWithMaxFeeRateAsync returns an instance of a type that can
+// be used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SendRawTransactionWithMaxFeeRate for the blocking version and more
+// details.
+func (c *Client) SendRawTransactionWithMaxFeeRateAsync(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) FutureSendRawTransactionResult {
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson command only knows the allowhighfees flag, so the call
+	// is sent as a raw request.
+	params := []interface{}{hex.EncodeToString(buf.Bytes()), maxFeeRate}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
+}
+
+// SendRawTransactionWithMaxFeeRate submits the encoded transaction to the
+// server which will then relay it to the network, as long as its fee rate does
+// not exceed maxFeeRate in BCH per kilobyte.  A maxFeeRate of 0 means no limit.
+//
+// Only nodes which replaced the allowhighfees flag of sendrawtransaction with
+// a numeric maxfeerate support this function.
+func (c *Client) SendRawTransactionWithMaxFeeRate(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) (*chainhash.Hash, error) {
+	return c.SendRawTransactionWithMaxFeeRateAsync(ctx, tx, maxFeeRate).Receive()
+}
+
 // FutureSignRawTransactionResult is a future promise to deliver the result
 // of one of the SignRawTransactionAsync family of RPC invocations (or an
 // applicable error).
@@ -314,9 +587,15 @@ type FutureSignRawTransactionResult chan *response
 
 // Receive waits for the response promised by the future and returns the
 // signed transaction as well as whether or not all inputs are now signed.
+//
+// Nodes which removed signrawtransaction fail with an
+// ErrSignRawTransactionUnsupported.
 func (r FutureSignRawTransactionResult) Receive() (*wire.MsgTx, bool, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
+		if isMethodNotFound(err) {
+			err = &ErrSignRawTransactionUnsupported{Err: err}
+		}
 		return nil, false, err
 	}
 
@@ -506,6 +785,212 @@ func (c *Client) SignRawTransaction4(ctx context.Context, tx *wire.MsgTx,
 		hashType).Receive()
 }
 
+// ErrSignRawTransactionUnsupported describes the condition where the node does
+// not know signrawtransaction, which Bitcoin Cash Node removed.  Use
+// SignRawTransactionWithKey or SignRawTransactionWithWallet with those nodes.
+type ErrSignRawTransactionUnsupported struct {
+	// Err is the method not found error returned by the node.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrSignRawTransactionUnsupported) Error() string {
+	return fmt.Sprintf("%v: use SignRawTransactionWithKey or "+
+		"SignRawTransactionWithWallet with this node", e.Err)
+}
+
+// Unwrap returns the error returned by the node.
+func (e *ErrSignRawTransactionUnsupported) Unwrap() error {
+	return e.Err
+}
+
+// SignRawTransactionInputError describes an input which could not be signed,
+// as reported by signrawtransactionwithkey and signrawtransactionwithwallet.
+type SignRawTransactionInputError struct {
+	TxID      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	ScriptSig string `json:"scriptSig"`
+	Sequence  uint32 `json:"sequence"`
+	Error     string `json:"error"`
+}
+
+// SignRawTransactionResult is the transaction signed by
+// SignRawTransactionWithKey or SignRawTransactionWithWallet.
+type SignRawTransactionResult struct {
+	// Transaction is the transaction with all inputs signed which could
+	// be signed.
+	Transaction *wire.MsgTx
+
+	// Complete is whether all inputs of the transaction are signed.
+	Complete bool
+
+	// Errors lists the inputs which could not be signed.  It is empty
+	// when Complete is set.
+	Errors []SignRawTransactionInputError
+}
+
+// FutureSignRawTransactionWithKeyResult is a future promise to deliver the
+// result of a SignRawTransactionWithKeyAsync or
+// SignRawTransactionWithWalletAsync RPC invocation (or an applicable error).
+type FutureSignRawTransactionWithKeyResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// signed transaction along with the inputs which could not be signed.
+func (r FutureSignRawTransactionWithKeyResult) Receive() (*SignRawTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as a signrawtransactionwithkey result.
+	var signResult struct {
+		Hex      string                         `json:"hex"`
+		Complete bool                           `json:"complete"`
+		Errors   []SignRawTransactionInputError `json:"errors"`
+	}
+	err = json.Unmarshal(res, &signResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(signResult.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the transaction and return it.
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+
+	return &SignRawTransactionResult{
+		Transaction: &msgTx,
+		Complete:    signResult.Complete,
+		Errors:      signResult.Errors,
+	}, nil
+}
+
+// forkIDSigHashType returns the passed signature hash type with the FORKID
+// flag Bitcoin Cash nodes require, or nil to leave the default of ALL|FORKID
+// to the node.
+func forkIDSigHashType(hashType SigHashType) *string {
+	if hashType == "" {
+		return nil
+	}
+	s := string(hashType)
+	if !strings.Contains(s, "FORKID") {
+		s += "|FORKID"
+	}
+	return &s
+}
+
+// signRawTransactionParams returns the parameters of signrawtransactionwithkey
+// when withKey is set, or of signrawtransactionwithwallet otherwise, omitting
+// trailing parameters which are not set.
+func signRawTransactionParams(tx *wire.MsgTx, privKeysWIF []string,
+	prevTxs []btcjson.RawTxInput, hashType SigHashType, withKey bool) ([]json.RawMessage, error) {
+
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return nil, err
+	}
+
+	params := []interface{}{hex.EncodeToString(buf.Bytes())}
+	if withKey {
+		if privKeysWIF == nil {
+			privKeysWIF = []string{}
+		}
+		params = append(params, privKeysWIF)
+	}
+	sigHashType := forkIDSigHashType(hashType)
+	if prevTxs != nil || sigHashType != nil {
+		if prevTxs == nil {
+			prevTxs = []btcjson.RawTxInput{}
+		}
+		params = append(params, prevTxs)
+	}
+	if sigHashType != nil {
+		params = append(params, *sigHashType)
+	}
+	return marshalParams(params)
+}
+
+// SignRawTransactionWithKeyAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See SignRawTransactionWithKey for the blocking version and more details.
+func (c *Client) SignRawTransactionWithKeyAsync(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []btcjson.RawTxInput,
+	hashType SigHashType) FutureSignRawTransactionWithKeyResult {
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	rawParams, err := signRawTransactionParams(tx, privKeysWIF, prevTxs,
+		hashType, true)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSignRawTransactionWithKeyResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithkey", rawParams))
+}
+
+// SignRawTransactionWithKey signs inputs for the passed transaction with ONLY
+// the passed private keys, which must be in wallet import format (WIF), and
+// returns the signed transaction as well as the inputs which could not be
+// signed.  It replaces SignRawTransaction3 and SignRawTransaction4 with nodes
+// which removed signrawtransaction, such as Bitcoin Cash Node.
+//
+// The previous outputs spent by the transaction only need to be passed in
+// prevTxs when the node does not know them.  An empty hashType leaves the
+// signature hash type to the node, which defaults to ALL|FORKID.  The FORKID
+// flag Bitcoin Cash requires is added to other hash types as needed.
+func (c *Client) SignRawTransactionWithKey(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []btcjson.RawTxInput,
+	hashType SigHashType) (*SignRawTransactionResult, error) {
+
+	return c.SignRawTransactionWithKeyAsync(ctx, tx, privKeysWIF, prevTxs,
+		hashType).Receive()
+}
+
+// SignRawTransactionWithWalletAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See SignRawTransactionWithWallet for the blocking version and more details.
+func (c *Client) SignRawTransactionWithWalletAsync(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []btcjson.RawTxInput, hashType SigHashType) FutureSignRawTransactionWithKeyResult {
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	rawParams, err := signRawTransactionParams(tx, nil, prevTxs, hashType,
+		false)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSignRawTransactionWithKeyResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithwallet", rawParams))
+}
+
+// SignRawTransactionWithWallet signs inputs for the passed transaction with
+// the private keys of the wallet and returns the signed transaction as well as
+// the inputs which could not be signed.  It replaces SignRawTransaction and
+// SignRawTransaction2 with nodes which removed signrawtransaction, such as
+// Bitcoin Cash Node.
+//
+// See SignRawTransactionWithKey for the meaning of prevTxs and hashType.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) SignRawTransactionWithWallet(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []btcjson.RawTxInput, hashType SigHashType) (*SignRawTransactionResult, error) {
+
+	return c.SignRawTransactionWithWalletAsync(ctx, tx, prevTxs,
+		hashType).Receive()
+}
+
 // FutureSearchRawTransactionsResult is a future promise to deliver the result
 // of the SearchRawTransactionsAsync RPC invocation (or an applicable error).
 type FutureSearchRawTransactionsResult chan *response
@@ -554,8 +1039,11 @@ func (r FutureSearchRawTransactionsResult) Receive() ([]*wire.MsgTx, error) {
 func (c *Client) SearchRawTransactionsAsync(ctx context.Context, address bchutil.Address, skip, count int, reverse bool, filterAddrs []string) FutureSearchRawTransactionsResult {
 	addr := address.EncodeAddress()
 	verbose := btcjson.Int(0)
+
+	// The vinextra flag is passed explicitly since parameters after the
+	// first omitted one are not sent.
 	cmd := btcjson.NewSearchRawTransactionsCmd(addr, verbose, &skip, &count,
-		nil, &reverse, &filterAddrs)
+		btcjson.Int(0), &reverse, &filterAddrs)
 	return c.sendCmd(ctx, cmd)
 }
 
@@ -603,7 +1091,9 @@ count int, includePrevOut, reverse bool, filterAddrs *[]string) FutureSearchRawT
 
 	addr := address.EncodeAddress()
 	verbose := btcjson.Int(1)
-	var prevOut *int
+	// The vinextra flag is always passed since parameters after the first
+	// omitted one are not sent, which would drop the reverse flag.
+	prevOut := btcjson.Int(0)
 	if includePrevOut {
 		prevOut = btcjson.Int(1)
 	}
@@ -626,6 +1116,137 @@ count int, includePrevOut, reverse bool, filterAddrs []string) ([]*btcjson.Searc
 		includePrevOut, reverse, &filterAddrs).Receive()
 }
 
+// ErrAddrIndexDisabled is returned by SearchRawTransactionsIterator when the
+// server runs without the optional address index, so callers can fall back to
+// another source of address history.
+var ErrAddrIndexDisabled = errors.New("address index is not enabled on " +
+	"the server")
+
+// defaultSearchPageSize is the page size SearchRawTransactionsIterator uses
+// when none is passed.  It matches the default count of the server.
+const defaultSearchPageSize = 100
+
+// SearchRawTransactionsIterator pages through the transactions involving an
+// address with searchrawtransactions, advancing the skip parameter as pages
+// are consumed.  It is created with Client.SearchRawTransactionsIterator and
+// must not be used from multiple goroutines at once.
+type SearchRawTransactionsIterator struct {
+	client      *Client
+	address     bchutil.Address
+	pageSize    int
+	reverse     bool
+	filterAddrs []string
+
+	// pending is the request for the next page, if one is in flight.
+	pending FutureSearchRawTransactionsVerboseResult
+	skip    int
+	page    []*btcjson.SearchRawTransactionsResult
+	done    bool
+	err     error
+}
+
+// SearchRawTransactionsIterator returns an iterator over the transactions
+// involving the passed address, fetched pageSize at a time.  A pageSize of
+// zero or less uses the server default of 100.  The request for the first page
+// is sent right away using the passed context.
+//
+// NOTE: Chain servers do not typically provide this capability unless it has
+// specifically been enabled.  Next fails with ErrAddrIndexDisabled if it has
+// not.
+func (c *Client) SearchRawTransactionsIterator(ctx context.Context, address bchutil.Address,
+	pageSize int, reverse bool, filterAddrs []string) *SearchRawTransactionsIterator {
+
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	it := &SearchRawTransactionsIterator{
+		client:      c,
+		address:     address,
+		pageSize:    pageSize,
+		reverse:     reverse,
+		filterAddrs: filterAddrs,
+	}
+	it.pending = it.fetch(ctx)
+	return it
+}
+
+// fetch sends the request for the page starting at the current skip.
+func (it *SearchRawTransactionsIterator) fetch(ctx context.Context) FutureSearchRawTransactionsVerboseResult {
+	var filterAddrs *[]string
+	if len(it.filterAddrs) > 0 {
+		filterAddrs = &it.filterAddrs
+	}
+	return it.client.SearchRawTransactionsVerboseAsync(ctx, it.address,
+		it.skip, it.pageSize, false, it.reverse, filterAddrs)
+}
+
+// Next returns the next transaction involving the address, fetching the next
+// page when the current one is used up.  It returns nil without an error once
+// all transactions have been returned.  Errors are sticky: once Next fails,
+// it keeps returning the same error.
+func (it *SearchRawTransactionsIterator) Next(ctx context.Context) (*btcjson.SearchRawTransactionsResult, error) {
+	for len(it.page) == 0 {
+		if it.err != nil {
+			return nil, it.err
+		}
+		if it.done {
+			return nil, nil
+		}
+
+		pending := it.pending
+		it.pending = nil
+		if pending == nil {
+			pending = it.fetch(ctx)
+		}
+		page, err := pending.Receive()
+		switch {
+		// Servers report a skip past the last transaction as having no
+		// information about the address.
+		case isNoTxInfo(err):
+			it.done = true
+			continue
+
+		case isAddrIndexDisabled(err):
+			it.err = ErrAddrIndexDisabled
+			continue
+
+		case err != nil:
+			it.err = err
+			continue
+		}
+
+		// A short page is the last one, so there is no need to ask for
+		// an empty page after it.
+		it.skip += len(page)
+		it.page = page
+		if len(page) < it.pageSize {
+			it.done = true
+		}
+	}
+
+	next := it.page[0]
+	it.page = it.page[1:]
+	return next, nil
+}
+
+// Collect returns the remaining transactions of the iterator, up to maxResults
+// of them.  A maxResults of zero or less collects all transactions, which
+// should be reserved for addresses known to have a bounded history.
+func (it *SearchRawTransactionsIterator) Collect(ctx context.Context, maxResults int) ([]*btcjson.SearchRawTransactionsResult, error) {
+	var results []*btcjson.SearchRawTransactionsResult
+	for maxResults <= 0 || len(results) < maxResults {
+		next, err := it.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		results = append(results, next)
+	}
+	return results, nil
+}
+
 // FutureDecodeScriptResult is a future promise to deliver the result
 // of a DecodeScriptAsync RPC invocation (or an applicable error).
 type FutureDecodeScriptResult chan *response
@@ -663,3 +1284,162 @@ func (c *Client) DecodeScriptAsync(ctx context.Context, serializedScript []byte)
 func (c *Client) DecodeScript(ctx context.Context, serializedScript []byte) (*btcjson.DecodeScriptResult, error) {
 	return c.DecodeScriptAsync(ctx, serializedScript).Receive()
 }
+
+// marshalParams marshals the passed params of a request sent with
+// RawRequestAsync, for commands the btcjson package does not know.
+func marshalParams(params []interface{}) ([]json.RawMessage, error) {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		marshalledParam, err := json.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		rawParams = append(rawParams, marshalledParam)
+	}
+	return rawParams, nil
+}
+
+// FundRawTransactionOpts holds the options of FundRawTransaction.  Options
+// which are not set are left to the node.
+type FundRawTransactionOpts struct {
+	// ChangeAddress is the address the change is sent to instead of a new
+	// address of the wallet.
+	ChangeAddress bchutil.Address
+
+	// ChangePosition is the index of the change output in the funded
+	// transaction instead of a random one.
+	ChangePosition *int
+
+	// IncludeWatching also selects inputs from watch-only addresses.
+	IncludeWatching *bool
+
+	// LockUnspents locks the selected inputs, so they are not selected
+	// again until they are spent or unlocked.
+	LockUnspents *bool
+
+	// FeeRate is the fee rate per kilobyte to pay instead of the rate the
+	// wallet estimates.
+	FeeRate *bchutil.Amount
+
+	// SubtractFeeFromOutputs lists the indexes of the outputs the fee is
+	// deducted from, split evenly, instead of adding it to the inputs.
+	SubtractFeeFromOutputs []int
+}
+
+// params returns the options as the JSON object the node expects.
+func (o *FundRawTransactionOpts) params() map[string]interface{} {
+	params := make(map[string]interface{})
+	if o.ChangeAddress != nil {
+		params["changeAddress"] = encodeCashAddress(o.ChangeAddress)
+	}
+	if o.ChangePosition != nil {
+		params["changePosition"] = *o.ChangePosition
+	}
+	if o.IncludeWatching != nil {
+		params["includeWatching"] = *o.IncludeWatching
+	}
+	if o.LockUnspents != nil {
+		params["lockUnspents"] = *o.LockUnspents
+	}
+	if o.FeeRate != nil {
+		params["feeRate"] = o.FeeRate.ToBCH()
+	}
+	if o.SubtractFeeFromOutputs != nil {
+		params["subtractFeeFromOutputs"] = o.SubtractFeeFromOutputs
+	}
+	return params
+}
+
+// FundRawTransactionResult is the transaction funded by FundRawTransaction.
+type FundRawTransactionResult struct {
+	// Transaction is the funded transaction.  Its inputs are not signed.
+	Transaction *wire.MsgTx
+
+	// Fee is the fee the funded transaction pays.
+	Fee bchutil.Amount
+
+	// ChangePosition is the index of the change output, or -1 when no
+	// change output was added.
+	ChangePosition int
+}
+
+// FutureFundRawTransactionResult is a future promise to deliver the result
+// of a FundRawTransactionAsync RPC invocation (or an applicable error).
+type FutureFundRawTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns the funded
+// transaction.
+func (r FutureFundRawTransactionResult) Receive() (*FundRawTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a fundrawtransaction result object.
+	var fundResult struct {
+		Hex       string  `json:"hex"`
+		Fee       float64 `json:"fee"`
+		ChangePos int     `json:"changepos"`
+	}
+	err = json.Unmarshal(res, &fundResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(fundResult.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the transaction.
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+
+	fee, err := bchutil.NewAmount(fundResult.Fee)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FundRawTransactionResult{
+		Transaction:    &msgTx,
+		Fee:            fee,
+		ChangePosition: fundResult.ChangePos,
+	}, nil
+}
+
+// FundRawTransactionAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See FundRawTransaction for the blocking version and more details.
+func (c *Client) FundRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, opts FundRawTransactionOpts) FutureFundRawTransactionResult {
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	params := []interface{}{hex.EncodeToString(buf.Bytes()), opts.params()}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureFundRawTransactionResult(c.RawRequestAsync(ctx,
+		"fundrawtransaction", rawParams))
+}
+
+// FundRawTransaction adds inputs from the wallet to the passed transaction
+// until they cover its outputs and the fee, adding a change output if needed.
+// The inputs the transaction already has are kept.  The funded transaction is
+// not signed.
+//
+// When the wallet can not cover the outputs the returned error is an RPC error
+// for which IsInsufficientFunds returns true.
+func (c *Client) FundRawTransaction(ctx context.Context, tx *wire.MsgTx, opts FundRawTransactionOpts) (*FundRawTransactionResult, error) {
+	return c.FundRawTransactionAsync(ctx, tx, opts).Receive()
+}
@@ -0,0 +1,751 @@
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+// genesisCoinbaseTx is the serialized coinbase transaction of the Bitcoin
+// genesis block.
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000" +
+	"000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65" +
+	"732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b" +
+	"206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff01" +
+	"00f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e039" +
+	"09a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d" +
+	"578a4c702b6bf11d5fac00000000"
+
+func BenchmarkGetRawTransactionWorkers(b *testing.B) {
+	server := newTestServer(b, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(5 * time.Millisecond)
+		return genesisCoinbaseTx, nil
+	})
+	defer server.Close()
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			config := testConnConfig(server)
+			config.HTTPPostWorkers = workers
+			client, err := New(config, nil)
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			// Keep more calls outstanding than there are workers.
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := client.GetRawTransaction(context.Background(),
+						&chainhash.Hash{})
+					if err != nil {
+						b.Errorf("GetRawTransaction: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestFundRawTransaction(t *testing.T) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return map[string]interface{}{
+			"hex":       genesisCoinbaseTx,
+			"fee":       0.00000226,
+			"changepos": 1,
+		}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	changeAddr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	feeRate := bchutil.Amount(1000)
+	lock := true
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	result, err := client.FundRawTransaction(context.Background(), tx,
+		FundRawTransactionOpts{
+			ChangeAddress:          changeAddr,
+			LockUnspents:           &lock,
+			FeeRate:                &feeRate,
+			SubtractFeeFromOutputs: []int{0},
+		})
+	if err != nil {
+		t.Fatalf("FundRawTransaction: %v", err)
+	}
+	if result.Fee != 226 || result.ChangePosition != 1 {
+		t.Fatalf("unexpected fee %v and change position %d", result.Fee,
+			result.ChangePosition)
+	}
+	if hash := result.Transaction.TxHash().String(); hash != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
+		t.Fatalf("unexpected funded transaction %s", hash)
+	}
+
+	// The transaction is sent as hex along with only the options set.
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := `["0100000000018813000000000000015100000000",` +
+		`{"changeAddress":"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",` +
+		`"feeRate":0.00001,"lockUnspents":true,"subtractFeeFromOutputs":[0]}]`
+	if got, _ := json.Marshal(params); string(got) != want {
+		t.Fatalf("sent params %s, want %s", got, want)
+	}
+}
+
+func TestFundRawTransactionInsufficientFunds(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCWalletInsufficientFunds,
+			"Insufficient funds")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	_, err := client.FundRawTransaction(context.Background(), tx,
+		FundRawTransactionOpts{})
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCWalletInsufficientFunds {
+		t.Fatalf("expected an insufficient funds RPC error, got %v", err)
+	}
+	if !IsInsufficientFunds(err) {
+		t.Fatal("IsInsufficientFunds does not match the error")
+	}
+}
+
+func TestCreateRawTransactionWithData(t *testing.T) {
+	// The node creates a transaction with a single output.
+	created := wire.NewMsgTx(wire.TxVersion)
+	created.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	var buf bytes.Buffer
+	if err := created.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return hex.EncodeToString(buf.Bytes()), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	addr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	amounts := map[bchutil.Address]bchutil.Amount{addr: 5000}
+	data := [][]byte{[]byte("memo"), bytes.Repeat([]byte{0xab}, 100)}
+	tx, err := client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, data, nil)
+	if err != nil {
+		t.Fatalf("CreateRawTransactionWithData: %v", err)
+	}
+
+	// A single data output pushing every entry precedes the outputs
+	// created by the node.
+	wantScripts := []string{
+		"6a046d656d6f4c64" + strings.Repeat("ab", 100),
+		"51",
+	}
+	if len(tx.TxOut) != len(wantScripts) {
+		t.Fatalf("%d outputs, want %d", len(tx.TxOut), len(wantScripts))
+	}
+	for i, want := range wantScripts {
+		out := tx.TxOut[i]
+		if script := hex.EncodeToString(out.PkScript); script != want {
+			t.Fatalf("output %d: script %s, want %s", i, script, want)
+		}
+	}
+	if tx.TxOut[0].Value != 0 {
+		t.Fatalf("data output pays %d", tx.TxOut[0].Value)
+	}
+
+	// Without data the transaction is the one created by the node.
+	tx, err = client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateRawTransactionWithData: %v", err)
+	}
+	if len(tx.TxOut) != 1 || hex.EncodeToString(tx.TxOut[0].PkScript) != "51" {
+		t.Fatalf("unexpected outputs without data: %v", tx.TxOut)
+	}
+
+	// An SLP GENESIS message with empty and single byte fields comes out
+	// as SLP expects it.
+	data = [][]byte{[]byte("SLP\x00"), {0x01}, []byte(SLPGenesis), nil, nil,
+		nil, nil, {0x00}, nil, {0, 0, 0, 0, 0, 0, 0, 0x64}}
+	tx, err = client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, data, nil)
+	if err != nil {
+		t.Fatalf("CreateRawTransactionWithData: %v", err)
+	}
+	wantScript := "6a04534c500001010747454e455349534c004c004c004c0001004c00080000000000000064"
+	if script := hex.EncodeToString(tx.TxOut[0].PkScript); script != wantScript {
+		t.Fatalf("SLP script %s, want %s", script, wantScript)
+	}
+	slp, err := ParseSLP(tx)
+	if err != nil {
+		t.Fatalf("ParseSLP: %v", err)
+	}
+	if slp == nil || slp.TransactionType != SLPGenesis ||
+		slp.TokenType != SLPTokenTypeFungible || slp.TokenID != tx.TxHash() ||
+		len(slp.OutputAmounts) != 2 || slp.OutputAmounts[1] != 100 {
+
+		t.Fatalf("unexpected SLP message %+v", slp)
+	}
+
+	// Data whose script exceeds the standardness limit, push prefixes
+	// included, is rejected before anything is sent to the node.
+	data = [][]byte{make([]byte, 200), make([]byte, 20)}
+	_, err = client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, data, nil)
+	var tooLarge *ErrNullDataTooLarge
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 224 {
+		t.Fatalf("expected ErrNullDataTooLarge, got %v", err)
+	}
+	data = [][]byte{make([]byte, 300)}
+	_, err = client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, data, nil)
+	if !errors.As(err, &tooLarge) || tooLarge.Size != 304 {
+		t.Fatalf("expected ErrNullDataTooLarge, got %v", err)
+	}
+	data = [][]byte{make([]byte, 220)}
+	if _, err := client.CreateRawTransactionWithData(context.Background(), nil,
+		amounts, data, nil); err != nil {
+
+		t.Fatalf("data at the limit: %v", err)
+	}
+}
+
+func TestSignRawTransactionWithKey(t *testing.T) {
+	const prevTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	var mtx sync.Mutex
+	var requests []*testRequest
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests = append(requests, req)
+		mtx.Unlock()
+		return map[string]interface{}{
+			"hex":      genesisCoinbaseTx,
+			"complete": false,
+			"errors": []map[string]interface{}{{
+				"txid":      prevTxID,
+				"vout":      1,
+				"scriptSig": "",
+				"sequence":  4294967295,
+				"error":     "Input not found or already spent",
+			}},
+		}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	prevTxs := []btcjson.RawTxInput{{
+		Txid:         prevTxID,
+		Vout:         1,
+		ScriptPubKey: "51",
+	}}
+	const wif = "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+	result, err := client.SignRawTransactionWithKey(context.Background(), tx,
+		[]string{wif}, prevTxs, SigHashAllAnyoneCanPay)
+	if err != nil {
+		t.Fatalf("SignRawTransactionWithKey: %v", err)
+	}
+	if result.Complete || result.Transaction.TxHash().String() != prevTxID {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// The inputs which could not be signed are reported.
+	wantErrors := []SignRawTransactionInputError{{
+		TxID:     prevTxID,
+		Vout:     1,
+		Sequence: 4294967295,
+		Error:    "Input not found or already spent",
+	}}
+	if len(result.Errors) != 1 || result.Errors[0] != wantErrors[0] {
+		t.Fatalf("unexpected errors %+v", result.Errors)
+	}
+
+	if _, err := client.SignRawTransactionWithWallet(context.Background(), tx,
+		nil, ""); err != nil {
+
+		t.Fatalf("SignRawTransactionWithWallet: %v", err)
+	}
+	if _, err := client.SignRawTransactionWithWallet(context.Background(), tx,
+		nil, SigHashSingle); err != nil {
+
+		t.Fatalf("SignRawTransactionWithWallet: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	txHex := `"` + hex.EncodeToString(buf.Bytes()) + `"`
+	prevTxsJSON, _ := json.Marshal(prevTxs)
+	want := []struct {
+		method string
+		params string
+	}{
+		{"signrawtransactionwithkey", `[` + txHex + `,["` + wif + `"],` +
+			string(prevTxsJSON) + `,"ALL|ANYONECANPAY|FORKID"]`},
+		{"signrawtransactionwithwallet", `[` + txHex + `]`},
+		{"signrawtransactionwithwallet", `[` + txHex + `,[],"SINGLE|FORKID"]`},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("%d requests, want %d", len(requests), len(want))
+	}
+	for i, req := range requests {
+		params, _ := json.Marshal(req.Params)
+		if req.Method != want[i].method || string(params) != want[i].params {
+			t.Errorf("call %d sent %s %s, want %s %s", i, req.Method,
+				params, want[i].method, want[i].params)
+		}
+	}
+}
+
+func TestSignRawTransactionUnsupported(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// Nodes without signrawtransaction point at its replacements, while
+	// the RPC error remains available.
+	_, _, err := client.SignRawTransaction(context.Background(),
+		wire.NewMsgTx(wire.TxVersion))
+	var unsupported *ErrSignRawTransactionUnsupported
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expected ErrSignRawTransactionUnsupported, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "SignRawTransactionWithKey") {
+		t.Fatalf("error %q does not name the replacement", err)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCMethodNotFound.Code {
+		t.Fatalf("RPC error not wrapped: %v", err)
+	}
+}
+
+func TestSendRawTransactionMaxFeeRate(t *testing.T) {
+	const txHash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	txHex := `"` + hex.EncodeToString(buf.Bytes()) + `"`
+
+	// Older nodes take the allowhighfees flag, newer nodes a numeric
+	// maxfeerate which they reject the flag in place of.
+	tests := []struct {
+		name          string
+		numeric       bool
+		allowHighFees bool
+		want          []string
+	}{
+		{"flag allowing high fees", false, true, []string{`[` + txHex + `,true]`}},
+		{"flag", false, false, []string{`[` + txHex + `,false]`}},
+		{"maxfeerate without limit", true, true, []string{
+			`[` + txHex + `,true]`,
+			`[` + txHex + `,0]`,
+		}},
+		{"default maxfeerate", true, false, []string{
+			`[` + txHex + `,false]`,
+			`[` + txHex + `]`,
+		}},
+	}
+	for _, test := range tests {
+		var mtx sync.Mutex
+		var params []string
+		numeric := test.numeric
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			p, _ := json.Marshal(req.Params)
+			mtx.Lock()
+			params = append(params, string(p))
+			mtx.Unlock()
+			if len(req.Params) > 1 {
+				var maxFeeRate float64
+				isNumber := json.Unmarshal(req.Params[1], &maxFeeRate) == nil
+				if isNumber != numeric {
+					return nil, btcjson.NewRPCError(btcjson.ErrRPCType,
+						"JSON value is not a number as expected")
+				}
+			}
+			return txHash, nil
+		})
+		client := newTestClient(t, server)
+
+		// The form the node accepts is only detected once, so the
+		// second transaction is sent in it right away.
+		for i := 0; i < 2; i++ {
+			hash, err := client.SendRawTransaction(context.Background(), tx,
+				test.allowHighFees)
+			if err != nil {
+				t.Fatalf("%s: SendRawTransaction: %v", test.name, err)
+			}
+			if hash.String() != txHash {
+				t.Fatalf("%s: unexpected hash %v", test.name, hash)
+			}
+		}
+		stopClient(client)
+		server.Close()
+		want := append(test.want, test.want[len(test.want)-1])
+
+		mtx.Lock()
+		if len(params) != len(want) {
+			t.Fatalf("%s: %d requests, want %d", test.name, len(params),
+				len(want))
+		}
+		for i, p := range params {
+			if p != want[i] {
+				t.Errorf("%s: call %d sent params %s, want %s", test.name,
+					i, p, want[i])
+			}
+		}
+		mtx.Unlock()
+	}
+
+	// The maxfeerate is passed as is.
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return txHash, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	if _, err := client.SendRawTransactionWithMaxFeeRate(context.Background(),
+		tx, 0.001); err != nil {
+
+		t.Fatalf("SendRawTransactionWithMaxFeeRate: %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if got, _ := json.Marshal(params); string(got) != `[`+txHex+`,0.001]` {
+		t.Fatalf("sent params %s", got)
+	}
+}
+
+// rawTransactionsServer returns a test server answering getrawtransaction for
+// hashes whose first byte is the index of the transaction with a transaction
+// whose lock time is that index.  Transactions whose index is in missing are
+// reported as unknown.  Every reply is delayed by latency.
+func rawTransactionsServer(tb testing.TB, latency time.Duration, missing map[uint32]bool) *httptest.Server {
+	return newTestServer(tb, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(latency)
+		var hashStr string
+		if err := json.Unmarshal(req.Params[0], &hashStr); err != nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidParameter, err.Error())
+		}
+		hash, err := chainhash.NewHashFromStr(hashStr)
+		if err != nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidParameter, err.Error())
+		}
+		index := uint32(hash[0])
+		if missing[index] {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCNoTxInfo,
+				"No such mempool or blockchain transaction")
+		}
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+		tx.LockTime = index
+		var buf bytes.Buffer
+		if err := tx.Serialize(&buf); err != nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInternal.Code, err.Error())
+		}
+		return hex.EncodeToString(buf.Bytes()), nil
+	})
+}
+
+// rawTransactionsHashes returns n hashes for a rawTransactionsServer.
+func rawTransactionsHashes(n int) []*chainhash.Hash {
+	hashes := make([]*chainhash.Hash, n)
+	for i := range hashes {
+		hashes[i] = &chainhash.Hash{byte(i)}
+	}
+	return hashes
+}
+
+func TestGetRawTransactions(t *testing.T) {
+	missing := map[uint32]bool{3: true, 17: true}
+	server := rawTransactionsServer(t, time.Millisecond, missing)
+	defer server.Close()
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 4
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	hashes := rawTransactionsHashes(40)
+	txs, err := client.GetRawTransactions(context.Background(), hashes, 8)
+	var partial *ErrPartialTransactions
+	if !errors.As(err, &partial) {
+		t.Fatalf("expected ErrPartialTransactions, got %v", err)
+	}
+
+	// The failed transactions are identified in order, while the others
+	// are returned in the order of their hashes.
+	if len(partial.Failed) != 2 || partial.Failed[0].Index != 3 ||
+		partial.Failed[1].Index != 17 || partial.Failed[1].Hash != hashes[17] {
+
+		t.Fatalf("unexpected failed transactions %+v", partial.Failed)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(partial.Failed[0].Err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCNoTxInfo {
+		t.Fatalf("unexpected error %v", partial.Failed[0].Err)
+	}
+	if len(txs) != len(hashes) {
+		t.Fatalf("%d transactions, want %d", len(txs), len(hashes))
+	}
+	for i, tx := range txs {
+		if missing[uint32(i)] {
+			if tx != nil {
+				t.Fatalf("transaction %d returned although it failed", i)
+			}
+			continue
+		}
+		if tx == nil || tx.MsgTx().LockTime != uint32(i) {
+			t.Fatalf("transaction %d out of order", i)
+		}
+	}
+
+	// All transactions fetched means no error.
+	txs, err = client.GetRawTransactions(context.Background(), hashes[:3], 0)
+	if err != nil || len(txs) != 3 {
+		t.Fatalf("GetRawTransactions = %d transactions, %v", len(txs), err)
+	}
+	if txs, err := client.GetRawTransactions(context.Background(), nil, 8); err != nil || len(txs) != 0 {
+		t.Fatalf("GetRawTransactions without hashes = %v, %v", txs, err)
+	}
+}
+
+func BenchmarkGetRawTransactions(b *testing.B) {
+	server := rawTransactionsServer(b, 2*time.Millisecond, nil)
+	defer server.Close()
+	hashes := rawTransactionsHashes(200)
+
+	for _, concurrency := range []int{1, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			config := testConnConfig(server)
+			config.HTTPPostWorkers = concurrency
+			client, err := New(config, nil)
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := client.GetRawTransactions(context.Background(),
+					hashes, concurrency)
+				if err != nil {
+					b.Fatalf("GetRawTransactions: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// searchRawTransactionsServer serves searchrawtransactions for an address
+// with the passed number of transactions, whose txids are their positions in
+// the address history.  It records the skip and count of every request.
+func searchRawTransactionsServer(t *testing.T, total int, pages *[][2]int, mu *sync.Mutex) *httptest.Server {
+	return newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "searchrawtransactions" || len(req.Params) != 6 {
+			t.Errorf("unexpected request %s %d", req.Method, len(req.Params))
+			return nil, nil
+		}
+		var skip, count int
+		var reverse bool
+		json.Unmarshal(req.Params[2], &skip)
+		json.Unmarshal(req.Params[3], &count)
+		json.Unmarshal(req.Params[5], &reverse)
+		if !reverse {
+			t.Errorf("reverse flag not sent: %s", req.Params[5])
+		}
+		mu.Lock()
+		*pages = append(*pages, [2]int{skip, count})
+		mu.Unlock()
+
+		if skip >= total {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCNoTxInfo,
+				"No information available about address")
+		}
+		var results []btcjson.SearchRawTransactionsResult
+		for i := skip; i < skip+count && i < total; i++ {
+			results = append(results, btcjson.SearchRawTransactionsResult{
+				Txid: fmt.Sprintf("%064x", i),
+			})
+		}
+		return results, nil
+	})
+}
+
+func TestSearchRawTransactionsIterator(t *testing.T) {
+	addr, err := bchutil.DecodeAddress(
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		total     int
+		pageSize  int
+		wantPages [][2]int
+	}{
+		// The short final page ends the iteration without another
+		// request.
+		{"short final page", 7, 3, [][2]int{{0, 3}, {3, 3}, {6, 3}}},
+
+		// A full final page is followed by a request past the end,
+		// which the server answers with an error.
+		{"full final page", 6, 3, [][2]int{{0, 3}, {3, 3}, {6, 3}}},
+		{"no transactions", 0, 3, [][2]int{{0, 3}}},
+		{"default page size", 2, 0, [][2]int{{0, 100}}},
+	}
+	for _, test := range tests {
+		var (
+			mu    sync.Mutex
+			pages [][2]int
+		)
+		server := searchRawTransactionsServer(t, test.total, &pages, &mu)
+		client := newTestClient(t, server)
+
+		ctx := context.Background()
+		it := client.SearchRawTransactionsIterator(ctx, addr, test.pageSize,
+			true, nil)
+		for i := 0; ; i++ {
+			result, err := it.Next(ctx)
+			if err != nil {
+				t.Fatalf("%s: Next: %v", test.name, err)
+			}
+			if result == nil {
+				if i != test.total {
+					t.Fatalf("%s: %d transactions, want %d",
+						test.name, i, test.total)
+				}
+				break
+			}
+			if result.Txid != fmt.Sprintf("%064x", i) {
+				t.Fatalf("%s: transaction %d has txid %s",
+					test.name, i, result.Txid)
+			}
+		}
+
+		// The iterator stays done.
+		if result, err := it.Next(ctx); result != nil || err != nil {
+			t.Fatalf("%s: Next after end = %v, %v", test.name, result, err)
+		}
+		mu.Lock()
+		if fmt.Sprint(pages) != fmt.Sprint(test.wantPages) {
+			t.Errorf("%s: requested pages %v, want %v", test.name,
+				pages, test.wantPages)
+		}
+		mu.Unlock()
+
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestSearchRawTransactionsIteratorCollect(t *testing.T) {
+	addr, err := bchutil.DecodeAddress(
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	var (
+		mu    sync.Mutex
+		pages [][2]int
+	)
+	server := searchRawTransactionsServer(t, 25, &pages, &mu)
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	ctx := context.Background()
+	it := client.SearchRawTransactionsIterator(ctx, addr, 10, true, nil)
+	results, err := it.Collect(ctx, 12)
+	if err != nil || len(results) != 12 {
+		t.Fatalf("Collect = %d results, %v", len(results), err)
+	}
+
+	// Collecting again picks up where the first call stopped.
+	results, err = it.Collect(ctx, 0)
+	if err != nil || len(results) != 13 || results[0].Txid != fmt.Sprintf("%064x", 12) {
+		t.Fatalf("Collect = %d results, %v", len(results), err)
+	}
+}
+
+func TestSearchRawTransactionsIteratorNoAddrIndex(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMisc,
+			"Address index must be enabled (--addrindex)")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	addr, err := bchutil.DecodeAddress(
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	ctx := context.Background()
+	it := client.SearchRawTransactionsIterator(ctx, addr, 10, false, nil)
+	for i := 0; i < 2; i++ {
+		if _, err := it.Next(ctx); err != ErrAddrIndexDisabled {
+			t.Fatalf("expected ErrAddrIndexDisabled, got %v", err)
+		}
+	}
+	if _, err := it.Collect(ctx, 5); err != ErrAddrIndexDisabled {
+		t.Fatalf("expected ErrAddrIndexDisabled from Collect, got %v", err)
+	}
+}
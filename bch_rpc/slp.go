@@ -0,0 +1,425 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/txscript"
+	"github.com/gcash/bchd/wire"
+)
+
+// slpLokadID is the protocol identifier pushed first by every SLP message.
+var slpLokadID = []byte("SLP\x00")
+
+// SLP token types which ParseSLP understands.
+const (
+	// SLPTokenTypeFungible is the token type of regular fungible tokens.
+	SLPTokenTypeFungible uint16 = 0x01
+
+	// SLPTokenTypeNFT1Child is the token type of NFT1 child tokens, which
+	// are created in a quantity of one without decimals or mint baton.
+	SLPTokenTypeNFT1Child uint16 = 0x41
+
+	// SLPTokenTypeNFT1Group is the token type of NFT1 group tokens.
+	SLPTokenTypeNFT1Group uint16 = 0x81
+)
+
+// SLP transaction types.
+const (
+	SLPGenesis = "GENESIS"
+	SLPMint    = "MINT"
+	SLPSend    = "SEND"
+)
+
+// slpMaxSendOutputs is the most token outputs a SEND message may carry.
+const slpMaxSendOutputs = 19
+
+// SLPTokenData is the SLP message carried by a transaction, as returned by
+// ParseSLP.
+type SLPTokenData struct {
+	// TokenType is the SLP token type, such as SLPTokenTypeFungible.
+	TokenType uint16
+
+	// TransactionType is one of SLPGenesis, SLPMint or SLPSend.
+	TransactionType string
+
+	// TokenID identifies the token.  It is the hash of the GENESIS
+	// transaction, so for GENESIS messages it is the hash of the parsed
+	// transaction itself.
+	TokenID chainhash.Hash
+
+	// Ticker, Name, DocumentURL, DocumentHash and Decimals describe the
+	// token and are only set for GENESIS messages.  DocumentHash is
+	// either empty or 32 bytes long.
+	Ticker       string
+	Name         string
+	DocumentURL  string
+	DocumentHash []byte
+	Decimals     uint8
+
+	// MintBatonVout is the output receiving the mint baton of a GENESIS
+	// or MINT message, or zero when the baton is not passed on.
+	MintBatonVout uint32
+
+	// OutputAmounts holds the token amount, in base units, sent to each
+	// transaction output, indexed by output.  The first entry, for the
+	// OP_RETURN output itself, is always zero.  Outputs beyond the end
+	// receive no tokens.
+	OutputAmounts []uint64
+}
+
+// ErrInvalidSLP describes an SLP message which does not conform to the SLP
+// specification.
+type ErrInvalidSLP struct {
+	// Reason describes which rule the message breaks.
+	Reason string
+}
+
+// Error satisfies the error interface.
+func (e *ErrInvalidSLP) Error() string {
+	return "invalid SLP message: " + e.Reason
+}
+
+// ErrUnsupportedSLPTokenType describes an SLP message of a token type
+// ParseSLP does not know.  Such messages are neither valid nor invalid as far
+// as the parser can tell.
+type ErrUnsupportedSLPTokenType struct {
+	TokenType uint16
+}
+
+// Error satisfies the error interface.
+func (e *ErrUnsupportedSLPTokenType) Error() string {
+	return fmt.Sprintf("unsupported SLP token type %#x", e.TokenType)
+}
+
+// invalidSLP returns an ErrInvalidSLP for the formatted reason.
+func invalidSLP(format string, args ...interface{}) error {
+	return &ErrInvalidSLP{Reason: fmt.Sprintf(format, args...)}
+}
+
+// slpChunks splits the passed script, which must follow the OP_RETURN, into
+// its pushed data.  SLP only allows data pushes in its messages, so any other
+// opcode is an error.  The chunks parsed before an error are returned along
+// with it.
+func slpChunks(script []byte) ([][]byte, error) {
+	var chunks [][]byte
+	for len(script) > 0 {
+		op := script[0]
+		script = script[1:]
+
+		var n int
+		switch {
+		case op >= txscript.OP_DATA_1 && op <= txscript.OP_DATA_75:
+			n = int(op)
+
+		case op == txscript.OP_PUSHDATA1:
+			if len(script) < 1 {
+				return chunks, invalidSLP("truncated OP_PUSHDATA1")
+			}
+			n = int(script[0])
+			script = script[1:]
+
+		case op == txscript.OP_PUSHDATA2:
+			if len(script) < 2 {
+				return chunks, invalidSLP("truncated OP_PUSHDATA2")
+			}
+			n = int(binary.LittleEndian.Uint16(script))
+			script = script[2:]
+
+		case op == txscript.OP_PUSHDATA4:
+			if len(script) < 4 {
+				return chunks, invalidSLP("truncated OP_PUSHDATA4")
+			}
+			length := binary.LittleEndian.Uint32(script)
+			if uint64(length) > uint64(len(script)-4) {
+				return chunks, invalidSLP("push of %d bytes past the "+
+					"end of the script", length)
+			}
+			n = int(length)
+			script = script[4:]
+
+		default:
+			return chunks, invalidSLP("disallowed opcode %#02x", op)
+		}
+
+		if n > len(script) {
+			return chunks, invalidSLP("push of %d bytes past the end of "+
+				"the script", n)
+		}
+		chunks = append(chunks, script[:n])
+		script = script[n:]
+	}
+	return chunks, nil
+}
+
+// parseSLPTokenID decodes a token id as pushed by MINT and SEND messages,
+// which is the hash in its usual display byte order.
+func parseSLPTokenID(chunk []byte) (chainhash.Hash, error) {
+	if len(chunk) != chainhash.HashSize {
+		return chainhash.Hash{}, invalidSLP("token id of %d bytes",
+			len(chunk))
+	}
+	hash, err := chainhash.NewHashFromStr(hex.EncodeToString(chunk))
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	return *hash, nil
+}
+
+// parseSLPAmount decodes a big-endian eight byte token quantity.
+func parseSLPAmount(chunk []byte, field string) (uint64, error) {
+	if len(chunk) != 8 {
+		return 0, invalidSLP("%s of %d bytes", field, len(chunk))
+	}
+	return binary.BigEndian.Uint64(chunk), nil
+}
+
+// parseSLPMintBaton decodes an optional mint baton output index, which must
+// not point at the OP_RETURN output or the first token output.
+func parseSLPMintBaton(chunk []byte) (uint32, error) {
+	switch {
+	case len(chunk) == 0:
+		return 0, nil
+	case len(chunk) != 1:
+		return 0, invalidSLP("mint baton vout of %d bytes", len(chunk))
+	case chunk[0] < 2:
+		return 0, invalidSLP("mint baton vout %d", chunk[0])
+	}
+	return uint32(chunk[0]), nil
+}
+
+// ParseSLP returns the SLP message carried by the first output of the passed
+// transaction.  Transactions without an SLP message return nil data and no
+// error.  Messages which break the SLP specification return an ErrInvalidSLP
+// describing the problem and messages of unknown token types return an
+// ErrUnsupportedSLPTokenType, never partially parsed data.
+//
+// Only the message itself is checked.  Whether the transaction's inputs carry
+// the tokens it spends has to be validated against the token graph.
+func ParseSLP(tx *wire.MsgTx) (*SLPTokenData, error) {
+	if len(tx.TxOut) == 0 {
+		return nil, nil
+	}
+	script := tx.TxOut[0].PkScript
+	if len(script) == 0 || script[0] != txscript.OP_RETURN {
+		return nil, nil
+	}
+
+	// Scripts which do not push the lokad id first are not SLP messages,
+	// whatever else they contain.
+	chunks, err := slpChunks(script[1:])
+	if len(chunks) == 0 || !bytes.Equal(chunks[0], slpLokadID) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) < 3 {
+		return nil, invalidSLP("missing token or transaction type")
+	}
+
+	data := &SLPTokenData{}
+	switch len(chunks[1]) {
+	case 1:
+		data.TokenType = uint16(chunks[1][0])
+	case 2:
+		data.TokenType = binary.BigEndian.Uint16(chunks[1])
+	default:
+		return nil, invalidSLP("token type of %d bytes", len(chunks[1]))
+	}
+	switch data.TokenType {
+	case SLPTokenTypeFungible, SLPTokenTypeNFT1Child, SLPTokenTypeNFT1Group:
+	default:
+		return nil, &ErrUnsupportedSLPTokenType{TokenType: data.TokenType}
+	}
+
+	data.TransactionType = string(chunks[2])
+	fields := chunks[3:]
+	switch data.TransactionType {
+	case SLPGenesis:
+		err = parseSLPGenesis(data, fields)
+		data.TokenID = tx.TxHash()
+
+	case SLPMint:
+		err = parseSLPMint(data, fields)
+
+	case SLPSend:
+		err = parseSLPSend(data, fields)
+
+	default:
+		err = invalidSLP("unknown transaction type %q", chunks[2])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// parseSLPGenesis fills in the passed data from the fields of a GENESIS
+// message following its transaction type.
+func parseSLPGenesis(data *SLPTokenData, fields [][]byte) error {
+	if len(fields) != 7 {
+		return invalidSLP("GENESIS with %d fields, want 7", len(fields))
+	}
+
+	data.Ticker = string(fields[0])
+	data.Name = string(fields[1])
+	data.DocumentURL = string(fields[2])
+	if len(fields[3]) != 0 && len(fields[3]) != 32 {
+		return invalidSLP("document hash of %d bytes", len(fields[3]))
+	}
+	if len(fields[3]) != 0 {
+		data.DocumentHash = fields[3]
+	}
+
+	if len(fields[4]) != 1 {
+		return invalidSLP("decimals of %d bytes", len(fields[4]))
+	}
+	data.Decimals = fields[4][0]
+	if data.Decimals > 9 {
+		return invalidSLP("%d decimals", data.Decimals)
+	}
+
+	var err error
+	data.MintBatonVout, err = parseSLPMintBaton(fields[5])
+	if err != nil {
+		return err
+	}
+	quantity, err := parseSLPAmount(fields[6], "initial quantity")
+	if err != nil {
+		return err
+	}
+	data.OutputAmounts = []uint64{0, quantity}
+
+	// NFT1 children are unique, so they are created once and alone.
+	if data.TokenType == SLPTokenTypeNFT1Child {
+		if data.Decimals != 0 || data.MintBatonVout != 0 || quantity != 1 {
+			return invalidSLP("NFT1 child GENESIS must create a " +
+				"single token without decimals or mint baton")
+		}
+	}
+	return nil
+}
+
+// parseSLPMint fills in the passed data from the fields of a MINT message
+// following its transaction type.
+func parseSLPMint(data *SLPTokenData, fields [][]byte) error {
+	if data.TokenType == SLPTokenTypeNFT1Child {
+		return invalidSLP("NFT1 child tokens cannot be minted")
+	}
+	if len(fields) != 3 {
+		return invalidSLP("MINT with %d fields, want 3", len(fields))
+	}
+
+	var err error
+	data.TokenID, err = parseSLPTokenID(fields[0])
+	if err != nil {
+		return err
+	}
+	data.MintBatonVout, err = parseSLPMintBaton(fields[1])
+	if err != nil {
+		return err
+	}
+	quantity, err := parseSLPAmount(fields[2], "additional quantity")
+	if err != nil {
+		return err
+	}
+	data.OutputAmounts = []uint64{0, quantity}
+	return nil
+}
+
+// parseSLPSend fills in the passed data from the fields of a SEND message
+// following its transaction type.
+func parseSLPSend(data *SLPTokenData, fields [][]byte) error {
+	if len(fields) < 2 || len(fields) > slpMaxSendOutputs+1 {
+		return invalidSLP("SEND with %d fields, want 2 to %d",
+			len(fields), slpMaxSendOutputs+1)
+	}
+
+	var err error
+	data.TokenID, err = parseSLPTokenID(fields[0])
+	if err != nil {
+		return err
+	}
+	data.OutputAmounts = make([]uint64, len(fields))
+	for i, field := range fields[1:] {
+		data.OutputAmounts[i+1], err = parseSLPAmount(field,
+			fmt.Sprintf("output %d quantity", i+1))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SLPTxRawResult is a verbose transaction together with the SLP message it
+// carries, if any.
+type SLPTxRawResult struct {
+	btcjson.TxRawResult
+
+	// SLP is the parsed SLP message, or nil for transactions without one.
+	SLP *SLPTokenData
+}
+
+// GetRawTransactionVerboseSLP returns information about a transaction given
+// its hash, like GetRawTransactionVerbose, with its SLP message attached.
+//
+// A transaction whose SLP message cannot be parsed is returned along with the
+// ErrInvalidSLP or ErrUnsupportedSLPTokenType error, so callers may still use
+// the transaction itself.
+func (c *Client) GetRawTransactionVerboseSLP(ctx context.Context, txHash *chainhash.Hash) (*SLPTxRawResult, error) {
+	rawTx, err := c.GetRawTransactionVerbose(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	return attachSLP(rawTx)
+}
+
+// DecodeRawTransactionSLP returns information about a transaction given its
+// serialized bytes, like DecodeRawTransaction, with its SLP message attached.
+//
+// See GetRawTransactionVerboseSLP for how unparsable SLP messages are
+// reported.
+func (c *Client) DecodeRawTransactionSLP(ctx context.Context, serializedTx []byte) (*SLPTxRawResult, error) {
+	rawTx, err := c.DecodeRawTransaction(ctx, serializedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoded transactions carry no hex of their own.
+	if rawTx.Hex == "" {
+		rawTx.Hex = hex.EncodeToString(serializedTx)
+	}
+	return attachSLP(rawTx)
+}
+
+// attachSLP parses the SLP message of the passed verbose transaction.
+func attachSLP(rawTx *btcjson.TxRawResult) (*SLPTxRawResult, error) {
+	result := &SLPTxRawResult{TxRawResult: *rawTx}
+
+	serializedTx, err := hex.DecodeString(rawTx.Hex)
+	if err != nil {
+		return nil, err
+	}
+	var msgTx wire.MsgTx
+	err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, err
+	}
+
+	result.SLP, err = ParseSLP(&msgTx)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
@@ -0,0 +1,352 @@
+package bch_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+)
+
+// slpTestTokenID is the token id used by the MINT and SEND test scripts.
+const slpTestTokenID = "8888888888888888888888888888888888888888888888888888888888888888"
+
+// slpSendScript returns a SEND script for slpTestTokenID with the passed
+// number of token outputs, where output i receives i tokens.
+func slpSendScript(outputs int) string {
+	script := "6a04534c500001010453454e4420" + slpTestTokenID
+	for i := 1; i <= outputs; i++ {
+		script += fmt.Sprintf("08%016x", i)
+	}
+	return script
+}
+
+// slpTestTx returns a transaction whose first output carries the passed
+// hex-encoded script.
+func slpTestTx(t *testing.T, script string) *wire.MsgTx {
+	t.Helper()
+
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		t.Fatalf("bad test script %s: %v", script, err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil))
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	tx.AddTxOut(wire.NewTxOut(546, []byte{0x51}))
+	return tx
+}
+
+func TestParseSLP(t *testing.T) {
+	tokenID, _ := chainhash.NewHashFromStr(slpTestTokenID)
+	otherTokenID, _ := chainhash.NewHashFromStr(
+		"0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	documentHash, _ := hex.DecodeString(
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	send19 := make([]uint64, 20)
+	for i := range send19 {
+		send19[i] = uint64(i)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   *SLPTokenData
+
+		// invalid and unsupported mark scripts which must fail with
+		// ErrInvalidSLP and ErrUnsupportedSLPTokenType.
+		invalid     bool
+		unsupported bool
+	}{
+		// Valid messages.
+		{
+			name:   "minimal GENESIS",
+			script: "6a04534c500001010747454e455349534c004c004c004c0001004c00080000000000000064",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPGenesis,
+				OutputAmounts:   []uint64{0, 100},
+			},
+		},
+		{
+			name: "GENESIS with all fields",
+			script: "6a04534c500001010747454e45534953035453540a5465737420546f6b656e" +
+				"1368747470733a2f2f6578616d706c652e636f6d20000102030405060708" +
+				"090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f0108010208000775f05a074000",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPGenesis,
+				Ticker:          "TST",
+				Name:            "Test Token",
+				DocumentURL:     "https://example.com",
+				DocumentHash:    documentHash,
+				Decimals:        8,
+				MintBatonVout:   2,
+				OutputAmounts:   []uint64{0, 2100000000000000},
+			},
+		},
+		{
+			name:   "GENESIS with two byte token type",
+			script: "6a04534c50000200010747454e455349534c004c004c004c0001004c00080000000000000001",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPGenesis,
+				OutputAmounts:   []uint64{0, 1},
+			},
+		},
+		{
+			name:   "NFT1 child GENESIS",
+			script: "6a04534c500001410747454e455349534c004c004c004c0001004c00080000000000000001",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeNFT1Child,
+				TransactionType: SLPGenesis,
+				OutputAmounts:   []uint64{0, 1},
+			},
+		},
+		{
+			name:   "MINT with baton",
+			script: "6a04534c50000101044d494e5420" + slpTestTokenID + "01020800000000000003e8",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPMint,
+				TokenID:         *tokenID,
+				MintBatonVout:   2,
+				OutputAmounts:   []uint64{0, 1000},
+			},
+		},
+		{
+			name:   "NFT1 group MINT without baton",
+			script: "6a04534c50000181044d494e5420" + slpTestTokenID + "4c00080000000000000005",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeNFT1Group,
+				TransactionType: SLPMint,
+				TokenID:         *tokenID,
+				OutputAmounts:   []uint64{0, 5},
+			},
+		},
+		{
+			name: "SEND to two outputs",
+			script: "6a04534c500001010453454e4420" +
+				"0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20" +
+				"080000000000000042080000000000000000",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPSend,
+				TokenID:         *otherTokenID,
+				OutputAmounts:   []uint64{0, 0x42, 0},
+			},
+		},
+		{
+			name:   "SEND to 19 outputs",
+			script: slpSendScript(19),
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPSend,
+				TokenID:         *tokenID,
+				OutputAmounts:   send19,
+			},
+		},
+		{
+			name:   "lokad id pushed with OP_PUSHDATA1",
+			script: "6a4c04534c500001010453454e4420" + slpTestTokenID + "080000000000000001",
+			want: &SLPTokenData{
+				TokenType:       SLPTokenTypeFungible,
+				TransactionType: SLPSend,
+				TokenID:         *tokenID,
+				OutputAmounts:   []uint64{0, 1},
+			},
+		},
+
+		// Scripts which are not SLP messages at all.
+		{name: "other protocol", script: "6a044558414d01010453454e44"},
+		{name: "not OP_RETURN", script: "76a914000000000000000000000000000000000000000088ac"},
+		{name: "bare OP_RETURN", script: "6a"},
+
+		// Malformed messages.
+		{
+			name:    "OP_0 instead of empty push",
+			script:  "6a04534c500001010747454e45534953004c004c004c0001004c00080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "trailing OP_1",
+			script:  slpSendScript(1) + "51",
+			invalid: true,
+		},
+		{
+			name:    "push past the end",
+			script:  "6a04534c500001010453454e44208888888888888888888888",
+			invalid: true,
+		},
+		{
+			name:    "lokad id only",
+			script:  "6a04534c5000",
+			invalid: true,
+		},
+		{
+			name:    "three byte token type",
+			script:  "6a04534c5000030000010453454e4420" + slpTestTokenID + "080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "lowercase transaction type",
+			script:  "6a04534c500001010473656e6420" + slpTestTokenID + "080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "GENESIS with 10 decimals",
+			script:  "6a04534c500001010747454e455349534c004c004c004c00010a4c00080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "GENESIS with mint baton vout 1",
+			script:  "6a04534c500001010747454e455349534c004c004c004c0001000101080000000000000001",
+			invalid: true,
+		},
+		{
+			name: "GENESIS with 31 byte document hash",
+			script: "6a04534c500001010747454e455349534c004c004c001f000102030405060708" +
+				"090a0b0c0d0e0f101112131415161718191a1b1c1d1e01004c00080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "GENESIS with extra field",
+			script:  "6a04534c500001010747454e455349534c004c004c004c0001004c000800000000000000010100",
+			invalid: true,
+		},
+		{
+			name:    "NFT1 child GENESIS of two tokens",
+			script:  "6a04534c500001410747454e455349534c004c004c004c0001004c00080000000000000002",
+			invalid: true,
+		},
+		{
+			name:    "NFT1 child MINT",
+			script:  "6a04534c50000141044d494e5420" + slpTestTokenID + "4c00080000000000000001",
+			invalid: true,
+		},
+		{
+			name:    "SEND to 20 outputs",
+			script:  slpSendScript(20),
+			invalid: true,
+		},
+		{
+			name:    "SEND without outputs",
+			script:  slpSendScript(0),
+			invalid: true,
+		},
+		{
+			name:    "SEND with seven byte amount",
+			script:  "6a04534c500001010453454e4420" + slpTestTokenID + "0700000000000000",
+			invalid: true,
+		},
+		{
+			name: "SEND with 31 byte token id",
+			script: "6a04534c500001010453454e441f" + slpTestTokenID[:62] +
+				"080000000000000001",
+			invalid: true,
+		},
+		{
+			name:        "unknown token type",
+			script:      "6a04534c500001020453454e4420" + slpTestTokenID + "080000000000000001",
+			unsupported: true,
+		},
+	}
+
+	for _, test := range tests {
+		tx := slpTestTx(t, test.script)
+		data, err := ParseSLP(tx)
+
+		var invalid *ErrInvalidSLP
+		var unsupported *ErrUnsupportedSLPTokenType
+		switch {
+		case test.invalid:
+			if !errors.As(err, &invalid) || data != nil {
+				t.Errorf("%s: got %+v, %v, want ErrInvalidSLP",
+					test.name, data, err)
+			}
+			continue
+
+		case test.unsupported:
+			if !errors.As(err, &unsupported) || data != nil {
+				t.Errorf("%s: got %+v, %v, want "+
+					"ErrUnsupportedSLPTokenType", test.name, data, err)
+			}
+			continue
+
+		case err != nil:
+			t.Errorf("%s: ParseSLP: %v", test.name, err)
+			continue
+		}
+
+		// The token id of GENESIS messages is their transaction hash.
+		if test.want != nil && test.want.TransactionType == SLPGenesis {
+			test.want.TokenID = tx.TxHash()
+		}
+		if !reflect.DeepEqual(data, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, data, test.want)
+		}
+	}
+}
+
+func TestGetRawTransactionVerboseSLP(t *testing.T) {
+	scripts := map[string]string{
+		"mint":    "6a04534c50000101044d494e5420" + slpTestTokenID + "01020800000000000003e8",
+		"invalid": "6a04534c50000101044d494e5420" + slpTestTokenID + "01010800000000000003e8",
+	}
+	txs := make(map[string]*wire.MsgTx)
+	for name, script := range scripts {
+		txs[name] = slpTestTx(t, script)
+	}
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		var txid string
+		json.Unmarshal(req.Params[0], &txid)
+		for _, tx := range txs {
+			if tx.TxHash().String() != txid {
+				continue
+			}
+			var buf bytes.Buffer
+			tx.Serialize(&buf)
+			return btcjson.TxRawResult{
+				Hex:  hex.EncodeToString(buf.Bytes()),
+				Txid: txid,
+			}, nil
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCNoTxInfo,
+			"No information available about transaction")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	ctx := context.Background()
+	mintHash := txs["mint"].TxHash()
+	result, err := client.GetRawTransactionVerboseSLP(ctx, &mintHash)
+	if err != nil {
+		t.Fatalf("GetRawTransactionVerboseSLP: %v", err)
+	}
+	if result.Txid != mintHash.String() || result.SLP == nil ||
+		result.SLP.TransactionType != SLPMint ||
+		!reflect.DeepEqual(result.SLP.OutputAmounts, []uint64{0, 1000}) {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// Malformed SLP messages still return the transaction.
+	invalidHash := txs["invalid"].TxHash()
+	result, err = client.GetRawTransactionVerboseSLP(ctx, &invalidHash)
+	var invalid *ErrInvalidSLP
+	if !errors.As(err, &invalid) || !strings.Contains(err.Error(), "mint baton") {
+		t.Fatalf("expected ErrInvalidSLP, got %v", err)
+	}
+	if result == nil || result.Txid != invalidHash.String() || result.SLP != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
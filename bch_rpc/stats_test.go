@@ -0,0 +1,134 @@
+package bch_rpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gcash/bchd/btcjson"
+)
+
+func TestStats(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblockcount" {
+			time.Sleep(time.Millisecond)
+			return 100, nil
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 4
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.GetBlockCount(context.Background())
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 2; i++ {
+		client.RawRequest(context.Background(), "nosuchmethod", nil)
+	}
+
+	stats := client.Stats()
+	if len(stats.Methods) != 2 {
+		t.Fatalf("stats for %d methods, want 2", len(stats.Methods))
+	}
+	count := stats.Methods["getblockcount"]
+	if count.Requests != 20 || count.Errors != 0 {
+		t.Fatalf("getblockcount: %d requests, %d errors", count.Requests,
+			count.Errors)
+	}
+	if count.P50 < time.Millisecond || count.P95 < count.P50 {
+		t.Fatalf("getblockcount: p50 %v, p95 %v", count.P50, count.P95)
+	}
+	unknown := stats.Methods["nosuchmethod"]
+	if unknown.Requests != 2 || unknown.Errors != 2 {
+		t.Fatalf("nosuchmethod: %d requests, %d errors", unknown.Requests,
+			unknown.Errors)
+	}
+}
+
+func TestStatsReservoir(t *testing.T) {
+	// Only the most recent latencies are kept.
+	var stats methodStats
+	for i := 1; i <= 3*statsReservoirSize; i++ {
+		stats.record(time.Duration(i), nil)
+	}
+	snapshot := stats.snapshot()
+	if snapshot.Requests != 3*statsReservoirSize {
+		t.Fatalf("%d requests, want %d", snapshot.Requests,
+			3*statsReservoirSize)
+	}
+
+	// The reservoir holds the latencies 513 to 768.
+	if snapshot.P50 != 640 || snapshot.P95 != 755 {
+		t.Fatalf("p50 %v, p95 %v", snapshot.P50, snapshot.P95)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		latencies []time.Duration
+		p         float64
+		want      time.Duration
+	}{
+		{nil, 0.5, 0},
+		{[]time.Duration{7}, 0.5, 7},
+		{[]time.Duration{7}, 0.95, 7},
+		{[]time.Duration{1, 2, 3, 4}, 0.5, 2},
+		{[]time.Duration{1, 2, 3, 4}, 0.95, 4},
+		{[]time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0.5, 5},
+		{[]time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 0.95, 10},
+	}
+	for _, test := range tests {
+		if got := percentile(test.latencies, test.p); got != test.want {
+			t.Errorf("percentile(%v, %v) = %v, want %v", test.latencies,
+				test.p, got, test.want)
+		}
+	}
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblock" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return 100, nil
+	})
+	defer server.Close()
+
+	logger := &testLogger{}
+	config := testConnConfig(server)
+	config.Logger = logger
+	config.SlowQueryThreshold = 20 * time.Millisecond
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if logger.contains("WRN Slow request") {
+		t.Fatal("fast request logged as slow")
+	}
+	if _, err := client.RawRequest(context.Background(), "getblock", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	if !logger.contains("WRN Slow request [getblock] took ") {
+		t.Fatalf("slow request not logged: %q", logger.lines)
+	}
+}
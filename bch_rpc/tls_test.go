@@ -0,0 +1,198 @@
+package bch_rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCert is a test certificate along with its private key.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// tlsCertificate returns the certificate in the form used by tls.Config.
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair: %v", err)
+	}
+	return cert
+}
+
+// newTestCert creates a certificate signed by the passed parent, or a
+// self-signed CA certificate when parent is nil.
+func newTestCert(t *testing.T, parent *testCert, template *x509.Certificate) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer,
+		&key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// newMutualTLSServer starts a TLS server which requires a client certificate
+// issued by ca and presents a certificate for the passed addresses.
+func newMutualTLSServer(t *testing.T, ca *testCert, ips []net.IP, names []string) *httptest.Server {
+	serverCert := newTestCert(t, ca, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  ips,
+		DNSNames:     names,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":1,"result":7,"error":null}`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	// Rejected handshakes are expected, so keep them out of the test log.
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	return server
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCert(t, nil, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	client := newTestCert(t, ca, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	// One server presents a certificate valid for the address it is
+	// reached by, the other one for an unrelated name only.
+	matching := newMutualTLSServer(t, ca, []net.IP{net.ParseIP("127.0.0.1")}, nil)
+	defer matching.Close()
+	mismatched := newMutualTLSServer(t, ca, nil, []string{"node.internal"})
+	defer mismatched.Close()
+
+	tests := []struct {
+		name         string
+		server       *httptest.Server
+		clientCert   bool
+		skipHostname bool
+		ok           bool
+	}{
+		{"client certificate", matching, true, false, true},
+		{"no client certificate", matching, false, false, false},
+		{"hostname mismatch", mismatched, true, false, false},
+		{"hostname mismatch skipped", mismatched, true, true, true},
+	}
+	for _, test := range tests {
+		config := &ConnConfig{
+			Host:                       strings.TrimPrefix(test.server.URL, "https://"),
+			HTTPPostMode:               true,
+			Certificates:               ca.certPEM,
+			InsecureSkipVerifyHostname: test.skipHostname,
+		}
+		if test.clientCert {
+			config.ClientCertificate = client.certPEM
+			config.ClientKey = client.keyPEM
+		}
+		c, err := New(config, nil)
+		if err != nil {
+			t.Fatalf("%s: New: %v", test.name, err)
+		}
+		count, err := c.GetBlockCount(context.Background())
+		switch {
+		case test.ok && err != nil:
+			t.Errorf("%s: GetBlockCount: %v", test.name, err)
+		case test.ok && count != 7:
+			t.Errorf("%s: unexpected count %d", test.name, count)
+		case !test.ok && err == nil:
+			t.Errorf("%s: expected TLS error", test.name)
+		}
+		stopClient(c)
+	}
+
+	// A certificate chain from unknown roots must still be rejected when
+	// only the host name check is skipped.
+	other := newTestCert(t, nil, &x509.Certificate{
+		SerialNumber:          big.NewInt(4),
+		Subject:               pkix.Name{CommonName: "other ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	c, err := New(&ConnConfig{
+		Host:                       strings.TrimPrefix(mismatched.URL, "https://"),
+		HTTPPostMode:               true,
+		Certificates:               other.certPEM,
+		ClientCertificate:          client.certPEM,
+		ClientKey:                  client.keyPEM,
+		InsecureSkipVerifyHostname: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(c)
+	if _, err := c.GetBlockCount(context.Background()); err == nil {
+		t.Fatal("expected unknown authority error")
+	}
+
+	// Invalid key material is reported when the client is created.
+	_, err = New(&ConnConfig{
+		Host:              "127.0.0.1:1",
+		HTTPPostMode:      true,
+		ClientCertificate: client.certPEM,
+	}, nil)
+	if err == nil {
+		t.Fatal("expected error for missing client key")
+	}
+}
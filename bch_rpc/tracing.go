@@ -0,0 +1,50 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+)
+
+const (
+	// QueueSpanName is the name of the span covering the time an HTTP POST
+	// mode request waits for a worker to send it.
+	QueueSpanName = "queue"
+
+	// RoundTripSpanName is the name of the span covering a single HTTP
+	// round trip of an HTTP POST mode request.  Retried requests have one
+	// for every attempt.
+	RoundTripSpanName = "http"
+)
+
+// FinishSpan ends a span started by a Tracer, tagging it with the passed error
+// when it is not nil.
+type FinishSpan func(err error)
+
+// Tracer is implemented by types which want every JSON-RPC call to show up as
+// a span in a distributed trace, such as an adapter to OpenTelemetry.
+//
+// StartSpan starts a span with the passed name as a child of the span carried
+// by the passed context, if any, and returns a context carrying the new span
+// along with the function ending it.  Every call gets a span named after its
+// method which covers its whole lifetime, from being issued until its result
+// is delivered.  In HTTP POST mode it has a QueueSpanName child covering the
+// wait for a worker and a RoundTripSpanName child for every HTTP round trip.
+// The context of the round trip span is the context of the HTTP request, so
+// an instrumented RoundTripper can propagate the trace to the server.
+//
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, FinishSpan)
+}
+
+// startSpan starts a span with the passed name when a tracer is configured.
+// The returned function is nil otherwise.
+func (c *Client) startSpan(ctx context.Context, name string) (context.Context, FinishSpan) {
+	if c.config.Tracer == nil {
+		return ctx, nil
+	}
+	return c.config.Tracer.StartSpan(ctx, name)
+}
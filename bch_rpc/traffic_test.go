@@ -0,0 +1,119 @@
+package bch_rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// trafficRecord is a call passed to a TrafficRecorder.
+type trafficRecord struct {
+	method   string
+	request  []byte
+	response []byte
+	err      error
+}
+
+func TestTrafficRecorder(t *testing.T) {
+	server, _ := newFlakyServer(1, unavailable)
+	defer server.Close()
+
+	var mtx sync.Mutex
+	var records []trafficRecord
+	config := testConnConfig(server)
+	config.Pass = "secret"
+	config.RetryPolicy = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	config.TrafficRecorder = func(method string, request, response []byte, duration time.Duration, err error) {
+		mtx.Lock()
+		records = append(records, trafficRecord{method, request, response, err})
+		mtx.Unlock()
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+
+	// Both attempts are recorded, the failed one with its error.
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(records))
+	}
+	for i, record := range records {
+		if record.method != "getblockcount" ||
+			!bytes.Contains(record.request, []byte(`"method":"getblockcount"`)) {
+
+			t.Fatalf("call %d recorded as %s: %s", i, record.method,
+				record.request)
+		}
+		if bytes.Contains(record.request, []byte("secret")) {
+			t.Fatalf("call %d recorded the credentials", i)
+		}
+	}
+	if _, ok := records[0].err.(*HTTPError); !ok ||
+		!bytes.Contains(records[0].response, []byte("reindexing")) {
+
+		t.Fatalf("failed attempt recorded as %q: %v", records[0].response,
+			records[0].err)
+	}
+	if records[1].err != nil ||
+		!bytes.Contains(records[1].response, []byte(`"result":100`)) {
+
+		t.Fatalf("successful attempt recorded as %q: %v",
+			records[1].response, records[1].err)
+	}
+}
+
+func TestJSONTrafficRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewJSONTrafficRecorder(&buf)
+	recorder("getblockcount", []byte(`{"id":1}`), []byte(`{"result":100}`),
+		1500*time.Microsecond, nil)
+	recorder("getblockcount", []byte(`{"id":2}`), []byte("<html>bad gateway</html>"),
+		time.Millisecond, &HTTPError{StatusCode: 502})
+	recorder("getblockcount", []byte(`{"id":3}`), nil, time.Millisecond,
+		context.DeadlineExceeded)
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(strings.NewReader(buf.String()))
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("wrote %d lines, want 3", len(entries))
+	}
+
+	first := entries[0]
+	if first["method"] != "getblockcount" || first["duration_ms"] != 1.5 ||
+		first["response"].(map[string]interface{})["result"] != 100.0 {
+
+		t.Fatalf("unexpected first line %v", first)
+	}
+	if _, ok := first["error"]; ok {
+		t.Fatalf("successful call written with an error: %v", first)
+	}
+	if entries[1]["response"] != "<html>bad gateway</html>" ||
+		entries[1]["error"] == nil {
+
+		t.Fatalf("unexpected second line %v", entries[1])
+	}
+	if entries[2]["response"] != nil ||
+		entries[2]["error"] != context.DeadlineExceeded.Error() {
+
+		t.Fatalf("unexpected third line %v", entries[2])
+	}
+}
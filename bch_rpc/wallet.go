@@ -0,0 +1,634 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bch_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchd/wire"
+	"github.com/gcash/bchutil"
+)
+
+// ListUnspentResult models an unspent output of the data returned by the
+// listunspent command.  Unlike btcjson.ListUnspentResult, it has the solvable
+// field and keeps the amount as sent by the node, so AmountSatoshi converts it
+// exactly.
+type ListUnspentResult struct {
+	TxID          string      `json:"txid"`
+	Vout          uint32      `json:"vout"`
+	Address       string      `json:"address"`
+	Label         string      `json:"label,omitempty"`
+	ScriptPubKey  string      `json:"scriptPubKey"`
+	RedeemScript  string      `json:"redeemScript,omitempty"`
+	Amount        json.Number `json:"amount"`
+	Confirmations int64       `json:"confirmations"`
+	Spendable     bool        `json:"spendable"`
+	Solvable      bool        `json:"solvable"`
+	Safe          bool        `json:"safe"`
+}
+
+// AmountSatoshi returns the amount of the output in satoshis.
+func (r *ListUnspentResult) AmountSatoshi() (bchutil.Amount, error) {
+	sat, err := ParseAmountSat(r.Amount)
+	if err != nil {
+		return 0, err
+	}
+	return bchutil.Amount(sat), nil
+}
+
+// OutPoint returns the outpoint of the output.
+func (r *ListUnspentResult) OutPoint() (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(r.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewOutPoint(hash, r.Vout), nil
+}
+
+// FutureListUnspentResult is a future promise to deliver the result of a
+// ListUnspentAsync, ListUnspentMinAsync, ListUnspentMinMaxAsync, or
+// ListUnspentMinMaxAddressesAsync RPC invocation (or an applicable error).
+type FutureListUnspentResult chan *response
+
+// Receive waits for the response promised by the future and returns all
+// unspent wallet transaction outputs returned by the RPC call.  If the
+// future was returned by a call to ListUnspentMinAsync, ListUnspentMinMaxAsync,
+// or ListUnspentMinMaxAddressesAsync, the range may be limited by the
+// parameters of the RPC invocation.
+func (r FutureListUnspentResult) Receive() ([]ListUnspentResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listunspent results.
+	var unspent []ListUnspentResult
+	err = json.Unmarshal(res, &unspent)
+	if err != nil {
+		return nil, err
+	}
+
+	return unspent, nil
+}
+
+// ListUnspentAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListUnspent for the blocking version and more details.
+func (c *Client) ListUnspentAsync(ctx context.Context) FutureListUnspentResult {
+	cmd := btcjson.NewListUnspentCmd(nil, nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListUnspentMinAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListUnspentMin for the blocking version and more details.
+func (c *Client) ListUnspentMinAsync(ctx context.Context, minConf int) FutureListUnspentResult {
+	cmd := btcjson.NewListUnspentCmd(&minConf, nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListUnspentMinMaxAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListUnspentMinMax for the blocking version and more details.
+func (c *Client) ListUnspentMinMaxAsync(ctx context.Context, minConf, maxConf int) FutureListUnspentResult {
+	cmd := btcjson.NewListUnspentCmd(&minConf, &maxConf, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListUnspentMinMaxAddressesAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ListUnspentMinMaxAddresses for the blocking version and more details.
+func (c *Client) ListUnspentMinMaxAddressesAsync(ctx context.Context, minConf, maxConf int, addrs []bchutil.Address) FutureListUnspentResult {
+	addrStrs := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		addrStrs = append(addrStrs, encodeCashAddress(a))
+	}
+
+	cmd := btcjson.NewListUnspentCmd(&minConf, &maxConf, &addrStrs)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListUnspent returns all unspent transaction outputs known to a wallet, using
+// the default number of minimum and maximum number of confirmations as a
+// filter (1 and 9999999, respectively).
+func (c *Client) ListUnspent(ctx context.Context) ([]ListUnspentResult, error) {
+	return c.ListUnspentAsync(ctx).Receive()
+}
+
+// ListUnspentMin returns all unspent transaction outputs known to a wallet,
+// using the specified number of minimum confirmations and default number of
+// maximum confirmations (9999999) as a filter.
+func (c *Client) ListUnspentMin(ctx context.Context, minConf int) ([]ListUnspentResult, error) {
+	return c.ListUnspentMinAsync(ctx, minConf).Receive()
+}
+
+// ListUnspentMinMax returns all unspent transaction outputs known to a wallet,
+// using the specified number of minimum and maximum number of confirmations as
+// a filter.
+func (c *Client) ListUnspentMinMax(ctx context.Context, minConf, maxConf int) ([]ListUnspentResult, error) {
+	return c.ListUnspentMinMaxAsync(ctx, minConf, maxConf).Receive()
+}
+
+// ListUnspentMinMaxAddresses returns all unspent transaction outputs that pay
+// to any of specified addresses in a wallet using the specified number of
+// minimum and maximum number of confirmations as a filter.  The addresses are
+// sent to the node in cashaddr format, so legacy addresses are accepted as
+// well.
+func (c *Client) ListUnspentMinMaxAddresses(ctx context.Context, minConf, maxConf int, addrs []bchutil.Address) ([]ListUnspentResult, error) {
+	return c.ListUnspentMinMaxAddressesAsync(ctx, minConf, maxConf, addrs).Receive()
+}
+
+// unmarshalAmount unmarshals a result holding an amount in BCH and converts it
+// to satoshis exactly.
+func unmarshalAmount(res []byte) (bchutil.Amount, error) {
+	var amount json.Number
+	if err := decodeJSON(res, &amount); err != nil {
+		return 0, err
+	}
+	sat, err := ParseAmountSat(amount)
+	if err != nil {
+		return 0, err
+	}
+	return bchutil.Amount(sat), nil
+}
+
+// SendToAddressOpts holds the options of SendToAddress.
+type SendToAddressOpts struct {
+	// Comment is stored in the wallet with the transaction.  It is not
+	// part of the transaction.
+	Comment string
+
+	// CommentTo names the recipient of the transaction and is stored in
+	// the wallet like Comment.
+	CommentTo string
+
+	// SubtractFeeFromAmount deducts the fee from the amount sent, so the
+	// recipient receives less than the amount instead of the wallet paying
+	// the fee on top.
+	SubtractFeeFromAmount bool
+}
+
+// FutureSendToAddressResult is a future promise to deliver the result of a
+// SendToAddressAsync RPC invocation (or an applicable error).
+type FutureSendToAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the hash
+// of the transaction sending the passed amount to the given address.
+func (r FutureSendToAddressResult) Receive() (*chainhash.Hash, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var txHash string
+	err = json.Unmarshal(res, &txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return chainhash.NewHashFromStr(txHash)
+}
+
+// SendToAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SendToAddress for the blocking version and more details.
+func (c *Client) SendToAddressAsync(ctx context.Context, address bchutil.Address, amount bchutil.Amount, opts SendToAddressOpts) FutureSendToAddressResult {
+	// The btcjson command knows no subtractfeefromamount and would send
+	// the amount as a float64, so the call is sent as a raw request.
+	params := []interface{}{encodeCashAddress(address), formatAmount(amount)}
+	if opts.Comment != "" || opts.CommentTo != "" || opts.SubtractFeeFromAmount {
+		params = append(params, opts.Comment, opts.CommentTo)
+	}
+	if opts.SubtractFeeFromAmount {
+		params = append(params, true)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendToAddressResult(c.RawRequestAsync(ctx,
+		"sendtoaddress", rawParams))
+}
+
+// SendToAddress sends the passed amount to the given address from the wallet
+// and returns the hash of the transaction.  The amount is sent to the node as
+// an exact decimal number, so it is not subject to float rounding.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) SendToAddress(ctx context.Context, address bchutil.Address, amount bchutil.Amount, opts SendToAddressOpts) (*chainhash.Hash, error) {
+	return c.SendToAddressAsync(ctx, address, amount, opts).Receive()
+}
+
+// FutureGetBalanceResult is a future promise to deliver the result of a
+// GetBalanceAsync, GetBalanceMinConfAsync or GetReceivedByAddressAsync RPC
+// invocation (or an applicable error).
+type FutureGetBalanceResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// amount returned by the server.
+func (r FutureGetBalanceResult) Receive() (bchutil.Amount, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return unmarshalAmount(res)
+}
+
+// GetBalanceAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBalance for the blocking version and more details.
+func (c *Client) GetBalanceAsync(ctx context.Context) FutureGetBalanceResult {
+	cmd := btcjson.NewGetBalanceCmd(nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetBalanceMinConfAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetBalanceMinConf for the blocking version and more details.
+func (c *Client) GetBalanceMinConfAsync(ctx context.Context, minConf int) FutureGetBalanceResult {
+	// Nodes require the dummy account "*" ahead of the confirmations.
+	cmd := btcjson.NewGetBalanceCmd(btcjson.String("*"), &minConf)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetBalance returns the available balance of the wallet, using the default
+// number of minimum confirmations.
+func (c *Client) GetBalance(ctx context.Context) (bchutil.Amount, error) {
+	return c.GetBalanceAsync(ctx).Receive()
+}
+
+// GetBalanceMinConf returns the balance of the wallet counting only outputs
+// with at least the specified number of confirmations.
+func (c *Client) GetBalanceMinConf(ctx context.Context, minConf int) (bchutil.Amount, error) {
+	return c.GetBalanceMinConfAsync(ctx, minConf).Receive()
+}
+
+// FutureGetNewAddressResult is a future promise to deliver the result of a
+// GetNewAddressAsync RPC invocation (or an applicable error).
+type FutureGetNewAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns a new
+// address.
+func (r FutureGetNewAddressResult) Receive() (bchutil.Address, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var addr string
+	err = json.Unmarshal(res, &addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAddress(addr)
+}
+
+// GetNewAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetNewAddress for the blocking version and more details.
+func (c *Client) GetNewAddressAsync(ctx context.Context, label string) FutureGetNewAddressResult {
+	cmd := btcjson.NewGetNewAddressCmd(&label)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetNewAddress returns a new address of the wallet, stored with the passed
+// label, which may be empty.  Addresses of testnet and regtest wallets which
+// the node returns in legacy format can not be told apart and are decoded for
+// testnet.
+func (c *Client) GetNewAddress(ctx context.Context, label string) (bchutil.Address, error) {
+	return c.GetNewAddressAsync(ctx, label).Receive()
+}
+
+// GetReceivedByAddressAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetReceivedByAddress for the blocking version and more details.
+func (c *Client) GetReceivedByAddressAsync(ctx context.Context, address bchutil.Address, minConf int) FutureGetBalanceResult {
+	cmd := btcjson.NewGetReceivedByAddressCmd(encodeCashAddress(address), &minConf)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetReceivedByAddress returns the total amount received by the passed address
+// of the wallet in transactions with at least the specified number of
+// confirmations.
+func (c *Client) GetReceivedByAddress(ctx context.Context, address bchutil.Address, minConf int) (bchutil.Amount, error) {
+	return c.GetReceivedByAddressAsync(ctx, address, minConf).Receive()
+}
+
+// FutureListTransactionsResult is a future promise to deliver the result of a
+// ListTransactionsAsync or ListTransactionsCountFromAsync RPC invocation (or an
+// applicable error).
+type FutureListTransactionsResult chan *response
+
+// Receive waits for the response promised by the future and returns a list of
+// the most recent transactions of the wallet.
+func (r FutureListTransactionsResult) Receive() ([]btcjson.ListTransactionsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listtransaction result objects.
+	var transactions []btcjson.ListTransactionsResult
+	err = json.Unmarshal(res, &transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// ListTransactionsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ListTransactions for the blocking version and more details.
+func (c *Client) ListTransactionsAsync(ctx context.Context) FutureListTransactionsResult {
+	cmd := btcjson.NewListTransactionsCmd(nil, nil, nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListTransactionsCountFromAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ListTransactionsCountFrom for the blocking version and more details.
+func (c *Client) ListTransactionsCountFromAsync(ctx context.Context, count, from int) FutureListTransactionsResult {
+	// Nodes require the dummy label "*" ahead of the count.
+	cmd := btcjson.NewListTransactionsCmd(btcjson.String("*"), &count, &from, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListTransactions returns the 10 most recent transactions of the wallet.
+//
+// The amounts and fees of the transactions are in BCH and may be converted
+// with bchutil.NewAmount.
+func (c *Client) ListTransactions(ctx context.Context) ([]btcjson.ListTransactionsResult, error) {
+	return c.ListTransactionsAsync(ctx).Receive()
+}
+
+// ListTransactionsCountFrom returns at most count of the most recent
+// transactions of the wallet, skipping the first from transactions.
+func (c *Client) ListTransactionsCountFrom(ctx context.Context, count, from int) ([]btcjson.ListTransactionsResult, error) {
+	return c.ListTransactionsCountFromAsync(ctx, count, from).Receive()
+}
+
+// ErrNotWalletTransaction describes the condition where the transaction asked
+// for with GetTransaction is not known to the wallet of the node, or its hash
+// is malformed.
+type ErrNotWalletTransaction struct {
+	// Err is the error returned by the node.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrNotWalletTransaction) Error() string {
+	return fmt.Sprintf("not a wallet transaction: %v", e.Err)
+}
+
+// Unwrap returns the error returned by the node.
+func (e *ErrNotWalletTransaction) Unwrap() error {
+	return e.Err
+}
+
+// FutureGetTransactionResult is a future promise to deliver the result of a
+// GetTransactionAsync RPC invocation (or an applicable error).
+type FutureGetTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns detailed
+// information about a wallet transaction.
+func (r FutureGetTransactionResult) Receive() (*btcjson.GetTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		// Wallets report unknown transactions as invalid ids.
+		var rpcErr *btcjson.RPCError
+		if errors.As(err, &rpcErr) &&
+			rpcErr.Code == btcjson.ErrRPCInvalidAddressOrKey {
+
+			return nil, &ErrNotWalletTransaction{Err: err}
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a gettransaction result object.
+	var getTx btcjson.GetTransactionResult
+	err = json.Unmarshal(res, &getTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &getTx, nil
+}
+
+// GetTransactionAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetTransaction for the blocking version and more details.
+func (c *Client) GetTransactionAsync(ctx context.Context, txHash *chainhash.Hash, includeWatchOnly bool) FutureGetTransactionResult {
+	hash := ""
+	if txHash != nil {
+		hash = txHash.String()
+	}
+
+	cmd := btcjson.NewGetTransactionCmd(hash, &includeWatchOnly)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetTransaction returns detailed information about a wallet transaction.
+// Unlike GetRawTransactionVerbose it does not require the node to run with a
+// transaction index, but only knows transactions involving the wallet.  Other
+// transactions fail with an ErrNotWalletTransaction.
+//
+// The amounts and fees of the result are in BCH.  TransactionAmounts and
+// TransactionDetailAmounts convert them to bchutil.Amount.
+func (c *Client) GetTransaction(ctx context.Context, txHash *chainhash.Hash, includeWatchOnly bool) (*btcjson.GetTransactionResult, error) {
+	return c.GetTransactionAsync(ctx, txHash, includeWatchOnly).Receive()
+}
+
+// TransactionAmounts returns the net amount and fee of a wallet transaction
+// returned by GetTransaction.  The amount is negative for transactions
+// spending from the wallet, and the fee is negative or zero: wallets only
+// report the fee of transactions they paid for.
+func TransactionAmounts(tx *btcjson.GetTransactionResult) (amount, fee bchutil.Amount, err error) {
+	amount, err = bchutil.NewAmount(tx.Amount)
+	if err != nil {
+		return 0, 0, err
+	}
+	fee, err = bchutil.NewAmount(tx.Fee)
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount, fee, nil
+}
+
+// TransactionDetailAmounts returns the amount and fee of an entry of the
+// details of a wallet transaction returned by GetTransaction.  The fee is only
+// reported for the send entries of transactions paid for by the wallet and is
+// zero otherwise.
+func TransactionDetailAmounts(detail *btcjson.GetTransactionDetailsResult) (amount, fee bchutil.Amount, err error) {
+	amount, err = bchutil.NewAmount(detail.Amount)
+	if err != nil {
+		return 0, 0, err
+	}
+	if detail.Fee != nil {
+		fee, err = bchutil.NewAmount(*detail.Fee)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return amount, fee, nil
+}
+
+// FutureImportAddressResult is a future promise to deliver the result of an
+// ImportAddressAsync or ImportAddressScriptAsync RPC invocation (or an
+// applicable error).
+type FutureImportAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// of importing the passed public address.
+func (r FutureImportAddressResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ImportAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ImportAddress for the blocking version and more details.
+func (c *Client) ImportAddressAsync(ctx context.Context, address bchutil.Address, label string, rescan bool) FutureImportAddressResult {
+	// The btcjson command names the label an account, so the call is sent
+	// as a raw request with every parameter in the order nodes expect.
+	params := []interface{}{encodeCashAddress(address), label, rescan}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureImportAddressResult(c.RawRequestAsync(ctx,
+		"importaddress", rawParams))
+}
+
+// ImportAddress imports the passed address into the wallet as watch-only,
+// stored with the passed label, which may be empty.
+//
+// With rescan set, the node scans the whole chain for transactions of the
+// address before it replies, which can take many minutes.  The import
+// proceeds on the node even when the request times out or its context is
+// canceled on the client side, so a timeout does not mean the import failed.
+// Use ImportAddressAsync with a context without deadline, or import without
+// rescan and rescan once after importing many addresses.
+func (c *Client) ImportAddress(ctx context.Context, address bchutil.Address, label string, rescan bool) error {
+	return c.ImportAddressAsync(ctx, address, label, rescan).Receive()
+}
+
+// ImportAddressScriptAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ImportAddressScript for the blocking version and more details.
+func (c *Client) ImportAddressScriptAsync(ctx context.Context, script []byte, label string, rescan, p2sh bool) FutureImportAddressResult {
+	params := []interface{}{hex.EncodeToString(script), label, rescan, p2sh}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureImportAddressResult(c.RawRequestAsync(ctx,
+		"importaddress", rawParams))
+}
+
+// ImportAddressScript imports the passed raw output script into the wallet as
+// watch-only, like ImportAddress.  With p2sh set, the script is treated as a
+// redeem script and the P2SH address paying to it is watched as well.
+//
+// See ImportAddress for the effect of rescan on the duration of the request.
+func (c *Client) ImportAddressScript(ctx context.Context, script []byte, label string, rescan, p2sh bool) error {
+	return c.ImportAddressScriptAsync(ctx, script, label, rescan, p2sh).Receive()
+}
+
+// FutureImportPrivKeyResult is a future promise to deliver the result of an
+// ImportPrivKeyAsync or ImportPrivKeyStringAsync RPC invocation (or an
+// applicable error).
+type FutureImportPrivKeyResult chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// of importing the passed private key which must be the wallet import format
+// (WIF).
+func (r FutureImportPrivKeyResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ImportPrivKeyAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ImportPrivKey for the blocking version and more details.
+func (c *Client) ImportPrivKeyAsync(ctx context.Context, privKeyWIF *bchutil.WIF, label string, rescan bool) FutureImportPrivKeyResult {
+	wif := ""
+	if privKeyWIF != nil {
+		wif = privKeyWIF.String()
+	}
+	return c.ImportPrivKeyStringAsync(ctx, wif, label, rescan)
+}
+
+// ImportPrivKey imports the passed private key into the wallet, stored with
+// the passed label, which may be empty.
+//
+// See ImportAddress for the effect of rescan on the duration of the request.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) ImportPrivKey(ctx context.Context, privKeyWIF *bchutil.WIF, label string, rescan bool) error {
+	return c.ImportPrivKeyAsync(ctx, privKeyWIF, label, rescan).Receive()
+}
+
+// ImportPrivKeyStringAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ImportPrivKeyString for the blocking version and more details.
+func (c *Client) ImportPrivKeyStringAsync(ctx context.Context, privKeyWIF string, label string, rescan bool) FutureImportPrivKeyResult {
+	params := []interface{}{privKeyWIF, label, rescan}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureImportPrivKeyResult(c.RawRequestAsync(ctx,
+		"importprivkey", rawParams))
+}
+
+// ImportPrivKeyString imports the passed private key in wallet import format
+// (WIF) into the wallet, like ImportPrivKey.  The key is passed to the node as
+// is.
+func (c *Client) ImportPrivKeyString(ctx context.Context, privKeyWIF string, label string, rescan bool) error {
+	return c.ImportPrivKeyStringAsync(ctx, privKeyWIF, label, rescan).Receive()
+}
@@ -0,0 +1,595 @@
+package bch_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gcash/bchd/btcjson"
+	"github.com/gcash/bchd/chaincfg"
+	"github.com/gcash/bchd/chaincfg/chainhash"
+	"github.com/gcash/bchutil"
+)
+
+// listUnspentReply is a listunspent reply of a Bitcoin ABC node.
+const listUnspentReply = `[
+	{
+		"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		"vout": 1,
+		"address": "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		"label": "",
+		"scriptPubKey": "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac",
+		"amount": 0.12345678,
+		"confirmations": 6,
+		"spendable": true,
+		"solvable": true,
+		"safe": true
+	},
+	{
+		"txid": "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098",
+		"vout": 0,
+		"address": "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq",
+		"scriptPubKey": "a91476a04053bda0a88bda5177b86a15c3b29f55987387",
+		"redeemScript": "51",
+		"amount": 21000000,
+		"confirmations": 0,
+		"spendable": false,
+		"solvable": false,
+		"safe": false
+	}
+]`
+
+func TestListUnspent(t *testing.T) {
+	var mtx sync.Mutex
+	var params [][]json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = append(params, req.Params)
+		mtx.Unlock()
+		return json.RawMessage(listUnspentReply), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	unspent, err := client.ListUnspent(context.Background())
+	if err != nil {
+		t.Fatalf("ListUnspent: %v", err)
+	}
+	if len(unspent) != 2 {
+		t.Fatalf("%d unspent outputs, want 2", len(unspent))
+	}
+	first, second := unspent[0], unspent[1]
+	if first.Vout != 1 || first.Confirmations != 6 || !first.Spendable ||
+		!first.Solvable || first.ScriptPubKey != "76a91476a04053bda0a88bda5177b86a15c3b29f55987388ac" {
+
+		t.Fatalf("unexpected first output %+v", first)
+	}
+	if second.Spendable || second.Solvable || second.RedeemScript != "51" {
+		t.Fatalf("unexpected second output %+v", second)
+	}
+
+	// The amounts convert to satoshis without rounding errors.
+	amounts := []bchutil.Amount{12345678, 21000000 * bchutil.SatoshiPerBitcoin}
+	for i, utxo := range unspent {
+		amount, err := utxo.AmountSatoshi()
+		if err != nil {
+			t.Fatalf("AmountSatoshi: %v", err)
+		}
+		if amount != amounts[i] {
+			t.Fatalf("output %d: amount %v, want %v", i, amount, amounts[i])
+		}
+	}
+
+	if _, err := client.ListUnspentMin(context.Background(), 3); err != nil {
+		t.Fatalf("ListUnspentMin: %v", err)
+	}
+	if _, err := client.ListUnspentMinMax(context.Background(), 3, 100); err != nil {
+		t.Fatalf("ListUnspentMinMax: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{`[]`, `[3]`, `[3,100]`}
+	for i, p := range params {
+		if got, _ := json.Marshal(p); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestListUnspentAddresses(t *testing.T) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return json.RawMessage(`[]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// Legacy addresses are sent in cashaddr format.
+	var addrs []bchutil.Address
+	for _, s := range []string{
+		"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		"3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC",
+		"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+	} {
+		addr, err := bchutil.DecodeAddress(s, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("DecodeAddress(%s): %v", s, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	unspent, err := client.ListUnspentMinMaxAddresses(context.Background(), 1, 10, addrs)
+	if err != nil {
+		t.Fatalf("ListUnspentMinMaxAddresses: %v", err)
+	}
+	if len(unspent) != 0 {
+		t.Fatalf("unexpected unspent outputs %v", unspent)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := `[1,10,["bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",` +
+		`"bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq",` +
+		`"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"]]`
+	if got, _ := json.Marshal(params); string(got) != want {
+		t.Fatalf("sent params %s, want %s", got, want)
+	}
+}
+
+func TestListUnspentInvalidReply(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`{"txid":"abc"}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	if _, err := client.ListUnspent(context.Background()); err == nil {
+		t.Fatal("expected an error for a reply which is not an array")
+	}
+}
+
+func TestSendToAddress(t *testing.T) {
+	const txHash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	var mtx sync.Mutex
+	var params [][]json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = append(params, req.Params)
+		mtx.Unlock()
+		return txHash, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	addr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+
+	// The amounts are sent exactly, neither rounded nor in exponent
+	// notation, from a single satoshi to the whole supply.
+	calls := []struct {
+		amount bchutil.Amount
+		opts   SendToAddressOpts
+	}{
+		{1, SendToAddressOpts{}},
+		{21000000 * bchutil.SatoshiPerBitcoin, SendToAddressOpts{}},
+		{21000000*bchutil.SatoshiPerBitcoin - 1, SendToAddressOpts{Comment: "payout"}},
+		{12345678, SendToAddressOpts{SubtractFeeFromAmount: true}},
+	}
+	for _, call := range calls {
+		hash, err := client.SendToAddress(context.Background(), addr,
+			call.amount, call.opts)
+		if err != nil {
+			t.Fatalf("SendToAddress: %v", err)
+		}
+		if hash.String() != txHash {
+			t.Fatalf("unexpected hash %v", hash)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	const cashAddr = `"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"`
+	want := []string{
+		`[` + cashAddr + `,0.00000001]`,
+		`[` + cashAddr + `,21000000]`,
+		`[` + cashAddr + `,20999999.99999999,"payout",""]`,
+		`[` + cashAddr + `,0.12345678,"","",true]`,
+	}
+	for i, p := range params {
+		if got, _ := json.Marshal(p); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestGetBalance(t *testing.T) {
+	var mtx sync.Mutex
+	var methods []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		methods = append(methods, req.Method)
+		mtx.Unlock()
+		return json.RawMessage(`20999999.99999999`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	// The amounts are converted to satoshis exactly, although a float64
+	// can not hold them.
+	const want = 21000000*bchutil.SatoshiPerBitcoin - 1
+	balance, err := client.GetBalance(context.Background())
+	if err != nil || balance != want {
+		t.Fatalf("GetBalance = %d, %v", balance, err)
+	}
+	balance, err = client.GetBalanceMinConf(context.Background(), 6)
+	if err != nil || balance != want {
+		t.Fatalf("GetBalanceMinConf = %d, %v", balance, err)
+	}
+	addr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	received, err := client.GetReceivedByAddress(context.Background(), addr, 1)
+	if err != nil || received != want {
+		t.Fatalf("GetReceivedByAddress = %d, %v", received, err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	wantMethods := []string{"getbalance", "getbalance", "getreceivedbyaddress"}
+	for i, method := range methods {
+		if method != wantMethods[i] {
+			t.Errorf("call %d sent %s, want %s", i, method, wantMethods[i])
+		}
+	}
+}
+
+func TestGetNewAddress(t *testing.T) {
+	replies := []string{
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+		"bchtest:qp63uahgrxged4z5jswyt5dn5v3lzsem6cq85x00dt",
+		"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+	}
+	var mtx sync.Mutex
+	var next int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		reply := replies[next]
+		next++
+		return reply, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	nets := []*chaincfg.Params{
+		&chaincfg.MainNetParams,
+		&chaincfg.TestNet3Params,
+		&chaincfg.MainNetParams,
+	}
+	for i, net := range nets {
+		addr, err := client.GetNewAddress(context.Background(), "deposits")
+		if err != nil {
+			t.Fatalf("GetNewAddress: %v", err)
+		}
+		if !addr.IsForNet(net) {
+			t.Fatalf("address %d is not for %s", i, net.Name)
+		}
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return json.RawMessage(`[{
+			"address": "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+			"category": "receive",
+			"amount": 0.00000001,
+			"vout": 0,
+			"confirmations": 3,
+			"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			"time": 1231006505,
+			"timereceived": 1231006505
+		}]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	transactions, err := client.ListTransactionsCountFrom(context.Background(), 50, 100)
+	if err != nil {
+		t.Fatalf("ListTransactionsCountFrom: %v", err)
+	}
+	if len(transactions) != 1 {
+		t.Fatalf("%d transactions, want 1", len(transactions))
+	}
+	tx := transactions[0]
+	if _, err := chainhash.NewHashFromStr(tx.TxID); err != nil {
+		t.Fatalf("unexpected txid %s", tx.TxID)
+	}
+	amount, err := bchutil.NewAmount(tx.Amount)
+	if err != nil || amount != 1 || tx.Category != "receive" {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if got, _ := json.Marshal(params); string(got) != `["*",50,100]` {
+		t.Fatalf("sent params %s", got)
+	}
+}
+
+func TestImport(t *testing.T) {
+	var mtx sync.Mutex
+	var requests []*testRequest
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests = append(requests, req)
+		mtx.Unlock()
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	ctx := context.Background()
+	addr, err := bchutil.DecodeAddress("1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	if err := client.ImportAddress(ctx, addr, "deposits", false); err != nil {
+		t.Fatalf("ImportAddress: %v", err)
+	}
+	if err := client.ImportAddressScript(ctx, []byte{0x51}, "", true, true); err != nil {
+		t.Fatalf("ImportAddressScript: %v", err)
+	}
+	const wifStr = "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"
+	wif, err := bchutil.DecodeWIF(wifStr)
+	if err != nil {
+		t.Fatalf("DecodeWIF: %v", err)
+	}
+	if err := client.ImportPrivKey(ctx, wif, "hot", true); err != nil {
+		t.Fatalf("ImportPrivKey: %v", err)
+	}
+	if err := client.ImportPrivKeyString(ctx, wifStr, "", false); err != nil {
+		t.Fatalf("ImportPrivKeyString: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []struct {
+		method string
+		params string
+	}{
+		{"importaddress", `["bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a","deposits",false]`},
+		{"importaddress", `["51","",true,true]`},
+		{"importprivkey", `["` + wifStr + `","hot",true]`},
+		{"importprivkey", `["` + wifStr + `","",false]`},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("%d requests, want %d", len(requests), len(want))
+	}
+	for i, req := range requests {
+		params, _ := json.Marshal(req.Params)
+		if req.Method != want[i].method || string(params) != want[i].params {
+			t.Errorf("call %d sent %s %s, want %s %s", i, req.Method,
+				params, want[i].method, want[i].params)
+		}
+	}
+}
+
+// The gettransaction fixtures are replies of a Bitcoin Cash Node wallet for a
+// deposit it received, a payment it sent and a transfer to one of its own
+// addresses.
+const (
+	getTransactionReceive = `{
+		"amount": 0.5,
+		"confirmations": 3,
+		"blockhash": "000000000000000001b6ce6b7b2bbe4d4e8abb8bbce5b98e8b1f1b5b0a9a2e10",
+		"blockindex": 7,
+		"blocktime": 1600000000,
+		"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		"walletconflicts": [],
+		"time": 1599999990,
+		"timereceived": 1599999990,
+		"details": [
+			{
+				"address": "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+				"category": "receive",
+				"amount": 0.5,
+				"label": "deposits",
+				"vout": 1
+			}
+		],
+		"hex": "0100000000000000000000"
+	}`
+
+	getTransactionSend = `{
+		"amount": -0.12345678,
+		"fee": -0.00000226,
+		"confirmations": 0,
+		"trusted": true,
+		"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		"walletconflicts": [],
+		"time": 1600000100,
+		"timereceived": 1600000100,
+		"details": [
+			{
+				"address": "bitcoincash:qqzkxrjkjfpv7r9ascwyk8wv8dfaskmxhq4my83jjh",
+				"category": "send",
+				"amount": -0.12345678,
+				"vout": 0,
+				"fee": -0.00000226,
+				"abandoned": false
+			}
+		],
+		"hex": "0100000000000000000000"
+	}`
+
+	getTransactionSelf = `{
+		"amount": 0,
+		"fee": -0.00000225,
+		"confirmations": 1,
+		"blockhash": "000000000000000001b6ce6b7b2bbe4d4e8abb8bbce5b98e8b1f1b5b0a9a2e10",
+		"blockindex": 2,
+		"blocktime": 1600000000,
+		"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		"walletconflicts": [],
+		"time": 1599999000,
+		"timereceived": 1599999000,
+		"details": [
+			{
+				"address": "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+				"category": "send",
+				"amount": -1.0,
+				"label": "",
+				"vout": 0,
+				"fee": -0.00000225,
+				"abandoned": false
+			},
+			{
+				"address": "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+				"category": "receive",
+				"amount": 1.0,
+				"label": "",
+				"vout": 0
+			}
+		],
+		"hex": "0100000000000000000000"
+	}`
+)
+
+func TestGetTransaction(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		reply  string
+		params string
+	)
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "gettransaction" {
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got, _ := json.Marshal(req.Params)
+		params = string(got)
+		if reply == "" {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidAddressOrKey,
+				"Invalid or non-wallet transaction id")
+		}
+		return json.RawMessage(reply), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	txHash, _ := chainhash.NewHashFromStr(
+		"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b")
+	type detail struct {
+		category    string
+		amount, fee bchutil.Amount
+	}
+	tests := []struct {
+		name        string
+		reply       string
+		amount, fee bchutil.Amount
+		details     []detail
+	}{
+		{
+			name:    "receive",
+			reply:   getTransactionReceive,
+			amount:  50000000,
+			details: []detail{{"receive", 50000000, 0}},
+		},
+		{
+			name:    "send",
+			reply:   getTransactionSend,
+			amount:  -12345678,
+			fee:     -226,
+			details: []detail{{"send", -12345678, -226}},
+		},
+		{
+			name:  "self-transfer",
+			reply: getTransactionSelf,
+			fee:   -225,
+			details: []detail{
+				{"send", -100000000, -225},
+				{"receive", 100000000, 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		mu.Lock()
+		reply = test.reply
+		mu.Unlock()
+
+		tx, err := client.GetTransaction(context.Background(), txHash, true)
+		if err != nil {
+			t.Fatalf("%s: GetTransaction: %v", test.name, err)
+		}
+		mu.Lock()
+		if want := `["` + txHash.String() + `",true]`; params != want {
+			t.Errorf("%s: params %s, want %s", test.name, params, want)
+		}
+		mu.Unlock()
+		if tx.TxID != txHash.String() || tx.Hex == "" {
+			t.Errorf("%s: unexpected result %+v", test.name, tx)
+		}
+
+		amount, fee, err := TransactionAmounts(tx)
+		if err != nil || amount != test.amount || fee != test.fee {
+			t.Errorf("%s: TransactionAmounts = %v, %v, %v, want %v, %v",
+				test.name, amount, fee, err, test.amount, test.fee)
+		}
+		if len(tx.Details) != len(test.details) {
+			t.Fatalf("%s: %d details, want %d", test.name,
+				len(tx.Details), len(test.details))
+		}
+		for i, want := range test.details {
+			got := &tx.Details[i]
+			amount, fee, err := TransactionDetailAmounts(got)
+			if err != nil || got.Category != want.category ||
+				amount != want.amount || fee != want.fee {
+
+				t.Errorf("%s: detail %d = %s %v %v %v, want %+v",
+					test.name, i, got.Category, amount, fee, err, want)
+			}
+		}
+	}
+
+	// Transactions unknown to the wallet fail with a typed error which
+	// still carries the error of the node.
+	mu.Lock()
+	reply = ""
+	mu.Unlock()
+	_, err := client.GetTransaction(context.Background(), txHash, false)
+	var notWalletErr *ErrNotWalletTransaction
+	if !errors.As(err, &notWalletErr) {
+		t.Fatalf("expected ErrNotWalletTransaction, got %v", err)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCInvalidAddressOrKey {
+		t.Fatalf("expected the node's error to be wrapped, got %v", err)
+	}
+}
@@ -0,0 +1,594 @@
+package bch_rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/websocket"
+)
+
+// testWSServer is a websocket JSON-RPC server which answers every request
+// with a null result and records the methods, last params per method and
+// handshake headers it sees.
+type testWSServer struct {
+	*httptest.Server
+
+	methods chan string
+
+	mtx     sync.Mutex
+	conns   []*websocket.Conn
+	headers []http.Header
+	params  map[string][]json.RawMessage
+}
+
+// newTestWSServer starts a websocket test server.  Callers are responsible for
+// closing it.
+func newTestWSServer() *testWSServer {
+	s := newUnstartedTestWSServer()
+	s.Start()
+	return s
+}
+
+// newUnstartedTestWSServer returns a websocket test server which has not been
+// started yet so its TLS configuration can still be changed.
+func newUnstartedTestWSServer() *testWSServer {
+	s := &testWSServer{
+		methods: make(chan string, 100),
+		params:  make(map[string][]json.RawMessage),
+	}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mtx.Lock()
+		s.conns = append(s.conns, conn)
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mtx.Unlock()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req testRequest
+			if err := json.Unmarshal(msg, &req); err != nil {
+				return
+			}
+			s.mtx.Lock()
+			s.params[req.Method] = req.Params
+			s.mtx.Unlock()
+			s.methods <- req.Method
+			fields := map[string]interface{}{
+				"id":     req.ID,
+				"result": nil,
+				"error":  nil,
+			}
+			if req.Jsonrpc == "2.0" {
+				// JSON-RPC 2.0 replies carry the version and omit
+				// the error of successful calls.
+				fields["jsonrpc"] = req.Jsonrpc
+				delete(fields, "error")
+			}
+			reply, _ := json.Marshal(fields)
+			s.mtx.Lock()
+			conn.WriteMessage(websocket.TextMessage, reply)
+			s.mtx.Unlock()
+		}
+	}))
+	return s
+}
+
+// connConfig returns a websocket connection configuration for the server.
+func (s *testWSServer) connConfig() *ConnConfig {
+	return &ConnConfig{
+		Host:       strings.TrimPrefix(s.URL, "http://"),
+		Endpoint:   "ws",
+		DisableTLS: true,
+	}
+}
+
+// lastParams returns the params of the last request for the passed method.
+func (s *testWSServer) lastParams(method string) string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	params, _ := json.Marshal(s.params[method])
+	return string(params)
+}
+
+// notify sends a notification over the i'th accepted connection.
+func (s *testWSServer) notify(i int, method string, params ...interface{}) error {
+	ntfn, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "1.0",
+		"method":  method,
+		"params":  params,
+		"id":      nil,
+	})
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.conns[i].WriteMessage(websocket.TextMessage, ntfn)
+}
+
+// dropConn closes the server side of the i'th accepted connection.
+func (s *testWSServer) dropConn(i int) {
+	s.mtx.Lock()
+	s.conns[i].Close()
+	s.mtx.Unlock()
+}
+
+func TestWebsocketHeaders(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	config := server.connConfig()
+	config.BearerToken = "token"
+	config.ExtraHeaders = map[string]string{"X-Api-Key": "key"}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	server.mtx.Lock()
+	defer server.mtx.Unlock()
+	header := server.headers[0]
+	if got := header.Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization %q", got)
+	}
+	if got := header.Get("X-Api-Key"); got != "key" {
+		t.Errorf("X-Api-Key %q", got)
+	}
+}
+
+func TestWebsocketCredentialsProvider(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	creds := &rotatingCredentials{pass: "first"}
+	config := server.connConfig()
+	config.CredentialsProvider = creds.provide
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// Rotated credentials are picked up when the client reconnects.
+	creds.rotate("second")
+	server.dropConn(0)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.mtx.Lock()
+		headers := server.headers
+		server.mtx.Unlock()
+		if len(headers) == 2 {
+			for i, want := range []string{"first", "second"} {
+				req := &http.Request{Header: headers[i]}
+				if user, pass, _ := req.BasicAuth(); user != "user" || pass != want {
+					t.Fatalf("connect %d authenticated as %s:%s", i, user, pass)
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client connected %d times, want 2", len(headers))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebsocketHostURL(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	config := server.connConfig()
+	config.Host = server.URL + "/"
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+}
+
+func TestWebsocketUnparsableMessage(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	reasons := make(chan interface{}, 10)
+	orphans := make(chan interface{}, 10)
+	config := server.connConfig()
+	config.OnUnparsableMessage = func(msg []byte, reason UnparsableReason) {
+		reasons <- reason
+	}
+	config.OnOrphanResponse = func(msg []byte, id uint64) {
+		orphans <- id
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// Broken frames do not disturb the connection.
+	server.mtx.Lock()
+	conn := server.conns[0]
+	server.mtx.Unlock()
+	for _, frame := range []string{`{"id":`, `{"id":42,"result":1,"error":null}`} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+	}
+	if reason := waitEvent(t, reasons, "unparsable message"); reason != ReasonInvalidJSON {
+		t.Fatalf("unparsable message reported as %v", reason)
+	}
+	if id := waitEvent(t, orphans, "orphan response"); id != uint64(42) {
+		t.Fatalf("orphan response reported with id %v", id)
+	}
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	if n := client.Stats().OrphanResponses; n != 1 {
+		t.Fatalf("%d orphan responses counted, want 1", n)
+	}
+}
+
+func TestWebsocketInterceptors(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	observed := make(chan interface{}, 10)
+	config := server.connConfig()
+	config.Interceptors = []Interceptor{
+		RenameMethods(map[string]string{"uptime": "getuptime"}),
+		func(ctx context.Context, req *Request, next Invoker) ([]byte, error) {
+			result, err := next(ctx, req)
+			observed <- req.Method
+			return result, err
+		},
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// The request is sent over the websocket with the renamed method, and
+	// the later interceptor sees the renamed request.
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	if method := <-server.methods; method != "getuptime" {
+		t.Fatalf("server saw method %q", method)
+	}
+	if method := waitEvent(t, observed, "intercepted result"); method != "getuptime" {
+		t.Fatalf("interceptor saw method %v", method)
+	}
+}
+
+func TestWebsocketMutualTLS(t *testing.T) {
+	ca := newTestCert(t, nil, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	serverCert := newTestCert(t, ca, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCert := newTestCert(t, ca, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	server := newUnstartedTestWSServer()
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	config := &ConnConfig{
+		Host:                 strings.TrimPrefix(server.URL, "https://"),
+		Endpoint:             "ws",
+		Certificates:         ca.certPEM,
+		DisableAutoReconnect: true,
+	}
+	if _, err := New(config, nil); err == nil {
+		t.Fatal("expected handshake without client certificate to fail")
+	}
+
+	config.ClientCertificate = clientCert.certPEM
+	config.ClientKey = clientCert.keyPEM
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+}
+
+func TestWebsocketMaxResponseBytes(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	config := server.connConfig()
+	config.MaxResponseBytes = 100
+	config.DisableAutoReconnect = true
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	server.mtx.Lock()
+	msg := `{"jsonrpc":"1.0","method":"blockconnected","params":["` +
+		strings.Repeat("00", 100) + `"],"id":null}`
+	server.conns[0].WriteMessage(websocket.TextMessage, []byte(msg))
+	server.mtx.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !client.Disconnected() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !client.Disconnected() {
+		t.Fatal("oversized message did not drop the connection")
+	}
+}
+
+func TestWebsocketFailover(t *testing.T) {
+	first := newTestWSServer()
+	defer first.Close()
+	second := newTestWSServer()
+	defer second.Close()
+	dead := deadHost(t)
+
+	config := first.connConfig()
+	config.Hosts = []string{
+		dead,
+		strings.TrimPrefix(first.URL, "http://"),
+		strings.TrimPrefix(second.URL, "http://"),
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	if got := client.ActiveEndpoint(); got != config.Hosts[1] {
+		t.Fatalf("active endpoint %s, want %s", got, config.Hosts[1])
+	}
+
+	// Take the active server down and wait for the client to reconnect to
+	// the remaining one.
+	first.Listener.Close()
+	first.dropConn(0)
+	deadline := time.Now().Add(5 * time.Second)
+	for client.ActiveEndpoint() != config.Hosts[2] && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := client.ActiveEndpoint(); got != config.Hosts[2] {
+		t.Fatalf("active endpoint %s, want %s", got, config.Hosts[2])
+	}
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+}
+
+func TestWebsocketConnectionCallbacks(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	// The initial connect may be reported before New returns.
+	var client *Client
+	ready := make(chan struct{})
+	connected := make(chan interface{}, 10)
+	disconnected := make(chan interface{}, 10)
+	config := server.connConfig()
+	config.OnClientConnected = func(retries int) {
+		// The callbacks may call back into the client.
+		<-ready
+		if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+			t.Errorf("RawRequest from callback: %v", err)
+		}
+		connected <- retries
+	}
+	config.OnClientDisconnected = func(err error) {
+		disconnected <- err
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	close(ready)
+	defer stopClient(client)
+
+	if retries := waitEvent(t, connected, "connect"); retries != 0 {
+		t.Fatalf("initial connect reported %v retries, want 0", retries)
+	}
+
+	// Dropping the connection reports the read error and the client
+	// reconnects right away.
+	server.dropConn(0)
+	if err := waitEvent(t, disconnected, "disconnect"); err == nil {
+		t.Fatal("disconnect reported no error")
+	}
+	if retries := waitEvent(t, connected, "reconnect"); retries != 0 {
+		t.Fatalf("reconnect reported %v retries, want 0", retries)
+	}
+}
+
+func TestWebsocketConnectedHandlers(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	// Both connect callbacks see every connect exactly once, the
+	// notification handler first.
+	events := make(chan interface{}, 10)
+	config := server.connConfig()
+	config.OnClientConnected = func(retries int) {
+		events <- "config"
+	}
+	ntfnHandlers := &NotificationHandlers{
+		OnClientConnected: func() {
+			events <- "handler"
+		},
+	}
+	client, err := New(config, ntfnHandlers)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	for _, what := range []string{"connect", "reconnect"} {
+		if what == "reconnect" {
+			server.dropConn(0)
+		}
+		for _, want := range []string{"handler", "config"} {
+			if got := waitEvent(t, events, what); got != want {
+				t.Fatalf("%s delivered %v, want %v", what, got, want)
+			}
+		}
+	}
+	select {
+	case got := <-events:
+		t.Fatalf("unexpected extra connect event %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebsocketSOCKSProxy(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+	proxy := newTestSOCKSServer(t, "proxyuser", "proxypass")
+	defer proxy.Close()
+
+	config := server.connConfig()
+	config.Proxy = "socks5://" + proxy.Addr()
+	config.ProxyUser = "proxyuser"
+	config.ProxyPass = "proxypass"
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	target := strings.TrimPrefix(server.URL, "http://")
+	if conns := proxy.connections(); len(conns) != 1 || conns[0] != target {
+		t.Fatalf("proxy connected to %v, want %s", conns, target)
+	}
+
+	// HTTP proxies cannot carry websocket connections.
+	config = server.connConfig()
+	config.Proxy = "http://" + proxy.Addr()
+	if _, err := New(config, nil); err != ErrHTTPProxyWebsocket {
+		t.Fatalf("expected ErrHTTPProxyWebsocket, got %v", err)
+	}
+}
+
+func TestWebsocketTrafficRecorder(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	var mtx sync.Mutex
+	var records []trafficRecord
+	config := server.connConfig()
+	config.TrafficRecorder = func(method string, request, response []byte, duration time.Duration, err error) {
+		mtx.Lock()
+		records = append(records, trafficRecord{method, request, response, err})
+		mtx.Unlock()
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(records) != 1 || records[0].method != "uptime" ||
+		!strings.Contains(string(records[0].request), `"method":"uptime"`) ||
+		!strings.Contains(string(records[0].response), `"result":null`) ||
+		records[0].err != nil {
+
+		t.Fatalf("unexpected records %+v", records)
+	}
+}
+
+func TestWebsocketJSONRPC2(t *testing.T) {
+	server := newTestWSServer()
+	defer server.Close()
+
+	var mtx sync.Mutex
+	var records []trafficRecord
+	config := server.connConfig()
+	config.JSONRPCVersion = "2.0"
+	config.TrafficRecorder = func(method string, request, response []byte, duration time.Duration, err error) {
+		mtx.Lock()
+		records = append(records, trafficRecord{method, request, response, err})
+		mtx.Unlock()
+	}
+	client, err := New(config, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.RawRequest(context.Background(), "uptime", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(records) != 2 {
+		t.Fatalf("recorded %d calls, want 2", len(records))
+	}
+	for _, record := range records {
+		if !strings.Contains(string(record.request), `"jsonrpc":"2.0"`) ||
+			!strings.Contains(string(record.response), `"jsonrpc":"2.0"`) ||
+			record.err != nil {
+
+			t.Fatalf("unexpected record %+v", record)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+// bech32Charset is the alphabet of the data part of bech32 and bech32m
+// strings.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Const and bech32mConst are the constants the checksums of bech32
+// strings of BIP 173 and bech32m strings of BIP 350 are built with.  The
+// btcutil package only knows the former, so segwit addresses are decoded here.
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// taprootWitnessVersion is the witness version of pay-to-taproot outputs.
+const taprootWitnessVersion = 1
+
+// AddressTaproot is a pay-to-taproot (P2TR) address of BIP 341, which is a
+// witness version 1 program of 32 bytes encoded with bech32m.
+type AddressTaproot struct {
+	hrp            string
+	witnessProgram [32]byte
+}
+
+// NewAddressTaproot returns a new AddressTaproot for the passed witness
+// program, which is the 32 byte x-only output key.
+func NewAddressTaproot(witnessProg []byte, net *chaincfg.Params) (*AddressTaproot, error) {
+	if len(witnessProg) != 32 {
+		return nil, fmt.Errorf("witness program must be 32 bytes for "+
+			"p2tr, got %d", len(witnessProg))
+	}
+	addr := &AddressTaproot{hrp: strings.ToLower(net.Bech32HRPSegwit)}
+	copy(addr.witnessProgram[:], witnessProg)
+	return addr, nil
+}
+
+// EncodeAddress returns the bech32m string encoding of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) EncodeAddress() string {
+	encoded, err := encodeSegWitAddress(a.hrp, taprootWitnessVersion,
+		a.witnessProgram[:])
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
+
+// ScriptAddress returns the witness program of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) ScriptAddress() []byte {
+	return a.witnessProgram[:]
+}
+
+// IsForNet returns whether the address is associated with the passed network.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return a.hrp == net.Bech32HRPSegwit
+}
+
+// String returns the bech32m string encoding of the address.
+//
+// This is part of the btcutil.Address interface implementation.
+func (a *AddressTaproot) String() string {
+	return a.EncodeAddress()
+}
+
+// Hrp returns the human-readable part of the address.
+func (a *AddressTaproot) Hrp() string {
+	return a.hrp
+}
+
+// WitnessVersion returns the witness version of the address, which is always
+// 1.
+func (a *AddressTaproot) WitnessVersion() byte {
+	return taprootWitnessVersion
+}
+
+// WitnessProgram returns the witness program of the address.
+func (a *AddressTaproot) WitnessProgram() []byte {
+	return a.witnessProgram[:]
+}
+
+// DecodeAddress decodes the passed string as an address of the passed network.
+// Unlike btcutil.DecodeAddress, it knows pay-to-taproot addresses, which are
+// returned as an *AddressTaproot, and rejects addresses of other networks.
+// Witness version 0 addresses must be encoded with bech32 and later versions
+// with bech32m as BIP 350 specifies.  Segwit addresses of versions later than
+// 1 are not in use yet and not supported.
+func DecodeAddress(addr string, params *chaincfg.Params) (btcutil.Address, error) {
+	if oneIndex := strings.LastIndexByte(addr, '1'); oneIndex > 0 {
+		prefix := addr[:oneIndex+1]
+		if strings.EqualFold(prefix, params.Bech32HRPSegwit+"1") {
+			return decodeSegWitAddress(addr, params)
+		}
+		if chaincfg.IsBech32SegwitPrefix(prefix) {
+			return nil, fmt.Errorf("address %s is not for network %s",
+				addr, params.Name)
+		}
+	}
+
+	decoded, err := btcutil.DecodeAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(params) {
+		return nil, fmt.Errorf("address %s is not for network %s", addr,
+			params.Name)
+	}
+	return decoded, nil
+}
+
+// decodeSegWitAddress decodes the passed segwit address, whose human-readable
+// part is the one of the passed network.
+func decodeSegWitAddress(addr string, params *chaincfg.Params) (btcutil.Address, error) {
+	hrp, data, checksumConst, err := decodeBech32(addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1 {
+		return nil, errors.New("missing witness version")
+	}
+	version := data[0]
+	if version > 16 {
+		return nil, fmt.Errorf("invalid witness version %d", version)
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return nil, fmt.Errorf("invalid witness program length %d",
+			len(program))
+	}
+	if version == 0 && checksumConst != bech32Const {
+		return nil, errors.New("witness version 0 address is not " +
+			"encoded with bech32")
+	}
+	if version != 0 && checksumConst != bech32mConst {
+		return nil, fmt.Errorf("witness version %d address is not "+
+			"encoded with bech32m", version)
+	}
+
+	net := *params
+	net.Bech32HRPSegwit = hrp
+	switch {
+	case version == 0 && len(program) == 20:
+		return btcutil.NewAddressWitnessPubKeyHash(program, &net)
+	case version == 0 && len(program) == 32:
+		return btcutil.NewAddressWitnessScriptHash(program, &net)
+	case version == 0:
+		return nil, fmt.Errorf("invalid witness program length %d for "+
+			"witness version 0", len(program))
+	case version == taprootWitnessVersion && len(program) == 32:
+		return NewAddressTaproot(program, &net)
+	}
+	return nil, fmt.Errorf("unsupported witness version %d address", version)
+}
+
+// encodeSegWitAddress encodes the passed witness program as a segwit address,
+// using bech32 for witness version 0 and bech32m for later versions.
+func encodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
+	converted, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	checksumConst := uint32(bech32mConst)
+	if version == 0 {
+		checksumConst = bech32Const
+	}
+	return encodeBech32(hrp, append([]byte{version}, converted...),
+		checksumConst), nil
+}
+
+// decodeBech32 decodes the passed bech32 or bech32m string, returning the
+// constant its checksum was built with to tell them apart.
+func decodeBech32(s string) (string, []byte, uint32, error) {
+	if len(s) > 90 {
+		return "", nil, 0, fmt.Errorf("invalid bech32 string length %d",
+			len(s))
+	}
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("mixed case bech32 string")
+	}
+	oneIndex := strings.LastIndexByte(lower, '1')
+	if oneIndex < 1 || oneIndex+7 > len(lower) {
+		return "", nil, 0, errors.New("invalid bech32 separator index")
+	}
+
+	hrp := lower[:oneIndex]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character "+
+				"%q", hrp[i])
+		}
+	}
+	data := make([]byte, 0, len(lower)-oneIndex-1)
+	for i := oneIndex + 1; i < len(lower); i++ {
+		value := strings.IndexByte(bech32Charset, lower[i])
+		if value < 0 {
+			return "", nil, 0, fmt.Errorf("invalid bech32 character "+
+				"%q", lower[i])
+		}
+		data = append(data, byte(value))
+	}
+
+	checksumConst := bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	if checksumConst != bech32Const && checksumConst != bech32mConst {
+		return "", nil, 0, errors.New("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], checksumConst, nil
+}
+
+// encodeBech32 encodes the passed 5 bit data with a checksum built with the
+// passed constant.
+func encodeBech32(hrp string, data []byte, checksumConst uint32) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ checksumConst
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, value := range data {
+		b.WriteByte(bech32Charset[value])
+	}
+	for i := 0; i < 6; i++ {
+		b.WriteByte(bech32Charset[(mod>>uint(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// bech32HRPExpand expands the passed human-readable part for the checksum
+// computation.
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// bech32Polymod computes the checksum polynomial of BIP 173 over the passed
+// values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd,
+		0x2a1462b3}
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// ValidateAddressResult models the data returned from the validateaddress
+// command.  Fields which do not apply to the address are left empty.
+type ValidateAddressResult struct {
+	IsValid      bool   `json:"isvalid"`
+	Address      string `json:"address,omitempty"`
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+	IsScript     bool   `json:"isscript,omitempty"`
+
+	// IsWitness is whether the address is a segwit address, whose witness
+	// version and program are then set.
+	IsWitness      bool   `json:"iswitness,omitempty"`
+	WitnessVersion int32  `json:"witness_version,omitempty"`
+	WitnessProgram string `json:"witness_program,omitempty"`
+
+	// Error tells why the address is invalid, which nodes before Bitcoin
+	// Core 0.21 do not report.
+	Error string `json:"error,omitempty"`
+}
+
+// FutureValidateAddressResult is a future promise to deliver the result of a
+// ValidateAddressAsync RPC invocation (or an applicable error).
+type FutureValidateAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns what the
+// node knows about the address.
+func (r FutureValidateAddressResult) Receive() (*ValidateAddressResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a validateaddress result object.
+	var result ValidateAddressResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ValidateAddress for the blocking version and more details.
+func (c *Client) ValidateAddressAsync(ctx context.Context, address btcutil.Address) FutureValidateAddressResult {
+	cmd := btcjson.NewValidateAddressCmd(address.EncodeAddress())
+	return c.sendCmd(ctx, cmd)
+}
+
+// ValidateAddress returns whether the node considers the passed address valid
+// and its output script and witness program.  Nodes before Bitcoin Core 22 do
+// not know taproot addresses and report them as invalid.
+func (c *Client) ValidateAddress(ctx context.Context, address btcutil.Address) (*ValidateAddressResult, error) {
+	return c.ValidateAddressAsync(ctx, address).Receive()
+}
@@ -0,0 +1,246 @@
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		params  *chaincfg.Params
+		program string
+		check   func(btcutil.Address) bool
+	}{
+		{
+			// Test vectors of BIP 350.
+			addr:    "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+			params:  &chaincfg.MainNetParams,
+			program: "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			check: func(addr btcutil.Address) bool {
+				_, ok := addr.(*AddressTaproot)
+				return ok
+			},
+		},
+		{
+			addr:    "tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c",
+			params:  &chaincfg.TestNet3Params,
+			program: "000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433",
+			check: func(addr btcutil.Address) bool {
+				_, ok := addr.(*AddressTaproot)
+				return ok
+			},
+		},
+		{
+			addr:    "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			params:  &chaincfg.MainNetParams,
+			program: "751e76e8199196d454941c45d1b3a323f1433bd6",
+			check: func(addr btcutil.Address) bool {
+				_, ok := addr.(*btcutil.AddressWitnessPubKeyHash)
+				return ok
+			},
+		},
+		{
+			addr:    "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7",
+			params:  &chaincfg.TestNet3Params,
+			program: "1863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262",
+			check: func(addr btcutil.Address) bool {
+				_, ok := addr.(*btcutil.AddressWitnessScriptHash)
+				return ok
+			},
+		},
+		{
+			addr:    "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			params:  &chaincfg.MainNetParams,
+			program: "77bff20c60e522dfaa3350c39b030a5d004e839a",
+			check: func(addr btcutil.Address) bool {
+				_, ok := addr.(*btcutil.AddressPubKeyHash)
+				return ok
+			},
+		},
+	}
+
+	for _, test := range tests {
+		addr, err := DecodeAddress(test.addr, test.params)
+		if err != nil {
+			t.Fatalf("DecodeAddress(%s): %v", test.addr, err)
+		}
+		if !test.check(addr) {
+			t.Fatalf("DecodeAddress(%s): unexpected address type %T",
+				test.addr, addr)
+		}
+		if program := hex.EncodeToString(addr.ScriptAddress()); program != test.program {
+			t.Fatalf("DecodeAddress(%s): got program %s, want %s",
+				test.addr, program, test.program)
+		}
+		if addr.EncodeAddress() != test.addr || !addr.IsForNet(test.params) {
+			t.Fatalf("DecodeAddress(%s): address does not round trip, "+
+				"got %s", test.addr, addr.EncodeAddress())
+		}
+
+		// Uppercase addresses are fine for bech32 and bech32m.
+		if strings.HasPrefix(test.addr, test.params.Bech32HRPSegwit) {
+			upper, err := DecodeAddress(strings.ToUpper(test.addr), test.params)
+			if err != nil {
+				t.Fatalf("DecodeAddress(%s): %v",
+					strings.ToUpper(test.addr), err)
+			}
+			if upper.EncodeAddress() != test.addr {
+				t.Fatalf("DecodeAddress(%s): got %s",
+					strings.ToUpper(test.addr), upper.EncodeAddress())
+			}
+		}
+	}
+
+	program, _ := hex.DecodeString(tests[0].program)
+	addr, err := NewAddressTaproot(program, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressTaproot: %v", err)
+	}
+	if addr.String() != tests[0].addr || addr.WitnessVersion() != 1 ||
+		!bytes.Equal(addr.WitnessProgram(), program) || addr.Hrp() != "bc" ||
+		addr.IsForNet(&chaincfg.TestNet3Params) {
+
+		t.Fatalf("unexpected taproot address %s", addr)
+	}
+	if _, err := NewAddressTaproot(program[:20], &chaincfg.MainNetParams); err == nil {
+		t.Fatal("NewAddressTaproot: expected an error for a short program")
+	}
+}
+
+func TestDecodeAddressInvalid(t *testing.T) {
+	invalid := []struct {
+		addr   string
+		params *chaincfg.Params
+	}{
+		// Witness version 0 encoded with bech32m, of BIP 350.
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", &chaincfg.MainNetParams},
+		{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", &chaincfg.TestNet3Params},
+		// Witness version 1 encoded with bech32, of BIP 350 and BIP 173.
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", &chaincfg.MainNetParams},
+		{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx", &chaincfg.MainNetParams},
+		// Addresses of other networks.
+		{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", &chaincfg.MainNetParams},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.RegressionNetParams},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", &chaincfg.TestNet3Params},
+		// Malformed addresses.
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj1", &chaincfg.MainNetParams},
+		{"BC1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.MainNetParams},
+		{"bc1gmk9yu", &chaincfg.MainNetParams},
+		{"", &chaincfg.MainNetParams},
+	}
+	for _, test := range invalid {
+		if addr, err := DecodeAddress(test.addr, test.params); err == nil {
+			t.Fatalf("DecodeAddress(%s): expected an error, got %s",
+				test.addr, addr)
+		}
+	}
+
+	// Witness programs encoded with the checksum of the other version.
+	program, _ := hex.DecodeString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	data, err := bech32.ConvertBits(program, 8, 5, true)
+	if err != nil {
+		t.Fatalf("ConvertBits: %v", err)
+	}
+	for version, checksumConst := range map[byte]uint32{0: bech32mConst, 1: bech32Const} {
+		encoded := encodeBech32("bc", append([]byte{version}, data...), checksumConst)
+		if addr, err := DecodeAddress(encoded, &chaincfg.MainNetParams); err == nil {
+			t.Fatalf("DecodeAddress(%s): expected an error, got %s",
+				encoded, addr)
+		}
+	}
+}
+
+func TestTaprootAddressRPCs(t *testing.T) {
+	const taprootAddr = "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0"
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "createrawtransaction":
+			return genesisCoinbaseTx, nil
+		case "validateaddress":
+			return json.RawMessage(`{
+  "isvalid": true,
+  "address": "` + taprootAddr + `",
+  "scriptPubKey": "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+  "isscript": true,
+  "iswitness": true,
+  "witness_version": 1,
+  "witness_program": "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+}`), nil
+		case "getaddressinfo":
+			return json.RawMessage(`{
+  "address": "` + taprootAddr + `",
+  "scriptPubKey": "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+  "ismine": false,
+  "solvable": false,
+  "iswatchonly": false,
+  "isscript": true,
+  "iswitness": true,
+  "witness_version": 1,
+  "witness_program": "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+  "ischange": false,
+  "labels": [
+  ]
+}`), nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress(taprootAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	amounts := map[btcutil.Address]btcutil.Amount{addr: 150000}
+	if _, err := client.CreateRawTransaction(ctx,
+		[]btcjson.TransactionInput{}, amounts, nil); err != nil {
+		t.Fatalf("CreateRawTransaction: %v", err)
+	}
+
+	validated, err := client.ValidateAddress(ctx, addr)
+	if err != nil {
+		t.Fatalf("ValidateAddress: %v", err)
+	}
+	if !validated.IsValid || !validated.IsWitness || validated.WitnessVersion != 1 ||
+		validated.WitnessProgram != hex.EncodeToString(addr.ScriptAddress()) {
+
+		t.Fatalf("unexpected validateaddress result %+v", validated)
+	}
+	info, err := client.GetAddressInfo(ctx, addr)
+	if err != nil {
+		t.Fatalf("GetAddressInfo: %v", err)
+	}
+	if !info.IsWitness || info.WitnessVersion != 1 ||
+		info.WitnessProgram != hex.EncodeToString(addr.ScriptAddress()) {
+
+		t.Fatalf("unexpected getaddressinfo result %+v", info)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, requests, []string{
+		`createrawtransaction[[],{"` + taprootAddr + `":0.0015}]`,
+		`validateaddress["` + taprootAddr + `"]`,
+		`getaddressinfo["` + taprootAddr + `"]`,
+	})
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// bip21Scheme is the scheme of BIP 21 payment URIs, which is matched ignoring
+// case.
+const bip21Scheme = "bitcoin:"
+
+// ErrInvalidBIP21URI is returned by ParseBIP21URI for strings which are not
+// valid BIP 21 payment URIs for the network.
+var ErrInvalidBIP21URI = errors.New("invalid BIP 21 payment URI")
+
+// ErrUnknownRequiredParams describes a BIP 21 payment URI carrying parameters
+// prefixed with "req-" which ParseBIP21URI does not know.  BIP 21 requires
+// such URIs to be considered invalid, since the payment they ask for cannot
+// be made as intended.
+type ErrUnknownRequiredParams struct {
+	// Params are the names of the unknown required parameters.
+	Params []string
+}
+
+// Error satisfies the error interface.
+func (e *ErrUnknownRequiredParams) Error() string {
+	return "unknown required BIP 21 parameters: " +
+		strings.Join(e.Params, ", ")
+}
+
+// PaymentRequest is a payment requested by a BIP 21 payment URI.
+type PaymentRequest struct {
+	// Address is the address to pay to.
+	Address btcutil.Address
+
+	// Amount is the amount requested, or zero when the URI leaves it to
+	// the payer.
+	Amount btcutil.Amount
+
+	// Label names the recipient and Message describes the payment.
+	Label   string
+	Message string
+
+	// Params holds the parameters of the URI other than amount, label and
+	// message, including any unknown "req-" ones, keyed by name.
+	Params map[string]string
+}
+
+// BuildBIP21URI returns the BIP 21 payment URI requesting amount, unless it is
+// zero, to be paid to the passed address.  The label, message and extra
+// parameters are included unless empty, with the extra parameters sorted by
+// name.
+func BuildBIP21URI(addr btcutil.Address, amount btcutil.Amount, label, message string, extraParams map[string]string) string {
+	var params []string
+	if amount > 0 {
+		params = append(params, "amount="+formatBIP21Amount(amount))
+	}
+	if label != "" {
+		params = append(params, "label="+bip21Escape(label))
+	}
+	if message != "" {
+		params = append(params, "message="+bip21Escape(message))
+	}
+	names := make([]string, 0, len(extraParams))
+	for name := range extraParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		params = append(params, bip21Escape(name)+"="+
+			bip21Escape(extraParams[name]))
+	}
+
+	uri := bip21Scheme + addr.EncodeAddress()
+	if len(params) > 0 {
+		uri += "?" + strings.Join(params, "&")
+	}
+	return uri
+}
+
+// ParseBIP21URI parses the passed BIP 21 payment URI, whose address must be
+// one of the passed network.  The amount is parsed as an exact decimal number
+// of BTC and the other parameters are percent-decoded.
+//
+// An *ErrUnknownRequiredParams is returned along with the parsed payment
+// request when the URI carries "req-" parameters which are not known, in which
+// case the payment must not be made.  ErrInvalidBIP21URI is wrapped by all
+// other errors.
+func ParseBIP21URI(uri string, params *chaincfg.Params) (*PaymentRequest, error) {
+	if len(uri) < len(bip21Scheme) ||
+		!strings.EqualFold(uri[:len(bip21Scheme)], bip21Scheme) {
+
+		return nil, fmt.Errorf("%w: missing bitcoin scheme", ErrInvalidBIP21URI)
+	}
+	rest := uri[len(bip21Scheme):]
+	var query string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+
+	addr, err := DecodeAddress(rest, params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBIP21URI, err)
+	}
+
+	request := &PaymentRequest{Address: addr}
+	seen := make(map[string]struct{})
+	var unknownRequired []string
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		name, value := param, ""
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name, value = param[:i], param[i+1:]
+		}
+		name, err = url.PathUnescape(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBIP21URI, err)
+		}
+		value, err = url.PathUnescape(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBIP21URI, err)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate parameter %q",
+				ErrInvalidBIP21URI, name)
+		}
+		seen[name] = struct{}{}
+
+		switch name {
+		case "amount":
+			request.Amount, err = parseBIP21Amount(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidBIP21URI,
+					err)
+			}
+		case "label":
+			request.Label = value
+		case "message":
+			request.Message = value
+		default:
+			if request.Params == nil {
+				request.Params = make(map[string]string)
+			}
+			request.Params[name] = value
+			if strings.HasPrefix(name, "req-") {
+				unknownRequired = append(unknownRequired, name)
+			}
+		}
+	}
+	if len(unknownRequired) > 0 {
+		return request, &ErrUnknownRequiredParams{Params: unknownRequired}
+	}
+	return request, nil
+}
+
+// formatBIP21Amount formats the passed amount as a decimal number of BTC
+// without trailing zeros.
+func formatBIP21Amount(amount btcutil.Amount) string {
+	whole := int64(amount) / btcutil.SatoshiPerBitcoin
+	fraction := int64(amount) % btcutil.SatoshiPerBitcoin
+	if fraction == 0 {
+		return strconv.FormatInt(whole, 10)
+	}
+	fractionStr := strings.TrimRight(fmt.Sprintf("%08d", fraction), "0")
+	return strconv.FormatInt(whole, 10) + "." + fractionStr
+}
+
+// parseBIP21Amount parses the passed decimal number of BTC without going
+// through a floating point number, which cannot represent most amounts
+// exactly.
+func parseBIP21Amount(s string) (btcutil.Amount, error) {
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if whole == "" && fraction == "" || len(fraction) > 8 ||
+		strings.Trim(whole+fraction, "0123456789") != "" {
+
+		return 0, fmt.Errorf("malformed amount %q", s)
+	}
+
+	// Amounts with more than eight whole digits exceed the supply.
+	whole = strings.TrimLeft(whole, "0")
+	if len(whole) > 8 {
+		return 0, fmt.Errorf("amount %q exceeds the supply", s)
+	}
+	var sat int64
+	if whole != "" {
+		sat, _ = strconv.ParseInt(whole, 10, 64)
+	}
+	sat *= btcutil.SatoshiPerBitcoin
+	if fraction != "" {
+		fractionSat, _ := strconv.ParseInt(fraction+
+			strings.Repeat("0", 8-len(fraction)), 10, 64)
+		sat += fractionSat
+	}
+	if sat > btcutil.MaxSatoshi {
+		return 0, fmt.Errorf("amount %q exceeds the supply", s)
+	}
+	return btcutil.Amount(sat), nil
+}
+
+// bip21Escape percent-encodes all characters of the passed string but the
+// unreserved ones of RFC 3986, as BIP 21 expects.  Unlike query escaping,
+// spaces are encoded as %20.
+func bip21Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z',
+			'0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
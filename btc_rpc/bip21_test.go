@@ -0,0 +1,129 @@
+package btc_rpc
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestBIP21RoundTrip(t *testing.T) {
+	tests := []struct {
+		addr    string
+		amount  btcutil.Amount
+		label   string
+		message string
+		extra   map[string]string
+		uri     string
+	}{
+		{
+			addr: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			uri:  "bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		},
+		{
+			addr:   "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			amount: 20030000000,
+			label:  "Luke-Jr",
+			uri:    "bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=200.3&label=Luke-Jr",
+		},
+		{
+			addr:    "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			amount:  1,
+			label:   "Café & Co",
+			message: "Order #42 = 100%",
+			extra:   map[string]string{"somethingelse": "x y", "lightning": "lnbc1"},
+			uri: "bitcoin:bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4?amount=0.00000001" +
+				"&label=Caf%C3%A9%20%26%20Co&message=Order%20%2342%20%3D%20100%25" +
+				"&lightning=lnbc1&somethingelse=x%20y",
+		},
+		{
+			addr:   "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			amount: btcutil.MaxSatoshi,
+			uri:    "bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=21000000",
+		},
+	}
+
+	for _, test := range tests {
+		addr, err := btcutil.DecodeAddress(test.addr, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("DecodeAddress(%s): %v", test.addr, err)
+		}
+		uri := BuildBIP21URI(addr, test.amount, test.label, test.message, test.extra)
+		if uri != test.uri {
+			t.Fatalf("BuildBIP21URI: got %s, want %s", uri, test.uri)
+		}
+
+		request, err := ParseBIP21URI(uri, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("ParseBIP21URI(%s): %v", uri, err)
+		}
+		if request.Address.EncodeAddress() != test.addr ||
+			request.Amount != test.amount || request.Label != test.label ||
+			request.Message != test.message ||
+			len(request.Params) != len(test.extra) ||
+			len(test.extra) > 0 && !reflect.DeepEqual(request.Params, test.extra) {
+
+			t.Fatalf("ParseBIP21URI(%s): unexpected request %+v", uri, request)
+		}
+	}
+}
+
+func TestParseBIP21URI(t *testing.T) {
+	// Parameters are decoded without turning plus signs into spaces, the
+	// scheme is matched ignoring case and amounts are exact.
+	request, err := ParseBIP21URI("BITCOIN:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"+
+		"?amount=0.1&message=a+b%20c&&label=", &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("ParseBIP21URI: %v", err)
+	}
+	if request.Amount != 10000000 || request.Message != "a+b c" ||
+		request.Label != "" || request.Params != nil {
+
+		t.Fatalf("unexpected request %+v", request)
+	}
+
+	// Unknown required parameters are reported along with the request.
+	request, err = ParseBIP21URI("bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"+
+		"?req-somethingyoudontunderstand=50&req-other=1&foo=bar", &chaincfg.MainNetParams)
+	var unknown *ErrUnknownRequiredParams
+	if !errors.As(err, &unknown) {
+		t.Fatalf("got error %v, want ErrUnknownRequiredParams", err)
+	}
+	if !reflect.DeepEqual(unknown.Params, []string{"req-somethingyoudontunderstand", "req-other"}) {
+		t.Fatalf("unexpected unknown parameters %v", unknown.Params)
+	}
+	if request == nil || request.Params["foo"] != "bar" ||
+		request.Params["req-other"] != "1" {
+
+		t.Fatalf("unexpected request %+v", request)
+	}
+
+	malformed := []string{
+		"",
+		"bitcoin",
+		"litecoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+		"bitcoin:",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3",
+		"bitcoin:tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=.",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=-1",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=1e3",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=1,5",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=1.2.3",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=0.000000001",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=21000000.00000001",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=100000000000000000000",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=1&amount=2",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?label=%zz",
+	}
+	for _, uri := range malformed {
+		_, err := ParseBIP21URI(uri, &chaincfg.MainNetParams)
+		if !errors.Is(err, ErrInvalidBIP21URI) {
+			t.Fatalf("ParseBIP21URI(%q): got error %v, want ErrInvalidBIP21URI",
+				uri, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// blockHashRangeWindow is the number of getblockhash requests
+// GetBlockHashRange has in flight at a time.
+const blockHashRangeWindow = 16
+
+// ErrTimeBeforeGenesis describes a time passed to GetBlockAtTime which is
+// before the genesis block.
+type ErrTimeBeforeGenesis struct {
+	// Target is the time passed to GetBlockAtTime and GenesisTime the
+	// time of the genesis block.
+	Target      time.Time
+	GenesisTime time.Time
+}
+
+// Error satisfies the error interface.
+func (e *ErrTimeBeforeGenesis) Error() string {
+	return fmt.Sprintf("time %v is before the genesis block at %v",
+		e.Target.UTC(), e.GenesisTime.UTC())
+}
+
+// ErrTimeAfterTip describes a time passed to GetBlockAtTime which no block of
+// the best block chain reached yet.  Callers may try again once more blocks
+// are mined.
+type ErrTimeAfterTip struct {
+	// Target is the time passed to GetBlockAtTime and TipMedianTime the
+	// median time of the best block.
+	Target        time.Time
+	TipMedianTime time.Time
+}
+
+// Error satisfies the error interface.
+func (e *ErrTimeAfterTip) Error() string {
+	return fmt.Sprintf("time %v is after the median time %v of the best "+
+		"block", e.Target.UTC(), e.TipMedianTime.UTC())
+}
+
+// blockTimes holds the times of a block in seconds since the Unix epoch.
+type blockTimes struct {
+	Time       int64 `json:"time"`
+	MedianTime int64 `json:"mediantime"`
+}
+
+// blockTimesAt returns the hash and times of the block of the best block chain
+// at the passed height.
+func (c *Client) blockTimesAt(ctx context.Context, height int64) (*chainhash.Hash, *blockTimes, error) {
+	hash, err := c.GetBlockHash(ctx, height)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The btcjson package does not know the median time of the verbose
+	// header, so the command is sent as a raw request.
+	rawParams, err := marshalParams([]interface{}{hash.String(), true})
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := c.RawRequest(ctx, "getblockheader", rawParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	var times blockTimes
+	if err := json.Unmarshal(res, &times); err != nil {
+		return nil, nil, err
+	}
+	return hash, &times, nil
+}
+
+// GetBlockAtTime returns the hash and height of the first block of the best
+// block chain whose median time past is at or after the passed time.
+//
+// The median time past of a block is the median of the times of the block and
+// the ten before it.  Unlike the times of the blocks themselves, which miners
+// may set up to two hours ahead, it never decreases along the chain, which
+// makes it the time to draw boundaries at and to binary search by.  It lags
+// behind the time of the block by about an hour.
+//
+// An *ErrTimeBeforeGenesis is returned for times before the genesis block and
+// an *ErrTimeAfterTip for times after the median time of the best block.  The
+// search requests two block headers per step, about 40 for mainnet.
+func (c *Client) GetBlockAtTime(ctx context.Context, target time.Time) (*chainhash.Hash, int64, error) {
+	tipHeight, err := c.GetBlockCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	genesisHash, genesis, err := c.blockTimesAt(ctx, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	if target.Before(time.Unix(genesis.Time, 0)) {
+		return nil, 0, &ErrTimeBeforeGenesis{
+			Target:      target,
+			GenesisTime: time.Unix(genesis.Time, 0),
+		}
+	}
+	if !target.After(time.Unix(genesis.MedianTime, 0)) {
+		return genesisHash, 0, nil
+	}
+	tipHash, tip, err := c.blockTimesAt(ctx, tipHeight)
+	if err != nil {
+		return nil, 0, err
+	}
+	if target.After(time.Unix(tip.MedianTime, 0)) {
+		return nil, 0, &ErrTimeAfterTip{
+			Target:        target,
+			TipMedianTime: time.Unix(tip.MedianTime, 0),
+		}
+	}
+
+	// The block at low is before the target and the one at high at or
+	// after it.
+	low, high, highHash := int64(0), tipHeight, tipHash
+	for high-low > 1 {
+		mid := low + (high-low)/2
+		hash, times, err := c.blockTimesAt(ctx, mid)
+		if err != nil {
+			return nil, 0, err
+		}
+		if target.After(time.Unix(times.MedianTime, 0)) {
+			low = mid
+		} else {
+			high, highHash = mid, hash
+		}
+	}
+	return highHash, high, nil
+}
+
+// GetBlockHashRange returns the hashes of the blocks of the best block chain
+// from startHeight to endHeight, both included.  The getblockhash requests are
+// issued up to 16 at a time, which HTTPPostWorkers of the connection
+// configuration may send to the server concurrently.
+func (c *Client) GetBlockHashRange(ctx context.Context, startHeight, endHeight int64) ([]*chainhash.Hash, error) {
+	if startHeight < 0 || endHeight < startHeight {
+		return nil, fmt.Errorf("invalid block height range [%d, %d]",
+			startHeight, endHeight)
+	}
+
+	n := endHeight - startHeight + 1
+	futures := make([]FutureGetBlockHashResult, 0, n)
+	hashes := make([]*chainhash.Hash, 0, n)
+	for height := startHeight; height <= endHeight; height++ {
+		if len(futures)-len(hashes) == blockHashRangeWindow {
+			hash, err := futures[len(hashes)].Receive()
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash)
+		}
+		futures = append(futures, c.GetBlockHashAsync(ctx, height))
+	}
+	for _, future := range futures[len(hashes):] {
+		hash, err := future.Receive()
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
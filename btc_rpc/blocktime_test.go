@@ -0,0 +1,193 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// syntheticChain is a chain of block times as seen by a mock server, whose
+// block at height h has a hash of h+1.
+type syntheticChain struct {
+	times       []int64
+	medianTimes []int64
+}
+
+// newSyntheticChain returns a chain of n blocks ten minutes apart, of which
+// every seventh block claims a time before the one of its parent as miners'
+// clocks allow.
+func newSyntheticChain(n int) *syntheticChain {
+	const genesisTime = 1231006505
+
+	chain := &syntheticChain{}
+	for h := 0; h < n; h++ {
+		blockTime := int64(genesisTime + h*600)
+		if h%7 == 6 {
+			blockTime -= 1500
+		}
+		chain.times = append(chain.times, blockTime)
+
+		start := h - 10
+		if start < 0 {
+			start = 0
+		}
+		window := append([]int64(nil), chain.times[start:]...)
+		sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+		chain.medianTimes = append(chain.medianTimes, window[len(window)/2])
+	}
+	return chain
+}
+
+// hash returns the hash of the block at the passed height.
+func (chain *syntheticChain) hash(height int64) string {
+	return fmt.Sprintf("%064x", height+1)
+}
+
+// handle answers the getblockcount, getblockhash and getblockheader requests
+// of the passed request.
+func (chain *syntheticChain) handle(req *testRequest) (interface{}, *btcjson.RPCError) {
+	switch req.Method {
+	case "getblockcount":
+		return len(chain.times) - 1, nil
+	case "getblockhash":
+		var height int64
+		json.Unmarshal(req.Params[0], &height)
+		if height < 0 || height >= int64(len(chain.times)) {
+			return nil, btcjson.NewRPCError(-8, "Block height out of range")
+		}
+		return chain.hash(height), nil
+	case "getblockheader":
+		var hash string
+		json.Unmarshal(req.Params[0], &hash)
+		var height int64
+		fmt.Sscanf(hash, "%x", &height)
+		height--
+		return map[string]interface{}{
+			"hash":       hash,
+			"height":     height,
+			"time":       chain.times[height],
+			"mediantime": chain.medianTimes[height],
+		}, nil
+	}
+	return nil, btcjson.ErrRPCMethodNotFound
+}
+
+func TestGetBlockAtTime(t *testing.T) {
+	chain := newSyntheticChain(1000)
+	server := newTestServer(t, chain.handle)
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	// The expected block is the first one whose median time is at or after
+	// the target.
+	firstAtOrAfter := func(target int64) int64 {
+		for h, medianTime := range chain.medianTimes {
+			if medianTime >= target {
+				return int64(h)
+			}
+		}
+		return -1
+	}
+	targets := []int64{chain.times[0], chain.times[0] + 1, chain.medianTimes[999]}
+	for h := 1; h < 1000; h += 37 {
+		targets = append(targets, chain.medianTimes[h], chain.times[h],
+			chain.medianTimes[h]-1, chain.medianTimes[h]+1)
+	}
+	for _, target := range targets {
+		hash, height, err := client.GetBlockAtTime(ctx, time.Unix(target, 0))
+		if err != nil {
+			t.Fatalf("GetBlockAtTime(%d): %v", target, err)
+		}
+		want := firstAtOrAfter(target)
+		if height != want || hash.String() != chain.hash(want) {
+			t.Fatalf("GetBlockAtTime(%d): got block %d %s, want %d",
+				target, height, hash, want)
+		}
+	}
+
+	_, _, err := client.GetBlockAtTime(ctx, time.Unix(chain.times[0]-1, 0))
+	var beforeGenesis *ErrTimeBeforeGenesis
+	if !errors.As(err, &beforeGenesis) ||
+		beforeGenesis.GenesisTime.Unix() != chain.times[0] {
+
+		t.Fatalf("got error %v, want ErrTimeBeforeGenesis", err)
+	}
+	_, _, err = client.GetBlockAtTime(ctx, time.Unix(chain.medianTimes[999]+1, 0))
+	var afterTip *ErrTimeAfterTip
+	if !errors.As(err, &afterTip) ||
+		afterTip.TipMedianTime.Unix() != chain.medianTimes[999] {
+
+		t.Fatalf("got error %v, want ErrTimeAfterTip", err)
+	}
+}
+
+func TestGetBlockHashRange(t *testing.T) {
+	chain := newSyntheticChain(1000)
+
+	var mtx sync.Mutex
+	var inFlight, maxInFlight int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mtx.Unlock()
+		defer func() {
+			mtx.Lock()
+			inFlight--
+			mtx.Unlock()
+		}()
+		time.Sleep(time.Millisecond)
+		return chain.handle(req)
+	})
+	defer server.Close()
+	config := testConnConfig(server)
+	config.HTTPPostWorkers = 64
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx := context.Background()
+
+	hashes, err := client.GetBlockHashRange(ctx, 0, 999)
+	if err != nil {
+		t.Fatalf("GetBlockHashRange: %v", err)
+	}
+	if len(hashes) != 1000 {
+		t.Fatalf("got %d hashes, want 1000", len(hashes))
+	}
+	for h, hash := range hashes {
+		if hash.String() != chain.hash(int64(h)) {
+			t.Fatalf("got hash %s at height %d, want %s", hash, h,
+				chain.hash(int64(h)))
+		}
+	}
+	mtx.Lock()
+	if maxInFlight > blockHashRangeWindow {
+		t.Fatalf("%d requests in flight, want at most %d", maxInFlight,
+			blockHashRangeWindow)
+	}
+	mtx.Unlock()
+
+	hashes, err = client.GetBlockHashRange(ctx, 500, 500)
+	if err != nil || len(hashes) != 1 || hashes[0].String() != chain.hash(500) {
+		t.Fatalf("GetBlockHashRange(500, 500): got %v, %v", hashes, err)
+	}
+	if _, err := client.GetBlockHashRange(ctx, 990, 1010); err == nil {
+		t.Fatal("GetBlockHashRange: expected an error beyond the tip")
+	}
+	if _, err := client.GetBlockHashRange(ctx, 10, 5); err == nil {
+		t.Fatal("GetBlockHashRange: expected an error for an inverted range")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// maxRBFSequence is the highest input sequence number which signals that a
+// transaction may be replaced, as specified by BIP 125.
+const maxRBFSequence = wire.MaxTxInSequenceNum - 2
+
+// SignalsRBF returns whether the passed transaction explicitly signals that it
+// may be replaced by one paying a higher fee, which is the case when any of
+// its inputs has a sequence number of at most 0xfffffffd.
+//
+// Transactions spending unconfirmed outputs of signaling transactions are
+// replaceable as well, which can only be told with the help of the memory pool
+// of a node.
+func SignalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence <= maxRBFSequence {
+			return true
+		}
+	}
+	return false
+}
+
+// BumpFeeOptions holds the optional settings of the replacement transaction
+// created by BumpFee.  Zero values leave the setting to the wallet.
+type BumpFeeOptions struct {
+	// ConfTarget is the number of blocks the fee rate is estimated for.
+	// It must not be set together with FeeRate.
+	ConfTarget int64
+
+	// FeeRate is the fee rate in satoshis per virtual byte.  Nodes before
+	// Bitcoin Core 0.21 expect a fee rate in BTC per kilo byte instead.
+	FeeRate btcutil.Amount
+
+	// Replaceable is whether the replacement transaction signals that it
+	// may be replaced in turn.  The wallet makes it replaceable when nil.
+	Replaceable *bool
+
+	// EstimateMode is the mode the fee rate is estimated in.
+	EstimateMode EstimateSmartFeeMode
+}
+
+// MarshalJSON encodes the options as the server expects them, leaving out the
+// settings which are left to the wallet.
+func (o BumpFeeOptions) MarshalJSON() ([]byte, error) {
+	options := struct {
+		ConfTarget   int64                `json:"conf_target,omitempty"`
+		FeeRate      int64                `json:"fee_rate,omitempty"`
+		Replaceable  *bool                `json:"replaceable,omitempty"`
+		EstimateMode EstimateSmartFeeMode `json:"estimate_mode,omitempty"`
+	}{
+		ConfTarget:  o.ConfTarget,
+		FeeRate:     int64(o.FeeRate),
+		Replaceable: o.Replaceable,
+	}
+	if o.EstimateMode != EstimateModeUnset {
+		options.EstimateMode = o.EstimateMode
+	}
+	return json.Marshal(options)
+}
+
+// BumpFeeResult models the data returned from the bumpfee command.
+type BumpFeeResult struct {
+	// TxID is the hash of the replacement transaction.
+	TxID chainhash.Hash
+
+	// OrigFee is the fee of the replaced transaction and Fee the one of
+	// the replacement transaction.
+	OrigFee btcutil.Amount
+	Fee     btcutil.Amount
+
+	// Errors are the errors the wallet ran into, if any.
+	Errors []string
+}
+
+// UnmarshalJSON decodes the result of the bumpfee command, converting the fees
+// in BTC to btcutil.Amount.
+func (r *BumpFeeResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		TxID    string   `json:"txid"`
+		OrigFee float64  `json:"origfee"`
+		Fee     float64  `json:"fee"`
+		Errors  []string `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	txHash, err := chainhash.NewHashFromStr(result.TxID)
+	if err != nil {
+		return err
+	}
+	origFee, err := btcutil.NewAmount(result.OrigFee)
+	if err != nil {
+		return err
+	}
+	fee, err := btcutil.NewAmount(result.Fee)
+	if err != nil {
+		return err
+	}
+
+	*r = BumpFeeResult{
+		TxID:    *txHash,
+		OrigFee: origFee,
+		Fee:     fee,
+		Errors:  result.Errors,
+	}
+	return nil
+}
+
+// FutureBumpFeeResult is a future promise to deliver the result of a
+// BumpFeeAsync RPC invocation (or an applicable error).
+type FutureBumpFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the hash
+// and fee of the replacement transaction.
+func (r FutureBumpFeeResult) Receive() (*BumpFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isNotReplaceable(err) {
+			return nil, &ErrTxNotReplaceable{Err: err}
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a bumpfee result object.
+	var bumpResult BumpFeeResult
+	err = json.Unmarshal(res, &bumpResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bumpResult, nil
+}
+
+// BumpFeeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See BumpFee for the blocking version and more details.
+func (c *Client) BumpFeeAsync(ctx context.Context, txHash *chainhash.Hash, opts *BumpFeeOptions) FutureBumpFeeResult {
+	params := []interface{}{txHash.String()}
+	if opts != nil {
+		params = append(params, opts)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureBumpFeeResult(c.RawRequestAsync(ctx, "bumpfee", rawParams))
+}
+
+// BumpFee replaces the passed wallet transaction, which must still be
+// unconfirmed, by one paying a higher fee, as allowed by BIP 125.  Nil options
+// leave the new fee rate to the fee estimation of the wallet.
+//
+// An *ErrTxNotReplaceable is returned when the transaction does not signal
+// that it may be replaced.  See SignalsRBF to check this beforehand.
+func (c *Client) BumpFee(ctx context.Context, txHash *chainhash.Hash, opts *BumpFeeOptions) (*BumpFeeResult, error) {
+	return c.BumpFeeAsync(ctx, txHash, opts).Receive()
+}
@@ -0,0 +1,131 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSignalsRBF(t *testing.T) {
+	tests := []struct {
+		name      string
+		sequences []uint32
+		want      bool
+	}{
+		{"no inputs", nil, false},
+		{"final", []uint32{wire.MaxTxInSequenceNum}, false},
+		{"locktime only", []uint32{wire.MaxTxInSequenceNum - 1}, false},
+		{"signaling", []uint32{wire.MaxTxInSequenceNum - 2}, true},
+		{"relative locktime", []uint32{144}, true},
+		{"one of many", []uint32{wire.MaxTxInSequenceNum, 0xfffffffd,
+			wire.MaxTxInSequenceNum - 1}, true},
+	}
+	for _, test := range tests {
+		tx := wire.NewMsgTx(2)
+		for _, sequence := range test.sequences {
+			txIn := wire.NewTxIn(&wire.OutPoint{}, nil, nil)
+			txIn.Sequence = sequence
+			tx.AddTxIn(txIn)
+		}
+		if got := SignalsRBF(tx); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	const (
+		origTxID = "9b0fc92260312ce44e74ef369f5c66bbb85848f2eddd5a7a1cde251e54ccfdd5"
+		bumpTxID = "a1f6cbd6e6f5c6c2a8f2f0b7d8a58b7e8e3d2b9e2c1e1c6f3b4f5e8a6c9d0e1f"
+	)
+
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+
+		var txid string
+		json.Unmarshal(req.Params[0], &txid)
+		if txid != origTxID {
+			// Reply like Bitcoin Core 25 does for transactions
+			// without an input signaling replaceability.
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCWallet,
+				"Transaction is not BIP 125 replaceable")
+		}
+		return json.RawMessage(`{
+  "txid": "` + bumpTxID + `",
+  "origfee": 0.00000141,
+  "fee": 0.00000564,
+  "errors": [
+  ]
+}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	txHash, _ := chainhash.NewHashFromStr(origTxID)
+	replaceable := false
+	result, err := client.BumpFee(ctx, txHash, &BumpFeeOptions{
+		FeeRate:      4,
+		Replaceable:  &replaceable,
+		EstimateMode: EstimateModeUnset,
+	})
+	if err != nil {
+		t.Fatalf("BumpFee: %v", err)
+	}
+	if result.TxID.String() != bumpTxID || result.OrigFee != 141 ||
+		result.Fee != 564 || len(result.Errors) != 0 {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	_, err = client.BumpFee(ctx, txHash, nil)
+	if err != nil {
+		t.Fatalf("BumpFee: %v", err)
+	}
+	_, err = client.BumpFee(ctx, txHash, &BumpFeeOptions{
+		ConfTarget:   6,
+		EstimateMode: EstimateModeConservative,
+	})
+	if err != nil {
+		t.Fatalf("BumpFee: %v", err)
+	}
+
+	_, err = client.BumpFee(ctx, &chainhash.Hash{}, nil)
+	var notReplaceable *ErrTxNotReplaceable
+	if !errors.As(err, &notReplaceable) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCWallet {
+		t.Fatalf("server error not wrapped: %v", err)
+	}
+
+	want := []string{
+		`["` + origTxID + `",{"fee_rate":4,"replaceable":false}]`,
+		`["` + origTxID + `"]`,
+		`["` + origTxID + `",{"conf_target":6,"estimate_mode":"CONSERVATIVE"}]`,
+		`["` + chainhash.Hash{}.String() + `"]`,
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(params) != len(want) {
+		t.Fatalf("got params %q, want %q", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d: sent params %s, want %s", i, params[i], want[i])
+		}
+	}
+}
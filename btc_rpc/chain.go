@@ -10,10 +10,13 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/gcs"
+	"github.com/btcsuite/btcutil/gcs/builder"
 )
 
 // FutureGetBestBlockHashResult is a future promise to deliver the result of a
@@ -58,10 +61,14 @@ func (c *Client) GetBestBlockHash(ctx context.Context) (*chainhash.Hash, error)
 type FutureGetBlockResult chan *response
 
 // Receive waits for the response promised by the future and returns the raw
-// block requested from the server given its hash.
+// block requested from the server given its hash.  An *ErrBlockPruned is
+// returned when the node pruned the block.
 func (r FutureGetBlockResult) Receive() (*wire.MsgBlock, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
+		if isBlockPruned(err) {
+			return nil, &ErrBlockPruned{Err: err}
+		}
 		return nil, err
 	}
 
@@ -115,10 +122,14 @@ func (c *Client) GetBlock(ctx context.Context, blockHash *chainhash.Hash) (*wire
 type FutureGetBlockVerboseResult chan *response
 
 // Receive waits for the response promised by the future and returns the data
-// structure from the server with information about the requested block.
+// structure from the server with information about the requested block.  An
+// *ErrBlockPruned is returned when the node pruned the block.
 func (r FutureGetBlockVerboseResult) Receive() (*btcjson.GetBlockVerboseResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
+		if isBlockPruned(err) {
+			return nil, &ErrBlockPruned{Err: err}
+		}
 		return nil, err
 	}
 
@@ -155,30 +166,190 @@ func (c *Client) GetBlockVerbose(ctx context.Context, blockHash *chainhash.Hash)
 	return c.GetBlockVerboseAsync(ctx, blockHash).Receive()
 }
 
-// GetBlockVerboseTxAsync returns an instance of a type that can be used to get
-// the result of the RPC at some future time by invoking the Receive function on
-// the returned instance.
-//
-// See GetBlockVerboseTx or the blocking version and more details.
-func (c *Client) GetBlockVerboseTxAsync(ctx context.Context, blockHash *chainhash.Hash) FutureGetBlockVerboseResult {
+// ScriptPubKeyResult models the script of a transaction output as returned by
+// getblock with a verbosity of 2 or 3.
+type ScriptPubKeyResult struct {
+	Asm  string `json:"asm"`
+	Desc string `json:"desc,omitempty"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+
+	// Address is set by Bitcoin Core 22 and later, while older versions
+	// set ReqSigs and Addresses instead.
+	Address   string   `json:"address,omitempty"`
+	ReqSigs   int32    `json:"reqSigs,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// AddressList returns the addresses of the script regardless of the format the
+// node reported them in.
+func (s *ScriptPubKeyResult) AddressList() []string {
+	if s.Address != "" {
+		return []string{s.Address}
+	}
+	return s.Addresses
+}
+
+// PrevoutResult models the output spent by a transaction input as returned by
+// getblock with a verbosity of 3.  The value is in BTC.
+type PrevoutResult struct {
+	Generated    bool               `json:"generated"`
+	Height       int64              `json:"height"`
+	Value        float64            `json:"value"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// VinResult models a transaction input as returned by getblock with a
+// verbosity of 2 or 3.  Coinbase inputs only set Coinbase, Sequence and, for
+// segwit blocks, Witness.
+type VinResult struct {
+	Coinbase  string             `json:"coinbase,omitempty"`
+	Txid      string             `json:"txid,omitempty"`
+	Vout      uint32             `json:"vout"`
+	ScriptSig *btcjson.ScriptSig `json:"scriptSig,omitempty"`
+	Witness   []string           `json:"txinwitness,omitempty"`
+	Sequence  uint32             `json:"sequence"`
+
+	// Prevout is only set with a verbosity of 3.
+	Prevout *PrevoutResult `json:"prevout,omitempty"`
+}
+
+// IsCoinBase returns whether the input is the input of a coinbase transaction.
+func (v *VinResult) IsCoinBase() bool {
+	return v.Coinbase != ""
+}
+
+// VoutResult models a transaction output as returned by getblock with a
+// verbosity of 2 or 3.  The value is in BTC.
+type VoutResult struct {
+	Value        float64            `json:"value"`
+	N            uint32             `json:"n"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// TxRawResult models a transaction as returned by getblock with a verbosity of
+// 2 or 3.  Hash is the witness hash, which equals Txid for transactions
+// without witness data.
+type TxRawResult struct {
+	Txid     string       `json:"txid"`
+	Hash     string       `json:"hash"`
+	Version  int32        `json:"version"`
+	Size     int32        `json:"size"`
+	VSize    int32        `json:"vsize"`
+	Weight   int32        `json:"weight"`
+	LockTime uint32       `json:"locktime"`
+	Vin      []VinResult  `json:"vin"`
+	Vout     []VoutResult `json:"vout"`
+	Hex      string       `json:"hex"`
+
+	// Fee is the fee paid by the transaction in BTC.  It is only set with
+	// a verbosity of 2 or 3 by nodes with the undo data of the block, and
+	// never for coinbase transactions.
+	Fee *float64 `json:"fee,omitempty"`
+}
+
+// GetBlockVerboseTxResult models the data returned from the getblock command
+// with a verbosity of 2 or 3.
+type GetBlockVerboseTxResult struct {
+	Hash          string        `json:"hash"`
+	Confirmations int64         `json:"confirmations"`
+	StrippedSize  int32         `json:"strippedsize"`
+	Size          int32         `json:"size"`
+	Weight        int32         `json:"weight"`
+	Height        int64         `json:"height"`
+	Version       int32         `json:"version"`
+	VersionHex    string        `json:"versionHex"`
+	MerkleRoot    string        `json:"merkleroot"`
+	Tx            []TxRawResult `json:"tx"`
+	Time          int64         `json:"time"`
+	MedianTime    int64         `json:"mediantime"`
+	Nonce         uint32        `json:"nonce"`
+	Bits          string        `json:"bits"`
+	Difficulty    float64       `json:"difficulty"`
+	ChainWork     string        `json:"chainwork"`
+	NTx           int64         `json:"nTx"`
+	PreviousHash  string        `json:"previousblockhash,omitempty"`
+	NextHash      string        `json:"nextblockhash,omitempty"`
+}
+
+// minVerbosity3Version is the first Bitcoin Core version which returns the
+// spent outputs with a getblock verbosity of 3.  Older versions treat it like
+// a verbosity of 2.
+const minVerbosity3Version = 230000
+
+// FutureGetBlockVerboseTxResult is a future promise to deliver the result of a
+// GetBlockVerboseTxAsync RPC invocation (or an applicable error).
+type FutureGetBlockVerboseTxResult chan *response
+
+// Receive waits for the response promised by the future and returns the data
+// structure from the server with information about the requested block and
+// its transactions.  An *ErrBlockPruned is returned when the node pruned the
+// block.
+func (r FutureGetBlockVerboseTxResult) Receive() (*GetBlockVerboseTxResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isBlockPruned(err) {
+			return nil, &ErrBlockPruned{Err: err}
+		}
+		return nil, err
+	}
+
+	// Unmarshal the raw result into a GetBlockVerboseTxResult.
+	var blockResult GetBlockVerboseTxResult
+	err = json.Unmarshal(res, &blockResult)
+	if err != nil {
+		return nil, err
+	}
+	return &blockResult, nil
+}
+
+// getBlockVerbosityAsync sends a getblock request with the passed verbosity.
+func (c *Client) getBlockVerbosityAsync(ctx context.Context, blockHash *chainhash.Hash, verbosity int) FutureGetBlockVerboseTxResult {
 	hash := ""
 	if blockHash != nil {
 		hash = blockHash.String()
 	}
 
-	cmd := btcjson.NewGetBlockCmd(hash, btcjson.Bool(true), btcjson.Bool(true))
-	return c.sendCmd(ctx, cmd)
+	// The btcjson package only knows the verbose flags of btcd, so the
+	// command is sent as a raw request with a numeric verbosity.
+	rawParams, err := marshalParams([]interface{}{hash, verbosity})
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureGetBlockVerboseTxResult(c.RawRequestAsync(ctx, "getblock",
+		rawParams))
+}
+
+// GetBlockVerboseTxAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetBlockVerboseTx for the blocking version and more details.
+func (c *Client) GetBlockVerboseTxAsync(ctx context.Context, blockHash *chainhash.Hash) FutureGetBlockVerboseTxResult {
+	return c.getBlockVerbosityAsync(ctx, blockHash, 2)
 }
 
 // GetBlockVerboseTx returns a data structure from the server with information
-// about a block and its transactions given its hash.
+// about a block and its decoded transactions given its hash.
 //
+// See GetBlockVerboseTxV3 to retrieve the outputs spent by the transactions as
+// well.
 // See GetBlockVerbose if only transaction hashes are preferred.
 // See GetBlock to retrieve a raw block instead.
-func (c *Client) GetBlockVerboseTx(ctx context.Context, blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+func (c *Client) GetBlockVerboseTx(ctx context.Context, blockHash *chainhash.Hash) (*GetBlockVerboseTxResult, error) {
 	return c.GetBlockVerboseTxAsync(ctx, blockHash).Receive()
 }
 
+// GetBlockVerboseTxV3 returns the same data as GetBlockVerboseTx along with the
+// output spent by every non-coinbase input in its Prevout.  This needs
+// Bitcoin Core 23 or later, older nodes result in an *ErrNodeVersion.
+func (c *Client) GetBlockVerboseTxV3(ctx context.Context, blockHash *chainhash.Hash) (*GetBlockVerboseTxResult, error) {
+	if err := c.requireNodeVersion(ctx, minVerbosity3Version); err != nil {
+		return nil, err
+	}
+	return c.getBlockVerbosityAsync(ctx, blockHash, 3).Receive()
+}
+
 // FutureGetBlockCountResult is a future promise to deliver the result of a
 // GetBlockCountAsync RPC invocation (or an applicable error).
 type FutureGetBlockCountResult chan *response
@@ -434,14 +605,14 @@ type FutureGetMempoolEntryResult chan *response
 // Receive waits for the response promised by the future and returns a data
 // structure with information about the transaction in the memory pool given
 // its hash.
-func (r FutureGetMempoolEntryResult) Receive() (*btcjson.GetMempoolEntryResult, error) {
+func (r FutureGetMempoolEntryResult) Receive() (*GetMempoolEntryResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
 		return nil, err
 	}
 
-	// Unmarshal the result as an array of strings.
-	var mempoolEntryResult btcjson.GetMempoolEntryResult
+	// Unmarshal the result as a getmempoolentry result object.
+	var mempoolEntryResult GetMempoolEntryResult
 	err = json.Unmarshal(res, &mempoolEntryResult)
 	if err != nil {
 		return nil, err
@@ -462,7 +633,10 @@ func (c *Client) GetMempoolEntryAsync(ctx context.Context, txHash string) Future
 
 // GetMempoolEntry returns a data structure with information about the
 // transaction in the memory pool given its hash.
-func (c *Client) GetMempoolEntry(ctx context.Context, txHash string) (*btcjson.GetMempoolEntryResult, error) {
+//
+// See GetMempoolAncestorsVerbose to retrieve the same data for the unconfirmed
+// transactions it spends from.
+func (c *Client) GetMempoolEntry(ctx context.Context, txHash string) (*GetMempoolEntryResult, error) {
 	return c.GetMempoolEntryAsync(ctx, txHash).Receive()
 }
 
@@ -623,17 +797,22 @@ func (r FutureVerifyChainResult) Receive() (bool, error) {
 // returned instance.
 //
 // See VerifyChain for the blocking version and more details.
-func (c *Client) VerifyChainAsync(ctx context.Context) FutureVerifyChainResult {
-	cmd := btcjson.NewVerifyChainCmd(nil, nil)
+func (c *Client) VerifyChainAsync(ctx context.Context, checkLevel, numBlocks *int32) FutureVerifyChainResult {
+	cmd := btcjson.NewVerifyChainCmd(checkLevel, numBlocks)
 	return c.sendCmd(ctx, cmd)
 }
 
 // VerifyChain requests the server to verify the block chain database using
-// the default check level and number of blocks to verify.
+// the passed check level and number of blocks to verify, or the defaults of
+// the server for those which are nil.  Bitcoin Core defaults to a check level
+// of 3 and the last 6 blocks.  A check level of 0 only reads the blocks from
+// disk and a number of blocks of 0 verifies all of them.
 //
-// See VerifyChainLevel and VerifyChainBlocks to override the defaults.
-func (c *Client) VerifyChain(ctx context.Context) (bool, error) {
-	return c.VerifyChainAsync(ctx).Receive()
+// Pruned nodes only verify the blocks they still store.  Thorough checks of many
+// blocks take hours, so the ResponseHeaderTimeout of the connection
+// configuration does not apply and the context should allow for them.
+func (c *Client) VerifyChain(ctx context.Context, checkLevel, numBlocks *int32) (bool, error) {
+	return c.VerifyChainAsync(ctx, checkLevel, numBlocks).Receive()
 }
 
 // VerifyChainLevelAsync returns an instance of a type that can be used to get
@@ -653,8 +832,8 @@ func (c *Client) VerifyChainLevelAsync(ctx context.Context, checkLevel int32) Fu
 // increasing the amount of checks done as consequently how long the
 // verification takes.
 //
-// See VerifyChain to use the default check level and VerifyChainBlocks to
-// override the number of blocks to verify.
+// See VerifyChain to use the defaults and VerifyChainBlocks to override the
+// number of blocks to verify.
 func (c *Client) VerifyChainLevel(ctx context.Context, checkLevel int32) (bool, error) {
 	return c.VerifyChainLevelAsync(ctx, checkLevel).Receive()
 }
@@ -792,8 +971,8 @@ func (c *Client) RescanBlocks(ctx context.Context, blockHashes []chainhash.Hash)
 // InvalidateBlockAsync RPC invocation (or an applicable error).
 type FutureInvalidateBlockResult chan *response
 
-// Receive waits for the response promised by the future and returns the raw
-// block requested from the server given its hash.
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when invalidating the block.
 func (r FutureInvalidateBlockResult) Receive() error {
 	_, err := receiveFuture(r)
 
@@ -815,11 +994,43 @@ func (c *Client) InvalidateBlockAsync(ctx context.Context, blockHash *chainhash.
 	return c.sendCmd(ctx, cmd)
 }
 
-// InvalidateBlock invalidates a specific block.
+// InvalidateBlock invalidates a specific block.  The node treats the block and
+// its descendants as invalid and reorganizes to the best valid chain, which
+// makes it the way to simulate reorganizations on regtest.
+//
+// See ReconsiderBlock to undo it.
 func (c *Client) InvalidateBlock(ctx context.Context, blockHash *chainhash.Hash) error {
 	return c.InvalidateBlockAsync(ctx, blockHash).Receive()
 }
 
+// FutureReconsiderBlockResult is a future promise to deliver the result of a
+// ReconsiderBlockAsync RPC invocation (or an applicable error).
+type FutureReconsiderBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when reconsidering the block.
+func (r FutureReconsiderBlockResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ReconsiderBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ReconsiderBlock for the blocking version and more details.
+func (c *Client) ReconsiderBlockAsync(ctx context.Context, blockHash *chainhash.Hash) FutureReconsiderBlockResult {
+	cmd := btcjson.NewReconsiderBlockCmd(blockHash.String())
+	return c.sendCmd(ctx, cmd)
+}
+
+// ReconsiderBlock removes the invalidity status of the passed block and its
+// descendants set by InvalidateBlock, after which the node reorganizes back to
+// them if they make the chain with the most work.
+func (c *Client) ReconsiderBlock(ctx context.Context, blockHash *chainhash.Hash) error {
+	return c.ReconsiderBlockAsync(ctx, blockHash).Receive()
+}
+
 // FutureGetCFilterResult is a future promise to deliver the result of a
 // GetCFilterAsync RPC invocation (or an applicable error).
 type FutureGetCFilterResult chan *response
@@ -928,3 +1139,122 @@ func (c *Client) GetCFilterHeader(ctx context.Context, blockHash *chainhash.Hash
 	filterType wire.FilterType) (*wire.MsgCFHeaders, error) {
 	return c.GetCFilterHeaderAsync(ctx, blockHash, filterType).Receive()
 }
+
+// ErrBlockFilterIndexDisabled is returned by GetBlockFilter when the server
+// was started without -blockfilterindex, or without an index for the
+// requested filter type.
+var ErrBlockFilterIndexDisabled = errors.New("block filter index is not " +
+	"enabled on the server")
+
+// BlockFilterBasic is the only filter type defined by BIP158 and the default
+// of GetBlockFilter.
+const BlockFilterBasic = "basic"
+
+// GetBlockFilterResult models the data returned from the getblockfilter
+// command with the filter and header decoded.
+type GetBlockFilterResult struct {
+	// Filter is the serialized filter, as passed to BlockFilterMatches.
+	Filter []byte
+
+	// Header is the filter header committing to the filter and the
+	// headers of all previous blocks.
+	Header chainhash.Hash
+}
+
+// FutureGetBlockFilterResult is a future promise to deliver the result of a
+// GetBlockFilterAsync RPC invocation (or an applicable error).
+type FutureGetBlockFilterResult chan *response
+
+// Receive waits for the response promised by the future and returns the block
+// filter and its header.
+func (r FutureGetBlockFilterResult) Receive() (*GetBlockFilterResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isBlockFilterIndexDisabled(err) {
+			return nil, ErrBlockFilterIndexDisabled
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a getblockfilter result object.
+	var filterResult struct {
+		Filter string `json:"filter"`
+		Header string `json:"header"`
+	}
+	err = json.Unmarshal(res, &filterResult)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := hex.DecodeString(filterResult.Filter)
+	if err != nil {
+		return nil, err
+	}
+	header, err := chainhash.NewHashFromStr(filterResult.Header)
+	if err != nil {
+		return nil, err
+	}
+	return &GetBlockFilterResult{Filter: filter, Header: *header}, nil
+}
+
+// GetBlockFilterAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockFilter for the blocking version and more details.
+func (c *Client) GetBlockFilterAsync(ctx context.Context, blockHash *chainhash.Hash, filterType string) FutureGetBlockFilterResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+	params := []interface{}{hash}
+	if filterType != "" {
+		params = append(params, filterType)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetBlockFilterResult(c.RawRequestAsync(ctx, "getblockfilter",
+		rawParams))
+}
+
+// GetBlockFilter returns the BIP158 compact filter of the block with the passed
+// hash along with its filter header.  An empty filter type means
+// BlockFilterBasic.
+//
+// NOTE: Bitcoin Core only serves block filters when started with
+// -blockfilterindex.  ErrBlockFilterIndexDisabled is returned otherwise.
+func (c *Client) GetBlockFilter(ctx context.Context, blockHash *chainhash.Hash, filterType string) (*GetBlockFilterResult, error) {
+	return c.GetBlockFilterAsync(ctx, blockHash, filterType).Receive()
+}
+
+// BlockFilterMatches reports whether any of the passed scripts, usually the
+// output scripts paying to the caller's addresses, is a member of the passed
+// basic block filter of the block with the passed hash.  The block hash is
+// needed since the filter key is derived from it.
+//
+// Like every compact filter match, a true result may be a false positive, so
+// the block has to be fetched to confirm it.  A false result is definitive.
+func BlockFilterMatches(filter []byte, blockHash *chainhash.Hash, scripts [][]byte) (bool, error) {
+	if len(scripts) == 0 {
+		return false, nil
+	}
+
+	gcsFilter, err := gcs.FromNBytes(builder.DefaultP, builder.DefaultM,
+		filter)
+	if err != nil {
+		return false, err
+	}
+
+	// An empty filter has no members, and gcs refuses to match against it.
+	if gcsFilter.N() == 0 {
+		return false, nil
+	}
+
+	key := builder.DeriveKey(blockHash)
+	return gcsFilter.MatchAny(key, scripts)
+}
@@ -0,0 +1,453 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// testGenesisBlockVerbosity2 is the mainnet genesis block as returned by
+// getblock with a verbosity of 2 by a node older than Bitcoin Core 22, which
+// still reported reqSigs and addresses.
+const testGenesisBlockVerbosity2 = `{
+  "hash": "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+  "confirmations": 800000,
+  "strippedsize": 285,
+  "size": 285,
+  "weight": 1140,
+  "height": 0,
+  "version": 1,
+  "versionHex": "00000001",
+  "merkleroot": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+  "tx": [{
+    "txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+    "hash": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+    "version": 1,
+    "size": 204,
+    "vsize": 204,
+    "weight": 816,
+    "locktime": 0,
+    "vin": [{
+      "coinbase": "04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73",
+      "sequence": 4294967295
+    }],
+    "vout": [{
+      "value": 50.00000000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "04678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5f OP_CHECKSIG",
+        "hex": "4104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac",
+        "reqSigs": 1,
+        "type": "pubkey",
+        "addresses": ["1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"]
+      }
+    }],
+    "hex": "` + genesisCoinbaseTx + `"
+  }],
+  "time": 1231006505,
+  "mediantime": 1231006505,
+  "nonce": 2083236893,
+  "bits": "1d00ffff",
+  "difficulty": 1,
+  "chainwork": "0000000000000000000000000000000000000000000000000000000100010001",
+  "nTx": 1,
+  "nextblockhash": "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+}`
+
+// testSegwitBlockVerbosity3 is a block with a segwit coinbase, a P2WPKH spend
+// and a taproot key path spend in the format Bitcoin Core 25 returns for a
+// getblock verbosity of 3.
+const testSegwitBlockVerbosity3 = `{
+  "hash": "00000000000000000002a2d5b7b12b3e7a5b0b1c1b5f3c9f3f47e7d0c8f6b3a1",
+  "confirmations": 3,
+  "height": 800000,
+  "version": 536870912,
+  "versionHex": "20000000",
+  "merkleroot": "7e1e3c1b4f7d8b0c5e8a1f9f6c0b2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c",
+  "time": 1690168629,
+  "mediantime": 1690165851,
+  "nonce": 106861918,
+  "bits": "17053894",
+  "difficulty": 53911173001054.59,
+  "chainwork": "00000000000000000000000000000000000000004d5a3b7a6c5e4d3c2b1a0f0e",
+  "nTx": 3,
+  "previousblockhash": "00000000000000000002a0b5b8e1f1c3c3fb4e8d7b0e6c1b0f2a3e4d5c6b7a89",
+  "strippedsize": 545,
+  "size": 794,
+  "weight": 2429,
+  "tx": [{
+    "txid": "b75ca3106ed100521aa50e3ec267a06431c6319538898b25e1b757a5736f5fb4",
+    "hash": "1e9b36b1e1f9e0d4a6ea7dcee4e2f1d9f66c1b1f7dd8c3fd5c2cf1c4b1b3a6d2",
+    "version": 1,
+    "size": 199,
+    "vsize": 172,
+    "weight": 688,
+    "locktime": 0,
+    "vin": [{
+      "coinbase": "0300350c0120",
+      "txinwitness": ["0000000000000000000000000000000000000000000000000000000000000000"],
+      "sequence": 4294967295
+    }],
+    "vout": [{
+      "value": 6.34554833,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "0 7086320071974eef5e72eaa01dd9096e10c0383483855ea6b344259c244f73c2",
+        "desc": "addr(bc1qwzrryqr3ja8w7hnja2spmkgfdcgvqwp5swz4af4ngsjecfz0w0pqud7k38)#y9upg3rz",
+        "hex": "00207086320071974eef5e72eaa01dd9096e10c0383483855ea6b344259c244f73c2",
+        "address": "bc1qwzrryqr3ja8w7hnja2spmkgfdcgvqwp5swz4af4ngsjecfz0w0pqud7k38",
+        "type": "witness_v0_scripthash"
+      }
+    }, {
+      "value": 0.00000000,
+      "n": 1,
+      "scriptPubKey": {
+        "asm": "OP_RETURN aa21a9ed9fbe517a588ccaca585a868f3cf19cb6897e3c26f3351361fb28ac8509e69a7e",
+        "desc": "raw(6a24aa21a9ed9fbe517a588ccaca585a868f3cf19cb6897e3c26f3351361fb28ac8509e69a7e)#00u3ukgk",
+        "hex": "6a24aa21a9ed9fbe517a588ccaca585a868f3cf19cb6897e3c26f3351361fb28ac8509e69a7e",
+        "type": "nulldata"
+      }
+    }],
+    "hex": "00"
+  }, {
+    "txid": "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+    "hash": "5e8c91fbb8e4b3d0cd9f6a1d34b3c6c0c1e26e2f0c1d5b5f1e0e7c9a1d0a6f33",
+    "version": 2,
+    "size": 222,
+    "vsize": 141,
+    "weight": 561,
+    "locktime": 799999,
+    "vin": [{
+      "txid": "9a6d0f6b2a6c8bdbd3c1b5f5d5c6e0a7c1b0d7e6f2a8b9c0d1e2f3a4b5c6d7e8",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "3044022075e2ba1e0e6a0fc8c1b1d3a0fbe1cc0a6a8a2e1b6b1c8d2f6a9e7e0c3b5d1f0a02205a6e3c0b1d5f2a8e7c9b4d0e1f3a6c8b2d5e7f9a1c3b5d7e9f0a2c4b6d8e0f01",
+        "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+      ],
+      "prevout": {
+        "generated": false,
+        "height": 799990,
+        "value": 0.01000000,
+        "scriptPubKey": {
+          "asm": "0 751e76e8199196d454941c45d1b3a323f1433bd6",
+          "desc": "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)#8zl0zxma",
+          "hex": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+          "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+          "type": "witness_v0_keyhash"
+        }
+      },
+      "sequence": 4294967293
+    }],
+    "vout": [{
+      "value": 0.00990000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "1 a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+        "desc": "rawtr(a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c)#8rmhllky",
+        "hex": "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+        "address": "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+        "type": "witness_v1_taproot"
+      }
+    }],
+    "fee": 0.00010000,
+    "hex": "00"
+  }, {
+    "txid": "3c4d6f6a0b1e2c5d8f9a0b3c6d7e8f1a2b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e",
+    "hash": "8f1e2d3c4b5a69788796a5b4c3d2e1f0f1e2d3c4b5a69788796a5b4c3d2e1f00",
+    "version": 2,
+    "size": 150,
+    "vsize": 99,
+    "weight": 396,
+    "locktime": 0,
+    "vin": [{
+      "txid": "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+      "vout": 0,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0"
+      ],
+      "prevout": {
+        "generated": false,
+        "height": 800000,
+        "value": 0.00990000,
+        "scriptPubKey": {
+          "asm": "1 a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+          "desc": "rawtr(a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c)#8rmhllky",
+          "hex": "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+          "address": "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+          "type": "witness_v1_taproot"
+        }
+      },
+      "sequence": 4294967295
+    }],
+    "vout": [{
+      "value": 0.00980000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "0 751e76e8199196d454941c45d1b3a323f1433bd6",
+        "desc": "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)#8zl0zxma",
+        "hex": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+        "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+        "type": "witness_v0_keyhash"
+      }
+    }],
+    "fee": 0.00010000,
+    "hex": "00"
+  }]
+}`
+
+func TestGetBlockVerboseTx(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		params, _ := json.Marshal(req.Params)
+		want := `["000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",2]`
+		if req.Method != "getblock" || string(params) != want {
+			t.Errorf("unexpected request %s %s", req.Method, params)
+		}
+		return json.RawMessage(testGenesisBlockVerbosity2), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	block, err := client.GetBlockVerboseTx(context.Background(),
+		chaincfg.MainNetParams.GenesisHash)
+	if err != nil {
+		t.Fatalf("GetBlockVerboseTx: %v", err)
+	}
+	if block.Height != 0 || block.Weight != 1140 || block.NTx != 1 ||
+		block.PreviousHash != "" || len(block.Tx) != 1 {
+		t.Fatalf("unexpected block %+v", block)
+	}
+	tx := block.Tx[0]
+	if tx.Txid != tx.Hash || tx.VSize != tx.Size || tx.Hex != genesisCoinbaseTx {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+	if len(tx.Vin) != 1 || !tx.Vin[0].IsCoinBase() ||
+		tx.Vin[0].Witness != nil || tx.Vin[0].Prevout != nil {
+		t.Fatalf("unexpected coinbase input %+v", tx.Vin)
+	}
+	addrs := tx.Vout[0].ScriptPubKey.AddressList()
+	if len(addrs) != 1 || addrs[0] != "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa" ||
+		tx.Vout[0].Value != 50 {
+		t.Fatalf("unexpected output %+v", tx.Vout[0])
+	}
+	if tx.Fee != nil {
+		t.Fatalf("unexpected coinbase fee %v", *tx.Fee)
+	}
+}
+
+func TestGetBlockVerboseTxV3(t *testing.T) {
+	blockHash, err := chainhash.NewHashFromStr(
+		"00000000000000000002a2d5b7b12b3e7a5b0b1c1b5f3c9f3f47e7d0c8f6b3a1")
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+
+	var mtx sync.Mutex
+	versionRequests := 0
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		switch req.Method {
+		case "getnetworkinfo":
+			mtx.Lock()
+			versionRequests++
+			mtx.Unlock()
+			return json.RawMessage(`{"version":250000,"subversion":"/Satoshi:25.0.0/"}`), nil
+		case "getblock":
+			params, _ := json.Marshal(req.Params)
+			if string(params) != `["`+blockHash.String()+`",3]` {
+				t.Errorf("unexpected params %s", params)
+			}
+			return json.RawMessage(testSegwitBlockVerbosity3), nil
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		block, err := client.GetBlockVerboseTxV3(ctx, blockHash)
+		if err != nil {
+			t.Fatalf("GetBlockVerboseTxV3: %v", err)
+		}
+		if len(block.Tx) != 3 || block.StrippedSize >= block.Size {
+			t.Fatalf("unexpected block %+v", block)
+		}
+
+		coinbase := block.Tx[0]
+		if !coinbase.Vin[0].IsCoinBase() || len(coinbase.Vin[0].Witness) != 1 ||
+			coinbase.Vin[0].Prevout != nil || coinbase.Txid == coinbase.Hash {
+			t.Fatalf("unexpected coinbase %+v", coinbase)
+		}
+		if addrs := coinbase.Vout[1].ScriptPubKey.AddressList(); len(addrs) != 0 {
+			t.Fatalf("unexpected witness commitment addresses %q", addrs)
+		}
+
+		segwitSpend := block.Tx[1]
+		vin := segwitSpend.Vin[0]
+		if vin.IsCoinBase() || len(vin.Witness) != 2 || vin.Prevout == nil ||
+			vin.Prevout.ScriptPubKey.Type != "witness_v0_keyhash" ||
+			vin.Prevout.Value != 0.01 || vin.Prevout.Height != 799990 {
+			t.Fatalf("unexpected segwit input %+v", vin)
+		}
+		if segwitSpend.Weight != 561 || segwitSpend.VSize != 141 ||
+			segwitSpend.Fee == nil || *segwitSpend.Fee != 0.0001 {
+			t.Fatalf("unexpected segwit spend %+v", segwitSpend)
+		}
+
+		taprootSpend := block.Tx[2]
+		vin = taprootSpend.Vin[0]
+		addrs := vin.Prevout.ScriptPubKey.AddressList()
+		if len(vin.Witness) != 1 || vin.Prevout.ScriptPubKey.Type != "witness_v1_taproot" ||
+			len(addrs) != 1 || addrs[0] != "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr" {
+			t.Fatalf("unexpected taproot input %+v", vin)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if versionRequests != 1 {
+		t.Fatalf("node version requested %d times", versionRequests)
+	}
+}
+
+func TestGetBlockVerboseTxV3OldNode(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getnetworkinfo" {
+			t.Errorf("unexpected %s request", req.Method)
+		}
+		return json.RawMessage(`{"version":220000}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	_, err := client.GetBlockVerboseTxV3(context.Background(),
+		chaincfg.MainNetParams.GenesisHash)
+	var versionErr *ErrNodeVersion
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if versionErr.Version != 220000 || versionErr.MinVersion != 230000 {
+		t.Fatalf("unexpected error %+v", versionErr)
+	}
+}
+
+func TestGetBlockFilter(t *testing.T) {
+	// The testnet3 genesis block and its basic filter are the first test
+	// vector of BIP158.  The filter holds the coinbase output script only.
+	genesis := chaincfg.TestNet3Params.GenesisBlock
+	genesisHash := chaincfg.TestNet3Params.GenesisHash
+	const filterHeader = "21584579b7eb08997773e5aeff3a7f932700042d0ed2a6129012b7d7ae81b750"
+
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		want := `["` + genesisHash.String() + `"]`
+		if got, _ := json.Marshal(req.Params); req.Method != "getblockfilter" ||
+			string(got) != want {
+
+			t.Errorf("unexpected request %s %s", req.Method, got)
+		}
+		return map[string]string{
+			"filter": "019dfca8",
+			"header": filterHeader,
+		}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	result, err := client.GetBlockFilter(context.Background(), genesisHash, "")
+	if err != nil {
+		t.Fatalf("GetBlockFilter: %v", err)
+	}
+	if hex.EncodeToString(result.Filter) != "019dfca8" ||
+		result.Header.String() != filterHeader {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	coinbaseScript := genesis.Transactions[0].TxOut[0].PkScript
+	otherScript := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	otherScript = append(otherScript, 0x88, 0xac)
+	tests := []struct {
+		name    string
+		scripts [][]byte
+		want    bool
+	}{
+		{"coinbase", [][]byte{coinbaseScript}, true},
+		{"other and coinbase", [][]byte{otherScript, coinbaseScript}, true},
+		{"other", [][]byte{otherScript}, false},
+		{"none", nil, false},
+	}
+	for _, test := range tests {
+		matched, err := BlockFilterMatches(result.Filter, genesisHash,
+			test.scripts)
+		if err != nil {
+			t.Fatalf("%s: BlockFilterMatches: %v", test.name, err)
+		}
+		if matched != test.want {
+			t.Fatalf("%s: matched %v, want %v", test.name, matched,
+				test.want)
+		}
+	}
+}
+
+func TestBlockFilterMatches(t *testing.T) {
+	// p2wpkh returns a P2WPKH output script with a key hash made of the
+	// passed byte.
+	p2wpkh := func(b byte) []byte {
+		script := []byte{0x00, 0x14}
+		for i := 0; i < 20; i++ {
+			script = append(script, b)
+		}
+		return script
+	}
+
+	// The filter of the regtest genesis hash holds the P2WPKH scripts of
+	// key hashes 0x01, 0x02 and 0x03.
+	blockHash := chaincfg.RegressionNetParams.GenesisHash
+	filter, _ := hex.DecodeString("031feac823d7e1b15c")
+	for b := byte(1); b < 8; b++ {
+		matched, err := BlockFilterMatches(filter, blockHash,
+			[][]byte{p2wpkh(b)})
+		if err != nil {
+			t.Fatalf("BlockFilterMatches: %v", err)
+		}
+		if want := b <= 3; matched != want {
+			t.Fatalf("script %d: matched %v, want %v", b, matched, want)
+		}
+	}
+
+	// Blocks without any scripts have an empty filter.
+	matched, err := BlockFilterMatches([]byte{0x00}, blockHash,
+		[][]byte{p2wpkh(1)})
+	if err != nil || matched {
+		t.Fatalf("empty filter: matched %v, %v", matched, err)
+	}
+}
+
+func TestGetBlockFilterIndexDisabled(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMisc,
+			"Index is not enabled for filtertype basic")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	_, err := client.GetBlockFilter(context.Background(),
+		chaincfg.MainNetParams.GenesisHash, BlockFilterBasic)
+	if err != ErrBlockFilterIndexDisabled {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sectoken-dev/tools/chainclient"
+)
+
+// chainClient adapts a Client to the chainclient.ChainClient interface.
+type chainClient struct {
+	c *Client
+}
+
+// ChainClient returns the client as a chainclient.ChainClient so it can be used
+// by code shared between the supported chains.
+func (c *Client) ChainClient() chainclient.ChainClient {
+	return chainClient{c: c}
+}
+
+// toChainHash converts a hash of this package to the shared hash type.
+func toChainHash(hash *chainhash.Hash) *chainclient.Hash {
+	h := chainclient.Hash(*hash)
+	return &h
+}
+
+// fromChainHash converts a shared hash to the hash type of this package.
+func fromChainHash(hash *chainclient.Hash) *chainhash.Hash {
+	if hash == nil {
+		return nil
+	}
+	h := chainhash.Hash(*hash)
+	return &h
+}
+
+// GetBlockCount returns the number of blocks in the longest block chain.
+func (a chainClient) GetBlockCount(ctx context.Context) (int64, error) {
+	return a.c.GetBlockCount(ctx)
+}
+
+// GetBlockHash returns the hash of the block in the best block chain at the
+// given height.
+func (a chainClient) GetBlockHash(ctx context.Context, blockHeight int64) (*chainclient.Hash, error) {
+	hash, err := a.c.GetBlockHash(ctx, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+	return toChainHash(hash), nil
+}
+
+// GetBestBlock returns the hash and height of the block in the longest (best)
+// chain.  The height is read from the header of the best block, as the
+// getbestblock command of Client.GetBestBlock is a btcd extension nodes do not
+// implement.
+func (a chainClient) GetBestBlock(ctx context.Context) (*chainclient.Hash, int32, error) {
+	hash, err := a.c.GetBestBlockHash(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	header, err := a.c.GetBlockHeaderVerbose(ctx, hash)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toChainHash(hash), header.Height, nil
+}
+
+// GetRawTransaction returns the serialized transaction with the given hash.
+// The bytes are passed on as the server sent them, without deserializing the
+// transaction.
+func (a chainClient) GetRawTransaction(ctx context.Context, txHash *chainclient.Hash) ([]byte, error) {
+	res, err := receiveFuture(a.c.GetRawTransactionAsync(ctx, fromChainHash(txHash)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string and decode the hex to raw bytes.
+	var txHex string
+	err = json.Unmarshal(res, &txHex)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(txHex)
+}
+
+// SendRawTransaction submits the serialized transaction to the server which
+// will then relay it to the network.
+func (a chainClient) SendRawTransaction(ctx context.Context, tx []byte) (*chainclient.Hash, error) {
+	hash, err := a.c.sendRawTransactionHexAsync(ctx, hex.EncodeToString(tx),
+		false).Receive()
+	if err != nil {
+		return nil, err
+	}
+	return toChainHash(hash), nil
+}
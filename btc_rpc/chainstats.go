@@ -0,0 +1,245 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// Hash types accepted by GetTxOutSetInfo.  Bitcoin Core 26 renamed
+// hash_serialized_2 to hash_serialized_3, which hashes the UTXO set the same
+// way.
+const (
+	TxOutSetHashSerialized2 = "hash_serialized_2"
+	TxOutSetHashSerialized3 = "hash_serialized_3"
+	TxOutSetHashMuHash      = "muhash"
+	TxOutSetHashNone        = "none"
+)
+
+// GetChainTxStatsResult models the data returned from the getchaintxstats
+// command.
+type GetChainTxStatsResult struct {
+	// Time is the timestamp of the final block of the window and TxCount
+	// the total number of transactions in the chain up to it.
+	Time    int64 `json:"time"`
+	TxCount int64 `json:"txcount"`
+
+	// WindowFinalBlockHash is the hash of the final block of the window.
+	// Nodes before Bitcoin Core 0.21 do not report its height.
+	WindowFinalBlockHash   string `json:"window_final_block_hash"`
+	WindowFinalBlockHeight int64  `json:"window_final_block_height"`
+
+	// WindowBlockCount is the size of the window in blocks and
+	// WindowInterval the time it spans in seconds.
+	WindowBlockCount int64 `json:"window_block_count"`
+	WindowInterval   int64 `json:"window_interval"`
+
+	// WindowTxCount is the number of transactions in the window.  It is
+	// zero for empty windows.
+	WindowTxCount int64 `json:"window_tx_count"`
+
+	// TxRate is the average number of transactions per second in the
+	// window.  It is zero for windows which do not span any time.
+	TxRate float64 `json:"txrate"`
+}
+
+// FutureGetChainTxStatsResult is a future promise to deliver the result of a
+// GetChainTxStatsAsync RPC invocation (or an applicable error).
+type FutureGetChainTxStatsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// statistics about the transactions in the chain.
+func (r FutureGetChainTxStatsResult) Receive() (*GetChainTxStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getchaintxstats result object.
+	var result GetChainTxStatsResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetChainTxStatsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetChainTxStats for the blocking version and more details.
+func (c *Client) GetChainTxStatsAsync(ctx context.Context, nBlocks *int64, blockHash *chainhash.Hash) FutureGetChainTxStatsResult {
+	var params []interface{}
+	if blockHash != nil {
+		params = []interface{}{nBlocks, blockHash.String()}
+	} else if nBlocks != nil {
+		params = []interface{}{*nBlocks}
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetChainTxStatsResult(c.RawRequestAsync(ctx,
+		"getchaintxstats", rawParams))
+}
+
+// GetChainTxStats returns statistics about the transactions in the chain up
+// to the passed block, or the best block when nil, and in a window of nBlocks
+// blocks ending with it.  The window defaults to about a month of blocks when
+// nBlocks is nil.
+func (c *Client) GetChainTxStats(ctx context.Context, nBlocks *int64, blockHash *chainhash.Hash) (*GetChainTxStatsResult, error) {
+	return c.GetChainTxStatsAsync(ctx, nBlocks, blockHash).Receive()
+}
+
+// GetTxOutSetInfoResult models the data returned from the gettxoutsetinfo
+// command.
+type GetTxOutSetInfoResult struct {
+	// Height and BestBlock identify the block the statistics were taken
+	// at.
+	Height    int64
+	BestBlock string
+
+	// Transactions is the number of transactions with unspent outputs.
+	// Nodes using the coin statistics index do not report it.
+	Transactions int64
+
+	// TxOuts is the number of unspent outputs and BogoSize a database
+	// independent measure of the size of the UTXO set.
+	TxOuts   int64
+	BogoSize int64
+
+	// HashSerialized is the serialized hash of the UTXO set, reported as
+	// hash_serialized_2 or hash_serialized_3 depending on the node, and
+	// MuHash the MuHash of it.  Nodes since Bitcoin Core 0.21 only report
+	// the requested one.
+	HashSerialized string
+	MuHash         string
+
+	// DiskSize is the estimated size of the UTXO set on disk.  Nodes
+	// using the coin statistics index do not report it.
+	DiskSize int64
+
+	// TotalAmount is the value of all unspent outputs, and
+	// TotalUnspendableAmount the value which was burned or is otherwise
+	// unspendable, which only nodes using the coin statistics index
+	// report.
+	TotalAmount            btcutil.Amount
+	TotalUnspendableAmount btcutil.Amount
+}
+
+// UnmarshalJSON decodes the result of the gettxoutsetinfo command of any node
+// version, converting the amounts in BTC to btcutil.Amount.
+func (r *GetTxOutSetInfoResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		Height                 int64   `json:"height"`
+		BestBlock              string  `json:"bestblock"`
+		Transactions           int64   `json:"transactions"`
+		TxOuts                 int64   `json:"txouts"`
+		BogoSize               int64   `json:"bogosize"`
+		HashSerialized2        string  `json:"hash_serialized_2"`
+		HashSerialized3        string  `json:"hash_serialized_3"`
+		MuHash                 string  `json:"muhash"`
+		DiskSize               int64   `json:"disk_size"`
+		TotalAmount            float64 `json:"total_amount"`
+		TotalUnspendableAmount float64 `json:"total_unspendable_amount"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	totalAmount, err := btcutil.NewAmount(result.TotalAmount)
+	if err != nil {
+		return err
+	}
+	totalUnspendableAmount, err := btcutil.NewAmount(result.TotalUnspendableAmount)
+	if err != nil {
+		return err
+	}
+	hashSerialized := result.HashSerialized3
+	if hashSerialized == "" {
+		hashSerialized = result.HashSerialized2
+	}
+	*r = GetTxOutSetInfoResult{
+		Height:                 result.Height,
+		BestBlock:              result.BestBlock,
+		Transactions:           result.Transactions,
+		TxOuts:                 result.TxOuts,
+		BogoSize:               result.BogoSize,
+		HashSerialized:         hashSerialized,
+		MuHash:                 result.MuHash,
+		DiskSize:               result.DiskSize,
+		TotalAmount:            totalAmount,
+		TotalUnspendableAmount: totalUnspendableAmount,
+	}
+	return nil
+}
+
+// FutureGetTxOutSetInfoResult is a future promise to deliver the result of a
+// GetTxOutSetInfoAsync RPC invocation (or an applicable error).
+type FutureGetTxOutSetInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// statistics about the UTXO set.
+func (r FutureGetTxOutSetInfoResult) Receive() (*GetTxOutSetInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a gettxoutsetinfo result object.
+	var result GetTxOutSetInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTxOutSetInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.  Since the server takes minutes to reply on
+// mainnet, this is usually preferable to blocking on GetTxOutSetInfo.
+//
+// See GetTxOutSetInfo for the blocking version and more details.
+func (c *Client) GetTxOutSetInfoAsync(ctx context.Context, hashType string) FutureGetTxOutSetInfoResult {
+	var params []interface{}
+	if hashType != "" {
+		params = []interface{}{hashType}
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The gettxoutsetinfo command of the btcjson package takes no hash
+	// type, so it is sent as a raw request.
+	return FutureGetTxOutSetInfoResult(c.RawRequestAsync(ctx,
+		"gettxoutsetinfo", rawParams))
+}
+
+// GetTxOutSetInfo returns statistics about the UTXO set, hashed with the
+// passed hash type, such as TxOutSetHashMuHash.  An empty hash type selects
+// the default of the node, and only Bitcoin Core 0.21 and later accept
+// another.
+//
+// The server walks the whole UTXO set unless it maintains the coin statistics
+// index, which takes minutes on mainnet.  The ResponseHeaderTimeout of the
+// connection configuration does not apply, but the RequestTimeout does when
+// the context has no deadline, so pass a context with a deadline allowing for
+// it.
+func (c *Client) GetTxOutSetInfo(ctx context.Context, hashType string) (*GetTxOutSetInfoResult, error) {
+	if hashType != "" {
+		if err := c.requireNodeVersion(ctx, 210000); err != nil {
+			return nil, err
+		}
+	}
+	return c.GetTxOutSetInfoAsync(ctx, hashType).Receive()
+}
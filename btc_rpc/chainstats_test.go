@@ -0,0 +1,212 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// txOutSetInfoLegacy is a gettxoutsetinfo result of Bitcoin Core 0.20.
+const txOutSetInfoLegacy = `{
+  "height": 640000,
+  "bestblock": "0000000000000000000b3021a283b981dd08f4ccf318b684b214f995d102af43",
+  "transactions": 40227430,
+  "txouts": 67337004,
+  "bogosize": 5056633893,
+  "hash_serialized_2": "e4d6bc6e2a9fc0a1b6b1fe1a0ac3b0d9b8e4a3f1f6a4b2c8d6e1f0a9b7c5d3e1",
+  "disk_size": 4034426410,
+  "total_amount": 18412500.00000000
+}`
+
+// txOutSetInfoMuHash is a gettxoutsetinfo result of Bitcoin Core 25 asked for
+// the MuHash of the UTXO set with the coin statistics index enabled.
+const txOutSetInfoMuHash = `{
+  "height": 800000,
+  "bestblock": "00000000000000000002a7c4c1e48d76c5a37902165a270156b7a8d72728a054",
+  "txouts": 111535121,
+  "bogosize": 8363614296,
+  "muhash": "2d2a9b8f0b3c5e7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1b3d5f7a9c1e3b5d",
+  "total_amount": 19406250.00000000,
+  "total_unspendable_amount": 219.71331064,
+  "block_info": {
+    "prevout_spent": 4524.10230839,
+    "coinbase": 6.43331826,
+    "new_outputs_ex_coinbase": 4523.91899013,
+    "unspendable": 0.00000000,
+    "unspendables": {
+      "genesis_block": 0.00000000,
+      "bip30": 0.00000000,
+      "scripts": 0.00000000,
+      "unclaimed_rewards": 0.00000000
+    }
+  }
+}`
+
+// txOutSetInfoSerialized3 is a gettxoutsetinfo result of Bitcoin Core 26.
+const txOutSetInfoSerialized3 = `{
+  "height": 820000,
+  "bestblock": "00000000000000000002d7b7e2c81fb6e7a8dbc2ad1c2fa23a8b23cf0f1d3d5a",
+  "txouts": 154000000,
+  "bogosize": 11700000000,
+  "hash_serialized_3": "f1e2d3c4b5a69788796a5b4c3d2e1f00112233445566778899aabbccddeeff00",
+  "transactions": 98000000,
+  "disk_size": 10500000000,
+  "total_amount": 19531250.00000000
+}`
+
+func TestGetChainTxStats(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+		return json.RawMessage(`{
+  "time": 1690000000,
+  "txcount": 870000000,
+  "window_final_block_hash": "00000000000000000002a7c4c1e48d76c5a37902165a270156b7a8d72728a054",
+  "window_final_block_height": 800000,
+  "window_block_count": 4320,
+  "window_tx_count": 12960000,
+  "window_interval": 2592000,
+  "txrate": 5.0
+}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	stats, err := client.GetChainTxStats(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("GetChainTxStats: %v", err)
+	}
+	if stats.TxCount != 870000000 || stats.WindowTxCount != 12960000 ||
+		stats.WindowBlockCount != 4320 || stats.TxRate != 5 ||
+		stats.WindowFinalBlockHeight != 800000 {
+
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	nBlocks := int64(144)
+	blockHash, _ := chainhash.NewHashFromStr(stats.WindowFinalBlockHash)
+	if _, err := client.GetChainTxStats(ctx, &nBlocks, nil); err != nil {
+		t.Fatalf("GetChainTxStats: %v", err)
+	}
+	if _, err := client.GetChainTxStats(ctx, nil, blockHash); err != nil {
+		t.Fatalf("GetChainTxStats: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, params, []string{
+		`[]`,
+		`[144]`,
+		`[null,"` + stats.WindowFinalBlockHash + `"]`,
+	})
+}
+
+func TestGetTxOutSetInfo(t *testing.T) {
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		if req.Method == "getnetworkinfo" {
+			return json.RawMessage(`{"version":250000}`), nil
+		}
+		var hashType string
+		if len(req.Params) > 0 {
+			json.Unmarshal(req.Params[0], &hashType)
+		}
+		switch hashType {
+		case TxOutSetHashMuHash:
+			return json.RawMessage(txOutSetInfoMuHash), nil
+		case TxOutSetHashSerialized3:
+			return json.RawMessage(txOutSetInfoSerialized3), nil
+		}
+		return json.RawMessage(txOutSetInfoLegacy), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	legacy, err := client.GetTxOutSetInfoAsync(ctx, "").Receive()
+	if err != nil {
+		t.Fatalf("GetTxOutSetInfo: %v", err)
+	}
+	if legacy.Height != 640000 || legacy.Transactions != 40227430 ||
+		legacy.HashSerialized != "e4d6bc6e2a9fc0a1b6b1fe1a0ac3b0d9b8e4a3f1f6a4b2c8d6e1f0a9b7c5d3e1" ||
+		legacy.MuHash != "" || legacy.DiskSize != 4034426410 ||
+		legacy.TotalAmount != 1841250000000000 {
+
+		t.Fatalf("unexpected legacy result %+v", legacy)
+	}
+
+	muHash, err := client.GetTxOutSetInfo(ctx, TxOutSetHashMuHash)
+	if err != nil {
+		t.Fatalf("GetTxOutSetInfo: %v", err)
+	}
+	if muHash.MuHash != "2d2a9b8f0b3c5e7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1b3d5f7a9c1e3b5d" ||
+		muHash.HashSerialized != "" || muHash.Transactions != 0 ||
+		muHash.TotalAmount != 1940625000000000 ||
+		muHash.TotalUnspendableAmount != 21971331064 {
+
+		t.Fatalf("unexpected muhash result %+v", muHash)
+	}
+
+	serialized3, err := client.GetTxOutSetInfo(ctx, TxOutSetHashSerialized3)
+	if err != nil {
+		t.Fatalf("GetTxOutSetInfo: %v", err)
+	}
+	if serialized3.HashSerialized != "f1e2d3c4b5a69788796a5b4c3d2e1f00112233445566778899aabbccddeeff00" {
+		t.Fatalf("unexpected result %+v", serialized3)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, requests, []string{
+		`gettxoutsetinfo[]`,
+		`getnetworkinfo[]`,
+		`gettxoutsetinfo["muhash"]`,
+		`gettxoutsetinfo["hash_serialized_3"]`,
+	})
+}
+
+func TestLongRunningMethodTimeout(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(200 * time.Millisecond)
+		if req.Method == "gettxoutsetinfo" {
+			return json.RawMessage(txOutSetInfoLegacy), nil
+		}
+		return 800000, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.ResponseHeaderTimeout = 50 * time.Millisecond
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The response header timeout only applies to ordinary methods.
+	if _, err := client.GetBlockCount(ctx); err == nil {
+		t.Fatal("GetBlockCount: expected a timeout")
+	}
+	if _, err := client.GetTxOutSetInfo(ctx, ""); err != nil {
+		t.Fatalf("GetTxOutSetInfo: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"sync"
+)
+
+// connEvent is a change of the connection state waiting to be passed to the
+// OnClientConnected or OnClientDisconnected callback.
+type connEvent struct {
+	connected bool
+	retries   int
+	err       error
+}
+
+// connNotifier passes connection state changes to the callbacks configured in
+// ConnConfig.  The changes are queued and delivered in order by a dedicated
+// goroutine, so the callbacks never run while the client holds any of its
+// locks and may safely call back into the client.
+type connNotifier struct {
+	onConnected    func(retries int)
+	onDisconnected func(err error)
+
+	// mtx protects the fields below.
+	mtx    sync.Mutex
+	queue  []connEvent
+	signal chan struct{}
+
+	// connected and failures track the connection state in HTTP POST
+	// mode, where there is no connection to watch.  failures is the
+	// number of consecutive transport failures.
+	connected bool
+	failures  int
+}
+
+// newConnNotifier returns a notifier for the callbacks in the passed
+// configuration, or nil when none are configured.
+func newConnNotifier(config *ConnConfig) *connNotifier {
+	if config.OnClientConnected == nil && config.OnClientDisconnected == nil {
+		return nil
+	}
+	return &connNotifier{
+		onConnected:    config.OnClientConnected,
+		onDisconnected: config.OnClientDisconnected,
+		signal:         make(chan struct{}, 1),
+	}
+}
+
+// notify queues the passed event for delivery.  It must be called with the
+// notifier mutex held so events are delivered in the order they happened.
+func (n *connNotifier) notify(event connEvent) {
+	n.queue = append(n.queue, event)
+	select {
+	case n.signal <- struct{}{}:
+	default:
+	}
+}
+
+// notifyConnected queues a connected event carrying the passed number of
+// reconnect attempts it took.  It does nothing on a nil notifier.
+func (n *connNotifier) notifyConnected(retries int) {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	n.notify(connEvent{connected: true, retries: retries})
+	n.mtx.Unlock()
+}
+
+// notifyDisconnected queues a disconnected event carrying the error which
+// caused it, if any.  It does nothing on a nil notifier.
+func (n *connNotifier) notifyDisconnected(err error) {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	n.notify(connEvent{err: err})
+	n.mtx.Unlock()
+}
+
+// reportPost derives the connection state in HTTP POST mode from the outcome
+// of a request.  The client counts as connected once the server answers a
+// request, and as disconnected after the passed threshold of consecutive
+// transport failures.  The retry count passed to OnClientConnected after a
+// disconnect is the number of failed requests in between.  It does nothing on
+// a nil notifier.
+func (n *connNotifier) reportPost(threshold int, err error) {
+	if n == nil {
+		return
+	}
+	failed, ok := transportFailure(err)
+	if !ok {
+		return
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if !failed {
+		if !n.connected {
+			n.connected = true
+			n.notify(connEvent{connected: true, retries: n.failures})
+		}
+		n.failures = 0
+		return
+	}
+	n.failures++
+	if n.connected && n.failures >= threshold {
+		n.connected = false
+		n.notify(connEvent{err: err})
+	}
+}
+
+// connEventHandler delivers queued connection events to the callbacks until
+// the client shuts down.
+//
+// This must be run as a goroutine.
+func (c *Client) connEventHandler() {
+	n := c.connNotifier
+out:
+	for {
+		select {
+		case <-n.signal:
+		case <-c.shutdown:
+			break out
+		}
+
+		n.mtx.Lock()
+		events := n.queue
+		n.queue = nil
+		n.mtx.Unlock()
+
+		for _, event := range events {
+			switch {
+			case event.connected && n.onConnected != nil:
+				n.onConnected(event.retries)
+			case !event.connected && n.onDisconnected != nil:
+				n.onDisconnected(event.err)
+			}
+		}
+	}
+	c.wg.Done()
+}
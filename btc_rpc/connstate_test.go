@@ -0,0 +1,119 @@
+package btc_rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitEvent returns the next value sent on the passed channel, failing the
+// test when none arrives in time.
+func waitEvent(t *testing.T, ch <-chan interface{}, what string) interface{} {
+	t.Helper()
+
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		return nil
+	}
+}
+
+func TestConnectionCallbacksHTTP(t *testing.T) {
+	var down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) != 0 {
+			unavailable(w)
+			return
+		}
+		w.Write([]byte(`{"id":1,"result":100,"error":null}`))
+	}))
+	defer server.Close()
+
+	var client *Client
+	connected := make(chan interface{}, 10)
+	disconnected := make(chan interface{}, 10)
+	config := testConnConfig(server)
+	config.FailoverThreshold = 2
+	config.OnClientConnected = func(retries int) {
+		// The callbacks may call back into the client.
+		if _, err := client.GetBlockCount(context.Background()); err != nil {
+			t.Errorf("GetBlockCount from callback: %v", err)
+		}
+		connected <- retries
+	}
+	config.OnClientDisconnected = func(err error) {
+		disconnected <- err
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if retries := waitEvent(t, connected, "connect"); retries != 0 {
+		t.Fatalf("initial connect reported %v retries, want 0", retries)
+	}
+
+	// A single failure is below the threshold.
+	atomic.StoreInt32(&down, 1)
+	client.GetBlockCount(context.Background())
+	select {
+	case err := <-disconnected:
+		t.Fatalf("disconnected after a single failure: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	for i := 0; i < 2; i++ {
+		client.GetBlockCount(context.Background())
+	}
+	err = waitEvent(t, disconnected, "disconnect").(error)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("disconnect reported %v, want the 503 reply", err)
+	}
+
+	// Errors which say nothing about the server are ignored.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.GetBlockCount(ctx)
+
+	atomic.StoreInt32(&down, 0)
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if retries := waitEvent(t, connected, "reconnect"); retries != 3 {
+		t.Fatalf("reconnect reported %v retries, want 3", retries)
+	}
+
+	select {
+	case retries := <-connected:
+		t.Fatalf("unexpected connect with %v retries", retries)
+	case err := <-disconnected:
+		t.Fatalf("unexpected disconnect: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConnectionCallbacksUnset(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	if client.connNotifier != nil {
+		t.Fatal("client without callbacks created a notifier")
+	}
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+}
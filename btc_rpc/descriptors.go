@@ -0,0 +1,291 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// GetAddressInfoResult models the data returned from the getaddressinfo
+// command.  Fields which do not apply to the address are left empty.
+type GetAddressInfoResult struct {
+	Address      string `json:"address"`
+	ScriptPubKey string `json:"scriptPubKey"`
+
+	// IsMine is whether the wallet can spend from the address, and
+	// IsWatchOnly whether it only watches it.
+	IsMine      bool `json:"ismine"`
+	IsWatchOnly bool `json:"iswatchonly"`
+	Solvable    bool `json:"solvable"`
+
+	// Desc is the output descriptor of the address, which is only set
+	// for solvable addresses.
+	Desc string `json:"desc,omitempty"`
+
+	IsScript       bool   `json:"isscript"`
+	IsChange       bool   `json:"ischange"`
+	IsWitness      bool   `json:"iswitness"`
+	WitnessVersion int32  `json:"witness_version,omitempty"`
+	WitnessProgram string `json:"witness_program,omitempty"`
+	PubKey         string `json:"pubkey,omitempty"`
+	IsCompressed   bool   `json:"iscompressed,omitempty"`
+
+	// Timestamp is the creation time of the key of the address in seconds
+	// since the Unix epoch.
+	Timestamp int64 `json:"timestamp,omitempty"`
+
+	// HDKeyPath, HDSeedID and HDMasterFingerprint describe the derivation
+	// of the key of the address from the HD seed of the wallet.
+	HDKeyPath           string `json:"hdkeypath,omitempty"`
+	HDSeedID            string `json:"hdseedid,omitempty"`
+	HDMasterFingerprint string `json:"hdmasterfingerprint,omitempty"`
+
+	// Labels are the labels of the address.
+	Labels []string `json:"labels"`
+}
+
+// UnmarshalJSON decodes the result of the getaddressinfo command.  Nodes before
+// Bitcoin Core 0.20 return the labels as objects carrying the name and purpose
+// of each label, of which only the name is kept.
+func (r *GetAddressInfoResult) UnmarshalJSON(data []byte) error {
+	type addressInfo GetAddressInfoResult
+	var result struct {
+		addressInfo
+		Labels []json.RawMessage `json:"labels"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	labels := make([]string, 0, len(result.Labels))
+	for _, rawLabel := range result.Labels {
+		var label string
+		if err := json.Unmarshal(rawLabel, &label); err != nil {
+			var labelObj struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(rawLabel, &labelObj) != nil {
+				return err
+			}
+			label = labelObj.Name
+		}
+		labels = append(labels, label)
+	}
+
+	*r = GetAddressInfoResult(result.addressInfo)
+	r.Labels = labels
+	return nil
+}
+
+// FutureGetAddressInfoResult is a future promise to deliver the result of a
+// GetAddressInfoAsync RPC invocation (or an applicable error).
+type FutureGetAddressInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns what the
+// wallet knows about the address.
+func (r FutureGetAddressInfoResult) Receive() (*GetAddressInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getaddressinfo result object.
+	var infoResult GetAddressInfoResult
+	err = json.Unmarshal(res, &infoResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &infoResult, nil
+}
+
+// GetAddressInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetAddressInfo for the blocking version and more details.
+func (c *Client) GetAddressInfoAsync(ctx context.Context, address btcutil.Address) FutureGetAddressInfoResult {
+	rawParams, err := marshalParams([]interface{}{address.EncodeAddress()})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetAddressInfoResult(c.RawRequestAsync(ctx, "getaddressinfo",
+		rawParams))
+}
+
+// GetAddressInfo returns what the wallet knows about the passed address, such
+// as whether it belongs to the wallet and how its key was derived.  Addresses
+// unknown to the wallet are not an error, their IsMine and IsWatchOnly are
+// false.
+func (c *Client) GetAddressInfo(ctx context.Context, address btcutil.Address) (*GetAddressInfoResult, error) {
+	return c.GetAddressInfoAsync(ctx, address).Receive()
+}
+
+// GetDescriptorInfoResult models the data returned from the getdescriptorinfo
+// command.
+type GetDescriptorInfoResult struct {
+	// Descriptor is the descriptor in canonical form, with its checksum
+	// and without private keys.
+	Descriptor string `json:"descriptor"`
+
+	// Checksum is the checksum of the descriptor as passed in.
+	Checksum string `json:"checksum"`
+
+	IsRange        bool `json:"isrange"`
+	IsSolvable     bool `json:"issolvable"`
+	HasPrivateKeys bool `json:"hasprivatekeys"`
+}
+
+// FutureGetDescriptorInfoResult is a future promise to deliver the result of a
+// GetDescriptorInfoAsync RPC invocation (or an applicable error).
+type FutureGetDescriptorInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// analysis of the descriptor.
+func (r FutureGetDescriptorInfoResult) Receive() (*GetDescriptorInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getdescriptorinfo result object.
+	var descResult GetDescriptorInfoResult
+	err = json.Unmarshal(res, &descResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &descResult, nil
+}
+
+// GetDescriptorInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetDescriptorInfo for the blocking version and more details.
+func (c *Client) GetDescriptorInfoAsync(ctx context.Context, descriptor string) FutureGetDescriptorInfoResult {
+	rawParams, err := marshalParams([]interface{}{descriptor})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetDescriptorInfoResult(c.RawRequestAsync(ctx,
+		"getdescriptorinfo", rawParams))
+}
+
+// GetDescriptorInfo analyses the passed output descriptor and returns its
+// checksum and whether it is ranged.  The checksum is needed by commands such
+// as ImportDescriptors, which do not accept descriptors without one.
+func (c *Client) GetDescriptorInfo(ctx context.Context, descriptor string) (*GetDescriptorInfoResult, error) {
+	return c.GetDescriptorInfoAsync(ctx, descriptor).Receive()
+}
+
+// FutureDeriveAddressesResult is a future promise to deliver the result of a
+// DeriveAddressesAsync RPC invocation (or an applicable error).
+type FutureDeriveAddressesResult struct {
+	params   *chaincfg.Params
+	response chan *response
+}
+
+// Receive waits for the response promised by the future and returns the
+// derived addresses.
+func (r FutureDeriveAddressesResult) Receive() ([]btcutil.Address, error) {
+	res, err := receiveFuture(r.response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of strings.
+	var encoded []string
+	err = json.Unmarshal(res, &encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]btcutil.Address, 0, len(encoded))
+	for _, addrStr := range encoded {
+		addr, err := DecodeAddress(addrStr, r.params)
+		if err != nil {
+			return nil, fmt.Errorf("derived address %s: %v", addrStr,
+				err)
+		}
+		addresses = append(addresses, addr)
+	}
+
+	return addresses, nil
+}
+
+// deriveRange returns the range parameter of the deriveaddresses command for
+// the passed range, which is nil when the range is not set.
+func deriveRange(rangeStart, rangeEnd *int64) (interface{}, error) {
+	switch {
+	case rangeStart == nil && rangeEnd == nil:
+		return nil, nil
+	case rangeStart == nil:
+		rangeStart = new(int64)
+	case rangeEnd == nil:
+		rangeEnd = rangeStart
+	}
+	if *rangeStart < 0 || *rangeStart > *rangeEnd {
+		return nil, fmt.Errorf("invalid derivation range [%d, %d]",
+			*rangeStart, *rangeEnd)
+	}
+	if *rangeStart == 0 {
+		return *rangeEnd, nil
+	}
+	return []int64{*rangeStart, *rangeEnd}, nil
+}
+
+// DeriveAddressesAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See DeriveAddresses for the blocking version and more details.
+func (c *Client) DeriveAddressesAsync(ctx context.Context, descriptor string, rangeStart, rangeEnd *int64) FutureDeriveAddressesResult {
+	future := FutureDeriveAddressesResult{params: c.chainParams()}
+
+	params := []interface{}{descriptor}
+	derivationRange, err := deriveRange(rangeStart, rangeEnd)
+	if err != nil {
+		future.response = newFutureError(err)
+		return future
+	}
+	if derivationRange != nil {
+		params = append(params, derivationRange)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		future.response = newFutureError(err)
+		return future
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	future.response = c.RawRequestAsync(ctx, "deriveaddresses", rawParams)
+	return future
+}
+
+// DeriveAddresses returns the addresses of the passed output descriptor, which
+// must include its checksum, decoded for the network in ConnConfig.Params.
+//
+// Ranged descriptors need a range, whose ends are both included.  A nil
+// rangeStart derives the addresses from index 0 to rangeEnd and a nil rangeEnd
+// only the address at index rangeStart.  Both are nil for descriptors without
+// a wildcard.
+//
+// Taproot addresses are returned as an *AddressTaproot.
+func (c *Client) DeriveAddresses(ctx context.Context, descriptor string, rangeStart, rangeEnd *int64) ([]btcutil.Address, error) {
+	return c.DeriveAddressesAsync(ctx, descriptor, rangeStart, rangeEnd).Receive()
+}
@@ -0,0 +1,267 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestGetAddressInfo(t *testing.T) {
+	const addr = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+
+	tests := []struct {
+		name   string
+		result string
+		labels []string
+	}{{
+		name: "current",
+		result: `{
+  "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+  "scriptPubKey": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+  "ismine": true,
+  "solvable": true,
+  "desc": "wpkh([d34db33f/84h/0h/0h/0/7]0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)#9p4yxj3u",
+  "parent_desc": "wpkh([d34db33f/84h/0h/0h]xpub6DJ2dNUysrn5Vt36jH2KLBT2i1auw1tTSSomg8PhqNiUtx8QX2SvC9nrHu81fT41fvDUnhMjEzQgXnQjKEu3oaqMSzhSrHMxyyoEAmUHQbY/0/*)#cjjspncu",
+  "iswatchonly": false,
+  "isscript": false,
+  "iswitness": true,
+  "witness_version": 0,
+  "witness_program": "751e76e8199196d454941c45d1b3a323f1433bd6",
+  "pubkey": "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+  "iscompressed": true,
+  "ischange": false,
+  "timestamp": 1690000000,
+  "hdkeypath": "m/84h/0h/0h/0/7",
+  "hdseedid": "0000000000000000000000000000000000000000",
+  "hdmasterfingerprint": "d34db33f",
+  "labels": [
+    "deposits"
+  ]
+}`,
+		labels: []string{"deposits"},
+	}, {
+		// Nodes before Bitcoin Core 0.20 return label objects.
+		name: "label objects",
+		result: `{
+  "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+  "scriptPubKey": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+  "ismine": true,
+  "iswatchonly": false,
+  "timestamp": 1690000000,
+  "hdkeypath": "m/84'/0'/0'/0/7",
+  "labels": [
+    {
+      "name": "deposits",
+      "purpose": "receive"
+    }
+  ]
+}`,
+		labels: []string{"deposits"},
+	}, {
+		name: "foreign",
+		result: `{
+  "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+  "scriptPubKey": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+  "ismine": false,
+  "solvable": false,
+  "iswatchonly": false,
+  "isscript": false,
+  "iswitness": true,
+  "witness_version": 0,
+  "witness_program": "751e76e8199196d454941c45d1b3a323f1433bd6",
+  "ischange": false,
+  "labels": [
+  ]
+}`,
+		labels: []string{},
+	}}
+
+	for _, test := range tests {
+		result := test.result
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			var sent string
+			json.Unmarshal(req.Params[0], &sent)
+			if req.Method != "getaddressinfo" || sent != addr {
+				t.Errorf("unexpected request %s %s", req.Method, sent)
+			}
+			return json.RawMessage(result), nil
+		})
+		client := newTestClient(t, server)
+
+		address, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+		if err != nil {
+			t.Fatalf("DecodeAddress: %v", err)
+		}
+		info, err := client.GetAddressInfo(context.Background(), address)
+		stopClient(client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: GetAddressInfo: %v", test.name, err)
+		}
+
+		if info.Address != addr || info.IsMine != (test.name != "foreign") ||
+			info.IsWatchOnly {
+
+			t.Fatalf("%s: unexpected result %+v", test.name, info)
+		}
+		if len(info.Labels) != len(test.labels) {
+			t.Fatalf("%s: got labels %q, want %q", test.name,
+				info.Labels, test.labels)
+		}
+		for i := range test.labels {
+			if info.Labels[i] != test.labels[i] {
+				t.Fatalf("%s: got labels %q, want %q", test.name,
+					info.Labels, test.labels)
+			}
+		}
+	}
+}
+
+func TestGetDescriptorInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`{
+  "descriptor": "` + testScanDesc + `",
+  "checksum": "cjjspncu",
+  "isrange": true,
+  "issolvable": true,
+  "hasprivatekeys": false
+}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetDescriptorInfo(context.Background(), testScanDesc[:len(testScanDesc)-9])
+	if err != nil {
+		t.Fatalf("GetDescriptorInfo: %v", err)
+	}
+	if info.Checksum != "cjjspncu" || !info.IsRange || info.HasPrivateKeys ||
+		info.Descriptor != testScanDesc {
+
+		t.Fatalf("unexpected result %+v", info)
+	}
+}
+
+func TestDeriveAddresses(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+
+	tests := []struct {
+		name       string
+		rangeStart *int64
+		rangeEnd   *int64
+		params     string
+		wantErr    bool
+	}{
+		{"no range", nil, nil, `["desc"]`, false},
+		{"range end", nil, int64Ptr(2), `["desc",2]`, false},
+		{"range from zero", int64Ptr(0), int64Ptr(2), `["desc",2]`, false},
+		{"range", int64Ptr(5), int64Ptr(7), `["desc",[5,7]]`, false},
+		{"single index", int64Ptr(5), nil, `["desc",[5,5]]`, false},
+		{"index zero", int64Ptr(0), nil, `["desc",0]`, false},
+		{"inverted", int64Ptr(7), int64Ptr(5), "", true},
+		{"negative", int64Ptr(-1), int64Ptr(5), "", true},
+	}
+
+	var mtx sync.Mutex
+	var params string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = string(p)
+		mtx.Unlock()
+		return []string{
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+		}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	for _, test := range tests {
+		mtx.Lock()
+		params = ""
+		mtx.Unlock()
+
+		addrs, err := client.DeriveAddresses(context.Background(), "desc",
+			test.rangeStart, test.rangeEnd)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: DeriveAddresses: %v", test.name, err)
+			continue
+		}
+		mtx.Lock()
+		if params != test.params {
+			t.Errorf("%s: sent params %s, want %s", test.name, params,
+				test.params)
+		}
+		mtx.Unlock()
+
+		if len(addrs) != 3 {
+			t.Fatalf("%s: got %d addresses, want 3", test.name, len(addrs))
+		}
+		if _, ok := addrs[0].(*btcutil.AddressWitnessPubKeyHash); !ok {
+			t.Errorf("%s: unexpected address type %T", test.name, addrs[0])
+		}
+		if _, ok := addrs[1].(*btcutil.AddressPubKeyHash); !ok {
+			t.Errorf("%s: unexpected address type %T", test.name, addrs[1])
+		}
+		if _, ok := addrs[2].(*btcutil.AddressScriptHash); !ok {
+			t.Errorf("%s: unexpected address type %T", test.name, addrs[2])
+		}
+	}
+}
+
+func TestDeriveAddressesNetwork(t *testing.T) {
+	const regtestAddr = "bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew"
+
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return []string{regtestAddr}, nil
+	})
+	defer server.Close()
+
+	for _, network := range []string{"regtest", "mainnet", "testnet3"} {
+		config := testConnConfig(server)
+		config.Params = network
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		addrs, err := client.DeriveAddresses(context.Background(), "desc",
+			nil, nil)
+		stopClient(client)
+
+		if network != "regtest" {
+			if err == nil {
+				t.Errorf("%s: expected an error for a regtest "+
+					"address", network)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: DeriveAddresses: %v", network, err)
+		}
+		if len(addrs) != 1 || addrs[0].EncodeAddress() != regtestAddr ||
+			!addrs[0].IsForNet(&chaincfg.RegressionNetParams) {
+
+			t.Fatalf("%s: unexpected addresses %v", network, addrs)
+		}
+	}
+
+	config := testConnConfig(server)
+	config.Params = "testnet4"
+	if _, err := New(config); err == nil {
+		t.Fatal("expected an error for an unknown network")
+	}
+}
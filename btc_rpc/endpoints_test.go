@@ -0,0 +1,144 @@
+package btc_rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// deadHost returns the address of a server which has been shut down, so
+// connections to it are refused.
+func deadHost(t *testing.T) string {
+	server := newTestServer(t, blockCountHandler)
+	server.Close()
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestFailover(t *testing.T) {
+	live := newTestServer(t, blockCountHandler)
+	defer live.Close()
+	dead := deadHost(t)
+	liveHost := strings.TrimPrefix(live.URL, "http://")
+
+	logger := &testLogger{}
+	config := testConnConfig(live)
+	config.Host = ""
+	config.Hosts = []string{dead, liveHost}
+	config.FailoverThreshold = 2
+	config.FailoverCooldown = time.Hour
+	config.Logger = logger
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if got := client.ActiveEndpoint(); got != dead {
+		t.Fatalf("active endpoint %s, want %s", got, dead)
+	}
+
+	// The first endpoint is used until it failed the configured number of
+	// times in a row.
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetBlockCount(ctx); err == nil {
+			t.Fatalf("request %d: expected connection error", i)
+		}
+	}
+	if !logger.contains("WRN RPC endpoint " + dead + " is unhealthy") {
+		t.Error("unhealthy endpoint was not logged")
+	}
+	if _, err := client.GetBlockCount(ctx); err != nil {
+		t.Fatalf("GetBlockCount after failover: %v", err)
+	}
+	if got := client.ActiveEndpoint(); got != liveHost {
+		t.Fatalf("active endpoint %s, want %s", got, liveHost)
+	}
+
+	status := client.Endpoints()
+	if len(status) != 2 {
+		t.Fatalf("got %d endpoints, want 2", len(status))
+	}
+	if s := status[0]; s.Host != dead || s.Active || s.Healthy ||
+		s.ConsecutiveFailures != 2 || s.LastError == nil ||
+		!s.RetryAt.After(time.Now()) {
+
+		t.Errorf("unexpected status for dead endpoint: %+v", s)
+	}
+	if s := status[1]; s.Host != liveHost || !s.Active || !s.Healthy ||
+		s.ConsecutiveFailures != 0 {
+
+		t.Errorf("unexpected status for live endpoint: %+v", s)
+	}
+}
+
+func TestFailoverRetry(t *testing.T) {
+	live := newTestServer(t, blockCountHandler)
+	defer live.Close()
+	dead := deadHost(t)
+
+	config := testConnConfig(live)
+	config.Hosts = []string{dead, strings.TrimPrefix(live.URL, "http://")}
+	config.FailoverThreshold = 1
+	config.RetryPolicy = &RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// The retry goes to the next endpoint, so the call itself succeeds.
+	count, err := client.GetBlockCount(context.Background())
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("unexpected count %d", count)
+	}
+}
+
+func TestFailoverCooldown(t *testing.T) {
+	config := &ConnConfig{
+		Hosts:             []string{"a", "b"},
+		FailoverThreshold: 1,
+		FailoverCooldown:  50 * time.Millisecond,
+	}
+	endpoints := newEndpointSet(config)
+
+	transient := &HTTPError{StatusCode: 503}
+	if !endpoints.report("a", transient) {
+		t.Fatal("endpoint was not marked unhealthy")
+	}
+	if got := endpoints.pick(); got != "b" {
+		t.Fatalf("picked %s, want b", got)
+	}
+
+	// Once every endpoint is unhealthy, the one available first is used.
+	endpoints.report("b", transient)
+	if got := endpoints.pick(); got != "a" {
+		t.Fatalf("picked %s, want a", got)
+	}
+
+	// RPC errors come from a working server, so they clear the failures,
+	// while errors unrelated to the server are ignored.
+	endpoints.report("b", &btcjson.RPCError{Code: -5})
+	endpoints.report("b", context.Canceled)
+	time.Sleep(60 * time.Millisecond)
+	for _, s := range endpoints.status() {
+		if !s.Healthy || (s.Host == "b" && s.ConsecutiveFailures != 0) {
+			t.Errorf("unexpected status %+v", s)
+		}
+	}
+
+	// A single Host is used as the only endpoint.
+	status := newEndpointSet(&ConnConfig{Host: "c"}).status()
+	if len(status) != 1 || status[0].Host != "c" || !status[0].Active {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
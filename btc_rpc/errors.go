@@ -0,0 +1,304 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// HTTPError describes an HTTP POST mode reply which did not carry a JSON-RPC
+// response, such as an error page from a proxy in front of the RPC server.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the reply.
+	StatusCode int
+
+	// Body is the raw body of the reply.
+	Body string
+}
+
+// Error satisfies the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status code: %d, response: %q", e.StatusCode, e.Body)
+}
+
+// The reject reasons below are reported by the various node implementations
+// when a transaction is not accepted.  Bitcoin Core and its forks report the
+// short reject reason codes while btcd and bchd report descriptive messages.
+var (
+	alreadyInMempoolReasons = []string{
+		"txn-already-in-mempool",
+		"txn-already-known",
+		"already have transaction",
+	}
+
+	missingInputsReasons = []string{
+		"missing inputs",
+		"bad-txns-inputs-missingorspent",
+		"unknown or fully-spent transaction",
+	}
+
+	// blockFilterIndexDisabledReasons are reported by nodes running
+	// without the block filter index asked for.
+	blockFilterIndexDisabledReasons = []string{
+		"index is not enabled for filtertype",
+	}
+
+	// scanInProgressReasons are reported by nodes asked to start a UTXO
+	// set scan while another one is running.
+	scanInProgressReasons = []string{
+		"scan already in progress",
+	}
+
+	// notReplaceableReasons are reported by wallets asked to bump the
+	// fee of a transaction which does not signal replaceability.
+	notReplaceableReasons = []string{
+		"not bip 125 replaceable",
+	}
+
+	// descriptorWalletRequiredReasons are reported by legacy wallets
+	// asked to run a command only descriptor wallets support.
+	descriptorWalletRequiredReasons = []string{
+		"not available for non-descriptor wallets",
+		"only available for descriptor wallets",
+	}
+
+	// legacyWalletRequiredReasons are reported by descriptor wallets
+	// asked to run a command only legacy wallets support.
+	legacyWalletRequiredReasons = []string{
+		"not available for descriptor wallets",
+		"only legacy wallets are supported",
+		"this type of wallet does not support this command",
+	}
+
+	// walletRescanningReasons are reported by wallets asked to rescan
+	// the block chain while they already are.
+	walletRescanningReasons = []string{
+		"wallet is currently rescanning",
+	}
+
+	// blockPrunedReasons are reported by pruned nodes asked for the data
+	// of a block they deleted already.
+	blockPrunedReasons = []string{
+		"block not available (pruned data)",
+	}
+
+	insufficientFeeReasons = []string{
+		"insufficient fee",
+		"min relay fee not met",
+		"mempool min fee not met",
+		"insufficient priority",
+		"under the required amount",
+	}
+)
+
+// rpcErrorContains returns whether the passed error is an RPC error returned
+// by the server whose message contains any of the passed reasons, ignoring
+// case.
+func rpcErrorContains(err error, reasons []string) bool {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, reason := range reasons {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAlreadyInMempool returns whether the passed error is the server rejecting
+// a transaction because it is already in its memory pool.
+func IsAlreadyInMempool(err error) bool {
+	return rpcErrorContains(err, alreadyInMempoolReasons)
+}
+
+// IsMissingInputs returns whether the passed error is the server rejecting a
+// transaction because it spends outputs which are unknown or already spent.
+func IsMissingInputs(err error) bool {
+	return rpcErrorContains(err, missingInputsReasons)
+}
+
+// IsInsufficientFee returns whether the passed error is the server rejecting a
+// transaction because its fee is below what the server requires to relay it.
+func IsInsufficientFee(err error) bool {
+	return rpcErrorContains(err, insufficientFeeReasons)
+}
+
+// isBlockFilterIndexDisabled returns whether the passed error is the server
+// refusing a block filter query because it runs without the filter index.
+func isBlockFilterIndexDisabled(err error) bool {
+	return rpcErrorContains(err, blockFilterIndexDisabledReasons)
+}
+
+// isScanInProgress returns whether the passed error is the server refusing to
+// start a UTXO set scan because another one is running.
+func isScanInProgress(err error) bool {
+	return rpcErrorContains(err, scanInProgressReasons)
+}
+
+// isNotReplaceable returns whether the passed error is the wallet refusing to
+// bump the fee of a transaction which does not signal replaceability.
+func isNotReplaceable(err error) bool {
+	return rpcErrorContains(err, notReplaceableReasons)
+}
+
+// isDescriptorWalletRequired returns whether the passed error is a legacy
+// wallet refusing a command only descriptor wallets support.
+func isDescriptorWalletRequired(err error) bool {
+	return rpcErrorContains(err, descriptorWalletRequiredReasons)
+}
+
+// isLegacyWalletRequired returns whether the passed error is a descriptor
+// wallet refusing a command only legacy wallets support.
+func isLegacyWalletRequired(err error) bool {
+	return rpcErrorContains(err, legacyWalletRequiredReasons)
+}
+
+// isWalletRescanning returns whether the passed error is the wallet refusing a
+// command because it is rescanning the block chain.
+func isWalletRescanning(err error) bool {
+	return rpcErrorContains(err, walletRescanningReasons)
+}
+
+// isBlockPruned returns whether the passed error was returned by a node asked
+// for the data of a block it pruned.
+func isBlockPruned(err error) bool {
+	return rpcErrorContains(err, blockPrunedReasons)
+}
+
+// The error codes below are returned by Bitcoin Core for transactions passed
+// to sendrawtransaction which are not accepted.
+const (
+	// rpcVerifyRejected is returned for transactions which are rejected
+	// by the memory pool policy or the consensus rules.
+	rpcVerifyRejected btcjson.RPCErrorCode = -26
+
+	// rpcVerifyAlreadyInChain is returned for transactions which are
+	// already in the block chain.
+	rpcVerifyAlreadyInChain btcjson.RPCErrorCode = -27
+)
+
+// ErrTxRejected describes a transaction the server refused to accept to its
+// memory pool.
+type ErrTxRejected struct {
+	// Reason is the reject reason reported by the server, such as
+	// "min relay fee not met" or "txn-mempool-conflict".
+	Reason string
+
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxRejected) Error() string {
+	return "transaction rejected: " + e.Reason
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxRejected) Unwrap() error {
+	return e.Err
+}
+
+// ErrTxAlreadyInChain describes a transaction which the server did not accept
+// because it is already in the block chain.
+type ErrTxAlreadyInChain struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxAlreadyInChain) Error() string {
+	return "transaction already in block chain"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxAlreadyInChain) Unwrap() error {
+	return e.Err
+}
+
+// sendRawTransactionError converts the errors Bitcoin Core returns for
+// transactions it did not accept to an *ErrTxRejected or an
+// *ErrTxAlreadyInChain.  Other errors are returned unchanged.
+func sendRawTransactionError(err error) error {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	switch rpcErr.Code {
+	case rpcVerifyRejected:
+		// Nodes before Bitcoin Core 0.20 prefix the reason with the
+		// numeric reject code, as in "66: insufficient priority".
+		reason := rpcErr.Message
+		if i := strings.Index(reason, ": "); i > 0 &&
+			strings.Trim(reason[:i], "0123456789") == "" {
+
+			reason = reason[i+2:]
+		}
+		return &ErrTxRejected{Reason: reason, Err: err}
+	case rpcVerifyAlreadyInChain:
+		return &ErrTxAlreadyInChain{Err: err}
+	}
+	return err
+}
+
+// ErrTxNotReplaceable describes a transaction whose fee the wallet refused to
+// bump because it does not signal that it may be replaced, as required by
+// BIP 125.
+type ErrTxNotReplaceable struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxNotReplaceable) Error() string {
+	return "transaction is not BIP 125 replaceable"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxNotReplaceable) Unwrap() error {
+	return e.Err
+}
+
+// ErrWalletRescanning describes a wallet which refused a command because it is
+// rescanning the block chain.  Callers may wait for the rescan to finish, see
+// RescanProgress, and try again.
+type ErrWalletRescanning struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrWalletRescanning) Error() string {
+	return "wallet is currently rescanning"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrWalletRescanning) Unwrap() error {
+	return e.Err
+}
+
+// ErrBlockPruned describes a block whose data the node deleted when pruning
+// its block storage.  Callers may fetch the block from a node which is not
+// pruned instead.
+type ErrBlockPruned struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrBlockPruned) Error() string {
+	return "block not available (pruned data)"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrBlockPruned) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,98 @@
+package btc_rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestRejectReasons(t *testing.T) {
+	tests := []struct {
+		msg              string
+		alreadyInMempool bool
+		missingInputs    bool
+		insufficientFee  bool
+	}{
+		// Bitcoin Core, Litecoin Core and Dash Core.
+		{"txn-already-in-mempool", true, false, false},
+		{"txn-already-known", true, false, false},
+		{"Missing inputs", false, true, false},
+		{"bad-txns-inputs-missingorspent", false, true, false},
+		{"min relay fee not met, 100 < 226 (code 66)", false, false, true},
+		{"mempool min fee not met, 100 < 1000", false, false, true},
+		{"66: insufficient fee", false, false, true},
+		{"insufficient priority", false, false, true},
+
+		// btcd and bchd.
+		{"TX rejected: already have transaction 0123", true, false, false},
+		{"TX rejected: orphan transaction 0123 references outputs " +
+			"of unknown or fully-spent transaction 4567", false, true, false},
+		{"TX rejected: transaction 0123 has 100 fees which is under " +
+			"the required amount of 226", false, false, true},
+
+		{"bad-txns-vout-negative", false, false, false},
+	}
+	for _, test := range tests {
+		err := &btcjson.RPCError{Code: -26, Message: test.msg}
+		if got := IsAlreadyInMempool(err); got != test.alreadyInMempool {
+			t.Errorf("%q: IsAlreadyInMempool %v", test.msg, got)
+		}
+		if got := IsMissingInputs(err); got != test.missingInputs {
+			t.Errorf("%q: IsMissingInputs %v", test.msg, got)
+		}
+		if got := IsInsufficientFee(err); got != test.insufficientFee {
+			t.Errorf("%q: IsInsufficientFee %v", test.msg, got)
+		}
+	}
+
+	// Errors not returned by the server never match.
+	if IsAlreadyInMempool(errors.New("txn-already-in-mempool")) {
+		t.Error("plain error matched IsAlreadyInMempool")
+	}
+}
+
+func TestErrorTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   btcjson.RPCErrorCode
+	}{
+		{"rpc error", http.StatusInternalServerError,
+			`{"id":1,"result":null,"error":{"code":-26,"message":"txn-already-in-mempool"}}`, -26},
+		{"not json", http.StatusBadGateway, "<html>bad gateway</html>", 0},
+		{"no rpc error", http.StatusServiceUnavailable, `{"message":"busy"}`, 0},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+		client := newTestClient(t, server)
+
+		_, err := client.RawRequest(context.Background(), "sendrawtransaction", nil)
+		var rpcErr *btcjson.RPCError
+		var httpErr *HTTPError
+		switch {
+		case test.code != 0:
+			if !errors.As(err, &rpcErr) || rpcErr.Code != test.code {
+				t.Errorf("%s: expected RPC error %d, got %v",
+					test.name, test.code, err)
+			}
+			if !IsAlreadyInMempool(err) {
+				t.Errorf("%s: IsAlreadyInMempool false", test.name)
+			}
+		case !errors.As(err, &httpErr):
+			t.Errorf("%s: expected HTTPError, got %v", test.name, err)
+		case httpErr.StatusCode != test.status || httpErr.Body != test.body:
+			t.Errorf("%s: unexpected HTTPError %+v", test.name, httpErr)
+		}
+
+		stopClient(client)
+		server.Close()
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// EstimateSmartFeeMode selects how conservative the fee rate returned by
+// EstimateSmartFee is.
+type EstimateSmartFeeMode string
+
+// Constants used to indicate the estimate mode for EstimateSmartFee.
+const (
+	// EstimateModeUnset leaves the estimate mode to the node.  It is not
+	// sent at all, so it also works with nodes which do not know of any
+	// estimate modes.
+	EstimateModeUnset EstimateSmartFeeMode = "UNSET"
+
+	// EstimateModeEconomical asks for a fee rate which is lower but more
+	// likely to be outbid by later transactions.
+	EstimateModeEconomical EstimateSmartFeeMode = "ECONOMICAL"
+
+	// EstimateModeConservative asks for a fee rate which is higher but
+	// more likely to get the transaction confirmed in time.
+	EstimateModeConservative EstimateSmartFeeMode = "CONSERVATIVE"
+)
+
+// String returns the EstimateSmartFeeMode in human-readable form.
+func (m EstimateSmartFeeMode) String() string {
+	return string(m)
+}
+
+// ErrFeeEstimateUnavailable describes the condition where the node has no fee
+// rate estimate, as is the case for freshly started nodes which have not seen
+// enough blocks yet.
+type ErrFeeEstimateUnavailable struct {
+	// Errors are the reasons given by the node, if any.
+	Errors []string
+}
+
+// Error satisfies the error interface.
+func (e *ErrFeeEstimateUnavailable) Error() string {
+	if len(e.Errors) == 0 {
+		return "no fee rate estimate"
+	}
+	return "no fee rate estimate: " + strings.Join(e.Errors, "; ")
+}
+
+// SatPerVByte converts a fee rate in BTC per kilo virtual byte, as returned by
+// EstimateSmartFee, to satoshis per virtual byte.  The result keeps fractions
+// of a satoshi, so a rate of 1010 satoshis per kilo virtual byte is 1.01 and
+// not 2.  Rounding, if any, is left to the caller.
+func SatPerVByte(btcPerKvB float64) (float64, error) {
+	perKvB, err := btcutil.NewAmount(btcPerKvB)
+	if err != nil {
+		return 0, err
+	}
+	if perKvB < 0 {
+		return 0, &ErrFeeEstimateUnavailable{}
+	}
+	return float64(perKvB) / 1000, nil
+}
+
+// EstimateSmartFeeResult models the data returned by the estimatesmartfee
+// command.
+type EstimateSmartFeeResult struct {
+	// FeeRate is the estimated fee rate in BTC per kilo virtual byte.  It
+	// is nil when the node has no estimate, in which case Errors lists the
+	// reasons.
+	FeeRate *float64 `json:"feerate,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+
+	// Blocks is the number of blocks the estimate is for, which may differ
+	// from the requested target.
+	Blocks int64 `json:"blocks"`
+}
+
+// FutureEstimateSmartFeeResult is a future promise to deliver the result of a
+// EstimateSmartFeeAsync RPC invocation (or an applicable error).
+type FutureEstimateSmartFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the fee
+// estimate provided by the server.
+func (r FutureEstimateSmartFeeResult) Receive() (*EstimateSmartFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an estimatesmartfee result object.
+	var feeResult EstimateSmartFeeResult
+	err = json.Unmarshal(res, &feeResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &feeResult, nil
+}
+
+// EstimateSmartFeeAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See EstimateSmartFee for the blocking version and more details.
+func (c *Client) EstimateSmartFeeAsync(ctx context.Context, confTarget int64, mode EstimateSmartFeeMode) FutureEstimateSmartFeeResult {
+	params := []interface{}{confTarget}
+	if mode != EstimateModeUnset {
+		params = append(params, mode)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureEstimateSmartFeeResult(c.RawRequestAsync(ctx, "estimatesmartfee", rawParams))
+}
+
+// EstimateSmartFee returns the fee rate in BTC per kilo virtual byte the node
+// estimates a transaction needs to begin confirmation within confTarget
+// blocks.  Nodes without an estimate return a result without a fee rate which
+// lists the reasons in Errors.
+//
+// See FeeRateSatPerVByte to get the fee rate in satoshis per virtual byte.
+func (c *Client) EstimateSmartFee(ctx context.Context, confTarget int64, mode EstimateSmartFeeMode) (*EstimateSmartFeeResult, error) {
+	return c.EstimateSmartFeeAsync(ctx, confTarget, mode).Receive()
+}
+
+// FeeRateSatPerVByte returns the fee rate in satoshis per virtual byte the node
+// estimates a transaction needs to begin confirmation within confTarget
+// blocks, leaving the estimate mode to the node.  The rate is exact and may
+// include fractions of a satoshi.  An *ErrFeeEstimateUnavailable is returned
+// when the node has no estimate.
+func (c *Client) FeeRateSatPerVByte(ctx context.Context, confTarget int64) (float64, error) {
+	result, err := c.EstimateSmartFee(ctx, confTarget, EstimateModeUnset)
+	if err != nil {
+		return 0, err
+	}
+	if result.FeeRate == nil {
+		return 0, &ErrFeeEstimateUnavailable{Errors: result.Errors}
+	}
+	return SatPerVByte(*result.FeeRate)
+}
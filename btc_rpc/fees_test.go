@@ -0,0 +1,130 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// newFeeServer starts a test server answering every request with the passed
+// reply and recording the params it was sent.
+func newFeeServer(t *testing.T, reply string) (*Client, func() []json.RawMessage, func()) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return json.RawMessage(reply), nil
+	})
+	client := newTestClient(t, server)
+	sent := func() []json.RawMessage {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return params
+	}
+	return client, sent, func() {
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestEstimateSmartFee(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  string
+		mode   EstimateSmartFeeMode
+		params string
+		blocks int64
+	}{{
+		name:   "conservative",
+		reply:  `{"feerate":0.00012345,"blocks":2}`,
+		mode:   EstimateModeConservative,
+		params: `[2,"CONSERVATIVE"]`,
+		blocks: 2,
+	}, {
+		name:   "economical",
+		reply:  `{"feerate":0.00001000,"blocks":3}`,
+		mode:   EstimateModeEconomical,
+		params: `[2,"ECONOMICAL"]`,
+		blocks: 3,
+	}, {
+		name:   "unset",
+		reply:  `{"feerate":0.00001000,"blocks":2}`,
+		mode:   EstimateModeUnset,
+		params: `[2]`,
+		blocks: 2,
+	}}
+	for _, test := range tests {
+		client, sent, stop := newFeeServer(t, test.reply)
+		result, err := client.EstimateSmartFee(context.Background(), 2, test.mode)
+		stop()
+		if err != nil {
+			t.Fatalf("%s: EstimateSmartFee: %v", test.name, err)
+		}
+		if got, _ := json.Marshal(sent()); string(got) != test.params {
+			t.Fatalf("%s: sent params %s, want %s", test.name, got,
+				test.params)
+		}
+		if result.FeeRate == nil || result.Blocks != test.blocks {
+			t.Fatalf("%s: unexpected result %+v", test.name, result)
+		}
+	}
+}
+
+func TestFeeRateSatPerVByte(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		rate  float64
+		errs  []string
+	}{{
+		name:  "whole",
+		reply: `{"feerate":0.00001000,"blocks":2}`,
+		rate:  1,
+	}, {
+		name:  "fraction",
+		reply: `{"feerate":0.00012345,"blocks":2}`,
+		rate:  12.345,
+	}, {
+		// Fractions of a satoshi are kept rather than rounded up.
+		name:  "just above whole",
+		reply: `{"feerate":0.00001010,"blocks":2}`,
+		rate:  1.01,
+	}, {
+		name:  "below one",
+		reply: `{"feerate":0.00000100,"blocks":2}`,
+		rate:  0.1,
+	}, {
+		name:  "fresh node",
+		reply: `{"errors":["Insufficient data or no feerate found"],"blocks":0}`,
+		errs:  []string{"Insufficient data or no feerate found"},
+	}}
+	for _, test := range tests {
+		client, sent, stop := newFeeServer(t, test.reply)
+		rate, err := client.FeeRateSatPerVByte(context.Background(), 2)
+		stop()
+		if got, _ := json.Marshal(sent()); string(got) != `[2]` {
+			t.Fatalf("%s: sent params %s", test.name, got)
+		}
+		if test.errs == nil {
+			if err != nil || rate != test.rate {
+				t.Fatalf("%s: FeeRateSatPerVByte = %v, %v, want %v",
+					test.name, rate, err, test.rate)
+			}
+			continue
+		}
+		var unavailable *ErrFeeEstimateUnavailable
+		if !errors.As(err, &unavailable) {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if len(unavailable.Errors) != 1 || unavailable.Errors[0] != test.errs[0] {
+			t.Fatalf("%s: unexpected reasons %q", test.name,
+				unavailable.Errors)
+		}
+	}
+}
@@ -5,4 +5,6 @@ go 1.13
 require (
 	github.com/btcsuite/btcd v0.20.0-beta
 	github.com/btcsuite/btcutil v0.0.0-20190425235716-9e5f4b9a998d
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
+	github.com/sectoken-dev/tools/chainclient v0.1.0
 )
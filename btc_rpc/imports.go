@@ -0,0 +1,331 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// minImportDescriptorsVersion is the first Bitcoin Core version supporting
+// the importdescriptors command.
+const minImportDescriptorsVersion = 210000
+
+// ErrWalletType describes the condition where a wallet RPC was sent to a
+// wallet of the wrong type, such as importdescriptors to a legacy wallet.
+type ErrWalletType struct {
+	// Method is the method the wallet refused.
+	Method string
+
+	// Descriptors is whether the method needs a descriptor wallet rather
+	// than a legacy one.
+	Descriptors bool
+
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrWalletType) Error() string {
+	if e.Descriptors {
+		return e.Method + " needs a descriptor wallet"
+	}
+	return e.Method + " needs a legacy wallet"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrWalletType) Unwrap() error {
+	return e.Err
+}
+
+// importTimestamp encodes the time the keys of an import were first used as
+// the server expects it.  A nil time is encoded as "now", which skips the
+// rescan for the import.
+func importTimestamp(t *time.Time) interface{} {
+	if t == nil {
+		return "now"
+	}
+	return t.Unix()
+}
+
+// ImportDescriptorRequest is an output descriptor imported by
+// ImportDescriptors.
+type ImportDescriptorRequest struct {
+	// Desc is the output descriptor, including its checksum.
+	Desc string
+
+	// Active makes the wallet derive new addresses from the descriptor,
+	// which must be ranged.
+	Active bool
+
+	// Range is the range of indexes imported for ranged descriptors.  The
+	// wallet uses its keypool size when it is nil.
+	Range *ScanRange
+
+	// NextIndex is the index the wallet derives the next address from.
+	NextIndex *uint32
+
+	// Timestamp is the time the outputs of the descriptor were first
+	// used, from which on the block chain is rescanned.  A nil timestamp
+	// imports the descriptor as new and skips the rescan.  Use the zero
+	// Unix time to rescan the whole block chain.
+	Timestamp *time.Time
+
+	// Internal marks the outputs of the descriptor as change.
+	Internal bool
+
+	// Label is the label of the addresses of the descriptor.  It may not
+	// be set for internal descriptors.
+	Label string
+}
+
+// MarshalJSON encodes the request as the server expects it.
+func (r ImportDescriptorRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Desc      string      `json:"desc"`
+		Active    bool        `json:"active,omitempty"`
+		Range     *ScanRange  `json:"range,omitempty"`
+		NextIndex *uint32     `json:"next_index,omitempty"`
+		Timestamp interface{} `json:"timestamp"`
+		Internal  bool        `json:"internal,omitempty"`
+		Label     string      `json:"label,omitempty"`
+	}{
+		Desc:      r.Desc,
+		Active:    r.Active,
+		Range:     r.Range,
+		NextIndex: r.NextIndex,
+		Timestamp: importTimestamp(r.Timestamp),
+		Internal:  r.Internal,
+		Label:     r.Label,
+	})
+}
+
+// ImportMultiRequest is a script or address imported by ImportMulti.  Exactly
+// one of Desc, ScriptPubKey and Address must be set.
+type ImportMultiRequest struct {
+	// Desc is an output descriptor.  Nodes before Bitcoin Core 0.18 do
+	// not support descriptors.
+	Desc string
+
+	// ScriptPubKey is the hex-encoded output script to import.
+	ScriptPubKey string
+
+	// Address is the address to import.
+	Address string
+
+	// RedeemScript and WitnessScript are the hex-encoded P2SH redeem
+	// script and P2WSH witness script of the imported output script.
+	RedeemScript  string
+	WitnessScript string
+
+	// PubKeys are the hex-encoded public keys and Keys the WIF-encoded
+	// private keys of the imported output script.
+	PubKeys []string
+	Keys    []string
+
+	// Range is the range of indexes imported for ranged descriptors.
+	Range *ScanRange
+
+	// Timestamp is the time the output script was first used, from which
+	// on the block chain is rescanned.  A nil timestamp imports the script
+	// as new and skips the rescan.  Use the zero Unix time to rescan the
+	// whole block chain.
+	Timestamp *time.Time
+
+	// Internal marks the output script as change, in which case Label
+	// may not be set.
+	Internal bool
+
+	// WatchOnly imports the output script without its private keys.
+	WatchOnly bool
+
+	// Label is the label of the imported output script.
+	Label string
+
+	// KeyPool adds the imported public keys to the keypool, which is only
+	// supported by wallets with private keys disabled.
+	KeyPool bool
+}
+
+// MarshalJSON encodes the request as the server expects it.  Addresses are
+// encoded as an address object in place of the output script.
+func (r ImportMultiRequest) MarshalJSON() ([]byte, error) {
+	var scriptPubKey interface{}
+	switch {
+	case r.ScriptPubKey != "":
+		scriptPubKey = r.ScriptPubKey
+	case r.Address != "":
+		scriptPubKey = map[string]string{"address": r.Address}
+	}
+	return json.Marshal(struct {
+		Desc          string      `json:"desc,omitempty"`
+		ScriptPubKey  interface{} `json:"scriptPubKey,omitempty"`
+		RedeemScript  string      `json:"redeemscript,omitempty"`
+		WitnessScript string      `json:"witnessscript,omitempty"`
+		PubKeys       []string    `json:"pubkeys,omitempty"`
+		Keys          []string    `json:"keys,omitempty"`
+		Range         *ScanRange  `json:"range,omitempty"`
+		Timestamp     interface{} `json:"timestamp"`
+		Internal      bool        `json:"internal,omitempty"`
+		WatchOnly     bool        `json:"watchonly,omitempty"`
+		Label         string      `json:"label,omitempty"`
+		KeyPool       bool        `json:"keypool,omitempty"`
+	}{
+		Desc:          r.Desc,
+		ScriptPubKey:  scriptPubKey,
+		RedeemScript:  r.RedeemScript,
+		WitnessScript: r.WitnessScript,
+		PubKeys:       r.PubKeys,
+		Keys:          r.Keys,
+		Range:         r.Range,
+		Timestamp:     importTimestamp(r.Timestamp),
+		Internal:      r.Internal,
+		WatchOnly:     r.WatchOnly,
+		Label:         r.Label,
+		KeyPool:       r.KeyPool,
+	})
+}
+
+// ImportResult is the outcome of a single request passed to ImportDescriptors
+// or ImportMulti.  Requests are imported independently, so some of them may
+// fail while others succeed.
+type ImportResult struct {
+	Success bool `json:"success"`
+
+	// Warnings are issues the wallet ran into but imported the request
+	// regardless of.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Error is the reason a request was not imported.
+	Error *btcjson.RPCError `json:"error,omitempty"`
+}
+
+// receiveImportResult waits for the response promised by the passed future of
+// the passed import method and returns the outcome of each request.
+func receiveImportResult(f chan *response, method string) ([]ImportResult, error) {
+	res, err := receiveFuture(f)
+	if err != nil {
+		switch {
+		case isDescriptorWalletRequired(err):
+			return nil, &ErrWalletType{Method: method, Descriptors: true,
+				Err: err}
+		case isLegacyWalletRequired(err):
+			return nil, &ErrWalletType{Method: method, Err: err}
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as an array of import result objects.
+	var results []ImportResult
+	err = json.Unmarshal(res, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FutureImportDescriptorsResult is a future promise to deliver the result of
+// an ImportDescriptorsAsync RPC invocation (or an applicable error).
+type FutureImportDescriptorsResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// outcome of each request, in the order of the requests.
+func (r FutureImportDescriptorsResult) Receive() ([]ImportResult, error) {
+	return receiveImportResult(r, "importdescriptors")
+}
+
+// ImportDescriptorsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ImportDescriptors for the blocking version and more details.
+func (c *Client) ImportDescriptorsAsync(ctx context.Context, requests []ImportDescriptorRequest) FutureImportDescriptorsResult {
+	if requests == nil {
+		requests = []ImportDescriptorRequest{}
+	}
+	rawParams, err := marshalParams([]interface{}{requests})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureImportDescriptorsResult(c.RawRequestAsync(ctx,
+		"importdescriptors", rawParams))
+}
+
+// ImportDescriptors imports the passed output descriptors into a descriptor
+// wallet and returns the outcome of each of them.  A request failing does not
+// fail the call, so the Success of every result needs to be checked.
+//
+// This needs Bitcoin Core 0.21 or later, older nodes result in an
+// *ErrNodeVersion.  Legacy wallets result in an *ErrWalletType, see
+// ImportMulti for those.
+func (c *Client) ImportDescriptors(ctx context.Context, requests []ImportDescriptorRequest) ([]ImportResult, error) {
+	if err := c.requireNodeVersion(ctx, minImportDescriptorsVersion); err != nil {
+		return nil, err
+	}
+	return c.ImportDescriptorsAsync(ctx, requests).Receive()
+}
+
+// FutureImportMultiResult is a future promise to deliver the result of an
+// ImportMultiAsync RPC invocation (or an applicable error).
+type FutureImportMultiResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// outcome of each request, in the order of the requests.
+func (r FutureImportMultiResult) Receive() ([]ImportResult, error) {
+	return receiveImportResult(r, "importmulti")
+}
+
+// ImportMultiAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ImportMulti for the blocking version and more details.
+func (c *Client) ImportMultiAsync(ctx context.Context, requests []ImportMultiRequest) FutureImportMultiResult {
+	for i, request := range requests {
+		var set int
+		for _, s := range []string{request.Desc, request.ScriptPubKey,
+			request.Address} {
+
+			if s != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return newFutureError(fmt.Errorf("import request %d needs "+
+				"exactly one of a descriptor, script or address", i))
+		}
+	}
+	if requests == nil {
+		requests = []ImportMultiRequest{}
+	}
+	rawParams, err := marshalParams([]interface{}{requests})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureImportMultiResult(c.RawRequestAsync(ctx, "importmulti",
+		rawParams))
+}
+
+// ImportMulti imports the passed scripts and addresses into a legacy wallet
+// and returns the outcome of each of them.  A request failing does not fail
+// the call, so the Success of every result needs to be checked.  The wallet
+// rescans the block chain from the earliest timestamp of the requests.
+//
+// Descriptor wallets result in an *ErrWalletType, see ImportDescriptors for
+// those.
+func (c *Client) ImportMulti(ctx context.Context, requests []ImportMultiRequest) ([]ImportResult, error) {
+	return c.ImportMultiAsync(ctx, requests).Receive()
+}
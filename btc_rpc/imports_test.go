@@ -0,0 +1,215 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestImportDescriptors(t *testing.T) {
+	const (
+		receiveDesc = "wpkh([d34db33f/84h/0h/0h]xpub6DJ2dNUysrn5Vt36jH2KLBT2i1auw1tTSSomg8PhqNiUtx8QX2SvC9nrHu81fT41fvDUnhMjEzQgXnQjKEu3oaqMSzhSrHMxyyoEAmUHQbY/0/*)#cjjspncu"
+		changeDesc  = "wpkh([d34db33f/84h/0h/0h]xpub6DJ2dNUysrn5Vt36jH2KLBT2i1auw1tTSSomg8PhqNiUtx8QX2SvC9nrHu81fT41fvDUnhMjEzQgXnQjKEu3oaqMSzhSrHMxyyoEAmUHQbY/1/*)#xsgpqvjd"
+	)
+
+	var mtx sync.Mutex
+	var params string
+	legacy := false
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		switch req.Method {
+		case "getnetworkinfo":
+			return json.RawMessage(`{"version":250000}`), nil
+		case "importdescriptors":
+			p, _ := json.Marshal(req.Params)
+			mtx.Lock()
+			defer mtx.Unlock()
+			params = string(p)
+			if legacy {
+				return nil, btcjson.NewRPCError(btcjson.ErrRPCWallet,
+					"importdescriptors is not available for non-descriptor wallets")
+			}
+			// Results as returned by Bitcoin Core 25.
+			return json.RawMessage(`[
+  {
+    "success": true,
+    "warnings": [
+      "Range not given, using default keypool range"
+    ]
+  },
+  {
+    "success": false,
+    "error": {
+      "code": -5,
+      "message": "Provided checksum 'xsgpqvjd' does not match computed checksum 'n2ydp3gs'"
+    }
+  }
+]`), nil
+		}
+		t.Errorf("unexpected method %s", req.Method)
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	birthday := time.Unix(1690000000, 0)
+	results, err := client.ImportDescriptors(ctx, []ImportDescriptorRequest{
+		{Desc: receiveDesc, Active: true, Timestamp: &birthday,
+			Label: "deposits"},
+		{Desc: changeDesc, Active: true, Range: &ScanRange{End: 999},
+			Internal: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportDescriptors: %v", err)
+	}
+	mtx.Lock()
+	want := `[[{"desc":"` + receiveDesc + `","active":true,` +
+		`"timestamp":1690000000,"label":"deposits"},` +
+		`{"desc":"` + changeDesc + `","active":true,"range":999,` +
+		`"timestamp":"now","internal":true}]]`
+	if params != want {
+		t.Fatalf("sent params %s, want %s", params, want)
+	}
+	mtx.Unlock()
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if !results[0].Success || len(results[0].Warnings) != 1 ||
+		results[0].Error != nil {
+
+		t.Fatalf("unexpected first result %+v", results[0])
+	}
+	if results[1].Success || results[1].Error == nil ||
+		results[1].Error.Code != btcjson.ErrRPCInvalidAddressOrKey {
+
+		t.Fatalf("unexpected second result %+v", results[1])
+	}
+
+	mtx.Lock()
+	legacy = true
+	mtx.Unlock()
+	_, err = client.ImportDescriptors(ctx, nil)
+	var walletErr *ErrWalletType
+	if !errors.As(err, &walletErr) || !walletErr.Descriptors ||
+		walletErr.Method != "importdescriptors" {
+
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestImportDescriptorsOldNode(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getnetworkinfo" {
+			t.Errorf("unexpected method %s", req.Method)
+			return nil, nil
+		}
+		return json.RawMessage(`{"version":200100}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	_, err := client.ImportDescriptors(context.Background(), nil)
+	var versionErr *ErrNodeVersion
+	if !errors.As(err, &versionErr) || versionErr.Version != 200100 {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestImportMulti(t *testing.T) {
+	var mtx sync.Mutex
+	var params string
+	descriptors := false
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		defer mtx.Unlock()
+		params = string(p)
+		if descriptors {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCWallet,
+				"Only legacy wallets are supported by this command")
+		}
+		return json.RawMessage(`[
+  {
+    "success": true
+  },
+  {
+    "success": true,
+    "warnings": [
+      "Importing as non-solvable: some required keys are missing. If this is intentional, don't provide any keys, pubkeys, witnessscript, or redeemscript."
+    ]
+  },
+  {
+    "success": false,
+    "error": {
+      "code": -5,
+      "message": "Invalid address \"bc1qinvalid\""
+    }
+  }
+]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	epoch := time.Unix(0, 0)
+	results, err := client.ImportMulti(ctx, []ImportMultiRequest{
+		{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			WatchOnly: true, Label: "cold"},
+		{ScriptPubKey: "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",
+			RedeemScript: "5121030000000000000000000000000000000000000000000000000000000000000001",
+			Timestamp:    &epoch},
+		{Address: "bc1qinvalid"},
+	})
+	if err != nil {
+		t.Fatalf("ImportMulti: %v", err)
+	}
+	mtx.Lock()
+	want := `[[{"scriptPubKey":{"address":"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},` +
+		`"timestamp":"now","watchonly":true,"label":"cold"},` +
+		`{"scriptPubKey":"a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",` +
+		`"redeemscript":"5121030000000000000000000000000000000000000000000000000000000000000001",` +
+		`"timestamp":0},` +
+		`{"scriptPubKey":{"address":"bc1qinvalid"},"timestamp":"now"}]]`
+	if params != want {
+		t.Fatalf("sent params %s, want %s", params, want)
+	}
+	mtx.Unlock()
+
+	// Every result is reported on its own.
+	if len(results) != 3 || !results[0].Success || !results[1].Success ||
+		len(results[1].Warnings) != 1 || results[2].Success ||
+		results[2].Error.Message != `Invalid address "bc1qinvalid"` {
+
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	_, err = client.ImportMulti(ctx, []ImportMultiRequest{
+		{Desc: "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)",
+			Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a request with two scripts")
+	}
+
+	mtx.Lock()
+	descriptors = true
+	mtx.Unlock()
+	_, err = client.ImportMulti(ctx, []ImportMultiRequest{
+		{Desc: "addr(bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4)"},
+	})
+	var walletErr *ErrWalletType
+	if !errors.As(err, &walletErr) || walletErr.Descriptors ||
+		walletErr.Method != "importmulti" {
+
+		t.Fatalf("unexpected error %v", err)
+	}
+}
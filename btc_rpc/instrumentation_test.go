@@ -0,0 +1,101 @@
+package btc_rpc
+
+import (
+	"context"
+	"expvar"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// expvarInt returns the value of the named counter for the passed method.
+func expvarInt(inst *ExpvarInstrumentation, method, name string) int64 {
+	m, ok := inst.Vars.Get(method).(*expvar.Map)
+	if !ok {
+		return 0
+	}
+	v, ok := m.Get(name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+func TestExpvarInstrumentation(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method == "getblockcount" {
+			return 100, nil
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	defer server.Close()
+
+	inst := NewExpvarInstrumentation("")
+	config := testConnConfig(server)
+	config.Instrumentation = inst
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetBlockCount(ctx); err != nil {
+			t.Fatalf("GetBlockCount: %v", err)
+		}
+	}
+	if _, err := client.RawRequest(ctx, "nosuchmethod", nil); err == nil {
+		t.Fatal("expected error for unknown method")
+	}
+
+	tests := []struct {
+		method, name string
+		want         int64
+	}{
+		{"getblockcount", "requests", 3},
+		{"getblockcount", "errors", 0},
+		{"getblockcount", "inflight", 0},
+		{"getblockcount", "le_inf", 3},
+		{"nosuchmethod", "requests", 1},
+		{"nosuchmethod", "errors", 1},
+		{"nosuchmethod", "inflight", 0},
+	}
+	for _, test := range tests {
+		got := expvarInt(inst, test.method, test.name)
+		if got != test.want {
+			t.Errorf("%s.%s = %d, want %d", test.method, test.name,
+				got, test.want)
+		}
+	}
+}
+
+func TestInstrumentationShutdown(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+
+	inst := NewExpvarInstrumentation("")
+	config := testConnConfig(server)
+	config.Instrumentation = inst
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// A request still in flight when the client shuts down must be
+	// reported as finished exactly once.
+	future := client.GetBlockCountAsync(context.Background())
+	client.Shutdown()
+	release()
+	client.WaitForShutdown()
+	future.Receive()
+
+	if got := expvarInt(inst, "getblockcount", "inflight"); got != 0 {
+		t.Fatalf("inflight = %d after shutdown, want 0", got)
+	}
+	if got := expvarInt(inst, "getblockcount", "requests"); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+}
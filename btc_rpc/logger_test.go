@@ -0,0 +1,124 @@
+package btc_rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// testLogger is a Logger which records every line it is given, prefixed with
+// the level.
+type testLogger struct {
+	mtx   sync.Mutex
+	lines []string
+}
+
+func (l *testLogger) logf(level, format string, params ...interface{}) {
+	l.mtx.Lock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, params...))
+	l.mtx.Unlock()
+}
+
+func (l *testLogger) Debugf(format string, params ...interface{}) {
+	l.logf("DBG", format, params...)
+}
+
+func (l *testLogger) Warnf(format string, params ...interface{}) {
+	l.logf("WRN", format, params...)
+}
+
+func (l *testLogger) Errorf(format string, params ...interface{}) {
+	l.logf("ERR", format, params...)
+}
+
+// contains returns whether any recorded line starts with the passed prefix.
+func (l *testLogger) contains(prefix string) bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for _, line := range l.lines {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoggerMalformedMessages(t *testing.T) {
+	logger := &testLogger{}
+	client, err := New(&ConnConfig{
+		Host:         "127.0.0.1:1",
+		HTTPPostMode: true,
+		Logger:       logger,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{`{`, "WRN Remote server sent invalid message"},
+		{`{"id":null,"params":[]}`, "WRN Malformed notification: missing method"},
+		{`{"id":null,"method":"blockconnected"}`, "WRN Malformed notification: missing params"},
+		{`{"id":1.5,"result":1}`, "WRN Malformed response: invalid identifier"},
+		{`{"id":99,"result":1,"error":null}`, "WRN Received unexpected reply: 1 (id 99)"},
+	}
+	for _, test := range tests {
+		client.handleMessage([]byte(test.msg))
+		if !logger.contains(test.want) {
+			t.Errorf("message %s: missing log line %q", test.msg,
+				test.want)
+		}
+	}
+}
+
+func TestLoggerDebugJSON(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return 100, nil
+	})
+	defer server.Close()
+
+	logger := &testLogger{}
+	config := testConnConfig(server)
+	config.Logger = logger
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if !logger.contains("DBG Sending command [getblockcount] with id 1") {
+		t.Error("request send was not logged")
+	}
+	if logger.contains("DBG Request JSON") {
+		t.Error("request JSON logged without DebugJSON")
+	}
+	stopClient(client)
+	if !logger.contains("DBG Shutting down RPC client") {
+		t.Error("shutdown was not logged")
+	}
+
+	config.DebugJSON = true
+	client, err = New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if !logger.contains(`DBG Request JSON for id 1: {"jsonrpc":"1.0","method":"getblockcount"`) {
+		t.Error("request JSON was not logged")
+	}
+	if !logger.contains(`DBG Response JSON for id 1: {"error":null,"id":1,"result":100}`) {
+		t.Error("response JSON was not logged")
+	}
+}
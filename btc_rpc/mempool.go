@@ -0,0 +1,471 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// MempoolFees holds the fees of a transaction in the memory pool.
+type MempoolFees struct {
+	// Base is the fee paid by the transaction and Modified the fee used
+	// for mining priority, which differs from Base for transactions
+	// prioritised with prioritisetransaction.
+	Base     btcutil.Amount
+	Modified btcutil.Amount
+
+	// Ancestor and Descendant are the modified fees of the transaction
+	// together with those of its unconfirmed ancestors or descendants.
+	Ancestor   btcutil.Amount
+	Descendant btcutil.Amount
+}
+
+// GetMempoolEntryResult models the data returned from the getmempoolentry
+// command and the verbose getmempoolancestors and getmempooldescendants
+// commands.
+type GetMempoolEntryResult struct {
+	// VSize is the virtual size of the transaction and Weight its weight,
+	// which older nodes do not report.
+	VSize  int64
+	Weight int64
+
+	// Time is the time the transaction entered the memory pool in seconds
+	// since the Unix epoch, and Height the block height at the time.
+	Time   int64
+	Height int64
+
+	// DescendantCount and DescendantSize are the number and virtual size
+	// of the transaction together with its unconfirmed descendants, and
+	// AncestorCount and AncestorSize those of the transaction together
+	// with its unconfirmed ancestors.
+	DescendantCount int64
+	DescendantSize  int64
+	AncestorCount   int64
+	AncestorSize    int64
+
+	WTxID string
+	Fees  MempoolFees
+
+	// Depends are the hashes of the unconfirmed transactions the
+	// transaction spends from, and SpentBy those of the unconfirmed
+	// transactions spending from it.
+	Depends []string
+	SpentBy []string
+
+	BIP125Replaceable bool
+	Unbroadcast       bool
+}
+
+// UnmarshalJSON decodes a memory pool entry, converting the fees in BTC to
+// btcutil.Amount.  Nodes before Bitcoin Core 0.17 only report the fees in flat
+// fields, of which the ancestor and descendant fees are in satoshis, and nodes
+// before Bitcoin Core 0.19 report the virtual size as size.  These fields were
+// removed by later versions and are only used when the fees object or the
+// virtual size are missing.
+func (r *GetMempoolEntryResult) UnmarshalJSON(data []byte) error {
+	var entry struct {
+		VSize           int64    `json:"vsize"`
+		Size            int64    `json:"size"`
+		Weight          int64    `json:"weight"`
+		Time            int64    `json:"time"`
+		Height          int64    `json:"height"`
+		DescendantCount int64    `json:"descendantcount"`
+		DescendantSize  int64    `json:"descendantsize"`
+		AncestorCount   int64    `json:"ancestorcount"`
+		AncestorSize    int64    `json:"ancestorsize"`
+		WTxID           string   `json:"wtxid"`
+		Depends         []string `json:"depends"`
+		SpentBy         []string `json:"spentby"`
+		Replaceable     bool     `json:"bip125-replaceable"`
+		Unbroadcast     bool     `json:"unbroadcast"`
+		Fees            *struct {
+			Base       float64 `json:"base"`
+			Modified   float64 `json:"modified"`
+			Ancestor   float64 `json:"ancestor"`
+			Descendant float64 `json:"descendant"`
+		} `json:"fees"`
+
+		// Flat fee fields of older nodes.
+		Fee            float64 `json:"fee"`
+		ModifiedFee    float64 `json:"modifiedfee"`
+		AncestorFees   int64   `json:"ancestorfees"`
+		DescendantFees int64   `json:"descendantfees"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+
+	var fees MempoolFees
+	if entry.Fees != nil {
+		var err error
+		for _, fee := range []struct {
+			amount *btcutil.Amount
+			btc    float64
+		}{
+			{&fees.Base, entry.Fees.Base},
+			{&fees.Modified, entry.Fees.Modified},
+			{&fees.Ancestor, entry.Fees.Ancestor},
+			{&fees.Descendant, entry.Fees.Descendant},
+		} {
+			*fee.amount, err = btcutil.NewAmount(fee.btc)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		base, err := btcutil.NewAmount(entry.Fee)
+		if err != nil {
+			return err
+		}
+		modified, err := btcutil.NewAmount(entry.ModifiedFee)
+		if err != nil {
+			return err
+		}
+		fees = MempoolFees{
+			Base:       base,
+			Modified:   modified,
+			Ancestor:   btcutil.Amount(entry.AncestorFees),
+			Descendant: btcutil.Amount(entry.DescendantFees),
+		}
+	}
+
+	vsize := entry.VSize
+	if vsize == 0 {
+		vsize = entry.Size
+	}
+
+	*r = GetMempoolEntryResult{
+		VSize:             vsize,
+		Weight:            entry.Weight,
+		Time:              entry.Time,
+		Height:            entry.Height,
+		DescendantCount:   entry.DescendantCount,
+		DescendantSize:    entry.DescendantSize,
+		AncestorCount:     entry.AncestorCount,
+		AncestorSize:      entry.AncestorSize,
+		WTxID:             entry.WTxID,
+		Fees:              fees,
+		Depends:           entry.Depends,
+		SpentBy:           entry.SpentBy,
+		BIP125Replaceable: entry.Replaceable,
+		Unbroadcast:       entry.Unbroadcast,
+	}
+	return nil
+}
+
+// PackageFeeRate returns the fee rate in satoshis per virtual byte of the
+// passed memory pool entry together with its unconfirmed ancestors, as
+// returned by GetMempoolAncestorsVerbose, which is the fee rate miners
+// consider the transaction at.  The modified fees are used, as miners do.
+func PackageFeeRate(entry *GetMempoolEntryResult, ancestors map[string]GetMempoolEntryResult) float64 {
+	fee := entry.Fees.Modified
+	vsize := entry.VSize
+	for _, ancestor := range ancestors {
+		fee += ancestor.Fees.Modified
+		vsize += ancestor.VSize
+	}
+	if vsize == 0 {
+		return 0
+	}
+	return float64(fee) / float64(vsize)
+}
+
+// FutureMempoolTxHashesResult is a future promise to deliver the result of a
+// GetMempoolAncestorsAsync or GetMempoolDescendantsAsync RPC invocation (or an
+// applicable error).
+type FutureMempoolTxHashesResult chan *response
+
+// Receive waits for the response promised by the future and returns the hashes
+// of the transactions.
+func (r FutureMempoolTxHashesResult) Receive() ([]*chainhash.Hash, error) {
+	// The result is a list of transaction hashes like the one of
+	// getrawmempool.
+	return FutureGetRawMempoolResult(r).Receive()
+}
+
+// FutureMempoolEntriesResult is a future promise to deliver the result of a
+// GetMempoolAncestorsVerboseAsync or GetMempoolDescendantsVerboseAsync RPC
+// invocation (or an applicable error).
+type FutureMempoolEntriesResult chan *response
+
+// Receive waits for the response promised by the future and returns a map of
+// transaction hashes to the memory pool entries of the transactions.
+func (r FutureMempoolEntriesResult) Receive() (map[string]GetMempoolEntryResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result as a map of strings (tx hashes) to their
+	// memory pool entries.
+	var entries map[string]GetMempoolEntryResult
+	err = json.Unmarshal(res, &entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// mempoolRelativesAsync sends the passed getmempoolancestors or
+// getmempooldescendants command for the passed transaction.
+func (c *Client) mempoolRelativesAsync(ctx context.Context, method string, txHash *chainhash.Hash, verbose bool) chan *response {
+	rawParams, err := marshalParams([]interface{}{txHash.String(), verbose})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return c.RawRequestAsync(ctx, method, rawParams)
+}
+
+// GetMempoolAncestorsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetMempoolAncestors for the blocking version and more details.
+func (c *Client) GetMempoolAncestorsAsync(ctx context.Context, txHash *chainhash.Hash) FutureMempoolTxHashesResult {
+	return c.mempoolRelativesAsync(ctx, "getmempoolancestors", txHash, false)
+}
+
+// GetMempoolAncestors returns the hashes of the unconfirmed transactions the
+// passed transaction in the memory pool spends from, directly or indirectly.
+//
+// See GetMempoolAncestorsVerbose to retrieve their memory pool entries
+// instead.
+func (c *Client) GetMempoolAncestors(ctx context.Context, txHash *chainhash.Hash) ([]*chainhash.Hash, error) {
+	return c.GetMempoolAncestorsAsync(ctx, txHash).Receive()
+}
+
+// GetMempoolAncestorsVerboseAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See GetMempoolAncestorsVerbose for the blocking version and more details.
+func (c *Client) GetMempoolAncestorsVerboseAsync(ctx context.Context, txHash *chainhash.Hash) FutureMempoolEntriesResult {
+	return c.mempoolRelativesAsync(ctx, "getmempoolancestors", txHash, true)
+}
+
+// GetMempoolAncestorsVerbose returns a map of transaction hashes to the memory
+// pool entries of the unconfirmed transactions the passed transaction in the
+// memory pool spends from, directly or indirectly.
+//
+// See PackageFeeRate to compute the fee rate of the transaction together with
+// its ancestors.
+func (c *Client) GetMempoolAncestorsVerbose(ctx context.Context, txHash *chainhash.Hash) (map[string]GetMempoolEntryResult, error) {
+	return c.GetMempoolAncestorsVerboseAsync(ctx, txHash).Receive()
+}
+
+// GetMempoolDescendantsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetMempoolDescendants for the blocking version and more details.
+func (c *Client) GetMempoolDescendantsAsync(ctx context.Context, txHash *chainhash.Hash) FutureMempoolTxHashesResult {
+	return c.mempoolRelativesAsync(ctx, "getmempooldescendants", txHash, false)
+}
+
+// GetMempoolDescendants returns the hashes of the unconfirmed transactions
+// spending from the passed transaction in the memory pool, directly or
+// indirectly.
+//
+// See GetMempoolDescendantsVerbose to retrieve their memory pool entries
+// instead.
+func (c *Client) GetMempoolDescendants(ctx context.Context, txHash *chainhash.Hash) ([]*chainhash.Hash, error) {
+	return c.GetMempoolDescendantsAsync(ctx, txHash).Receive()
+}
+
+// GetMempoolDescendantsVerboseAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See GetMempoolDescendantsVerbose for the blocking version and more details.
+func (c *Client) GetMempoolDescendantsVerboseAsync(ctx context.Context, txHash *chainhash.Hash) FutureMempoolEntriesResult {
+	return c.mempoolRelativesAsync(ctx, "getmempooldescendants", txHash, true)
+}
+
+// GetMempoolDescendantsVerbose returns a map of transaction hashes to the
+// memory pool entries of the unconfirmed transactions spending from the passed
+// transaction in the memory pool, directly or indirectly.
+func (c *Client) GetMempoolDescendantsVerbose(ctx context.Context, txHash *chainhash.Hash) (map[string]GetMempoolEntryResult, error) {
+	return c.GetMempoolDescendantsVerboseAsync(ctx, txHash).Receive()
+}
+
+// GetMempoolInfoResult models the data returned from the getmempoolinfo
+// command.  Fields which the node is too old to report are left empty.
+type GetMempoolInfoResult struct {
+	// Loaded is whether the node finished loading the memory pool saved
+	// on shutdown.
+	Loaded bool
+
+	// Size is the number of transactions in the memory pool, Bytes the
+	// sum of their virtual sizes and Usage the memory used for them.
+	Size  int64
+	Bytes int64
+	Usage int64
+
+	// TotalFee is the sum of the fees of the transactions.
+	TotalFee btcutil.Amount
+
+	// MaxMempool is the memory the memory pool may use before the
+	// transactions paying the lowest fee rates are evicted.
+	MaxMempool int64
+
+	// MempoolMinFee is the lowest fee rate per kilo virtual byte of the
+	// transactions accepted to the memory pool, which is raised above
+	// MinRelayTxFee while transactions are evicted for the memory pool
+	// being full.  MinRelayTxFee is the lowest fee rate relayed at all
+	// and IncrementalRelayFee the increase of the fee rate needed to
+	// replace transactions.
+	MempoolMinFee       btcutil.Amount
+	MinRelayTxFee       btcutil.Amount
+	IncrementalRelayFee btcutil.Amount
+
+	// UnbroadcastCount is the number of transactions which have not been
+	// announced to any peer yet.
+	UnbroadcastCount int64
+
+	// FullRBF is whether the node accepts replacements of transactions
+	// which do not signal replaceability.
+	FullRBF bool
+}
+
+// UnmarshalJSON decodes the result of the getmempoolinfo command, converting
+// the fees and fee rates in BTC to btcutil.Amount.
+func (r *GetMempoolInfoResult) UnmarshalJSON(data []byte) error {
+	var info struct {
+		Loaded              bool    `json:"loaded"`
+		Size                int64   `json:"size"`
+		Bytes               int64   `json:"bytes"`
+		Usage               int64   `json:"usage"`
+		TotalFee            float64 `json:"total_fee"`
+		MaxMempool          int64   `json:"maxmempool"`
+		MempoolMinFee       float64 `json:"mempoolminfee"`
+		MinRelayTxFee       float64 `json:"minrelaytxfee"`
+		IncrementalRelayFee float64 `json:"incrementalrelayfee"`
+		UnbroadcastCount    int64   `json:"unbroadcastcount"`
+		FullRBF             bool    `json:"fullrbf"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return err
+	}
+
+	*r = GetMempoolInfoResult{
+		Loaded:           info.Loaded,
+		Size:             info.Size,
+		Bytes:            info.Bytes,
+		Usage:            info.Usage,
+		MaxMempool:       info.MaxMempool,
+		UnbroadcastCount: info.UnbroadcastCount,
+		FullRBF:          info.FullRBF,
+	}
+	for _, fee := range []struct {
+		amount *btcutil.Amount
+		btc    float64
+	}{
+		{&r.TotalFee, info.TotalFee},
+		{&r.MempoolMinFee, info.MempoolMinFee},
+		{&r.MinRelayTxFee, info.MinRelayTxFee},
+		{&r.IncrementalRelayFee, info.IncrementalRelayFee},
+	} {
+		var err error
+		*fee.amount, err = btcutil.NewAmount(fee.btc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FutureGetMempoolInfoResult is a future promise to deliver the result of a
+// GetMempoolInfoAsync RPC invocation (or an applicable error).
+type FutureGetMempoolInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the state
+// of the memory pool.
+func (r FutureGetMempoolInfoResult) Receive() (*GetMempoolInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getmempoolinfo result object.
+	var result GetMempoolInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetMempoolInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetMempoolInfo for the blocking version and more details.
+func (c *Client) GetMempoolInfoAsync(ctx context.Context) FutureGetMempoolInfoResult {
+	cmd := btcjson.NewGetMempoolInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetMempoolInfo returns the state of the memory pool of the node, including
+// the fee rates transactions need to be accepted to it.
+//
+// See MinAcceptableFeeRate to get the lowest fee rate the node accepts.
+func (c *Client) GetMempoolInfo(ctx context.Context) (*GetMempoolInfoResult, error) {
+	return c.GetMempoolInfoAsync(ctx).Receive()
+}
+
+// MinAcceptableFeeRate returns the lowest fee rate in satoshis per virtual
+// byte the node accepts transactions to its memory pool at, which is the
+// higher of mempoolminfee and minrelaytxfee.  Fractions of a satoshi are
+// rounded up, so transactions paying the returned fee rate are not rejected
+// for a too low fee unless the memory pool fills up in the meantime.
+func (c *Client) MinAcceptableFeeRate(ctx context.Context) (btcutil.Amount, error) {
+	info, err := c.GetMempoolInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+	perKvB := info.MempoolMinFee
+	if info.MinRelayTxFee > perKvB {
+		perKvB = info.MinRelayTxFee
+	}
+
+	// Round up to a whole satoshi per virtual byte.
+	return (perKvB + 999) / 1000, nil
+}
+
+// FutureSaveMempoolResult is a future promise to deliver the result of a
+// SaveMempoolAsync RPC invocation (or an applicable error).
+type FutureSaveMempoolResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when saving the memory pool.
+func (r FutureSaveMempoolResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SaveMempoolAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See SaveMempool for the blocking version and more details.
+func (c *Client) SaveMempoolAsync(ctx context.Context) FutureSaveMempoolResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSaveMempoolResult(c.RawRequestAsync(ctx, "savemempool", nil))
+}
+
+// SaveMempool has the node write its memory pool to disk, as it does on
+// shutdown, so the transactions survive a crash.  It fails while the memory
+// pool saved on the previous shutdown is still being loaded.
+func (c *Client) SaveMempool(ctx context.Context) error {
+	return c.SaveMempoolAsync(ctx).Receive()
+}
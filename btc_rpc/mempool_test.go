@@ -0,0 +1,309 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+const (
+	childTxID       = "f1c0aae3f6a6b3b8a2b5c7f0d1e2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4"
+	parentTxID      = "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41"
+	grandparentTxID = "9a6d0f6b2a6c8bdbd3c1b5f5d5c6e0a7c1b0d7e6f2a8b9c0d1e2f3a4b5c6d7e8"
+)
+
+// childEntry is a memory pool entry as returned by Bitcoin Core 25, which
+// only reports the fees in the fees object.
+const childEntry = `{
+  "vsize": 141,
+  "weight": 561,
+  "time": 1690000000,
+  "height": 800000,
+  "descendantcount": 1,
+  "descendantsize": 141,
+  "ancestorcount": 3,
+  "ancestorsize": 423,
+  "wtxid": "1e0f2ad4c6b8a79e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e",
+  "fees": {
+    "base": 0.00002820,
+    "modified": 0.00002820,
+    "ancestor": 0.00003102,
+    "descendant": 0.00002820
+  },
+  "depends": [
+    "` + parentTxID + `"
+  ],
+  "spentby": [
+  ],
+  "bip125-replaceable": true,
+  "unbroadcast": false
+}`
+
+// mempoolAncestors are the ancestors of childEntry.  The parent is reported as
+// by Bitcoin Core 0.20, with both the flat fee fields and the fees object, and
+// the grandparent as by Bitcoin Core 0.16, with the flat fee fields only.
+const mempoolAncestors = `{
+  "` + parentTxID + `": {
+    "fees": {
+      "base": 0.00000141,
+      "modified": 0.00000141,
+      "ancestor": 0.00000282,
+      "descendant": 0.00003102
+    },
+    "vsize": 141,
+    "weight": 561,
+    "fee": 0.00000141,
+    "modifiedfee": 0.00000141,
+    "time": 1689999900,
+    "height": 799999,
+    "descendantcount": 2,
+    "descendantsize": 282,
+    "descendantfees": 2961,
+    "ancestorcount": 2,
+    "ancestorsize": 282,
+    "ancestorfees": 282,
+    "wtxid": "2f1e0d9c8b7a6f5e1e0f2ad4c6b8a79e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a",
+    "depends": [
+      "` + grandparentTxID + `"
+    ],
+    "spentby": [
+      "` + childTxID + `"
+    ],
+    "bip125-replaceable": true
+  },
+  "` + grandparentTxID + `": {
+    "size": 141,
+    "fee": 0.00000141,
+    "modifiedfee": 0.00000141,
+    "time": 1689999800,
+    "height": 799998,
+    "startingpriority": 0,
+    "currentpriority": 0,
+    "descendantcount": 3,
+    "descendantsize": 423,
+    "descendantfees": 3102,
+    "ancestorcount": 1,
+    "ancestorsize": 141,
+    "ancestorfees": 141,
+    "depends": [
+    ]
+  }
+}`
+
+func TestMempoolAncestry(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+
+		var verbose bool
+		if len(req.Params) > 1 {
+			json.Unmarshal(req.Params[1], &verbose)
+		}
+		switch {
+		case req.Method == "getmempoolentry":
+			return json.RawMessage(childEntry), nil
+		case req.Method == "getmempoolancestors" && verbose:
+			return json.RawMessage(mempoolAncestors), nil
+		case req.Method == "getmempoolancestors":
+			return []string{parentTxID, grandparentTxID}, nil
+		case req.Method == "getmempooldescendants":
+			return []string{}, nil
+		}
+		t.Errorf("unexpected method %s", req.Method)
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	entry, err := client.GetMempoolEntry(ctx, childTxID)
+	if err != nil {
+		t.Fatalf("GetMempoolEntry: %v", err)
+	}
+	wantFees := MempoolFees{Base: 2820, Modified: 2820, Ancestor: 3102,
+		Descendant: 2820}
+	if entry.Fees != wantFees || entry.VSize != 141 || entry.Weight != 561 ||
+		entry.AncestorCount != 3 || !entry.BIP125Replaceable ||
+		len(entry.Depends) != 1 || entry.Depends[0] != parentTxID {
+
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+
+	txHash, _ := chainhash.NewHashFromStr(childTxID)
+	ancestors, err := client.GetMempoolAncestorsVerbose(ctx, txHash)
+	if err != nil {
+		t.Fatalf("GetMempoolAncestorsVerbose: %v", err)
+	}
+	if len(ancestors) != 2 {
+		t.Fatalf("got %d ancestors, want 2", len(ancestors))
+	}
+	parent := ancestors[parentTxID]
+	wantFees = MempoolFees{Base: 141, Modified: 141, Ancestor: 282,
+		Descendant: 3102}
+	if parent.Fees != wantFees || parent.VSize != 141 ||
+		len(parent.SpentBy) != 1 {
+
+		t.Fatalf("unexpected parent entry %+v", parent)
+	}
+	grandparent := ancestors[grandparentTxID]
+	wantFees = MempoolFees{Base: 141, Modified: 141, Ancestor: 141,
+		Descendant: 3102}
+	if grandparent.Fees != wantFees || grandparent.VSize != 141 ||
+		grandparent.Weight != 0 {
+
+		t.Fatalf("unexpected grandparent entry %+v", grandparent)
+	}
+
+	// The child pays 20 sat/vB but its ancestors only 1 sat/vB, so the
+	// package pays 3102 satoshis for 423 virtual bytes.
+	feeRate := PackageFeeRate(entry, ancestors)
+	if math.Abs(feeRate-3102.0/423) > 1e-9 {
+		t.Fatalf("got package fee rate %v, want %v", feeRate, 3102.0/423)
+	}
+	if feeRate := PackageFeeRate(entry, nil); feeRate != 20 {
+		t.Fatalf("got fee rate %v without ancestors, want 20", feeRate)
+	}
+	if feeRate := PackageFeeRate(&GetMempoolEntryResult{}, nil); feeRate != 0 {
+		t.Fatalf("got fee rate %v for an empty entry, want 0", feeRate)
+	}
+
+	hashes, err := client.GetMempoolAncestors(ctx, txHash)
+	if err != nil {
+		t.Fatalf("GetMempoolAncestors: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0].String() != parentTxID ||
+		hashes[1].String() != grandparentTxID {
+
+		t.Fatalf("unexpected ancestors %v", hashes)
+	}
+	hashes, err = client.GetMempoolDescendants(ctx, txHash)
+	if err != nil {
+		t.Fatalf("GetMempoolDescendants: %v", err)
+	}
+	if len(hashes) != 0 {
+		t.Fatalf("unexpected descendants %v", hashes)
+	}
+
+	want := []string{
+		`getmempoolentry["` + childTxID + `"]`,
+		`getmempoolancestors["` + childTxID + `",true]`,
+		`getmempoolancestors["` + childTxID + `",false]`,
+		`getmempooldescendants["` + childTxID + `",false]`,
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(params) != len(want) {
+		t.Fatalf("got requests %q, want %q", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("request %d: got %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+func TestMinAcceptableFeeRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		rate  btcutil.Amount
+	}{{
+		name: "idle",
+		reply: `{"loaded":true,"size":1200,"bytes":650000,"usage":3200000,` +
+			`"total_fee":0.04312345,"maxmempool":300000000,` +
+			`"mempoolminfee":0.00001000,"minrelaytxfee":0.00001000,` +
+			`"incrementalrelayfee":0.00001000,"unbroadcastcount":0,"fullrbf":false}`,
+		rate: 1,
+	}, {
+		name: "congested",
+		reply: `{"loaded":true,"size":120000,"bytes":95000000,"usage":300000000,` +
+			`"maxmempool":300000000,"mempoolminfee":0.00012345,"minrelaytxfee":0.00001000}`,
+		rate: 13,
+	}, {
+		// Fee rates below a satoshi per virtual byte, as nodes run
+		// with -minrelaytxfee=0.000001 accept, are rounded up.
+		name: "below one",
+		reply: `{"size":0,"bytes":0,"usage":0,"maxmempool":300000000,` +
+			`"mempoolminfee":0.00000100,"minrelaytxfee":0.00000100}`,
+		rate: 1,
+	}, {
+		name: "fraction",
+		reply: `{"size":0,"bytes":0,"usage":0,"maxmempool":300000000,` +
+			`"mempoolminfee":0.00000000,"minrelaytxfee":0.00002001}`,
+		rate: 3,
+	}}
+	for _, test := range tests {
+		client, sent, stop := newFeeServer(t, test.reply)
+		rate, err := client.MinAcceptableFeeRate(context.Background())
+		stop()
+		if len(sent()) != 0 {
+			t.Fatalf("%s: sent params %v", test.name, sent())
+		}
+		if err != nil || rate != test.rate {
+			t.Fatalf("%s: MinAcceptableFeeRate = %v, %v, want %v",
+				test.name, rate, err, test.rate)
+		}
+	}
+}
+
+func TestGetMempoolInfo(t *testing.T) {
+	var mtx sync.Mutex
+	var methods []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		methods = append(methods, req.Method)
+		mtx.Unlock()
+		switch req.Method {
+		case "getmempoolinfo":
+			return json.RawMessage(`{"loaded":true,"size":1200,"bytes":650000,` +
+				`"usage":3200000,"total_fee":0.04312345,"maxmempool":300000000,` +
+				`"mempoolminfee":0.00001000,"minrelaytxfee":0.00001000,` +
+				`"incrementalrelayfee":0.00001000,"unbroadcastcount":2,"fullrbf":true}`), nil
+		case "savemempool":
+			return nil, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetMempoolInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetMempoolInfo: %v", err)
+	}
+	want := GetMempoolInfoResult{
+		Loaded:              true,
+		Size:                1200,
+		Bytes:               650000,
+		Usage:               3200000,
+		TotalFee:            4312345,
+		MaxMempool:          300000000,
+		MempoolMinFee:       1000,
+		MinRelayTxFee:       1000,
+		IncrementalRelayFee: 1000,
+		UnbroadcastCount:    2,
+		FullRBF:             true,
+	}
+	if *info != want {
+		t.Fatalf("got %+v, want %+v", info, want)
+	}
+	if err := client.SaveMempool(context.Background()); err != nil {
+		t.Fatalf("SaveMempool: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, methods, []string{"getmempoolinfo", "savemempool"})
+}
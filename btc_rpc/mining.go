@@ -0,0 +1,201 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// minGenerateBlockVersion is the first Bitcoin Core version which knows the
+// generateblock command.
+const minGenerateBlockVersion = 210000
+
+// ErrNotRegtest describes a call which only makes sense on regtest made by a
+// client configured for another network.
+type ErrNotRegtest struct {
+	// Network is the network the client is configured for.
+	Network string
+}
+
+// Error satisfies the error interface.
+func (e *ErrNotRegtest) Error() string {
+	network := e.Network
+	if network == "" {
+		network = "mainnet"
+	}
+	return "call needs a regtest node, the client is configured for " +
+		network
+}
+
+// requireRegtest returns an *ErrNotRegtest when the client is not configured
+// for regtest.
+func (c *Client) requireRegtest() error {
+	if c.config.Params != "regtest" {
+		return &ErrNotRegtest{Network: c.config.Params}
+	}
+	return nil
+}
+
+// FuturePrioritiseTransactionResult is a future promise to deliver the result
+// of a PrioritiseTransactionAsync RPC invocation (or an applicable error).
+type FuturePrioritiseTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the fee delta was applied.
+func (r FuturePrioritiseTransactionResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal the result as a boolean.
+	var applied bool
+	if err := json.Unmarshal(res, &applied); err != nil {
+		return false, err
+	}
+	return applied, nil
+}
+
+// PrioritiseTransactionAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See PrioritiseTransaction for the blocking version and more details.
+func (c *Client) PrioritiseTransactionAsync(ctx context.Context, txid *chainhash.Hash, feeDelta int64) FuturePrioritiseTransactionResult {
+	// The second parameter is the priority delta of nodes before Bitcoin
+	// Core 0.15, which later nodes require to be 0.
+	rawParams, err := marshalParams([]interface{}{txid.String(), 0, feeDelta})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FuturePrioritiseTransactionResult(c.RawRequestAsync(ctx,
+		"prioritisetransaction", rawParams))
+}
+
+// PrioritiseTransaction has the node treat the passed transaction as if it
+// paid feeDelta satoshis more, or less for a negative delta, when selecting
+// transactions for blocks and evicting them from the memory pool.  The fee
+// the transaction actually pays is unchanged and the delta is kept for
+// transactions not in the memory pool yet.  It always returns true.
+func (c *Client) PrioritiseTransaction(ctx context.Context, txid *chainhash.Hash, feeDelta int64) (bool, error) {
+	return c.PrioritiseTransactionAsync(ctx, txid, feeDelta).Receive()
+}
+
+// FutureGenerateToAddressResult is a future promise to deliver the result of
+// a GenerateToAddressAsync RPC invocation (or an applicable error).
+type FutureGenerateToAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the hashes
+// of the generated blocks.
+func (r FutureGenerateToAddressResult) Receive() ([]*chainhash.Hash, error) {
+	// The result is a list of block hashes, which is decoded like the list
+	// of transaction hashes of getrawmempool.
+	return FutureGetRawMempoolResult(r).Receive()
+}
+
+// GenerateToAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GenerateToAddress for the blocking version and more details.
+func (c *Client) GenerateToAddressAsync(ctx context.Context, nBlocks int64, addr btcutil.Address, maxTries *int64) FutureGenerateToAddressResult {
+	params := []interface{}{nBlocks, addr.EncodeAddress()}
+	if maxTries != nil {
+		params = append(params, *maxTries)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGenerateToAddressResult(c.RawRequestAsync(ctx,
+		"generatetoaddress", rawParams))
+}
+
+// GenerateToAddress has the node mine nBlocks blocks paying their coinbase to
+// the passed address and returns their hashes.  A nil maxTries leaves the
+// number of nonces tried per block to the node.  The blocks include the
+// transactions of the memory pool like any mined block.
+//
+// Mining blocks on demand only works on regtest, so an *ErrNotRegtest is
+// returned when the client is configured for another network.
+func (c *Client) GenerateToAddress(ctx context.Context, nBlocks int64, addr btcutil.Address, maxTries *int64) ([]*chainhash.Hash, error) {
+	if err := c.requireRegtest(); err != nil {
+		return nil, err
+	}
+	return c.GenerateToAddressAsync(ctx, nBlocks, addr, maxTries).Receive()
+}
+
+// FutureGenerateBlockResult is a future promise to deliver the result of a
+// GenerateBlockAsync RPC invocation (or an applicable error).
+type FutureGenerateBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the hash
+// of the generated block.
+func (r FutureGenerateBlockResult) Receive() (*chainhash.Hash, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a generateblock result object.
+	var result struct {
+		Hash string `json:"hash"`
+	}
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return chainhash.NewHashFromStr(result.Hash)
+}
+
+// GenerateBlockAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GenerateBlock for the blocking version and more details.
+func (c *Client) GenerateBlockAsync(ctx context.Context, addr btcutil.Address, txids []*chainhash.Hash) FutureGenerateBlockResult {
+	transactions := make([]string, 0, len(txids))
+	for _, txid := range txids {
+		transactions = append(transactions, txid.String())
+	}
+	rawParams, err := marshalParams([]interface{}{addr.EncodeAddress(),
+		transactions})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGenerateBlockResult(c.RawRequestAsync(ctx, "generateblock",
+		rawParams))
+}
+
+// GenerateBlock has the node mine a block paying its coinbase to the passed
+// address and including exactly the passed memory pool transactions, in
+// order, and returns its hash.  Unlike GenerateToAddress, it leaves out every
+// other transaction of the memory pool, so tests control what confirms.
+//
+// This needs Bitcoin Core 0.21 or later, older nodes result in an
+// *ErrNodeVersion.  Like GenerateToAddress it only works on regtest and an
+// *ErrNotRegtest is returned when the client is configured for another
+// network.
+func (c *Client) GenerateBlock(ctx context.Context, addr btcutil.Address, txids []*chainhash.Hash) (*chainhash.Hash, error) {
+	if err := c.requireRegtest(); err != nil {
+		return nil, err
+	}
+	if err := c.requireNodeVersion(ctx, minGenerateBlockVersion); err != nil {
+		return nil, err
+	}
+	return c.GenerateBlockAsync(ctx, addr, txids).Receive()
+}
@@ -0,0 +1,126 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+func TestMining(t *testing.T) {
+	const (
+		regtestAddr = "bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew"
+		blockHash   = "3f5a8c1b2e4d6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8"
+	)
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "getnetworkinfo":
+			return json.RawMessage(`{"version":250000}`), nil
+		case "generatetoaddress":
+			return []string{regtestGenesisHash, blockHash}, nil
+		case "generateblock":
+			return json.RawMessage(`{"hash":"` + blockHash + `"}`), nil
+		case "prioritisetransaction":
+			return true, nil
+		case "invalidateblock", "reconsiderblock":
+			return nil, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	config := testConnConfig(server)
+	config.Params = "regtest"
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress(regtestAddr, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	hashes, err := client.GenerateToAddress(ctx, 2, addr, nil)
+	if err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+	if len(hashes) != 2 || hashes[1].String() != blockHash {
+		t.Fatalf("unexpected block hashes %v", hashes)
+	}
+	maxTries := int64(1000)
+	if _, err := client.GenerateToAddress(ctx, 1, addr, &maxTries); err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+
+	txid, _ := chainhash.NewHashFromStr(genesisTxID)
+	applied, err := client.PrioritiseTransaction(ctx, txid, -5000)
+	if err != nil || !applied {
+		t.Fatalf("PrioritiseTransaction: got %v, %v, want true", applied, err)
+	}
+	hash, err := client.GenerateBlock(ctx, addr, []*chainhash.Hash{txid})
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	if hash.String() != blockHash {
+		t.Fatalf("got block hash %s, want %s", hash, blockHash)
+	}
+	if _, err := client.GenerateBlock(ctx, addr, nil); err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+
+	if err := client.InvalidateBlock(ctx, hash); err != nil {
+		t.Fatalf("InvalidateBlock: %v", err)
+	}
+	if err := client.ReconsiderBlock(ctx, hash); err != nil {
+		t.Fatalf("ReconsiderBlock: %v", err)
+	}
+
+	mtx.Lock()
+	checkRequests(t, requests, []string{
+		`generatetoaddress[2,"` + regtestAddr + `"]`,
+		`generatetoaddress[1,"` + regtestAddr + `",1000]`,
+		`prioritisetransaction["` + genesisTxID + `",0,-5000]`,
+		`getnetworkinfo[]`,
+		`generateblock["` + regtestAddr + `",["` + genesisTxID + `"]]`,
+		`generateblock["` + regtestAddr + `",[]]`,
+		`invalidateblock["` + blockHash + `"]`,
+		`reconsiderblock["` + blockHash + `"]`,
+	})
+	mtx.Unlock()
+
+	// Clients configured for other networks refuse to generate blocks
+	// without asking the node.
+	mainnetClient := newTestClient(t, server)
+	defer stopClient(mainnetClient)
+	mainnetAddr, _ := btcutil.DecodeAddress("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		&chaincfg.MainNetParams)
+	_, err = mainnetClient.GenerateToAddress(ctx, 1, mainnetAddr, nil)
+	var notRegtest *ErrNotRegtest
+	if !errors.As(err, &notRegtest) || notRegtest.Network != "" {
+		t.Fatalf("GenerateToAddress: got error %v, want ErrNotRegtest", err)
+	}
+	_, err = mainnetClient.GenerateBlock(ctx, mainnetAddr, nil)
+	if !errors.As(err, &notRegtest) {
+		t.Fatalf("GenerateBlock: got error %v, want ErrNotRegtest", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(requests) != 8 {
+		t.Fatalf("unexpected requests %v", requests[8:])
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// netParams returns the parameters of the passed network name, or an error
+// when the network is not known.  An empty name means mainnet.
+func netParams(name string) (*chaincfg.Params, error) {
+	switch name {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet3":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		// Signet shares the address encoding of testnet3, which is
+		// all the client needs the parameters for.
+		return &chaincfg.TestNet3Params, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	case "simnet":
+		return &chaincfg.SimNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown network %q", name)
+}
+
+// chainParams returns the parameters of the network the server runs on, as
+// configured in the connection configuration.
+func (c *Client) chainParams() *chaincfg.Params {
+	// The network was checked by New, so there is no error.
+	params, _ := netParams(c.config.Params)
+	return params
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrNodeVersion describes the condition where a call needs a newer node than
+// the one the client talks to.
+type ErrNodeVersion struct {
+	// Version is the version of the node, as reported by NodeVersion.
+	Version int32
+
+	// MinVersion is the minimum version the call needs.
+	MinVersion int32
+}
+
+// Error satisfies the error interface.
+func (e *ErrNodeVersion) Error() string {
+	return fmt.Sprintf("node version %d is older than the required %d",
+		e.Version, e.MinVersion)
+}
+
+// NodeVersion returns the version of the node as reported by getnetworkinfo,
+// such as 250000 for Bitcoin Core 25.0.  The version is only queried once and
+// cached for the lifetime of the client.
+func (c *Client) NodeVersion(ctx context.Context) (int32, error) {
+	if version := atomic.LoadInt32(&c.nodeVersion); version != 0 {
+		return version, nil
+	}
+
+	// Only the version of the result is needed, so the command is sent as
+	// a raw request rather than decoding the whole result.
+	res, err := c.RawRequest(ctx, "getnetworkinfo", nil)
+	if err != nil {
+		return 0, err
+	}
+	var info struct {
+		Version int32 `json:"version"`
+	}
+	if err := json.Unmarshal(res, &info); err != nil {
+		return 0, err
+	}
+	atomic.StoreInt32(&c.nodeVersion, info.Version)
+	return info.Version, nil
+}
+
+// requireNodeVersion returns an *ErrNodeVersion when the node is older than
+// the passed version.
+func (c *Client) requireNodeVersion(ctx context.Context, minVersion int32) error {
+	version, err := c.NodeVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version < minVersion {
+		return &ErrNodeVersion{Version: version, MinVersion: minVersion}
+	}
+	return nil
+}
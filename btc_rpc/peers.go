@@ -0,0 +1,405 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// AddNodeCommand enumerates the commands AddNode accepts.
+type AddNodeCommand string
+
+// Constants used to indicate the command for AddNode.
+const (
+	// AddNodeAdd adds the node to the list of nodes the server keeps
+	// connected to.
+	AddNodeAdd AddNodeCommand = "add"
+
+	// AddNodeRemove removes the node from the list of nodes the server
+	// keeps connected to.
+	AddNodeRemove AddNodeCommand = "remove"
+
+	// AddNodeOneTry connects to the node once.
+	AddNodeOneTry AddNodeCommand = "onetry"
+)
+
+// SetBanCommand enumerates the commands SetBan accepts.
+type SetBanCommand string
+
+// Constants used to indicate the command for SetBan.
+const (
+	// SetBanAdd bans the subnet.
+	SetBanAdd SetBanCommand = "add"
+
+	// SetBanRemove lifts the ban of the subnet.
+	SetBanRemove SetBanCommand = "remove"
+)
+
+// NodeAddress is an address of a node known to the server, as returned by
+// GetNodeAddresses.
+type NodeAddress struct {
+	// Time is when the node was last seen.
+	Time time.Time
+
+	// Services are the services the node offers.
+	Services uint64
+
+	// Address and Port are where the node listens.
+	Address string
+	Port    uint16
+
+	// Network is the network of the address, such as "ipv4" or "onion".
+	// Nodes before Bitcoin Core 22 do not report it.
+	Network string
+}
+
+// UnmarshalJSON decodes an entry of the getnodeaddresses result, converting
+// the time from a UNIX timestamp.
+func (a *NodeAddress) UnmarshalJSON(data []byte) error {
+	var address struct {
+		Time     int64  `json:"time"`
+		Services uint64 `json:"services"`
+		Address  string `json:"address"`
+		Port     uint16 `json:"port"`
+		Network  string `json:"network"`
+	}
+	if err := json.Unmarshal(data, &address); err != nil {
+		return err
+	}
+	*a = NodeAddress{
+		Time:     time.Unix(address.Time, 0),
+		Services: address.Services,
+		Address:  address.Address,
+		Port:     address.Port,
+		Network:  address.Network,
+	}
+	return nil
+}
+
+// AddedNodeInfo describes a node added with AddNode, as returned by
+// GetAddedNodeInfo.
+type AddedNodeInfo struct {
+	// AddedNode is the address the node was added with.
+	AddedNode string `json:"addednode"`
+
+	// Connected is whether the server is connected to the node.
+	Connected bool `json:"connected"`
+
+	// Addresses holds the addresses the server is connected to the node
+	// at.
+	Addresses []AddedNodeAddress `json:"addresses"`
+}
+
+// AddedNodeAddress is an address the server is connected to an added node at.
+type AddedNodeAddress struct {
+	Address string `json:"address"`
+
+	// Connected is the direction of the connection, either "inbound" or
+	// "outbound".
+	Connected string `json:"connected"`
+}
+
+// BannedSubnet is a subnet banned by the server, as returned by ListBanned.
+type BannedSubnet struct {
+	// Address is the banned subnet, such as "192.0.2.0/24".
+	Address string
+
+	// BanCreated is when the ban was created and BannedUntil when it
+	// expires.
+	BanCreated  time.Time
+	BannedUntil time.Time
+
+	// BanReason is why the subnet was banned.  Only nodes before Bitcoin
+	// Core 0.20 report it.
+	BanReason string
+}
+
+// UnmarshalJSON decodes an entry of the listbanned result, converting the
+// times from UNIX timestamps.
+func (b *BannedSubnet) UnmarshalJSON(data []byte) error {
+	var banned struct {
+		Address     string `json:"address"`
+		BanCreated  int64  `json:"ban_created"`
+		BannedUntil int64  `json:"banned_until"`
+		BanReason   string `json:"ban_reason"`
+	}
+	if err := json.Unmarshal(data, &banned); err != nil {
+		return err
+	}
+	*b = BannedSubnet{
+		Address:     banned.Address,
+		BanCreated:  time.Unix(banned.BanCreated, 0),
+		BannedUntil: time.Unix(banned.BannedUntil, 0),
+		BanReason:   banned.BanReason,
+	}
+	return nil
+}
+
+// FutureGetNodeAddressesResult is a future promise to deliver the result of a
+// GetNodeAddressesAsync RPC invocation (or an applicable error).
+type FutureGetNodeAddressesResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// addresses of the nodes.
+func (r FutureGetNodeAddressesResult) Receive() ([]NodeAddress, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getnodeaddresses result objects.
+	var addresses []NodeAddress
+	if err := json.Unmarshal(res, &addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// GetNodeAddressesAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetNodeAddresses for the blocking version and more details.
+func (c *Client) GetNodeAddressesAsync(ctx context.Context, count int) FutureGetNodeAddressesResult {
+	rawParams, err := marshalParams([]interface{}{count})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetNodeAddressesResult(c.RawRequestAsync(ctx,
+		"getnodeaddresses", rawParams))
+}
+
+// GetNodeAddresses returns up to count addresses of nodes known to the server,
+// chosen at random.  A count of 0 returns all known addresses, which only
+// Bitcoin Core 0.21 and later support.
+func (c *Client) GetNodeAddresses(ctx context.Context, count int) ([]NodeAddress, error) {
+	return c.GetNodeAddressesAsync(ctx, count).Receive()
+}
+
+// FutureAddNodeResult is a future promise to deliver the result of an
+// AddNodeAsync RPC invocation (or an applicable error).
+type FutureAddNodeResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when performing the specified command.
+func (r FutureAddNodeResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// AddNodeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See AddNode for the blocking version and more details.
+func (c *Client) AddNodeAsync(ctx context.Context, addr string, cmd AddNodeCommand) FutureAddNodeResult {
+	cmdAddNode := btcjson.NewAddNodeCmd(addr, btcjson.AddNodeSubCmd(cmd))
+	return c.sendCmd(ctx, cmdAddNode)
+}
+
+// AddNode adds the node at the passed address, such as "192.0.2.1:8333", to
+// the list of nodes the server keeps connected to, removes it from the list or
+// connects to it once, depending on the passed command.
+func (c *Client) AddNode(ctx context.Context, addr string, cmd AddNodeCommand) error {
+	return c.AddNodeAsync(ctx, addr, cmd).Receive()
+}
+
+// FutureDisconnectNodeResult is a future promise to deliver the result of a
+// DisconnectNodeAsync RPC invocation (or an applicable error).
+type FutureDisconnectNodeResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when disconnecting the node.
+func (r FutureDisconnectNodeResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// DisconnectNodeAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See DisconnectNode for the blocking version and more details.
+func (c *Client) DisconnectNodeAsync(ctx context.Context, addressOrID string) FutureDisconnectNodeResult {
+	// Node ids are passed as the second parameter, with an empty address.
+	params := []interface{}{addressOrID}
+	if id, err := strconv.ParseInt(addressOrID, 10, 64); err == nil {
+		params = []interface{}{"", id}
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureDisconnectNodeResult(c.RawRequestAsync(ctx,
+		"disconnectnode", rawParams))
+}
+
+// DisconnectNode disconnects the server from the passed peer, which is either
+// its address, such as "192.0.2.1:8333", or its numeric id as reported by
+// getpeerinfo.
+func (c *Client) DisconnectNode(ctx context.Context, addressOrID string) error {
+	return c.DisconnectNodeAsync(ctx, addressOrID).Receive()
+}
+
+// FutureGetAddedNodeInfoResult is a future promise to deliver the result of a
+// GetAddedNodeInfoAsync RPC invocation (or an applicable error).
+type FutureGetAddedNodeInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns
+// information about the added nodes.
+func (r FutureGetAddedNodeInfoResult) Receive() ([]AddedNodeInfo, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getaddednodeinfo result objects.
+	var nodes []AddedNodeInfo
+	if err := json.Unmarshal(res, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// GetAddedNodeInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetAddedNodeInfo for the blocking version and more details.
+func (c *Client) GetAddedNodeInfoAsync(ctx context.Context, node *string) FutureGetAddedNodeInfoResult {
+	var params []interface{}
+	if node != nil {
+		params = []interface{}{*node}
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package still knows the command with the dns parameter
+	// nodes dropped in Bitcoin Core 0.14, so it is sent as a raw request.
+	return FutureGetAddedNodeInfoResult(c.RawRequestAsync(ctx,
+		"getaddednodeinfo", rawParams))
+}
+
+// GetAddedNodeInfo returns information about the passed node added with
+// AddNode, or about all of them when node is nil.
+func (c *Client) GetAddedNodeInfo(ctx context.Context, node *string) ([]AddedNodeInfo, error) {
+	return c.GetAddedNodeInfoAsync(ctx, node).Receive()
+}
+
+// FutureSetBanResult is a future promise to deliver the result of a
+// SetBanAsync RPC invocation (or an applicable error).
+type FutureSetBanResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when banning or unbanning the subnet.
+func (r FutureSetBanResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SetBanAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See SetBan for the blocking version and more details.
+func (c *Client) SetBanAsync(ctx context.Context, subnet string, cmd SetBanCommand, banTime time.Duration) FutureSetBanResult {
+	params := []interface{}{subnet, cmd}
+	if cmd == SetBanAdd && banTime > 0 {
+		params = append(params, int64(banTime/time.Second))
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSetBanResult(c.RawRequestAsync(ctx, "setban", rawParams))
+}
+
+// SetBan bans the passed subnet, such as "192.0.2.0/24" or a single address,
+// for banTime, or lifts its ban, depending on the passed command.  A zero
+// banTime bans the subnet for the default time of the server, which is a day.
+// The server disconnects the peers in the subnet and refuses their
+// connections while the ban lasts.
+func (c *Client) SetBan(ctx context.Context, subnet string, cmd SetBanCommand, banTime time.Duration) error {
+	return c.SetBanAsync(ctx, subnet, cmd, banTime).Receive()
+}
+
+// FutureListBannedResult is a future promise to deliver the result of a
+// ListBannedAsync RPC invocation (or an applicable error).
+type FutureListBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// banned subnets.
+func (r FutureListBannedResult) Receive() ([]BannedSubnet, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listbanned result objects.
+	var banned []BannedSubnet
+	if err := json.Unmarshal(res, &banned); err != nil {
+		return nil, err
+	}
+	return banned, nil
+}
+
+// ListBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ListBanned for the blocking version and more details.
+func (c *Client) ListBannedAsync(ctx context.Context) FutureListBannedResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureListBannedResult(c.RawRequestAsync(ctx, "listbanned", nil))
+}
+
+// ListBanned returns the subnets banned by the server.
+func (c *Client) ListBanned(ctx context.Context) ([]BannedSubnet, error) {
+	return c.ListBannedAsync(ctx).Receive()
+}
+
+// FutureClearBannedResult is a future promise to deliver the result of a
+// ClearBannedAsync RPC invocation (or an applicable error).
+type FutureClearBannedResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when clearing the bans.
+func (r FutureClearBannedResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ClearBannedAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ClearBanned for the blocking version and more details.
+func (c *Client) ClearBannedAsync(ctx context.Context) FutureClearBannedResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureClearBannedResult(c.RawRequestAsync(ctx, "clearbanned", nil))
+}
+
+// ClearBanned lifts all bans of the server.
+func (c *Client) ClearBanned(ctx context.Context) error {
+	return c.ClearBannedAsync(ctx).Receive()
+}
@@ -0,0 +1,177 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestPeerManagement(t *testing.T) {
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "getnodeaddresses":
+			return json.RawMessage(`[
+  {
+    "time": 1690000000,
+    "services": 1033,
+    "address": "192.0.2.1",
+    "port": 8333,
+    "network": "ipv4"
+  },
+  {
+    "time": 1689990000,
+    "services": 3081,
+    "address": "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd.onion",
+    "port": 8333,
+    "network": "onion"
+  }
+]`), nil
+		case "getaddednodeinfo":
+			return json.RawMessage(`[
+  {
+    "addednode": "192.0.2.1:8333",
+    "connected": true,
+    "addresses": [
+      {
+        "address": "192.0.2.1:8333",
+        "connected": "outbound"
+      }
+    ]
+  },
+  {
+    "addednode": "198.51.100.7:8333",
+    "connected": false,
+    "addresses": [
+    ]
+  }
+]`), nil
+		case "listbanned":
+			// The first entry is reported as by Bitcoin Core 25 and
+			// the second as by Bitcoin Core 0.19.
+			return json.RawMessage(`[
+  {
+    "address": "203.0.113.0/24",
+    "ban_created": 1690000000,
+    "banned_until": 1690086400,
+    "ban_duration": 86400,
+    "time_remaining": 3600
+  },
+  {
+    "address": "198.51.100.9/32",
+    "banned_until": 1690003600,
+    "ban_created": 1690000000,
+    "ban_reason": "manually added"
+  }
+]`), nil
+		case "addnode", "disconnectnode", "setban", "clearbanned":
+			return nil, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addresses, err := client.GetNodeAddresses(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetNodeAddresses: %v", err)
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("got %d addresses, want 2", len(addresses))
+	}
+	if !addresses[0].Time.Equal(time.Unix(1690000000, 0)) ||
+		addresses[0].Services != 1033 || addresses[0].Port != 8333 ||
+		addresses[1].Network != "onion" {
+
+		t.Fatalf("unexpected addresses %+v", addresses)
+	}
+
+	if err := client.AddNode(ctx, "192.0.2.1:8333", AddNodeAdd); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	if err := client.AddNode(ctx, "198.51.100.7:8333", AddNodeOneTry); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	node := "192.0.2.1:8333"
+	if _, err := client.GetAddedNodeInfo(ctx, &node); err != nil {
+		t.Fatalf("GetAddedNodeInfo: %v", err)
+	}
+	nodes, err := client.GetAddedNodeInfo(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetAddedNodeInfo: %v", err)
+	}
+	if len(nodes) != 2 || !nodes[0].Connected || len(nodes[0].Addresses) != 1 ||
+		nodes[0].Addresses[0].Connected != "outbound" || nodes[1].Connected {
+
+		t.Fatalf("unexpected added nodes %+v", nodes)
+	}
+	if err := client.DisconnectNode(ctx, "192.0.2.1:8333"); err != nil {
+		t.Fatalf("DisconnectNode: %v", err)
+	}
+	if err := client.DisconnectNode(ctx, "17"); err != nil {
+		t.Fatalf("DisconnectNode: %v", err)
+	}
+	if err := client.AddNode(ctx, "192.0.2.1:8333", AddNodeRemove); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+
+	if err := client.SetBan(ctx, "203.0.113.0/24", SetBanAdd, 0); err != nil {
+		t.Fatalf("SetBan: %v", err)
+	}
+	if err := client.SetBan(ctx, "198.51.100.9", SetBanAdd, time.Hour); err != nil {
+		t.Fatalf("SetBan: %v", err)
+	}
+	banned, err := client.ListBanned(ctx)
+	if err != nil {
+		t.Fatalf("ListBanned: %v", err)
+	}
+	if len(banned) != 2 {
+		t.Fatalf("got %d banned subnets, want 2", len(banned))
+	}
+	if banned[0].Address != "203.0.113.0/24" ||
+		banned[0].BannedUntil.Sub(banned[0].BanCreated) != 24*time.Hour ||
+		banned[0].BanReason != "" {
+
+		t.Fatalf("unexpected banned subnet %+v", banned[0])
+	}
+	if !banned[1].BannedUntil.Equal(time.Unix(1690003600, 0)) ||
+		banned[1].BanReason != "manually added" {
+
+		t.Fatalf("unexpected banned subnet %+v", banned[1])
+	}
+	if err := client.SetBan(ctx, "198.51.100.9", SetBanRemove, time.Hour); err != nil {
+		t.Fatalf("SetBan: %v", err)
+	}
+	if err := client.ClearBanned(ctx); err != nil {
+		t.Fatalf("ClearBanned: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, requests, []string{
+		`getnodeaddresses[2]`,
+		`addnode["192.0.2.1:8333","add"]`,
+		`addnode["198.51.100.7:8333","onetry"]`,
+		`getaddednodeinfo["192.0.2.1:8333"]`,
+		`getaddednodeinfo[]`,
+		`disconnectnode["192.0.2.1:8333"]`,
+		`disconnectnode["",17]`,
+		`addnode["192.0.2.1:8333","remove"]`,
+		`setban["203.0.113.0/24","add"]`,
+		`setban["198.51.100.9","add",3600]`,
+		`listbanned[]`,
+		`setban["198.51.100.9","remove"]`,
+		`clearbanned[]`,
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// FuturePruneStatusResult is a future promise to deliver the result of a
+// PruneStatusAsync RPC invocation (or an applicable error).
+type FuturePruneStatusResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the node is pruned and the height of its first stored block.
+func (r FuturePruneStatusResult) Receive() (bool, int64, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, 0, err
+	}
+
+	// Unmarshal the pruning fields of the getblockchaininfo result.
+	var result struct {
+		Pruned      bool  `json:"pruned"`
+		PruneHeight int64 `json:"pruneheight"`
+	}
+	if err := json.Unmarshal(res, &result); err != nil {
+		return false, 0, err
+	}
+	return result.Pruned, result.PruneHeight, nil
+}
+
+// PruneStatusAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See PruneStatus for the blocking version and more details.
+func (c *Client) PruneStatusAsync(ctx context.Context) FuturePruneStatusResult {
+	// Only the pruning fields of the result are needed, so the command is
+	// sent as a raw request rather than decoding the whole result.
+	return FuturePruneStatusResult(c.RawRequestAsync(ctx,
+		"getblockchaininfo", nil))
+}
+
+// PruneStatus returns whether the node prunes its block storage, as reported
+// by getblockchaininfo, and if so the height of the first block whose data it
+// still stores.  The data of blocks below pruneHeight is gone, while their
+// headers and the effects on the UTXO set are kept.
+func (c *Client) PruneStatus(ctx context.Context) (pruned bool, pruneHeight int64, err error) {
+	return c.PruneStatusAsync(ctx).Receive()
+}
+
+// GetBlockAvailability returns whether the node still stores the data of the
+// block at the passed height of the main chain, as opposed to having pruned
+// it.  Heights beyond the best block result in an error.
+//
+// The block is requested with a verbosity of 1 and its transactions are not
+// decoded, which is a lot less data than the raw block.
+func (c *Client) GetBlockAvailability(ctx context.Context, height int64) (bool, error) {
+	hash, err := c.GetBlockHash(ctx, height)
+	if err != nil {
+		return false, err
+	}
+
+	rawParams, err := marshalParams([]interface{}{hash.String(), 1})
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.RawRequest(ctx, "getblock", rawParams); err != nil {
+		if isBlockPruned(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
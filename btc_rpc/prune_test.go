@@ -0,0 +1,130 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+func TestPruning(t *testing.T) {
+	const (
+		prunedHash = "0000000000000000000000000000000000000000000000000000000000000001"
+		storedHash = "0000000000000000000000000000000000000000000000000000000000000002"
+	)
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "getblockchaininfo":
+			// The softforks are reported as by Bitcoin Core 0.19
+			// and later, which btcjson fails to decode.
+			return json.RawMessage(`{
+  "chain": "main",
+  "blocks": 810000,
+  "headers": 810000,
+  "pruned": true,
+  "pruneheight": 700000,
+  "automatic_pruning": true,
+  "prune_target_size": 5242880000,
+  "softforks": {"taproot": {"type": "bip9", "active": true}}
+}`), nil
+		case "getblockhash":
+			var height int64
+			json.Unmarshal(req.Params[0], &height)
+			switch {
+			case height > 810000:
+				return nil, btcjson.NewRPCError(-8, "Block height out of range")
+			case height < 700000:
+				return prunedHash, nil
+			}
+			return storedHash, nil
+		case "getblock":
+			var hash string
+			json.Unmarshal(req.Params[0], &hash)
+			if hash == prunedHash {
+				return nil, btcjson.NewRPCError(-1, "Block not available (pruned data)")
+			}
+			return json.RawMessage(`{"hash":"` + storedHash + `","height":800000,"tx":[]}`), nil
+		case "verifychain":
+			return true, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	pruned, pruneHeight, err := client.PruneStatus(ctx)
+	if err != nil {
+		t.Fatalf("PruneStatus: %v", err)
+	}
+	if !pruned || pruneHeight != 700000 {
+		t.Fatalf("got pruned %v at height %d, want pruned at height 700000",
+			pruned, pruneHeight)
+	}
+
+	available, err := client.GetBlockAvailability(ctx, 800000)
+	if err != nil || !available {
+		t.Fatalf("GetBlockAvailability(800000): got %v, %v, want true",
+			available, err)
+	}
+	available, err = client.GetBlockAvailability(ctx, 100)
+	if err != nil || available {
+		t.Fatalf("GetBlockAvailability(100): got %v, %v, want false",
+			available, err)
+	}
+	if _, err := client.GetBlockAvailability(ctx, 900000); err == nil {
+		t.Fatal("GetBlockAvailability(900000): expected an error")
+	}
+
+	hash, _ := chainhash.NewHashFromStr(prunedHash)
+	_, err = client.GetBlockVerbose(ctx, hash)
+	var prunedErr *ErrBlockPruned
+	if !errors.As(err, &prunedErr) {
+		t.Fatalf("GetBlockVerbose: got error %v, want ErrBlockPruned", err)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -1 {
+		t.Fatalf("GetBlockVerbose: got error %v, want the RPC error", err)
+	}
+
+	checkLevel, numBlocks := int32(1), int32(0)
+	for _, test := range []struct {
+		checkLevel, numBlocks *int32
+	}{
+		{nil, nil},
+		{&checkLevel, nil},
+		{&checkLevel, &numBlocks},
+	} {
+		verified, err := client.VerifyChain(ctx, test.checkLevel, test.numBlocks)
+		if err != nil || !verified {
+			t.Fatalf("VerifyChain: got %v, %v, want true", verified, err)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, requests, []string{
+		`getblockchaininfo[]`,
+		`getblockhash[800000]`,
+		`getblock["` + storedHash + `",1]`,
+		`getblockhash[100]`,
+		`getblock["` + prunedHash + `",1]`,
+		`getblockhash[900000]`,
+		`getblock["` + prunedHash + `",true]`,
+		`verifychain[]`,
+		`verifychain[1]`,
+		`verifychain[1,0]`,
+	})
+}
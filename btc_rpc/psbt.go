@@ -0,0 +1,702 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// psbtMagic is the prefix of every serialized PSBT.
+var psbtMagic = []byte("psbt\xff")
+
+// ErrInvalidPsbt is returned by PsbtToBytes for strings which are not base64
+// encoded PSBTs.
+var ErrInvalidPsbt = errors.New("not a base64 encoded PSBT")
+
+// PsbtToBytes decodes a base64 encoded PSBT, as accepted and returned by the
+// PSBT RPCs, to its serialized form.
+func PsbtToBytes(psbt string) ([]byte, error) {
+	serialized, err := base64.StdEncoding.DecodeString(psbt)
+	if err != nil || !bytes.HasPrefix(serialized, psbtMagic) {
+		return nil, ErrInvalidPsbt
+	}
+	return serialized, nil
+}
+
+// PsbtFromBytes encodes a serialized PSBT in base64 as expected by the PSBT
+// RPCs.
+func PsbtFromBytes(serialized []byte) string {
+	return base64.StdEncoding.EncodeToString(serialized)
+}
+
+// PsbtScript describes a redeem or witness script of a PSBT input or output.
+type PsbtScript struct {
+	Asm  string `json:"asm"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+}
+
+// PsbtScriptSig describes the final signature script of a PSBT input.
+type PsbtScriptSig struct {
+	Asm string `json:"asm"`
+	Hex string `json:"hex"`
+}
+
+// PsbtScriptPubKey describes the script of an output spent by a PSBT input.
+type PsbtScriptPubKey struct {
+	Asm     string `json:"asm"`
+	Desc    string `json:"desc,omitempty"`
+	Hex     string `json:"hex"`
+	Address string `json:"address,omitempty"`
+	Type    string `json:"type"`
+}
+
+// PsbtWitnessUtxo describes the output spent by a segwit PSBT input.  The
+// amount is in BTC.
+type PsbtWitnessUtxo struct {
+	Amount       float64          `json:"amount"`
+	ScriptPubKey PsbtScriptPubKey `json:"scriptPubKey"`
+}
+
+// PsbtBip32Deriv describes the BIP 32 derivation of a public key involved in
+// a PSBT input or output.
+type PsbtBip32Deriv struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"master_fingerprint"`
+	Path              string `json:"path"`
+}
+
+// DecodePsbtInput models an input of the result of the decodepsbt command.
+type DecodePsbtInput struct {
+	// NonWitnessUtxo is the full transaction whose output is spent, and
+	// WitnessUtxo the spent output alone.  Either or both may be set once
+	// the UTXO information has been added to the PSBT.
+	NonWitnessUtxo *btcjson.TxRawResult `json:"non_witness_utxo,omitempty"`
+	WitnessUtxo    *PsbtWitnessUtxo     `json:"witness_utxo,omitempty"`
+
+	// PartialSignatures maps hex encoded public keys to their signatures.
+	PartialSignatures map[string]string `json:"partial_signatures,omitempty"`
+
+	Sighash            string            `json:"sighash,omitempty"`
+	RedeemScript       *PsbtScript       `json:"redeem_script,omitempty"`
+	WitnessScript      *PsbtScript       `json:"witness_script,omitempty"`
+	Bip32Derivs        []PsbtBip32Deriv  `json:"bip32_derivs,omitempty"`
+	FinalScriptSig     *PsbtScriptSig    `json:"final_scriptSig,omitempty"`
+	FinalScriptWitness []string          `json:"final_scriptwitness,omitempty"`
+	Unknown            map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodePsbtOutput models an output of the result of the decodepsbt command.
+type DecodePsbtOutput struct {
+	RedeemScript  *PsbtScript       `json:"redeem_script,omitempty"`
+	WitnessScript *PsbtScript       `json:"witness_script,omitempty"`
+	Bip32Derivs   []PsbtBip32Deriv  `json:"bip32_derivs,omitempty"`
+	Unknown       map[string]string `json:"unknown,omitempty"`
+}
+
+// DecodePsbtResult models the data returned from the decodepsbt command.
+type DecodePsbtResult struct {
+	// Tx is the unsigned transaction of the PSBT.
+	Tx      btcjson.TxRawResult `json:"tx"`
+	Unknown map[string]string   `json:"unknown"`
+	Inputs  []DecodePsbtInput   `json:"inputs"`
+	Outputs []DecodePsbtOutput  `json:"outputs"`
+
+	// Fee is the fee paid by the transaction in BTC.  It is only known
+	// once all inputs carry their UTXO information.
+	Fee *float64 `json:"fee,omitempty"`
+}
+
+// AnalyzePsbtMissing lists what an input of a PSBT still lacks before it can
+// be finalized, as part of the result of the analyzepsbt command.
+type AnalyzePsbtMissing struct {
+	// PubKeys are the hash160s of the public keys whose BIP 32
+	// derivation is missing.
+	PubKeys []string `json:"pubkeys,omitempty"`
+
+	// Signatures are the hash160s of the public keys whose signature is
+	// missing.
+	Signatures []string `json:"signatures,omitempty"`
+
+	// RedeemScript and WitnessScript are the hashes of missing scripts.
+	RedeemScript  string `json:"redeemscript,omitempty"`
+	WitnessScript string `json:"witnessscript,omitempty"`
+}
+
+// AnalyzePsbtInput models an input of the result of the analyzepsbt command.
+type AnalyzePsbtInput struct {
+	HasUtxo bool                `json:"has_utxo"`
+	IsFinal bool                `json:"is_final"`
+	Missing *AnalyzePsbtMissing `json:"missing,omitempty"`
+
+	// Next is the role which has to process the input next, such as
+	// "updater", "signer" or "finalizer".
+	Next string `json:"next,omitempty"`
+}
+
+// AnalyzePsbtResult models the data returned from the analyzepsbt command.
+type AnalyzePsbtResult struct {
+	Inputs []AnalyzePsbtInput `json:"inputs"`
+
+	// EstimatedVSize, EstimatedFeeRate and Fee are only known once all
+	// inputs carry their UTXO information.  The fee rate is in BTC per
+	// kilo virtual byte and the fee in BTC.
+	EstimatedVSize   *int64   `json:"estimated_vsize,omitempty"`
+	EstimatedFeeRate *float64 `json:"estimated_feerate,omitempty"`
+	Fee              *float64 `json:"fee,omitempty"`
+
+	// Next is the role which has to process the PSBT next.
+	Next string `json:"next"`
+
+	// Error describes why the PSBT is invalid, if it is.
+	Error string `json:"error,omitempty"`
+}
+
+// FinalizePsbtResult models the data returned from the finalizepsbt command.
+type FinalizePsbtResult struct {
+	// Psbt is the partially finalized PSBT.  It is set unless the PSBT
+	// is complete and the transaction was extracted.
+	Psbt string `json:"psbt,omitempty"`
+
+	// Hex is the serialized network transaction.  It is only set for
+	// complete PSBTs when extracting was asked for.
+	Hex string `json:"hex,omitempty"`
+
+	// Complete reports whether all inputs are finalized.
+	Complete bool `json:"complete"`
+}
+
+// MsgTx deserializes the extracted transaction of a complete PSBT.  It fails
+// when the result carries no transaction.
+func (r *FinalizePsbtResult) MsgTx() (*wire.MsgTx, error) {
+	if r.Hex == "" {
+		return nil, errors.New("finalized PSBT carries no transaction")
+	}
+	serializedTx, err := hex.DecodeString(r.Hex)
+	if err != nil {
+		return nil, err
+	}
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+	return &msgTx, nil
+}
+
+// WalletProcessPsbtResult models the data returned from the walletprocesspsbt
+// command.
+type WalletProcessPsbtResult struct {
+	Psbt     string `json:"psbt"`
+	Complete bool   `json:"complete"`
+}
+
+// FuturePsbtResult is a future promise to deliver the result of a
+// CreatePsbtAsync, CombinePsbtAsync or ConvertToPsbtAsync RPC invocation (or an
+// applicable error).
+type FuturePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the base64
+// encoded PSBT.
+func (r FuturePsbtResult) Receive() (string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	// Unmarshal result as a string.
+	var psbt string
+	err = json.Unmarshal(res, &psbt)
+	if err != nil {
+		return "", err
+	}
+
+	return psbt, nil
+}
+
+// CreatePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See CreatePsbt for the blocking version and more details.
+func (c *Client) CreatePsbtAsync(ctx context.Context, inputs []btcjson.TransactionInput,
+	amounts map[btcutil.Address]btcutil.Amount, lockTime *int64) FuturePsbtResult {
+
+	convertedAmts := make(map[string]float64, len(amounts))
+	for addr, amount := range amounts {
+		convertedAmts[addr.EncodeAddress()] = amount.ToBTC()
+	}
+	params := []interface{}{inputs, convertedAmts}
+	if lockTime != nil {
+		params = append(params, *lockTime)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FuturePsbtResult(c.RawRequestAsync(ctx, "createpsbt", rawParams))
+}
+
+// CreatePsbt returns a new base64 encoded PSBT spending the provided inputs
+// and sending to the provided addresses, without any UTXO information or
+// signatures.
+func (c *Client) CreatePsbt(ctx context.Context, inputs []btcjson.TransactionInput,
+	amounts map[btcutil.Address]btcutil.Amount, lockTime *int64) (string, error) {
+
+	return c.CreatePsbtAsync(ctx, inputs, amounts, lockTime).Receive()
+}
+
+// CombinePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See CombinePsbt for the blocking version and more details.
+func (c *Client) CombinePsbtAsync(ctx context.Context, psbts []string) FuturePsbtResult {
+	rawParams, err := marshalParams([]interface{}{psbts})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FuturePsbtResult(c.RawRequestAsync(ctx, "combinepsbt", rawParams))
+}
+
+// CombinePsbt merges the passed base64 encoded PSBTs of the same transaction,
+// such as those signed by different signers, into one.
+func (c *Client) CombinePsbt(ctx context.Context, psbts []string) (string, error) {
+	return c.CombinePsbtAsync(ctx, psbts).Receive()
+}
+
+// ConvertToPsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ConvertToPsbt for the blocking version and more details.
+func (c *Client) ConvertToPsbtAsync(ctx context.Context, tx *wire.MsgTx, permitSigData bool) FuturePsbtResult {
+	txHex := ""
+	if tx != nil {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		txHex = hex.EncodeToString(buf.Bytes())
+	}
+	rawParams, err := marshalParams([]interface{}{txHex, permitSigData})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FuturePsbtResult(c.RawRequestAsync(ctx, "converttopsbt", rawParams))
+}
+
+// ConvertToPsbt converts the passed unsigned transaction, as returned by
+// CreateRawTransaction, to a base64 encoded PSBT.  Transactions with signature
+// data are rejected unless permitSigData is set, in which case the signatures
+// are dropped.
+func (c *Client) ConvertToPsbt(ctx context.Context, tx *wire.MsgTx, permitSigData bool) (string, error) {
+	return c.ConvertToPsbtAsync(ctx, tx, permitSigData).Receive()
+}
+
+// FutureDecodePsbtResult is a future promise to deliver the result of a
+// DecodePsbtAsync RPC invocation (or an applicable error).
+type FutureDecodePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// decoded PSBT.
+func (r FutureDecodePsbtResult) Receive() (*DecodePsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a decodepsbt result object.
+	var decoded DecodePsbtResult
+	err = json.Unmarshal(res, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+// DecodePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See DecodePsbt for the blocking version and more details.
+func (c *Client) DecodePsbtAsync(ctx context.Context, psbt string) FutureDecodePsbtResult {
+	rawParams, err := marshalParams([]interface{}{psbt})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureDecodePsbtResult(c.RawRequestAsync(ctx, "decodepsbt", rawParams))
+}
+
+// DecodePsbt returns the unsigned transaction of the passed base64 encoded
+// PSBT together with the UTXO information, scripts and signatures gathered for
+// its inputs and outputs.
+func (c *Client) DecodePsbt(ctx context.Context, psbt string) (*DecodePsbtResult, error) {
+	return c.DecodePsbtAsync(ctx, psbt).Receive()
+}
+
+// FutureAnalyzePsbtResult is a future promise to deliver the result of an
+// AnalyzePsbtAsync RPC invocation (or an applicable error).
+type FutureAnalyzePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// analysis of the PSBT.
+func (r FutureAnalyzePsbtResult) Receive() (*AnalyzePsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an analyzepsbt result object.
+	var analysis AnalyzePsbtResult
+	err = json.Unmarshal(res, &analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	return &analysis, nil
+}
+
+// AnalyzePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See AnalyzePsbt for the blocking version and more details.
+func (c *Client) AnalyzePsbtAsync(ctx context.Context, psbt string) FutureAnalyzePsbtResult {
+	rawParams, err := marshalParams([]interface{}{psbt})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureAnalyzePsbtResult(c.RawRequestAsync(ctx, "analyzepsbt", rawParams))
+}
+
+// AnalyzePsbt returns which role has to process each input of the passed base64
+// encoded PSBT next, what the inputs are missing, and the estimated fee rate
+// once all UTXO information is present.
+func (c *Client) AnalyzePsbt(ctx context.Context, psbt string) (*AnalyzePsbtResult, error) {
+	return c.AnalyzePsbtAsync(ctx, psbt).Receive()
+}
+
+// FutureFinalizePsbtResult is a future promise to deliver the result of a
+// FinalizePsbtAsync RPC invocation (or an applicable error).
+type FutureFinalizePsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// finalized PSBT or its transaction.
+func (r FutureFinalizePsbtResult) Receive() (*FinalizePsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a finalizepsbt result object.
+	var finalized FinalizePsbtResult
+	err = json.Unmarshal(res, &finalized)
+	if err != nil {
+		return nil, err
+	}
+
+	return &finalized, nil
+}
+
+// FinalizePsbtAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See FinalizePsbt for the blocking version and more details.
+func (c *Client) FinalizePsbtAsync(ctx context.Context, psbt string, extract bool) FutureFinalizePsbtResult {
+	rawParams, err := marshalParams([]interface{}{psbt, extract})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureFinalizePsbtResult(c.RawRequestAsync(ctx, "finalizepsbt", rawParams))
+}
+
+// FinalizePsbt finalizes the inputs of the passed base64 encoded PSBT which
+// have all the signatures they need.  When all inputs are finalized and
+// extract is set, the result carries the network transaction in Hex, ready for
+// SendRawTransaction.  Otherwise it carries the PSBT with the inputs finalized
+// so far.
+func (c *Client) FinalizePsbt(ctx context.Context, psbt string, extract bool) (*FinalizePsbtResult, error) {
+	return c.FinalizePsbtAsync(ctx, psbt, extract).Receive()
+}
+
+// FutureWalletProcessPsbtResult is a future promise to deliver the result of a
+// WalletProcessPsbtAsync RPC invocation (or an applicable error).
+type FutureWalletProcessPsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the PSBT
+// updated by the wallet.
+func (r FutureWalletProcessPsbtResult) Receive() (*WalletProcessPsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a walletprocesspsbt result object.
+	var processed WalletProcessPsbtResult
+	err = json.Unmarshal(res, &processed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &processed, nil
+}
+
+// WalletProcessPsbtAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See WalletProcessPsbt for the blocking version and more details.
+func (c *Client) WalletProcessPsbtAsync(ctx context.Context, psbt string, sign bool,
+	hashType SigHashType, bip32Derivs bool) FutureWalletProcessPsbtResult {
+
+	rawParams, err := marshalParams([]interface{}{psbt, sign,
+		string(hashType), bip32Derivs})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureWalletProcessPsbtResult(c.RawRequestAsync(ctx,
+		"walletprocesspsbt", rawParams))
+}
+
+// WalletProcessPsbt adds the UTXO information, scripts and BIP 32 derivations
+// the wallet of the server knows to the passed base64 encoded PSBT and, if sign
+// is set, signs the inputs it can with the passed signature hash type.  Use
+// SigHashAll unless another type is needed, or SigHashDefault with servers
+// supporting taproot.
+//
+// NOTE: This function requires the wallet to be unlocked when signing.
+func (c *Client) WalletProcessPsbt(ctx context.Context, psbt string, sign bool,
+	hashType SigHashType, bip32Derivs bool) (*WalletProcessPsbtResult, error) {
+
+	return c.WalletProcessPsbtAsync(ctx, psbt, sign, hashType,
+		bip32Derivs).Receive()
+}
+
+// PsbtOutput is an output of the transaction funded by WalletCreateFundedPsbt.
+// It either pays Amount to Address, or carries Data in an OP_RETURN output,
+// in which case Address must be nil.
+type PsbtOutput struct {
+	Address btcutil.Address
+	Amount  btcutil.Amount
+	Data    []byte
+}
+
+// MarshalJSON encodes the output as the single key object the server expects,
+// either mapping the address to the amount in BTC or data to the hex encoded
+// data.
+func (o PsbtOutput) MarshalJSON() ([]byte, error) {
+	switch {
+	case o.Address != nil && o.Data != nil:
+		return nil, errors.New("output has both an address and data")
+	case o.Address != nil:
+		return json.Marshal(map[string]json.Number{
+			o.Address.EncodeAddress(): formatAmount(o.Amount),
+		})
+	case o.Data != nil:
+		return json.Marshal(map[string]string{
+			"data": hex.EncodeToString(o.Data),
+		})
+	}
+	return nil, errors.New("output has neither an address nor data")
+}
+
+// WalletCreateFundedPsbtOpts holds the optional settings of the transaction
+// funded by WalletCreateFundedPsbt.  Zero values leave the setting to the
+// wallet.
+type WalletCreateFundedPsbtOpts struct {
+	// ChangeAddress receives the change instead of a new address of the
+	// wallet, and ChangePosition is the index of the change output, which
+	// is random when nil.
+	ChangeAddress  btcutil.Address
+	ChangePosition *int
+
+	// IncludeWatching is whether watch-only outputs may be spent.
+	IncludeWatching bool
+
+	// LockUnspents is whether the selected outputs are locked.
+	LockUnspents bool
+
+	// ConfTarget is the number of blocks the fee rate is estimated for.
+	// It must not be set together with FeeRate.
+	ConfTarget int64
+
+	// FeeRate is the fee rate in satoshis per virtual byte, which may
+	// include fractions of a satoshi such as 1.01.  Nodes before Bitcoin
+	// Core 0.21 do not accept it.
+	FeeRate float64
+
+	// EstimateMode is the mode the fee rate is estimated in.
+	EstimateMode EstimateSmartFeeMode
+
+	// SubtractFeeFromOutputs holds the indexes of the outputs the fee is
+	// deducted from, in equal parts, instead of adding inputs for it.
+	SubtractFeeFromOutputs []int
+
+	// Replaceable is whether the transaction signals that it may be
+	// replaced, as specified by BIP 125.  The wallet decides when nil.
+	Replaceable *bool
+}
+
+// MarshalJSON encodes the options as the server expects them, leaving out the
+// settings which are left to the wallet.
+func (o WalletCreateFundedPsbtOpts) MarshalJSON() ([]byte, error) {
+	options := struct {
+		ChangeAddress          string               `json:"changeAddress,omitempty"`
+		ChangePosition         *int                 `json:"changePosition,omitempty"`
+		IncludeWatching        bool                 `json:"includeWatching,omitempty"`
+		LockUnspents           bool                 `json:"lockUnspents,omitempty"`
+		ConfTarget             int64                `json:"conf_target,omitempty"`
+		FeeRate                float64              `json:"fee_rate,omitempty"`
+		EstimateMode           EstimateSmartFeeMode `json:"estimate_mode,omitempty"`
+		SubtractFeeFromOutputs []int                `json:"subtractFeeFromOutputs,omitempty"`
+		Replaceable            *bool                `json:"replaceable,omitempty"`
+	}{
+		ChangePosition:         o.ChangePosition,
+		IncludeWatching:        o.IncludeWatching,
+		LockUnspents:           o.LockUnspents,
+		ConfTarget:             o.ConfTarget,
+		FeeRate:                o.FeeRate,
+		SubtractFeeFromOutputs: o.SubtractFeeFromOutputs,
+		Replaceable:            o.Replaceable,
+	}
+	if o.ChangeAddress != nil {
+		options.ChangeAddress = o.ChangeAddress.EncodeAddress()
+	}
+	if o.EstimateMode != EstimateModeUnset {
+		options.EstimateMode = o.EstimateMode
+	}
+	return json.Marshal(options)
+}
+
+// WalletCreateFundedPsbtResult models the data returned from the
+// walletcreatefundedpsbt command.
+type WalletCreateFundedPsbtResult struct {
+	// Psbt is the base64 encoded PSBT of the funded transaction.
+	Psbt string
+
+	// Fee is the fee the transaction pays.
+	Fee btcutil.Amount
+
+	// ChangePos is the index of the change output, or -1 when there is
+	// none.
+	ChangePos int
+}
+
+// UnmarshalJSON decodes the result of the walletcreatefundedpsbt command,
+// converting the fee in BTC to btcutil.Amount.
+func (r *WalletCreateFundedPsbtResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		Psbt      string  `json:"psbt"`
+		Fee       float64 `json:"fee"`
+		ChangePos int     `json:"changepos"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	fee, err := btcutil.NewAmount(result.Fee)
+	if err != nil {
+		return err
+	}
+	*r = WalletCreateFundedPsbtResult{
+		Psbt:      result.Psbt,
+		Fee:       fee,
+		ChangePos: result.ChangePos,
+	}
+	return nil
+}
+
+// FutureWalletCreateFundedPsbtResult is a future promise to deliver the result
+// of a WalletCreateFundedPsbtAsync RPC invocation (or an applicable error).
+type FutureWalletCreateFundedPsbtResult chan *response
+
+// Receive waits for the response promised by the future and returns the funded
+// PSBT along with its fee and change position.
+func (r FutureWalletCreateFundedPsbtResult) Receive() (*WalletCreateFundedPsbtResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a walletcreatefundedpsbt result object.
+	var result WalletCreateFundedPsbtResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// WalletCreateFundedPsbtAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See WalletCreateFundedPsbt for the blocking version and more details.
+func (c *Client) WalletCreateFundedPsbtAsync(ctx context.Context, inputs []btcjson.TransactionInput,
+	outputs []PsbtOutput, locktime uint32, opts *WalletCreateFundedPsbtOpts,
+	bip32Derivs bool) FutureWalletCreateFundedPsbtResult {
+
+	if inputs == nil {
+		inputs = []btcjson.TransactionInput{}
+	}
+	if opts == nil {
+		opts = &WalletCreateFundedPsbtOpts{}
+	}
+	rawParams, err := marshalParams([]interface{}{inputs, outputs, locktime,
+		opts, bip32Derivs})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureWalletCreateFundedPsbtResult(c.RawRequestAsync(ctx,
+		"walletcreatefundedpsbt", rawParams))
+}
+
+// WalletCreateFundedPsbt creates a PSBT paying the passed outputs and has the
+// wallet of the server fund it, adding inputs to the passed ones and a change
+// output as needed.  The returned PSBT carries the UTXO information of its
+// inputs, and their BIP 32 derivations if bip32Derivs is set, but no
+// signatures, see WalletProcessPsbt to sign it.
+func (c *Client) WalletCreateFundedPsbt(ctx context.Context, inputs []btcjson.TransactionInput,
+	outputs []PsbtOutput, locktime uint32, opts *WalletCreateFundedPsbtOpts,
+	bip32Derivs bool) (*WalletCreateFundedPsbtResult, error) {
+
+	return c.WalletCreateFundedPsbtAsync(ctx, inputs, outputs, locktime, opts,
+		bip32Derivs).Receive()
+}
@@ -0,0 +1,337 @@
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+)
+
+// testPsbt is an unsigned PSBT spending one input to a P2WPKH output, without
+// any UTXO information.
+const testPsbt = "cHNidP8BAFICAAAAATuj7f16exKyescsPmd2j2F/yBvDiIpRMjqfuKpLHl5K" +
+	"AAAAAAD9////AfC59QUAAAAAFgAUAAECAwQFBgcICQoLDA0ODxAREhMAAAAAAAAA"
+
+// The fixtures below follow the format of the results of Bitcoin Core 25.
+
+const testDecodePsbtResult = `{
+  "tx": {
+    "txid": "c3f96666a55e591d491e71134f515cd2db7245bc462dd74de336f07655d63b93",
+    "hash": "c3f96666a55e591d491e71134f515cd2db7245bc462dd74de336f07655d63b93",
+    "version": 2,
+    "size": 82,
+    "vsize": 82,
+    "weight": 328,
+    "locktime": 0,
+    "vin": [{
+      "txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+      "vout": 0,
+      "scriptSig": {"asm": "", "hex": ""},
+      "sequence": 4294967293
+    }],
+    "vout": [{
+      "value": 0.99990000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "0 000102030405060708090a0b0c0d0e0f10111213",
+        "hex": "0014000102030405060708090a0b0c0d0e0f10111213",
+        "address": "bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew",
+        "type": "witness_v0_keyhash"
+      }
+    }]
+  },
+  "global_xpubs": [],
+  "psbt_version": 0,
+  "proprietary": [],
+  "unknown": {},
+  "inputs": [{
+    "witness_utxo": {
+      "amount": 1.00000000,
+      "scriptPubKey": {
+        "asm": "0 d85c2b71d0060b09c9886aeb815e50991dda124d",
+        "hex": "0014d85c2b71d0060b09c9886aeb815e50991dda124d",
+        "address": "bcrt1qmpwzkuwsqc9snjvgdt4czhjsnywa5yjdqpxskv",
+        "type": "witness_v0_keyhash"
+      }
+    },
+    "partial_signatures": {
+      "02e2a1ac2ab8d5b4b5b3a4d6a4eb1bc1a9d40b5d1a8bd2fa7bd8bb5f3e5a3c2d1f": "3044022079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179802201ae1663dd3a8d0fa9c6b0f6f0e6e3e1bb1c3d24f5d5b16a8d6a1d0bb1a6e0b2d01"
+    },
+    "bip32_derivs": [{
+      "pubkey": "02e2a1ac2ab8d5b4b5b3a4d6a4eb1bc1a9d40b5d1a8bd2fa7bd8bb5f3e5a3c2d1f",
+      "master_fingerprint": "d90c6a4f",
+      "path": "m/84'/1'/0'/0/0"
+    }]
+  }],
+  "outputs": [{}],
+  "fee": 0.00010000
+}`
+
+const testAnalyzePsbtResult = `{
+  "inputs": [{
+    "has_utxo": true,
+    "is_final": false,
+    "missing": {
+      "signatures": ["d85c2b71d0060b09c9886aeb815e50991dda124d"]
+    },
+    "next": "signer"
+  }],
+  "estimated_vsize": 110,
+  "estimated_feerate": 0.00090909,
+  "fee": 0.00010000,
+  "next": "signer"
+}`
+
+func TestPsbtBytes(t *testing.T) {
+	serialized, err := PsbtToBytes(testPsbt)
+	if err != nil {
+		t.Fatalf("PsbtToBytes: %v", err)
+	}
+	if !bytes.HasPrefix(serialized, []byte("psbt\xff")) {
+		t.Fatalf("unexpected serialized PSBT %x", serialized)
+	}
+	if got := PsbtFromBytes(serialized); got != testPsbt {
+		t.Fatalf("unexpected PSBT %s", got)
+	}
+
+	for _, psbt := range []string{"", "not base64!", "AQIDBA=="} {
+		if _, err := PsbtToBytes(psbt); err != ErrInvalidPsbt {
+			t.Errorf("PsbtToBytes(%q): unexpected error %v", psbt, err)
+		}
+	}
+}
+
+func TestPsbtWorkflow(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		params, _ := json.Marshal(req.Params)
+		var want, result string
+		switch req.Method {
+		case "createpsbt":
+			want = `[[{"txid":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","vout":0}],` +
+				`{"bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew":0.9999},0]`
+			result = `"` + testPsbt + `"`
+		case "walletprocesspsbt":
+			want = `["` + testPsbt + `",true,"ALL",false]`
+			result = `{"psbt":"` + testPsbt + `","complete":false}`
+		case "decodepsbt":
+			want = `["` + testPsbt + `"]`
+			result = testDecodePsbtResult
+		case "analyzepsbt":
+			want = `["` + testPsbt + `"]`
+			result = testAnalyzePsbtResult
+		case "combinepsbt":
+			want = `[["` + testPsbt + `","` + testPsbt + `"]]`
+			result = `"` + testPsbt + `"`
+		case "finalizepsbt":
+			want = `["` + testPsbt + `",true]`
+			result = `{"psbt":"` + testPsbt + `","complete":false}`
+		default:
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+				"Method not found")
+		}
+		if string(params) != want {
+			t.Errorf("%s: unexpected params %s", req.Method, params)
+		}
+		return json.RawMessage(result), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := btcutil.DecodeAddress("bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	inputs := []btcjson.TransactionInput{{
+		Txid: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		Vout: 0,
+	}}
+	lockTime := int64(0)
+	psbt, err := client.CreatePsbt(ctx, inputs,
+		map[btcutil.Address]btcutil.Amount{addr: 99990000}, &lockTime)
+	if err != nil {
+		t.Fatalf("CreatePsbt: %v", err)
+	}
+	if psbt != testPsbt {
+		t.Fatalf("CreatePsbt: unexpected PSBT %s", psbt)
+	}
+
+	processed, err := client.WalletProcessPsbt(ctx, psbt, true, SigHashAll, false)
+	if err != nil {
+		t.Fatalf("WalletProcessPsbt: %v", err)
+	}
+	if processed.Complete || processed.Psbt != testPsbt {
+		t.Fatalf("WalletProcessPsbt: unexpected result %+v", processed)
+	}
+
+	decoded, err := client.DecodePsbt(ctx, psbt)
+	if err != nil {
+		t.Fatalf("DecodePsbt: %v", err)
+	}
+	if decoded.Tx.Txid != "c3f96666a55e591d491e71134f515cd2db7245bc462dd74de336f07655d63b93" ||
+		len(decoded.Tx.Vin) != 1 || len(decoded.Tx.Vout) != 1 {
+		t.Fatalf("DecodePsbt: unexpected transaction %+v", decoded.Tx)
+	}
+	if len(decoded.Inputs) != 1 || len(decoded.Outputs) != 1 {
+		t.Fatalf("DecodePsbt: unexpected inputs %+v and outputs %+v",
+			decoded.Inputs, decoded.Outputs)
+	}
+	input := decoded.Inputs[0]
+	if input.NonWitnessUtxo != nil || input.WitnessUtxo == nil ||
+		input.WitnessUtxo.Amount != 1 ||
+		input.WitnessUtxo.ScriptPubKey.Type != "witness_v0_keyhash" {
+		t.Fatalf("DecodePsbt: unexpected UTXO information %+v", input)
+	}
+	if len(input.PartialSignatures) != 1 || len(input.Bip32Derivs) != 1 ||
+		input.Bip32Derivs[0].Path != "m/84'/1'/0'/0/0" {
+		t.Fatalf("DecodePsbt: unexpected signing data %+v", input)
+	}
+	if decoded.Fee == nil || *decoded.Fee != 0.0001 {
+		t.Fatalf("DecodePsbt: unexpected fee %v", decoded.Fee)
+	}
+
+	analysis, err := client.AnalyzePsbt(ctx, psbt)
+	if err != nil {
+		t.Fatalf("AnalyzePsbt: %v", err)
+	}
+	if analysis.Next != "signer" || len(analysis.Inputs) != 1 {
+		t.Fatalf("AnalyzePsbt: unexpected result %+v", analysis)
+	}
+	analyzed := analysis.Inputs[0]
+	if !analyzed.HasUtxo || analyzed.IsFinal || analyzed.Missing == nil ||
+		len(analyzed.Missing.Signatures) != 1 {
+		t.Fatalf("AnalyzePsbt: unexpected input %+v", analyzed)
+	}
+	if analysis.EstimatedVSize == nil || *analysis.EstimatedVSize != 110 ||
+		analysis.EstimatedFeeRate == nil || *analysis.EstimatedFeeRate != 0.00090909 {
+		t.Fatalf("AnalyzePsbt: unexpected estimates %v, %v",
+			analysis.EstimatedVSize, analysis.EstimatedFeeRate)
+	}
+
+	combined, err := client.CombinePsbt(ctx, []string{psbt, psbt})
+	if err != nil {
+		t.Fatalf("CombinePsbt: %v", err)
+	}
+	if combined != testPsbt {
+		t.Fatalf("CombinePsbt: unexpected PSBT %s", combined)
+	}
+
+	finalized, err := client.FinalizePsbt(ctx, combined, true)
+	if err != nil {
+		t.Fatalf("FinalizePsbt: %v", err)
+	}
+	if finalized.Complete || finalized.Psbt != testPsbt || finalized.Hex != "" {
+		t.Fatalf("FinalizePsbt: unexpected result %+v", finalized)
+	}
+	if _, err := finalized.MsgTx(); err == nil {
+		t.Fatal("MsgTx: expected an error for an incomplete PSBT")
+	}
+}
+
+func TestFinalizePsbtComplete(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`{"hex":"` + genesisCoinbaseTx + `","complete":true}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	finalized, err := client.FinalizePsbt(context.Background(), testPsbt, true)
+	if err != nil {
+		t.Fatalf("FinalizePsbt: %v", err)
+	}
+	if !finalized.Complete || finalized.Psbt != "" {
+		t.Fatalf("unexpected result %+v", finalized)
+	}
+	msgTx, err := finalized.MsgTx()
+	if err != nil {
+		t.Fatalf("MsgTx: %v", err)
+	}
+	if got := msgTx.TxHash().String(); got != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
+		t.Fatalf("unexpected txid %s", got)
+	}
+}
+
+func TestWalletCreateFundedPsbt(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+		return json.RawMessage(`{"psbt":"` + testPsbt + `","fee":0.00002820,"changepos":1}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := btcutil.DecodeAddress("bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	changeAddr, err := btcutil.DecodeAddress("bcrt1qmpwzkuwsqc9snjvgdt4czhjsnywa5yjdqpxskv",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	outputs := []PsbtOutput{
+		{Address: addr, Amount: 99990000},
+		{Data: []byte("deposit:42")},
+	}
+	replaceable := true
+	result, err := client.WalletCreateFundedPsbt(ctx, nil, outputs, 0,
+		&WalletCreateFundedPsbtOpts{
+			ChangeAddress:          changeAddr,
+			FeeRate:                1.01,
+			SubtractFeeFromOutputs: []int{0},
+			Replaceable:            &replaceable,
+		}, true)
+	if err != nil {
+		t.Fatalf("WalletCreateFundedPsbt: %v", err)
+	}
+	if result.Psbt != testPsbt || result.Fee != 2820 || result.ChangePos != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	inputs := []btcjson.TransactionInput{{
+		Txid: "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		Vout: 0,
+	}}
+	// Amounts are sent exactly rather than in exponent notation.
+	dust := []PsbtOutput{{Address: addr, Amount: 1}}
+	if _, err := client.WalletCreateFundedPsbt(ctx, inputs, dust, 800000,
+		nil, false); err != nil {
+
+		t.Fatalf("WalletCreateFundedPsbt: %v", err)
+	}
+
+	// Outputs with both or neither an address and data are rejected
+	// before anything is sent.
+	for _, output := range []PsbtOutput{{}, {Address: addr, Data: []byte{0}}} {
+		_, err := client.WalletCreateFundedPsbt(ctx, nil,
+			[]PsbtOutput{output}, 0, nil, false)
+		if err == nil {
+			t.Fatalf("expected an error for output %+v", output)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, params, []string{
+		`[[],[{"bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew":0.9999},{"data":"6465706f7369743a3432"}],0,` +
+			`{"changeAddress":"bcrt1qmpwzkuwsqc9snjvgdt4czhjsnywa5yjdqpxskv","fee_rate":1.01,` +
+			`"subtractFeeFromOutputs":[0],"replaceable":true},true]`,
+		`[[{"txid":"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b","vout":0}],` +
+			`[{"bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew":0.00000001}],800000,{},false]`,
+	})
+}
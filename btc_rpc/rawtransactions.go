@@ -52,6 +52,11 @@ const (
 	// people to add inputs.  In addition, it uses the SigHashSingle signing
 	// method for outputs.
 	SigHashSingleAnyoneCanPay SigHashType = "SINGLE|ANYONECANPAY"
+
+	// SigHashDefault signs taproot inputs without a sighash byte and
+	// other inputs with SigHashAll.  Only the PSBT RPCs of Bitcoin Core
+	// 22 and later accept it.
+	SigHashDefault SigHashType = "DEFAULT"
 )
 
 // String returns the SighHashType in human-readable form.
@@ -244,14 +249,15 @@ func (c *Client) CreateRawTransactionAsync(ctx context.Context, inputs []btcjson
 
 	convertedAmts := make(map[string]float64, len(amounts))
 	for addr, amount := range amounts {
-		convertedAmts[addr.String()] = amount.ToBTC()
+		convertedAmts[addr.EncodeAddress()] = amount.ToBTC()
 	}
 	cmd := btcjson.NewCreateRawTransactionCmd(inputs, convertedAmts, lockTime)
 	return c.sendCmd(ctx, cmd)
 }
 
 // CreateRawTransaction returns a new transaction spending the provided inputs
-// and sending to the provided addresses.
+// and sending to the provided addresses.  Pay-to-taproot addresses are passed
+// as an *AddressTaproot, as returned by DecodeAddress.
 func (c *Client) CreateRawTransaction(ctx context.Context, inputs []btcjson.TransactionInput,
 	amounts map[btcutil.Address]btcutil.Amount, lockTime *int64) (*wire.MsgTx, error) {
 
@@ -265,10 +271,13 @@ type FutureSendRawTransactionResult chan *response
 // Receive waits for the response promised by the future and returns the result
 // of submitting the encoded transaction to the server which then relays it to
 // the network.
+//
+// Transactions the server rejects fail with an *ErrTxRejected, and those
+// already in the block chain with an *ErrTxAlreadyInChain.
 func (r FutureSendRawTransactionResult) Receive() (*chainhash.Hash, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
-		return nil, err
+		return nil, sendRawTransactionError(err)
 	}
 
 	// Unmarshal result as a string.
@@ -297,16 +306,76 @@ func (c *Client) SendRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, al
 		txHex = hex.EncodeToString(buf.Bytes())
 	}
 
-	cmd := btcjson.NewSendRawTransactionCmd(txHex, &allowHighFees)
-	return c.sendCmd(ctx, cmd)
+	return c.sendRawTransactionHexAsync(ctx, txHex, allowHighFees)
+}
+
+// sendRawTransactionHexAsync sends the hex-serialized transaction.  Unless the
+// client is configured for legacy nodes, high fees are allowed by passing a
+// maxfeerate of 0, and the default limit of the node applies otherwise.
+func (c *Client) sendRawTransactionHexAsync(ctx context.Context, txHex string, allowHighFees bool) FutureSendRawTransactionResult {
+	if c.config.LegacySendRawTransaction {
+		cmd := btcjson.NewSendRawTransactionCmd(txHex, &allowHighFees)
+		return c.sendCmd(ctx, cmd)
+	}
+
+	// The btcjson command only knows the allowhighfees flag, so the call
+	// is sent as a raw request.
+	params := []interface{}{txHex}
+	if allowHighFees {
+		params = append(params, 0)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
 }
 
 // SendRawTransaction submits the encoded transaction to the server which will
 // then relay it to the network.
+//
+// Setting allowHighFees lifts the fee rate limit of the server.  Otherwise its
+// default limit applies.  Use SendRawTransactionWithMaxFeeRate to pass a limit
+// of its own.
 func (c *Client) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
 	return c.SendRawTransactionAsync(ctx, tx, allowHighFees).Receive()
 }
 
+// SendRawTransactionWithMaxFeeRateAsync returns an instance of a type that can
+// be used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SendRawTransactionWithMaxFeeRate for the blocking version and more
+// details.
+func (c *Client) SendRawTransactionWithMaxFeeRateAsync(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) FutureSendRawTransactionResult {
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson command only knows the allowhighfees flag, so the call
+	// is sent as a raw request.
+	params := []interface{}{hex.EncodeToString(buf.Bytes()), maxFeeRate}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
+}
+
+// SendRawTransactionWithMaxFeeRate submits the encoded transaction to the
+// server which will then relay it to the network, as long as its fee rate does
+// not exceed maxFeeRate in BTC per kilo virtual byte.  A maxFeeRate of 0 means
+// no limit.
+//
+// Only Bitcoin Core 0.19 and later support this function.
+func (c *Client) SendRawTransactionWithMaxFeeRate(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) (*chainhash.Hash, error) {
+	return c.SendRawTransactionWithMaxFeeRateAsync(ctx, tx, maxFeeRate).Receive()
+}
+
 // FutureSignRawTransactionResult is a future promise to deliver the result
 // of one of the SignRawTransactionAsync family of RPC invocations (or an
 // applicable error).
@@ -359,12 +428,20 @@ func (c *Client) SignRawTransactionAsync(ctx context.Context, tx *wire.MsgTx) Fu
 	}
 
 	cmd := btcjson.NewSignRawTransactionCmd(txHex, nil, nil, nil)
-	return c.sendCmd(ctx, cmd)
+	return c.signRawTransactionAsync(ctx, cmd, "signrawtransactionwithwallet",
+		[]interface{}{txHex})
 }
 
 // SignRawTransaction signs inputs for the passed transaction and returns the
 // signed transaction as well as whether or not all inputs are now signed.
 //
+// The SignRawTransaction family uses the signrawtransactionwithwallet and
+// signrawtransactionwithkey commands of Bitcoin Core 0.17 and later, falling
+// back to the signrawtransaction command of btcd and older nodes.  Which of
+// them the server knows is detected on first use.  See
+// SignRawTransactionWithWallet and SignRawTransactionWithKey for the reasons
+// inputs could not be signed.
+//
 // This function assumes the RPC server already knows the input transactions and
 // private keys for the passed transaction which needs to be signed and uses the
 // default signature hash type.  Use one of the SignRawTransaction# variants to
@@ -390,7 +467,8 @@ func (c *Client) SignRawTransaction2Async(ctx context.Context, tx *wire.MsgTx, i
 	}
 
 	cmd := btcjson.NewSignRawTransactionCmd(txHex, &inputs, nil, nil)
-	return c.sendCmd(ctx, cmd)
+	return c.signRawTransactionAsync(ctx, cmd, "signrawtransactionwithwallet",
+		[]interface{}{txHex, prevTxsFromRawTxInputs(inputs)})
 }
 
 // SignRawTransaction2 signs inputs for the passed transaction given the list
@@ -428,7 +506,11 @@ func (c *Client) SignRawTransaction3Async(ctx context.Context, tx *wire.MsgTx,
 
 	cmd := btcjson.NewSignRawTransactionCmd(txHex, &inputs, &privKeysWIF,
 		nil)
-	return c.sendCmd(ctx, cmd)
+	if privKeysWIF == nil {
+		privKeysWIF = []string{}
+	}
+	return c.signRawTransactionAsync(ctx, cmd, "signrawtransactionwithkey",
+		[]interface{}{txHex, privKeysWIF, prevTxsFromRawTxInputs(inputs)})
 }
 
 // SignRawTransaction3 signs inputs for the passed transaction given the list
@@ -476,7 +558,16 @@ func (c *Client) SignRawTransaction4Async(ctx context.Context, tx *wire.MsgTx,
 
 	cmd := btcjson.NewSignRawTransactionCmd(txHex, &inputs, &privKeysWIF,
 		btcjson.String(string(hashType)))
-	return c.sendCmd(ctx, cmd)
+
+	// Without private keys the keys of the wallet are used.
+	prevTxs := prevTxsFromRawTxInputs(inputs)
+	if privKeysWIF == nil {
+		return c.signRawTransactionAsync(ctx, cmd,
+			"signrawtransactionwithwallet",
+			[]interface{}{txHex, prevTxs, hashType})
+	}
+	return c.signRawTransactionAsync(ctx, cmd, "signrawtransactionwithkey",
+		[]interface{}{txHex, privKeysWIF, prevTxs, hashType})
 }
 
 // SignRawTransaction4 signs inputs for the passed transaction using the
@@ -663,3 +754,17 @@ func (c *Client) DecodeScriptAsync(ctx context.Context, serializedScript []byte)
 func (c *Client) DecodeScript(ctx context.Context, serializedScript []byte) (*btcjson.DecodeScriptResult, error) {
 	return c.DecodeScriptAsync(ctx, serializedScript).Receive()
 }
+
+// marshalParams marshals the passed params of a request sent with
+// RawRequestAsync, for commands the btcjson package does not know.
+func marshalParams(params []interface{}) ([]json.RawMessage, error) {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		marshalledParam, err := json.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		rawParams = append(rawParams, marshalledParam)
+	}
+	return rawParams, nil
+}
@@ -0,0 +1,169 @@
+//go:build regtest
+// +build regtest
+
+// The tests of this file run against a Bitcoin Core node on regtest, which is
+// reached at the address in BTC_RPC_REGTEST_HOST, 127.0.0.1:18443 by default,
+// with the credentials in BTC_RPC_REGTEST_USER and BTC_RPC_REGTEST_PASS.  They
+// mine blocks and reorganize the chain, so the node must not be shared with
+// other tests.  Run them with
+//
+//	go test -tags regtest -run Regtest ./...
+
+package btc_rpc
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// regtestMiningAddr is the address the blocks mined by the tests pay to.
+const regtestMiningAddr = "bcrt1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnard0ew"
+
+// newRegtestClient returns a client talking to the regtest node of the
+// environment.  Callers are responsible for shutting the client down.
+func newRegtestClient(t *testing.T) *Client {
+	t.Helper()
+
+	host := os.Getenv("BTC_RPC_REGTEST_HOST")
+	if host == "" {
+		host = "127.0.0.1:18443"
+	}
+	client, err := New(&ConnConfig{
+		Host:         host,
+		User:         os.Getenv("BTC_RPC_REGTEST_USER"),
+		Pass:         os.Getenv("BTC_RPC_REGTEST_PASS"),
+		Params:       "regtest",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+// mineRegtestBlocks mines n blocks and returns their hashes.
+func mineRegtestBlocks(t *testing.T, client *Client, n int64) []*chainhash.Hash {
+	t.Helper()
+
+	addr, err := DecodeAddress(regtestMiningAddr, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	hashes, err := client.GenerateToAddress(context.Background(), n, addr, nil)
+	if err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+	if int64(len(hashes)) != n {
+		t.Fatalf("mined %d blocks, want %d", len(hashes), n)
+	}
+	return hashes
+}
+
+func TestRegtestReorg(t *testing.T) {
+	client := newRegtestClient(t)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	hashes := mineRegtestBlocks(t, client, 3)
+	height, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+
+	// Invalidating the first of the blocks takes all three off the chain.
+	if err := client.InvalidateBlock(ctx, hashes[0]); err != nil {
+		t.Fatalf("InvalidateBlock: %v", err)
+	}
+	reorgHeight, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if reorgHeight != height-3 {
+		t.Fatalf("got height %d after invalidating, want %d", reorgHeight,
+			height-3)
+	}
+
+	if err := client.ReconsiderBlock(ctx, hashes[0]); err != nil {
+		t.Fatalf("ReconsiderBlock: %v", err)
+	}
+	best, err := client.GetBestBlockHash(ctx)
+	if err != nil {
+		t.Fatalf("GetBestBlockHash: %v", err)
+	}
+	if !best.IsEqual(hashes[2]) {
+		t.Fatalf("got best block %s after reconsidering, want %s", best,
+			hashes[2])
+	}
+}
+
+func TestRegtestGenerateBlock(t *testing.T) {
+	client := newRegtestClient(t)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress(regtestMiningAddr, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	hash, err := client.GenerateBlock(ctx, addr, nil)
+	if err != nil {
+		t.Fatalf("GenerateBlock: %v", err)
+	}
+	block, err := client.GetBlock(ctx, hash)
+	if err != nil {
+		t.Fatalf("GetBlock: %v", err)
+	}
+	if len(block.Transactions) != 1 {
+		t.Fatalf("got %d transactions, want only the coinbase",
+			len(block.Transactions))
+	}
+
+	available, err := client.GetBlockAvailability(ctx, 1)
+	if err != nil || !available {
+		t.Fatalf("GetBlockAvailability: got %v, %v, want true", available,
+			err)
+	}
+	checkLevel, numBlocks := int32(1), int32(6)
+	verified, err := client.VerifyChain(ctx, &checkLevel, &numBlocks)
+	if err != nil || !verified {
+		t.Fatalf("VerifyChain: got %v, %v, want true", verified, err)
+	}
+}
+
+func TestRegtestMempool(t *testing.T) {
+	client := newRegtestClient(t)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	// Fee deltas apply to transactions which are not in the memory pool
+	// yet, so any transaction hash does.
+	txid, _ := chainhash.NewHashFromStr(genesisTxID)
+	applied, err := client.PrioritiseTransaction(ctx, txid, 1000)
+	if err != nil || !applied {
+		t.Fatalf("PrioritiseTransaction: got %v, %v, want true", applied,
+			err)
+	}
+	if _, err := client.PrioritiseTransaction(ctx, txid, -1000); err != nil {
+		t.Fatalf("PrioritiseTransaction: %v", err)
+	}
+
+	rate, err := client.MinAcceptableFeeRate(ctx)
+	if err != nil {
+		t.Fatalf("MinAcceptableFeeRate: %v", err)
+	}
+	if rate < 1 {
+		t.Fatalf("got minimum fee rate %v, want at least 1 sat/vB", rate)
+	}
+	pruned, _, err := client.PruneStatus(ctx)
+	if err != nil {
+		t.Fatalf("PruneStatus: %v", err)
+	}
+	if pruned {
+		t.Fatal("the regtest node must not be pruned")
+	}
+}
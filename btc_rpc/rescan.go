@@ -0,0 +1,162 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// RescanBlockchainResult models the data returned from the rescanblockchain
+// command.
+type RescanBlockchainResult struct {
+	// StartHeight and StopHeight are the heights of the first and last
+	// block rescanned.
+	StartHeight int64 `json:"start_height"`
+	StopHeight  int64 `json:"stop_height"`
+}
+
+// FutureRescanBlockchainResult is a future promise to deliver the result of a
+// RescanBlockchainAsync RPC invocation (or an applicable error).
+type FutureRescanBlockchainResult chan *response
+
+// Receive waits for the response promised by the future and returns the range
+// of blocks rescanned.  An *ErrWalletRescanning is returned when the wallet
+// was rescanning already.
+func (r FutureRescanBlockchainResult) Receive() (*RescanBlockchainResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isWalletRescanning(err) {
+			return nil, &ErrWalletRescanning{Err: err}
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a rescanblockchain result object.
+	var result RescanBlockchainResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RescanBlockchainAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See RescanBlockchain for the blocking version and more details.
+func (c *Client) RescanBlockchainAsync(ctx context.Context, startHeight, stopHeight *int64) FutureRescanBlockchainResult {
+	var params []interface{}
+	if stopHeight != nil {
+		params = []interface{}{startHeight, *stopHeight}
+	} else if startHeight != nil {
+		params = []interface{}{*startHeight}
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureRescanBlockchainResult(c.RawRequestAsync(ctx,
+		"rescanblockchain", rawParams))
+}
+
+// RescanBlockchain has the wallet rescan the block chain from startHeight to
+// stopHeight for transactions it is involved in.  A nil startHeight starts
+// from the genesis block and a nil stopHeight stops at the best block.
+//
+// The rescan takes hours on mainnet and keeps going when the request is given
+// up on, so the context should allow for it.  The ResponseHeaderTimeout of the
+// connection configuration does not apply.  RescanProgress tells how far the
+// rescan got and when it is done, also from another client.  The wallet runs
+// one rescan at a time and fails with an *ErrWalletRescanning while another
+// one is running.
+func (c *Client) RescanBlockchain(ctx context.Context, startHeight, stopHeight *int64) (*RescanBlockchainResult, error) {
+	return c.RescanBlockchainAsync(ctx, startHeight, stopHeight).Receive()
+}
+
+// RescanProgress describes the rescan a wallet is running, as returned by
+// RescanProgress.
+type RescanProgress struct {
+	// Scanning is whether the wallet is rescanning the block chain.  The
+	// other fields are only set while it is.
+	Scanning bool
+
+	// Duration is the time the rescan has been running for.
+	Duration time.Duration
+
+	// Progress is the share of the blocks rescanned so far, between zero
+	// and one.
+	Progress float64
+}
+
+// UnmarshalJSON decodes the scanning field of the getwalletinfo result, which
+// is either false or an object describing the running rescan.
+func (p *RescanProgress) UnmarshalJSON(data []byte) error {
+	var scanning bool
+	if err := json.Unmarshal(data, &scanning); err == nil {
+		*p = RescanProgress{Scanning: scanning}
+		return nil
+	}
+
+	var progress struct {
+		Duration int64   `json:"duration"`
+		Progress float64 `json:"progress"`
+	}
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return err
+	}
+	*p = RescanProgress{
+		Scanning: true,
+		Duration: time.Duration(progress.Duration) * time.Second,
+		Progress: progress.Progress,
+	}
+	return nil
+}
+
+// FutureRescanProgressResult is a future promise to deliver the result of a
+// RescanProgressAsync RPC invocation (or an applicable error).
+type FutureRescanProgressResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// progress of the rescan.
+func (r FutureRescanProgressResult) Receive() (*RescanProgress, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the scanning field of the getwalletinfo result.  Nodes
+	// before Bitcoin Core 0.17 do not report it.
+	var result struct {
+		Scanning RescanProgress `json:"scanning"`
+	}
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result.Scanning, nil
+}
+
+// RescanProgressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See RescanProgress for the blocking version and more details.
+func (c *Client) RescanProgressAsync(ctx context.Context) FutureRescanProgressResult {
+	// Only the scanning field of the result is needed, so the command is
+	// sent as a raw request rather than decoding the whole result.
+	return FutureRescanProgressResult(c.RawRequestAsync(ctx, "getwalletinfo",
+		nil))
+}
+
+// RescanProgress returns the progress of the rescan the wallet is running, as
+// reported by getwalletinfo.  It reports whether a rescan started with
+// RescanBlockchain is done even when its request timed out.
+func (c *Client) RescanProgress(ctx context.Context) (*RescanProgress, error) {
+	return c.RescanProgressAsync(ctx).Receive()
+}
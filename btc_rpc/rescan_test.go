@@ -0,0 +1,99 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestRescanBlockchain(t *testing.T) {
+	// The wallet reports the rescan started by another client first, which
+	// then finishes, as Bitcoin Core 25 does.
+	walletInfos := []string{
+		`{"walletname":"watch","txcount":12,"scanning":{"duration":10,"progress":0.25}}`,
+		`{"walletname":"watch","txcount":12,"scanning":{"duration":40,"progress":0.99}}`,
+		`{"walletname":"watch","txcount":15,"scanning":false}`,
+		`{"walletname":"watch","txcount":15}`,
+	}
+
+	var mtx sync.Mutex
+	var requests []string
+	var rescans int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		defer mtx.Unlock()
+		requests = append(requests, req.Method+string(p))
+		switch req.Method {
+		case "getwalletinfo":
+			info := walletInfos[0]
+			walletInfos = walletInfos[1:]
+			return json.RawMessage(info), nil
+		case "rescanblockchain":
+			rescans++
+			if rescans == 1 {
+				return nil, btcjson.NewRPCError(-4, "Wallet is currently "+
+					"rescanning. Abort existing rescan or wait.")
+			}
+			return json.RawMessage(`{"start_height":790000,"stop_height":800000}`), nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	startHeight := int64(790000)
+	_, err := client.RescanBlockchain(ctx, &startHeight, nil)
+	var rescanning *ErrWalletRescanning
+	if !errors.As(err, &rescanning) {
+		t.Fatalf("RescanBlockchain: got error %v, want ErrWalletRescanning", err)
+	}
+
+	wantProgress := []RescanProgress{
+		{Scanning: true, Duration: 10 * time.Second, Progress: 0.25},
+		{Scanning: true, Duration: 40 * time.Second, Progress: 0.99},
+		{},
+		{},
+	}
+	for i, want := range wantProgress {
+		progress, err := client.RescanProgress(ctx)
+		if err != nil {
+			t.Fatalf("RescanProgress: %v", err)
+		}
+		if *progress != want {
+			t.Fatalf("poll %d: got progress %+v, want %+v", i, progress,
+				want)
+		}
+	}
+
+	stopHeight := int64(800000)
+	result, err := client.RescanBlockchainAsync(ctx, &startHeight, &stopHeight).Receive()
+	if err != nil {
+		t.Fatalf("RescanBlockchain: %v", err)
+	}
+	if result.StartHeight != 790000 || result.StopHeight != 800000 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if _, err := client.RescanBlockchain(ctx, nil, &stopHeight); err != nil {
+		t.Fatalf("RescanBlockchain: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, requests, []string{
+		`rescanblockchain[790000]`,
+		`getwalletinfo[]`,
+		`getwalletinfo[]`,
+		`getwalletinfo[]`,
+		`getwalletinfo[]`,
+		`rescanblockchain[790000,800000]`,
+		`rescanblockchain[null,800000]`,
+	})
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// Bitcoin Core 0.17 split signrawtransaction into signrawtransactionwithkey
+// and signrawtransactionwithwallet and 0.18 removed it, while btcd only knows
+// signrawtransaction.  The SignRawTransaction family detects which commands
+// the server knows on first use and caches the answer in the client.
+const (
+	signRawTxUnknown int32 = iota
+	signRawTxLegacy
+	signRawTxSplit
+)
+
+// PrevTx describes a previous output spent by a transaction to sign which the
+// server does not know about.
+type PrevTx struct {
+	// TxID and Vout identify the spent output.
+	TxID string
+	Vout uint32
+
+	// ScriptPubKey is the hex encoded script of the spent output.
+	ScriptPubKey string
+
+	// RedeemScript is the hex encoded redeem script of P2SH outputs and
+	// WitnessScript the witness script of P2WSH outputs.
+	RedeemScript  string
+	WitnessScript string
+
+	// Amount is the value of the spent output, which is required to sign
+	// segwit outputs.
+	Amount btcutil.Amount
+}
+
+// MarshalJSON encodes the previous output as the server expects it, with the
+// amount in BTC.
+func (p PrevTx) MarshalJSON() ([]byte, error) {
+	prevTx := struct {
+		TxID          string   `json:"txid"`
+		Vout          uint32   `json:"vout"`
+		ScriptPubKey  string   `json:"scriptPubKey"`
+		RedeemScript  string   `json:"redeemScript,omitempty"`
+		WitnessScript string   `json:"witnessScript,omitempty"`
+		Amount        *float64 `json:"amount,omitempty"`
+	}{
+		TxID:          p.TxID,
+		Vout:          p.Vout,
+		ScriptPubKey:  p.ScriptPubKey,
+		RedeemScript:  p.RedeemScript,
+		WitnessScript: p.WitnessScript,
+	}
+	if p.Amount != 0 {
+		amount := p.Amount.ToBTC()
+		prevTx.Amount = &amount
+	}
+	return json.Marshal(prevTx)
+}
+
+// prevTxsFromRawTxInputs converts the inputs taken by the SignRawTransaction
+// family to previous outputs.  A nil slice is returned for no inputs.
+func prevTxsFromRawTxInputs(inputs []btcjson.RawTxInput) []PrevTx {
+	if len(inputs) == 0 {
+		return nil
+	}
+	prevTxs := make([]PrevTx, 0, len(inputs))
+	for _, input := range inputs {
+		prevTxs = append(prevTxs, PrevTx{
+			TxID:         input.Txid,
+			Vout:         input.Vout,
+			ScriptPubKey: input.ScriptPubKey,
+			RedeemScript: input.RedeemScript,
+		})
+	}
+	return prevTxs
+}
+
+// SignRawTransactionResult models the data returned from the
+// signrawtransactionwithkey and signrawtransactionwithwallet commands.
+type SignRawTransactionResult struct {
+	// Tx is the transaction with all signatures added so far.
+	Tx *wire.MsgTx
+
+	// Complete is whether all inputs of the transaction are signed.
+	Complete bool
+
+	// Errors holds an entry for every input which could not be signed,
+	// such as inputs of a multisig output still missing signatures.
+	Errors []btcjson.SignRawTransactionError
+}
+
+// receiveSignRawTransactionResult waits for the response promised by the
+// passed future and returns the signing result.
+func receiveSignRawTransactionResult(f chan *response) (*SignRawTransactionResult, error) {
+	res, err := receiveFuture(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as a signrawtransaction result.
+	var signRawTxResult btcjson.SignRawTransactionResult
+	if err := json.Unmarshal(res, &signRawTxResult); err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(signRawTxResult.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the transaction and return it.
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+
+	return &SignRawTransactionResult{
+		Tx:       &msgTx,
+		Complete: signRawTxResult.Complete,
+		Errors:   signRawTxResult.Errors,
+	}, nil
+}
+
+// signRawTransactionParams returns the parameters of the passed transaction
+// to sign, previous outputs and signature hash type, leaving out the trailing
+// ones which are not set.
+func signRawTransactionParams(tx *wire.MsgTx, privKeysWIF []string, withKeys bool,
+	prevTxs []PrevTx, hashType SigHashType) ([]interface{}, error) {
+
+	txHex := ""
+	if tx != nil {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return nil, err
+		}
+		txHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	params := []interface{}{txHex}
+	if withKeys {
+		if privKeysWIF == nil {
+			privKeysWIF = []string{}
+		}
+		params = append(params, privKeysWIF)
+	}
+	switch {
+	case hashType != "":
+		params = append(params, prevTxs, hashType)
+	case prevTxs != nil:
+		params = append(params, prevTxs)
+	}
+	return params, nil
+}
+
+// FutureSignRawTransactionWithKeyResult is a future promise to deliver the
+// result of a SignRawTransactionWithKeyAsync RPC invocation (or an applicable
+// error).
+type FutureSignRawTransactionWithKeyResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// signing result.
+func (r FutureSignRawTransactionWithKeyResult) Receive() (*SignRawTransactionResult, error) {
+	return receiveSignRawTransactionResult(r)
+}
+
+// SignRawTransactionWithKeyAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SignRawTransactionWithKey for the blocking version and more details.
+func (c *Client) SignRawTransactionWithKeyAsync(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []PrevTx,
+	hashType SigHashType) FutureSignRawTransactionWithKeyResult {
+
+	params, err := signRawTransactionParams(tx, privKeysWIF, true, prevTxs,
+		hashType)
+	if err != nil {
+		return newFutureError(err)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSignRawTransactionWithKeyResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithkey", rawParams))
+}
+
+// SignRawTransactionWithKey signs the inputs of the passed transaction with
+// the passed private keys in wallet import format (WIF) only, and returns the
+// transaction along with the inputs which could not be signed.  The previous
+// outputs the server does not know about must be passed in prevTxs, and an
+// empty hashType selects the default signature hash type.
+//
+// Only Bitcoin Core 0.17 and later support this function.
+func (c *Client) SignRawTransactionWithKey(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []PrevTx,
+	hashType SigHashType) (*SignRawTransactionResult, error) {
+
+	return c.SignRawTransactionWithKeyAsync(ctx, tx, privKeysWIF, prevTxs,
+		hashType).Receive()
+}
+
+// FutureSignRawTransactionWithWalletResult is a future promise to deliver the
+// result of a SignRawTransactionWithWalletAsync RPC invocation (or an
+// applicable error).
+type FutureSignRawTransactionWithWalletResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// signing result.
+func (r FutureSignRawTransactionWithWalletResult) Receive() (*SignRawTransactionResult, error) {
+	return receiveSignRawTransactionResult(r)
+}
+
+// SignRawTransactionWithWalletAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SignRawTransactionWithWallet for the blocking version and more details.
+func (c *Client) SignRawTransactionWithWalletAsync(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []PrevTx, hashType SigHashType) FutureSignRawTransactionWithWalletResult {
+
+	params, err := signRawTransactionParams(tx, nil, false, prevTxs, hashType)
+	if err != nil {
+		return newFutureError(err)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSignRawTransactionWithWalletResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithwallet", rawParams))
+}
+
+// SignRawTransactionWithWallet signs the inputs of the passed transaction
+// with the keys of the wallet, and returns the transaction along with the
+// inputs which could not be signed.  The previous outputs the wallet does not
+// know about must be passed in prevTxs, and an empty hashType selects the
+// default signature hash type.
+//
+// Only Bitcoin Core 0.17 and later support this function.
+func (c *Client) SignRawTransactionWithWallet(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []PrevTx, hashType SigHashType) (*SignRawTransactionResult, error) {
+
+	return c.SignRawTransactionWithWalletAsync(ctx, tx, prevTxs,
+		hashType).Receive()
+}
+
+// signRawTransactionAsync sends a request of the SignRawTransaction family.
+// Servers knowing signrawtransaction are sent the passed legacy command, and
+// all others the passed method and parameters.  As long as it is not known
+// which of the commands the server supports, the new one is tried first.
+func (c *Client) signRawTransactionAsync(ctx context.Context,
+	legacyCmd *btcjson.SignRawTransactionCmd, method string,
+	params []interface{}) FutureSignRawTransactionResult {
+
+	switch atomic.LoadInt32(&c.signRawTxMethods) {
+	case signRawTxLegacy:
+		return c.sendCmd(ctx, legacyCmd)
+	case signRawTxSplit:
+		rawParams, err := marshalParams(params)
+		if err != nil {
+			return newFutureError(err)
+		}
+		return FutureSignRawTransactionResult(c.RawRequestAsync(ctx,
+			method, rawParams))
+	}
+
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	future := make(chan *response, 1)
+	go func() {
+		r := <-c.RawRequestAsync(ctx, method, rawParams)
+		var rpcErr *btcjson.RPCError
+		switch {
+		case isMethodNotFound(r.err):
+			// Only remember the server to be a legacy one when it
+			// knows signrawtransaction, since nodes running without
+			// a wallet know neither signrawtransaction nor
+			// signrawtransactionwithwallet.
+			r = <-c.sendCmd(ctx, legacyCmd)
+			if !isMethodNotFound(r.err) &&
+				(r.err == nil || errors.As(r.err, &rpcErr)) {
+
+				atomic.StoreInt32(&c.signRawTxMethods,
+					signRawTxLegacy)
+			}
+
+		case r.err == nil || errors.As(r.err, &rpcErr):
+			// Any reply other than a transport error shows the
+			// server knows the command.
+			atomic.StoreInt32(&c.signRawTxMethods, signRawTxSplit)
+		}
+		future <- r
+	}()
+	return future
+}
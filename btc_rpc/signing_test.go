@@ -0,0 +1,215 @@
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// signResult is a signing result for the genesis coinbase transaction, where
+// only the signature of one of the keys of a 2-of-3 multisig output was added.
+const signResult = `{
+  "hex": "` + genesisCoinbaseTx + `",
+  "complete": false,
+  "errors": [
+    {
+      "txid": "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+      "vout": 1,
+      "witness": [
+        "",
+        "3044022018a4f4d2e1c3a0b9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6c502200a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f01"
+      ],
+      "scriptSig": "",
+      "sequence": 4294967293,
+      "error": "CHECK(MULTI)SIG failing with non-zero signature (possibly need more signatures)"
+    }
+  ]
+}`
+
+// newSigningClient returns a client of a test server which knows the passed
+// signing methods, a function returning the requests the server received and
+// one shutting both down.
+func newSigningClient(t *testing.T, methods ...string) (*Client, func() []string, func()) {
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		params, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(params))
+		mtx.Unlock()
+		for _, method := range methods {
+			if req.Method == method {
+				return json.RawMessage(signResult), nil
+			}
+		}
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+			"Method not found")
+	})
+	client := newTestClient(t, server)
+	recorded := func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string(nil), requests...)
+	}
+	return client, recorded, func() {
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func genesisCoinbaseMsgTx(t *testing.T) *wire.MsgTx {
+	t.Helper()
+
+	serializedTx, _ := hex.DecodeString(genesisCoinbaseTx)
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	return &tx
+}
+
+func checkRequests(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got requests %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSignRawTransactionDetection(t *testing.T) {
+	tx := genesisCoinbaseMsgTx(t)
+	txHex := `"` + genesisCoinbaseTx + `"`
+	keys := []string{"cVpF924EspNh8KjYsfhgY96mmxvT6DgdWiTYMtMjuM74hJaU5psW"}
+	inputs := []btcjson.RawTxInput{{
+		Txid:         "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+		Vout:         1,
+		ScriptPubKey: "a914f815b036d9bbbce5e9f2a00abd1bf3dc91e9551087",
+	}}
+	prevTxs := `[{"txid":"0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",` +
+		`"vout":1,"scriptPubKey":"a914f815b036d9bbbce5e9f2a00abd1bf3dc91e9551087"}]`
+	legacyInputs := `[{"txid":"0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",` +
+		`"vout":1,"scriptPubKey":"a914f815b036d9bbbce5e9f2a00abd1bf3dc91e9551087","redeemScript":""}]`
+	ctx := context.Background()
+
+	signAll := func(client *Client) {
+		t.Helper()
+
+		if _, complete, err := client.SignRawTransaction(ctx, tx); err != nil || complete {
+			t.Fatalf("SignRawTransaction: %v, %v", complete, err)
+		}
+		if _, _, err := client.SignRawTransaction2(ctx, tx, inputs); err != nil {
+			t.Fatalf("SignRawTransaction2: %v", err)
+		}
+		if _, _, err := client.SignRawTransaction3(ctx, tx, inputs, keys); err != nil {
+			t.Fatalf("SignRawTransaction3: %v", err)
+		}
+		if _, _, err := client.SignRawTransaction4(ctx, tx, nil, nil, SigHashSingle); err != nil {
+			t.Fatalf("SignRawTransaction4: %v", err)
+		}
+	}
+
+	// Bitcoin Core 0.18 and later only know the split commands.
+	client, requests, done := newSigningClient(t,
+		"signrawtransactionwithwallet", "signrawtransactionwithkey")
+	defer done()
+	signAll(client)
+	checkRequests(t, requests(), []string{
+		"signrawtransactionwithwallet[" + txHex + "]",
+		"signrawtransactionwithwallet[" + txHex + "," + prevTxs + "]",
+		"signrawtransactionwithkey[" + txHex + `,["` + keys[0] + `"],` + prevTxs + "]",
+		"signrawtransactionwithwallet[" + txHex + `,null,"SINGLE"]`,
+	})
+
+	// btcd only knows signrawtransaction, which is used once detected.
+	client, requests, done = newSigningClient(t, "signrawtransaction")
+	defer done()
+	signAll(client)
+	checkRequests(t, requests(), []string{
+		"signrawtransactionwithwallet[" + txHex + "]",
+		"signrawtransaction[" + txHex + "]",
+		"signrawtransaction[" + txHex + "," + legacyInputs + "]",
+		"signrawtransaction[" + txHex + "," + legacyInputs + `,["` + keys[0] + `"]]`,
+		"signrawtransaction[" + txHex + `,null,null,"SINGLE"]`,
+	})
+
+	// Nodes running without a wallet know neither command, so detection
+	// is retried.
+	client, requests, done = newSigningClient(t)
+	defer done()
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.SignRawTransaction(ctx, tx); !isMethodNotFound(err) {
+			t.Fatalf("SignRawTransaction: got error %v, want method not found", err)
+		}
+	}
+	checkRequests(t, requests(), []string{
+		"signrawtransactionwithwallet[" + txHex + "]",
+		"signrawtransaction[" + txHex + "]",
+		"signrawtransactionwithwallet[" + txHex + "]",
+		"signrawtransaction[" + txHex + "]",
+	})
+}
+
+func TestSignRawTransactionWithKey(t *testing.T) {
+	tx := genesisCoinbaseMsgTx(t)
+	ctx := context.Background()
+	client, requests, done := newSigningClient(t,
+		"signrawtransactionwithkey", "signrawtransactionwithwallet")
+	defer done()
+
+	prevTxs := []PrevTx{{
+		TxID:          "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+		Vout:          1,
+		ScriptPubKey:  "0020701a8d401c84fb13e6baf169d59684e17abd9fa216c8cc5b9fc63d622ff8c58d",
+		WitnessScript: "5221021a3b5c7d9f21436587a9cbed0f1e3d5c7b9a8f6e4d2c0b1a3957864a2c4e6f8121031b3c5d7e9f1a2b4c6d8e0f2a4b6c8d0e2f4a6b8c0d2e4f6a8b0c2d4e6f8a0b2c2102a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f953ae",
+		Amount:        150000000,
+	}}
+	result, err := client.SignRawTransactionWithKey(ctx, tx,
+		[]string{"cVpF924EspNh8KjYsfhgY96mmxvT6DgdWiTYMtMjuM74hJaU5psW"},
+		prevTxs, "")
+	if err != nil {
+		t.Fatalf("SignRawTransactionWithKey: %v", err)
+	}
+	if result.Complete || result.Tx.TxHash() != tx.TxHash() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(result.Errors))
+	}
+	signErr := result.Errors[0]
+	if signErr.TxID != prevTxs[0].TxID || signErr.Vout != 1 ||
+		signErr.Sequence != 4294967293 || signErr.ScriptSig != "" ||
+		signErr.Error != "CHECK(MULTI)SIG failing with non-zero signature (possibly need more signatures)" {
+
+		t.Fatalf("unexpected error %+v", signErr)
+	}
+
+	if _, err := client.SignRawTransactionWithWallet(ctx, tx, nil, SigHashAllAnyoneCanPay); err != nil {
+		t.Fatalf("SignRawTransactionWithWallet: %v", err)
+	}
+	if _, err := client.SignRawTransactionWithKey(ctx, tx, nil, nil, ""); err != nil {
+		t.Fatalf("SignRawTransactionWithKey: %v", err)
+	}
+
+	txHex := `"` + genesisCoinbaseTx + `"`
+	checkRequests(t, requests(), []string{
+		"signrawtransactionwithkey[" + txHex +
+			`,["cVpF924EspNh8KjYsfhgY96mmxvT6DgdWiTYMtMjuM74hJaU5psW"],` +
+			`[{"txid":"` + prevTxs[0].TxID + `","vout":1,` +
+			`"scriptPubKey":"` + prevTxs[0].ScriptPubKey + `",` +
+			`"witnessScript":"` + prevTxs[0].WitnessScript + `",` +
+			`"amount":1.5}]]`,
+		"signrawtransactionwithwallet[" + txHex + `,null,"ALL|ANYONECANPAY"]`,
+		"signrawtransactionwithkey[" + txHex + ",[]]",
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// TrafficRecorder receives the raw JSON of every request sent to the server
+// along with the raw reply, for example to diagnose incompatibilities with a
+// node version without capturing the network traffic.  Response is nil when no
+// reply was received, and err is the error the attempt failed with, if any.
+// HTTP POST mode requests which are retried are recorded once per attempt.
+//
+// Only the message bodies are passed, so the credentials sent in the request
+// headers are never included.  The byte slices are copies which may be
+// retained.  The recorder is called synchronously from the goroutines
+// answering requests, so it must be safe for concurrent use and should return
+// quickly.
+type TrafficRecorder func(method string, request, response []byte, duration time.Duration, err error)
+
+// recordTraffic passes a copy of the passed request and response to the
+// configured TrafficRecorder, if any.
+func (c *Client) recordTraffic(method string, request, response []byte, duration time.Duration, err error) {
+	if c.config.TrafficRecorder == nil {
+		return
+	}
+	c.config.TrafficRecorder(method, copyBytes(request), copyBytes(response),
+		duration, err)
+}
+
+// copyBytes returns a copy of the passed byte slice, keeping nil slices nil.
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+// trafficEntry is a line written by the recorder NewJSONTrafficRecorder
+// returns.
+type trafficEntry struct {
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	Request    interface{} `json:"request"`
+	Response   interface{} `json:"response"`
+	DurationMS float64     `json:"duration_ms"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewJSONTrafficRecorder returns a TrafficRecorder which writes every request
+// to w as a single line of JSON with the fields time, method, request,
+// response, duration_ms and error.  Requests and responses which are not valid
+// JSON, such as the error page of a proxy, are written as strings.  Write
+// errors are ignored.
+func NewJSONTrafficRecorder(w io.Writer) TrafficRecorder {
+	var mtx sync.Mutex
+	return func(method string, request, response []byte, duration time.Duration, err error) {
+		entry := trafficEntry{
+			Time:       time.Now(),
+			Method:     method,
+			Request:    rawOrString(request),
+			Response:   rawOrString(response),
+			DurationMS: float64(duration) / float64(time.Millisecond),
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		line, marshalErr := json.Marshal(entry)
+		if marshalErr != nil {
+			return
+		}
+
+		mtx.Lock()
+		w.Write(append(line, '\n'))
+		mtx.Unlock()
+	}
+}
+
+// rawOrString returns the passed message as raw JSON when it is valid JSON and
+// as a string otherwise.  Empty messages are returned as nil.
+func rawOrString(msg []byte) interface{} {
+	if len(msg) == 0 {
+		return nil
+	}
+	if json.Valid(msg) {
+		return json.RawMessage(msg)
+	}
+	return string(msg)
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// maxProofTransactions is the highest number of transactions a block, and so
+// a proof, can hold, as nodes bound it by the block weight limit divided by
+// the weight of the smallest transaction.
+const maxProofTransactions = 4000000 / 240
+
+// ErrInvalidTxOutProof is returned by VerifyTxOutProofLocal for proofs which
+// are malformed or do not commit to the expected merkle root.
+var ErrInvalidTxOutProof = errors.New("invalid transaction inclusion proof")
+
+// FutureGetTxOutProofResult is a future promise to deliver the result of a
+// GetTxOutProofAsync RPC invocation (or an applicable error).
+type FutureGetTxOutProofResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// serialized proof.
+func (r FutureGetTxOutProofResult) Receive() ([]byte, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var proofHex string
+	if err := json.Unmarshal(res, &proofHex); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(proofHex)
+}
+
+// GetTxOutProofAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetTxOutProof for the blocking version and more details.
+func (c *Client) GetTxOutProofAsync(ctx context.Context, txids []*chainhash.Hash, blockHash *chainhash.Hash) FutureGetTxOutProofResult {
+	txidStrs := make([]string, 0, len(txids))
+	for _, txid := range txids {
+		txidStrs = append(txidStrs, txid.String())
+	}
+	var hash *string
+	if blockHash != nil {
+		hash = btcjson.String(blockHash.String())
+	}
+	cmd := btcjson.NewGetTxOutProofCmd(txidStrs, hash)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetTxOutProof returns a serialized proof that the passed transactions are
+// included in a block, which is a merkle block message as used by SPV
+// clients.  All transactions must be in the same block.  Without a block
+// hash, the server only finds the block of transactions with unspent outputs
+// unless it maintains a transaction index.
+func (c *Client) GetTxOutProof(ctx context.Context, txids []*chainhash.Hash, blockHash *chainhash.Hash) ([]byte, error) {
+	return c.GetTxOutProofAsync(ctx, txids, blockHash).Receive()
+}
+
+// FutureVerifyTxOutProofResult is a future promise to deliver the result of a
+// VerifyTxOutProofAsync RPC invocation (or an applicable error).
+type FutureVerifyTxOutProofResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// hashes of the transactions the proof commits to.
+func (r FutureVerifyTxOutProofResult) Receive() ([]*chainhash.Hash, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of strings.
+	var txidStrs []string
+	if err := json.Unmarshal(res, &txidStrs); err != nil {
+		return nil, err
+	}
+	txids := make([]*chainhash.Hash, 0, len(txidStrs))
+	for _, txidStr := range txidStrs {
+		txid, err := chainhash.NewHashFromStr(txidStr)
+		if err != nil {
+			return nil, err
+		}
+		txids = append(txids, txid)
+	}
+	return txids, nil
+}
+
+// VerifyTxOutProofAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See VerifyTxOutProof for the blocking version and more details.
+func (c *Client) VerifyTxOutProofAsync(ctx context.Context, proof []byte) FutureVerifyTxOutProofResult {
+	cmd := btcjson.NewVerifyTxOutProofCmd(hex.EncodeToString(proof))
+	return c.sendCmd(ctx, cmd)
+}
+
+// VerifyTxOutProof has the server verify the passed proof, as returned by
+// GetTxOutProof, and returns the hashes of the transactions it commits to.
+// No hashes are returned when the block of the proof is not in the best
+// chain of the server.
+func (c *Client) VerifyTxOutProof(ctx context.Context, proof []byte) ([]*chainhash.Hash, error) {
+	return c.VerifyTxOutProofAsync(ctx, proof).Receive()
+}
+
+// VerifyTxOutProofLocal verifies the passed proof, as returned by
+// GetTxOutProof, without a server and returns the hashes of the transactions
+// it commits to.  The proof must commit to the passed merkle root, which the
+// caller has to take from a block header it trusts.  ErrInvalidTxOutProof is
+// returned for proofs which do not.
+func VerifyTxOutProofLocal(proof []byte, expectedMerkleRoot chainhash.Hash) ([]*chainhash.Hash, error) {
+	var merkleBlock wire.MsgMerkleBlock
+	r := bytes.NewReader(proof)
+	err := merkleBlock.BtcDecode(r, wire.ProtocolVersion, wire.BaseEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTxOutProof, err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidTxOutProof,
+			r.Len())
+	}
+	if merkleBlock.Header.MerkleRoot != expectedMerkleRoot {
+		return nil, fmt.Errorf("%w: block has merkle root %v, expected %v",
+			ErrInvalidTxOutProof, merkleBlock.Header.MerkleRoot,
+			expectedMerkleRoot)
+	}
+
+	tree, err := newPartialMerkleTree(&merkleBlock)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTxOutProof, err)
+	}
+	root, matches, err := tree.extractMatches()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTxOutProof, err)
+	}
+	if root != expectedMerkleRoot {
+		return nil, fmt.Errorf("%w: proof commits to merkle root %v, "+
+			"expected %v", ErrInvalidTxOutProof, root, expectedMerkleRoot)
+	}
+	return matches, nil
+}
+
+// partialMerkleTree walks the partial merkle tree of a merkle block the same
+// way nodes do, which is a depth-first traversal where every node consumes a
+// flag bit telling whether it is the parent of a matched transaction.
+type partialMerkleTree struct {
+	numTransactions uint32
+	hashes          []*chainhash.Hash
+	flags           []byte
+	bitsUsed        int
+	hashesUsed      int
+}
+
+// newPartialMerkleTree returns the partial merkle tree of the passed merkle
+// block after checking its size limits.
+func newPartialMerkleTree(merkleBlock *wire.MsgMerkleBlock) (*partialMerkleTree, error) {
+	t := &partialMerkleTree{
+		numTransactions: merkleBlock.Transactions,
+		hashes:          merkleBlock.Hashes,
+		flags:           merkleBlock.Flags,
+	}
+	switch {
+	case t.numTransactions == 0:
+		return nil, errors.New("no transactions")
+	case t.numTransactions > maxProofTransactions:
+		return nil, fmt.Errorf("%d transactions exceed the limit",
+			t.numTransactions)
+	case len(t.hashes) > int(t.numTransactions):
+		return nil, errors.New("more hashes than transactions")
+	case len(t.flags)*8 < len(t.hashes):
+		return nil, errors.New("fewer flag bits than hashes")
+	}
+	return t, nil
+}
+
+// width returns the number of nodes at the passed height of the tree, where
+// the leaves are at height zero.
+func (t *partialMerkleTree) width(height uint) uint32 {
+	return uint32((uint64(t.numTransactions) + 1<<height - 1) >> height)
+}
+
+// extractMatches returns the merkle root the tree commits to and the hashes
+// of the matched transactions.  It fails unless the tree uses up all hashes
+// and flag bytes.
+func (t *partialMerkleTree) extractMatches() (chainhash.Hash, []*chainhash.Hash, error) {
+	var height uint
+	for t.width(height) > 1 {
+		height++
+	}
+	var matches []*chainhash.Hash
+	root, err := t.traverse(height, 0, &matches)
+	if err != nil {
+		return chainhash.Hash{}, nil, err
+	}
+	if (t.bitsUsed+7)/8 != len(t.flags) {
+		return chainhash.Hash{}, nil, errors.New("unused flag bytes")
+	}
+	if t.hashesUsed != len(t.hashes) {
+		return chainhash.Hash{}, nil, errors.New("unused hashes")
+	}
+	return root, matches, nil
+}
+
+// traverse returns the hash of the node at the passed height and position,
+// adding the matched transactions below it to matches.
+func (t *partialMerkleTree) traverse(height uint, pos uint32, matches *[]*chainhash.Hash) (chainhash.Hash, error) {
+	if t.bitsUsed >= len(t.flags)*8 {
+		return chainhash.Hash{}, errors.New("ran out of flag bits")
+	}
+	parentOfMatch := t.flags[t.bitsUsed/8]&(1<<uint(t.bitsUsed%8)) != 0
+	t.bitsUsed++
+
+	// The hash of leaves and of nodes without matches below them is part
+	// of the proof.
+	if height == 0 || !parentOfMatch {
+		if t.hashesUsed >= len(t.hashes) {
+			return chainhash.Hash{}, errors.New("ran out of hashes")
+		}
+		hash := *t.hashes[t.hashesUsed]
+		t.hashesUsed++
+		if height == 0 && parentOfMatch {
+			matched := hash
+			*matches = append(*matches, &matched)
+		}
+		return hash, nil
+	}
+
+	left, err := t.traverse(height-1, pos*2, matches)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	right := left
+	if pos*2+1 < t.width(height-1) {
+		right, err = t.traverse(height-1, pos*2+1, matches)
+		if err != nil {
+			return chainhash.Hash{}, err
+		}
+		// Identical siblings would allow proving transactions which
+		// are not in the block, see CVE-2012-2459.
+		if right == left {
+			return chainhash.Hash{}, errors.New("identical sibling hashes")
+		}
+	}
+	var buf [chainhash.HashSize * 2]byte
+	copy(buf[:chainhash.HashSize], left[:])
+	copy(buf[chainhash.HashSize:], right[:])
+	return chainhash.DoubleHashH(buf[:]), nil
+}
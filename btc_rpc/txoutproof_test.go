@@ -0,0 +1,189 @@
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bloom"
+)
+
+const (
+	// regtestGenesisHash is the hash of the regtest genesis block.
+	regtestGenesisHash = "0f9188f13cb7b2c71f2a335e3a4fc328bf5beb436012afca590b1a11466e2206"
+
+	// genesisProof is the proof regtest nodes return for the coinbase
+	// transaction of the genesis block.
+	genesisProof = "01000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+		"3ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a" +
+		"dae5494d" + "ffff7f20" + "02000000" +
+		"01000000" +
+		"01" + "3ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a" +
+		"01" + "01"
+
+	genesisTxID = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+)
+
+// blockProof returns a proof for the transactions at the passed indexes of a
+// block of numTxs transactions, built the way nodes build them, along with
+// the merkle root of the block and the hashes of the proven transactions.
+func blockProof(t *testing.T, numTxs int, indexes ...int) ([]byte, chainhash.Hash, []chainhash.Hash) {
+	t.Helper()
+
+	var block wire.MsgBlock
+	for i := 0; i < numTxs; i++ {
+		tx := wire.NewMsgTx(1)
+		prevOut := wire.NewOutPoint(&chainhash.Hash{1}, uint32(i))
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(int64(i), []byte{txscript.OP_TRUE}))
+		block.AddTransaction(tx)
+	}
+	utilBlock := btcutil.NewBlock(&block)
+	merkles := blockchain.BuildMerkleTreeStore(utilBlock.Transactions(), false)
+	block.Header.MerkleRoot = *merkles[len(merkles)-1]
+
+	filter := bloom.NewFilter(uint32(len(indexes)), 0, 0.000001,
+		wire.BloomUpdateNone)
+	var proven []chainhash.Hash
+	for _, index := range indexes {
+		txHash := block.Transactions[index].TxHash()
+		filter.AddHash(&txHash)
+		proven = append(proven, txHash)
+	}
+	merkleBlock, _ := bloom.NewMerkleBlock(btcutil.NewBlock(&block), filter)
+
+	var buf bytes.Buffer
+	err := merkleBlock.BtcEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	return buf.Bytes(), block.Header.MerkleRoot, proven
+}
+
+func TestVerifyTxOutProofLocal(t *testing.T) {
+	proof, _ := hex.DecodeString(genesisProof)
+	genesisRoot, _ := chainhash.NewHashFromStr(genesisTxID)
+	txids, err := VerifyTxOutProofLocal(proof, *genesisRoot)
+	if err != nil {
+		t.Fatalf("VerifyTxOutProofLocal: %v", err)
+	}
+	if len(txids) != 1 || txids[0].String() != genesisTxID {
+		t.Fatalf("unexpected transactions %v", txids)
+	}
+
+	tests := []struct {
+		numTxs  int
+		indexes []int
+	}{
+		{2, []int{1}},
+		{7, []int{2, 6}},
+		{7, []int{0, 1, 2, 3, 4, 5, 6}},
+		{100, []int{37}},
+	}
+	for _, test := range tests {
+		proof, root, proven := blockProof(t, test.numTxs, test.indexes...)
+		txids, err := VerifyTxOutProofLocal(proof, root)
+		if err != nil {
+			t.Fatalf("%d transactions: VerifyTxOutProofLocal: %v",
+				test.numTxs, err)
+		}
+		if len(txids) != len(proven) {
+			t.Fatalf("%d transactions: got %v, want %v", test.numTxs,
+				txids, proven)
+		}
+		for i := range proven {
+			if *txids[i] != proven[i] {
+				t.Fatalf("%d transactions: got %v, want %v",
+					test.numTxs, txids, proven)
+			}
+		}
+	}
+
+	// The header of a merkle block is followed by the number of
+	// transactions, the number of hashes and the first hash.
+	proof, root, _ := blockProof(t, 7, 2, 6)
+	const firstHash = wire.MaxBlockHeaderPayload + 4 + 1
+	tampered := append([]byte(nil), proof...)
+	tampered[firstHash] ^= 1
+	otherRoot := root
+	otherRoot[0] ^= 1
+	badProofs := []struct {
+		name  string
+		proof []byte
+		root  chainhash.Hash
+	}{
+		{"tampered hash", tampered, root},
+		{"other merkle root", proof, otherRoot},
+		{"truncated", proof[:len(proof)-1], root},
+		{"trailing data", append(append([]byte(nil), proof...), 0), root},
+	}
+	for _, test := range badProofs {
+		_, err := VerifyTxOutProofLocal(test.proof, test.root)
+		if !errors.Is(err, ErrInvalidTxOutProof) {
+			t.Errorf("%s: got error %v, want ErrInvalidTxOutProof",
+				test.name, err)
+		}
+	}
+}
+
+func TestTxOutProof(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "gettxoutproof":
+			return genesisProof, nil
+		case "verifytxoutproof":
+			return []string{genesisTxID}, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	txid, _ := chainhash.NewHashFromStr(genesisTxID)
+	blockHash, _ := chainhash.NewHashFromStr(regtestGenesisHash)
+	proof, err := client.GetTxOutProof(ctx, []*chainhash.Hash{txid}, blockHash)
+	if err != nil {
+		t.Fatalf("GetTxOutProof: %v", err)
+	}
+	if hex.EncodeToString(proof) != genesisProof {
+		t.Fatalf("unexpected proof %x", proof)
+	}
+	txids, err := client.VerifyTxOutProof(ctx, proof)
+	if err != nil {
+		t.Fatalf("VerifyTxOutProof: %v", err)
+	}
+	if len(txids) != 1 || *txids[0] != *txid {
+		t.Fatalf("unexpected transactions %v", txids)
+	}
+	if _, err := client.GetTxOutProof(ctx, []*chainhash.Hash{txid}, nil); err != nil {
+		t.Fatalf("GetTxOutProof: %v", err)
+	}
+
+	want := []string{
+		`gettxoutproof[["` + genesisTxID + `"],"` + regtestGenesisHash + `"]`,
+		`verifytxoutproof["` + genesisProof + `"]`,
+		`gettxoutproof[["` + genesisTxID + `"]]`,
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	checkRequests(t, params, want)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// witnessScaleFactor is the factor the size of the data outside of witnesses
+// counts with in the weight of a transaction, as specified by BIP 141.
+const witnessScaleFactor = 4
+
+// TxWeight returns the weight of the passed transaction as defined by BIP 141,
+// which is the size of the transaction without witness data times three plus
+// its full size, including the segwit marker and flag.
+func TxWeight(tx *wire.MsgTx) int64 {
+	baseSize := int64(tx.SerializeSizeStripped())
+	totalSize := int64(tx.SerializeSize())
+	return baseSize*(witnessScaleFactor-1) + totalSize
+}
+
+// TxVSize returns the virtual size of the passed transaction, which is its
+// weight divided by four and rounded up.  Fee rates are expressed in satoshis
+// per virtual byte.
+func TxVSize(tx *wire.MsgTx) int64 {
+	return (TxWeight(tx) + witnessScaleFactor - 1) / witnessScaleFactor
+}
+
+// WTxID returns the witness transaction id of the passed transaction as
+// defined by BIP 141, which commits to the witness data as well.  It matches
+// the transaction id for transactions without witness data.
+func WTxID(tx *wire.MsgTx) chainhash.Hash {
+	return tx.WitnessHash()
+}
+
+// DecodedTx is a deserialized transaction along with the identifiers and sizes
+// the decoderawtransaction command reports for it.
+type DecodedTx struct {
+	MsgTx *wire.MsgTx
+
+	// TxID and WTxID are the transaction id and the witness transaction
+	// id.
+	TxID  chainhash.Hash
+	WTxID chainhash.Hash
+
+	// Size is the serialized size including witness data, VSize the
+	// virtual size and Weight the weight of the transaction.
+	Size   int64
+	VSize  int64
+	Weight int64
+}
+
+// DecodeTx deserializes the passed transaction and computes the fields
+// decoderawtransaction reports for it without asking a server.
+func DecodeTx(serializedTx []byte) (*DecodedTx, error) {
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, err
+	}
+	return &DecodedTx{
+		MsgTx:  &msgTx,
+		TxID:   msgTx.TxHash(),
+		WTxID:  WTxID(&msgTx),
+		Size:   int64(msgTx.SerializeSize()),
+		VSize:  TxVSize(&msgTx),
+		Weight: TxWeight(&msgTx),
+	}, nil
+}
+
+// Decode deserializes the extracted transaction of a complete PSBT along with
+// its identifiers and sizes.  It fails when the result carries no transaction.
+func (r *FinalizePsbtResult) Decode() (*DecodedTx, error) {
+	if r.Hex == "" {
+		return nil, errors.New("finalized PSBT carries no transaction")
+	}
+	serializedTx, err := hex.DecodeString(r.Hex)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTx(serializedTx)
+}
@@ -0,0 +1,158 @@
+package btc_rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// The transactions below are real serialized transactions along with their
+// published identifiers and sizes:
+//   - legacyTx is the first bitcoin transfer, mined in mainnet block 170.
+//   - p2wpkhTx is the testnet P2WPKH spend btcd's wire package tests its
+//     txid, wtxid and sizes against.
+//   - bip143Tx is the signed native P2WPKH example of BIP 143, which spends
+//     a P2PK output along with the P2WPKH one.
+//   - taprootTx is a key path spend with SIGHASH_SINGLE from Bitcoin Core's
+//     taproot script assets, as shipped in btcd's txscript test data.
+const (
+	legacyTx = "0100000001c997a5e56e104102fa209c6a852dd90660a20b2d9c35" +
+		"2423edce25857fcd3704000000004847304402204e45e16932b8af514961a1d3a1a2" +
+		"5fdf3f4f7732e9d624c6c61548ab5fb8cd410220181522ec8eca07de4860a4acdd12" +
+		"909d831cc56cbbac4622082221a8768d1d0901ffffffff0200ca9a3b000000004341" +
+		"04ae1a62fe09c5f51b13905f07f06b99a2f7159b2225f374cd378d71302fa28414e7" +
+		"aab37397f554a7df5f142c21c1b7303b8a0626f1baded5c72a704f7e6cd84cac0028" +
+		"6bee0000000043410411db93e1dcdb8a016b49840f8c53bc1eb68a382e97b1482eca" +
+		"d7b148a6909a5cb2e0eaddfb84ccf9744464f82e160bfa9b8b64f9d4c03f999b8643" +
+		"f656b412a3ac00000000"
+
+	p2wpkhTx = "01000000000101a53352d5135766f03076597418263da2d9c958315968" +
+		"fea823529467481ff9cd1300000000ffffffff010b070600000000001600149ddac6" +
+		"f39d51e0398e532a22c41ba189406a852302463043021f4d2381dc97f182abd8185f" +
+		"51753018523212f5ddc07cc4e63a8dc03658da190220608b5c4d92b86b6de7d78ef2" +
+		"3a2fa735bcb59b914a48b0e187c5e7569a18197001210307ead084807eb76346df69" +
+		"77000c89392f45c76425b26181f521d7f370066a8f00000000"
+
+	bip143Tx = "01000000000102fff7f7881a8099afa6940d42d1e7f6362bec38171ea3" +
+		"edf433541db4e4ad969f00000000494830450221008b9d1dc26ba6a9cb62127b0274" +
+		"2fa9d754cd3bebf337f7a55d114c8e5cdd30be022040529b194ba3f9281a99f2b1c0" +
+		"a19c0489bc22ede944ccf4ecbab4cc618ef3ed01eeffffffef51e1b804cc89d182d2" +
+		"79655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202c" +
+		"b206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093" +
+		"510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac0002" +
+		"47304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f0" +
+		"1cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de6" +
+		"7eebee0121025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07" +
+		"aeee635711000000"
+
+	taprootTx = "0100000000010160f8b8616e71e7ed05613145ce7cda782ac9861e64f9" +
+		"ce24e333ca1e91d91270410000000011ab04a302b6940f000000000017a914472b5d" +
+		"2e0c04ba5495728dd81d0885af2587df47875802000000000000160014deb4696df9" +
+		"5e4685eae8f9ff2e77fc7edabbe2fc0141671024994d289f8082e67b82ad6c507ae0" +
+		"4d157119b587baff5ad260381797efb62a929e26d45f8965e5bd110b3aef395b702f" +
+		"d402ff517d175be30d7cf3f09a0340030000"
+)
+
+func TestTxSizes(t *testing.T) {
+	tests := []struct {
+		name   string
+		hex    string
+		txid   string
+		wtxid  string
+		size   int64
+		vsize  int64
+		weight int64
+	}{
+		{
+			name:   "legacy",
+			hex:    legacyTx,
+			txid:   "f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16",
+			wtxid:  "f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16",
+			size:   275,
+			vsize:  275,
+			weight: 1100,
+		},
+		{
+			name:   "p2wpkh",
+			hex:    p2wpkhTx,
+			txid:   "0f167d1385a84d1518cfee208b653fc9163b605ccf1b75347e2850b3e2eb19f3",
+			wtxid:  "0858eab78e77b6b033da30f46699996396cf48fcf625a783c85a51403e175e74",
+			size:   190,
+			vsize:  109,
+			weight: 436,
+		},
+		{
+			// A weight of 1042 is 260.5 virtual bytes, rounded up.
+			name:   "p2pk and p2wpkh",
+			hex:    bip143Tx,
+			txid:   "e8151a2af31c368a35053ddd4bdb285a8595c769a3ad83e0fa02314a602d4609",
+			wtxid:  "c36c38370907df2324d9ce9d149d191192f338b37665a82e78e76a12c909b762",
+			size:   343,
+			vsize:  261,
+			weight: 1042,
+		},
+		{
+			// A weight of 525 is 131.25 virtual bytes, rounded up.
+			name:   "p2tr key path",
+			hex:    taprootTx,
+			txid:   "8216185773fcc46281fb147578fa5bbb2e29a32fcc9250677831f689ffa78eea",
+			wtxid:  "b3b047d99321b3fb7fdb23f6f5fb1e298304b7b7ed6de8fff63e6d48e391f499",
+			size:   183,
+			vsize:  132,
+			weight: 525,
+		},
+	}
+	for _, test := range tests {
+		serializedTx, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatalf("%s: DecodeString: %v", test.name, err)
+		}
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+			t.Fatalf("%s: Deserialize: %v", test.name, err)
+		}
+
+		if weight := TxWeight(&tx); weight != test.weight {
+			t.Errorf("%s: got weight %d, want %d", test.name, weight,
+				test.weight)
+		}
+		if vsize := TxVSize(&tx); vsize != test.vsize {
+			t.Errorf("%s: got vsize %d, want %d", test.name, vsize, test.vsize)
+		}
+		if wtxid := WTxID(&tx); wtxid.String() != test.wtxid {
+			t.Errorf("%s: got wtxid %v, want %v", test.name, wtxid,
+				test.wtxid)
+		}
+
+		decoded, err := DecodeTx(serializedTx)
+		if err != nil {
+			t.Fatalf("%s: DecodeTx: %v", test.name, err)
+		}
+		if decoded.TxID.String() != test.txid ||
+			decoded.WTxID.String() != test.wtxid ||
+			decoded.Size != test.size || decoded.VSize != test.vsize ||
+			decoded.Weight != test.weight {
+
+			t.Errorf("%s: unexpected decoded transaction %+v", test.name,
+				decoded)
+		}
+	}
+}
+
+func TestFinalizePsbtResultDecode(t *testing.T) {
+	result := FinalizePsbtResult{Hex: genesisCoinbaseTx, Complete: true}
+	decoded, err := result.Decode()
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if decoded.TxID.String() != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" ||
+		decoded.WTxID != decoded.TxID || decoded.VSize != 204 {
+
+		t.Fatalf("unexpected decoded transaction %+v", decoded)
+	}
+	if _, err := (&FinalizePsbtResult{Psbt: "cHNidP8B"}).Decode(); err == nil {
+		t.Fatal("expected an error for a result without transaction")
+	}
+}
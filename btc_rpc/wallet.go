@@ -0,0 +1,399 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil"
+)
+
+// walletMethods are the methods served by a wallet rather than the node, which
+// a server with several wallets loaded needs to receive on the path of the
+// wallet they are meant for.
+var walletMethods = map[string]struct{}{
+	"abandontransaction":           {},
+	"abortrescan":                  {},
+	"addmultisigaddress":           {},
+	"backupwallet":                 {},
+	"bumpfee":                      {},
+	"createencryptedwallet":        {},
+	"dumpprivkey":                  {},
+	"dumpwallet":                   {},
+	"encryptwallet":                {},
+	"exportwatchingwallet":         {},
+	"fundrawtransaction":           {},
+	"getaddressesbylabel":          {},
+	"getaddressinfo":               {},
+	"getbalance":                   {},
+	"getbalances":                  {},
+	"getnewaddress":                {},
+	"getrawchangeaddress":          {},
+	"getreceivedbyaddress":         {},
+	"getreceivedbylabel":           {},
+	"gettransaction":               {},
+	"getunconfirmedbalance":        {},
+	"getwalletinfo":                {},
+	"importaddress":                {},
+	"importdescriptors":            {},
+	"importmulti":                  {},
+	"importprivkey":                {},
+	"importprunedfunds":            {},
+	"importpubkey":                 {},
+	"importwallet":                 {},
+	"keypoolrefill":                {},
+	"listaddressgroupings":         {},
+	"listaddresstransactions":      {},
+	"listdescriptors":              {},
+	"listlabels":                   {},
+	"listlockunspent":              {},
+	"listreceivedbyaddress":        {},
+	"listreceivedbylabel":          {},
+	"listsinceblock":               {},
+	"listtransactions":             {},
+	"listunspent":                  {},
+	"lockunspent":                  {},
+	"psbtbumpfee":                  {},
+	"removeprunedfunds":            {},
+	"rescanblockchain":             {},
+	"send":                         {},
+	"sendall":                      {},
+	"sendmany":                     {},
+	"sendtoaddress":                {},
+	"sethdseed":                    {},
+	"setlabel":                     {},
+	"settxfee":                     {},
+	"setwalletflag":                {},
+	"signmessage":                  {},
+	"signrawtransaction":           {},
+	"signrawtransactionwithwallet": {},
+	"upgradewallet":                {},
+	"walletcreatefundedpsbt":       {},
+	"walletlock":                   {},
+	"walletpassphrase":             {},
+	"walletpassphrasechange":       {},
+	"walletprocesspsbt":            {},
+}
+
+// isWalletMethod returns whether the passed method is served by a wallet.
+func isWalletMethod(method string) bool {
+	_, ok := walletMethods[method]
+	return ok
+}
+
+// ForWallet returns a client sending the wallet RPCs issued through it, such
+// as getbalance or listunspent, to the named wallet of a server with several
+// wallets loaded.  All other RPCs are sent like those of the client it was
+// returned by.  The wallet path only applies in HTTP POST mode.
+//
+// The returned client shares the connection, configuration, statistics and
+// lifetime of the client it was returned by, so it is cheap to create one per
+// call.  Shutting down either of them shuts down both.
+func (c *Client) ForWallet(name string) *Client {
+	return &Client{clientState: c.clientState, wallet: name}
+}
+
+// Wallet returns the name of the wallet the client was scoped to by ForWallet,
+// or an empty string for clients returned by New.
+func (c *Client) Wallet() string {
+	return c.wallet
+}
+
+// CreateWalletOptions holds the optional settings of a wallet created by
+// CreateWallet.  Nil fields leave the setting to the server.
+type CreateWalletOptions struct {
+	// DisablePrivateKeys creates a watch-only wallet.
+	DisablePrivateKeys bool
+
+	// Blank creates a wallet without keys or an HD seed.
+	Blank bool
+
+	// Passphrase encrypts the wallet with the passphrase.
+	Passphrase string
+
+	// AvoidReuse keeps the wallet from spending outputs of addresses
+	// which were already spent from.
+	AvoidReuse bool
+
+	// Descriptors creates a descriptor wallet rather than a legacy one.
+	// Bitcoin Core 23 and later create descriptor wallets by default.
+	Descriptors *bool
+
+	// LoadOnStartup adds the wallet to the wallets the server loads on
+	// startup, or removes it.
+	LoadOnStartup *bool
+}
+
+// LoadWalletResult models the data returned from the createwallet and
+// loadwallet commands.
+type LoadWalletResult struct {
+	Name string `json:"name"`
+
+	// Warning is set by servers before Bitcoin Core 25, which replaced it
+	// with Warnings.
+	Warning  string   `json:"warning,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// FutureLoadWalletResult is a future promise to deliver the result of a
+// CreateWalletAsync or LoadWalletAsync RPC invocation (or an applicable error).
+type FutureLoadWalletResult chan *response
+
+// Receive waits for the response promised by the future and returns the name
+// of the wallet and any warnings about it.
+func (r FutureLoadWalletResult) Receive() (*LoadWalletResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a loadwallet result object.
+	var loadResult LoadWalletResult
+	err = json.Unmarshal(res, &loadResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loadResult, nil
+}
+
+// CreateWalletAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See CreateWallet for the blocking version and more details.
+func (c *Client) CreateWalletAsync(ctx context.Context, name string, opts *CreateWalletOptions) FutureLoadWalletResult {
+	params := []interface{}{name}
+	if opts != nil {
+		// Absent settings are sent as null, which the server treats
+		// like omitted parameters.
+		params = append(params, opts.DisablePrivateKeys, opts.Blank,
+			opts.Passphrase, opts.AvoidReuse, opts.Descriptors,
+			opts.LoadOnStartup)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureLoadWalletResult(c.RawRequestAsync(ctx, "createwallet",
+		rawParams))
+}
+
+// CreateWallet creates and loads a new wallet with the passed name.  Nil
+// options leave all settings to the server.
+//
+// Use ForWallet to issue wallet RPCs to the new wallet.
+func (c *Client) CreateWallet(ctx context.Context, name string, opts *CreateWalletOptions) (*LoadWalletResult, error) {
+	return c.CreateWalletAsync(ctx, name, opts).Receive()
+}
+
+// LoadWalletAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See LoadWallet for the blocking version and more details.
+func (c *Client) LoadWalletAsync(ctx context.Context, name string, loadOnStartup *bool) FutureLoadWalletResult {
+	params := []interface{}{name}
+	if loadOnStartup != nil {
+		params = append(params, *loadOnStartup)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureLoadWalletResult(c.RawRequestAsync(ctx, "loadwallet",
+		rawParams))
+}
+
+// LoadWallet loads the wallet with the passed name from the wallet directory
+// of the server.  A non-nil loadOnStartup adds the wallet to, or removes it
+// from, the wallets the server loads on startup.
+//
+// Use ForWallet to issue wallet RPCs to the loaded wallet.
+func (c *Client) LoadWallet(ctx context.Context, name string, loadOnStartup *bool) (*LoadWalletResult, error) {
+	return c.LoadWalletAsync(ctx, name, loadOnStartup).Receive()
+}
+
+// UnloadWalletAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See UnloadWallet for the blocking version and more details.
+func (c *Client) UnloadWalletAsync(ctx context.Context, name string, loadOnStartup *bool) FutureLoadWalletResult {
+	params := []interface{}{name}
+	if loadOnStartup != nil {
+		params = append(params, *loadOnStartup)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureLoadWalletResult(c.RawRequestAsync(ctx, "unloadwallet",
+		rawParams))
+}
+
+// UnloadWallet unloads the wallet with the passed name.  A non-nil
+// loadOnStartup adds the wallet to, or removes it from, the wallets the server
+// loads on startup.  The name of the returned result is empty.
+func (c *Client) UnloadWallet(ctx context.Context, name string, loadOnStartup *bool) (*LoadWalletResult, error) {
+	return c.UnloadWalletAsync(ctx, name, loadOnStartup).Receive()
+}
+
+// FutureListWalletsResult is a future promise to deliver the result of a
+// ListWalletsAsync RPC invocation (or an applicable error).
+type FutureListWalletsResult chan *response
+
+// Receive waits for the response promised by the future and returns the names
+// of the loaded wallets.
+func (r FutureListWalletsResult) Receive() ([]string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of strings.
+	var wallets []string
+	err = json.Unmarshal(res, &wallets)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// ListWalletsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ListWallets for the blocking version and more details.
+func (c *Client) ListWalletsAsync(ctx context.Context) FutureListWalletsResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureListWalletsResult(c.RawRequestAsync(ctx, "listwallets", nil))
+}
+
+// ListWallets returns the names of the wallets loaded by the server.  The
+// default wallet has an empty name.
+func (c *Client) ListWallets(ctx context.Context) ([]string, error) {
+	return c.ListWalletsAsync(ctx).Receive()
+}
+
+// ListSinceBlockTransaction is a wallet transaction as returned by the
+// listsinceblock command.
+type ListSinceBlockTransaction struct {
+	btcjson.ListTransactionsResult
+
+	// Label is the label of the address of the transaction, which
+	// replaced the deprecated account.
+	Label string `json:"label"`
+
+	// BlockHeight is the height of the block the transaction is in,
+	// which is only reported by Bitcoin Core 0.21 and later.
+	BlockHeight int64 `json:"blockheight"`
+}
+
+// AmountValue returns the amount of the transaction as a btcutil.Amount.  It
+// is negative for sent transactions.
+func (t *ListSinceBlockTransaction) AmountValue() btcutil.Amount {
+	// The amount was decoded from JSON, which has no NaN and infinities,
+	// so there is no error.
+	amount, _ := btcutil.NewAmount(t.Amount)
+	return amount
+}
+
+// FeeValue returns the fee of the transaction as a btcutil.Amount, which is
+// only reported for sent transactions and negative.  It is zero otherwise.
+func (t *ListSinceBlockTransaction) FeeValue() btcutil.Amount {
+	if t.Fee == nil {
+		return 0
+	}
+	fee, _ := btcutil.NewAmount(*t.Fee)
+	return fee
+}
+
+// ListSinceBlockResult models the data returned from the listsinceblock
+// command.
+type ListSinceBlockResult struct {
+	Transactions []ListSinceBlockTransaction `json:"transactions"`
+
+	// Removed are the transactions which were in blocks since the passed
+	// block but were removed from the best chain by a reorganization.
+	// Nodes before Bitcoin Core 0.17 do not report them.
+	Removed []ListSinceBlockTransaction `json:"removed"`
+
+	// LastBlock is the hash of the block to pass to the next call to only
+	// list the transactions since this one.
+	LastBlock string `json:"lastblock"`
+}
+
+// FutureListSinceBlockResult is a future promise to deliver the result of a
+// ListSinceBlockAsync RPC invocation (or an applicable error).
+type FutureListSinceBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns the wallet
+// transactions since the block along with the reorganized ones.
+func (r FutureListSinceBlockResult) Receive() (*ListSinceBlockResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a listsinceblock result object.
+	var listResult ListSinceBlockResult
+	err = json.Unmarshal(res, &listResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listResult, nil
+}
+
+// ListSinceBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListSinceBlock for the blocking version and more details.
+func (c *Client) ListSinceBlockAsync(ctx context.Context, blockHash *chainhash.Hash, targetConfirmations int, includeWatchOnly bool) FutureListSinceBlockResult {
+	var hash *string
+	if blockHash != nil {
+		hash = btcjson.String(blockHash.String())
+	}
+	rawParams, err := marshalParams([]interface{}{hash, targetConfirmations,
+		includeWatchOnly})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// btcjson drops the trailing parameters when the block hash is nil, so
+	// the command is sent as a raw request with a null block hash instead.
+	return FutureListSinceBlockResult(c.RawRequestAsync(ctx,
+		"listsinceblock", rawParams))
+}
+
+// ListSinceBlock returns the wallet transactions in blocks after the passed
+// block, or all of them when the block is nil, along with the unconfirmed
+// ones.  Transactions in blocks which were reorganized out of the best chain
+// since the passed block are returned in Removed.
+//
+// LastBlock of the result is the hash of the block targetConfirmations - 1
+// blocks below the tip, so passing it to the next call lists the transactions
+// which gained fewer than targetConfirmations confirmations since this call
+// once more.
+func (c *Client) ListSinceBlock(ctx context.Context, blockHash *chainhash.Hash, targetConfirmations int, includeWatchOnly bool) (*ListSinceBlockResult, error) {
+	return c.ListSinceBlockAsync(ctx, blockHash, targetConfirmations,
+		includeWatchOnly).Receive()
+}
@@ -0,0 +1,293 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+func TestForWallet(t *testing.T) {
+	type call struct {
+		method string
+		path   string
+		params string
+	}
+
+	var mtx sync.Mutex
+	var path string
+	var calls []call
+	server := newUnstartedTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		params, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		calls = append(calls, call{req.Method, path, string(params)})
+		mtx.Unlock()
+		switch req.Method {
+		case "getblockcount":
+			return 42, nil
+		case "createwallet", "loadwallet":
+			var name string
+			json.Unmarshal(req.Params[0], &name)
+			return json.RawMessage(`{"name":"` + name + `","warning":""}`), nil
+		case "unloadwallet":
+			return json.RawMessage(`{"warning":""}`), nil
+		case "listwallets":
+			return []string{"", "hot"}, nil
+		}
+		return json.RawMessage(`{}`), nil
+	})
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		path = r.URL.Path
+		mtx.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	hot := client.ForWallet("hot")
+	if hot.Wallet() != "hot" || client.Wallet() != "" {
+		t.Fatalf("unexpected wallets %q and %q", hot.Wallet(), client.Wallet())
+	}
+
+	loadOnStartup := true
+	if _, err := client.CreateWallet(ctx, "hot", nil); err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	result, err := client.CreateWallet(ctx, "watch", &CreateWalletOptions{
+		DisablePrivateKeys: true,
+		LoadOnStartup:      &loadOnStartup,
+	})
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	if result.Name != "watch" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if _, err := client.LoadWallet(ctx, "cold", nil); err != nil {
+		t.Fatalf("LoadWallet: %v", err)
+	}
+	if _, err := hot.RawRequest(ctx, "getwalletinfo", nil); err != nil {
+		t.Fatalf("getwalletinfo: %v", err)
+	}
+	if _, err := hot.GetBlockCount(ctx); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if _, err := client.RawRequest(ctx, "getwalletinfo", nil); err != nil {
+		t.Fatalf("getwalletinfo: %v", err)
+	}
+	if _, err := hot.UnloadWallet(ctx, "cold", &loadOnStartup); err != nil {
+		t.Fatalf("UnloadWallet: %v", err)
+	}
+	wallets, err := hot.ListWallets(ctx)
+	if err != nil {
+		t.Fatalf("ListWallets: %v", err)
+	}
+	if len(wallets) != 2 || wallets[1] != "hot" {
+		t.Fatalf("unexpected wallets %q", wallets)
+	}
+
+	want := []call{
+		{"createwallet", "/", `["hot"]`},
+		{"createwallet", "/", `["watch",true,false,"",false,null,true]`},
+		{"loadwallet", "/", `["cold"]`},
+		{"getwalletinfo", "/wallet/hot", `[]`},
+		{"getblockcount", "/", `[]`},
+		{"getwalletinfo", "/", `[]`},
+		{"unloadwallet", "/", `["cold",true]`},
+		{"listwallets", "/", `[]`},
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(calls) != len(want) {
+		t.Fatalf("got %d calls, want %d: %+v", len(calls), len(want), calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: got %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestForWalletConfiguredWallet(t *testing.T) {
+	var mtx sync.Mutex
+	var paths []string
+	server := newUnstartedTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`{}`), nil
+	})
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		paths = append(paths, r.URL.Path)
+		mtx.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.Wallet = "default"
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx := context.Background()
+
+	// The scoped wallet takes precedence over the configured one for wallet
+	// RPCs only, all other requests keep going to the configured path.
+	if _, err := client.RawRequest(ctx, "getbalances", nil); err != nil {
+		t.Fatalf("getbalances: %v", err)
+	}
+	if _, err := client.ForWallet("hot").RawRequest(ctx, "getbalances", nil); err != nil {
+		t.Fatalf("getbalances: %v", err)
+	}
+	if _, err := client.ForWallet("hot").RawRequest(ctx, "getnetworkinfo", nil); err != nil {
+		t.Fatalf("getnetworkinfo: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{"/wallet/default", "/wallet/hot", "/wallet/default"}
+	if len(paths) != len(want) {
+		t.Fatalf("got paths %q, want %q", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("got paths %q, want %q", paths, want)
+		}
+	}
+}
+
+func TestListSinceBlock(t *testing.T) {
+	const checkpoint = "00000000000000000001b9f6b5a13c5c8c4f9e5e0fa0a05c9e7f1c7a1d8e5c3b"
+
+	// The deposit to 3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy was confirmed in
+	// a block since reorganized out of the best chain, and the withdrawal
+	// is still unconfirmed.
+	const listResult = `{
+  "transactions": [
+    {
+      "involvesWatchonly": true,
+      "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+      "category": "receive",
+      "amount": 0.25000000,
+      "label": "deposits",
+      "vout": 1,
+      "confirmations": 3,
+      "blockhash": "0000000000000000000320283a032748cef8227873ff4872689bf23f1cda83a5",
+      "blockheight": 800002,
+      "blockindex": 512,
+      "blocktime": 1690001000,
+      "txid": "5a4ebc3ae1f3d4e0d0c1f7aa8b7de6e5e2e7f0b1b2b3b4b5b6b7b8b9babbbcbd",
+      "walletconflicts": [
+      ],
+      "time": 1690000900,
+      "timereceived": 1690000900,
+      "bip125-replaceable": "no"
+    },
+    {
+      "address": "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+      "category": "send",
+      "amount": -0.10000000,
+      "vout": 0,
+      "fee": -0.00001410,
+      "confirmations": 0,
+      "trusted": true,
+      "txid": "6b5fcd4bf2f4e5f1e1d2f8bb9c8ef7f6f3f8f1c2c3c4c5c6c7c8c9cacbcccdce",
+      "walletconflicts": [
+      ],
+      "time": 1690001100,
+      "timereceived": 1690001100,
+      "bip125-replaceable": "yes",
+      "abandoned": false
+    }
+  ],
+  "removed": [
+    {
+      "involvesWatchonly": true,
+      "address": "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+      "category": "receive",
+      "amount": 1.50000000,
+      "label": "deposits",
+      "vout": 0,
+      "confirmations": -1,
+      "blockhash": "00000000000000000004cc3ee7b0a3d4bbafd0e88e4e0d1c8a1f3bb3e4c5d6e7",
+      "blockheight": 800001,
+      "blockindex": 17,
+      "blocktime": 1690000600,
+      "txid": "7c60de5c03f5f6f2f2e3f9ccad9ff8f7f4f9f2d3d4d5d6d7d8d9dadbdcdddedf",
+      "walletconflicts": [
+      ],
+      "time": 1690000500,
+      "timereceived": 1690000500,
+      "bip125-replaceable": "no"
+    }
+  ],
+  "lastblock": "0000000000000000000320283a032748cef8227873ff4872689bf23f1cda83a5"
+}`
+
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, string(p))
+		mtx.Unlock()
+		return json.RawMessage(listResult), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	blockHash, _ := chainhash.NewHashFromStr(checkpoint)
+	result, err := client.ListSinceBlock(ctx, blockHash, 6, true)
+	if err != nil {
+		t.Fatalf("ListSinceBlock: %v", err)
+	}
+	if _, err := client.ListSinceBlock(ctx, nil, 1, false); err != nil {
+		t.Fatalf("ListSinceBlock: %v", err)
+	}
+
+	if len(result.Transactions) != 2 || len(result.Removed) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	deposit := result.Transactions[0]
+	if deposit.AmountValue() != 25000000 || deposit.FeeValue() != 0 ||
+		deposit.Label != "deposits" || deposit.BlockHeight != 800002 ||
+		!deposit.InvolvesWatchOnly {
+
+		t.Fatalf("unexpected deposit %+v", deposit)
+	}
+	withdrawal := result.Transactions[1]
+	if withdrawal.AmountValue() != -10000000 || withdrawal.FeeValue() != -1410 ||
+		withdrawal.Category != "send" {
+
+		t.Fatalf("unexpected withdrawal %+v", withdrawal)
+	}
+	removed := result.Removed[0]
+	if removed.AmountValue() != 150000000 || removed.Confirmations != -1 ||
+		removed.TxID != "7c60de5c03f5f6f2f2e3f9ccad9ff8f7f4f9f2d3d4d5d6d7d8d9dadbdcdddedf" {
+
+		t.Fatalf("unexpected removed transaction %+v", removed)
+	}
+	if result.LastBlock != "0000000000000000000320283a032748cef8227873ff4872689bf23f1cda83a5" {
+		t.Fatalf("unexpected last block %s", result.LastBlock)
+	}
+
+	want := []string{`["` + checkpoint + `",6,true]`, `[null,1,false]`}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(params) != len(want) || params[0] != want[0] || params[1] != want[1] {
+		t.Fatalf("sent params %q, want %q", params, want)
+	}
+}
@@ -0,0 +1,390 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// zmqDialTimeout is the time allowed for connecting to a ZMQ endpoint
+	// and performing the handshake.
+	zmqDialTimeout = 10 * time.Second
+
+	// zmqMinReconnectDelay and zmqMaxReconnectDelay bound the time
+	// between attempts to reconnect to a ZMQ endpoint, which doubles with
+	// every failed attempt.
+	zmqMinReconnectDelay = 500 * time.Millisecond
+	zmqMaxReconnectDelay = 30 * time.Second
+)
+
+// The ZMQ topics published by nodes.
+const (
+	ZMQTopicRawBlock  = "rawblock"
+	ZMQTopicRawTx     = "rawtx"
+	ZMQTopicHashBlock = "hashblock"
+	ZMQTopicHashTx    = "hashtx"
+)
+
+// ZMQEndpoints are the addresses of the ZMQ publisher sockets of a node, such
+// as "tcp://127.0.0.1:28332", one for each topic.  Topics without an address
+// are not subscribed to, and topics sharing an address share a connection.
+type ZMQEndpoints struct {
+	RawBlock  string
+	RawTx     string
+	HashBlock string
+	HashTx    string
+}
+
+// ZMQHandlers are the callbacks a ZMQListener delivers notifications to.  All
+// of them are optional, but only topics with a handler are subscribed to.
+//
+// Handlers of topics sharing an address are called in the order the node
+// published the notifications, while handlers of topics on different
+// addresses may be called concurrently.  They must not block for long, since
+// the node drops notifications for slow subscribers.
+type ZMQHandlers struct {
+	// OnRawBlock is called with every block connected to the best chain.
+	OnRawBlock func(block *wire.MsgBlock)
+
+	// OnRawTx is called with every transaction accepted to the memory
+	// pool or included in a connected block.
+	OnRawTx func(tx *wire.MsgTx)
+
+	// OnHashBlock and OnHashTx are called with the hashes of the same
+	// blocks and transactions.
+	OnHashBlock func(hash *chainhash.Hash)
+	OnHashTx    func(hash *chainhash.Hash)
+
+	// OnSequenceGap is called when notifications of a topic were missed,
+	// as told by the sequence number the node attaches to every
+	// notification.  Notifications are missed when the node drops them
+	// for slow subscribers, while reconnecting, or when the node restarts
+	// and begins counting anew.  Callers should then catch up by polling
+	// the node.
+	OnSequenceGap func(topic string, expected, received uint32)
+
+	// OnError is called with errors connecting to an endpoint or decoding
+	// a notification.  The listener reconnects on its own after
+	// connection errors.
+	OnError func(err error)
+}
+
+// ZMQListener receives the notifications a node publishes over ZMQ, which
+// push new blocks and transactions without polling, and passes them to the
+// handlers it was created with.
+type ZMQListener struct {
+	handlers ZMQHandlers
+
+	// quit is closed by Close to stop the listener goroutines.
+	quit      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	// mtx protects conns, the connections of the listener goroutines.
+	mtx   sync.Mutex
+	conns map[*zmtpConn]struct{}
+}
+
+// NewZMQListener connects to the passed ZMQ endpoints of a node and returns a
+// listener passing their notifications to the passed handlers until it is
+// closed.  Connecting happens in the background, failures are reported to
+// OnError and retried.
+//
+// See GetZmqNotifications to discover the endpoints of a node.
+func NewZMQListener(endpoints ZMQEndpoints, handlers ZMQHandlers) (*ZMQListener, error) {
+	// Group the topics by address so topics sharing an address share a
+	// connection.
+	addresses := make(map[string][]string)
+	for _, endpoint := range []struct {
+		topic   string
+		address string
+		handled bool
+	}{
+		{ZMQTopicRawBlock, endpoints.RawBlock, handlers.OnRawBlock != nil},
+		{ZMQTopicRawTx, endpoints.RawTx, handlers.OnRawTx != nil},
+		{ZMQTopicHashBlock, endpoints.HashBlock, handlers.OnHashBlock != nil},
+		{ZMQTopicHashTx, endpoints.HashTx, handlers.OnHashTx != nil},
+	} {
+		if endpoint.address == "" || !endpoint.handled {
+			continue
+		}
+		if !strings.HasPrefix(endpoint.address, "tcp://") {
+			return nil, fmt.Errorf("unsupported ZMQ endpoint %q, only "+
+				"tcp endpoints are supported", endpoint.address)
+		}
+		addresses[endpoint.address] = append(addresses[endpoint.address],
+			endpoint.topic)
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("no ZMQ endpoint with a handler")
+	}
+
+	l := &ZMQListener{
+		handlers: handlers,
+		quit:     make(chan struct{}),
+		conns:    make(map[*zmtpConn]struct{}),
+	}
+	for address, topics := range addresses {
+		l.wg.Add(1)
+		go l.listen(address, topics)
+	}
+	return l, nil
+}
+
+// Close disconnects the listener from all endpoints and waits for the handlers
+// which are running to return.  No handlers are called once it returned.
+func (l *ZMQListener) Close() {
+	l.closeOnce.Do(func() {
+		close(l.quit)
+		l.mtx.Lock()
+		for conn := range l.conns {
+			conn.Close()
+		}
+		l.mtx.Unlock()
+	})
+	l.wg.Wait()
+}
+
+// listen keeps the listener connected to the passed address and receives the
+// notifications of the passed topics.  It must be run as a goroutine.
+func (l *ZMQListener) listen(address string, topics []string) {
+	defer l.wg.Done()
+
+	// The last sequence number of every topic is kept across reconnects,
+	// so notifications missed while reconnecting are reported as well.
+	sequences := make(map[string]uint32)
+	delay := zmqMinReconnectDelay
+	for {
+		conn, err := l.connect(address, topics)
+		if err == nil {
+			delay = zmqMinReconnectDelay
+			err = l.receive(conn, sequences)
+			l.mtx.Lock()
+			delete(l.conns, conn)
+			l.mtx.Unlock()
+			conn.Close()
+		}
+
+		select {
+		case <-l.quit:
+			return
+		default:
+		}
+		l.handleError(fmt.Errorf("zmq %s: %v", address, err))
+
+		select {
+		case <-l.quit:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > zmqMaxReconnectDelay {
+			delay = zmqMaxReconnectDelay
+		}
+	}
+}
+
+// connect connects to the passed address and subscribes to the passed topics.
+func (l *ZMQListener) connect(address string, topics []string) (*zmtpConn, error) {
+	dialer := net.Dialer{Timeout: zmqDialTimeout, KeepAlive: 30 * time.Second}
+	netConn, err := dialer.Dial("tcp", strings.TrimPrefix(address, "tcp://"))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := newZMTPConn(netConn, "SUB", zmqDialTimeout)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	for _, topic := range topics {
+		if err := conn.subscribe(topic); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	// Register the connection so Close can interrupt receiving, unless
+	// the listener was closed meanwhile.
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	select {
+	case <-l.quit:
+		conn.Close()
+		return nil, errors.New("listener closed")
+	default:
+	}
+	l.conns[conn] = struct{}{}
+	return conn, nil
+}
+
+// receive passes the notifications received over the passed connection to the
+// handlers until the connection fails.
+func (l *ZMQListener) receive(conn *zmtpConn, sequences map[string]uint32) error {
+	for {
+		frames, err := conn.readMessage()
+		if err != nil {
+			return err
+		}
+
+		// Notifications consist of the topic, the body and the sequence
+		// number of the notification within its topic.
+		if len(frames) != 3 || len(frames[2]) != 4 {
+			l.handleError(fmt.Errorf("zmq: malformed notification "+
+				"with %d parts", len(frames)))
+			continue
+		}
+		topic := string(frames[0])
+		sequence := binary.LittleEndian.Uint32(frames[2])
+		if last, ok := sequences[topic]; ok && sequence != last+1 &&
+			l.handlers.OnSequenceGap != nil {
+
+			l.handlers.OnSequenceGap(topic, last+1, sequence)
+		}
+		sequences[topic] = sequence
+
+		if err := l.notify(topic, frames[1]); err != nil {
+			l.handleError(fmt.Errorf("zmq %s notification %d: %v",
+				topic, sequence, err))
+		}
+	}
+}
+
+// notify decodes the passed notification body of the passed topic and passes
+// it to its handler.
+func (l *ZMQListener) notify(topic string, body []byte) error {
+	switch topic {
+	case ZMQTopicRawBlock:
+		var block wire.MsgBlock
+		if err := block.Deserialize(bytes.NewReader(body)); err != nil {
+			return err
+		}
+		l.handlers.OnRawBlock(&block)
+
+	case ZMQTopicRawTx:
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(body)); err != nil {
+			return err
+		}
+		l.handlers.OnRawTx(&tx)
+
+	case ZMQTopicHashBlock, ZMQTopicHashTx:
+		// Hashes are published in the byte order they are displayed
+		// in, which is the reverse of the one of chainhash.Hash.
+		if len(body) != chainhash.HashSize {
+			return fmt.Errorf("hash of %d bytes", len(body))
+		}
+		var hash chainhash.Hash
+		for i, b := range body {
+			hash[chainhash.HashSize-1-i] = b
+		}
+		if topic == ZMQTopicHashBlock {
+			l.handlers.OnHashBlock(&hash)
+		} else {
+			l.handlers.OnHashTx(&hash)
+		}
+
+	default:
+		return errors.New("unexpected topic")
+	}
+	return nil
+}
+
+// handleError passes the passed error to the OnError handler, if any.
+func (l *ZMQListener) handleError(err error) {
+	if l.handlers.OnError != nil {
+		l.handlers.OnError(err)
+	}
+}
+
+// ZmqNotification describes a ZMQ publisher socket of a node, as returned by
+// the getzmqnotifications command.
+type ZmqNotification struct {
+	// Type is the type of the notifications published, such as
+	// "pubrawblock".
+	Type string `json:"type"`
+
+	// Address is the address the socket is bound to.
+	Address string `json:"address"`
+
+	// HWM is the number of notifications queued for a subscriber before
+	// further ones are dropped.
+	HWM int64 `json:"hwm"`
+}
+
+// ZMQEndpointsFromNotifications returns the endpoints of the passed publisher
+// sockets.  Sockets bound to all interfaces, such as "tcp://0.0.0.0:28332",
+// need their host replaced by the one of the node before connecting to them
+// from another host.
+func ZMQEndpointsFromNotifications(notifications []ZmqNotification) ZMQEndpoints {
+	var endpoints ZMQEndpoints
+	for _, n := range notifications {
+		switch n.Type {
+		case "pub" + ZMQTopicRawBlock:
+			endpoints.RawBlock = n.Address
+		case "pub" + ZMQTopicRawTx:
+			endpoints.RawTx = n.Address
+		case "pub" + ZMQTopicHashBlock:
+			endpoints.HashBlock = n.Address
+		case "pub" + ZMQTopicHashTx:
+			endpoints.HashTx = n.Address
+		}
+	}
+	return endpoints
+}
+
+// FutureGetZmqNotificationsResult is a future promise to deliver the result of
+// a GetZmqNotificationsAsync RPC invocation (or an applicable error).
+type FutureGetZmqNotificationsResult chan *response
+
+// Receive waits for the response promised by the future and returns the ZMQ
+// publisher sockets of the node.
+func (r FutureGetZmqNotificationsResult) Receive() ([]ZmqNotification, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getzmqnotifications result objects.
+	var notifications []ZmqNotification
+	err = json.Unmarshal(res, &notifications)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// GetZmqNotificationsAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetZmqNotifications for the blocking version and more details.
+func (c *Client) GetZmqNotificationsAsync(ctx context.Context) FutureGetZmqNotificationsResult {
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetZmqNotificationsResult(c.RawRequestAsync(ctx,
+		"getzmqnotifications", nil))
+}
+
+// GetZmqNotifications returns the ZMQ publisher sockets the node is configured
+// with, which is empty when ZMQ is disabled.
+//
+// See ZMQEndpointsFromNotifications and NewZMQListener to subscribe to them.
+func (c *Client) GetZmqNotifications(ctx context.Context) ([]ZmqNotification, error) {
+	return c.GetZmqNotificationsAsync(ctx).Receive()
+}
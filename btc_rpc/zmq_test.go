@@ -0,0 +1,242 @@
+package btc_rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// zmqPublisher is a scripted ZMQ publisher socket standing in for a node.
+type zmqPublisher struct {
+	t        *testing.T
+	listener net.Listener
+}
+
+func newZMQPublisher(t *testing.T) *zmqPublisher {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	return &zmqPublisher{t: t, listener: listener}
+}
+
+// address returns the ZMQ endpoint of the publisher.
+func (p *zmqPublisher) address() string {
+	return "tcp://" + p.listener.Addr().String()
+}
+
+// accept accepts the next subscriber and returns the connection along with
+// the topics it subscribed to.
+func (p *zmqPublisher) accept(topics int) (*zmtpConn, []string) {
+	netConn, err := p.listener.Accept()
+	if err != nil {
+		p.t.Fatalf("Accept: %v", err)
+	}
+	conn, err := newZMTPConn(netConn, "PUB", 5*time.Second)
+	if err != nil {
+		p.t.Fatalf("handshake: %v", err)
+	}
+	var subscribed []string
+	for len(subscribed) < topics {
+		frames, err := conn.readMessage()
+		if err != nil {
+			p.t.Fatalf("readMessage: %v", err)
+		}
+		if len(frames) != 1 || len(frames[0]) == 0 || frames[0][0] != 1 {
+			p.t.Fatalf("unexpected subscription %q", frames)
+		}
+		subscribed = append(subscribed, string(frames[0][1:]))
+	}
+	sort.Strings(subscribed)
+	return conn, subscribed
+}
+
+// publish publishes a notification as nodes do.
+func (p *zmqPublisher) publish(conn *zmtpConn, topic string, body []byte, sequence uint32) {
+	var seq [4]byte
+	binary.LittleEndian.PutUint32(seq[:], sequence)
+	if err := conn.writeMessage([]byte(topic), body, seq[:]); err != nil {
+		p.t.Fatalf("writeMessage: %v", err)
+	}
+}
+
+func TestZMQListener(t *testing.T) {
+	const (
+		genesisTxID  = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+		genesisBlock = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	)
+	rawTx, _ := hex.DecodeString(genesisCoinbaseTx)
+	hashBody, _ := hex.DecodeString(genesisBlock)
+
+	type gap struct {
+		topic              string
+		expected, received uint32
+	}
+	txs := make(chan *wire.MsgTx, 10)
+	blockHashes := make(chan *chainhash.Hash, 10)
+	gaps := make(chan gap, 10)
+	handlers := ZMQHandlers{
+		OnRawTx:     func(tx *wire.MsgTx) { txs <- tx },
+		OnHashBlock: func(hash *chainhash.Hash) { blockHashes <- hash },
+		OnSequenceGap: func(topic string, expected, received uint32) {
+			gaps <- gap{topic, expected, received}
+		},
+	}
+
+	publisher := newZMQPublisher(t)
+	defer publisher.listener.Close()
+
+	// The raw block endpoint has no handler and is not connected to.
+	listener, err := NewZMQListener(ZMQEndpoints{
+		RawBlock:  "tcp://127.0.0.1:1",
+		RawTx:     publisher.address(),
+		HashBlock: publisher.address(),
+	}, handlers)
+	if err != nil {
+		t.Fatalf("NewZMQListener: %v", err)
+	}
+	defer listener.Close()
+
+	conn, topics := publisher.accept(2)
+	if topics[0] != "hashblock" || topics[1] != "rawtx" {
+		t.Fatalf("unexpected subscriptions %q", topics)
+	}
+	publisher.publish(conn, "rawtx", rawTx, 0)
+	publisher.publish(conn, "hashblock", hashBody, 7)
+	publisher.publish(conn, "hashblock", hashBody, 8)
+	publisher.publish(conn, "hashblock", hashBody, 10)
+
+	select {
+	case tx := <-txs:
+		if tx.TxHash().String() != genesisTxID {
+			t.Fatalf("got tx %v, want %s", tx.TxHash(), genesisTxID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transaction received")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case hash := <-blockHashes:
+			if hash.String() != genesisBlock {
+				t.Fatalf("got block hash %v, want %s", hash,
+					genesisBlock)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no block hash received")
+		}
+	}
+	select {
+	case g := <-gaps:
+		if g != (gap{"hashblock", 9, 10}) {
+			t.Fatalf("unexpected gap %+v", g)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sequence gap reported")
+	}
+
+	// Drop the connection.  The listener reconnects and reports the
+	// notifications published in between as missed.
+	conn.Close()
+	conn, _ = publisher.accept(2)
+	publisher.publish(conn, "rawtx", rawTx, 3)
+	select {
+	case g := <-gaps:
+		if g != (gap{"rawtx", 1, 3}) {
+			t.Fatalf("unexpected gap %+v", g)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no sequence gap reported after reconnecting")
+	}
+	select {
+	case <-txs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transaction received after reconnecting")
+	}
+
+	// The connection is closed by now, so publishing may fail.
+	listener.Close()
+	conn.writeMessage([]byte("rawtx"), rawTx, []byte{4, 0, 0, 0})
+	select {
+	case <-txs:
+		t.Fatal("transaction received after Close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNewZMQListenerErrors(t *testing.T) {
+	onRawTx := func(*wire.MsgTx) {}
+	tests := []struct {
+		name      string
+		endpoints ZMQEndpoints
+		handlers  ZMQHandlers
+	}{
+		{"no endpoints", ZMQEndpoints{}, ZMQHandlers{OnRawTx: onRawTx}},
+		{"no handlers", ZMQEndpoints{RawTx: "tcp://127.0.0.1:28332"},
+			ZMQHandlers{}},
+		{"ipc", ZMQEndpoints{RawTx: "ipc:///tmp/bitcoind.rawtx"},
+			ZMQHandlers{OnRawTx: onRawTx}},
+	}
+	for _, test := range tests {
+		if _, err := NewZMQListener(test.endpoints, test.handlers); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestGetZmqNotifications(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`[
+  {
+    "type": "pubhashblock",
+    "address": "tcp://127.0.0.1:28332",
+    "hwm": 1000
+  },
+  {
+    "type": "pubrawblock",
+    "address": "tcp://127.0.0.1:28332",
+    "hwm": 1000
+  },
+  {
+    "type": "pubrawtx",
+    "address": "tcp://127.0.0.1:28333",
+    "hwm": 10000
+  },
+  {
+    "type": "pubsequence",
+    "address": "tcp://127.0.0.1:28334",
+    "hwm": 1000
+  }
+]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	notifications, err := client.GetZmqNotifications(context.Background())
+	if err != nil {
+		t.Fatalf("GetZmqNotifications: %v", err)
+	}
+	if len(notifications) != 4 || notifications[2].HWM != 10000 {
+		t.Fatalf("unexpected notifications %+v", notifications)
+	}
+	endpoints := ZMQEndpointsFromNotifications(notifications)
+	want := ZMQEndpoints{
+		RawBlock:  "tcp://127.0.0.1:28332",
+		RawTx:     "tcp://127.0.0.1:28333",
+		HashBlock: "tcp://127.0.0.1:28332",
+	}
+	if endpoints != want {
+		t.Fatalf("got endpoints %+v, want %+v", endpoints, want)
+	}
+}
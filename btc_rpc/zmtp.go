@@ -0,0 +1,198 @@
+// Copyright (c) 2014-2017 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btc_rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// The implementation below speaks just enough of ZMTP 3.0, the wire protocol
+// of ZeroMQ, to subscribe to the publisher sockets of a node.  Only the NULL
+// security mechanism is supported, which is the only one nodes offer.
+
+const (
+	// zmtpGreetingSize is the size of the greeting each peer starts with.
+	zmtpGreetingSize = 64
+
+	// Flags of a frame.
+	zmtpFlagMore    = 0x01
+	zmtpFlagLong    = 0x02
+	zmtpFlagCommand = 0x04
+
+	// zmtpMaxFrameSize is the largest frame accepted, which leaves room
+	// for the largest blocks.
+	zmtpMaxFrameSize = 64 << 20
+)
+
+// zmtpConn is a ZMTP connection whose handshake is done.
+type zmtpConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// newZMTPConn performs the handshake of a ZMTP connection over the passed
+// connection, announcing the passed socket type, such as "SUB", to the peer.
+// The handshake fails when it takes longer than the passed timeout.
+func newZMTPConn(conn net.Conn, socketType string, timeout time.Duration) (*zmtpConn, error) {
+	c := &zmtpConn{conn: conn, r: bufio.NewReader(conn)}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	// Both peers send their greeting right away, which is made up of the
+	// signature, the version, the security mechanism and whether the peer
+	// acts as server, followed by padding.
+	greeting := make([]byte, zmtpGreetingSize)
+	greeting[0] = 0xff
+	greeting[9] = 0x7f
+	greeting[10] = 3
+	copy(greeting[12:32], "NULL")
+	if _, err := conn.Write(greeting); err != nil {
+		return nil, err
+	}
+	peerGreeting := make([]byte, zmtpGreetingSize)
+	if _, err := io.ReadFull(c.r, peerGreeting); err != nil {
+		return nil, err
+	}
+	if peerGreeting[0] != 0xff || peerGreeting[9] != 0x7f {
+		return nil, errors.New("zmtp: peer is not a ZeroMQ socket")
+	}
+	if peerGreeting[10] < 3 {
+		return nil, fmt.Errorf("zmtp: unsupported peer version %d.%d",
+			peerGreeting[10], peerGreeting[11])
+	}
+	mechanism := string(bytes.TrimRight(peerGreeting[12:32], "\x00"))
+	if mechanism != "NULL" {
+		return nil, fmt.Errorf("zmtp: unsupported security mechanism %q",
+			mechanism)
+	}
+
+	// With the NULL mechanism both peers send a READY command carrying
+	// their socket type next.
+	ready := []byte{5}
+	ready = append(ready, "READY"...)
+	ready = append(ready, byte(len("Socket-Type")))
+	ready = append(ready, "Socket-Type"...)
+	var valueSize [4]byte
+	binary.BigEndian.PutUint32(valueSize[:], uint32(len(socketType)))
+	ready = append(ready, valueSize[:]...)
+	ready = append(ready, socketType...)
+	if err := c.writeFrame(zmtpFlagCommand, ready); err != nil {
+		return nil, err
+	}
+	flags, body, err := c.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	if flags&zmtpFlagCommand == 0 || len(body) == 0 ||
+		int(body[0]) >= len(body) {
+
+		return nil, errors.New("zmtp: malformed handshake command")
+	}
+	if name := string(body[1 : 1+body[0]]); name != "READY" {
+		// Peers refusing the connection send an ERROR command.
+		return nil, fmt.Errorf("zmtp: peer sent %s command", name)
+	}
+
+	return c, nil
+}
+
+// writeFrame writes a single frame with the passed flags and body.
+func (c *zmtpConn) writeFrame(flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | zmtpFlagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := c.conn.Write(append(header, body...))
+	return err
+}
+
+// readFrame reads a single frame and returns its flags and body.
+func (c *zmtpConn) readFrame() (byte, []byte, error) {
+	flags, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&zmtpFlagLong != 0 {
+		var sizeBytes [8]byte
+		if _, err := io.ReadFull(c.r, sizeBytes[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(sizeBytes[:])
+	} else {
+		shortSize, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(shortSize)
+	}
+	if size > zmtpMaxFrameSize {
+		return 0, nil, fmt.Errorf("zmtp: frame of %d bytes exceeds "+
+			"limit", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+// readMessage reads the next message and returns its frames.  Commands the
+// peer sends in between, such as heartbeats, are skipped.
+func (c *zmtpConn) readMessage() ([][]byte, error) {
+	var frames [][]byte
+	for {
+		flags, body, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if flags&zmtpFlagCommand != 0 {
+			continue
+		}
+		frames = append(frames, body)
+		if flags&zmtpFlagMore == 0 {
+			return frames, nil
+		}
+	}
+}
+
+// writeMessage writes a message made up of the passed frames.
+func (c *zmtpConn) writeMessage(frames ...[]byte) error {
+	for i, frame := range frames {
+		var flags byte
+		if i < len(frames)-1 {
+			flags = zmtpFlagMore
+		}
+		if err := c.writeFrame(flags, frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribe subscribes to the messages whose first frame starts with the
+// passed topic.  ZMTP 3.0 subscriptions are messages starting with 1, which
+// later versions of the protocol still accept.
+func (c *zmtpConn) subscribe(topic string) error {
+	return c.writeMessage(append([]byte{1}, topic...))
+}
+
+// Close closes the connection.
+func (c *zmtpConn) Close() error {
+	return c.conn.Close()
+}
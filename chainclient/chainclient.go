@@ -0,0 +1,71 @@
+// Package chainclient defines the operations the RPC clients for Bitcoin and
+// the chains derived from it have in common, so code such as deposit scanning
+// can be written once for all of them.  Each client package returns an
+// implementation from its Client's ChainClient method.
+package chainclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// HashSize is the size of a block or transaction hash in bytes.
+const HashSize = 32
+
+// Hash is a block or transaction hash.  It is stored in the internal byte
+// order of the chain, which is the reverse of the order it is displayed in, so
+// it converts directly to and from the hash types of the client packages.
+type Hash [HashSize]byte
+
+// String returns the hash as the byte-reversed hexadecimal string it is
+// displayed as.
+func (h Hash) String() string {
+	for i := 0; i < HashSize/2; i++ {
+		h[i], h[HashSize-1-i] = h[HashSize-1-i], h[i]
+	}
+	return hex.EncodeToString(h[:])
+}
+
+// NewHashFromStr returns the hash displayed as the passed byte-reversed
+// hexadecimal string.
+func NewHashFromStr(s string) (*Hash, error) {
+	if len(s) != HashSize*2 {
+		return nil, fmt.Errorf("hash string has length %d, want %d",
+			len(s), HashSize*2)
+	}
+	var h Hash
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return nil, err
+	}
+	for i := 0; i < HashSize/2; i++ {
+		h[i], h[HashSize-1-i] = h[HashSize-1-i], h[i]
+	}
+	return &h, nil
+}
+
+// ChainClient is implemented by the RPC clients of every supported chain.
+// Transactions are passed as their serialized bytes, which every chain
+// understands without having to agree on a transaction type.
+type ChainClient interface {
+	// GetBlockCount returns the number of blocks in the longest block
+	// chain.
+	GetBlockCount(ctx context.Context) (int64, error)
+
+	// GetBlockHash returns the hash of the block in the best block chain
+	// at the given height.
+	GetBlockHash(ctx context.Context, blockHeight int64) (*Hash, error)
+
+	// GetBestBlock returns the hash and height of the block in the longest
+	// (best) chain.
+	GetBestBlock(ctx context.Context) (*Hash, int32, error)
+
+	// GetRawTransaction returns the serialized transaction with the given
+	// hash.
+	GetRawTransaction(ctx context.Context, txHash *Hash) ([]byte, error)
+
+	// SendRawTransaction submits the serialized transaction to the server
+	// which will then relay it to the network.  It returns the hash of the
+	// transaction.
+	SendRawTransaction(ctx context.Context, tx []byte) (*Hash, error)
+}
@@ -0,0 +1,31 @@
+package chainclient
+
+import (
+	"testing"
+)
+
+func TestHashString(t *testing.T) {
+	const str = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	hash, err := NewHashFromStr(str)
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+	if hash[0] != 0x6f || hash[HashSize-1] != 0 {
+		t.Fatalf("hash %x is not in internal byte order", hash[:])
+	}
+	if got := hash.String(); got != str {
+		t.Fatalf("String returned %s, want %s", got, str)
+	}
+}
+
+func TestNewHashFromStrInvalid(t *testing.T) {
+	for _, str := range []string{
+		"",
+		"6fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000ff",
+		"zz0000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f",
+	} {
+		if _, err := NewHashFromStr(str); err == nil {
+			t.Errorf("NewHashFromStr(%q) succeeded", str)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sectoken-dev/tools/bch_rpc"
+	"github.com/sectoken-dev/tools/btc_rpc"
+	"github.com/sectoken-dev/tools/chainclient"
+	"github.com/sectoken-dev/tools/dash_rpc"
+	"github.com/sectoken-dev/tools/ltc_rpc"
+)
+
+const (
+	// bestHeight is the height of the best block of the mock server.
+	bestHeight = 100
+
+	// txHex is the coinbase transaction of block 1, which the mock server
+	// serves and accepts.
+	txHex = "0100000001000000000000000000000000000000000000000000000000" +
+		"0000000000000000ffffffff0704ffff001d0104ffffffff0100f2052a0100" +
+		"000043410496b538e853519c726a2c91e61ec11600ae1390813a627c66fb8b" +
+		"e7947be63c52da7589379515d4e0a604f8141781e62294721166bf621e73a8" +
+		"2cbf2342c858eeac00000000"
+
+	// txID is the hash of the transaction, as displayed.
+	txID = "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098"
+)
+
+// blockHash returns the displayed hash of the block at the passed height on
+// the mock server.
+func blockHash(height int64) string {
+	return fmt.Sprintf("%064x", height)
+}
+
+// newMockServer starts a JSON-RPC server answering the methods used by
+// chainclient.ChainClient.  Callers are responsible for closing it.
+func newMockServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+
+		var result, rpcErr interface{}
+		var param string
+		switch req.Method {
+		case "getblockcount":
+			result = bestHeight
+		case "getblockhash":
+			var height int64
+			json.Unmarshal(req.Params[0], &height)
+			result = blockHash(height)
+		case "getbestblockhash":
+			result = blockHash(bestHeight)
+		case "getblockheader":
+			// The mock block hashes are their heights in hex.
+			json.Unmarshal(req.Params[0], &param)
+			height, err := strconv.ParseInt(param, 16, 64)
+			if err != nil {
+				t.Errorf("getblockheader for unknown block %s", param)
+			}
+			result = map[string]interface{}{
+				"hash":   param,
+				"height": height,
+			}
+		case "getrawtransaction":
+			json.Unmarshal(req.Params[0], &param)
+			if param != txID {
+				t.Errorf("getrawtransaction for %s, want %s", param, txID)
+			}
+			result = txHex
+		case "sendrawtransaction":
+			json.Unmarshal(req.Params[0], &param)
+			if param != txHex {
+				t.Errorf("sendrawtransaction got %s, want %s", param, txHex)
+			}
+			result = txID
+		default:
+			// Like the nodes, the server does not know getbestblock,
+			// which is a btcd extension.
+			if req.Method != "getbestblock" {
+				t.Errorf("unexpected method %s", req.Method)
+			}
+			rpcErr = map[string]interface{}{
+				"code":    -32601,
+				"message": "Method not found",
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     req.ID,
+			"result": result,
+			"error":  rpcErr,
+		})
+	}))
+}
+
+// coins creates a chainclient.ChainClient for every supported chain talking
+// to the passed host, along with a function shutting the client down.
+var coins = []struct {
+	name      string
+	newClient func(host string) (chainclient.ChainClient, func(), error)
+}{
+	{
+		name: "btc",
+		newClient: func(host string) (chainclient.ChainClient, func(), error) {
+			client, err := btc_rpc.New(&btc_rpc.ConnConfig{
+				Host:         host,
+				HTTPPostMode: true,
+				DisableTLS:   true,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return client.ChainClient(), func() {
+				client.Shutdown()
+				client.WaitForShutdown()
+			}, nil
+		},
+	},
+	{
+		name: "ltc",
+		newClient: func(host string) (chainclient.ChainClient, func(), error) {
+			client, err := ltc_rpc.New(&ltc_rpc.ConnConfig{
+				Host:         host,
+				HTTPPostMode: true,
+				DisableTLS:   true,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return client.ChainClient(), func() {
+				client.Shutdown()
+				client.WaitForShutdown()
+			}, nil
+		},
+	},
+	{
+		name: "bch",
+		newClient: func(host string) (chainclient.ChainClient, func(), error) {
+			client, err := bch_rpc.New(&bch_rpc.ConnConfig{
+				Host:         host,
+				HTTPPostMode: true,
+				DisableTLS:   true,
+			}, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			return client.ChainClient(), func() {
+				client.Shutdown()
+				client.WaitForShutdown()
+			}, nil
+		},
+	},
+	{
+		name: "dash",
+		newClient: func(host string) (chainclient.ChainClient, func(), error) {
+			client, err := dash_rpc.New(&dash_rpc.ConnConfig{
+				Host:         host,
+				HTTPPostMode: true,
+				DisableTLS:   true,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			return client.ChainClient(), func() {
+				client.Shutdown()
+				client.WaitForShutdown()
+			}, nil
+		},
+	},
+}
+
+func TestChainClient(t *testing.T) {
+	server := newMockServer(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	rawTx, err := hex.DecodeString(txHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTxID, err := chainclient.NewHashFromStr(txID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, coin := range coins {
+		t.Run(coin.name, func(t *testing.T) {
+			client, stop, err := coin.newClient(host)
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer stop()
+			ctx := context.Background()
+
+			count, err := client.GetBlockCount(ctx)
+			if err != nil {
+				t.Fatalf("GetBlockCount: %v", err)
+			}
+			if count != bestHeight {
+				t.Fatalf("block count %d, want %d", count, bestHeight)
+			}
+
+			hash, err := client.GetBlockHash(ctx, 42)
+			if err != nil {
+				t.Fatalf("GetBlockHash: %v", err)
+			}
+			if hash.String() != blockHash(42) {
+				t.Fatalf("block hash %v, want %s", hash, blockHash(42))
+			}
+
+			hash, height, err := client.GetBestBlock(ctx)
+			if err != nil {
+				t.Fatalf("GetBestBlock: %v", err)
+			}
+			if hash.String() != blockHash(bestHeight) || height != bestHeight {
+				t.Fatalf("best block %v at %d, want %s at %d", hash,
+					height, blockHash(bestHeight), bestHeight)
+			}
+
+			tx, err := client.GetRawTransaction(ctx, wantTxID)
+			if err != nil {
+				t.Fatalf("GetRawTransaction: %v", err)
+			}
+			if !bytes.Equal(tx, rawTx) {
+				t.Fatalf("raw transaction %x, want %s", tx, txHex)
+			}
+
+			hash, err = client.SendRawTransaction(ctx, rawTx)
+			if err != nil {
+				t.Fatalf("SendRawTransaction: %v", err)
+			}
+			if *hash != *wantTxID {
+				t.Fatalf("sent transaction %v, want %v", hash, wantTxID)
+			}
+		})
+	}
+}
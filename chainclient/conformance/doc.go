@@ -0,0 +1,4 @@
+// Package conformance tests that the RPC clients of every supported chain
+// implement chainclient.ChainClient the same way.  It is a module of its own
+// so the chainclient package does not depend on the client packages.
+package conformance
@@ -0,0 +1,20 @@
+module github.com/sectoken-dev/tools/chainclient/conformance
+
+go 1.13
+
+require (
+	github.com/sectoken-dev/tools/bch_rpc v0.0.0
+	github.com/sectoken-dev/tools/btc_rpc v0.0.0
+	github.com/sectoken-dev/tools/chainclient v0.0.0
+	github.com/sectoken-dev/tools/dash_rpc v0.0.0
+	github.com/sectoken-dev/tools/ltc_rpc v0.0.0
+)
+
+replace (
+	github.com/ltcsuite/ltcutil v0.0.0-20190507082654-23cdfa9fcc3d => github.com/ltcsuite/ltcutil v0.0.0-20190507133322-23cdfa9fcc3d
+	github.com/sectoken-dev/tools/bch_rpc => ../../bch_rpc
+	github.com/sectoken-dev/tools/btc_rpc => ../../btc_rpc
+	github.com/sectoken-dev/tools/chainclient => ../
+	github.com/sectoken-dev/tools/dash_rpc => ../../dash_rpc
+	github.com/sectoken-dev/tools/ltc_rpc => ../../ltc_rpc
+)
@@ -0,0 +1,34 @@
+package chainclient_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/sectoken-dev/tools/chainclient"
+)
+
+// This example shows a loop scanning every new block of any supported chain.
+// The client is obtained from the ChainClient method of a client package, for
+// example btc_rpc or dash_rpc.
+func Example() {
+	var client chainclient.ChainClient
+	scan := func(height int64, hash *chainclient.Hash) {}
+
+	ctx := context.Background()
+	var next int64
+	for {
+		count, err := client.GetBlockCount(ctx)
+		if err != nil {
+			time.Sleep(time.Minute)
+			continue
+		}
+		for ; next <= count; next++ {
+			hash, err := client.GetBlockHash(ctx, next)
+			if err != nil {
+				break
+			}
+			scan(next, hash)
+		}
+		time.Sleep(time.Minute)
+	}
+}
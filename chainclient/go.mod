@@ -0,0 +1,3 @@
+module github.com/sectoken-dev/tools/chainclient
+
+go 1.13
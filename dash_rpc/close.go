@@ -0,0 +1,52 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// closePollInterval is how often Close checks whether the pending requests
+// have been answered.
+const closePollInterval = 5 * time.Millisecond
+
+// isClosing returns whether Close was called, after which no new requests are
+// accepted.
+func (c *Client) isClosing() bool {
+	return atomic.LoadUint32(&c.closing) != 0
+}
+
+// Close gracefully shuts the client down.  Requests issued after Close was
+// called fail with ErrClientShutdown, while requests which were already
+// queued or in flight are answered by the server as usual.  Once they all are,
+// or the passed context is done, the client is shut down as by Shutdown, which
+// fails any requests still pending with ErrClientShutdown.
+//
+// Close returns the context's error when the context was done before the
+// pending requests were answered, and nil otherwise.  WaitForShutdown may be
+// used afterwards to wait for the client goroutines to exit.
+func (c *Client) Close(ctx context.Context) error {
+	atomic.StoreUint32(&c.closing, 1)
+
+	ticker := time.NewTicker(closePollInterval)
+	defer ticker.Stop()
+	var err error
+out:
+	for c.PendingRequests() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break out
+		case <-c.shutdown:
+			break out
+		}
+	}
+
+	c.Shutdown()
+	return err
+}
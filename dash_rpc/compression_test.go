@@ -0,0 +1,108 @@
+package dash_rpc
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newGzipServer starts a test server which answers every request with the
+// passed result, compressing the reply when the client accepts gzip.  The
+// returned function reports the Accept-Encoding headers received so far.
+func newGzipServer(t *testing.T, result interface{}) (*httptest.Server, func() []string) {
+	var mtx sync.Mutex
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req testRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		accept := r.Header.Get("Accept-Encoding")
+		mtx.Lock()
+		encodings = append(encodings, accept)
+		mtx.Unlock()
+
+		reply := map[string]interface{}{"id": req.ID, "result": result, "error": nil}
+		if !strings.Contains(accept, "gzip") {
+			json.NewEncoder(w).Encode(reply)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		json.NewEncoder(gz).Encode(reply)
+		gz.Close()
+	}))
+	return server, func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string(nil), encodings...)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	// A large, highly compressible result like a verbose block.
+	hex := strings.Repeat("0123456789abcdef", 1<<20)
+	server, encodings := newGzipServer(t, hex)
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.EnableCompression = true
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	result, err := client.RawRequest(context.Background(), "getblock", nil)
+	if err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	var got string
+	if err := json.Unmarshal(result, &got); err != nil || got != hex {
+		t.Fatalf("result of %d bytes did not round-trip: %v", len(result), err)
+	}
+	if got := encodings(); len(got) != 1 || got[0] != "gzip" {
+		t.Fatalf("unexpected Accept-Encoding %q", got)
+	}
+}
+
+func TestCompressionDisabled(t *testing.T) {
+	server, encodings := newGzipServer(t, 100)
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if got := encodings(); len(got) != 1 || got[0] != "" {
+		t.Fatalf("unexpected Accept-Encoding %q", got)
+	}
+}
+
+func TestCompressionMaxResponseBytes(t *testing.T) {
+	// The compressed reply is far below the limit, but the decompressed
+	// one is not.
+	server, _ := newGzipServer(t, strings.Repeat("0", 1<<20))
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.EnableCompression = true
+	config.MaxResponseBytes = 64 << 10
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	_, err = client.RawRequest(context.Background(), "getblock", nil)
+	if _, ok := err.(*ErrResponseTooLarge); !ok {
+		t.Fatalf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
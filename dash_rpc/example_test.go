@@ -25,7 +25,8 @@ func TestNew(t *testing.T) {
 
 	}
 	ctx := context.Background()
-	ctx, _ = context.WithTimeout(ctx, time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
 	// _, _ = client.GetBlockCount(ctx)
 	bh, _ := wire.NewShaHashFromStr("0000029dcece0728de9f0362e57f0ebda98e59612e86c72c26ffff0af6d6ddd0")
 	ret, err  := client.GetBlockVerbose(ctx, bh)
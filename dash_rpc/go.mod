@@ -3,6 +3,8 @@ module github.com/sectoken-dev/tools/dash_rpc
 go 1.13
 
 require (
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
 	github.com/sectoken-dev/godash v0.0.0-20200423075754-947ef6478e28
 	github.com/sectoken-dev/godashutil v0.0.0-20200423074243-b5e2b2b40bb7
+	github.com/sectoken-dev/tools/chainclient v0.1.0
 )
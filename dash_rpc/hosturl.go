@@ -0,0 +1,73 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// hostURL returns the URL of the RPC server at the passed host, which is
+// either a bare "host:port" or a full URL with a scheme and optionally a path.
+// Bare hosts use https unless TLS is disabled.  Full URLs must use the scheme
+// matching the DisableTLS setting, so a configuration never silently talks
+// plain HTTP when TLS was expected, or the other way around.
+func hostURL(host string, config *ConnConfig) (*url.URL, error) {
+	scheme := "https"
+	if config.DisableTLS {
+		scheme = "http"
+	}
+	if !strings.Contains(host, "://") {
+		return &url.URL{Scheme: scheme, Host: host}, nil
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host %q: %w", host, err)
+	}
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, fmt.Errorf("invalid host %q: unsupported scheme %q",
+			host, u.Scheme)
+	case u.Scheme != scheme:
+		return nil, fmt.Errorf("invalid host %q: scheme %s conflicts "+
+			"with DisableTLS=%v", host, u.Scheme, config.DisableTLS)
+	case u.Host == "":
+		return nil, fmt.Errorf("invalid host %q: missing host", host)
+	case u.RawQuery != "" || u.Fragment != "":
+		return nil, fmt.Errorf("invalid host %q: query and fragment "+
+			"are not supported", host)
+	}
+	return u, nil
+}
+
+// postURL returns the URL HTTP POST mode requests to the passed host are sent
+// to, which is the host URL followed by the path of the configured wallet.
+func postURL(host string, config *ConnConfig) (string, error) {
+	u, err := hostURL(host, config)
+	if err != nil {
+		return "", err
+	}
+	if config.Wallet != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/wallet/" + config.Wallet
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+// checkHosts returns an error when any of the configured hosts is invalid.
+func checkHosts(config *ConnConfig) error {
+	hosts := config.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{config.Host}
+	}
+	for _, host := range hosts {
+		if _, err := hostURL(host, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
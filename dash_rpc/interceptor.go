@@ -0,0 +1,121 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Request is a JSON-RPC request as seen by an Interceptor.
+type Request struct {
+	// ID is the id of the request.
+	ID uint64
+
+	// Method is the method of the request.  It is only used for logging
+	// and statistics, so an interceptor changing the method sent to the
+	// server must rewrite JSON as well.
+	Method string
+
+	// JSON is the marshalled request sent to the server.
+	JSON []byte
+
+	// Header holds extra headers to send along with the request, and is
+	// empty unless an interceptor adds any.  It is only used in HTTP POST
+	// mode, where it is applied after the headers of the connection
+	// configuration.
+	Header http.Header
+}
+
+// Invoker sends a request on to the next interceptor, or to the server for the
+// last one, and returns the raw result.
+type Invoker func(ctx context.Context, req *Request) ([]byte, error)
+
+// Interceptor wraps every request issued by a client.  It is called with the
+// request and the Invoker passing it on, and returns the raw result delivered
+// to the caller.  An interceptor may change the request before invoking next,
+// inspect or replace the result, or answer the request itself without
+// invoking next at all.
+//
+// Interceptors run in the order they are configured in, the first one being
+// the outermost, for every request regardless of the transport.  Every
+// intercepted request is issued from a goroutine of its own, so requests
+// configured in OrderedMethods are only sent in order relative to each other
+// once they leave the interceptors.
+type Interceptor func(ctx context.Context, req *Request, next Invoker) ([]byte, error)
+
+// RenameMethods returns an interceptor which renames the methods of requests
+// according to the passed map, which maps the method a request was issued with
+// to the method sent to the server instead.  It allows talking to nodes which
+// only know an older, or newer, name of a method.
+func RenameMethods(names map[string]string) Interceptor {
+	return func(ctx context.Context, req *Request, next Invoker) ([]byte, error) {
+		method, ok := names[req.Method]
+		if !ok {
+			return next(ctx, req)
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(req.JSON, &fields); err != nil {
+			return nil, err
+		}
+		marshalledMethod, err := json.Marshal(method)
+		if err != nil {
+			return nil, err
+		}
+		fields["method"] = marshalledMethod
+		marshalledJSON, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		renamed := *req
+		renamed.Method = method
+		renamed.JSON = marshalledJSON
+		return next(ctx, &renamed)
+	}
+}
+
+// intercept passes the request through the configured interceptors in a
+// goroutine of its own and delivers their result to the caller.  The request
+// they pass on is issued like any other, so it is counted, limited and timed
+// out as usual.
+func (c *Client) intercept(ctx context.Context, jReq *jsonRequest) {
+	invoke := func(ctx context.Context, req *Request) ([]byte, error) {
+		inner := &jsonRequest{
+			id:             req.ID,
+			method:         req.Method,
+			cmd:            jReq.cmd,
+			marshalledJSON: req.JSON,
+			header:         req.Header,
+			responseChan:   make(chan *response, 1),
+			intercepted:    true,
+		}
+		c.sendRequest(ctx, inner)
+		resp := <-inner.responseChan
+		return resp.result, resp.err
+	}
+
+	// Wrap the invocation from the last interceptor to the first, so the
+	// first one configured runs first.
+	interceptors := c.config.Interceptors
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoke
+		invoke = func(ctx context.Context, req *Request) ([]byte, error) {
+			return interceptor(ctx, req, next)
+		}
+	}
+
+	go func() {
+		result, err := invoke(ctx, &Request{
+			ID:     jReq.id,
+			Method: jReq.method,
+			JSON:   jReq.marshalledJSON,
+			Header: make(http.Header),
+		})
+		jReq.respond(&response{result: result, err: err})
+	}()
+}
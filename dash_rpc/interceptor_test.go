@@ -0,0 +1,203 @@
+package dash_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sectoken-dev/godash/btcjson"
+)
+
+func TestInterceptorsOrder(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+
+	var mtx sync.Mutex
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, req *Request, next Invoker) ([]byte, error) {
+			mtx.Lock()
+			calls = append(calls, name+" "+req.Method)
+			mtx.Unlock()
+			result, err := next(ctx, req)
+			mtx.Lock()
+			calls = append(calls, name+" "+string(result))
+			mtx.Unlock()
+			return result, err
+		}
+	}
+
+	config := testConnConfig(server)
+	config.Interceptors = []Interceptor{record("first"), record("second")}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	count, err := client.GetBlockCount(context.Background())
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("unexpected block count %d", count)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		"first getblockcount", "second getblockcount",
+		"second 100", "first 100",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("interceptor calls %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("interceptor calls %q, want %q", calls, want)
+		}
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		t.Errorf("request %s reached the server", req.Method)
+		return nil, nil
+	})
+	defer server.Close()
+
+	errCanned := errors.New("canned error")
+	config := testConnConfig(server)
+	config.Interceptors = []Interceptor{
+		func(ctx context.Context, req *Request, next Invoker) ([]byte, error) {
+			if req.Method == "getblockcount" {
+				return []byte("42"), nil
+			}
+			return nil, errCanned
+		},
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	count, err := client.GetBlockCount(context.Background())
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if count != 42 {
+		t.Fatalf("unexpected block count %d", count)
+	}
+	if _, err := client.GetBestBlockHash(context.Background()); err != errCanned {
+		t.Fatalf("expected the canned error, got %v", err)
+	}
+
+	// Requests answered by an interceptor never reach the client.
+	if stats := client.Stats(); len(stats.Methods) != 0 {
+		t.Fatalf("unexpected statistics %v", stats.Methods)
+	}
+}
+
+func TestInterceptorHeader(t *testing.T) {
+	var mtx sync.Mutex
+	var tenants []string
+	server := newUnstartedTestServer(t, blockCountHandler)
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		mtx.Unlock()
+		handler.ServeHTTP(w, r)
+	})
+	server.Start()
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.Interceptors = []Interceptor{
+		func(ctx context.Context, req *Request, next Invoker) ([]byte, error) {
+			req.Header.Set("X-Tenant", "acme")
+			return next(ctx, req)
+		},
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(tenants) != 1 || tenants[0] != "acme" {
+		t.Fatalf("server saw tenants %q", tenants)
+	}
+}
+
+func TestRenameMethods(t *testing.T) {
+	var mtx sync.Mutex
+	var methods []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		methods = append(methods, req.Method)
+		mtx.Unlock()
+		if req.Method == "getblockcount" {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound.Code,
+				"Method not found")
+		}
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.Interceptors = []Interceptor{
+		RenameMethods(map[string]string{"getblockcount": "getheight"}),
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	count, err := client.GetBlockCount(context.Background())
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if count != 100 {
+		t.Fatalf("unexpected block count %d", count)
+	}
+
+	// Methods not in the map are passed on unchanged, with their params.
+	params := []json.RawMessage{json.RawMessage(`"abc"`)}
+	if _, err := client.RawRequest(context.Background(), "getblock", params); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(methods) != 2 || methods[0] != "getheight" ||
+		methods[1] != "getblock" {
+
+		t.Fatalf("server saw methods %q", methods)
+	}
+	if _, ok := client.Stats().Methods["getheight"]; !ok {
+		t.Fatal("renamed method missing from the statistics")
+	}
+}
+
+func TestRenameMethodsInvalidJSON(t *testing.T) {
+	rename := RenameMethods(map[string]string{"getblockcount": "getheight"})
+	req := &Request{Method: "getblockcount", JSON: []byte("{")}
+	_, err := rename(context.Background(), req, func(ctx context.Context, req *Request) ([]byte, error) {
+		t.Fatal("request with invalid JSON passed on")
+		return nil, nil
+	})
+	if err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
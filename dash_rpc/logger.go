@@ -0,0 +1,33 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+// Logger is the interface used by the client to report conditions which are
+// not returned to any caller, such as malformed or unexpected messages from
+// the server.  It is satisfied by btclog.Logger and by thin adapters around
+// most structured logging packages.
+//
+// Implementations must be safe for concurrent use.
+type Logger interface {
+	Debugf(format string, params ...interface{})
+	Warnf(format string, params ...interface{})
+	Errorf(format string, params ...interface{})
+}
+
+// nopLogger is a Logger which discards everything.  It is used when the
+// connection configuration does not provide a Logger.
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, params ...interface{}) {}
+func (nopLogger) Warnf(format string, params ...interface{})  {}
+func (nopLogger) Errorf(format string, params ...interface{}) {}
+
+// logJSON logs the raw JSON of a request or response at debug level when the
+// connection configuration has DebugJSON set.
+func (c *Client) logJSON(kind string, id uint64, msg []byte) {
+	if c.config.DebugJSON {
+		c.log.Debugf("%s JSON for id %d: %s", kind, id, msg)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// satoshiDecimals is the number of decimals of an amount in coins.
+const satoshiDecimals = 8
+
+// decodeJSON unmarshals the passed JSON into v like json.Unmarshal, except that
+// numbers decoded into an interface{} become a json.Number rather than a
+// float64, so they are never rounded.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// parseID returns the id of a response decoded by decodeJSON.  Ids may be
+// either integers or strings holding one, and must fit in a uint64.
+func parseID(id interface{}) (uint64, bool) {
+	var s string
+	switch id := id.(type) {
+	case json.Number:
+		s = id.String()
+	case string:
+		s = id
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	return n, err == nil
+}
+
+// UnmarshalResult unmarshals the raw result of a request, such as the one
+// returned by RawRequest, into v like json.Unmarshal, except that numbers
+// decoded into an interface{} become a json.Number rather than a float64.  It
+// is meant for verbose results whose amounts must be read exactly with
+// ParseAmountSat.
+func UnmarshalResult(result []byte, v interface{}) error {
+	return decodeJSON(result, v)
+}
+
+// ParseAmountSat returns the number of satoshis of the passed amount in coins,
+// as found in verbose results, such as "0.12345678".  Unlike converting the
+// amount from a float64 it is exact, so it also holds for amounts beyond the
+// precision of a float64.  Amounts with more than 8 decimals, in exponent
+// notation or out of the range of an int64 are rejected.
+func ParseAmountSat(amount json.Number) (int64, error) {
+	s := amount.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	if whole == "" || len(fraction) > satoshiDecimals ||
+		!isDigits(whole) || !isDigits(fraction) {
+
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	fraction += strings.Repeat("0", satoshiDecimals-len(fraction))
+
+	sat, err := strconv.ParseInt(sign+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	return sat, nil
+}
+
+// isDigits returns whether the passed string only consists of decimal digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
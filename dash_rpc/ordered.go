@@ -0,0 +1,29 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+// newOrderedMethods returns the set of the passed methods, or nil when there
+// are none.
+func newOrderedMethods(methods []string) map[string]struct{} {
+	if len(methods) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(methods))
+	for _, method := range methods {
+		set[method] = struct{}{}
+	}
+	return set
+}
+
+// postChan returns the channel HTTP POST mode requests for the passed method
+// are queued on.  Methods configured as ordered share a queue served by a
+// single dedicated worker, so they are sent one at a time in the order they
+// were issued regardless of the number of HTTP POST workers.
+func (c *Client) postChan(method string) chan *sendPostDetails {
+	if _, ok := c.orderedMethods[method]; ok {
+		return c.orderedPostChan
+	}
+	return c.sendPostChan
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrClientOverloaded describes the condition where a request was issued while
+// the configured maximum number of requests was already pending on a client
+// configured to fail fast.
+type ErrClientOverloaded struct {
+	// Limit is the maximum number of pending requests which was reached.
+	Limit int
+}
+
+// Error satisfies the error interface.
+func (e *ErrClientOverloaded) Error() string {
+	return fmt.Sprintf("client overloaded: %d requests pending", e.Limit)
+}
+
+// newPendingSlots returns the channel limiting the number of pending requests
+// to the passed maximum, or nil when the number is not limited.
+func newPendingSlots(max int) chan struct{} {
+	if max <= 0 {
+		return nil
+	}
+	return make(chan struct{}, max)
+}
+
+// acquirePending counts the passed request as pending until it is answered.
+// When the configured maximum number of requests is already pending, it either
+// fails right away with ErrClientOverloaded or waits until another request is
+// answered, the context is done or the client shuts down, depending on the
+// FailFast setting.
+func (c *Client) acquirePending(ctx context.Context, jReq *jsonRequest) error {
+	if c.pendingSlots != nil {
+		select {
+		case c.pendingSlots <- struct{}{}:
+		default:
+			if c.config.FailFast {
+				return &ErrClientOverloaded{Limit: cap(c.pendingSlots)}
+			}
+			select {
+			case c.pendingSlots <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.shutdown:
+				return ErrClientShutdown
+			}
+		}
+	}
+
+	atomic.AddInt64(&c.pending, 1)
+	jReq.release = c.releasePending
+	return nil
+}
+
+// releasePending stops counting a request acquired by acquirePending as
+// pending.
+func (c *Client) releasePending() {
+	atomic.AddInt64(&c.pending, -1)
+	if c.pendingSlots != nil {
+		<-c.pendingSlots
+	}
+}
+
+// PendingRequests returns the number of requests which were issued but not
+// answered yet, including those waiting for the rate limit.  It is meant for
+// monitoring how close the client is to MaxPendingRequests.
+func (c *Client) PendingRequests() int {
+	return int(atomic.LoadInt64(&c.pending))
+}
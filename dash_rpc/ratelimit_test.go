@@ -0,0 +1,111 @@
+package dash_rpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sectoken-dev/godash/btcjson"
+)
+
+func TestRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes about ten seconds")
+	}
+
+	var mtx sync.Mutex
+	var requests int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests++
+		mtx.Unlock()
+		return 100, nil
+	})
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.RateLimit = &RateLimit{RequestsPerSecond: 10, Burst: 10}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// The burst is sent right away, every later request waits for its
+	// token.
+	const calls = 100
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetBlockCount(context.Background()); err != nil {
+				t.Errorf("GetBlockCount: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	if want := 9 * time.Second; elapsed < want {
+		t.Fatalf("%d requests took %v, want at least %v", calls, elapsed, want)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if requests != calls {
+		t.Fatalf("server saw %d requests, want %d", requests, calls)
+	}
+}
+
+func TestRateLimitContext(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.RateLimit = &RateLimit{RequestsPerSecond: 1}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+
+	// The next token is a second away, so a shorter deadline fails the
+	// request without waiting.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetBlockCount(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("call returned after %v", elapsed)
+	}
+
+	// A cancelled wait gives its token back.
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := client.GetBlockCount(ctx); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if delay := client.limiter.reserve(1); delay > time.Second {
+		t.Fatalf("cancelled requests kept their tokens, delay %v", delay)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	if newRateLimiter(nil) != nil {
+		t.Fatal("nil RateLimit created a limiter")
+	}
+	if newRateLimiter(&RateLimit{Burst: 5}) != nil {
+		t.Fatal("zero rate created a limiter")
+	}
+}
@@ -0,0 +1,55 @@
+package dash_rpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sectoken-dev/godash/btcjson"
+	"github.com/sectoken-dev/godash/wire"
+)
+
+// genesisCoinbaseTx is the serialized coinbase transaction of the Bitcoin
+// genesis block.
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000" +
+	"000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65" +
+	"732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b" +
+	"206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff01" +
+	"00f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e039" +
+	"09a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d" +
+	"578a4c702b6bf11d5fac00000000"
+
+func BenchmarkGetRawTransactionWorkers(b *testing.B) {
+	server := newTestServer(b, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(5 * time.Millisecond)
+		return genesisCoinbaseTx, nil
+	})
+	defer server.Close()
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			config := testConnConfig(server)
+			config.HTTPPostWorkers = workers
+			client, err := New(config)
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			// Keep more calls outstanding than there are workers.
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := client.GetRawTransaction(context.Background(),
+						&wire.ShaHash{})
+					if err != nil {
+						b.Errorf("GetRawTransaction: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package dash_rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sectoken-dev/godash/btcjson"
+)
+
+// newFlakyServer starts a test server which fails the first failures
+// requests using the passed fail function and answers every later request
+// with a block count of 100.  The returned function reports how many requests
+// the server received.
+func newFlakyServer(failures int, fail func(w http.ResponseWriter)) (*httptest.Server, func() int) {
+	var mtx sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		requests++
+		n := requests
+		mtx.Unlock()
+
+		if n <= failures {
+			fail(w)
+			return
+		}
+		w.Write([]byte(`{"id":1,"result":100,"error":null}`))
+	}))
+	return server, func() int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return requests
+	}
+}
+
+// unavailable replies with a 503 error page.
+func unavailable(w http.ResponseWriter) {
+	http.Error(w, "reindexing", http.StatusServiceUnavailable)
+}
+
+// dropConnection closes the connection without replying.
+func dropConnection(w http.ResponseWriter) {
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+// newRetryClient returns a client for the passed server which retries
+// requests up to maxAttempts times.
+func newRetryClient(t *testing.T, server *httptest.Server, maxAttempts int) *Client {
+	t.Helper()
+
+	config := testConnConfig(server)
+	config.RetryPolicy = &RetryPolicy{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: time.Millisecond,
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(w http.ResponseWriter)
+	}{
+		{"unavailable", unavailable},
+		{"dropped connection", dropConnection},
+	}
+	for _, test := range tests {
+		server, requests := newFlakyServer(2, test.fail)
+
+		logger := &testLogger{}
+		instr := NewExpvarInstrumentation("")
+		config := testConnConfig(server)
+		config.Logger = logger
+		config.Instrumentation = instr
+		config.RetryPolicy = &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		}
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("%s: New: %v", test.name, err)
+		}
+
+		count, err := client.GetBlockCount(context.Background())
+		if err != nil {
+			t.Errorf("%s: GetBlockCount: %v", test.name, err)
+		} else if count != 100 {
+			t.Errorf("%s: unexpected count %d", test.name, count)
+		}
+		if got := requests(); got != 3 {
+			t.Errorf("%s: server saw %d requests, want 3", test.name, got)
+		}
+		if !logger.contains("DBG Retrying command [getblockcount] with id 1") {
+			t.Errorf("%s: retry was not logged", test.name)
+		}
+		vars := instr.method("getblockcount")
+		if got := vars.Get("retries").String(); got != "2" {
+			t.Errorf("%s: retries %s, want 2", test.name, got)
+		}
+		if got := vars.Get("requests").String(); got != "1" {
+			t.Errorf("%s: requests %s, want 1", test.name, got)
+		}
+
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestRetryAttemptsExhausted(t *testing.T) {
+	server, requests := newFlakyServer(5, unavailable)
+	defer server.Close()
+
+	client := newRetryClient(t, server, 3)
+	defer stopClient(client)
+
+	_, err := client.GetBlockCount(context.Background())
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 HTTPError, got %v", err)
+	}
+	if got := requests(); got != 3 {
+		t.Fatalf("server saw %d requests, want 3", got)
+	}
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	server, requests := newFlakyServer(1, unavailable)
+	defer server.Close()
+
+	client := newRetryClient(t, server, 3)
+	defer stopClient(client)
+
+	// Methods which change state are not retried by default.
+	ctx := context.Background()
+	if _, err := client.RawRequest(ctx, "sendrawtransaction", nil); err == nil {
+		t.Fatal("expected sendrawtransaction to fail")
+	}
+	if got := requests(); got != 1 {
+		t.Fatalf("server saw %d requests, want 1", got)
+	}
+
+	// Opting in retries them against a server which fails once.
+	server2, requests := newFlakyServer(1, unavailable)
+	defer server2.Close()
+	client2 := newRetryClient(t, server2, 3)
+	defer stopClient(client2)
+	if _, err := client2.RawRequest(WithRetry(ctx), "sendrawtransaction", nil); err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+	if got := requests(); got != 2 {
+		t.Fatalf("server saw %d requests, want 2", got)
+	}
+}
+
+func TestRetryRPCError(t *testing.T) {
+	var mtx sync.Mutex
+	var requests int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests++
+		mtx.Unlock()
+		return nil, &btcjson.RPCError{Code: -5, Message: "Block not found"}
+	})
+	defer server.Close()
+
+	client := newRetryClient(t, server, 3)
+	defer stopClient(client)
+
+	_, err := client.RawRequest(context.Background(), "getblock", nil)
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected RPC error, got %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if requests != 1 {
+		t.Fatalf("server saw %d requests, want 1", requests)
+	}
+}
+
+func TestRetryContextDeadline(t *testing.T) {
+	server, requests := newFlakyServer(5, unavailable)
+	defer server.Close()
+
+	config := testConnConfig(server)
+	config.RetryPolicy = &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// The backoff is longer than the deadline allows, so the request
+	// fails right away instead of retrying.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.GetBlockCount(ctx); err == nil {
+		t.Fatal("expected GetBlockCount to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("call returned after %v", elapsed)
+	}
+	if got := requests(); got != 1 {
+		t.Fatalf("server saw %d requests, want 1", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+	}
+	want := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	}
+	for i, backoff := range want {
+		if got := policy.backoff(i + 1); got != backoff {
+			t.Errorf("attempt %d: backoff %v, want %v", i+1, got, backoff)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&HTTPError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&HTTPError{StatusCode: http.StatusBadGateway}, true},
+		{&HTTPError{StatusCode: http.StatusNotFound}, false},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, false},
+		{&btcjson.RPCError{Code: -28, Message: "Loading block index..."}, false},
+		{ErrInvalidAuth, false},
+		{&ErrResponseTooLarge{Method: "getblock", Limit: 1}, false},
+	}
+	for _, test := range tests {
+		if got := IsTransient(test.err); got != test.want {
+			t.Errorf("%v: IsTransient %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package dash_rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSpan is a span recorded by testTracer.
+type testSpan struct {
+	name     string
+	parent   *testSpan
+	finished bool
+	err      error
+}
+
+// testSpanKey is the context key of the span carried by a context.
+type testSpanKey struct{}
+
+// testTracer is a Tracer recording every span.  It has the same shape as an
+// adapter to OpenTelemetry, which would look like this:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) StartSpan(ctx context.Context, name string) (context.Context, dash_rpc.FinishSpan) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//				span.SetStatus(codes.Error, err.Error())
+//			}
+//			span.End()
+//		}
+//	}
+type testTracer struct {
+	mtx   sync.Mutex
+	spans []*testSpan
+}
+
+// StartSpan records a new span as a child of the span in the context.
+//
+// This is part of the Tracer interface.
+func (t *testTracer) StartSpan(ctx context.Context, name string) (context.Context, FinishSpan) {
+	parent, _ := ctx.Value(testSpanKey{}).(*testSpan)
+	span := &testSpan{name: name, parent: parent}
+	t.mtx.Lock()
+	t.spans = append(t.spans, span)
+	t.mtx.Unlock()
+	return context.WithValue(ctx, testSpanKey{}, span), func(err error) {
+		t.mtx.Lock()
+		span.finished = true
+		span.err = err
+		t.mtx.Unlock()
+	}
+}
+
+// snapshot returns copies of the recorded spans.
+func (t *testTracer) snapshot() []testSpan {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	spans := make([]testSpan, 0, len(t.spans))
+	for _, span := range t.spans {
+		spans = append(spans, *span)
+	}
+	return spans
+}
+
+func TestTracer(t *testing.T) {
+	server, _ := newFlakyServer(1, unavailable)
+	defer server.Close()
+
+	tracer := new(testTracer)
+	config := testConnConfig(server)
+	config.Tracer = tracer
+	config.RetryPolicy = &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// The caller's span is the parent of the request span.
+	ctx, finish := tracer.StartSpan(context.Background(), "caller")
+	if _, err := client.GetBlockCount(ctx); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	finish(nil)
+
+	spans := tracer.snapshot()
+	want := []struct {
+		name, parent string
+		failed       bool
+	}{
+		{"caller", "", false},
+		{"getblockcount", "caller", false},
+		{QueueSpanName, "getblockcount", false},
+		{RoundTripSpanName, "getblockcount", true},
+		{RoundTripSpanName, "getblockcount", false},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("recorded %d spans, want %d", len(spans), len(want))
+	}
+	for i, span := range spans {
+		parent := ""
+		if span.parent != nil {
+			parent = span.parent.name
+		}
+		if span.name != want[i].name || parent != want[i].parent ||
+			!span.finished || (span.err != nil) != want[i].failed {
+
+			t.Fatalf("span %d is %s (parent %q, finished %v, error %v), "+
+				"want %s (parent %q)", i, span.name, parent,
+				span.finished, span.err, want[i].name, want[i].parent)
+		}
+	}
+}
+
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls the function.
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTracerPropagation(t *testing.T) {
+	// The round trip span reaches the transport with the HTTP request.
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+
+	var mtx sync.Mutex
+	var seen []string
+	tracer := new(testTracer)
+	config := testConnConfig(server)
+	config.Tracer = tracer
+	config.RoundTripper = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if span, ok := r.Context().Value(testSpanKey{}).(*testSpan); ok {
+			mtx.Lock()
+			seen = append(seen, span.name)
+			mtx.Unlock()
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if len(seen) != 1 || seen[0] != RoundTripSpanName {
+		t.Fatalf("transport saw spans %q", seen)
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+
+	tracer := new(testTracer)
+	config := testConnConfig(server)
+	config.Tracer = tracer
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetBlockCount(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// The request span is tagged with the error delivered to the caller.
+	spans := tracer.snapshot()
+	if len(spans) == 0 || spans[0].name != "getblockcount" ||
+		!errors.Is(spans[0].err, context.DeadlineExceeded) {
+
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
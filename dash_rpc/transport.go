@@ -0,0 +1,47 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package dash_rpc
+
+import (
+	"net/http"
+	"time"
+)
+
+// TransportOptions tunes the connection pool of the HTTP transport used in
+// HTTP POST mode.  Zero values keep the defaults, which are those of
+// http.Transport except for MaxIdleConnsPerHost.
+type TransportOptions struct {
+	// MaxIdleConns limits the number of idle connections kept open across
+	// all hosts.  Zero means no limit.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the number of idle connections kept open
+	// to each host.  Zero means 10.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the number of connections to each host,
+	// including connections in use.  Requests wait for a connection once
+	// the limit is reached.  Zero means no limit.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open.  Zero
+	// means idle connections are kept until the server closes them.
+	IdleConnTimeout time.Duration
+
+	// TLSHandshakeTimeout bounds the time spent on the TLS handshake of a
+	// new connection.  Zero means no timeout.
+	TLSHandshakeTimeout time.Duration
+}
+
+// apply sets the options on the passed transport.
+func (o *TransportOptions) apply(transport *http.Transport) {
+	transport.MaxIdleConns = o.MaxIdleConns
+	if o.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+	transport.MaxConnsPerHost = o.MaxConnsPerHost
+	transport.IdleConnTimeout = o.IdleConnTimeout
+	transport.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+}
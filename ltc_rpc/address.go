@@ -0,0 +1,357 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcutil"
+	"github.com/ltcsuite/ltcutil/bech32"
+)
+
+// bech32Charset is the alphabet of the data part of bech32 strings.
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Const is the constant the checksums of bech32 strings of BIP 173 are
+// built with.
+const bech32Const = 1
+
+// mwebAddressLen is the length of the payload of an MWEB address, which is the
+// serialized scan and spend public keys.
+const mwebAddressLen = 66
+
+// mwebHRPs maps the human-readable part of the segwit addresses of a network
+// to the one of its MWEB addresses, which the chaincfg package does not know.
+var mwebHRPs = map[string]string{
+	"ltc":  "ltcmweb",
+	"tltc": "tmweb",
+	"rltc": "tmweb",
+}
+
+// AddressMweb is a MimbleWimble extension block (MWEB) stealth address, such
+// as ltcmweb1qq... on mainnet.  It is a bech32 encoding of a version 0 and
+// the scan and spend public keys of the recipient.
+//
+// Outputs to MWEB addresses have no output script, so the address is only
+// meant to be classified and passed to the node as a string, for instance as
+// a key of the amounts of CreateRawTransaction.
+type AddressMweb struct {
+	hrp     string
+	payload [mwebAddressLen]byte
+}
+
+// EncodeAddress returns the bech32 string encoding of the address.
+//
+// This is part of the ltcutil.Address interface implementation.
+func (a *AddressMweb) EncodeAddress() string {
+	converted, err := bech32.ConvertBits(a.payload[:], 8, 5, true)
+	if err != nil {
+		return ""
+	}
+	return encodeBech32(a.hrp, append([]byte{0}, converted...))
+}
+
+// ScriptAddress returns the serialized scan and spend public keys of the
+// address, as there is no output script to take the payload of.
+//
+// This is part of the ltcutil.Address interface implementation.
+func (a *AddressMweb) ScriptAddress() []byte {
+	return a.payload[:]
+}
+
+// IsForNet returns whether the address is associated with the passed network.
+//
+// This is part of the ltcutil.Address interface implementation.
+func (a *AddressMweb) IsForNet(net *chaincfg.Params) bool {
+	return a.hrp == mwebHRPs[net.Bech32HRPSegwit]
+}
+
+// String returns the bech32 string encoding of the address.
+//
+// This is part of the ltcutil.Address interface implementation.
+func (a *AddressMweb) String() string {
+	return a.EncodeAddress()
+}
+
+// ScanPubKey returns the serialized compressed scan public key of the address.
+func (a *AddressMweb) ScanPubKey() []byte {
+	return a.payload[:33]
+}
+
+// SpendPubKey returns the serialized compressed spend public key of the
+// address.
+func (a *AddressMweb) SpendPubKey() []byte {
+	return a.payload[33:]
+}
+
+// DecodeAddress decodes the passed string as an address of the passed network.
+// Besides the pay-to-pubkey-hash, pay-to-script-hash and witness version 0
+// addresses ltcutil.DecodeAddress knows, it decodes MWEB addresses, which are
+// returned as an *AddressMweb, and it rejects addresses of other networks.
+func DecodeAddress(addr string, params *chaincfg.Params) (ltcutil.Address, error) {
+	if oneIndex := strings.LastIndexByte(addr, '1'); oneIndex > 0 {
+		hrp := strings.ToLower(addr[:oneIndex])
+		switch {
+		case hrp == params.Bech32HRPSegwit:
+			return decodeSegWitAddress(addr, params)
+		case hrp == mwebHRPs[params.Bech32HRPSegwit]:
+			return decodeMwebAddress(addr)
+		case chaincfg.IsBech32SegwitPrefix(hrp + "1"):
+			return nil, fmt.Errorf("address %s is not for network %s",
+				addr, params.Name)
+		}
+		for _, mwebHRP := range mwebHRPs {
+			if hrp == mwebHRP {
+				return nil, fmt.Errorf("address %s is not for "+
+					"network %s", addr, params.Name)
+			}
+		}
+	}
+
+	decoded, err := ltcutil.DecodeAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(params) {
+		return nil, fmt.Errorf("address %s is not for network %s", addr,
+			params.Name)
+	}
+	return decoded, nil
+}
+
+// RegressionNetParams are the parameters of the regression test network of
+// Litecoin Core.  They are the ones of the chaincfg package with the rltc
+// segwit prefix of the addresses regtest nodes hand out, where the pinned
+// chaincfg package still has the bcrt prefix of Bitcoin.
+var RegressionNetParams = func() chaincfg.Params {
+	params := chaincfg.RegressionNetParams
+	params.Bech32HRPSegwit = "rltc"
+	return params
+}()
+
+// addressNets are the networks whose addresses are recognized when decoding an
+// address returned by a node whose network is not configured.  Legacy testnet
+// and regtest addresses can not be told apart, so they are decoded for
+// testnet.
+var addressNets = []*chaincfg.Params{
+	&chaincfg.MainNetParams,
+	&chaincfg.TestNet4Params,
+	&RegressionNetParams,
+}
+
+// decodeAddress decodes an address returned by a node for the passed network,
+// or for whichever of the known networks it belongs to when it is nil.
+func decodeAddress(addr string, params *chaincfg.Params) (ltcutil.Address, error) {
+	if params != nil {
+		return DecodeAddress(addr, params)
+	}
+	for _, net := range addressNets {
+		if decoded, err := DecodeAddress(addr, net); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid address %q", addr)
+}
+
+// decodeSegWitAddress decodes the passed segwit address, whose human-readable
+// part is the one of the passed network.  Only witness version 0 is supported.
+func decodeSegWitAddress(addr string, params *chaincfg.Params) (ltcutil.Address, error) {
+	_, data, err := decodeBech32(addr, 90)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1 {
+		return nil, errors.New("missing witness version")
+	}
+	if data[0] != 0 {
+		return nil, fmt.Errorf("unsupported witness version %d address",
+			data[0])
+	}
+	program, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	switch len(program) {
+	case 20:
+		return ltcutil.NewAddressWitnessPubKeyHash(program, params)
+	case 32:
+		return ltcutil.NewAddressWitnessScriptHash(program, params)
+	}
+	return nil, fmt.Errorf("invalid witness program length %d for witness "+
+		"version 0", len(program))
+}
+
+// decodeMwebAddress decodes the passed MWEB address.  MWEB addresses are longer
+// than the 90 characters BIP 173 allows.
+func decodeMwebAddress(addr string) (*AddressMweb, error) {
+	hrp, data, err := decodeBech32(addr, 1023)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1 || data[0] != 0 {
+		return nil, errors.New("unsupported MWEB address version")
+	}
+	payload, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != mwebAddressLen {
+		return nil, fmt.Errorf("invalid MWEB address length %d",
+			len(payload))
+	}
+	decoded := &AddressMweb{hrp: hrp}
+	copy(decoded.payload[:], payload)
+	return decoded, nil
+}
+
+// decodeBech32 decodes the passed bech32 string of at most maxLen characters.
+func decodeBech32(s string, maxLen int) (string, []byte, error) {
+	if len(s) > maxLen {
+		return "", nil, fmt.Errorf("invalid bech32 string length %d",
+			len(s))
+	}
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, errors.New("mixed case bech32 string")
+	}
+	oneIndex := strings.LastIndexByte(lower, '1')
+	if oneIndex < 1 || oneIndex+7 > len(lower) {
+		return "", nil, errors.New("invalid bech32 separator index")
+	}
+
+	hrp := lower[:oneIndex]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q",
+				hrp[i])
+		}
+	}
+	data := make([]byte, 0, len(lower)-oneIndex-1)
+	for i := oneIndex + 1; i < len(lower); i++ {
+		value := strings.IndexByte(bech32Charset, lower[i])
+		if value < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q",
+				lower[i])
+		}
+		data = append(data, byte(value))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != bech32Const {
+		return "", nil, errors.New("invalid bech32 checksum")
+	}
+	return hrp, data[:len(data)-6], nil
+}
+
+// encodeBech32 encodes the passed 5 bit data with a bech32 checksum.
+func encodeBech32(hrp string, data []byte) string {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ bech32Const
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, value := range data {
+		b.WriteByte(bech32Charset[value])
+	}
+	for i := 0; i < 6; i++ {
+		b.WriteByte(bech32Charset[(mod>>uint(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// bech32HRPExpand expands the passed human-readable part for the checksum
+// computation.
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// bech32Polymod computes the checksum polynomial of BIP 173 over the passed
+// values.
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd,
+		0x2a1462b3}
+	chk := uint32(1)
+	for _, value := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// ValidateAddressResult models the data returned from the validateaddress
+// command.  Fields which do not apply to the address are left empty.
+type ValidateAddressResult struct {
+	IsValid      bool   `json:"isvalid"`
+	Address      string `json:"address,omitempty"`
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+	IsScript     bool   `json:"isscript,omitempty"`
+
+	// IsWitness is whether the address is a segwit address, whose witness
+	// version and program are then set.
+	IsWitness      bool   `json:"iswitness,omitempty"`
+	WitnessVersion int32  `json:"witness_version,omitempty"`
+	WitnessProgram string `json:"witness_program,omitempty"`
+
+	// Error tells why the address is invalid, which older nodes do not
+	// report.
+	Error string `json:"error,omitempty"`
+}
+
+// FutureValidateAddressResult is a future promise to deliver the result of a
+// ValidateAddressAsync RPC invocation (or an applicable error).
+type FutureValidateAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns what the
+// node knows about the address.
+func (r FutureValidateAddressResult) Receive() (*ValidateAddressResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a validateaddress result object.
+	var result ValidateAddressResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ValidateAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ValidateAddress for the blocking version and more details.
+func (c *Client) ValidateAddressAsync(ctx context.Context, address ltcutil.Address) FutureValidateAddressResult {
+	cmd := btcjson.NewValidateAddressCmd(address.EncodeAddress())
+	return c.sendCmd(ctx, cmd)
+}
+
+// ValidateAddress returns whether the node considers the passed address valid
+// and its output script and witness program.  MWEB addresses have neither, and
+// nodes before Litecoin Core 0.21.2 report them as invalid.
+func (c *Client) ValidateAddress(ctx context.Context, address ltcutil.Address) (*ValidateAddressResult, error) {
+	return c.ValidateAddressAsync(ctx, address).Receive()
+}
@@ -0,0 +1,214 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcutil"
+	"github.com/ltcsuite/ltcutil/bech32"
+)
+
+const (
+	// mwebMainNetAddr and mwebTestNetAddr are MWEB addresses whose scan and
+	// spend public keys are the generator point and its double.
+	mwebMainNetAddr = "ltcmweb1qqfumuen7l8wthtz45p3ftn58pvrs9xlumvkuu2xet8egzkcklqtesqkxq3legs0d04knq32qd62uqlxct3mcujuvau7202avpxu4cuy7u5l7dx6j"
+	mwebTestNetAddr = "tmweb1qqfumuen7l8wthtz45p3ftn58pvrs9xlumvkuu2xet8egzkcklqtesqkxq3legs0d04knq32qd62uqlxct3mcujuvau7202avpxu4cuy7u57hv3x9"
+
+	// mwebPayload is the payload of the MWEB addresses.
+	mwebPayload = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+		"02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	// All but the MWEB addresses are of the hash of the BIP 173 test
+	// vectors.
+	const hash160 = "751e76e8199196d454941c45d1b3a323f1433bd6"
+	tests := []struct {
+		addr    string
+		params  *chaincfg.Params
+		program string
+		typ     string
+	}{
+		{"LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ", &chaincfg.MainNetParams,
+			hash160, "*ltcutil.AddressPubKeyHash"},
+		{"MJaRnao1s62a2zAKSkmG582KbLKianqb7v", &chaincfg.MainNetParams,
+			hash160, "*ltcutil.AddressScriptHash"},
+		{"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9", &chaincfg.MainNetParams,
+			hash160, "*ltcutil.AddressWitnessPubKeyHash"},
+		{mwebMainNetAddr, &chaincfg.MainNetParams, mwebPayload,
+			"*ltc_rpc.AddressMweb"},
+		{"mrCDrCybB6J1vRfbwM5hemdJz73FwDBC8r", &chaincfg.TestNet4Params,
+			hash160, "*ltcutil.AddressPubKeyHash"},
+		{"QXHFfTBKYXjaaTH1e7Rox8CcdNPGHVhM59", &chaincfg.TestNet4Params,
+			hash160, "*ltcutil.AddressScriptHash"},
+		{"tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0", &chaincfg.TestNet4Params,
+			hash160, "*ltcutil.AddressWitnessPubKeyHash"},
+		{mwebTestNetAddr, &chaincfg.TestNet4Params, mwebPayload,
+			"*ltc_rpc.AddressMweb"},
+	}
+
+	for _, test := range tests {
+		addr, err := DecodeAddress(test.addr, test.params)
+		if err != nil {
+			t.Fatalf("DecodeAddress(%s): %v", test.addr, err)
+		}
+		if typ := fmt.Sprintf("%T", addr); typ != test.typ {
+			t.Fatalf("DecodeAddress(%s): got address type %s, want %s",
+				test.addr, typ, test.typ)
+		}
+		if program := hex.EncodeToString(addr.ScriptAddress()); program != test.program {
+			t.Fatalf("DecodeAddress(%s): got program %s, want %s",
+				test.addr, program, test.program)
+		}
+		if addr.EncodeAddress() != test.addr || !addr.IsForNet(test.params) {
+			t.Fatalf("DecodeAddress(%s): address does not round trip, "+
+				"got %s", test.addr, addr.EncodeAddress())
+		}
+
+		// Uppercase addresses are fine for bech32.
+		if strings.Contains(test.addr, "1q") {
+			upper, err := DecodeAddress(strings.ToUpper(test.addr), test.params)
+			if err != nil {
+				t.Fatalf("DecodeAddress(%s): %v",
+					strings.ToUpper(test.addr), err)
+			}
+			if upper.EncodeAddress() != test.addr {
+				t.Fatalf("DecodeAddress(%s): got %s",
+					strings.ToUpper(test.addr), upper.EncodeAddress())
+			}
+		}
+	}
+
+	addr, _ := DecodeAddress(mwebMainNetAddr, &chaincfg.MainNetParams)
+	mweb := addr.(*AddressMweb)
+	payload, _ := hex.DecodeString(mwebPayload)
+	if !bytes.Equal(mweb.ScanPubKey(), payload[:33]) ||
+		!bytes.Equal(mweb.SpendPubKey(), payload[33:]) ||
+		mweb.IsForNet(&chaincfg.TestNet4Params) {
+
+		t.Fatalf("unexpected MWEB address %s", mweb)
+	}
+}
+
+func TestDecodeAddressInvalid(t *testing.T) {
+	invalid := []struct {
+		addr   string
+		params *chaincfg.Params
+	}{
+		// Addresses of other networks.
+		{"LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ", &chaincfg.TestNet4Params},
+		{"tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0", &chaincfg.MainNetParams},
+		{mwebTestNetAddr, &chaincfg.MainNetParams},
+		{mwebMainNetAddr, &chaincfg.TestNet4Params},
+		// Bitcoin addresses.
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", &chaincfg.MainNetParams},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", &chaincfg.MainNetParams},
+		// Malformed addresses.
+		{"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n8", &chaincfg.MainNetParams},
+		{"LTC1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9", &chaincfg.MainNetParams},
+		{mwebMainNetAddr[:len(mwebMainNetAddr)-1] + "q", &chaincfg.MainNetParams},
+		{"ltc1gmk9yu", &chaincfg.MainNetParams},
+		{"", &chaincfg.MainNetParams},
+	}
+	for _, test := range invalid {
+		if addr, err := DecodeAddress(test.addr, test.params); err == nil {
+			t.Fatalf("DecodeAddress(%s): expected an error, got %s",
+				test.addr, addr)
+		}
+	}
+
+	// MWEB addresses of another version or a truncated payload.
+	payload, _ := hex.DecodeString(mwebPayload)
+	for _, data := range [][]byte{payload[:65], payload} {
+		converted, err := bech32.ConvertBits(data, 8, 5, true)
+		if err != nil {
+			t.Fatalf("ConvertBits: %v", err)
+		}
+		version := byte(0)
+		if len(data) == len(payload) {
+			version = 1
+		}
+		encoded := encodeBech32("ltcmweb", append([]byte{version}, converted...))
+		if addr, err := DecodeAddress(encoded, &chaincfg.MainNetParams); err == nil {
+			t.Fatalf("DecodeAddress(%s): expected an error, got %s",
+				encoded, addr)
+		}
+	}
+}
+
+func TestAddressRPCs(t *testing.T) {
+	const p2pkhAddr = "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ"
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "createrawtransaction":
+			return genesisCoinbaseTx, nil
+		case "validateaddress":
+			return json.RawMessage(`{
+  "isvalid": true,
+  "address": "` + mwebMainNetAddr + `",
+  "isscript": false,
+  "iswitness": false
+}`), nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	mweb, err := DecodeAddress(mwebMainNetAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	p2pkh, err := DecodeAddress(p2pkhAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	_, err = client.CreateRawTransaction(ctx, []btcjson.TransactionInput{}, map[ltcutil.Address]ltcutil.Amount{
+		mweb:  100000000,
+		p2pkh: 50000000,
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateRawTransaction: %v", err)
+	}
+	result, err := client.ValidateAddress(ctx, mweb)
+	if err != nil {
+		t.Fatalf("ValidateAddress: %v", err)
+	}
+	if !result.IsValid || result.Address != mwebMainNetAddr ||
+		result.ScriptPubKey != "" || result.IsWitness {
+
+		t.Fatalf("unexpected validateaddress result %+v", result)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`createrawtransaction[[],{"` + p2pkhAddr + `":0.5,"` + mwebMainNetAddr + `":1}]`,
+		`validateaddress["` + mwebMainNetAddr + `"]`,
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got requests %v, want %v", requests, want)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Fatalf("got request %s, want %s", requests[i], want[i])
+		}
+	}
+}
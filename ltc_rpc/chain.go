@@ -10,10 +10,16 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/ltcsuite/ltcd/btcjson"
 	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
 	"github.com/ltcsuite/ltcd/wire"
+	"github.com/ltcsuite/ltcutil/gcs"
+	"github.com/ltcsuite/ltcutil/gcs/builder"
 )
 
 // FutureGetBestBlockHashResult is a future promise to deliver the result of a
@@ -79,12 +85,7 @@ func (r FutureGetBlockResult) Receive() (*wire.MsgBlock, error) {
 	}
 
 	// Deserialize the block and return it.
-	var msgBlock wire.MsgBlock
-	err = msgBlock.Deserialize(bytes.NewReader(serializedBlock))
-	if err != nil {
-		return nil, err
-	}
-	return &msgBlock, nil
+	return deserializeBlock(serializedBlock)
 }
 
 // GetBlockAsync returns an instance of a type that can be used to get the
@@ -155,27 +156,103 @@ func (c *Client) GetBlockVerbose(ctx context.Context, blockHash *chainhash.Hash)
 	return c.GetBlockVerboseAsync(ctx, blockHash).Receive()
 }
 
+// BlockTxResult models a transaction of the data returned by the getblock
+// command with a verbosity of 2.
+type BlockTxResult struct {
+	Txid     string `json:"txid"`
+	Hash     string `json:"hash"`
+	Version  int32  `json:"version"`
+	Size     int32  `json:"size"`
+	LockTime uint32 `json:"locktime"`
+
+	// Vsize and Weight are the virtual size and weight of the transaction,
+	// which take the discount of witness data into account.
+	Vsize  int32 `json:"vsize"`
+	Weight int32 `json:"weight"`
+
+	Vin  []btcjson.Vin  `json:"vin"`
+	Vout []btcjson.Vout `json:"vout"`
+	Hex  string         `json:"hex"`
+}
+
+// GetBlockVerboseTxResult models the data returned by the getblock command
+// with a verbosity of 2.
+type GetBlockVerboseTxResult struct {
+	Hash          string          `json:"hash"`
+	Confirmations int64           `json:"confirmations"`
+	StrippedSize  int32           `json:"strippedsize"`
+	Size          int32           `json:"size"`
+	Weight        int32           `json:"weight"`
+	Height        int64           `json:"height"`
+	Version       int32           `json:"version"`
+	VersionHex    string          `json:"versionHex"`
+	MerkleRoot    string          `json:"merkleroot"`
+	Tx            []BlockTxResult `json:"tx"`
+	Time          int64           `json:"time"`
+	MedianTime    int64           `json:"mediantime"`
+	Nonce         uint32          `json:"nonce"`
+	Bits          string          `json:"bits"`
+	Difficulty    float64         `json:"difficulty"`
+	ChainWork     string          `json:"chainwork"`
+	NTx           int64           `json:"nTx"`
+	PreviousHash  string          `json:"previousblockhash,omitempty"`
+	NextHash      string          `json:"nextblockhash,omitempty"`
+
+	// MWEB is the MWEB extension block as reported by Litecoin Core 0.21.2
+	// and later, which blocks before the activation of MWEB lack.
+	MWEB json.RawMessage `json:"mweb,omitempty"`
+}
+
+// FutureGetBlockVerboseTxResult is a future promise to deliver the result of a
+// GetBlockVerboseTxAsync RPC invocation (or an applicable error).
+type FutureGetBlockVerboseTxResult chan *response
+
+// Receive waits for the response promised by the future and returns the data
+// structure from the server with information about the requested block and
+// its transactions.
+func (r FutureGetBlockVerboseTxResult) Receive() (*GetBlockVerboseTxResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the raw result into a GetBlockVerboseTxResult.
+	var blockResult GetBlockVerboseTxResult
+	if err := json.Unmarshal(res, &blockResult); err != nil {
+		return nil, err
+	}
+	return &blockResult, nil
+}
+
 // GetBlockVerboseTxAsync returns an instance of a type that can be used to get
 // the result of the RPC at some future time by invoking the Receive function on
 // the returned instance.
 //
 // See GetBlockVerboseTx or the blocking version and more details.
-func (c *Client) GetBlockVerboseTxAsync(ctx context.Context, blockHash *chainhash.Hash) FutureGetBlockVerboseResult {
+func (c *Client) GetBlockVerboseTxAsync(ctx context.Context, blockHash *chainhash.Hash) FutureGetBlockVerboseTxResult {
 	hash := ""
 	if blockHash != nil {
 		hash = blockHash.String()
 	}
 
-	cmd := btcjson.NewGetBlockCmd(hash, btcjson.Bool(true), btcjson.Bool(true))
-	return c.sendCmd(ctx, cmd)
+	// The btcjson package only knows the verbose flags of btcd, while
+	// Litecoin Core takes a verbosity, so the command is sent as a raw
+	// request.
+	rawParams, err := marshalParams([]interface{}{hash, 2})
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureGetBlockVerboseTxResult(c.RawRequestAsync(ctx, "getblock",
+		rawParams))
 }
 
 // GetBlockVerboseTx returns a data structure from the server with information
-// about a block and its transactions given its hash.
+// about a block and its transactions given its hash, including the size,
+// virtual size and weight of each transaction.
 //
 // See GetBlockVerbose if only transaction hashes are preferred.
 // See GetBlock to retrieve a raw block instead.
-func (c *Client) GetBlockVerboseTx(ctx context.Context, blockHash *chainhash.Hash) (*btcjson.GetBlockVerboseResult, error) {
+func (c *Client) GetBlockVerboseTx(ctx context.Context, blockHash *chainhash.Hash) (*GetBlockVerboseTxResult, error) {
 	return c.GetBlockVerboseTxAsync(ctx, blockHash).Receive()
 }
 
@@ -382,20 +459,82 @@ func (c *Client) GetBlockHeader(ctx context.Context, blockHash *chainhash.Hash)
 	return c.GetBlockHeaderAsync(ctx, blockHash).Receive()
 }
 
+// GetBlockHeaderVerboseResult models the data returned from the getblockheader
+// command when the verbose flag is set.  Unlike
+// btcjson.GetBlockHeaderVerboseResult, it also holds the median time, chain
+// work and transaction count Litecoin Core reports.
+//
+// Hash is the SHA-256d hash identifying the block.  Litecoin checks the proof
+// of work against the scrypt hash of the header instead, which the server does
+// not report, so it has to be computed with the PowHash method of the header
+// returned by Header.
+type GetBlockHeaderVerboseResult struct {
+	Hash          string  `json:"hash"`
+	Confirmations int64   `json:"confirmations"`
+	Height        int32   `json:"height"`
+	Version       int32   `json:"version"`
+	VersionHex    string  `json:"versionHex"`
+	MerkleRoot    string  `json:"merkleroot"`
+	Time          int64   `json:"time"`
+	MedianTime    int64   `json:"mediantime"`
+	Nonce         uint32  `json:"nonce"`
+	Bits          string  `json:"bits"`
+	Difficulty    float64 `json:"difficulty"`
+	ChainWork     string  `json:"chainwork"`
+	NTx           int64   `json:"nTx"`
+	PreviousHash  string  `json:"previousblockhash,omitempty"`
+	NextHash      string  `json:"nextblockhash,omitempty"`
+}
+
+// Header returns the block header described by the result, whose hash is
+// checked against Hash.
+func (r *GetBlockHeaderVerboseResult) Header() (*wire.BlockHeader, error) {
+	var prevBlock chainhash.Hash
+	if r.PreviousHash != "" {
+		hash, err := chainhash.NewHashFromStr(r.PreviousHash)
+		if err != nil {
+			return nil, err
+		}
+		prevBlock = *hash
+	}
+	merkleRoot, err := chainhash.NewHashFromStr(r.MerkleRoot)
+	if err != nil {
+		return nil, err
+	}
+	bits, err := strconv.ParseUint(r.Bits, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &wire.BlockHeader{
+		Version:    r.Version,
+		PrevBlock:  prevBlock,
+		MerkleRoot: *merkleRoot,
+		Timestamp:  time.Unix(r.Time, 0),
+		Bits:       uint32(bits),
+		Nonce:      r.Nonce,
+	}
+	if hash := header.BlockHash(); hash.String() != r.Hash {
+		return nil, fmt.Errorf("header hashes to %v instead of %s", hash,
+			r.Hash)
+	}
+	return header, nil
+}
+
 // FutureGetBlockHeaderVerboseResult is a future promise to deliver the result of a
 // GetBlockAsync RPC invocation (or an applicable error).
 type FutureGetBlockHeaderVerboseResult chan *response
 
 // Receive waits for the response promised by the future and returns the
 // data structure of the blockheader requested from the server given its hash.
-func (r FutureGetBlockHeaderVerboseResult) Receive() (*btcjson.GetBlockHeaderVerboseResult, error) {
+func (r FutureGetBlockHeaderVerboseResult) Receive() (*GetBlockHeaderVerboseResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
 		return nil, err
 	}
 
-	// Unmarshal result as a string.
-	var bh btcjson.GetBlockHeaderVerboseResult
+	// Unmarshal result as a getblockheader result object.
+	var bh GetBlockHeaderVerboseResult
 	err = json.Unmarshal(res, &bh)
 	if err != nil {
 		return nil, err
@@ -420,10 +559,11 @@ func (c *Client) GetBlockHeaderVerboseAsync(ctx context.Context, blockHash *chai
 }
 
 // GetBlockHeaderVerbose returns a data structure with information about the
-// blockheader from the server given its hash.
+// blockheader from the server given its hash, such as its height and the
+// hashes of the previous and next blocks.
 //
 // See GetBlockHeader to retrieve a blockheader instead.
-func (c *Client) GetBlockHeaderVerbose(ctx context.Context, blockHash *chainhash.Hash) (*btcjson.GetBlockHeaderVerboseResult, error) {
+func (c *Client) GetBlockHeaderVerbose(ctx context.Context, blockHash *chainhash.Hash) (*GetBlockHeaderVerboseResult, error) {
 	return c.GetBlockHeaderVerboseAsync(ctx, blockHash).Receive()
 }
 
@@ -434,14 +574,17 @@ type FutureGetMempoolEntryResult chan *response
 // Receive waits for the response promised by the future and returns a data
 // structure with information about the transaction in the memory pool given
 // its hash.
-func (r FutureGetMempoolEntryResult) Receive() (*btcjson.GetMempoolEntryResult, error) {
+func (r FutureGetMempoolEntryResult) Receive() (*GetMempoolEntryResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
+		if isTxNotInMempool(err) {
+			return nil, &ErrTxNotInMempool{Err: err}
+		}
 		return nil, err
 	}
 
-	// Unmarshal the result as an array of strings.
-	var mempoolEntryResult btcjson.GetMempoolEntryResult
+	// Unmarshal the result as a getmempoolentry result object.
+	var mempoolEntryResult GetMempoolEntryResult
 	err = json.Unmarshal(res, &mempoolEntryResult)
 	if err != nil {
 		return nil, err
@@ -461,8 +604,13 @@ func (c *Client) GetMempoolEntryAsync(ctx context.Context, txHash string) Future
 }
 
 // GetMempoolEntry returns a data structure with information about the
-// transaction in the memory pool given its hash.
-func (c *Client) GetMempoolEntry(ctx context.Context, txHash string) (*btcjson.GetMempoolEntryResult, error) {
+// transaction in the memory pool given its hash.  The returned error is an
+// *ErrTxNotInMempool when the transaction is not in the memory pool, such as
+// after it was mined.
+//
+// See EffectiveFeeRate to compute the fee rate miners consider the transaction
+// at.
+func (c *Client) GetMempoolEntry(ctx context.Context, txHash string) (*GetMempoolEntryResult, error) {
 	return c.GetMempoolEntryAsync(ctx, txHash).Receive()
 }
 
@@ -523,15 +671,15 @@ type FutureGetRawMempoolVerboseResult chan *response
 // Receive waits for the response promised by the future and returns a map of
 // transaction hashes to an associated data structure with information about the
 // transaction for all transactions in the memory pool.
-func (r FutureGetRawMempoolVerboseResult) Receive() (map[string]btcjson.GetRawMempoolVerboseResult, error) {
+func (r FutureGetRawMempoolVerboseResult) Receive() (map[string]GetMempoolEntryResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
 		return nil, err
 	}
 
-	// Unmarshal the result as a map of strings (tx shas) to their detailed
-	// results.
-	var mempoolItems map[string]btcjson.GetRawMempoolVerboseResult
+	// Unmarshal the result as a map of strings (tx shas) to their memory
+	// pool entries.
+	var mempoolItems map[string]GetMempoolEntryResult
 	err = json.Unmarshal(res, &mempoolItems)
 	if err != nil {
 		return nil, err
@@ -554,7 +702,7 @@ func (c *Client) GetRawMempoolVerboseAsync(ctx context.Context) FutureGetRawMemp
 // the memory pool.
 //
 // See GetRawMempool to retrieve only the transaction hashes instead.
-func (c *Client) GetRawMempoolVerbose(ctx context.Context) (map[string]btcjson.GetRawMempoolVerboseResult, error) {
+func (c *Client) GetRawMempoolVerbose(ctx context.Context) (map[string]GetMempoolEntryResult, error) {
 	return c.GetRawMempoolVerboseAsync(ctx).Receive()
 }
 
@@ -792,8 +940,8 @@ func (c *Client) RescanBlocks(ctx context.Context, blockHashes []chainhash.Hash)
 // InvalidateBlockAsync RPC invocation (or an applicable error).
 type FutureInvalidateBlockResult chan *response
 
-// Receive waits for the response promised by the future and returns the raw
-// block requested from the server given its hash.
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when invalidating the block.
 func (r FutureInvalidateBlockResult) Receive() error {
 	_, err := receiveFuture(r)
 
@@ -815,11 +963,43 @@ func (c *Client) InvalidateBlockAsync(ctx context.Context, blockHash *chainhash.
 	return c.sendCmd(ctx, cmd)
 }
 
-// InvalidateBlock invalidates a specific block.
+// InvalidateBlock invalidates a specific block.  The node treats the block and
+// its descendants as invalid and reorganizes to the best valid chain, which
+// makes it the way to simulate reorganizations on regtest.
+//
+// See ReconsiderBlock to undo it.
 func (c *Client) InvalidateBlock(ctx context.Context, blockHash *chainhash.Hash) error {
 	return c.InvalidateBlockAsync(ctx, blockHash).Receive()
 }
 
+// FutureReconsiderBlockResult is a future promise to deliver the result of a
+// ReconsiderBlockAsync RPC invocation (or an applicable error).
+type FutureReconsiderBlockResult chan *response
+
+// Receive waits for the response promised by the future and returns an error
+// if any occurred when reconsidering the block.
+func (r FutureReconsiderBlockResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// ReconsiderBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ReconsiderBlock for the blocking version and more details.
+func (c *Client) ReconsiderBlockAsync(ctx context.Context, blockHash *chainhash.Hash) FutureReconsiderBlockResult {
+	cmd := btcjson.NewReconsiderBlockCmd(blockHash.String())
+	return c.sendCmd(ctx, cmd)
+}
+
+// ReconsiderBlock removes the invalidity status of the passed block and its
+// descendants set by InvalidateBlock, after which the node reorganizes back to
+// them if they make the chain with the most work.
+func (c *Client) ReconsiderBlock(ctx context.Context, blockHash *chainhash.Hash) error {
+	return c.ReconsiderBlockAsync(ctx, blockHash).Receive()
+}
+
 // FutureGetCFilterResult is a future promise to deliver the result of a
 // GetCFilterAsync RPC invocation (or an applicable error).
 type FutureGetCFilterResult chan *response
@@ -928,3 +1108,290 @@ func (c *Client) GetCFilterHeader(ctx context.Context, blockHash *chainhash.Hash
 	filterType wire.FilterType) (*wire.MsgCFHeaders, error) {
 	return c.GetCFilterHeaderAsync(ctx, blockHash, filterType).Receive()
 }
+
+// ErrBlockFilterIndexDisabled is returned by GetBlockFilter when the server
+// was started without -blockfilterindex, or without an index for the
+// requested filter type.
+var ErrBlockFilterIndexDisabled = errors.New("block filter index is not " +
+	"enabled on the server")
+
+// BlockFilterBasic is the only filter type defined by BIP158 and the default
+// of GetBlockFilter.
+const BlockFilterBasic = "basic"
+
+// GetBlockFilterResult models the data returned from the getblockfilter
+// command with the filter and header decoded.
+type GetBlockFilterResult struct {
+	// Filter is the serialized filter, as passed to BlockFilterMatches.
+	Filter []byte
+
+	// Header is the filter header committing to the filter and the
+	// headers of all previous blocks.
+	Header chainhash.Hash
+}
+
+// FutureGetBlockFilterResult is a future promise to deliver the result of a
+// GetBlockFilterAsync RPC invocation (or an applicable error).
+type FutureGetBlockFilterResult chan *response
+
+// Receive waits for the response promised by the future and returns the block
+// filter and its header.
+func (r FutureGetBlockFilterResult) Receive() (*GetBlockFilterResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isBlockFilterIndexDisabled(err) {
+			return nil, ErrBlockFilterIndexDisabled
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a getblockfilter result object.
+	var filterResult struct {
+		Filter string `json:"filter"`
+		Header string `json:"header"`
+	}
+	err = json.Unmarshal(res, &filterResult)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := hex.DecodeString(filterResult.Filter)
+	if err != nil {
+		return nil, err
+	}
+	header, err := chainhash.NewHashFromStr(filterResult.Header)
+	if err != nil {
+		return nil, err
+	}
+	return &GetBlockFilterResult{Filter: filter, Header: *header}, nil
+}
+
+// GetBlockFilterAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockFilter for the blocking version and more details.
+func (c *Client) GetBlockFilterAsync(ctx context.Context, blockHash *chainhash.Hash, filterType string) FutureGetBlockFilterResult {
+	hash := ""
+	if blockHash != nil {
+		hash = blockHash.String()
+	}
+	params := []interface{}{hash}
+	if filterType != "" {
+		params = append(params, filterType)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGetBlockFilterResult(c.RawRequestAsync(ctx, "getblockfilter",
+		rawParams))
+}
+
+// GetBlockFilter returns the BIP158 compact filter of the block with the passed
+// hash along with its filter header.  An empty filter type means
+// BlockFilterBasic.
+//
+// NOTE: Litecoin Core only serves block filters when started with
+// -blockfilterindex.  ErrBlockFilterIndexDisabled is returned otherwise.
+func (c *Client) GetBlockFilter(ctx context.Context, blockHash *chainhash.Hash, filterType string) (*GetBlockFilterResult, error) {
+	return c.GetBlockFilterAsync(ctx, blockHash, filterType).Receive()
+}
+
+// BlockFilterMatches reports whether any of the passed scripts, usually the
+// output scripts paying to the caller's addresses, is a member of the passed
+// basic block filter of the block with the passed hash.  The block hash is
+// needed since the filter key is derived from it.
+//
+// Like every compact filter match, a true result may be a false positive, so
+// the block has to be fetched to confirm it.  A false result is definitive.
+// Filters only cover the canonical block, so outputs paying to MWEB
+// addresses never match.
+func BlockFilterMatches(filter []byte, blockHash *chainhash.Hash, scripts [][]byte) (bool, error) {
+	if len(scripts) == 0 {
+		return false, nil
+	}
+
+	gcsFilter, err := gcs.FromNBytes(builder.DefaultP, builder.DefaultM,
+		filter)
+	if err != nil {
+		return false, err
+	}
+
+	// An empty filter has no members, and gcs refuses to match against it.
+	if gcsFilter.N() == 0 {
+		return false, nil
+	}
+
+	key := builder.DeriveKey(blockHash)
+	return gcsFilter.MatchAny(key, scripts)
+}
+
+// ChainTipStatus classifies the status of a chain tip reported by
+// getchaintips.
+type ChainTipStatus int
+
+// These constants describe the possible statuses of a chain tip.
+const (
+	// ChainTipUnknown indicates a status this package does not know.
+	ChainTipUnknown ChainTipStatus = iota
+
+	// ChainTipActive indicates the tip of the best chain, which is valid.
+	ChainTipActive
+
+	// ChainTipValidFork indicates a fully validated branch which is not
+	// part of the best chain.
+	ChainTipValidFork
+
+	// ChainTipValidHeaders indicates a branch whose blocks are all
+	// available but were never fully validated.
+	ChainTipValidHeaders
+
+	// ChainTipHeadersOnly indicates a branch with valid headers for which
+	// not all blocks are available.
+	ChainTipHeadersOnly
+
+	// ChainTipInvalid indicates a branch containing at least one invalid
+	// block.
+	ChainTipInvalid
+)
+
+// Map of the status strings reported by nodes to their ChainTipStatus.
+var chainTipStatuses = map[string]ChainTipStatus{
+	"active":        ChainTipActive,
+	"valid-fork":    ChainTipValidFork,
+	"valid-headers": ChainTipValidHeaders,
+	"headers-only":  ChainTipHeadersOnly,
+	"invalid":       ChainTipInvalid,
+}
+
+// String returns the ChainTipStatus as reported by nodes.
+func (s ChainTipStatus) String() string {
+	for str, status := range chainTipStatuses {
+		if status == s {
+			return str
+		}
+	}
+	return fmt.Sprintf("Unknown ChainTipStatus (%d)", int(s))
+}
+
+// ChainTip models a chain tip returned from the getchaintips command.
+type ChainTip struct {
+	// Height is the height of the tip and Hash its block hash.
+	Height int32
+	Hash   string
+
+	// BranchLen is the number of blocks of the branch since it forked off
+	// the best chain, which is 0 for the active tip.
+	BranchLen int32
+
+	// Status is the status of the branch, and ChainTipUnknown for
+	// statuses this package does not know.
+	Status ChainTipStatus
+}
+
+// UnmarshalJSON decodes a chain tip, classifying its status.
+func (t *ChainTip) UnmarshalJSON(data []byte) error {
+	var tip struct {
+		Height    int32  `json:"height"`
+		Hash      string `json:"hash"`
+		BranchLen int32  `json:"branchlen"`
+		Status    string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &tip); err != nil {
+		return err
+	}
+
+	*t = ChainTip{
+		Height:    tip.Height,
+		Hash:      tip.Hash,
+		BranchLen: tip.BranchLen,
+		Status:    chainTipStatuses[tip.Status],
+	}
+	return nil
+}
+
+// FutureGetChainTipsResult is a future promise to deliver the result of a
+// GetChainTipsAsync RPC invocation (or an applicable error).
+type FutureGetChainTipsResult chan *response
+
+// Receive waits for the response promised by the future and returns the tips
+// of all branches known to the server.
+func (r FutureGetChainTipsResult) Receive() ([]ChainTip, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getchaintips result objects.
+	var chainTips []ChainTip
+	err = json.Unmarshal(res, &chainTips)
+	if err != nil {
+		return nil, err
+	}
+
+	return chainTips, nil
+}
+
+// GetChainTipsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetChainTips for the blocking version and more details.
+func (c *Client) GetChainTipsAsync(ctx context.Context) FutureGetChainTipsResult {
+	cmd := btcjson.NewGetChainTipsCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetChainTips returns the tips of all branches of the block tree known to the
+// server, including the tip of the best chain.  A ChainTipValidFork tip close
+// to the active one signals a competing chain, and a ChainTipHeadersOnly or
+// ChainTipValidHeaders tip above the active one a longer chain the server has
+// not switched to, such as while it is stuck on a minority fork.
+func (c *Client) GetChainTips(ctx context.Context) ([]ChainTip, error) {
+	return c.GetChainTipsAsync(ctx).Receive()
+}
+
+// isOnBestChainAttempts is how many times IsOnBestChain queries the server
+// before giving up on the best chain changing under it.
+const isOnBestChainAttempts = 3
+
+// IsOnBestChain returns whether the block with the passed hash is in the best
+// chain of the server, such as to tell whether a deposit was reorganized out.
+//
+// The confirmations of the block reported by getblockheader are checked to
+// reach the active tip reported by getchaintips, so that a reorganization
+// between the two requests is noticed and they are sent again.  An error is
+// returned when the best chain keeps changing, and for blocks the server does
+// not know at all.
+func (c *Client) IsOnBestChain(ctx context.Context, blockHash *chainhash.Hash) (bool, error) {
+	for attempt := 0; attempt < isOnBestChainAttempts; attempt++ {
+		tipsFuture := c.GetChainTipsAsync(ctx)
+		headerFuture := c.GetBlockHeaderVerboseAsync(ctx, blockHash)
+		tips, err := tipsFuture.Receive()
+		if err != nil {
+			return false, err
+		}
+		header, err := headerFuture.Receive()
+		if err != nil {
+			return false, err
+		}
+
+		// Blocks outside the best chain have negative confirmations.
+		if header.Confirmations < 0 {
+			return false, nil
+		}
+		for _, tip := range tips {
+			if tip.Status == ChainTipActive &&
+				int64(tip.Height) == int64(header.Height)+header.Confirmations-1 {
+
+				return true, nil
+			}
+		}
+	}
+	return false, fmt.Errorf("best chain changed while checking block %v",
+		blockHash)
+}
@@ -0,0 +1,484 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+)
+
+// blockVerboseTxFixture is a getblock result with a verbosity of 2 of a block
+// with a segwit spend and a HogEx, in the format of Litecoin Core 0.21.2.
+const blockVerboseTxFixture = `{
+  "hash": "0000000000000000000000000000000000000000000000000000000000000004",
+  "confirmations": 3,
+  "strippedsize": 366,
+  "size": 475,
+  "weight": 1573,
+  "height": 2265984,
+  "version": 536870912,
+  "versionHex": "20000000",
+  "merkleroot": "0000000000000000000000000000000000000000000000000000000000000005",
+  "tx": [
+    {
+      "txid": "0000000000000000000000000000000000000000000000000000000000000011",
+      "hash": "0000000000000000000000000000000000000000000000000000000000000012",
+      "version": 2,
+      "size": 191,
+      "vsize": 110,
+      "weight": 437,
+      "locktime": 2100000,
+      "vin": [
+        {
+          "txid": "0000000000000000000000000000000000000000000000000000000000000001",
+          "vout": 1,
+          "scriptSig": {
+            "asm": "",
+            "hex": ""
+          },
+          "txinwitness": [
+            "3044",
+            "02"
+          ],
+          "sequence": 4294967295
+        }
+      ],
+      "vout": [
+        {
+          "value": 1.5,
+          "n": 0,
+          "scriptPubKey": {
+            "asm": "0 751e76e8199196d454941c45d1b3a323f1433bd6",
+            "hex": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+            "reqSigs": 1,
+            "type": "witness_v0_keyhash",
+            "addresses": [
+              "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9"
+            ]
+          }
+        }
+      ],
+      "hex": "02000000000101"
+    },
+    {
+      "txid": "0000000000000000000000000000000000000000000000000000000000000021",
+      "hash": "0000000000000000000000000000000000000000000000000000000000000021",
+      "version": 2,
+      "size": 96,
+      "vsize": 96,
+      "weight": 384,
+      "locktime": 0,
+      "vin": [
+        {
+          "txid": "0000000000000000000000000000000000000000000000000000000000000002",
+          "vout": 0,
+          "scriptSig": {
+            "asm": "",
+            "hex": ""
+          },
+          "sequence": 4294967295
+        }
+      ],
+      "vout": [
+        {
+          "value": 25,
+          "n": 0,
+          "scriptPubKey": {
+            "asm": "8 0303030303030303030303030303030303030303030303030303030303030303",
+            "hex": "58200303030303030303030303030303030303030303030303030303030303030303",
+            "type": "witness_unknown"
+          }
+        }
+      ],
+      "hex": "02000000000801"
+    }
+  ],
+  "time": 1651000000,
+  "mediantime": 1650999000,
+  "nonce": 42,
+  "bits": "1a01cd2d",
+  "difficulty": 9307429.14,
+  "chainwork": "0000000000000000000000000000000000000000000006f6d3b4e5f1a2b3c4d5",
+  "nTx": 2,
+  "previousblockhash": "0000000000000000000000000000000000000000000000000000000000000003",
+  "mweb": {
+    "hash": "0000000000000000000000000000000000000000000000000000000000000031",
+    "height": 2265984
+  }
+}`
+
+func TestGetBlockVerboseTx(t *testing.T) {
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		if req.Method != "getblock" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		return json.RawMessage(blockVerboseTxFixture), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	hash, _ := chainhash.NewHashFromStr("04")
+	block, err := client.GetBlockVerboseTx(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("GetBlockVerboseTx: %v", err)
+	}
+	mtx.Lock()
+	if p, _ := json.Marshal(params); string(p) != `["`+hash.String()+`",2]` {
+		t.Fatalf("got params %s, want a verbosity of 2", p)
+	}
+	mtx.Unlock()
+
+	if block.Height != 2265984 || block.Weight != 1573 || block.NTx != 2 ||
+		len(block.Tx) != 2 || len(block.MWEB) == 0 {
+
+		t.Fatalf("unexpected block %+v", block)
+	}
+	segwit := block.Tx[0]
+	if segwit.Size != 191 || segwit.Vsize != 110 || segwit.Weight != 437 ||
+		len(segwit.Vin) != 1 || len(segwit.Vin[0].Witness) != 2 ||
+		segwit.Vout[0].ScriptPubKey.Addresses[0] != "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9" {
+
+		t.Fatalf("unexpected segwit transaction %+v", segwit)
+	}
+	hogEx := block.Tx[1]
+	if hogEx.Vsize != hogEx.Size || hogEx.Weight != 4*hogEx.Size ||
+		hogEx.Vout[0].Value != 25 {
+
+		t.Fatalf("unexpected HogEx %+v", hogEx)
+	}
+}
+
+func TestGetRawTransactionWitness(t *testing.T) {
+	witnessTx := newWitnessTx()
+	hogEx := newHogEx()
+	rawTxs := map[string]string{
+		witnessTx.TxHash().String(): hex.EncodeToString(serializeTx(t, witnessTx)),
+		hogEx.TxHash().String(): hex.EncodeToString(withMwebFlag(
+			serializeTx(t, hogEx), []byte{0})),
+	}
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getrawtransaction" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		var txid string
+		json.Unmarshal(req.Params[0], &txid)
+		return rawTxs[txid], nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	txHash := witnessTx.TxHash()
+	tx, err := client.GetRawTransaction(ctx, &txHash)
+	if err != nil {
+		t.Fatalf("GetRawTransaction: %v", err)
+	}
+	// HasWitness must not panic after WitnessHash, which the pinned
+	// ltcutil.Tx only gets right once HasWitness was called before.
+	if *tx.WitnessHash() != witnessTx.WitnessHash() || !tx.HasWitness() {
+		t.Fatalf("got transaction %v, want %v", tx.WitnessHash(),
+			witnessTx.WitnessHash())
+	}
+
+	txHash = hogEx.TxHash()
+	tx, err = client.GetRawTransaction(ctx, &txHash)
+	if err != nil {
+		t.Fatalf("GetRawTransaction: %v", err)
+	}
+	if *tx.Hash() != txHash {
+		t.Fatalf("got transaction %v, want %v", tx.Hash(), txHash)
+	}
+}
+
+func TestGetBlockFilter(t *testing.T) {
+	// The filter of the testnet4 genesis block holds the coinbase output
+	// script only.  It and its header were computed with the BIP158
+	// reference algorithm, which reproduces the BIP158 test vectors.
+	genesis := chaincfg.TestNet4Params.GenesisBlock
+	genesisHash := chaincfg.TestNet4Params.GenesisHash
+	const filterHeader = "9287611ccee252d9cfb29f2c98ac5d513ae0d7aa8970719f841b279dda23d002"
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		return map[string]string{
+			"filter": "014c8c60",
+			"header": filterHeader,
+		}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	result, err := client.GetBlockFilter(context.Background(), genesisHash, "")
+	if err != nil {
+		t.Fatalf("GetBlockFilter: %v", err)
+	}
+	if hex.EncodeToString(result.Filter) != "014c8c60" ||
+		result.Header.String() != filterHeader {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+	mtx.Lock()
+	if want := `getblockfilter["` + genesisHash.String() + `"]`; len(requests) != 1 ||
+		requests[0] != want {
+
+		t.Fatalf("got requests %v, want %s", requests, want)
+	}
+	mtx.Unlock()
+
+	coinbaseScript := genesis.Transactions[0].TxOut[0].PkScript
+	otherScript := append([]byte{0x76, 0xa9, 0x14}, make([]byte, 20)...)
+	otherScript = append(otherScript, 0x88, 0xac)
+	tests := []struct {
+		name    string
+		scripts [][]byte
+		want    bool
+	}{
+		{"coinbase", [][]byte{coinbaseScript}, true},
+		{"other and coinbase", [][]byte{otherScript, coinbaseScript}, true},
+		{"other", [][]byte{otherScript}, false},
+		{"none", nil, false},
+	}
+	for _, test := range tests {
+		matched, err := BlockFilterMatches(result.Filter, genesisHash,
+			test.scripts)
+		if err != nil {
+			t.Fatalf("%s: BlockFilterMatches: %v", test.name, err)
+		}
+		if matched != test.want {
+			t.Fatalf("%s: matched %v, want %v", test.name, matched,
+				test.want)
+		}
+	}
+
+	// The key is derived from the block hash, so the filter does not
+	// match under another one.
+	matched, err := BlockFilterMatches(result.Filter,
+		chaincfg.MainNetParams.GenesisHash, [][]byte{coinbaseScript})
+	if err != nil || matched {
+		t.Fatalf("other block: matched %v, %v", matched, err)
+	}
+}
+
+func TestBlockFilterMatches(t *testing.T) {
+	// p2wpkh returns a P2WPKH output script with a key hash made of the
+	// passed byte.
+	p2wpkh := func(b byte) []byte {
+		script := []byte{0x00, 0x14}
+		for i := 0; i < 20; i++ {
+			script = append(script, b)
+		}
+		return script
+	}
+
+	// The filter of the regtest genesis hash holds the P2WPKH scripts of
+	// key hashes 0x01, 0x02 and 0x03.
+	blockHash := RegressionNetParams.GenesisHash
+	filter, _ := hex.DecodeString("03bf3b4cc640d8b040")
+	for b := byte(1); b < 8; b++ {
+		matched, err := BlockFilterMatches(filter, blockHash,
+			[][]byte{p2wpkh(b)})
+		if err != nil {
+			t.Fatalf("BlockFilterMatches: %v", err)
+		}
+		if want := b <= 3; matched != want {
+			t.Fatalf("script %d: matched %v, want %v", b, matched, want)
+		}
+	}
+
+	// Blocks without any scripts have an empty filter.
+	matched, err := BlockFilterMatches([]byte{0x00}, blockHash,
+		[][]byte{p2wpkh(1)})
+	if err != nil || matched {
+		t.Fatalf("empty filter: matched %v, %v", matched, err)
+	}
+}
+
+func TestGetBlockFilterIndexDisabled(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMisc,
+			"Index is not enabled for filtertype basic")
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	_, err := client.GetBlockFilter(context.Background(),
+		chaincfg.MainNetParams.GenesisHash, BlockFilterBasic)
+	if err != ErrBlockFilterIndexDisabled {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// chainTipsFixture is a getchaintips reply with the active tip and two valid
+// forks, the first of which is a single block competing with the parent of the
+// active tip.
+const chainTipsFixture = `[
+  {
+    "height": 2500010,
+    "hash": "` + activeTipHash + `",
+    "branchlen": 0,
+    "status": "active"
+  },
+  {
+    "height": 2500009,
+    "hash": "` + forkTipHash + `",
+    "branchlen": 1,
+    "status": "valid-fork"
+  },
+  {
+    "height": 2499000,
+    "hash": "6b2ad1e3c5f7a9b1d3e5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9",
+    "branchlen": 2,
+    "status": "valid-fork"
+  },
+  {
+    "height": 2400000,
+    "hash": "1f3a5c7e9b1d3f5a7c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e7b9d1f3a",
+    "branchlen": 1,
+    "status": "some-new-status"
+  }
+]`
+
+const (
+	activeTipHash = "4e2c8a6f1b3d5e7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1b3d5f"
+	forkTipHash   = "a7c9e1b3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9"
+	depositHash   = "3d5f7a9c1e3b5d7f9a1c3e5b7d9f1a3c5e7b9d1f3a5c7e9b1d3f5a7c9e1b3d5f"
+)
+
+func TestGetChainTips(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getchaintips" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		return json.RawMessage(chainTipsFixture), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	tips, err := client.GetChainTips(context.Background())
+	if err != nil {
+		t.Fatalf("GetChainTips: %v", err)
+	}
+	want := []ChainTipStatus{ChainTipActive, ChainTipValidFork,
+		ChainTipValidFork, ChainTipUnknown}
+	if len(tips) != len(want) {
+		t.Fatalf("%d tips, want %d", len(tips), len(want))
+	}
+	for i := range tips {
+		if tips[i].Status != want[i] {
+			t.Errorf("tip %d has status %v, want %v", i, tips[i].Status,
+				want[i])
+		}
+	}
+	if tips[1].Height != 2500009 || tips[1].BranchLen != 1 ||
+		tips[1].Hash != forkTipHash {
+
+		t.Errorf("unexpected fork tip %+v", tips[1])
+	}
+	if got := ChainTipValidFork.String(); got != "valid-fork" {
+		t.Errorf("ChainTipValidFork prints as %q", got)
+	}
+}
+
+func TestIsOnBestChain(t *testing.T) {
+	// The headers are keyed by block hash.  The deposit is 3 blocks below
+	// the active tip, and the fork tip is not in the best chain.
+	headers := map[string]string{
+		depositHash:   `{"hash":"` + depositHash + `","height":2500008,"confirmations":3}`,
+		activeTipHash: `{"hash":"` + activeTipHash + `","height":2500010,"confirmations":1}`,
+		forkTipHash:   `{"hash":"` + forkTipHash + `","height":2500009,"confirmations":-1}`,
+	}
+
+	var mtx sync.Mutex
+	var tipsCalls int
+	// staleTips is how many getchaintips replies still report the active
+	// tip one block lower than getblockheader, as during a new block.
+	var staleTips int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch req.Method {
+		case "getchaintips":
+			tipsCalls++
+			if staleTips > 0 {
+				staleTips--
+				return json.RawMessage(`[{"height":2500009,"hash":"` +
+					forkTipHash + `","branchlen":0,"status":"active"}]`), nil
+			}
+			return json.RawMessage(chainTipsFixture), nil
+		case "getblockheader":
+			var hash string
+			json.Unmarshal(req.Params[0], &hash)
+			if header, ok := headers[hash]; ok {
+				return json.RawMessage(header), nil
+			}
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Block not found",
+			}
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		hash      string
+		staleTips int
+		want      bool
+		wantErr   bool
+		calls     int
+	}{
+		{"deposit", depositHash, 0, true, false, 1},
+		{"active tip", activeTipHash, 0, true, false, 1},
+		{"fork tip", forkTipHash, 0, false, false, 1},
+		{"new block while checking", depositHash, 1, true, false, 2},
+		{"best chain keeps changing", depositHash, 3, false, true, 3},
+		{"unknown block", "00000000000000000000000000000000000000000000000000000000000000ff",
+			0, false, true, 1},
+	}
+	for _, test := range tests {
+		mtx.Lock()
+		tipsCalls, staleTips = 0, test.staleTips
+		mtx.Unlock()
+
+		hash, err := chainhash.NewHashFromStr(test.hash)
+		if err != nil {
+			t.Fatalf("%s: NewHashFromStr: %v", test.name, err)
+		}
+		got, err := client.IsOnBestChain(ctx, hash)
+		if (err != nil) != test.wantErr || got != test.want {
+			t.Fatalf("%s: got %v, %v, want %v", test.name, got, err,
+				test.want)
+		}
+		mtx.Lock()
+		calls := tipsCalls
+		mtx.Unlock()
+		if calls != test.calls {
+			t.Fatalf("%s: sent getchaintips %d times, want %d", test.name,
+				calls, test.calls)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ltc_rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCloseDrains(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	client := newTestClient(t, server)
+
+	// A long-running request is in flight and another one is queued
+	// behind it on the only worker.
+	first := client.GetBlockCountAsync(context.Background())
+	queued := client.GetBlockCountAsync(context.Background())
+	waitPending(t, client, 2)
+
+	closed := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		closed <- client.Close(ctx)
+	}()
+	for !client.isClosing() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New requests are refused while the pending ones drain.
+	if _, err := client.GetBlockCount(context.Background()); err != ErrClientShutdown {
+		t.Fatalf("expected ErrClientShutdown, got %v", err)
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned before the requests drained: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release()
+	for _, future := range []FutureGetBlockCountResult{first, queued} {
+		if _, err := future.Receive(); err != nil {
+			t.Fatalf("GetBlockCount during close: %v", err)
+		}
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	client.WaitForShutdown()
+}
+
+func TestCloseContextExpired(t *testing.T) {
+	server, release := newSlowServer()
+	defer server.Close()
+	defer release()
+	client := newTestClient(t, server)
+
+	first := client.GetBlockCountAsync(context.Background())
+	waitPending(t, client, 1)
+
+	// The request is aborted once the context expires first.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if _, err := first.Receive(); err != ErrClientShutdown {
+		t.Fatalf("expected ErrClientShutdown, got %v", err)
+	}
+	release()
+	client.WaitForShutdown()
+}
+
+func TestCloseIdle(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+	client := newTestClient(t, server)
+
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	client.WaitForShutdown()
+
+	// Closing again, or shutting down, after the client is closed is
+	// harmless.
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	client.Shutdown()
+}
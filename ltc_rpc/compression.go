@@ -0,0 +1,31 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// setAcceptEncoding asks the server for gzip compressed responses on the
+// passed request when compression is enabled.
+func (c *Client) setAcceptEncoding(httpReq *http.Request) {
+	if c.config.EnableCompression {
+		httpReq.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// responseBody returns a reader for the decoded body of the passed response,
+// which decompresses it when the server compressed it.  The body of the
+// response itself must still be closed by the caller.
+func responseBody(httpResponse *http.Response) (io.Reader, error) {
+	encoding := httpResponse.Header.Get("Content-Encoding")
+	if !strings.EqualFold(encoding, "gzip") {
+		return httpResponse.Body, nil
+	}
+	return gzip.NewReader(httpResponse.Body)
+}
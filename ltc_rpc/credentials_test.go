@@ -0,0 +1,153 @@
+package ltc_rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// rotatingCredentials is a mock secret store whose password can be rotated.
+// It serves as both the CredentialsProvider of a client and the check of a
+// test server.
+type rotatingCredentials struct {
+	mtx      sync.Mutex
+	pass     string
+	err      error
+	fetches  int
+	requests int
+}
+
+// rotate replaces the password.
+func (r *rotatingCredentials) rotate(pass string) {
+	r.mtx.Lock()
+	r.pass = pass
+	r.mtx.Unlock()
+}
+
+// provide is the CredentialsProvider returning the current password.
+func (r *rotatingCredentials) provide(ctx context.Context) (string, string, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.fetches++
+	return "user", r.pass, r.err
+}
+
+// counts returns the number of times the credentials were fetched and the
+// number of requests the server received.
+func (r *rotatingCredentials) counts() (fetches, requests int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.fetches, r.requests
+}
+
+// newServer starts a test server which answers getblockcount with 100 to
+// requests authenticated with the current password, and rejects the others.
+// When reject is set, every request is rejected.
+func (r *rotatingCredentials) newServer(t *testing.T, reject bool) *httptest.Server {
+	server := newUnstartedTestServer(t, blockCountHandler)
+	handler := server.Config.Handler
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mtx.Lock()
+		r.requests++
+		user, pass, _ := req.BasicAuth()
+		ok := !reject && user == "user" && pass == r.pass
+		r.mtx.Unlock()
+		if !ok {
+			http.Error(w, "", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+	server.Start()
+	return server
+}
+
+// newClient returns a client authenticating with the credentials.
+func (r *rotatingCredentials) newClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+
+	config := testConnConfig(server)
+	config.CredentialsProvider = r.provide
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+func TestCredentialsProviderRotation(t *testing.T) {
+	creds := &rotatingCredentials{pass: "first"}
+	server := creds.newServer(t, false)
+	defer server.Close()
+	client := creds.newClient(t, server)
+	defer stopClient(client)
+
+	// The credentials are cached between requests.
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetBlockCount(context.Background()); err != nil {
+			t.Fatalf("GetBlockCount: %v", err)
+		}
+	}
+	if fetches, requests := creds.counts(); fetches != 1 || requests != 3 {
+		t.Fatalf("%d fetches for %d requests, want 1 for 3", fetches, requests)
+	}
+
+	// After a rotation the rejected request is sent again with the new
+	// password, which is used from then on.
+	creds.rotate("second")
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetBlockCount(context.Background()); err != nil {
+			t.Fatalf("GetBlockCount after rotation: %v", err)
+		}
+	}
+	if fetches, requests := creds.counts(); fetches != 2 || requests != 6 {
+		t.Fatalf("%d fetches for %d requests, want 2 for 6", fetches, requests)
+	}
+}
+
+func TestCredentialsProviderRejected(t *testing.T) {
+	creds := &rotatingCredentials{pass: "pass"}
+	server := creds.newServer(t, true)
+	defer server.Close()
+	client := creds.newClient(t, server)
+	defer stopClient(client)
+
+	// Fresh credentials are only fetched once before giving up.
+	if _, err := client.GetBlockCount(context.Background()); err != ErrInvalidAuth {
+		t.Fatalf("expected ErrInvalidAuth, got %v", err)
+	}
+	if fetches, requests := creds.counts(); fetches != 2 || requests != 2 {
+		t.Fatalf("%d fetches for %d requests, want 2 for 2", fetches, requests)
+	}
+}
+
+func TestCredentialsProviderError(t *testing.T) {
+	errVault := errors.New("vault sealed")
+	creds := &rotatingCredentials{err: errVault}
+	server := creds.newServer(t, false)
+	defer server.Close()
+	client := creds.newClient(t, server)
+	defer stopClient(client)
+
+	_, err := client.GetBlockCount(context.Background())
+	var providerErr *ErrCredentialsProvider
+	if !errors.As(err, &providerErr) || !errors.Is(err, errVault) {
+		t.Fatalf("expected ErrCredentialsProvider, got %v", err)
+	}
+
+	// Failures are not cached, so the provider is asked again once it
+	// recovers.
+	creds.mtx.Lock()
+	creds.err = nil
+	creds.pass = "pass"
+	creds.mtx.Unlock()
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount after the provider recovered: %v", err)
+	}
+	if fetches, requests := creds.counts(); fetches != 2 || requests != 1 {
+		t.Fatalf("%d fetches for %d requests, want 2 for 1", fetches, requests)
+	}
+}
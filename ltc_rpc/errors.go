@@ -0,0 +1,259 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+// HTTPError describes an HTTP POST mode reply which did not carry a JSON-RPC
+// response, such as an error page from a proxy in front of the RPC server.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the reply.
+	StatusCode int
+
+	// Body is the raw body of the reply.
+	Body string
+}
+
+// Error satisfies the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("status code: %d, response: %q", e.StatusCode, e.Body)
+}
+
+// The reject reasons below are reported by the various node implementations
+// when a transaction is not accepted.  Bitcoin Core and its forks report the
+// short reject reason codes while btcd and bchd report descriptive messages.
+var (
+	alreadyInMempoolReasons = []string{
+		"txn-already-in-mempool",
+		"txn-already-known",
+		"already have transaction",
+	}
+
+	missingInputsReasons = []string{
+		"missing inputs",
+		"bad-txns-inputs-missingorspent",
+		"unknown or fully-spent transaction",
+	}
+
+	// blockFilterIndexDisabledReasons are reported by nodes running
+	// without the block filter index asked for.
+	blockFilterIndexDisabledReasons = []string{
+		"index is not enabled for filtertype",
+	}
+
+	insufficientFeeReasons = []string{
+		"insufficient fee",
+		"min relay fee not met",
+		"mempool min fee not met",
+		"insufficient priority",
+		"under the required amount",
+	}
+
+	// scanInProgressReasons are reported by nodes asked to start a UTXO
+	// set scan while another one is running.
+	scanInProgressReasons = []string{
+		"scan already in progress",
+	}
+
+	// txNotInMempoolReasons are reported by getmempoolentry for
+	// transactions which are not in the memory pool.
+	txNotInMempoolReasons = []string{
+		"transaction not in mempool",
+	}
+
+	// insufficientFundsReasons are reported by wallets which can not
+	// cover the outputs of a transaction they are asked to fund.
+	insufficientFundsReasons = []string{
+		"insufficient funds",
+	}
+)
+
+// rpcErrorContains returns whether the passed error is an RPC error returned
+// by the server whose message contains any of the passed reasons, ignoring
+// case.
+func rpcErrorContains(err error, reasons []string) bool {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	for _, reason := range reasons {
+		if strings.Contains(msg, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAlreadyInMempool returns whether the passed error is the server rejecting
+// a transaction because it is already in its memory pool.
+func IsAlreadyInMempool(err error) bool {
+	return rpcErrorContains(err, alreadyInMempoolReasons)
+}
+
+// IsMissingInputs returns whether the passed error is the server rejecting a
+// transaction because it spends outputs which are unknown or already spent.
+func IsMissingInputs(err error) bool {
+	return rpcErrorContains(err, missingInputsReasons)
+}
+
+// IsInsufficientFee returns whether the passed error is the server rejecting a
+// transaction because its fee is below what the server requires to relay it.
+func IsInsufficientFee(err error) bool {
+	return rpcErrorContains(err, insufficientFeeReasons)
+}
+
+// isBlockFilterIndexDisabled returns whether the passed error is the server
+// refusing a block filter query because it runs without the filter index.
+func isBlockFilterIndexDisabled(err error) bool {
+	return rpcErrorContains(err, blockFilterIndexDisabledReasons)
+}
+
+// isScanInProgress returns whether the passed error is the server refusing to
+// start a UTXO set scan because another one is running.
+func isScanInProgress(err error) bool {
+	return rpcErrorContains(err, scanInProgressReasons)
+}
+
+// The error codes below are returned by Litecoin Core for transactions passed
+// to sendrawtransaction which are not accepted.
+const (
+	// rpcVerifyRejected is returned for transactions which are rejected
+	// by the memory pool policy or the consensus rules.
+	rpcVerifyRejected btcjson.RPCErrorCode = -26
+
+	// rpcVerifyAlreadyInChain is returned for transactions which are
+	// already in the block chain.
+	rpcVerifyAlreadyInChain btcjson.RPCErrorCode = -27
+)
+
+// ErrTxRejected describes a transaction the server refused to accept to its
+// memory pool.
+type ErrTxRejected struct {
+	// Reason is the reject reason reported by the server, such as
+	// "min relay fee not met" or "txn-mempool-conflict".
+	Reason string
+
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxRejected) Error() string {
+	return "transaction rejected: " + e.Reason
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxRejected) Unwrap() error {
+	return e.Err
+}
+
+// ErrTxAlreadyInChain describes a transaction which the server did not accept
+// because it is already in the block chain.
+type ErrTxAlreadyInChain struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxAlreadyInChain) Error() string {
+	return "transaction already in block chain"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxAlreadyInChain) Unwrap() error {
+	return e.Err
+}
+
+// sendRawTransactionError converts the errors Litecoin Core returns for
+// transactions it did not accept to an *ErrTxRejected or an
+// *ErrTxAlreadyInChain.  Other errors are returned unchanged.
+func sendRawTransactionError(err error) error {
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) {
+		return err
+	}
+	switch rpcErr.Code {
+	case rpcVerifyRejected:
+		// Older nodes prefix the reason with the numeric reject code, as
+		// in "66: insufficient priority".
+		reason := rpcErr.Message
+		if i := strings.Index(reason, ": "); i > 0 &&
+			strings.Trim(reason[:i], "0123456789") == "" {
+
+			reason = reason[i+2:]
+		}
+		return &ErrTxRejected{Reason: reason, Err: err}
+	case rpcVerifyAlreadyInChain:
+		return &ErrTxAlreadyInChain{Err: err}
+	}
+	return err
+}
+
+// ErrInsufficientFunds describes the wallet of the server failing to fund a
+// transaction because its spendable balance does not cover the outputs and
+// the fee.
+type ErrInsufficientFunds struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrInsufficientFunds) Error() string {
+	return "insufficient funds: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrInsufficientFunds) Unwrap() error {
+	return e.Err
+}
+
+// isInsufficientFunds returns whether the passed error is the wallet of the
+// server reporting insufficient funds.
+func isInsufficientFunds(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) &&
+		rpcErr.Code == btcjson.ErrRPCWalletInsufficientFunds {
+
+		return true
+	}
+	return rpcErrorContains(err, insufficientFundsReasons)
+}
+
+// ErrTxNotInMempool describes a transaction the server was asked about which
+// is not in its memory pool, either because it was never accepted or because
+// it was mined, evicted or replaced since.
+type ErrTxNotInMempool struct {
+	// Err is the error returned by the server.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrTxNotInMempool) Error() string {
+	return "transaction not in memory pool"
+}
+
+// Unwrap returns the error returned by the server.
+func (e *ErrTxNotInMempool) Unwrap() error {
+	return e.Err
+}
+
+// isTxNotInMempool returns whether the passed error is the server reporting a
+// transaction it was asked about is not in its memory pool.
+func isTxNotInMempool(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) &&
+		rpcErr.Code != btcjson.ErrRPCInvalidAddressOrKey {
+
+		return false
+	}
+	return rpcErrorContains(err, txNotInMempoolReasons)
+}
@@ -23,7 +23,8 @@ func TestNew(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	ctx, _ = context.WithTimeout(ctx, time.Second)
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
 
 	fmt.Println(client.GetBlockCount(ctx))
 
@@ -0,0 +1,137 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Estimate modes of EstimateSmartFee.  An empty mode leaves the mode to the
+// node and is not sent at all.
+const (
+	// EstimateModeEconomical asks for a fee rate which is lower but more
+	// likely to be outbid by later transactions.
+	EstimateModeEconomical = "ECONOMICAL"
+
+	// EstimateModeConservative asks for a fee rate which is higher but
+	// more likely to get the transaction confirmed in time.
+	EstimateModeConservative = "CONSERVATIVE"
+)
+
+// EstimateSmartFeeResult models the data returned by the estimatesmartfee
+// command.
+type EstimateSmartFeeResult struct {
+	// FeeRate is the estimated fee rate in LTC per kilo virtual byte as
+	// sent by the node, which is read exactly with ParseAmountSat.  It is
+	// nil when the node has no estimate, in which case Errors lists the
+	// reasons.
+	FeeRate *json.Number `json:"feerate,omitempty"`
+	Errors  []string     `json:"errors,omitempty"`
+
+	// Blocks is the number of blocks the estimate is for, which may differ
+	// from the requested target.
+	Blocks int64 `json:"blocks"`
+}
+
+// ErrFeeEstimateUnavailable describes the condition where the node has no fee
+// rate estimate, as is the case for freshly started nodes which have not seen
+// enough blocks yet.
+type ErrFeeEstimateUnavailable struct {
+	// Errors are the reasons given by the node, if any.
+	Errors []string
+}
+
+// Error satisfies the error interface.
+func (e *ErrFeeEstimateUnavailable) Error() string {
+	if len(e.Errors) == 0 {
+		return "no fee rate estimate"
+	}
+	return "no fee rate estimate: " + strings.Join(e.Errors, "; ")
+}
+
+// litoshiPerVByte converts the passed fee rate in litoshis per kilo virtual
+// byte to one per virtual byte.  Fractions of a litoshi are rounded up, so the
+// fee rate is never underestimated and the 1000 lit/kvB default minimum relay
+// fee of Litecoin, or anything below, does not become 0.
+func litoshiPerVByte(perKvB int64) int64 {
+	return (perKvB + 999) / 1000
+}
+
+// FutureEstimateSmartFeeResult is a future promise to deliver the result of a
+// EstimateSmartFeeAsync RPC invocation (or an applicable error).
+type FutureEstimateSmartFeeResult chan *response
+
+// Receive waits for the response promised by the future and returns the fee
+// estimate provided by the server.
+func (r FutureEstimateSmartFeeResult) Receive() (*EstimateSmartFeeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an estimatesmartfee result object.
+	var feeResult EstimateSmartFeeResult
+	if err := json.Unmarshal(res, &feeResult); err != nil {
+		return nil, err
+	}
+	return &feeResult, nil
+}
+
+// EstimateSmartFeeAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See EstimateSmartFee for the blocking version and more details.
+func (c *Client) EstimateSmartFeeAsync(ctx context.Context, confTarget int64, mode string) FutureEstimateSmartFeeResult {
+	params := []interface{}{confTarget}
+	if mode != "" {
+		params = append(params, mode)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureEstimateSmartFeeResult(c.RawRequestAsync(ctx,
+		"estimatesmartfee", rawParams))
+}
+
+// EstimateSmartFee returns the fee rate in LTC per kilo virtual byte the node
+// estimates a transaction needs to begin confirmation within confTarget
+// blocks.  The mode is EstimateModeEconomical, EstimateModeConservative or
+// empty to leave it to the node.  Nodes without an estimate return a result
+// without a fee rate which lists the reasons in Errors.
+//
+// See FeeRateLitoshiPerVByte to get the fee rate in litoshis per virtual byte.
+func (c *Client) EstimateSmartFee(ctx context.Context, confTarget int64, mode string) (*EstimateSmartFeeResult, error) {
+	return c.EstimateSmartFeeAsync(ctx, confTarget, mode).Receive()
+}
+
+// FeeRateLitoshiPerVByte returns the fee rate in litoshis per virtual byte the
+// node estimates a transaction needs to begin confirmation within confTarget
+// blocks, leaving the estimate mode to the node.  Fractions of a litoshi are
+// rounded up.  An *ErrFeeEstimateUnavailable is returned when the node has no
+// estimate.
+func (c *Client) FeeRateLitoshiPerVByte(ctx context.Context, confTarget int64) (int64, error) {
+	result, err := c.EstimateSmartFee(ctx, confTarget, "")
+	if err != nil {
+		return 0, err
+	}
+	if result.FeeRate == nil || len(result.Errors) != 0 {
+		return 0, &ErrFeeEstimateUnavailable{Errors: result.Errors}
+	}
+	perKvB, err := ParseAmountSat(*result.FeeRate)
+	if err != nil {
+		return 0, err
+	}
+	if perKvB <= 0 {
+		return 0, &ErrFeeEstimateUnavailable{}
+	}
+	return litoshiPerVByte(perKvB), nil
+}
@@ -0,0 +1,104 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+func TestEstimateSmartFee(t *testing.T) {
+	// The fixtures are keyed by the confirmation target they are returned
+	// for.
+	fixtures := map[int64]string{
+		2:    `{"feerate":0.00012345,"blocks":2}`,
+		6:    `{"feerate":0.00001,"blocks":6}`,
+		12:   `{"feerate":0.00000001,"blocks":12}`,
+		1008: `{"errors":["Insufficient data or no feerate found"],"blocks":0}`,
+	}
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		if req.Method != "estimatesmartfee" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		var confTarget int64
+		json.Unmarshal(req.Params[0], &confTarget)
+		return json.RawMessage(fixtures[confTarget]), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	result, err := client.EstimateSmartFee(ctx, 2, EstimateModeConservative)
+	if err != nil {
+		t.Fatalf("EstimateSmartFee: %v", err)
+	}
+	if result.FeeRate == nil || result.FeeRate.String() != "0.00012345" ||
+		result.Blocks != 2 || len(result.Errors) != 0 {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+	result, err = client.EstimateSmartFee(ctx, 1008, "")
+	if err != nil {
+		t.Fatalf("EstimateSmartFee: %v", err)
+	}
+	if result.FeeRate != nil || len(result.Errors) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	tests := []struct {
+		confTarget int64
+		want       int64
+	}{
+		{2, 13},
+		// The default minimum relay fee of Litecoin is exactly 1 lit/vB.
+		{6, 1},
+		// Fractions of a litoshi round up rather than to 0.
+		{12, 1},
+	}
+	for _, test := range tests {
+		rate, err := client.FeeRateLitoshiPerVByte(ctx, test.confTarget)
+		if err != nil {
+			t.Fatalf("FeeRateLitoshiPerVByte(%d): %v", test.confTarget, err)
+		}
+		if rate != test.want {
+			t.Fatalf("FeeRateLitoshiPerVByte(%d): got %d lit/vB, want %d",
+				test.confTarget, rate, test.want)
+		}
+	}
+
+	_, err = client.FeeRateLitoshiPerVByte(ctx, 1008)
+	var unavailable *ErrFeeEstimateUnavailable
+	if !errors.As(err, &unavailable) || len(unavailable.Errors) != 1 {
+		t.Fatalf("got error %v, want ErrFeeEstimateUnavailable", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`estimatesmartfee[2,"CONSERVATIVE"]`,
+		`estimatesmartfee[1008]`,
+		`estimatesmartfee[2]`,
+		`estimatesmartfee[6]`,
+		`estimatesmartfee[12]`,
+		`estimatesmartfee[1008]`,
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got requests %v, want %v", requests, want)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Fatalf("got request %s, want %s", requests[i], want[i])
+		}
+	}
+}
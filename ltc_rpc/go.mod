@@ -5,6 +5,8 @@ go 1.13
 replace github.com/ltcsuite/ltcutil v0.0.0-20190507082654-23cdfa9fcc3d => github.com/ltcsuite/ltcutil v0.0.0-20190507133322-23cdfa9fcc3d
 
 require (
+	github.com/btcsuite/go-socks v0.0.0-20170105172521-4720035b7bfd
 	github.com/ltcsuite/ltcd v0.0.0-20190519120615-e27ee083f08f
 	github.com/ltcsuite/ltcutil v0.0.0-20190507082654-23cdfa9fcc3d
+	github.com/sectoken-dev/tools/chainclient v0.1.0
 )
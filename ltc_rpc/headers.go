@@ -0,0 +1,131 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// walkHeadersConcurrency is how many requests WalkHeaders has outstanding at
+// a time.
+const walkHeadersConcurrency = 8
+
+// WalkHeaders returns up to count block headers ending with the one of
+// fromHash, oldest first, following the previous block hashes backwards.
+// Fewer are returned when the walk reaches the genesis block.
+//
+// When the block is in the best block chain, the hashes and headers of the
+// blocks before it are fetched by height with several requests outstanding at
+// a time, and then checked to link up.  When they do not, such as for a block
+// of a side chain or after a reorganization while walking, the headers are
+// fetched one at a time by their previous block hashes instead.
+func (c *Client) WalkHeaders(ctx context.Context, fromHash *chainhash.Hash, count int) ([]*wire.BlockHeader, error) {
+	if count < 1 {
+		return nil, nil
+	}
+	verbose, err := c.GetBlockHeaderVerbose(ctx, fromHash)
+	if err != nil {
+		return nil, err
+	}
+	if int64(count) > int64(verbose.Height)+1 {
+		count = int(verbose.Height) + 1
+	}
+
+	// Blocks outside the best block chain have negative confirmations, and
+	// their height does not lead to their ancestors.
+	if verbose.Confirmations >= 0 {
+		headers, err := c.walkHeadersByHeight(ctx, int64(verbose.Height), count)
+		if err != nil {
+			return nil, err
+		}
+		if headersLink(headers, fromHash) {
+			return headers, nil
+		}
+	}
+	return c.walkHeadersByHash(ctx, fromHash, count)
+}
+
+// walkHeadersByHeight returns the count headers of the best block chain up to
+// the given height, oldest first.
+func (c *Client) walkHeadersByHeight(ctx context.Context, height int64, count int) ([]*wire.BlockHeader, error) {
+	concurrency := walkHeadersConcurrency
+	if concurrency > count {
+		concurrency = count
+	}
+
+	first := height - int64(count) + 1
+	headers := make([]*wire.BlockHeader, count)
+	errs := make([]error, count)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				hash, err := c.GetBlockHashAsync(ctx,
+					first+int64(i)).Receive()
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				headers[i], errs[i] = c.GetBlockHeaderAsync(ctx,
+					hash).Receive()
+				if errs[i] == nil && headers[i].BlockHash() != *hash {
+					errs[i] = fmt.Errorf("header of block %v "+
+						"hashes to %v", hash, headers[i].BlockHash())
+				}
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// walkHeadersByHash returns count headers ending with the one of fromHash,
+// oldest first, fetching them one at a time by their previous block hashes.
+func (c *Client) walkHeadersByHash(ctx context.Context, fromHash *chainhash.Hash, count int) ([]*wire.BlockHeader, error) {
+	headers := make([]*wire.BlockHeader, count)
+	hash := *fromHash
+	for i := count - 1; i >= 0; i-- {
+		header, err := c.GetBlockHeader(ctx, &hash)
+		if err != nil {
+			return nil, err
+		}
+		if header.BlockHash() != hash {
+			return nil, fmt.Errorf("header of block %v hashes to %v",
+				hash, header.BlockHash())
+		}
+		headers[i] = header
+		hash = header.PrevBlock
+	}
+	return headers, nil
+}
+
+// headersLink returns whether each of the headers, oldest first, follows the
+// one before it and the last one is the header of lastHash.
+func headersLink(headers []*wire.BlockHeader, lastHash *chainhash.Hash) bool {
+	for i := 1; i < len(headers); i++ {
+		if headers[i].PrevBlock != headers[i-1].BlockHash() {
+			return false
+		}
+	}
+	return headers[len(headers)-1].BlockHash() == *lastHash
+}
@@ -0,0 +1,275 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// headerChain is a synthetic chain of block headers with the regtest
+// difficulty, whose proof of work is not valid.
+type headerChain struct {
+	headers []*wire.BlockHeader
+
+	// byHash holds the height of each header, and best whether it is in
+	// the best chain.
+	byHash map[chainhash.Hash]int
+	best   map[chainhash.Hash]bool
+}
+
+// newHeaderChain returns a chain of n headers, plus a side chain block
+// following the one before the tip.
+func newHeaderChain(n int) (*headerChain, *wire.BlockHeader) {
+	chain := &headerChain{
+		byHash: make(map[chainhash.Hash]int),
+		best:   make(map[chainhash.Hash]bool),
+	}
+	var prevBlock chainhash.Hash
+	for i := 0; i < n; i++ {
+		header := &wire.BlockHeader{
+			Version:    0x20000000,
+			PrevBlock:  prevBlock,
+			MerkleRoot: chainhash.Hash{byte(i), 1},
+			Timestamp:  time.Unix(1296688602+int64(i)*150, 0),
+			Bits:       0x207fffff,
+			Nonce:      uint32(i),
+		}
+		prevBlock = header.BlockHash()
+		chain.headers = append(chain.headers, header)
+		chain.byHash[prevBlock] = i
+		chain.best[prevBlock] = true
+	}
+
+	fork := *chain.headers[n-1]
+	fork.Nonce = 1000
+	chain.byHash[fork.BlockHash()] = n - 1
+	return chain, &fork
+}
+
+// replies returns the replies of the server to the getblockhash and
+// getblockheader requests for the chain and the side chain block.
+func (chain *headerChain) replies(fork *wire.BlockHeader) map[string]string {
+	replies := make(map[string]string)
+	tip := len(chain.headers) - 1
+	headers := append(chain.headers[:len(chain.headers):len(chain.headers)],
+		fork)
+	for i, header := range headers {
+		hash := header.BlockHash()
+		var buf bytes.Buffer
+		header.Serialize(&buf)
+		replies[fmt.Sprintf(`getblockheader["%v",false]`, hash)] =
+			`"` + hex.EncodeToString(buf.Bytes()) + `"`
+
+		height := chain.byHash[hash]
+		confirmations := tip - height + 1
+		if !chain.best[hash] {
+			confirmations = -1
+		} else {
+			replies[fmt.Sprintf("getblockhash[%d]", i)] = `"` +
+				hash.String() + `"`
+		}
+		next := ""
+		if chain.best[hash] && height < tip {
+			next = fmt.Sprintf(`"nextblockhash":"%v",`,
+				chain.headers[height+1].BlockHash())
+		}
+		prev := ""
+		if height > 0 {
+			prev = fmt.Sprintf(`"previousblockhash":"%v",`,
+				header.PrevBlock)
+		}
+		replies[fmt.Sprintf(`getblockheader["%v",true]`, hash)] = fmt.Sprintf(
+			`{"hash":"%v","confirmations":%d,"height":%d,"version":%d,`+
+				`"versionHex":"%08x","merkleroot":"%v","time":%d,`+
+				`"mediantime":%d,"nonce":%d,"bits":"%08x",`+
+				`"difficulty":4.656542373906925e-10,"chainwork":"%064x",`+
+				`%s%s"nTx":1}`,
+			hash, confirmations, height, header.Version, header.Version,
+			header.MerkleRoot, header.Timestamp.Unix(),
+			header.Timestamp.Unix(), header.Nonce, header.Bits,
+			(height+1)*2, prev, next)
+	}
+	return replies
+}
+
+func newHeaderChainServer(t *testing.T, fork *wire.BlockHeader, chain *headerChain) (*Client, func() []string, func()) {
+	replies := chain.replies(fork)
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		request := req.Method + string(p)
+		mtx.Lock()
+		requests = append(requests, request)
+		mtx.Unlock()
+		reply, ok := replies[request]
+		if !ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found",
+			}
+		}
+		return json.RawMessage(reply), nil
+	})
+	client := newTestClient(t, server)
+	sent := func() []string {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]string(nil), requests...)
+	}
+	return client, sent, func() {
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestGetBlockHeaderVerbose(t *testing.T) {
+	chain, fork := newHeaderChain(10)
+	client, _, stop := newHeaderChainServer(t, fork, chain)
+	defer stop()
+
+	want := chain.headers[5]
+	hash := want.BlockHash()
+	result, err := client.GetBlockHeaderVerbose(context.Background(), &hash)
+	if err != nil {
+		t.Fatalf("GetBlockHeaderVerbose: %v", err)
+	}
+	if result.Height != 5 || result.Confirmations != 5 ||
+		result.PreviousHash != want.PrevBlock.String() ||
+		result.NextHash != chain.headers[6].BlockHash().String() ||
+		result.Bits != "207fffff" || result.NTx != 1 ||
+		result.MedianTime != want.Timestamp.Unix() {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	header, err := result.Header()
+	if err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	if header.BlockHash() != hash || header.Bits != want.Bits ||
+		!header.Timestamp.Equal(want.Timestamp) {
+
+		t.Fatalf("got header %+v, want %+v", header, want)
+	}
+
+	// The scrypt hash the proof of work is checked against differs from the
+	// block hash.
+	powHash, err := header.PowHash()
+	if err != nil {
+		t.Fatalf("PowHash: %v", err)
+	}
+	if *powHash == hash {
+		t.Fatalf("proof of work hash is the block hash")
+	}
+
+	result.Nonce++
+	if _, err := result.Header(); err == nil {
+		t.Fatalf("Header accepted a header not matching the hash")
+	}
+}
+
+func TestWalkHeaders(t *testing.T) {
+	chain, fork := newHeaderChain(10)
+	tipHash := chain.headers[9].BlockHash()
+	forkHash := fork.BlockHash()
+
+	tests := []struct {
+		name  string
+		from  chainhash.Hash
+		count int
+		want  []*wire.BlockHeader
+		// byHash is whether the headers are walked by their previous
+		// block hashes.
+		byHash bool
+	}{
+		{"whole chain", tipHash, 10, chain.headers, false},
+		{"part of the chain", tipHash, 4, chain.headers[6:], false},
+		{"middle of the chain", chain.headers[5].BlockHash(), 3, chain.headers[3:6], false},
+		{"beyond genesis", tipHash, 25, chain.headers, false},
+		{"genesis", chain.headers[0].BlockHash(), 5, chain.headers[:1], false},
+		{"side chain", forkHash, 5, append(append([]*wire.BlockHeader(nil),
+			chain.headers[5:9]...), fork), true},
+	}
+	for _, test := range tests {
+		client, sent, stop := newHeaderChainServer(t, fork, chain)
+		headers, err := client.WalkHeaders(context.Background(), &test.from,
+			test.count)
+		requests := sent()
+		stop()
+		if err != nil {
+			t.Fatalf("%s: WalkHeaders: %v", test.name, err)
+		}
+		if len(headers) != len(test.want) {
+			t.Fatalf("%s: got %d headers, want %d", test.name,
+				len(headers), len(test.want))
+		}
+		for i := range test.want {
+			if headers[i].BlockHash() != test.want[i].BlockHash() {
+				t.Fatalf("%s: header %d is %v, want %v", test.name, i,
+					headers[i].BlockHash(), test.want[i].BlockHash())
+			}
+		}
+
+		var byHeight int
+		for _, request := range requests {
+			if strings.HasPrefix(request, "getblockhash") {
+				byHeight++
+			}
+		}
+		if test.byHash && byHeight != 0 {
+			t.Fatalf("%s: side chain walked by height", test.name)
+		}
+		if !test.byHash && byHeight != len(test.want) {
+			t.Fatalf("%s: sent %d getblockhash requests, want %d",
+				test.name, byHeight, len(test.want))
+		}
+	}
+}
+
+func TestWalkHeadersReorg(t *testing.T) {
+	chain, fork := newHeaderChain(10)
+
+	// The best chain was reorganized to the side chain block after its
+	// verbose header was fetched, so the block at its height is the fork.
+	// The walk falls back to following the previous block hashes.
+	forkHash := fork.BlockHash()
+	replies := chain.replies(fork)
+	tipHash := chain.headers[9].BlockHash()
+	replies["getblockhash[9]"] = `"` + forkHash.String() + `"`
+
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		reply, ok := replies[req.Method+string(p)]
+		if !ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found",
+			}
+		}
+		return json.RawMessage(reply), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	headers, err := client.WalkHeaders(context.Background(), &tipHash, 3)
+	if err != nil {
+		t.Fatalf("WalkHeaders: %v", err)
+	}
+	if len(headers) != 3 || headers[2].BlockHash() != tipHash ||
+		headers[0].BlockHash() != chain.headers[7].BlockHash() {
+
+		t.Fatalf("unexpected headers %v", headers)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+// PingResult describes a successful round trip to the RPC server.
+type PingResult struct {
+	// Method is the RPC that was used to reach the server.
+	Method string
+
+	// Latency is the round-trip time of that RPC.
+	Latency time.Duration
+}
+
+// Ping issues a lightweight RPC to verify the server is reachable and accepts
+// the configured credentials.  The uptime RPC is used when the server supports
+// it, falling back to getblockcount for servers which do not.
+func (c *Client) Ping(ctx context.Context) (*PingResult, error) {
+	for _, method := range []string{"uptime", "getblockcount"} {
+		start := time.Now()
+		_, err := c.RawRequest(ctx, method, nil)
+		if isMethodNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &PingResult{Method: method, Latency: time.Since(start)}, nil
+	}
+
+	return nil, errors.New("server supports neither uptime nor " +
+		"getblockcount")
+}
+
+// HealthStatus classifies the outcome of a health check.
+type HealthStatus int
+
+// These constants describe the possible outcomes of a health check.
+const (
+	// HealthOK indicates the server answered the health check.
+	HealthOK HealthStatus = iota
+
+	// HealthAuthFailure indicates the server rejected the credentials.
+	HealthAuthFailure
+
+	// HealthConnectionRefused indicates nothing is listening at the
+	// configured address.
+	HealthConnectionRefused
+
+	// HealthRPCError indicates the server was reached but answered the
+	// health check with an RPC error.
+	HealthRPCError
+
+	// HealthUnreachable indicates any other failure to reach the server,
+	// such as a timeout or a name resolution error.
+	HealthUnreachable
+)
+
+// Map of HealthStatus values back to their constant names for pretty printing.
+var healthStatusStrings = map[HealthStatus]string{
+	HealthOK:                "HealthOK",
+	HealthAuthFailure:       "HealthAuthFailure",
+	HealthConnectionRefused: "HealthConnectionRefused",
+	HealthRPCError:          "HealthRPCError",
+	HealthUnreachable:       "HealthUnreachable",
+}
+
+// String returns the HealthStatus in human-readable form.
+func (s HealthStatus) String() string {
+	if str, ok := healthStatusStrings[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("Unknown HealthStatus (%d)", int(s))
+}
+
+// HealthError is returned by Healthy when the health check fails.  Status
+// classifies the failure and Err is the underlying error.
+type HealthError struct {
+	Status HealthStatus
+	Err    error
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *HealthError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Status, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HealthError) Unwrap() error {
+	return e.Err
+}
+
+// Healthy reports whether the RPC server is reachable and accepts the
+// configured credentials.  When it is not, the returned error is a
+// *HealthError classifying the failure.
+func (c *Client) Healthy(ctx context.Context) (bool, error) {
+	_, err := c.Ping(ctx)
+	if err != nil {
+		return false, &HealthError{Status: classifyHealth(err), Err: err}
+	}
+	return true, nil
+}
+
+// classifyHealth returns the HealthStatus describing the passed error.
+func classifyHealth(err error) HealthStatus {
+	var rpcErr *btcjson.RPCError
+	switch {
+	case err == nil:
+		return HealthOK
+	case errors.Is(err, ErrInvalidAuth):
+		return HealthAuthFailure
+	case errors.As(err, &rpcErr):
+		return HealthRPCError
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return HealthConnectionRefused
+	}
+	return HealthUnreachable
+}
+
+// isMethodNotFound returns whether the passed error is the RPC error servers
+// reply with for methods they do not implement.
+func isMethodNotFound(err error) bool {
+	var rpcErr *btcjson.RPCError
+	return errors.As(err, &rpcErr) &&
+		rpcErr.Code == btcjson.ErrRPCMethodNotFound.Code
+}
@@ -0,0 +1,121 @@
+package ltc_rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+func TestPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods map[string]interface{}
+		want    string
+	}{
+		{
+			name:    "uptime",
+			methods: map[string]interface{}{"uptime": 1234, "getblockcount": 1},
+			want:    "uptime",
+		},
+		{
+			name:    "getblockcount fallback",
+			methods: map[string]interface{}{"getblockcount": 1},
+			want:    "getblockcount",
+		},
+	}
+
+	for _, test := range tests {
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			if result, ok := test.methods[req.Method]; ok {
+				return result, nil
+			}
+			return nil, btcjson.ErrRPCMethodNotFound
+		})
+		client := newTestClient(t, server)
+
+		res, err := client.Ping(context.Background())
+		if err != nil {
+			t.Errorf("%s: Ping: %v", test.name, err)
+		} else if res.Method != test.want {
+			t.Errorf("%s: pinged with %q, want %q", test.name,
+				res.Method, test.want)
+		}
+		healthy, err := client.Healthy(context.Background())
+		if !healthy || err != nil {
+			t.Errorf("%s: Healthy: %v, %v", test.name, healthy, err)
+		}
+
+		stopClient(client)
+		server.Close()
+	}
+}
+
+func TestHealthyClassification(t *testing.T) {
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+
+	rpcError := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return nil, btcjson.NewRPCError(btcjson.ErrRPCMisc, "Loading block index...")
+	})
+	defer rpcError.Close()
+
+	// Start and immediately stop a server to get an address nothing is
+	// listening on.
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refusedHost := strings.TrimPrefix(refused.URL, "http://")
+	refused.Close()
+
+	tests := []struct {
+		name   string
+		host   string
+		status HealthStatus
+	}{
+		{"auth", strings.TrimPrefix(unauthorized.URL, "http://"), HealthAuthFailure},
+		{"rpc error", strings.TrimPrefix(rpcError.URL, "http://"), HealthRPCError},
+		{"refused", refusedHost, HealthConnectionRefused},
+	}
+
+	for _, test := range tests {
+		client, err := New(&ConnConfig{
+			Host:         test.host,
+			HTTPPostMode: true,
+			DisableTLS:   true,
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+
+		healthy, err := client.Healthy(context.Background())
+		var healthErr *HealthError
+		switch {
+		case healthy:
+			t.Errorf("%s: reported healthy", test.name)
+		case !errors.As(err, &healthErr):
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		case healthErr.Status != test.status:
+			t.Errorf("%s: status %v, want %v", test.name,
+				healthErr.Status, test.status)
+		}
+		stopClient(client)
+	}
+
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(unauthorized.URL, "http://"),
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	if _, err := client.GetBlockCount(context.Background()); err != ErrInvalidAuth {
+		t.Fatalf("expected ErrInvalidAuth, got %v", err)
+	}
+}
@@ -10,11 +10,13 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"sync"
@@ -22,6 +24,7 @@ import (
 	"time"
 
 	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
 )
 
 var (
@@ -67,6 +70,21 @@ var (
 		"connected")
 )
 
+// ErrResponseTooLarge describes the condition where the server replied to a
+// request with more than the configured maximum number of bytes.
+type ErrResponseTooLarge struct {
+	// Method is the method of the request the response belongs to.
+	Method string
+
+	// Limit is the maximum response size in bytes which was exceeded.
+	Limit int64
+}
+
+// Error satisfies the error interface.
+func (e *ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("response to %s exceeds %d bytes", e.Method, e.Limit)
+}
+
 const (
 	// sendBufferSize is the number of elements the websocket send channel
 	// can queue before blocking.
@@ -79,6 +97,19 @@ const (
 	// connectionRetryInterval is the amount of time to wait in between
 	// retries when automatically reconnecting to an RPC server.
 	connectionRetryInterval = time.Second * 5
+
+	// maxIdleConnsPerHost is the number of idle HTTP POST mode connections
+	// kept open for reuse.
+	maxIdleConnsPerHost = 10
+
+	// defaultMaxResponseBytes is the maximum size of a response when the
+	// connection configuration does not specify one.
+	defaultMaxResponseBytes = 64 << 20
+
+	// maxDrainBytes is how much of the remainder of an oversized response
+	// is read to reuse the connection.  Connections with more left are
+	// closed instead.
+	maxDrainBytes = 64 << 10
 )
 
 // sendPostDetails houses an HTTP POST request to send to an RPC server as well
@@ -87,6 +118,11 @@ const (
 type sendPostDetails struct {
 	httpRequest *http.Request
 	jsonRequest *jsonRequest
+	host        string
+
+	// finishQueue ends the span covering the time the request is queued.
+	// It is nil when the client has no tracer.
+	finishQueue FinishSpan
 }
 
 // jsonRequest holds information about a json request that is used to properly
@@ -97,6 +133,78 @@ type jsonRequest struct {
 	cmd            interface{}
 	marshalledJSON []byte
 	responseChan   chan *response
+
+	// responded is set atomically once a response has been delivered on
+	// responseChan.  Only the first response for a request is delivered.
+	responded uint32
+
+	// done is closed once a response has been delivered.  It is only
+	// created for requests whose context can be cancelled.
+	done chan struct{}
+
+	// cancel releases the resources of a context derived for the request
+	// by the client, if any.  It is called once a response is delivered.
+	cancel context.CancelFunc
+
+	// instrumentation is the hook to notify once the request finishes.
+	// It is only set when the client is configured with an
+	// Instrumentation.
+	instrumentation Instrumentation
+
+	// started is the time the request was issued.
+	started time.Time
+
+	// finished records the outcome of the request in the client
+	// statistics once it is answered.  It is nil until the request is
+	// issued.
+	finished func(method string, duration time.Duration, err error)
+
+	// release stops counting the request as pending once it is answered.
+	// It is nil until the request is counted.
+	release func()
+
+	// finishSpan ends the span covering the request.  It is nil when the
+	// client has no tracer.
+	finishSpan FinishSpan
+
+	// header holds extra headers an interceptor added to the request.
+	header http.Header
+
+	// intercepted is set for requests which were already passed through
+	// the configured interceptors.
+	intercepted bool
+}
+
+// respond delivers the passed response to the request's response channel.
+// Only the first response is delivered.  Any later responses, such as a reply
+// from the server arriving after the caller's context has already expired,
+// are discarded so the sender never blocks on a full channel.
+//
+// This function is safe for concurrent access.
+func (jReq *jsonRequest) respond(resp *response) {
+	if !atomic.CompareAndSwapUint32(&jReq.responded, 0, 1) {
+		return
+	}
+	jReq.responseChan <- resp
+	if jReq.done != nil {
+		close(jReq.done)
+	}
+	if jReq.cancel != nil {
+		jReq.cancel()
+	}
+	if jReq.release != nil {
+		jReq.release()
+	}
+	if jReq.finished != nil {
+		jReq.finished(jReq.method, time.Since(jReq.started), resp.err)
+	}
+	if jReq.instrumentation != nil {
+		jReq.instrumentation.RequestFinished(jReq.method,
+			time.Since(jReq.started), resp.err)
+	}
+	if jReq.finishSpan != nil {
+		jReq.finishSpan(resp.err)
+	}
 }
 
 // Client represents a Bitcoin RPC client which allows easy access to the
@@ -112,15 +220,62 @@ type jsonRequest struct {
 // the returned future will block until the result is available if it's not
 // already.
 type Client struct {
-	id uint64 // atomic, so must stay 64-bit aligned
+	id      uint64 // atomic, so must stay 64-bit aligned
+	pending int64  // atomic, so must stay 64-bit aligned
+
+	// orphanResponses counts the responses which did not belong to any
+	// pending request.  It is accessed atomically, so must stay 64-bit
+	// aligned.
+	orphanResponses uint64
 
 	// config holds the connection configuration assoiated with this client.
 	config *ConnConfig
 
+	// log is the logger from the connection configuration, or a logger
+	// which discards everything when none was configured.
+	log Logger
+
 	// httpClient is the underlying HTTP client to use when running in HTTP
 	// POST mode.
 	httpClient *http.Client
 
+	// maxResponseBytes is the maximum number of bytes read for a single
+	// response.
+	maxResponseBytes int64
+
+	// endpoints tracks the health of the configured RPC servers.
+	endpoints *endpointSet
+
+	// stats collects the per-method statistics returned by Stats.
+	stats statsCollector
+
+	// limiter enforces the configured rate limit.  It is nil when requests
+	// are not rate limited.
+	limiter *rateLimiter
+
+	// orderedMethods is the set of methods sent in the order they were
+	// issued.  It is nil when no methods are ordered.
+	orderedMethods map[string]struct{}
+
+	// pendingSlots holds a value for every pending request when their
+	// number is limited.  It is nil otherwise.
+	pendingSlots chan struct{}
+
+	// credentials caches the credentials returned by the configured
+	// CredentialsProvider.  It is nil when there is none.
+	credentials *credentialsCache
+
+	// connNotifier passes connection state changes to the callbacks in
+	// the connection configuration.  It is nil when none are configured.
+	connNotifier *connNotifier
+
+	// postWorkers is the number of HTTP POST mode workers still running.
+	// It is accessed atomically.
+	postWorkers int32
+
+	// closing is set once Close was called.  It is accessed atomically.
+	closing uint32
+
 	// mtx is a mutex to protect access to connection related fields.
 	mtx sync.Mutex
 
@@ -139,6 +294,7 @@ type Client struct {
 	// Networking infrastructure.
 	sendChan        chan []byte
 	sendPostChan    chan *sendPostDetails
+	orderedPostChan chan *sendPostDetails
 	connEstablished chan struct{}
 	disconnect      chan struct{}
 	shutdown        chan struct{}
@@ -217,9 +373,10 @@ type (
 	// into. It supports both requests (for notification support) and
 	// responses.  The partially-unmarshaled message is a notification if
 	// the embedded ID (from the response) is nil.  Otherwise, it is a
-	// response.
+	// response.  Messages are decoded with decodeJSON, so the ID is either
+	// a json.Number or a string.
 	inMessage struct {
-		ID *float64 `json:"id"`
+		ID interface{} `json:"id"`
 		*rawNotification
 		*rawResponse
 	}
@@ -263,8 +420,10 @@ func (c *Client) handleMessage(msg []byte) {
 	var in inMessage
 	in.rawResponse = new(rawResponse)
 	in.rawNotification = new(rawNotification)
-	err := json.Unmarshal(msg, &in)
+	err := decodeJSON(msg, &in)
 	if err != nil {
+		c.log.Warnf("Remote server sent invalid message: %v", err)
+		c.unparsableMessage(msg, ReasonInvalidJSON)
 		return
 	}
 
@@ -272,47 +431,58 @@ func (c *Client) handleMessage(msg []byte) {
 	if in.ID == nil {
 		ntfn := in.rawNotification
 		if ntfn == nil {
-
+			c.log.Warnf("Malformed notification: missing " +
+				"method and parameters")
+			c.unparsableMessage(msg, ReasonMissingMethod)
 			return
 		}
 		if ntfn.Method == "" {
-
+			c.log.Warnf("Malformed notification: missing method")
+			c.unparsableMessage(msg, ReasonMissingMethod)
 			return
 		}
 		// params are not optional: nil isn't valid (but len == 0 is)
 		if ntfn.Params == nil {
-
+			c.log.Warnf("Malformed notification: missing params")
+			c.unparsableMessage(msg, ReasonMissingParams)
 			return
 		}
-		// Deliver the notification.
-
+		// There are no notification handlers, so the notification is
+		// dropped.
+		c.log.Warnf("Received unhandled notification [%s]", ntfn.Method)
 		return
 	}
 
-	// ensure that in.ID can be converted to an integer without loss of precision
-	if *in.ID < 0 || *in.ID != math.Trunc(*in.ID) {
-
+	// Ids are decoded as a json.Number rather than a float64, so ids
+	// beyond the precision of a float64 are not rounded.
+	id, ok := parseID(in.ID)
+	if !ok {
+		c.log.Warnf("Malformed response: invalid identifier")
+		c.unparsableMessage(msg, ReasonInvalidID)
 		return
 	}
 
 	if in.rawResponse == nil {
-
+		c.log.Warnf("Malformed response: missing result and error")
+		c.unparsableMessage(msg, ReasonMissingResult)
 		return
 	}
 
-	id := uint64(*in.ID)
-
+	c.log.Debugf("Received response for id %d", id)
+	c.logJSON("Response", id, msg)
 	request := c.removeRequest(id)
 
 	// Nothing more to do if there is no request associated with this reply.
 	if request == nil || request.responseChan == nil {
-
+		c.log.Warnf("Received unexpected reply: %s (id %d)", in.Result,
+			id)
+		c.orphanResponse(msg, id)
 		return
 	}
 
 	// Deliver the response.
 	result, err := in.rawResponse.result()
-	request.responseChan <- &response{result: result, err: err}
+	request.respond(&response{result: result, err: err})
 }
 
 
@@ -329,53 +499,155 @@ func (c *Client) disconnectChan() <-chan struct{} {
 
 // handleSendPostMessage handles performing the passed HTTP request, reading the
 // result, unmarshalling it, and delivering the unmarshalled result to the
-// provided response channel.
+// provided response channel.  Failed attempts are retried according to the
+// configured retry policy.
 func (c *Client) handleSendPostMessage(details *sendPostDetails) {
 	jReq := details.jsonRequest
+	defer c.removeRequest(jReq.id)
+	if details.finishQueue != nil {
+		details.finishQueue(nil)
+	}
 
-	httpResponse, err := c.httpClient.Do(details.httpRequest)
-	if err != nil {
-		jReq.responseChan <- &response{err: err}
+	// Skip requests which were answered while they were queued, such as
+	// those cancelled by the caller or failed by Shutdown.
+	if atomic.LoadUint32(&jReq.responded) != 0 {
 		return
 	}
 
-	// Read the raw bytes and close the response.
-	respBytes, err := ioutil.ReadAll(httpResponse.Body)
+	httpReq, host := details.httpRequest, details.host
+	ctx := httpReq.Context()
+	retry := c.shouldRetry(ctx, jReq.method)
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		result, err := c.sendPostAttempt(httpReq, jReq)
+		c.reportEndpoint(host, err)
+
+		// Credentials from a provider may have been rotated since they
+		// were cached, so a rejected request is sent once more with
+		// fresh ones before the failure is surfaced.
+		if !refreshed && c.refreshCredentials(httpReq, err) {
+			refreshed = true
+			attempt--
+		} else if err == nil || !retry || !c.waitRetry(ctx, jReq, attempt, err) {
+			jReq.respond(&response{result: result, err: err})
+			return
+		}
+
+		// Send the retry to whichever endpoint is active now, which is
+		// another one once the failed endpoint is marked unhealthy.
+		host = c.endpoints.pick()
+		httpReq, err = c.newPostRequest(ctx, host, jReq)
+		if err != nil {
+			jReq.respond(&response{err: err})
+			return
+		}
+	}
+}
+
+// sendPostAttempt performs the passed HTTP request once and returns the
+// result, or error, the server replied with.
+func (c *Client) sendPostAttempt(httpReq *http.Request, jReq *jsonRequest) (result []byte, err error) {
+	c.log.Debugf("Sending command [%s] with id %d", jReq.method, jReq.id)
+	c.logJSON("Request", jReq.id, jReq.marshalledJSON)
+
+	// Trace the round trip as a child of the request, passing the span on
+	// to the transport with the request's context.
+	ctx, finishRoundTrip := c.startSpan(httpReq.Context(), RoundTripSpanName)
+	if finishRoundTrip != nil {
+		httpReq = httpReq.WithContext(ctx)
+		defer func() {
+			finishRoundTrip(err)
+		}()
+	}
+
+	// Record the attempt once its outcome is known.
+	var respBytes []byte
+	if c.config.TrafficRecorder != nil {
+		start := time.Now()
+		defer func() {
+			c.recordTraffic(jReq.method, jReq.marshalledJSON,
+				respBytes, time.Since(start), err)
+		}()
+	}
+
+	// Abort the round trip once the client shuts down instead of waiting
+	// for the server to answer.
+	ctx, cancel := c.withShutdown(httpReq.Context())
+	defer cancel()
+	httpResponse, err := c.httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the raw bytes and close the response.  One byte more than the
+	// limit is read to detect oversized responses, whose remainder is
+	// drained up to maxDrainBytes so the connection can be reused.  The
+	// limit applies to the decompressed size of compressed responses.
+	limit := c.maxResponseBytes
+	body, err := responseBody(httpResponse)
+	if err == nil {
+		respBytes, err = ioutil.ReadAll(io.LimitReader(body, limit+1))
+	}
+	if err == nil && int64(len(respBytes)) > limit {
+		io.Copy(ioutil.Discard, io.LimitReader(httpResponse.Body,
+			maxDrainBytes))
+		httpResponse.Body.Close()
+		return nil, &ErrResponseTooLarge{
+			Method: jReq.method,
+			Limit:  limit,
+		}
+	}
 	httpResponse.Body.Close()
 	if err != nil {
-		err = fmt.Errorf("error reading json reply: %v", err)
-		jReq.responseChan <- &response{err: err}
-		return
+		return nil, fmt.Errorf("error reading json reply: %w", err)
+	}
+	c.logJSON("Response", jReq.id, respBytes)
+
+	// The server rejects requests with bad credentials before running any
+	// command, so there is no JSON-RPC reply to decode.
+	if httpResponse.StatusCode == http.StatusUnauthorized {
+		return nil, ErrInvalidAuth
 	}
 
 	// Try to unmarshal the response as a regular JSON-RPC response.
 	var resp rawResponse
-	err = json.Unmarshal(respBytes, &resp)
+	err = decodeJSON(respBytes, &resp)
 	if err != nil {
 		// When the response itself isn't a valid JSON-RPC response
 		// return an error which includes the HTTP status code and raw
 		// response bytes.
-		err = fmt.Errorf("status code: %d, response: %q",
-			httpResponse.StatusCode, string(respBytes))
-		jReq.responseChan <- &response{err: err}
-		return
+		return nil, &HTTPError{
+			StatusCode: httpResponse.StatusCode,
+			Body:       string(respBytes),
+		}
 	}
 
-	res, err := resp.result()
-	jReq.responseChan <- &response{result: res, err: err}
+	// A failed request must not be mistaken for a null result when the
+	// reply carries no RPC error to describe the failure.
+	if resp.Error == nil && (httpResponse.StatusCode < 200 ||
+		httpResponse.StatusCode > 299) {
+
+		return nil, &HTTPError{
+			StatusCode: httpResponse.StatusCode,
+			Body:       string(respBytes),
+		}
+	}
+
+	return resp.result()
 }
 
 // sendPostHandler handles all outgoing messages when the client is running
 // in HTTP POST mode.  It uses a buffered channel to serialize output messages
-// while allowing the sender to continue running asynchronously.  It must be run
-// as a goroutine.
-func (c *Client) sendPostHandler() {
+// while allowing the sender to continue running asynchronously.  One handler
+// runs for each of the configured HTTP POST workers, plus one for the ordered
+// methods.  It must be run as a goroutine.
+func (c *Client) sendPostHandler(sendPostChan chan *sendPostDetails) {
 out:
 	for {
 		// Send any messages ready for send until the shutdown channel
 		// is closed.
 		select {
-		case details := <-c.sendPostChan:
+		case details := <-sendPostChan:
 			c.handleSendPostMessage(details)
 
 		case <-c.shutdown:
@@ -388,16 +660,25 @@ out:
 cleanup:
 	for {
 		select {
-		case details := <-c.sendPostChan:
-			details.jsonRequest.responseChan <- &response{
+		case details := <-sendPostChan:
+			if details.finishQueue != nil {
+				details.finishQueue(ErrClientShutdown)
+			}
+			details.jsonRequest.respond(&response{
 				result: nil,
 				err:    ErrClientShutdown,
-			}
+			})
 
 		default:
 			break cleanup
 		}
 	}
+
+	// Close any connections kept open for reuse once the last worker is
+	// done, since no more requests will be sent.
+	if atomic.AddInt32(&c.postWorkers, -1) == 0 {
+		c.httpClient.CloseIdleConnections()
+	}
 	c.wg.Done()
 
 }
@@ -405,17 +686,28 @@ cleanup:
 // sendPostRequest sends the passed HTTP request to the RPC server using the
 // HTTP client associated with the client.  It is backed by a buffered channel,
 // so it will not block until the send channel is full.
-func (c *Client) sendPostRequest(httpReq *http.Request, jReq *jsonRequest) {
-	// Don't send the message if shutting down.
-	select {
-	case <-c.shutdown:
-		jReq.responseChan <- &response{result: nil, err: ErrClientShutdown}
-	default:
+func (c *Client) sendPostRequest(httpReq *http.Request, jReq *jsonRequest, host string) {
+	// Track the request so Shutdown responds to it even while it is still
+	// queued.  Don't send the message if shutting down.
+	if err := c.addRequest(jReq); err != nil {
+		jReq.respond(&response{result: nil, err: err})
+		return
 	}
 
-	c.sendPostChan <- &sendPostDetails{
+	// Stop waiting for a worker to take the request once the client shuts
+	// down, which responds to every tracked request.
+	_, finishQueue := c.startSpan(httpReq.Context(), QueueSpanName)
+	select {
+	case c.postChan(jReq.method) <- &sendPostDetails{
 		jsonRequest: jReq,
 		httpRequest: httpReq,
+		host:        host,
+		finishQueue: finishQueue,
+	}:
+	case <-c.shutdown:
+		if finishQueue != nil {
+			finishQueue(ErrClientShutdown)
+		}
 	}
 }
 
@@ -432,6 +724,10 @@ func newFutureError(err error) chan *response {
 // reply or any errors.  The examined errors include an error in the
 // futureResult and the error in the reply from the server.  This will block
 // until the result is available on the passed channel.
+//
+// The context passed when the request was issued is watched by the client, so
+// if it is cancelled or its deadline expires before the server replies, the
+// context's error is delivered on the channel instead.
 func receiveFuture(f chan *response) ([]byte, error) {
 	// Wait for a response on the returned channel.
 	r := <-f
@@ -439,38 +735,142 @@ func receiveFuture(f chan *response) ([]byte, error) {
 }
 
 // sendPost sends the passed request to the server by issuing an HTTP POST
-// request using the provided response channel for the reply.  Typically a new
-// connection is opened and closed for each command when using this method,
-// however, the underlying HTTP client might coalesce multiple commands
-// depending on several factors including the remote server configuration.
+// request using the provided response channel for the reply.  Connections are
+// kept open and reused for later commands unless the connection configuration
+// disables it.
 func (c *Client) sendPost(ctx context.Context, jReq *jsonRequest) {
+	host := c.endpoints.pick()
+	httpReq, err := c.newPostRequest(ctx, host, jReq)
+	if err != nil {
+		jReq.respond(&response{result: nil, err: err})
+		return
+	}
+	c.sendPostRequest(httpReq, jReq, host)
+}
+
+// newPostRequest returns the HTTP POST request sending the passed JSON-RPC
+// request to the passed host.
+func (c *Client) newPostRequest(ctx context.Context, host string, jReq *jsonRequest) (*http.Request, error) {
 	// Generate a request to the configured RPC server.
-	protocol := "http"
-	if !c.config.DisableTLS {
-		protocol = "https"
+	url, err := postURL(host, c.config)
+	if err != nil {
+		return nil, err
 	}
-	url := protocol + "://" + c.config.Host
 	bodyReader := bytes.NewReader(jReq.marshalledJSON)
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bodyReader)
 	if err != nil {
-		jReq.responseChan <- &response{result: nil, err: err}
-		return
+		return nil, err
 	}
-	httpReq.Close = true
+	httpReq.Close = c.config.DisableConnectionReuse
 	httpReq.Header.Set("Content-Type", "application/json")
+	c.setAcceptEncoding(httpReq)
 	httpReq.Header.Set("filter_id", c.config.FilterID)
 	httpReq.Header.Set("change_address", c.config.ChangeAddress)
 
-	// Configure basic access authorization.
-	httpReq.SetBasicAuth(c.config.User, c.config.Pass)
+	// Configure authorization and any extra headers.
+	setHeaders(httpReq.Header, c.config)
+	for key, values := range jReq.header {
+		httpReq.Header[key] = values
+	}
+	if err := c.setCredentials(httpReq); err != nil {
+		return nil, err
+	}
+
+	return httpReq, nil
+}
+
+// withShutdown returns a context derived from the passed context which is
+// also cancelled once the client shuts down.  The returned cancel function
+// must be called once the context is no longer needed.
+func (c *Client) withShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-c.shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
 
-	c.sendPostRequest(httpReq, jReq)
+// watchContext arranges for the passed request to be answered with the
+// context's error should the context be done before a response is delivered.
+// The request is also removed from the request map so it does not leak.
+// Contexts which can never be cancelled are not watched.
+func (c *Client) watchContext(ctx context.Context, jReq *jsonRequest) {
+	if ctx.Done() == nil {
+		return
+	}
+
+	jReq.done = make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.removeRequest(jReq.id)
+			jReq.respond(&response{result: nil, err: ctx.Err()})
+
+		case <-jReq.done:
+		}
+	}()
 }
 
 // sendRequest sends the passed json request to the associated server using the
 // provided response channel for the reply.  It handles both websocket and HTTP
 // POST mode depending on the configuration of the client.
 func (c *Client) sendRequest(ctx context.Context, jReq *jsonRequest) {
+	// Pass the request through the interceptors first, which issue the
+	// request they end up with on their own.
+	if len(c.config.Interceptors) > 0 && !jReq.intercepted {
+		c.intercept(ctx, jReq)
+		return
+	}
+
+	jReq.started = time.Now()
+	jReq.finished = c.requestFinished
+	if instr := c.config.Instrumentation; instr != nil {
+		instr.RequestStarted(jReq.method)
+		jReq.instrumentation = instr
+	}
+
+	// Trace the request, with the span covering its whole lifetime.
+	ctx, jReq.finishSpan = c.startSpan(ctx, jReq.method)
+
+	// Bound the request by the configured timeout when the caller did not
+	// provide a deadline of their own.
+	if c.config.RequestTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			ctx, jReq.cancel = context.WithTimeout(ctx,
+				c.config.RequestTimeout)
+		}
+	}
+
+	// Count the request as pending, which fails or blocks the caller when
+	// too many requests are pending already.
+	if err := c.acquirePending(ctx, jReq); err != nil {
+		jReq.respond(&response{result: nil, err: err})
+		return
+	}
+
+	// Refuse new requests once the client is closing.  This is checked
+	// after counting the request as pending, so Close either waits for
+	// the request or the request sees that the client is closing.
+	if c.isClosing() {
+		jReq.respond(&response{result: nil, err: ErrClientShutdown})
+		return
+	}
+
+	// Block the caller until the rate limit allows another request.
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx, 1); err != nil {
+			jReq.respond(&response{result: nil, err: err})
+			return
+		}
+	}
+
+	// Stop waiting on the server once the caller's context is done.
+	c.watchContext(ctx, jReq)
+
 	// Choose which marshal and send function to use depending on whether
 	// the client running in HTTP POST mode or not.  When running in HTTP
 	// POST mode, the command is issued via an HTTP client.  Otherwise,
@@ -479,6 +879,11 @@ func (c *Client) sendRequest(ctx context.Context, jReq *jsonRequest) {
 		c.sendPost(ctx, jReq)
 		return
 	}
+
+	// The websocket transport is never started, so there is nothing that
+	// would ever deliver a reply.  Fail the request rather than leaving
+	// the caller blocked on the response channel forever.
+	jReq.respond(&response{result: nil, err: ErrClientNotConnected})
 }
 
 // sendCmd sends the passed command to the associated server and returns a
@@ -494,7 +899,7 @@ func (c *Client) sendCmd(ctx context.Context, cmd interface{}) chan *response {
 
 	// Marshal the command.
 	id := c.NextID()
-	marshalledJSON, err := btcjson.MarshalCmd(id, cmd)
+	marshalledJSON, err := c.marshalCmd(id, cmd)
 	if err != nil {
 		return newFutureError(err)
 	}
@@ -513,7 +918,7 @@ func (c *Client) sendCmd(ctx context.Context, cmd interface{}) chan *response {
 	return responseChan
 }
 
-// doShutdown closes the shutdown channel and 
+// doShutdown closes the shutdown channel and logs the shutdown unless shutdown
 // is already in progress.  It will return false if the shutdown is not needed.
 //
 // This function is safe for concurrent access.
@@ -526,34 +931,45 @@ func (c *Client) doShutdown() bool {
 	default:
 	}
 
+	c.log.Debugf("Shutting down RPC client %s", c.ActiveEndpoint())
 	close(c.shutdown)
 	return true
 }
 
 // Shutdown shuts down the client by disconnecting any connections associated
 // with the client and, when automatic reconnect is enabled, preventing future
-// attempts to reconnect.  It also stops all goroutines.
+// attempts to reconnect.  It also stops all goroutines.  Pending requests fail
+// with ErrClientShutdown; use Close to let them finish first.
 func (c *Client) Shutdown() {
 	// Do the shutdown under the request lock to prevent clients from
 	// adding new requests while the client shutdown process is initiated.
 	c.requestLock.Lock()
-	defer c.requestLock.Unlock()
 
 	// Ignore the shutdown request if the client is already in the process
 	// of shutting down or already shutdown.
 	if !c.doShutdown() {
+		c.requestLock.Unlock()
 		return
 	}
 
-	// Send the ErrClientShutdown error to any pending requests.
+	// Collect the pending requests before releasing the lock.  No new
+	// requests can be added once the shutdown channel is closed.
+	pending := make([]*jsonRequest, 0, c.requestList.Len())
 	for e := c.requestList.Front(); e != nil; e = e.Next() {
-		req := e.Value.(*jsonRequest)
-		req.responseChan <- &response{
+		pending = append(pending, e.Value.(*jsonRequest))
+	}
+	c.removeAllRequests()
+	c.requestLock.Unlock()
+
+	// Send the ErrClientShutdown error to any pending requests.  This is
+	// done without holding the request lock since responding may invoke
+	// caller-provided instrumentation.
+	for _, req := range pending {
+		req.respond(&response{
 			result: nil,
 			err:    ErrClientShutdown,
-		}
+		})
 	}
-	c.removeAllRequests()
 
 }
 
@@ -563,8 +979,20 @@ func (c *Client) start() {
 	// Start the I/O processing handlers depending on whether the client is
 	// in HTTP POST mode or the default websocket mode.
 	if c.config.HTTPPostMode {
-		c.wg.Add(1)
-		go c.sendPostHandler()
+		workers := c.config.HTTPPostWorkers
+		if workers < 1 {
+			workers = 1
+		}
+		if c.orderedPostChan != nil {
+			c.wg.Add(1)
+			atomic.AddInt32(&c.postWorkers, 1)
+			go c.sendPostHandler(c.orderedPostChan)
+		}
+		atomic.AddInt32(&c.postWorkers, int32(workers))
+		c.wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go c.sendPostHandler(c.sendPostChan)
+		}
 	} else {
 	}
 }
@@ -579,9 +1007,33 @@ func (c *Client) WaitForShutdown() {
 // This
 type ConnConfig struct {
 	// Host is the IP address and port of the RPC server you want to connect
-	// to.
+	// to.  It may also be a full URL such as
+	// "https://rpc.example.com/btc/mainnet", whose path is kept.  The
+	// scheme of a full URL must be https unless DisableTLS is set, in
+	// which case it must be http.
 	Host string
 
+	// Hosts, when set, lists several RPC servers serving the same chain and
+	// is used instead of Host.  Requests are sent to the first healthy one,
+	// failing over to the next once it fails FailoverThreshold times in a
+	// row.  The failed server is avoided until FailoverCooldown has passed.
+	// Only HTTP POST mode requests fail over.
+	Hosts []string
+
+	// FailoverThreshold is the number of consecutive transport failures,
+	// such as refused connections or 503 replies, after which a server is
+	// considered unhealthy.  A zero value means three.
+	FailoverThreshold int
+
+	// FailoverCooldown is how long an unhealthy server is avoided before
+	// it is tried again.  A zero value means 30 seconds.
+	FailoverCooldown time.Duration
+
+	// Wallet, when set, sends HTTP POST mode requests to the endpoint of
+	// the named wallet of a node with several wallets loaded, which is
+	// the path "/wallet/<name>" appended to the path of the host.
+	Wallet string
+
 	// Endpoint is the websocket endpoint on the RPC server.  This is
 	// typically "ws".
 	Endpoint string
@@ -592,6 +1044,24 @@ type ConnConfig struct {
 	// Pass is the passphrase to use to authenticate to the RPC server.
 	Pass string
 
+	// BearerToken, when set, is sent in an "Authorization: Bearer" header
+	// instead of authenticating with User and Pass.  This is typically
+	// required by hosted node providers.
+	BearerToken string
+
+	// CredentialsProvider, when set, is asked for the username and
+	// password to authenticate with instead of using User, Pass and
+	// BearerToken.  The credentials it returns are cached for a short
+	// while, and a request the server rejects as unauthorized is sent
+	// once more with fresh credentials before failing with
+	// ErrInvalidAuth.
+	CredentialsProvider CredentialsProvider
+
+	// ExtraHeaders are additional HTTP headers sent with every request.
+	// They are applied after the authorization header, so they may also
+	// be used to replace it with a provider specific scheme.
+	ExtraHeaders map[string]string
+
 	// DisableTLS specifies whether transport layer security should be
 	// disabled.  It is recommended to always use TLS if the RPC server
 	// supports it as otherwise your username and password is sent across
@@ -603,8 +1073,27 @@ type ConnConfig struct {
 	// is true.
 	Certificates []byte
 
-	// Proxy specifies to connect through a SOCKS 5 proxy server.  It may
-	// be an empty string if a proxy is not required.
+	// ClientCertificate and ClientKey are the bytes for a PEM-encoded
+	// certificate and private key presented to the server, which is
+	// required by servers or proxies enforcing mutual TLS.  They have no
+	// effect if the DisableTLS parameter is true.
+	ClientCertificate []byte
+	ClientKey         []byte
+
+	// InsecureSkipVerifyHostname disables checking that the server
+	// certificate is valid for Host.  The certificate chain is still
+	// verified against Certificates, or the system roots when Certificates
+	// is empty.  This is intended for self-signed setups where the server
+	// is reached by an address its certificate does not name, and leaves
+	// the connection open to anyone holding a certificate from the same
+	// roots.
+	InsecureSkipVerifyHostname bool
+
+	// Proxy specifies to connect through a proxy server.  Addresses with
+	// a socks5:// scheme or without a scheme, such as 127.0.0.1:9050, are
+	// SOCKS 5 proxies, while addresses with an http:// or https:// scheme
+	// are HTTP proxies, which only HTTP POST mode supports.  It may be an
+	// empty string if a proxy is not required.
 	Proxy string
 
 	// ProxyUser is an optional username to use for the proxy server if it
@@ -630,44 +1119,299 @@ type ConnConfig struct {
 	// flag can be set to true to use basic HTTP POST requests instead.
 	HTTPPostMode bool
 
+	// DisableConnectionReuse closes the connection after every HTTP POST
+	// mode request instead of keeping it open for later requests.
+	DisableConnectionReuse bool
+
+	// MaxResponseBytes is the maximum size of a single response from the
+	// server.  Larger responses fail with an *ErrResponseTooLarge.  A zero
+	// value means a limit of 64 MiB.
+	MaxResponseBytes int64
+
+	// EnableCompression asks the server for gzip compressed responses in
+	// HTTP POST mode, which greatly reduces the size of large responses
+	// such as verbose blocks.  Responses are decompressed transparently,
+	// and MaxResponseBytes limits their decompressed size.
+	EnableCompression bool
+
+	// RetryPolicy, when set, retries HTTP POST mode requests which fail
+	// with a transient error.  See RetryPolicy for which requests are
+	// retried.
+	RetryPolicy *RetryPolicy
+
+	// RateLimit, when set, limits the rate requests are sent to the
+	// server at.  Callers block while the limit is exhausted.
+	RateLimit *RateLimit
+
+	// HTTPPostWorkers is the number of HTTP POST mode requests sent to the
+	// server concurrently.  Values below one mean one, which sends requests
+	// one at a time in the order they were issued.
+	HTTPPostWorkers int
+
+	// OrderedMethods lists methods whose HTTP POST mode requests are sent
+	// one at a time in the order they were issued, such as walletpassphrase
+	// and walletlock, even when HTTPPostWorkers sends other requests
+	// concurrently.  A request is only sent once the previous request for
+	// any of the listed methods was answered.
+	OrderedMethods []string
+
+	// MaxPendingRequests limits the number of requests which were issued
+	// but not answered yet.  Callers issuing a request at the limit block
+	// until another request is answered or their context is done, or fail
+	// with ErrClientOverloaded when FailFast is set.  Zero means no limit.
+	MaxPendingRequests int
+
+	// FailFast makes requests issued while MaxPendingRequests requests are
+	// pending fail right away with ErrClientOverloaded instead of waiting.
+	FailFast bool
+
+	// Transport, when set, tunes the connection pool of the HTTP
+	// transport used in HTTP POST mode.
+	Transport *TransportOptions
+
+	// RoundTripper, when set, sends the HTTP POST mode requests instead of
+	// a transport created from this configuration, for example to wrap a
+	// transport with tracing.  The proxy, TLS, timeout and Transport
+	// settings do not apply to it.
+	RoundTripper http.RoundTripper
+
+	// OnClientConnected, when set, is called once the client has connected
+	// to the RPC server and again after every reconnect, with the number
+	// of attempts it took to reconnect.  In HTTP POST mode, where there is
+	// no connection to watch, the client counts as connected once the
+	// server answers a request, and the retry count is the number of
+	// requests which failed while it was disconnected.
+	//
+	// The callbacks run in their own goroutine, one at a time and in the
+	// order the changes happened, so they may call back into the client.
+	OnClientConnected func(retries int)
+
+	// OnClientDisconnected, when set, is called when the connection to the
+	// RPC server is lost, with the error which caused it when known.  In
+	// HTTP POST mode the client counts as disconnected after
+	// FailoverThreshold consecutive requests failed to reach the server.
+	OnClientDisconnected func(err error)
+
 	// EnableBCInfoHacks is an option provided to enable compatibility hacks
 	// when connecting to blockchain.info RPC server
 	EnableBCInfoHacks bool
 
+	// LegacySendRawTransaction makes SendRawTransaction pass the
+	// allowhighfees flag understood by nodes before Litecoin Core 0.18.
+	// Later nodes expect a numeric maxfeerate in its place and reject the
+	// flag.
+	LegacySendRawTransaction bool
+
+	// ChainParams, when set, is the network of the server.  Private keys
+	// passed to SignRawTransactionWithKey and ImportPrivKey are checked to
+	// be for it before they are sent.  Otherwise keys of any Litecoin
+	// network are accepted.  GenerateToAddress requires it to be
+	// &RegressionNetParams.
+	ChainParams *chaincfg.Params
+
+	// RequestTimeout bounds the total time a request may take, including
+	// the time it spends queued before being sent.  It only applies when
+	// the context passed to a call has no deadline of its own.  A zero
+	// value means no timeout.
+	RequestTimeout time.Duration
+
+	// DialTimeout is the maximum amount of time to wait for a connection
+	// to the RPC server to be established.  A zero value means no timeout
+	// beyond any imposed by the operating system.
+	DialTimeout time.Duration
+
+	// ResponseHeaderTimeout is the amount of time to wait for the server's
+	// response headers after fully writing a request.  A zero value means
+	// no timeout.
+	ResponseHeaderTimeout time.Duration
+
+	// Instrumentation, when set, is notified as every request is issued
+	// and again once it finishes.  See the Instrumentation interface for
+	// details.
+	Instrumentation Instrumentation
+
+	// JSONRPCVersion is the JSON-RPC version requests are sent with,
+	// either "1.0" or "2.0".  An empty string means "1.0".  Replies in
+	// either version are understood regardless of this setting.
+	JSONRPCVersion string
+
+	// SlowQueryThreshold, when positive, logs a warning through the
+	// Logger with the method and duration of every request which takes
+	// longer than the threshold.
+	SlowQueryThreshold time.Duration
+
+	// Tracer, when set, traces every request as a span, with child spans
+	// for the time spent queued and every HTTP round trip.  See the Tracer
+	// interface for details.
+	Tracer Tracer
+
+	// TrafficRecorder, when set, receives the raw JSON of every request and
+	// response.  See the TrafficRecorder type for details.
+	TrafficRecorder TrafficRecorder
+
+	// Interceptors wrap every request issued by the client, the first one
+	// being the outermost.  See the Interceptor type for details.
+	Interceptors []Interceptor
+
+	// OnUnparsableMessage, when set, is called with every message from
+	// the server which could not be handled, along with the reason.  Such
+	// messages are dropped, so a server speaking an unexpected dialect
+	// otherwise only shows up as requests which are never answered.
+	//
+	// The hooks are called synchronously from the goroutine reading the
+	// messages, so they must return quickly.
+	OnUnparsableMessage func(msg []byte, reason UnparsableReason)
+
+	// OnOrphanResponse, when set, is called with every response from the
+	// server which does not belong to any pending request, such as a late
+	// reply to a request whose context expired, along with its id.  These
+	// responses are also counted in Stats.
+	OnOrphanResponse func(msg []byte, id uint64)
+
+	// Logger receives log lines for conditions which are not reported to
+	// any caller, such as malformed messages from the server, along with
+	// debug output about requests being sent.  A nil Logger discards
+	// everything.
+	Logger Logger
+
+	// DebugJSON additionally logs the raw JSON of every request and
+	// response at debug level.  It has no effect without a Logger.
+	DebugJSON bool
+
 	FilterID string
 	ChangeAddress string
 }
 
+// setHeaders sets the authorization header and any extra headers from the
+// passed connection configuration on the passed header.
+func setHeaders(header http.Header, config *ConnConfig) {
+	if config.BearerToken != "" {
+		header.Set("Authorization", "Bearer "+config.BearerToken)
+	} else {
+		login := config.User + ":" + config.Pass
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+		header.Set("Authorization", auth)
+	}
+
+	for key, value := range config.ExtraHeaders {
+		header.Set(key, value)
+	}
+}
+
+// newTLSConfig returns the TLS configuration described by the passed
+// connection configuration.  It returns nil when TLS is disabled or the
+// default configuration suffices.
+func newTLSConfig(config *ConnConfig) (*tls.Config, error) {
+	if config.DisableTLS {
+		return nil, nil
+	}
+	if len(config.Certificates) == 0 && len(config.ClientCertificate) == 0 &&
+		!config.InsecureSkipVerifyHostname {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if len(config.Certificates) > 0 {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(config.Certificates)
+		tlsConfig.RootCAs = pool
+	}
+	if len(config.ClientCertificate) > 0 {
+		cert, err := tls.X509KeyPair(config.ClientCertificate,
+			config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if config.InsecureSkipVerifyHostname {
+		// Disable the standard verification, which includes the host
+		// name, and verify the chain alone instead.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyChain(tlsConfig.RootCAs)
+	}
+	return tlsConfig, nil
+}
+
+// verifyChain returns a function suitable for tls.Config.VerifyPeerCertificate
+// which verifies the certificate chain presented by the server against the
+// passed roots, or the system roots when nil, without checking the host name.
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server presented no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
 // newHTTPClient returns a new http client that is configured according to the
 // proxy and TLS settings in the associated connection configuration.
 func newHTTPClient(config *ConnConfig) (*http.Client, error) {
-	// Set proxy function if there is a proxy configured.
+	// A custom round tripper replaces the transport configured below.
+	if config.RoundTripper != nil {
+		return &http.Client{Transport: config.RoundTripper}, nil
+	}
+
+	// Set proxy function if there is an HTTP proxy configured.  SOCKS 5
+	// proxies are dialed instead, see below.
+	socksProxy, proxyURL, err := parseProxy(config)
+	if err != nil {
+		return nil, err
+	}
 	var proxyFunc func(*http.Request) (*url.URL, error)
-	if config.Proxy != "" {
-		proxyURL, err := url.Parse(config.Proxy)
-		if err != nil {
-			return nil, err
-		}
+	if proxyURL != nil {
 		proxyFunc = http.ProxyURL(proxyURL)
 	}
 
 	// Configure TLS if needed.
-	var tlsConfig *tls.Config
-	if !config.DisableTLS {
-		if len(config.Certificates) > 0 {
-			pool := x509.NewCertPool()
-			pool.AppendCertsFromPEM(config.Certificates)
-			tlsConfig = &tls.Config{
-				RootCAs: pool,
-			}
-		}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxyFunc,
+		TLSClientConfig:       tlsConfig,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		DisableKeepAlives:     config.DisableConnectionReuse,
+
+		// Compressed responses are requested and decompressed by the
+		// client itself depending on EnableCompression.
+		DisableCompression: true,
+	}
+	if config.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: config.DialTimeout}
+		transport.DialContext = dialer.DialContext
+	}
+	if socksProxy != nil {
+		transport.DialContext = socksDialContext(socksProxy,
+			config.DialTimeout)
+	}
+	if config.Transport != nil {
+		config.Transport.apply(transport)
 	}
 
 	client := http.Client{
-		Transport: &http.Transport{
-			Proxy:           proxyFunc,
-			TLSClientConfig: tlsConfig,
-		},
+		Transport: transport,
 	}
 
 	return &client, nil
@@ -678,6 +1422,13 @@ func newHTTPClient(config *ConnConfig) (*http.Client, error) {
 // interested in receiving notifications and will be ignored if the
 // configuration is set to run in HTTP POST mode.
 func New(config *ConnConfig) (*Client, error) {
+	if err := checkJSONRPCVersion(config.JSONRPCVersion); err != nil {
+		return nil, err
+	}
+	if err := checkHosts(config); err != nil {
+		return nil, err
+	}
+
 	// Either open a websocket connection or create an HTTP client depending
 	// on the HTTP POST mode.  Also, set the notification handlers to nil
 	// when running in HTTP POST mode.
@@ -695,16 +1446,44 @@ func New(config *ConnConfig) (*Client, error) {
 
 	}
 
+	log := config.Logger
+	if log == nil {
+		log = nopLogger{}
+	}
+
+	maxResponseBytes := config.MaxResponseBytes
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = defaultMaxResponseBytes
+	}
+
 	client := &Client{
-		config:          config,
-		httpClient:      httpClient,
-		requestMap:      make(map[uint64]*list.Element),
-		requestList:     list.New(),
-		sendChan:        make(chan []byte, sendBufferSize),
-		sendPostChan:    make(chan *sendPostDetails, sendPostBufferSize),
-		connEstablished: connEstablished,
-		disconnect:      make(chan struct{}),
-		shutdown:        make(chan struct{}),
+		config:           config,
+		log:              log,
+		httpClient:       httpClient,
+		maxResponseBytes: maxResponseBytes,
+		endpoints:        newEndpointSet(config),
+		limiter:          newRateLimiter(config.RateLimit),
+		pendingSlots:     newPendingSlots(config.MaxPendingRequests),
+		orderedMethods:   newOrderedMethods(config.OrderedMethods),
+		connNotifier:     newConnNotifier(config),
+		credentials:      newCredentialsCache(config.CredentialsProvider),
+		requestMap:       make(map[uint64]*list.Element),
+		requestList:      list.New(),
+		sendChan:         make(chan []byte, sendBufferSize),
+		sendPostChan:     make(chan *sendPostDetails, sendPostBufferSize),
+		connEstablished:  connEstablished,
+		disconnect:       make(chan struct{}),
+		shutdown:         make(chan struct{}),
+	}
+
+	if client.orderedMethods != nil {
+		client.orderedPostChan = make(chan *sendPostDetails,
+			sendPostBufferSize)
+	}
+
+	if client.connNotifier != nil {
+		client.wg.Add(1)
+		go client.connEventHandler()
 	}
 
 	if start {
@@ -0,0 +1,421 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/wire"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// MempoolAcceptResult models an entry of the data returned by the
+// testmempoolaccept command.
+type MempoolAcceptResult struct {
+	// Txid is the hash of the transaction and Wtxid its witness hash,
+	// which older nodes do not report.
+	Txid  string
+	Wtxid string
+
+	// Allowed is whether the transaction would be accepted to the memory
+	// pool, and RejectReason why not otherwise.
+	Allowed      bool
+	RejectReason string
+
+	// Vsize is the virtual size of the transaction and Fee the fee it
+	// pays.  Both are only reported for allowed transactions and by newer
+	// nodes, and are 0 otherwise.
+	Vsize int64
+	Fee   ltcutil.Amount
+}
+
+// UnmarshalJSON unmarshals an entry of the testmempoolaccept result.  The fee
+// is read exactly, whether it is nested as the base fee in a fees object like
+// newer nodes report it or a plain amount.
+func (r *MempoolAcceptResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		Txid         string          `json:"txid"`
+		Wtxid        string          `json:"wtxid"`
+		Allowed      bool            `json:"allowed"`
+		RejectReason string          `json:"reject-reason"`
+		Vsize        int64           `json:"vsize"`
+		Fee          json.Number     `json:"fee"`
+		Fees         json.RawMessage `json:"fees"`
+	}
+	if err := decodeJSON(data, &result); err != nil {
+		return err
+	}
+
+	fee := result.Fee
+	if len(result.Fees) != 0 {
+		var fees struct {
+			Base json.Number `json:"base"`
+		}
+		if result.Fees[0] == '{' {
+			if err := decodeJSON(result.Fees, &fees); err != nil {
+				return err
+			}
+		} else if err := decodeJSON(result.Fees, &fees.Base); err != nil {
+			return err
+		}
+		fee = fees.Base
+	}
+	var litoshi int64
+	if fee != "" {
+		var err error
+		if litoshi, err = ParseAmountSat(fee); err != nil {
+			return err
+		}
+	}
+
+	*r = MempoolAcceptResult{
+		Txid:         result.Txid,
+		Wtxid:        result.Wtxid,
+		Allowed:      result.Allowed,
+		RejectReason: result.RejectReason,
+		Vsize:        result.Vsize,
+		Fee:          ltcutil.Amount(litoshi),
+	}
+	return nil
+}
+
+// FutureTestMempoolAcceptResult is a future promise to deliver the result of a
+// TestMempoolAcceptAsync RPC invocation (or an applicable error).
+type FutureTestMempoolAcceptResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// each of the transactions would be accepted to the memory pool.
+func (r FutureTestMempoolAcceptResult) Receive() ([]MempoolAcceptResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of testmempoolaccept results.
+	var results []MempoolAcceptResult
+	if err := json.Unmarshal(res, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// TestMempoolAcceptAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See TestMempoolAccept for the blocking version and more details.
+func (c *Client) TestMempoolAcceptAsync(ctx context.Context, txs []*wire.MsgTx, maxFeeRate *float64) FutureTestMempoolAcceptResult {
+	rawTxs := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		rawTxs = append(rawTxs, hex.EncodeToString(buf.Bytes()))
+	}
+	params := []interface{}{rawTxs}
+	if maxFeeRate != nil {
+		params = append(params, *maxFeeRate)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureTestMempoolAcceptResult(c.RawRequestAsync(ctx,
+		"testmempoolaccept", rawParams))
+}
+
+// TestMempoolAccept returns whether the node would accept each of the passed
+// transactions to its memory pool, in order, without relaying them.  A nil
+// maxFeeRate leaves the highest acceptable fee rate, in LTC per kilo virtual
+// byte, to the node.  Litecoin Core 0.21 and earlier only take a single
+// transaction at a time and return an error for more.
+func (c *Client) TestMempoolAccept(ctx context.Context, txs []*wire.MsgTx, maxFeeRate *float64) ([]MempoolAcceptResult, error) {
+	return c.TestMempoolAcceptAsync(ctx, txs, maxFeeRate).Receive()
+}
+
+// amountField is an amount in LTC as found in a result, and the field it is
+// converted into.
+type amountField struct {
+	litoshi *ltcutil.Amount
+	ltc     json.Number
+}
+
+// parseAmounts converts each of the passed amounts in LTC to litoshis exactly.
+// Missing amounts are 0.
+func parseAmounts(fields ...amountField) error {
+	for _, field := range fields {
+		if field.ltc == "" {
+			*field.litoshi = 0
+			continue
+		}
+		sat, err := ParseAmountSat(field.ltc)
+		if err != nil {
+			return err
+		}
+		*field.litoshi = ltcutil.Amount(sat)
+	}
+	return nil
+}
+
+// MempoolFees holds the fees of a transaction in the memory pool.
+type MempoolFees struct {
+	// Base is the fee paid by the transaction and Modified the fee used
+	// for mining priority, which differs from Base for transactions
+	// prioritised with prioritisetransaction.
+	Base     ltcutil.Amount
+	Modified ltcutil.Amount
+
+	// Ancestor and Descendant are the modified fees of the transaction
+	// together with those of its unconfirmed ancestors or descendants.
+	Ancestor   ltcutil.Amount
+	Descendant ltcutil.Amount
+}
+
+// GetMempoolEntryResult models the data returned from the getmempoolentry
+// command and the entries of the verbose getrawmempool command.
+type GetMempoolEntryResult struct {
+	// VSize is the virtual size of the transaction and Weight its weight,
+	// which older nodes do not report.
+	VSize  int64
+	Weight int64
+
+	// Time is the time the transaction entered the memory pool in seconds
+	// since the Unix epoch, and Height the block height at the time.
+	Time   int64
+	Height int64
+
+	// DescendantCount and DescendantSize are the number and virtual size
+	// of the transaction together with its unconfirmed descendants, and
+	// AncestorCount and AncestorSize those of the transaction together
+	// with its unconfirmed ancestors.
+	DescendantCount int64
+	DescendantSize  int64
+	AncestorCount   int64
+	AncestorSize    int64
+
+	WTxID string
+	Fees  MempoolFees
+
+	// Depends are the hashes of the unconfirmed transactions the
+	// transaction spends from, and SpentBy those of the unconfirmed
+	// transactions spending from it.
+	Depends []string
+	SpentBy []string
+
+	BIP125Replaceable bool
+}
+
+// UnmarshalJSON decodes a memory pool entry, converting the fees in LTC to
+// litoshis exactly.  Nodes before Litecoin Core 0.17 only report the fees in
+// flat fields, of which the ancestor and descendant fees are in litoshis, and
+// report the virtual size as size.  These fields are only used when the fees
+// object or the virtual size are missing.
+func (r *GetMempoolEntryResult) UnmarshalJSON(data []byte) error {
+	var entry struct {
+		VSize           int64    `json:"vsize"`
+		Size            int64    `json:"size"`
+		Weight          int64    `json:"weight"`
+		Time            int64    `json:"time"`
+		Height          int64    `json:"height"`
+		DescendantCount int64    `json:"descendantcount"`
+		DescendantSize  int64    `json:"descendantsize"`
+		AncestorCount   int64    `json:"ancestorcount"`
+		AncestorSize    int64    `json:"ancestorsize"`
+		WTxID           string   `json:"wtxid"`
+		Depends         []string `json:"depends"`
+		SpentBy         []string `json:"spentby"`
+		Replaceable     bool     `json:"bip125-replaceable"`
+		Fees            *struct {
+			Base       json.Number `json:"base"`
+			Modified   json.Number `json:"modified"`
+			Ancestor   json.Number `json:"ancestor"`
+			Descendant json.Number `json:"descendant"`
+		} `json:"fees"`
+
+		// Flat fee fields of older nodes.
+		Fee            json.Number `json:"fee"`
+		ModifiedFee    json.Number `json:"modifiedfee"`
+		AncestorFees   int64       `json:"ancestorfees"`
+		DescendantFees int64       `json:"descendantfees"`
+	}
+	if err := decodeJSON(data, &entry); err != nil {
+		return err
+	}
+
+	var fees MempoolFees
+	if entry.Fees != nil {
+		err := parseAmounts(
+			amountField{&fees.Base, entry.Fees.Base},
+			amountField{&fees.Modified, entry.Fees.Modified},
+			amountField{&fees.Ancestor, entry.Fees.Ancestor},
+			amountField{&fees.Descendant, entry.Fees.Descendant},
+		)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := parseAmounts(
+			amountField{&fees.Base, entry.Fee},
+			amountField{&fees.Modified, entry.ModifiedFee},
+		)
+		if err != nil {
+			return err
+		}
+		fees.Ancestor = ltcutil.Amount(entry.AncestorFees)
+		fees.Descendant = ltcutil.Amount(entry.DescendantFees)
+	}
+
+	vsize := entry.VSize
+	if vsize == 0 {
+		vsize = entry.Size
+	}
+
+	*r = GetMempoolEntryResult{
+		VSize:             vsize,
+		Weight:            entry.Weight,
+		Time:              entry.Time,
+		Height:            entry.Height,
+		DescendantCount:   entry.DescendantCount,
+		DescendantSize:    entry.DescendantSize,
+		AncestorCount:     entry.AncestorCount,
+		AncestorSize:      entry.AncestorSize,
+		WTxID:             entry.WTxID,
+		Fees:              fees,
+		Depends:           entry.Depends,
+		SpentBy:           entry.SpentBy,
+		BIP125Replaceable: entry.Replaceable,
+	}
+	return nil
+}
+
+// EffectiveFeeRate returns the fee rate in litoshis per virtual byte miners
+// consider the passed memory pool entry at, which is the lower of its own fee
+// rate and the one of the transaction together with its unconfirmed
+// ancestors.  The modified fees are used, as miners do.  Descendants paying
+// for the transaction are not taken into account.
+func EffectiveFeeRate(entry *GetMempoolEntryResult) float64 {
+	if entry.VSize == 0 {
+		return 0
+	}
+	feeRate := float64(entry.Fees.Modified) / float64(entry.VSize)
+	if entry.AncestorSize != 0 {
+		ancestorFeeRate := float64(entry.Fees.Ancestor) /
+			float64(entry.AncestorSize)
+		if ancestorFeeRate < feeRate {
+			feeRate = ancestorFeeRate
+		}
+	}
+	return feeRate
+}
+
+// GetMempoolInfoResult models the data returned from the getmempoolinfo
+// command.  Fields which the node is too old to report are left empty.
+type GetMempoolInfoResult struct {
+	// Loaded is whether the node finished loading the memory pool saved
+	// on shutdown.
+	Loaded bool
+
+	// Size is the number of transactions in the memory pool, Bytes the
+	// sum of their virtual sizes and Usage the memory used for them.
+	Size  int64
+	Bytes int64
+	Usage int64
+
+	// TotalFee is the sum of the fees of the transactions.
+	TotalFee ltcutil.Amount
+
+	// MaxMempool is the memory the memory pool may use before the
+	// transactions paying the lowest fee rates are evicted.
+	MaxMempool int64
+
+	// MempoolMinFee is the lowest fee rate per kilo virtual byte of the
+	// transactions accepted to the memory pool, which is raised above
+	// MinRelayTxFee while transactions are evicted for the memory pool
+	// being full.  MinRelayTxFee is the lowest fee rate relayed at all.
+	MempoolMinFee ltcutil.Amount
+	MinRelayTxFee ltcutil.Amount
+
+	// UnbroadcastCount is the number of transactions which have not been
+	// announced to any peer yet.
+	UnbroadcastCount int64
+}
+
+// UnmarshalJSON decodes the result of the getmempoolinfo command, converting
+// the fees and fee rates in LTC to litoshis exactly.
+func (r *GetMempoolInfoResult) UnmarshalJSON(data []byte) error {
+	var info struct {
+		Loaded           bool        `json:"loaded"`
+		Size             int64       `json:"size"`
+		Bytes            int64       `json:"bytes"`
+		Usage            int64       `json:"usage"`
+		TotalFee         json.Number `json:"total_fee"`
+		MaxMempool       int64       `json:"maxmempool"`
+		MempoolMinFee    json.Number `json:"mempoolminfee"`
+		MinRelayTxFee    json.Number `json:"minrelaytxfee"`
+		UnbroadcastCount int64       `json:"unbroadcastcount"`
+	}
+	if err := decodeJSON(data, &info); err != nil {
+		return err
+	}
+
+	*r = GetMempoolInfoResult{
+		Loaded:           info.Loaded,
+		Size:             info.Size,
+		Bytes:            info.Bytes,
+		Usage:            info.Usage,
+		MaxMempool:       info.MaxMempool,
+		UnbroadcastCount: info.UnbroadcastCount,
+	}
+	return parseAmounts(
+		amountField{&r.TotalFee, info.TotalFee},
+		amountField{&r.MempoolMinFee, info.MempoolMinFee},
+		amountField{&r.MinRelayTxFee, info.MinRelayTxFee},
+	)
+}
+
+// FutureGetMempoolInfoResult is a future promise to deliver the result of a
+// GetMempoolInfoAsync RPC invocation (or an applicable error).
+type FutureGetMempoolInfoResult chan *response
+
+// Receive waits for the response promised by the future and returns the state
+// of the memory pool.
+func (r FutureGetMempoolInfoResult) Receive() (*GetMempoolInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getmempoolinfo result object.
+	var result GetMempoolInfoResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetMempoolInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GetMempoolInfo for the blocking version and more details.
+func (c *Client) GetMempoolInfoAsync(ctx context.Context) FutureGetMempoolInfoResult {
+	cmd := btcjson.NewGetMempoolInfoCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetMempoolInfo returns the state of the memory pool of the node, including
+// the fee rates transactions need to be accepted to it.
+func (c *Client) GetMempoolInfo(ctx context.Context) (*GetMempoolInfoResult, error) {
+	return c.GetMempoolInfoAsync(ctx).Receive()
+}
@@ -0,0 +1,370 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+func TestTestMempoolAccept(t *testing.T) {
+	accepted := newWitnessTx()
+	dust := newWitnessTx()
+	dust.TxOut[0].Value = 100
+
+	// The replies are keyed by the transaction they are returned for.  The
+	// second one for the accepted transaction has the fee format of older
+	// nodes.
+	replies := map[string][]string{
+		hex.EncodeToString(serializeTx(t, accepted)): {
+			`{"txid":"` + accepted.TxHash().String() + `","wtxid":"` +
+				accepted.WitnessHash().String() + `","allowed":true,` +
+				`"vsize":110,"fees":{"base":0.00000226}}`,
+			`{"txid":"` + accepted.TxHash().String() + `","allowed":true,` +
+				`"vsize":110,"fee":0.00000226}`,
+		},
+		hex.EncodeToString(serializeTx(t, dust)): {
+			`{"txid":"` + dust.TxHash().String() + `","allowed":false,` +
+				`"reject-reason":"dust"}`,
+		},
+	}
+
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		defer mtx.Unlock()
+		params = append(params, string(p))
+		if req.Method != "testmempoolaccept" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		var rawTxs []string
+		json.Unmarshal(req.Params[0], &rawTxs)
+		entries := make([]json.RawMessage, 0, len(rawTxs))
+		for _, rawTx := range rawTxs {
+			reply := replies[rawTx]
+			entries = append(entries, json.RawMessage(reply[0]))
+			if len(reply) > 1 {
+				replies[rawTx] = reply[1:]
+			}
+		}
+		return entries, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	maxFeeRate := 0.1
+	results, err := client.TestMempoolAccept(ctx, []*wire.MsgTx{accepted, dust},
+		&maxFeeRate)
+	if err != nil {
+		t.Fatalf("TestMempoolAccept: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	first, second := results[0], results[1]
+	if !first.Allowed || first.Txid != accepted.TxHash().String() ||
+		first.Wtxid != accepted.WitnessHash().String() ||
+		first.Vsize != 110 || first.Fee != 226 {
+
+		t.Fatalf("unexpected first result %+v", first)
+	}
+	if second.Allowed || second.RejectReason != "dust" || second.Fee != 0 {
+		t.Fatalf("unexpected second result %+v", second)
+	}
+
+	results, err = client.TestMempoolAccept(ctx, []*wire.MsgTx{accepted}, nil)
+	if err != nil {
+		t.Fatalf("TestMempoolAccept: %v", err)
+	}
+	if len(results) != 1 || !results[0].Allowed || results[0].Fee != 226 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	acceptedHex := hex.EncodeToString(serializeTx(t, accepted))
+	want := []string{
+		`[["` + acceptedHex + `","` + hex.EncodeToString(serializeTx(t, dust)) +
+			`"],0.1]`,
+		`[["` + acceptedHex + `"]]`,
+	}
+	if len(params) != len(want) {
+		t.Fatalf("got params %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+const (
+	childTxID  = "f1c0aae3f6a6b3b8a2b5c7f0d1e2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4"
+	parentTxID = "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41"
+	minedTxID  = "9a6d0f6b2a6c8bdbd3c1b5f5d5c6e0a7c1b0d7e6f2a8b9c0d1e2f3a4b5c6d7e8"
+)
+
+// childEntry is a memory pool entry as returned by Litecoin Core 0.21, which
+// only reports the fees in the fees object.  Its parent pays a lower fee rate.
+const childEntry = `{
+  "vsize": 141,
+  "weight": 561,
+  "time": 1690000000,
+  "height": 2500000,
+  "descendantcount": 1,
+  "descendantsize": 141,
+  "ancestorcount": 2,
+  "ancestorsize": 282,
+  "wtxid": "1e0f2ad4c6b8a79e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e",
+  "fees": {
+    "base": 0.00002820,
+    "modified": 0.00002820,
+    "ancestor": 0.00002961,
+    "descendant": 0.00002820
+  },
+  "depends": [
+    "` + parentTxID + `"
+  ],
+  "spentby": [
+  ],
+  "bip125-replaceable": false
+}`
+
+// parentEntry is the parent of childEntry as returned by Litecoin Core 0.16,
+// with the flat fee fields only.
+const parentEntry = `{
+  "size": 141,
+  "fee": 0.00000141,
+  "modifiedfee": 0.00000141,
+  "time": 1689999900,
+  "height": 2499999,
+  "startingpriority": 0,
+  "currentpriority": 0,
+  "descendantcount": 2,
+  "descendantsize": 282,
+  "descendantfees": 2961,
+  "ancestorcount": 1,
+  "ancestorsize": 141,
+  "ancestorfees": 141,
+  "depends": [
+  ]
+}`
+
+func TestGetMempoolEntry(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getmempoolentry" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		var txID string
+		json.Unmarshal(req.Params[0], &txID)
+		switch txID {
+		case childTxID:
+			return json.RawMessage(childEntry), nil
+		case parentTxID:
+			return json.RawMessage(parentEntry), nil
+		}
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Transaction not in mempool",
+		}
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	child, err := client.GetMempoolEntry(ctx, childTxID)
+	if err != nil {
+		t.Fatalf("GetMempoolEntry: %v", err)
+	}
+	want := GetMempoolEntryResult{
+		VSize:           141,
+		Weight:          561,
+		Time:            1690000000,
+		Height:          2500000,
+		DescendantCount: 1,
+		DescendantSize:  141,
+		AncestorCount:   2,
+		AncestorSize:    282,
+		WTxID:           "1e0f2ad4c6b8a79e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e",
+		Fees: MempoolFees{
+			Base:       2820,
+			Modified:   2820,
+			Ancestor:   2961,
+			Descendant: 2820,
+		},
+		Depends: []string{parentTxID},
+		SpentBy: []string{},
+	}
+	if !reflect.DeepEqual(*child, want) {
+		t.Fatalf("got %+v, want %+v", *child, want)
+	}
+
+	parent, err := client.GetMempoolEntry(ctx, parentTxID)
+	if err != nil {
+		t.Fatalf("GetMempoolEntry: %v", err)
+	}
+	wantFees := MempoolFees{
+		Base:       141,
+		Modified:   141,
+		Ancestor:   141,
+		Descendant: 2961,
+	}
+	if parent.VSize != 141 || parent.Fees != wantFees ||
+		parent.DescendantCount != 2 {
+
+		t.Fatalf("unexpected parent entry %+v", *parent)
+	}
+
+	_, err = client.GetMempoolEntry(ctx, minedTxID)
+	var notInMempool *ErrTxNotInMempool
+	if !errors.As(err, &notInMempool) {
+		t.Fatalf("got error %v, want ErrTxNotInMempool", err)
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) ||
+		rpcErr.Code != btcjson.ErrRPCInvalidAddressOrKey {
+
+		t.Fatalf("server error %v not wrapped", err)
+	}
+}
+
+func TestGetRawMempool(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+		var verbose bool
+		json.Unmarshal(req.Params[0], &verbose)
+		if verbose {
+			return json.RawMessage(`{"` + childTxID + `":` + childEntry +
+				`,"` + parentTxID + `":` + parentEntry + `}`), nil
+		}
+		return []string{childTxID, parentTxID}, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	hashes, err := client.GetRawMempool(ctx)
+	if err != nil {
+		t.Fatalf("GetRawMempool: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0].String() != childTxID ||
+		hashes[1].String() != parentTxID {
+
+		t.Fatalf("unexpected hashes %v", hashes)
+	}
+
+	entries, err := client.GetRawMempoolVerbose(ctx)
+	if err != nil {
+		t.Fatalf("GetRawMempoolVerbose: %v", err)
+	}
+	if len(entries) != 2 || entries[childTxID].Fees.Ancestor != 2961 ||
+		entries[parentTxID].Fees.Descendant != 2961 ||
+		entries[childTxID].AncestorCount != 2 ||
+		entries[parentTxID].DescendantCount != 2 {
+
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{`getrawmempool[false]`, `getrawmempool[true]`}
+	if len(params) != len(want) {
+		t.Fatalf("got requests %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+func TestEffectiveFeeRate(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry GetMempoolEntryResult
+		want  float64
+	}{{
+		name: "without ancestors",
+		entry: GetMempoolEntryResult{VSize: 200, AncestorSize: 200,
+			Fees: MempoolFees{Modified: 3000, Ancestor: 3000}},
+		want: 15,
+	}, {
+		// The parent paying 1 lit/vB holds the child back.
+		name: "paying for its parent",
+		entry: GetMempoolEntryResult{VSize: 141, AncestorSize: 282,
+			Fees: MempoolFees{Modified: 2820, Ancestor: 2961}},
+		want: 2961.0 / 282,
+	}, {
+		// The parent paying more does not help the child.
+		name: "below its parent",
+		entry: GetMempoolEntryResult{VSize: 141, AncestorSize: 282,
+			Fees: MempoolFees{Modified: 141, Ancestor: 3000}},
+		want: 1,
+	}, {
+		name: "prioritised",
+		entry: GetMempoolEntryResult{VSize: 100, AncestorSize: 100,
+			Fees: MempoolFees{Base: 100, Modified: 10100, Ancestor: 10100}},
+		want: 101,
+	}, {
+		name:  "empty",
+		entry: GetMempoolEntryResult{},
+		want:  0,
+	}}
+	for _, test := range tests {
+		got := EffectiveFeeRate(&test.entry)
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: got %v lit/vB, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetMempoolInfo(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		if req.Method != "getmempoolinfo" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		return json.RawMessage(`{"loaded":true,"size":180,"bytes":52000,` +
+			`"usage":310000,"total_fee":0.00912345,"maxmempool":300000000,` +
+			`"mempoolminfee":0.00001000,"minrelaytxfee":0.00001000,` +
+			`"unbroadcastcount":0}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	info, err := client.GetMempoolInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetMempoolInfo: %v", err)
+	}
+	want := GetMempoolInfoResult{
+		Loaded:        true,
+		Size:          180,
+		Bytes:         52000,
+		Usage:         310000,
+		TotalFee:      912345,
+		MaxMempool:    300000000,
+		MempoolMinFee: 1000,
+		MinRelayTxFee: 1000,
+	}
+	if *info != want {
+		t.Fatalf("got %+v, want %+v", *info, want)
+	}
+}
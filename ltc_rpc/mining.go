@@ -0,0 +1,142 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// ErrNotRegtest describes a call which only makes sense on regtest made by a
+// client configured for another network.
+type ErrNotRegtest struct {
+	// Network is the name of the network the client is configured for,
+	// which is empty when ConnConfig.ChainParams is not set.
+	Network string
+}
+
+// Error satisfies the error interface.
+func (e *ErrNotRegtest) Error() string {
+	if e.Network == "" {
+		return "call needs a regtest node, the client is not configured " +
+			"for a network"
+	}
+	return "call needs a regtest node, the client is configured for " +
+		e.Network
+}
+
+// requireRegtest returns an *ErrNotRegtest when the client is not configured
+// for regtest.
+func (c *Client) requireRegtest() error {
+	net := c.config.ChainParams
+	if net == nil {
+		return &ErrNotRegtest{}
+	}
+	if net.Net != RegressionNetParams.Net {
+		return &ErrNotRegtest{Network: net.Name}
+	}
+	return nil
+}
+
+// FuturePrioritiseTransactionResult is a future promise to deliver the result
+// of a PrioritiseTransactionAsync RPC invocation (or an applicable error).
+type FuturePrioritiseTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns whether
+// the fee delta was applied.
+func (r FuturePrioritiseTransactionResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal the result as a boolean.
+	var applied bool
+	if err := json.Unmarshal(res, &applied); err != nil {
+		return false, err
+	}
+	return applied, nil
+}
+
+// PrioritiseTransactionAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See PrioritiseTransaction for the blocking version and more details.
+func (c *Client) PrioritiseTransactionAsync(ctx context.Context, txid *chainhash.Hash, feeDelta ltcutil.Amount) FuturePrioritiseTransactionResult {
+	// The second parameter is the priority delta of nodes before Litecoin
+	// Core 0.15, which later nodes require to be 0.
+	rawParams, err := marshalParams([]interface{}{txid.String(), 0,
+		int64(feeDelta)})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FuturePrioritiseTransactionResult(c.RawRequestAsync(ctx,
+		"prioritisetransaction", rawParams))
+}
+
+// PrioritiseTransaction has the node treat the passed transaction as if it
+// paid feeDelta more, or less for a negative delta, when selecting
+// transactions for blocks and evicting them from the memory pool.  The fee
+// the transaction actually pays is unchanged and the delta is kept for
+// transactions not in the memory pool yet.  The delta shows up as the
+// modified fee of the memory pool entry.  It always returns true.
+func (c *Client) PrioritiseTransaction(ctx context.Context, txid *chainhash.Hash, feeDelta ltcutil.Amount) (bool, error) {
+	return c.PrioritiseTransactionAsync(ctx, txid, feeDelta).Receive()
+}
+
+// FutureGenerateToAddressResult is a future promise to deliver the result of
+// a GenerateToAddressAsync RPC invocation (or an applicable error).
+type FutureGenerateToAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the hashes
+// of the generated blocks.
+func (r FutureGenerateToAddressResult) Receive() ([]*chainhash.Hash, error) {
+	// The result is a list of block hashes, which is decoded like the list
+	// of transaction hashes of getrawmempool.
+	return FutureGetRawMempoolResult(r).Receive()
+}
+
+// GenerateToAddressAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See GenerateToAddress for the blocking version and more details.
+func (c *Client) GenerateToAddressAsync(ctx context.Context, nBlocks int64, addr ltcutil.Address, maxTries *int64) FutureGenerateToAddressResult {
+	if err := c.requireRegtest(); err != nil {
+		return newFutureError(err)
+	}
+	params := []interface{}{nBlocks, addr.EncodeAddress()}
+	if maxTries != nil {
+		params = append(params, *maxTries)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureGenerateToAddressResult(c.RawRequestAsync(ctx,
+		"generatetoaddress", rawParams))
+}
+
+// GenerateToAddress has the node mine nBlocks blocks paying their coinbase to
+// the passed address and returns their hashes.  A nil maxTries leaves the
+// number of nonces tried per block to the node.  The blocks include the
+// transactions of the memory pool like any mined block.
+//
+// Mining blocks on demand only works on regtest, so an *ErrNotRegtest is
+// returned without asking the node unless ConnConfig.ChainParams is
+// &RegressionNetParams.
+func (c *Client) GenerateToAddress(ctx context.Context, nBlocks int64, addr ltcutil.Address, maxTries *int64) ([]*chainhash.Hash, error) {
+	return c.GenerateToAddressAsync(ctx, nBlocks, addr, maxTries).Receive()
+}
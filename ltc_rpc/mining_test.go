@@ -0,0 +1,117 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+)
+
+// regtestMiningAddr is a P2WPKH address on regtest.
+const regtestMiningAddr = "rltc1qqqqsyqcyq5rqwzqfpg9scrgwpugpzysnrwhxws"
+
+func TestMining(t *testing.T) {
+	const blockHash = "3f5a8c1b2e4d6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8"
+	genesisHash := RegressionNetParams.GenesisHash.String()
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "generatetoaddress":
+			return []string{genesisHash, blockHash}, nil
+		case "prioritisetransaction":
+			return true, nil
+		case "invalidateblock", "reconsiderblock":
+			return nil, nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	config := testConnConfig(server)
+	config.ChainParams = &RegressionNetParams
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress(regtestMiningAddr, &RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	hashes, err := client.GenerateToAddress(ctx, 2, addr, nil)
+	if err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+	if len(hashes) != 2 || hashes[1].String() != blockHash {
+		t.Fatalf("unexpected block hashes %v", hashes)
+	}
+	maxTries := int64(1000)
+	if _, err := client.GenerateToAddress(ctx, 1, addr, &maxTries); err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+
+	txid := chainhash.Hash{1}
+	applied, err := client.PrioritiseTransaction(ctx, &txid, -5000)
+	if err != nil || !applied {
+		t.Fatalf("PrioritiseTransaction: got %v, %v, want true", applied, err)
+	}
+
+	if err := client.InvalidateBlock(ctx, hashes[1]); err != nil {
+		t.Fatalf("InvalidateBlock: %v", err)
+	}
+	if err := client.ReconsiderBlock(ctx, hashes[1]); err != nil {
+		t.Fatalf("ReconsiderBlock: %v", err)
+	}
+
+	// Clients configured for other networks, or none, refuse to generate
+	// blocks without asking the node.
+	for _, net := range []*chaincfg.Params{&chaincfg.MainNetParams, nil} {
+		config := testConnConfig(server)
+		config.ChainParams = net
+		otherClient, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		_, err = otherClient.GenerateToAddress(ctx, 1, addr, nil)
+		stopClient(otherClient)
+		var notRegtest *ErrNotRegtest
+		if !errors.As(err, &notRegtest) {
+			t.Fatalf("GenerateToAddress: got error %v, want ErrNotRegtest",
+				err)
+		}
+		if net != nil && notRegtest.Network != net.Name {
+			t.Fatalf("got network %q, want %q", notRegtest.Network,
+				net.Name)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`generatetoaddress[2,"` + regtestMiningAddr + `"]`,
+		`generatetoaddress[1,"` + regtestMiningAddr + `",1000]`,
+		`prioritisetransaction["` + txid.String() + `",0,-5000]`,
+		`invalidateblock["` + blockHash + `"]`,
+		`reconsiderblock["` + blockHash + `"]`,
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got requests %v, want %v", requests, want)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("call %d sent %s, want %s", i, requests[i], want[i])
+		}
+	}
+}
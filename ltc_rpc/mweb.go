@@ -0,0 +1,222 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// Bits of the flag byte of the extended transaction serialization.  The wire
+// package only knows the witness flag of BIP 144.  Litecoin Core 0.21.2 adds
+// the MWEB flag, whose data follows the witnesses.
+const (
+	witnessFlag = 0x01
+	mwebFlag    = 0x08
+)
+
+// ErrMwebData is returned when decoding a transaction which carries MWEB data,
+// such as a peg-in, which the wire package cannot represent.  Integration
+// transactions (HogEx) set the MWEB flag without carrying any and decode fine.
+var ErrMwebData = errors.New("transaction carries MWEB data")
+
+// txLayout describes where the parts of a serialized transaction are.
+type txLayout struct {
+	// flags is the flag byte of the extended serialization, 0 for the
+	// legacy serialization.
+	flags byte
+
+	// mwebOffset is the offset of the MWEB data when flags has the MWEB
+	// flag set.
+	mwebOffset int
+
+	// length is the length of the serialized transaction.
+	length int
+}
+
+// scanTx returns the layout of the transaction serialized at the start of the
+// passed bytes, which may be followed by more data.
+func scanTx(serialized []byte) (*txLayout, error) {
+	r := bytes.NewReader(serialized)
+	layout := &txLayout{}
+
+	if err := skipBytes(r, 4); err != nil {
+		return nil, err
+	}
+	numInputs, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if numInputs == 0 {
+		// An empty list of inputs is the marker of the extended
+		// serialization, which the flag byte follows.
+		if layout.flags, err = r.ReadByte(); err != nil {
+			return nil, err
+		}
+		if layout.flags == 0 {
+			return nil, errors.New("transaction without inputs")
+		}
+		if numInputs, err = wire.ReadVarInt(r, 0); err != nil {
+			return nil, err
+		}
+	}
+	if layout.flags&^(witnessFlag|mwebFlag) != 0 {
+		return nil, fmt.Errorf("unknown transaction flags %#x",
+			layout.flags)
+	}
+	for i := uint64(0); i < numInputs; i++ {
+		// Each input is an outpoint, a signature script and a
+		// sequence number.
+		if err := skipBytes(r, 36); err != nil {
+			return nil, err
+		}
+		if err := skipVarBytes(r); err != nil {
+			return nil, err
+		}
+		if err := skipBytes(r, 4); err != nil {
+			return nil, err
+		}
+	}
+	numOutputs, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < numOutputs; i++ {
+		// Each output is a value and a public key script.
+		if err := skipBytes(r, 8); err != nil {
+			return nil, err
+		}
+		if err := skipVarBytes(r); err != nil {
+			return nil, err
+		}
+	}
+	if layout.flags&witnessFlag != 0 {
+		for i := uint64(0); i < numInputs; i++ {
+			numItems, err := wire.ReadVarInt(r, 0)
+			if err != nil {
+				return nil, err
+			}
+			for j := uint64(0); j < numItems; j++ {
+				if err := skipVarBytes(r); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if layout.flags&mwebFlag != 0 {
+		// The MWEB data is optional and a HogEx has none, which is
+		// serialized as a single zero byte.
+		layout.mwebOffset = len(serialized) - r.Len()
+		present, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if present != 0 {
+			return nil, ErrMwebData
+		}
+	}
+	if err := skipBytes(r, 4); err != nil {
+		return nil, err
+	}
+	layout.length = len(serialized) - r.Len()
+	return layout, nil
+}
+
+// skipBytes skips n bytes of the passed reader.
+func skipBytes(r *bytes.Reader, n uint64) error {
+	if uint64(r.Len()) < n {
+		return io.ErrUnexpectedEOF
+	}
+	_, err := r.Seek(int64(n), io.SeekCurrent)
+	return err
+}
+
+// skipVarBytes skips a byte slice prefixed by its length of the passed reader.
+func skipVarBytes(r *bytes.Reader) error {
+	n, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	return skipBytes(r, n)
+}
+
+// deserializeTxPrefix deserializes the transaction serialized at the start of
+// the passed bytes and returns it along with its layout.  The MWEB flag of
+// HogEx transactions is dropped, so they are serialized without it again.
+func deserializeTxPrefix(serialized []byte) (*wire.MsgTx, *txLayout, error) {
+	layout, err := scanTx(serialized)
+	if err != nil {
+		return nil, nil, err
+	}
+	serialized = serialized[:layout.length]
+
+	if layout.flags&mwebFlag != 0 {
+		stripped := make([]byte, 0, layout.length)
+		stripped = append(stripped, serialized[:4]...)
+		if layout.flags&witnessFlag != 0 {
+			stripped = append(stripped, 0, witnessFlag)
+		}
+		stripped = append(stripped, serialized[6:layout.mwebOffset]...)
+		stripped = append(stripped, serialized[layout.length-4:]...)
+		serialized = stripped
+	}
+
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, nil, err
+	}
+	return &msgTx, layout, nil
+}
+
+// deserializeTx deserializes the passed transaction, which may be a witness
+// transaction or a HogEx.  An ErrMwebData is returned for transactions
+// carrying MWEB data.
+func deserializeTx(serialized []byte) (*wire.MsgTx, error) {
+	msgTx, layout, err := deserializeTxPrefix(serialized)
+	if err != nil {
+		return nil, err
+	}
+	if layout.length != len(serialized) {
+		return nil, errors.New("invalid data after transaction")
+	}
+	return msgTx, nil
+}
+
+// deserializeBlock deserializes the passed block.  The MWEB extension block,
+// which follows the transactions of blocks ending with a HogEx, is skipped.
+func deserializeBlock(serialized []byte) (*wire.MsgBlock, error) {
+	r := bytes.NewReader(serialized)
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Header.Deserialize(r); err != nil {
+		return nil, err
+	}
+	numTxs, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	hogEx := false
+	for i := uint64(0); i < numTxs; i++ {
+		msgTx, layout, err := deserializeTxPrefix(serialized[len(serialized)-r.Len():])
+		if err != nil {
+			return nil, err
+		}
+		if err := skipBytes(r, uint64(layout.length)); err != nil {
+			return nil, err
+		}
+		if err := msgBlock.AddTransaction(msgTx); err != nil {
+			return nil, err
+		}
+		hogEx = layout.flags&mwebFlag != 0
+	}
+	if r.Len() != 0 && !hogEx {
+		return nil, errors.New("invalid data after block")
+	}
+	return &msgBlock, nil
+}
@@ -0,0 +1,160 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// p2wpkhScript is the output script of the ltc1 address of the BIP 173 test
+// vectors.
+var p2wpkhScript = []byte{0x00, 0x14, 0x75, 0x1e, 0x76, 0xe8, 0x19, 0x91,
+	0x96, 0xd4, 0x54, 0x94, 0x1c, 0x45, 0xd1, 0xb3, 0xa3, 0x23, 0xf1, 0x43,
+	0x3b, 0xd6}
+
+// newWitnessTx returns a transaction spending a pay-to-witness-pubkey-hash
+// output.
+func newWitnessTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 1), nil,
+		wire.TxWitness{bytes.Repeat([]byte{0x30}, 71),
+			bytes.Repeat([]byte{0x02}, 33)}))
+	tx.AddTxOut(wire.NewTxOut(150000000, p2wpkhScript))
+	tx.LockTime = 2100000
+	return tx
+}
+
+// newHogEx returns an integration transaction of MWEB, which spends the
+// previous one and pays to the pegged-in coins.
+func newHogEx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(2500000000, append([]byte{0x58, 0x20},
+		bytes.Repeat([]byte{0x03}, 32)...)))
+	return tx
+}
+
+// serializeTx returns the serialization of the passed transaction, including
+// its witnesses.
+func serializeTx(t *testing.T, tx *wire.MsgTx) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withMwebFlag returns the passed serialized transaction with the MWEB flag
+// set and the passed MWEB data, the way Litecoin Core serializes it.
+func withMwebFlag(serialized, mwebData []byte) []byte {
+	flagged := append([]byte(nil), serialized[:4]...)
+	body := serialized[4 : len(serialized)-4]
+	if serialized[4] == 0 {
+		flagged = append(flagged, 0, serialized[5]|mwebFlag)
+		body = body[2:]
+	} else {
+		flagged = append(flagged, 0, mwebFlag)
+	}
+	flagged = append(flagged, body...)
+	flagged = append(flagged, mwebData...)
+	return append(flagged, serialized[len(serialized)-4:]...)
+}
+
+func TestDeserializeTx(t *testing.T) {
+	witnessTx := serializeTx(t, newWitnessTx())
+	hogEx := serializeTx(t, newHogEx())
+	tests := []struct {
+		name       string
+		serialized []byte
+		want       []byte
+	}{
+		{"witness", witnessTx, witnessTx},
+		{"HogEx", withMwebFlag(hogEx, []byte{0}), hogEx},
+		{"witness and MWEB flags", withMwebFlag(witnessTx, []byte{0}), witnessTx},
+	}
+	for _, test := range tests {
+		msgTx, err := deserializeTx(test.serialized)
+		if err != nil {
+			t.Fatalf("%s: deserializeTx: %v", test.name, err)
+		}
+		if got := serializeTx(t, msgTx); !bytes.Equal(got, test.want) {
+			t.Fatalf("%s: got transaction %x, want %x", test.name, got,
+				test.want)
+		}
+	}
+
+	_, err := deserializeTx(withMwebFlag(witnessTx, []byte{1, 0x42, 0x42}))
+	if !errors.Is(err, ErrMwebData) {
+		t.Fatalf("got error %v, want ErrMwebData", err)
+	}
+	invalid := [][]byte{
+		append(append([]byte(nil), witnessTx...), 0),
+		witnessTx[:len(witnessTx)-1],
+		append([]byte{2, 0, 0, 0, 0, 2}, witnessTx[6:]...),
+		withMwebFlag(hogEx, nil),
+	}
+	for _, serialized := range invalid {
+		if _, err := deserializeTx(serialized); err == nil {
+			t.Fatalf("deserializeTx(%x): expected an error", serialized)
+		}
+	}
+}
+
+func TestDeserializeBlock(t *testing.T) {
+	block := wire.NewMsgBlock(wire.NewBlockHeader(0x20000000,
+		&chainhash.Hash{4}, &chainhash.Hash{5}, 0x1a01cd2d, 42))
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), []byte{0x03, 0x20, 0x0b, 0x20},
+		wire.TxWitness{make([]byte, 32)}))
+	coinbase.AddTxOut(wire.NewTxOut(1250000000, p2wpkhScript))
+	for _, tx := range []*wire.MsgTx{coinbase, newWitnessTx(), newHogEx()} {
+		block.AddTransaction(tx)
+	}
+
+	// The HogEx is serialized with the MWEB flag and the extension block
+	// follows it.
+	var buf bytes.Buffer
+	if err := block.Header.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	wire.WriteVarInt(&buf, 0, 3)
+	buf.Write(serializeTx(t, coinbase))
+	buf.Write(serializeTx(t, block.Transactions[1]))
+	buf.Write(withMwebFlag(serializeTx(t, block.Transactions[2]), []byte{0}))
+	buf.Write([]byte{0x01, 0xde, 0xad, 0xbe, 0xef})
+
+	decoded, err := deserializeBlock(buf.Bytes())
+	if err != nil {
+		t.Fatalf("deserializeBlock: %v", err)
+	}
+	if decoded.BlockHash() != block.BlockHash() ||
+		len(decoded.Transactions) != len(block.Transactions) {
+
+		t.Fatalf("unexpected block %v", decoded.BlockHash())
+	}
+	for i, tx := range block.Transactions {
+		if decoded.Transactions[i].WitnessHash() != tx.WitnessHash() {
+			t.Fatalf("got transaction %v at %d, want %v",
+				decoded.Transactions[i].WitnessHash(), i,
+				tx.WitnessHash())
+		}
+	}
+
+	// Only blocks with a HogEx are followed by an extension block.
+	buf.Reset()
+	if err := block.Header.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	wire.WriteVarInt(&buf, 0, 1)
+	buf.Write(serializeTx(t, coinbase))
+	buf.Write([]byte{0x01, 0xde, 0xad, 0xbe, 0xef})
+	if _, err := deserializeBlock(buf.Bytes()); err == nil {
+		t.Fatal("deserializeBlock: expected an error for trailing data")
+	}
+}
@@ -0,0 +1,158 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcutil"
+)
+
+func TestLargeResponseIDs(t *testing.T) {
+	client, err := New(&ConnConfig{Host: "127.0.0.1:1", HTTPPostMode: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	// Ids beyond 2^53 can not be represented by a float64 and used to be
+	// rounded to the id of another request.  Ids sent as strings are
+	// accepted as well.
+	ids := []uint64{1<<53 + 1, 1<<64 - 1}
+	formats := []string{"%d", `"%d"`}
+	for _, id := range ids {
+		for _, format := range formats {
+			jReq := &jsonRequest{
+				id:           id,
+				method:       "getblockcount",
+				responseChan: make(chan *response, 1),
+			}
+			if err := client.addRequest(jReq); err != nil {
+				t.Fatalf("addRequest: %v", err)
+			}
+			msg := fmt.Sprintf(`{"id":`+format+`,"result":100,"error":null}`, id)
+			client.handleMessage([]byte(msg))
+
+			select {
+			case resp := <-jReq.responseChan:
+				if resp.err != nil || string(resp.result) != "100" {
+					t.Fatalf("id %s: unexpected response %s, %v", msg,
+						resp.result, resp.err)
+				}
+			default:
+				t.Fatalf("response %s not delivered", msg)
+			}
+		}
+	}
+	if n := client.Stats().OrphanResponses; n != 0 {
+		t.Fatalf("%d orphan responses, want 0", n)
+	}
+}
+
+func TestParseAmountSat(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   int64
+		valid  bool
+	}{
+		{"0", 0, true},
+		{"1", 100000000, true},
+		{"0.00000001", 1, true},
+		{"0.1", 10000000, true},
+		{"-0.5", -50000000, true},
+		{"20999999.97690000", 2099999997690000, true},
+		// 2^53 + 1 satoshis, which a float64 rounds to 2^53.
+		{"90071992.54740993", 9007199254740993, true},
+		{"92233720368.54775807", 1<<63 - 1, true},
+		{"92233720368.54775808", 0, false},
+		{"0.123456789", 0, false},
+		{"1e-8", 0, false},
+		{".5", 0, false},
+		{"1.", 100000000, true},
+		{"", 0, false},
+		{"--1", 0, false},
+	}
+	for _, test := range tests {
+		got, err := ParseAmountSat(json.Number(test.amount))
+		if (err == nil) != test.valid {
+			t.Errorf("ParseAmountSat(%q): unexpected error %v", test.amount,
+				err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseAmountSat(%q) = %d, want %d", test.amount, got,
+				test.want)
+		}
+	}
+}
+
+// maxLitoshi is the total supply of 84 million LTC in litoshis.  The pinned
+// ltcutil package copied its MaxSatoshi of 21 million from btcutil.
+const maxLitoshi = 84e6 * ltcutil.SatoshiPerBitcoin
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount ltcutil.Amount
+		want   string
+	}{
+		{0, "0"},
+		{1, "0.00000001"},
+		{10, "0.0000001"},
+		{12345678, "0.12345678"},
+		{ltcutil.SatoshiPerBitcoin, "1"},
+		{-50000000, "-0.5"},
+		// 2^53 + 1 litoshis, which a float64 rounds to 2^53.
+		{9007199254740993, "90071992.54740993"},
+		{maxLitoshi - 1, "83999999.99999999"},
+		{maxLitoshi, "84000000"},
+	}
+	for _, test := range tests {
+		got := formatAmount(test.amount)
+		if string(got) != test.want {
+			t.Errorf("formatAmount(%d) = %s, want %s", int64(test.amount),
+				got, test.want)
+		}
+		if sat, err := ParseAmountSat(got); err != nil || sat != int64(test.amount) {
+			t.Errorf("ParseAmountSat(%s) = %d, %v", got, sat, err)
+		}
+	}
+}
+
+func TestUnmarshalResultAmounts(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return json.RawMessage(`{"vout":[{"value":90071992.54740993,"n":0},` +
+			`{"value":0.00000001,"n":1}]}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	result, err := client.RawRequest(context.Background(), "getrawtransaction", nil)
+	if err != nil {
+		t.Fatalf("RawRequest: %v", err)
+	}
+
+	// Verbose results decoded into generic values keep the exact amounts.
+	var tx map[string]interface{}
+	if err := UnmarshalResult(result, &tx); err != nil {
+		t.Fatalf("UnmarshalResult: %v", err)
+	}
+	var sats []int64
+	for _, vout := range tx["vout"].([]interface{}) {
+		value := vout.(map[string]interface{})["value"].(json.Number)
+		sat, err := ParseAmountSat(value)
+		if err != nil {
+			t.Fatalf("ParseAmountSat: %v", err)
+		}
+		sats = append(sats, sat)
+	}
+	if len(sats) != 2 || sats[0] != 9007199254740993 || sats[1] != 1 {
+		t.Fatalf("unexpected amounts %v", sats)
+	}
+
+	if err := UnmarshalResult([]byte(`{} {}`), &tx); err == nil {
+		t.Fatal("expected an error for trailing data")
+	}
+}
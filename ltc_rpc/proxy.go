@@ -0,0 +1,75 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/go-socks/socks"
+)
+
+// parseProxy parses the proxy of the passed connection configuration.  Proxies
+// with a socks5 scheme or without a scheme are returned as a SOCKS 5 proxy,
+// and proxies with an http or https scheme as the URL of an HTTP proxy.  The
+// proxy credentials of the configuration are added to either.  Both are nil
+// when no proxy is configured.
+func parseProxy(config *ConnConfig) (*socks.Proxy, *url.URL, error) {
+	if config.Proxy == "" {
+		return nil, nil, nil
+	}
+
+	addr := config.Proxy
+	if strings.Contains(addr, "://") {
+		proxyURL, err := url.Parse(addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch proxyURL.Scheme {
+		case "http", "https":
+			if config.ProxyUser != "" {
+				proxyURL.User = url.UserPassword(config.ProxyUser,
+					config.ProxyPass)
+			}
+			return nil, proxyURL, nil
+		case "socks5":
+			addr = proxyURL.Host
+		default:
+			return nil, nil, fmt.Errorf("unsupported proxy scheme %q",
+				proxyURL.Scheme)
+		}
+	}
+
+	proxy := &socks.Proxy{
+		Addr:     addr,
+		Username: config.ProxyUser,
+		Password: config.ProxyPass,
+	}
+	return proxy, nil, nil
+}
+
+// socksDialContext returns a function dialing connections through the passed
+// SOCKS 5 proxy for use as the DialContext of an HTTP transport.  A dial gives
+// up after the passed timeout or at the context deadline, whichever is sooner,
+// where a zero timeout means no timeout.
+func socksDialContext(proxy *socks.Proxy, timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialTimeout := timeout
+		if deadline, ok := ctx.Deadline(); ok {
+			untilDeadline := time.Until(deadline)
+			if untilDeadline <= 0 {
+				return nil, context.DeadlineExceeded
+			}
+			if dialTimeout == 0 || untilDeadline < dialTimeout {
+				dialTimeout = untilDeadline
+			}
+		}
+		return proxy.DialTimeout(network, addr, dialTimeout)
+	}
+}
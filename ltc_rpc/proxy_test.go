@@ -0,0 +1,288 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testSOCKSServer is a SOCKS 5 proxy which requires username and password
+// authentication and records the addresses it connects clients to.
+type testSOCKSServer struct {
+	listener net.Listener
+	user     string
+	pass     string
+
+	mtx     sync.Mutex
+	targets []string
+}
+
+// newTestSOCKSServer starts a SOCKS 5 proxy accepting the passed credentials.
+// Callers are responsible for closing it.
+func newTestSOCKSServer(t *testing.T, user, pass string) *testSOCKSServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &testSOCKSServer{listener: listener, user: user, pass: pass}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// Addr returns the address of the proxy.
+func (s *testSOCKSServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the proxy from accepting connections.
+func (s *testSOCKSServer) Close() {
+	s.listener.Close()
+}
+
+// connections returns the addresses the proxy connected clients to.
+func (s *testSOCKSServer) connections() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string(nil), s.targets...)
+}
+
+// serve handles a single client connection.
+func (s *testSOCKSServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// Greeting, which must offer username and password authentication.
+	var head [2]byte
+	if _, err := io.ReadFull(conn, head[:]); err != nil || head[0] != 5 {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+	if !bytes.Contains(methods, []byte{2}) {
+		conn.Write([]byte{5, 0xff})
+		return
+	}
+	conn.Write([]byte{5, 2})
+
+	// Username and password authentication.
+	readString := func() (string, error) {
+		var n [1]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return "", err
+		}
+		b := make([]byte, n[0])
+		_, err := io.ReadFull(conn, b)
+		return string(b), err
+	}
+	var version [1]byte
+	if _, err := io.ReadFull(conn, version[:]); err != nil {
+		return
+	}
+	user, err := readString()
+	if err != nil {
+		return
+	}
+	pass, err := readString()
+	if err != nil {
+		return
+	}
+	if user != s.user || pass != s.pass {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	// Connect request.  The client sends host names as domain names.
+	var req [4]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make(net.IP, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = ip.String()
+	case 3:
+		if host, err = readString(); err != nil {
+			return
+		}
+	default:
+		return
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(conn, port[:]); err != nil {
+		return
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	s.mtx.Lock()
+	s.targets = append(s.targets, target)
+	s.mtx.Unlock()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestSOCKSProxy(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+	proxy := newTestSOCKSServer(t, "proxyuser", "proxypass")
+	defer proxy.Close()
+	target := strings.TrimPrefix(server.URL, "http://")
+
+	for _, addr := range []string{"socks5://" + proxy.Addr(), proxy.Addr()} {
+		config := testConnConfig(server)
+		config.Proxy = addr
+		config.ProxyUser = "proxyuser"
+		config.ProxyPass = "proxypass"
+		config.DisableConnectionReuse = true
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		count, err := client.GetBlockCount(context.Background())
+		stopClient(client)
+		if err != nil {
+			t.Fatalf("GetBlockCount through %s: %v", addr, err)
+		}
+		if count != 100 {
+			t.Fatalf("unexpected block count %d", count)
+		}
+	}
+
+	conns := proxy.connections()
+	if len(conns) != 2 || conns[0] != target || conns[1] != target {
+		t.Fatalf("proxy connected to %v, want %s twice", conns, target)
+	}
+}
+
+func TestSOCKSProxyAuth(t *testing.T) {
+	server := newTestServer(t, blockCountHandler)
+	defer server.Close()
+	proxy := newTestSOCKSServer(t, "proxyuser", "proxypass")
+	defer proxy.Close()
+
+	config := testConnConfig(server)
+	config.Proxy = "socks5://" + proxy.Addr()
+	config.ProxyUser = "proxyuser"
+	config.ProxyPass = "wrong"
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err == nil {
+		t.Fatal("request succeeded with wrong proxy credentials")
+	}
+	if conns := proxy.connections(); len(conns) != 0 {
+		t.Fatalf("proxy connected to %v", conns)
+	}
+}
+
+func TestHTTPProxy(t *testing.T) {
+	// The test server acts as the proxy, so the RPC server host never has
+	// to resolve.
+	var mtx sync.Mutex
+	var requestURI, proxyAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		requestURI = r.RequestURI
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		mtx.Unlock()
+		w.Write([]byte(`{"id":1,"result":100,"error":null}`))
+	}))
+	defer proxy.Close()
+
+	config := testConnConfig(proxy)
+	config.Host = "rpc.invalid:8332"
+	config.Proxy = proxy.URL
+	config.ProxyUser = "proxyuser"
+	config.ProxyPass = "proxypass"
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	if _, err := client.GetBlockCount(context.Background()); err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if requestURI != "http://rpc.invalid:8332" && requestURI != "http://rpc.invalid:8332/" {
+		t.Fatalf("proxy received request for %q", requestURI)
+	}
+	if proxyAuth == "" {
+		t.Fatal("proxy credentials were not sent")
+	}
+}
+
+func TestParseProxy(t *testing.T) {
+	tests := []struct {
+		proxy     string
+		socksAddr string
+		httpURL   string
+		err       bool
+	}{
+		{proxy: ""},
+		{proxy: "127.0.0.1:9050", socksAddr: "127.0.0.1:9050"},
+		{proxy: "socks5://localhost:9050", socksAddr: "localhost:9050"},
+		{proxy: "http://proxy:3128", httpURL: "http://proxy:3128"},
+		{proxy: "https://proxy:3128", httpURL: "https://proxy:3128"},
+		{proxy: "socks4://proxy:1080", err: true},
+	}
+
+	for _, test := range tests {
+		socksProxy, proxyURL, err := parseProxy(&ConnConfig{Proxy: test.proxy})
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.proxy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.proxy, err)
+			continue
+		}
+		var socksAddr, httpURL string
+		if socksProxy != nil {
+			socksAddr = socksProxy.Addr
+		}
+		if proxyURL != nil {
+			httpURL = proxyURL.String()
+		}
+		if socksAddr != test.socksAddr || httpURL != test.httpURL {
+			t.Errorf("%q: got SOCKS proxy %q and HTTP proxy %q, want "+
+				"%q and %q", test.proxy, socksAddr, httpURL,
+				test.socksAddr, test.httpURL)
+		}
+	}
+}
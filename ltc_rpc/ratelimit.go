@@ -0,0 +1,105 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit configures client-side rate limiting of the requests sent to the
+// RPC server, for example to stay below the limits of a hosted node provider.
+// Callers issuing a request while the limit is exhausted block until the
+// request may be sent or their context is done.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained number of requests sent per
+	// second.  Values of zero or below disable rate limiting.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests which may be sent at once after the
+	// client was idle.  Values below one mean one.
+	Burst int
+}
+
+// rateLimiter is a token bucket which refills at a fixed rate up to its burst
+// size.  Every request takes a token, and requests which find the bucket
+// empty reserve a token in advance and wait until it has been refilled.
+type rateLimiter struct {
+	mtx    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a rate limiter enforcing the passed limit, or nil
+// when the limit does not restrict anything.
+func newRateLimiter(limit *RateLimit) *rateLimiter {
+	if limit == nil || limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   limit.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens from the bucket and returns how long to wait before
+// they are available.
+func (l *rateLimiter) reserve(n int) time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel returns n tokens which were reserved but not used.
+func (l *rateLimiter) cancel(n int) {
+	l.mtx.Lock()
+	l.tokens += float64(n)
+	l.mtx.Unlock()
+}
+
+// wait blocks until n requests may be sent.  It returns the context error
+// without waiting when the context deadline passes before that, or as soon as
+// the context is done while waiting.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	delay := l.reserve(n)
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		l.cancel(n)
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(n)
+		return ctx.Err()
+	}
+}
@@ -45,7 +45,7 @@ func (c *Client) RawRequestAsync(ctx context.Context, method string, params []js
 	// than custom commands.
 	id := c.NextID()
 	rawRequest := &btcjson.Request{
-		Jsonrpc: "1.0",
+		Jsonrpc: c.jsonRPCVersion(),
 		ID:      id,
 		Method:  method,
 		Params:  params,
@@ -74,6 +74,23 @@ func (c *Client) RawRequestAsync(ctx context.Context, method string, params []js
 // requests that are not handled by this client package, or to proxy partially
 // unmarshaled requests to another JSON-RPC server if a request cannot be
 // handled directly.
+//
+// The returned bytes are the raw "result" member of the server reply and may
+// be unmarshaled into any caller-defined type.  A server-side error is
+// returned as a *btcjson.RPCError.
 func (c *Client) RawRequest(ctx context.Context, method string, params []json.RawMessage) (json.RawMessage, error) {
 	return c.RawRequestAsync(ctx, method, params).Receive()
 }
+
+// marshalParams marshals each of the passed parameters of a raw request.
+func marshalParams(params []interface{}) ([]json.RawMessage, error) {
+	rawParams := make([]json.RawMessage, 0, len(params))
+	for _, param := range params {
+		marshalledParam, err := json.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		rawParams = append(rawParams, marshalledParam)
+	}
+	return rawParams, nil
+}
@@ -85,11 +85,16 @@ func (r FutureGetRawTransactionResult) Receive() (*ltcutil.Tx, error) {
 	}
 
 	// Deserialize the transaction and return it.
-	var msgTx wire.MsgTx
-	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+	msgTx, err := deserializeTx(serializedTx)
+	if err != nil {
 		return nil, err
 	}
-	return ltcutil.NewTx(&msgTx), nil
+	tx := ltcutil.NewTx(msgTx)
+
+	// The pinned ltcutil.Tx.HasWitness dereferences a nil pointer when
+	// WitnessHash was called first, unless its result is cached already.
+	tx.HasWitness()
+	return tx, nil
 }
 
 // GetRawTransactionAsync returns an instance of a type that can be used to get
@@ -242,16 +247,19 @@ func (r FutureCreateRawTransactionResult) Receive() (*wire.MsgTx, error) {
 func (c *Client) CreateRawTransactionAsync(ctx context.Context, inputs []btcjson.TransactionInput,
 	amounts map[ltcutil.Address]ltcutil.Amount, lockTime *int64) FutureCreateRawTransactionResult {
 
+	// The addresses are passed as their encoding, which also holds for the
+	// MWEB addresses of DecodeAddress.
 	convertedAmts := make(map[string]float64, len(amounts))
 	for addr, amount := range amounts {
-		convertedAmts[addr.String()] = amount.ToBTC()
+		convertedAmts[addr.EncodeAddress()] = amount.ToBTC()
 	}
 	cmd := btcjson.NewCreateRawTransactionCmd(inputs, convertedAmts, lockTime)
 	return c.sendCmd(ctx, cmd)
 }
 
 // CreateRawTransaction returns a new transaction spending the provided inputs
-// and sending to the provided addresses.
+// and sending to the provided addresses, which may be of any type
+// DecodeAddress returns, MWEB addresses included.
 func (c *Client) CreateRawTransaction(ctx context.Context, inputs []btcjson.TransactionInput,
 	amounts map[ltcutil.Address]ltcutil.Amount, lockTime *int64) (*wire.MsgTx, error) {
 
@@ -265,10 +273,13 @@ type FutureSendRawTransactionResult chan *response
 // Receive waits for the response promised by the future and returns the result
 // of submitting the encoded transaction to the server which then relays it to
 // the network.
+//
+// Transactions the server rejects fail with an *ErrTxRejected, and those
+// already in the block chain with an *ErrTxAlreadyInChain.
 func (r FutureSendRawTransactionResult) Receive() (*chainhash.Hash, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
-		return nil, err
+		return nil, sendRawTransactionError(err)
 	}
 
 	// Unmarshal result as a string.
@@ -297,16 +308,76 @@ func (c *Client) SendRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, al
 		txHex = hex.EncodeToString(buf.Bytes())
 	}
 
-	cmd := btcjson.NewSendRawTransactionCmd(txHex, &allowHighFees)
-	return c.sendCmd(ctx, cmd)
+	return c.sendRawTransactionHexAsync(ctx, txHex, allowHighFees)
+}
+
+// sendRawTransactionHexAsync sends the hex-serialized transaction.  Unless the
+// client is configured for legacy nodes, high fees are allowed by passing a
+// maxfeerate of 0, and the default limit of the node applies otherwise.
+func (c *Client) sendRawTransactionHexAsync(ctx context.Context, txHex string, allowHighFees bool) FutureSendRawTransactionResult {
+	if c.config.LegacySendRawTransaction {
+		cmd := btcjson.NewSendRawTransactionCmd(txHex, &allowHighFees)
+		return c.sendCmd(ctx, cmd)
+	}
+
+	// The btcjson command only knows the allowhighfees flag, so the call
+	// is sent as a raw request.
+	params := []interface{}{txHex}
+	if allowHighFees {
+		params = append(params, 0)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
 }
 
 // SendRawTransaction submits the encoded transaction to the server which will
 // then relay it to the network.
+//
+// Setting allowHighFees lifts the fee rate limit of the server.  Otherwise its
+// default limit applies.  Use SendRawTransactionWithMaxFeeRate to pass a limit
+// of its own.
 func (c *Client) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
 	return c.SendRawTransactionAsync(ctx, tx, allowHighFees).Receive()
 }
 
+// SendRawTransactionWithMaxFeeRateAsync returns an instance of a type that can
+// be used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SendRawTransactionWithMaxFeeRate for the blocking version and more
+// details.
+func (c *Client) SendRawTransactionWithMaxFeeRateAsync(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) FutureSendRawTransactionResult {
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson command only knows the allowhighfees flag, so the call
+	// is sent as a raw request.
+	params := []interface{}{hex.EncodeToString(buf.Bytes()), maxFeeRate}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendRawTransactionResult(c.RawRequestAsync(ctx,
+		"sendrawtransaction", rawParams))
+}
+
+// SendRawTransactionWithMaxFeeRate submits the encoded transaction to the
+// server which will then relay it to the network, as long as its fee rate does
+// not exceed maxFeeRate in LTC per kilo virtual byte.  A maxFeeRate of 0 means
+// no limit.
+//
+// Only Litecoin Core 0.18 and later support this function.
+func (c *Client) SendRawTransactionWithMaxFeeRate(ctx context.Context, tx *wire.MsgTx, maxFeeRate float64) (*chainhash.Hash, error) {
+	return c.SendRawTransactionWithMaxFeeRateAsync(ctx, tx, maxFeeRate).Receive()
+}
+
 // FutureSignRawTransactionResult is a future promise to deliver the result
 // of one of the SignRawTransactionAsync family of RPC invocations (or an
 // applicable error).
@@ -535,12 +606,11 @@ func (r FutureSearchRawTransactionsResult) Receive() ([]*wire.MsgTx, error) {
 		}
 
 		// Deserialize the transaction and add it to the result slice.
-		var msgTx wire.MsgTx
-		err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+		msgTx, err := deserializeTx(serializedTx)
 		if err != nil {
 			return nil, err
 		}
-		msgTxns = append(msgTxns, &msgTx)
+		msgTxns = append(msgTxns, msgTx)
 	}
 
 	return msgTxns, nil
@@ -632,14 +702,14 @@ type FutureDecodeScriptResult chan *response
 
 // Receive waits for the response promised by the future and returns information
 // about a script given its serialized bytes.
-func (r FutureDecodeScriptResult) Receive() (*btcjson.DecodeScriptResult, error) {
+func (r FutureDecodeScriptResult) Receive() (*DecodeScriptResult, error) {
 	res, err := receiveFuture(r)
 	if err != nil {
 		return nil, err
 	}
 
 	// Unmarshal result as a decodescript result object.
-	var decodeScriptResult btcjson.DecodeScriptResult
+	var decodeScriptResult DecodeScriptResult
 	err = json.Unmarshal(res, &decodeScriptResult)
 	if err != nil {
 		return nil, err
@@ -659,7 +729,11 @@ func (c *Client) DecodeScriptAsync(ctx context.Context, serializedScript []byte)
 	return c.sendCmd(ctx, cmd)
 }
 
-// DecodeScript returns information about a script given its serialized bytes.
-func (c *Client) DecodeScript(ctx context.Context, serializedScript []byte) (*btcjson.DecodeScriptResult, error) {
+// DecodeScript returns information about a script given its serialized bytes,
+// such as its type and the P2SH and segwit addresses paying to it as a redeem
+// or witness script.
+//
+// See ClassifyScript to classify a script without asking a node.
+func (c *Client) DecodeScript(ctx context.Context, serializedScript []byte) (*DecodeScriptResult, error) {
 	return c.DecodeScriptAsync(ctx, serializedScript).Receive()
 }
@@ -0,0 +1,201 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// genesisCoinbaseTx is the serialized coinbase transaction of the Bitcoin
+// genesis block.
+const genesisCoinbaseTx = "01000000010000000000000000000000000000000000000000" +
+	"000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65" +
+	"732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b" +
+	"206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff01" +
+	"00f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e039" +
+	"09a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d" +
+	"578a4c702b6bf11d5fac00000000"
+
+func BenchmarkGetRawTransactionWorkers(b *testing.B) {
+	server := newTestServer(b, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		time.Sleep(5 * time.Millisecond)
+		return genesisCoinbaseTx, nil
+	})
+	defer server.Close()
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			config := testConnConfig(server)
+			config.HTTPPostWorkers = workers
+			client, err := New(config)
+			if err != nil {
+				b.Fatalf("New: %v", err)
+			}
+			defer stopClient(client)
+
+			// Keep more calls outstanding than there are workers.
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := client.GetRawTransaction(context.Background(),
+						&chainhash.Hash{})
+					if err != nil {
+						b.Errorf("GetRawTransaction: %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	const txHash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(5000, []byte{0x51}))
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	txHex := `"` + hex.EncodeToString(buf.Bytes()) + `"`
+
+	// Old nodes take the allowhighfees flag, newer nodes a numeric
+	// maxfeerate which they reject the flag in place of.
+	tests := []struct {
+		name          string
+		legacy        bool
+		allowHighFees bool
+		want          string
+	}{
+		{"default maxfeerate", false, false, `[` + txHex + `]`},
+		{"maxfeerate without limit", false, true, `[` + txHex + `,0]`},
+		{"legacy flag", true, false, `[` + txHex + `,false]`},
+		{"legacy flag allowing high fees", true, true, `[` + txHex + `,true]`},
+	}
+	for _, test := range tests {
+		var mtx sync.Mutex
+		var params string
+		legacy := test.legacy
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			p, _ := json.Marshal(req.Params)
+			mtx.Lock()
+			params = string(p)
+			mtx.Unlock()
+			if len(req.Params) > 1 {
+				var maxFeeRate float64
+				isNumber := json.Unmarshal(req.Params[1], &maxFeeRate) == nil
+				if isNumber == legacy {
+					return nil, btcjson.NewRPCError(btcjson.ErrRPCType,
+						"JSON value is not a number as expected")
+				}
+			}
+			return txHash, nil
+		})
+		config := testConnConfig(server)
+		config.LegacySendRawTransaction = test.legacy
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+
+		hash, err := client.SendRawTransaction(context.Background(), tx,
+			test.allowHighFees)
+		stopClient(client)
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: SendRawTransaction: %v", test.name, err)
+		}
+		if hash.String() != txHash {
+			t.Fatalf("%s: unexpected hash %v", test.name, hash)
+		}
+		mtx.Lock()
+		if params != test.want {
+			t.Errorf("%s: sent params %s, want %s", test.name, params,
+				test.want)
+		}
+		mtx.Unlock()
+	}
+
+	// The maxfeerate is passed as is.
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return txHash, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	if _, err := client.SendRawTransactionWithMaxFeeRate(context.Background(),
+		tx, 0.001); err != nil {
+
+		t.Fatalf("SendRawTransactionWithMaxFeeRate: %v", err)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if got, _ := json.Marshal(params); string(got) != `[`+txHex+`,0.001]` {
+		t.Fatalf("sent params %s", got)
+	}
+}
+
+func TestSendRawTransactionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    btcjson.RPCErrorCode
+		message string
+		reason  string
+	}{
+		{"rejected", -26, "dust", "dust"},
+		{"rejected with reject code", -26, "64: non-mandatory-script-verify-flag", "non-mandatory-script-verify-flag"},
+		{"already in chain", -27, "Transaction already in block chain", ""},
+		{"missing inputs", -25, "Missing inputs", ""},
+	}
+	for _, test := range tests {
+		server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+			return nil, btcjson.NewRPCError(test.code, test.message)
+		})
+		client := newTestClient(t, server)
+		_, err := client.SendRawTransaction(context.Background(),
+			wire.NewMsgTx(wire.TxVersion), false)
+		stopClient(client)
+		server.Close()
+
+		var rejected *ErrTxRejected
+		var inChain *ErrTxAlreadyInChain
+		switch test.code {
+		case -26:
+			if !errors.As(err, &rejected) || rejected.Reason != test.reason {
+				t.Fatalf("%s: unexpected error %v", test.name, err)
+			}
+		case -27:
+			if !errors.As(err, &inChain) {
+				t.Fatalf("%s: unexpected error %v", test.name, err)
+			}
+		default:
+			if errors.As(err, &rejected) || errors.As(err, &inChain) ||
+				!IsMissingInputs(err) {
+
+				t.Fatalf("%s: unexpected error %v", test.name, err)
+			}
+		}
+
+		// The error of the server stays available.
+		var rpcErr *btcjson.RPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != test.code {
+			t.Fatalf("%s: RPC error not wrapped: %v", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+//go:build regtest
+// +build regtest
+
+// The tests of this file run against a throwaway Litecoin Core node on
+// regtest.  Each test starts litecoind, taken from LITECOIND or the PATH, with
+// a fresh data directory and the transaction index, and stops it and removes
+// the directory when done.  The tests are skipped when litecoind is not found.
+// To run them against an existing node instead, set LTC_RPC_REGTEST_HOST to
+// its address and LTC_RPC_REGTEST_USER and LTC_RPC_REGTEST_PASS to its
+// credentials.  The node then needs -txindex, and must not be shared with
+// other tests since they mine blocks and reorganize the chain.  Run them with
+//
+//	go test -tags regtest -run Regtest ./...
+
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+)
+
+// regtestStartTimeout bounds how long a started node may take to answer.
+const regtestStartTimeout = 30 * time.Second
+
+// newRegtestClient returns a client talking to a regtest node and a function
+// shutting the client down and stopping the node, if it was started.
+func newRegtestClient(t *testing.T) (*Client, func()) {
+	t.Helper()
+
+	if host := os.Getenv("LTC_RPC_REGTEST_HOST"); host != "" {
+		client := connectRegtest(t, host, os.Getenv("LTC_RPC_REGTEST_USER"),
+			os.Getenv("LTC_RPC_REGTEST_PASS"))
+		return client, func() { stopClient(client) }
+	}
+
+	litecoind := os.Getenv("LITECOIND")
+	if litecoind == "" {
+		litecoind = "litecoind"
+	}
+	path, err := exec.LookPath(litecoind)
+	if err != nil {
+		t.Skipf("no regtest node: %v", err)
+	}
+	dataDir, err := ioutil.TempDir("", "ltc_rpc_regtest")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	port := freePort(t)
+	cmd := exec.Command(path, "-regtest", "-datadir="+dataDir,
+		"-rpcport="+strconv.Itoa(port), "-rpcuser=user",
+		"-rpcpassword=pass", "-txindex", "-listen=0", "-disablewallet",
+		"-printtoconsole=0")
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		t.Fatalf("starting %s: %v", path, err)
+	}
+
+	client := connectRegtest(t, "127.0.0.1:"+strconv.Itoa(port), "user",
+		"pass")
+	cleanup := func() {
+		stopClient(client)
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			cmd.Process.Kill()
+		}
+		cmd.Wait()
+		os.RemoveAll(dataDir)
+	}
+
+	// The node refuses requests while it is warming up.
+	deadline := time.Now().Add(regtestStartTimeout)
+	for {
+		_, err := client.GetBlockCount(context.Background())
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cleanup()
+			t.Fatalf("node did not start: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return client, cleanup
+}
+
+// connectRegtest returns a client configured for regtest talking to the node
+// at the passed address.
+func connectRegtest(t *testing.T, host, user, pass string) *Client {
+	t.Helper()
+
+	client, err := New(&ConnConfig{
+		Host:         host,
+		User:         user,
+		Pass:         pass,
+		ChainParams:  &RegressionNetParams,
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return client
+}
+
+// freePort returns a TCP port on the loopback interface nothing listens on.
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// mineRegtestBlocks mines n blocks and returns their hashes.
+func mineRegtestBlocks(t *testing.T, client *Client, n int64) []*chainhash.Hash {
+	t.Helper()
+
+	addr, err := DecodeAddress(regtestMiningAddr, &RegressionNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	hashes, err := client.GenerateToAddress(context.Background(), n, addr, nil)
+	if err != nil {
+		t.Fatalf("GenerateToAddress: %v", err)
+	}
+	if int64(len(hashes)) != n {
+		t.Fatalf("mined %d blocks, want %d", len(hashes), n)
+	}
+	return hashes
+}
+
+func TestRegtestGenerate(t *testing.T) {
+	client, cleanup := newRegtestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	height, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	hashes := mineRegtestBlocks(t, client, 5)
+	newHeight, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if newHeight != height+5 {
+		t.Fatalf("got height %d after mining, want %d", newHeight, height+5)
+	}
+
+	for i, hash := range hashes {
+		heightHash, err := client.GetBlockHash(ctx, height+1+int64(i))
+		if err != nil {
+			t.Fatalf("GetBlockHash: %v", err)
+		}
+		if !heightHash.IsEqual(hash) {
+			t.Fatalf("block %d has hash %v, want %v", i, heightHash, hash)
+		}
+
+		// The coinbase transaction comes back from the transaction index
+		// as it is in the block.
+		block, err := client.GetBlock(ctx, hash)
+		if err != nil {
+			t.Fatalf("GetBlock: %v", err)
+		}
+		coinbase := block.Transactions[0]
+		coinbaseHash := coinbase.TxHash()
+		tx, err := client.GetRawTransaction(ctx, &coinbaseHash)
+		if err != nil {
+			t.Fatalf("GetRawTransaction: %v", err)
+		}
+		var want, got bytes.Buffer
+		coinbase.Serialize(&want)
+		tx.MsgTx().Serialize(&got)
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Fatalf("coinbase %v does not round-trip", coinbaseHash)
+		}
+	}
+}
+
+func TestRegtestReorg(t *testing.T) {
+	client, cleanup := newRegtestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	hashes := mineRegtestBlocks(t, client, 3)
+	height, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+
+	// Invalidating the first of the blocks takes all three off the chain.
+	if err := client.InvalidateBlock(ctx, hashes[0]); err != nil {
+		t.Fatalf("InvalidateBlock: %v", err)
+	}
+	reorgHeight, err := client.GetBlockCount(ctx)
+	if err != nil {
+		t.Fatalf("GetBlockCount: %v", err)
+	}
+	if reorgHeight != height-3 {
+		t.Fatalf("got height %d after invalidating, want %d", reorgHeight,
+			height-3)
+	}
+	onBestChain, err := client.IsOnBestChain(ctx, hashes[2])
+	if err != nil || onBestChain {
+		t.Fatalf("IsOnBestChain after invalidating: got %v, %v, want false",
+			onBestChain, err)
+	}
+
+	if err := client.ReconsiderBlock(ctx, hashes[0]); err != nil {
+		t.Fatalf("ReconsiderBlock: %v", err)
+	}
+	best, err := client.GetBestBlockHash(ctx)
+	if err != nil {
+		t.Fatalf("GetBestBlockHash: %v", err)
+	}
+	if !best.IsEqual(hashes[2]) {
+		t.Fatalf("got best block %s after reconsidering, want %s", best,
+			hashes[2])
+	}
+	onBestChain, err = client.IsOnBestChain(ctx, hashes[2])
+	if err != nil || !onBestChain {
+		t.Fatalf("IsOnBestChain after reconsidering: got %v, %v, want true",
+			onBestChain, err)
+	}
+}
+
+func TestRegtestPrioritiseTransaction(t *testing.T) {
+	client, cleanup := newRegtestClient(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Fee deltas apply to transactions which are not in the memory pool
+	// yet, so any transaction hash does.
+	txid := chainhash.Hash{1}
+	applied, err := client.PrioritiseTransaction(ctx, &txid, 1000)
+	if err != nil || !applied {
+		t.Fatalf("PrioritiseTransaction: got %v, %v, want true", applied,
+			err)
+	}
+	if _, err := client.PrioritiseTransaction(ctx, &txid, -1000); err != nil {
+		t.Fatalf("PrioritiseTransaction: %v", err)
+	}
+
+	info, err := client.GetMempoolInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetMempoolInfo: %v", err)
+	}
+	if info.MinRelayTxFee <= 0 {
+		t.Fatalf("got minimum relay fee %v, want a positive one",
+			info.MinRelayTxFee)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultRetryInitialBackoff is the wait before the first retry when
+	// a retry policy does not specify one.
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+
+	// defaultRetryMaxBackoff is the longest wait between two attempts when
+	// a retry policy does not specify one.
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+// RetryPolicy configures how HTTP POST mode requests which fail with a
+// transient error are retried.
+//
+// Only methods which do not change any state on the server, such as getblock
+// or getblockcount, are retried by default.  Other methods, such as
+// sendrawtransaction, are only retried when the context passed to the call
+// was returned by WithRetry.
+//
+// A request waiting to be retried occupies its HTTP POST worker, so with the
+// default of a single worker any requests queued behind it wait as well.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent,
+	// including the first attempt.  Values below two disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry.  The wait doubles
+	// after every further attempt.  A zero value means 100ms.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between two attempts.  A zero value means
+	// five seconds.
+	MaxBackoff time.Duration
+
+	// Retryable reports whether a failed attempt should be retried.  A nil
+	// Retryable retries the errors IsTransient reports as transient.
+	Retryable func(err error) bool
+}
+
+// backoff returns the wait before the attempt following the passed attempt,
+// which is counted from one.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	initial, max := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = defaultRetryInitialBackoff
+	}
+	if max <= 0 {
+		max = defaultRetryMaxBackoff
+	}
+	backoff := initial
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// retryable returns whether the passed error is worth retrying according to
+// the policy.
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransient(err)
+}
+
+// idempotentMethods are the methods which are retried without the caller
+// opting in since sending them more than once is harmless.
+var idempotentMethods = map[string]struct{}{
+	"decoderawtransaction":  {},
+	"decodescript":          {},
+	"estimatefee":           {},
+	"estimatesmartfee":      {},
+	"getbestblock":          {},
+	"getbestblockhash":      {},
+	"getblock":              {},
+	"getblockchaininfo":     {},
+	"getblockcount":         {},
+	"getblockhash":          {},
+	"getblockheader":        {},
+	"getcfilter":            {},
+	"getcfilterheader":      {},
+	"getconnectioncount":    {},
+	"getcurrentnet":         {},
+	"getdifficulty":         {},
+	"getheaders":            {},
+	"getinfo":               {},
+	"getmempoolentry":       {},
+	"getmempoolinfo":        {},
+	"getnetworkinfo":        {},
+	"getpeerinfo":           {},
+	"getrawmempool":         {},
+	"getrawtransaction":     {},
+	"gettxout":              {},
+	"searchrawtransactions": {},
+	"uptime":                {},
+	"validateaddress":       {},
+	"version":               {},
+}
+
+// retryKey is the context key marking a request as safe to retry.
+type retryKey struct{}
+
+// WithRetry returns a context which allows the request it is passed to be
+// retried according to the client's RetryPolicy even when its method is not
+// known to be idempotent.  It has no effect on clients without a RetryPolicy.
+func WithRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryKey{}, true)
+}
+
+// shouldRetry returns whether the passed request may be retried at all.
+func (c *Client) shouldRetry(ctx context.Context, method string) bool {
+	policy := c.config.RetryPolicy
+	if policy == nil || policy.MaxAttempts < 2 {
+		return false
+	}
+	if _, ok := idempotentMethods[method]; ok {
+		return true
+	}
+	optIn, _ := ctx.Value(retryKey{}).(bool)
+	return optIn
+}
+
+// IsTransient returns whether the passed error is a failure which may go away
+// when the request is sent again, such as a dropped connection or a server
+// which is temporarily unavailable.  Errors returned by the server for the
+// request itself are never transient.
+func IsTransient(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// Context errors are wrapped by the HTTP client as well, but they
+	// mean the caller gave up.
+	if errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// RetryInstrumentation is implemented by Instrumentation implementations
+// which also want to observe retries.  RequestRetried is called every time a
+// failed attempt is about to be retried, with the number of the failed
+// attempt counted from one.
+type RetryInstrumentation interface {
+	RequestRetried(method string, attempt int, err error)
+}
+
+// waitRetry reports the failed attempt and waits before the request is sent
+// again.  It returns false without waiting when the request must not be
+// retried, including when the context deadline would pass or the client shuts
+// down before the next attempt.
+func (c *Client) waitRetry(ctx context.Context, jReq *jsonRequest, attempt int, err error) bool {
+	policy := c.config.RetryPolicy
+	if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+		return false
+	}
+	backoff := policy.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+		return false
+	}
+
+	c.log.Debugf("Retrying command [%s] with id %d in %v after attempt "+
+		"%d failed: %v", jReq.method, jReq.id, backoff, attempt, err)
+	if instr, ok := c.config.Instrumentation.(RetryInstrumentation); ok {
+		instr.RequestRetried(jReq.method, attempt, err)
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+	case <-c.shutdown:
+	}
+	return false
+}
@@ -0,0 +1,325 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ltcsuite/ltcutil"
+)
+
+// Actions accepted by ScanTxOutSet.
+const (
+	// ScanActionStart starts a scan and waits for it to finish.
+	ScanActionStart = "start"
+
+	// ScanActionStatus returns the progress of the running scan.
+	ScanActionStatus = "status"
+
+	// ScanActionAbort aborts the running scan.  It is only supported by
+	// ScanTxOutSetAbort.
+	ScanActionAbort = "abort"
+)
+
+// ErrScanInProgress is returned by ScanTxOutSet when another scan is already
+// running on the server, which only runs one at a time.
+var ErrScanInProgress = errors.New("a UTXO set scan is already in progress")
+
+// ScanRange is the range of HD chain indexes explored for a ranged descriptor.
+// Both ends are included.
+type ScanRange struct {
+	Begin uint32
+	End   uint32
+}
+
+// MarshalJSON encodes the scan range as the server expects it.  Ranges
+// starting at zero are encoded as their end alone, which is understood by all
+// server versions.
+func (r ScanRange) MarshalJSON() ([]byte, error) {
+	if r.Begin > r.End {
+		return nil, fmt.Errorf("range begin %d after end %d", r.Begin,
+			r.End)
+	}
+	if r.Begin == 0 {
+		return json.Marshal(r.End)
+	}
+	return json.Marshal([]uint32{r.Begin, r.End})
+}
+
+// ScanObject is an output descriptor whose outputs are searched for by
+// ScanTxOutSet.
+type ScanObject struct {
+	// Desc is the output descriptor, such as
+	// "wpkh([d34db33f/84h/2h/0h]xpub.../0/*)".  Litecoin Core reads
+	// extended keys with the xpub and tpub prefixes, not Ltub.
+	Desc string
+
+	// Range is the range of indexes explored for descriptors with a
+	// wildcard.  The server explores indexes 0 to 1000 when it is nil.
+	Range *ScanRange
+}
+
+// MarshalJSON encodes the scan object as the server expects it.  Objects
+// without a range are encoded as the bare descriptor.
+func (o ScanObject) MarshalJSON() ([]byte, error) {
+	if o.Range == nil {
+		return json.Marshal(o.Desc)
+	}
+	return json.Marshal(struct {
+		Desc  string     `json:"desc"`
+		Range *ScanRange `json:"range"`
+	}{o.Desc, o.Range})
+}
+
+// ScanTxOutSetUnspent describes an unspent output found by ScanTxOutSet.
+type ScanTxOutSetUnspent struct {
+	Txid         string
+	Vout         uint32
+	ScriptPubKey string
+	Desc         string
+	Amount       ltcutil.Amount
+	Height       int64
+
+	// Coinbase is only reported by newer nodes and false otherwise.
+	Coinbase bool
+}
+
+// ScanTxOutSetResult models the data returned from the scantxoutset command.
+type ScanTxOutSetResult struct {
+	// Success is false when the scan was aborted.
+	Success bool
+
+	// TxOuts is the number of unspent outputs scanned, at Height with
+	// the hash BestBlock.
+	TxOuts    int64
+	Height    int64
+	BestBlock string
+
+	Unspents    []ScanTxOutSetUnspent
+	TotalAmount ltcutil.Amount
+}
+
+// UnmarshalJSON decodes the result of the scantxoutset command, converting the
+// amounts in LTC to litoshis exactly.
+func (r *ScanTxOutSetResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		Success   bool   `json:"success"`
+		TxOuts    int64  `json:"txouts"`
+		Height    int64  `json:"height"`
+		BestBlock string `json:"bestblock"`
+		Unspents  []struct {
+			Txid         string      `json:"txid"`
+			Vout         uint32      `json:"vout"`
+			ScriptPubKey string      `json:"scriptPubKey"`
+			Desc         string      `json:"desc"`
+			Amount       json.Number `json:"amount"`
+			Coinbase     bool        `json:"coinbase"`
+			Height       int64       `json:"height"`
+		} `json:"unspents"`
+		TotalAmount json.Number `json:"total_amount"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	totalAmount, err := ParseAmountSat(result.TotalAmount)
+	if err != nil {
+		return err
+	}
+	unspents := make([]ScanTxOutSetUnspent, 0, len(result.Unspents))
+	for _, unspent := range result.Unspents {
+		amount, err := ParseAmountSat(unspent.Amount)
+		if err != nil {
+			return err
+		}
+		unspents = append(unspents, ScanTxOutSetUnspent{
+			Txid:         unspent.Txid,
+			Vout:         unspent.Vout,
+			ScriptPubKey: unspent.ScriptPubKey,
+			Desc:         unspent.Desc,
+			Amount:       ltcutil.Amount(amount),
+			Height:       unspent.Height,
+			Coinbase:     unspent.Coinbase,
+		})
+	}
+
+	*r = ScanTxOutSetResult{
+		Success:     result.Success,
+		TxOuts:      result.TxOuts,
+		Height:      result.Height,
+		BestBlock:   result.BestBlock,
+		Unspents:    unspents,
+		TotalAmount: ltcutil.Amount(totalAmount),
+	}
+	return nil
+}
+
+// FutureScanTxOutSetResult is a future promise to deliver the result of a
+// ScanTxOutSetAsync RPC invocation (or an applicable error).
+type FutureScanTxOutSetResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// unspent outputs found by the scan.
+func (r FutureScanTxOutSetResult) Receive() (*ScanTxOutSetResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		if isScanInProgress(err) {
+			return nil, ErrScanInProgress
+		}
+		return nil, err
+	}
+
+	// Unmarshal result as a scantxoutset result object.
+	var scanResult ScanTxOutSetResult
+	err = json.Unmarshal(res, &scanResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scanResult, nil
+}
+
+// ScanTxOutSetAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ScanTxOutSet for the blocking version and more details.
+func (c *Client) ScanTxOutSetAsync(ctx context.Context, action string, scanObjects []ScanObject) FutureScanTxOutSetResult {
+	if action != ScanActionStart {
+		return newFutureError(fmt.Errorf("unsupported scan action %q, "+
+			"see ScanTxOutSetStatus and ScanTxOutSetAbort", action))
+	}
+	if scanObjects == nil {
+		scanObjects = []ScanObject{}
+	}
+	rawParams, err := marshalParams([]interface{}{action, scanObjects})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureScanTxOutSetResult(c.RawRequestAsync(ctx, "scantxoutset",
+		rawParams))
+}
+
+// ScanTxOutSet scans the UTXO set of the server for outputs matching the
+// passed descriptors and returns them along with their total amount.  The
+// action must be ScanActionStart, the call then blocks until the scan is
+// done, which takes minutes on mainnet, so the context and the
+// ResponseHeaderTimeout of the connection should allow for it.  Litecoin Core
+// supports the command since 0.17.
+//
+// The server only runs one scan at a time and fails with ErrScanInProgress
+// while another one is running.  See ScanTxOutSetStatus and ScanTxOutSetAbort
+// to watch and abort the running scan.
+func (c *Client) ScanTxOutSet(ctx context.Context, action string, scanObjects []ScanObject) (*ScanTxOutSetResult, error) {
+	return c.ScanTxOutSetAsync(ctx, action, scanObjects).Receive()
+}
+
+// ScanTxOutSetStart is a convenience for ScanTxOutSet with ScanActionStart.
+func (c *Client) ScanTxOutSetStart(ctx context.Context, scanObjects []ScanObject) (*ScanTxOutSetResult, error) {
+	return c.ScanTxOutSet(ctx, ScanActionStart, scanObjects)
+}
+
+// FutureScanTxOutSetStatusResult is a future promise to deliver the result of
+// a ScanTxOutSetStatusAsync RPC invocation (or an applicable error).
+type FutureScanTxOutSetStatusResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// progress of the running scan in percent, and whether a scan is running at
+// all.
+func (r FutureScanTxOutSetStatusResult) Receive() (float64, bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Unmarshal result as a status object, which is null when no scan is
+	// running.
+	var status *struct {
+		Progress float64 `json:"progress"`
+	}
+	err = json.Unmarshal(res, &status)
+	if err != nil {
+		return 0, false, err
+	}
+	if status == nil {
+		return 0, false, nil
+	}
+
+	return status.Progress, true, nil
+}
+
+// ScanTxOutSetStatusAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ScanTxOutSetStatus for the blocking version and more details.
+func (c *Client) ScanTxOutSetStatusAsync(ctx context.Context) FutureScanTxOutSetStatusResult {
+	rawParams, err := marshalParams([]interface{}{ScanActionStatus})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureScanTxOutSetStatusResult(c.RawRequestAsync(ctx,
+		"scantxoutset", rawParams))
+}
+
+// ScanTxOutSetStatus returns the progress in percent of the scan started by
+// ScanTxOutSet, and whether a scan is running at all.
+func (c *Client) ScanTxOutSetStatus(ctx context.Context) (float64, bool, error) {
+	return c.ScanTxOutSetStatusAsync(ctx).Receive()
+}
+
+// FutureScanTxOutSetAbortResult is a future promise to deliver the result of a
+// ScanTxOutSetAbortAsync RPC invocation (or an applicable error).
+type FutureScanTxOutSetAbortResult chan *response
+
+// Receive waits for the response promised by the future and returns whether a
+// running scan was aborted.
+func (r FutureScanTxOutSetAbortResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	// Unmarshal result as a boolean.
+	var aborted bool
+	err = json.Unmarshal(res, &aborted)
+	if err != nil {
+		return false, err
+	}
+
+	return aborted, nil
+}
+
+// ScanTxOutSetAbortAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ScanTxOutSetAbort for the blocking version and more details.
+func (c *Client) ScanTxOutSetAbortAsync(ctx context.Context) FutureScanTxOutSetAbortResult {
+	rawParams, err := marshalParams([]interface{}{ScanActionAbort})
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureScanTxOutSetAbortResult(c.RawRequestAsync(ctx,
+		"scantxoutset", rawParams))
+}
+
+// ScanTxOutSetAbort aborts the scan started by ScanTxOutSet, which then returns
+// a result whose Success is false.  It returns whether a scan was running.
+func (c *Client) ScanTxOutSetAbort(ctx context.Context) (bool, error) {
+	return c.ScanTxOutSetAbortAsync(ctx).Receive()
+}
@@ -0,0 +1,171 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+// testScanDesc is a ranged descriptor of the receive chain of a BIP84 account
+// of coin type 2, which is Litecoin.
+const testScanDesc = "wpkh([d34db33f/84h/2h/0h]xpub6DJ2dNUysrn5Vt36jH2KLBT2i1auw1tTSSomg8PhqNiUtx8QX2SvC9nrHu81fT41fvDUnhMjEzQgXnQjKEu3oaqMSzhSrHMxyyoEAmUHQbY/0/*)#dsxj9345"
+
+func TestScanObjectMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  ScanObject
+		want string
+	}{
+		{"descriptor", ScanObject{Desc: "addr(ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9)"},
+			`"addr(ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9)"`},
+		{"range end", ScanObject{Desc: testScanDesc, Range: &ScanRange{End: 999}},
+			`{"desc":"` + testScanDesc + `","range":999}`},
+		{"range", ScanObject{Desc: testScanDesc, Range: &ScanRange{Begin: 1000, End: 1999}},
+			`{"desc":"` + testScanDesc + `","range":[1000,1999]}`},
+		{"single index", ScanObject{Desc: testScanDesc, Range: &ScanRange{Begin: 7, End: 7}},
+			`{"desc":"` + testScanDesc + `","range":[7,7]}`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.obj)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", test.name, err)
+		}
+		if string(got) != test.want {
+			t.Fatalf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	obj := ScanObject{Desc: testScanDesc, Range: &ScanRange{Begin: 2, End: 1}}
+	if _, err := json.Marshal(obj); err == nil {
+		t.Fatal("expected an error for an inverted range")
+	}
+}
+
+func TestScanTxOutSet(t *testing.T) {
+	const scanResult = `{
+  "success": true,
+  "txouts": 153215678,
+  "height": 2500000,
+  "bestblock": "f4ce8a9eb7fbd2e4a5d26adc1d4c1e5f5c5a4b3f3d2e1a0b9c8d7e6f5a4b3c2d",
+  "unspents": [{
+    "txid": "0d7c2fa8a0d4cd6bd8ad6ac2d2c8e2eadb1ac0d8ff3b5b4b1b4f3c5f7d5a8c41",
+    "vout": 0,
+    "scriptPubKey": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+    "desc": "wpkh([d34db33f/84h/2h/0h/0/3]0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)#cpg7fv55",
+    "amount": 0.00990000,
+    "height": 2499990
+  }, {
+    "txid": "9a6d0f6b2a6c8bdbd3c1b5f5d5c6e0a7c1b0d7e6f2a8b9c0d1e2f3a4b5c6d7e8",
+    "vout": 1,
+    "scriptPubKey": "001406afd46bcdfd22ef94ac122aa11f241244a37ecc",
+    "desc": "wpkh([d34db33f/84h/2h/0h/0/1012]02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)#6lqkmk8s",
+    "amount": 12345678.87654321,
+    "height": 2490000
+  }],
+  "total_amount": 12345678.88644321
+}`
+
+	var mtx sync.Mutex
+	var params string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = string(p)
+		mtx.Unlock()
+		return json.RawMessage(scanResult), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	result, err := client.ScanTxOutSetStart(context.Background(), []ScanObject{
+		{Desc: testScanDesc, Range: &ScanRange{End: 999}},
+		{Desc: testScanDesc, Range: &ScanRange{Begin: 1000, End: 1999}},
+	})
+	if err != nil {
+		t.Fatalf("ScanTxOutSetStart: %v", err)
+	}
+	mtx.Lock()
+	want := `["start",[{"desc":"` + testScanDesc + `","range":999},` +
+		`{"desc":"` + testScanDesc + `","range":[1000,1999]}]]`
+	if params != want {
+		t.Fatalf("sent params %s, want %s", params, want)
+	}
+	mtx.Unlock()
+
+	if !result.Success || result.Height != 2500000 || len(result.Unspents) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.Unspents[0].Amount != 990000 ||
+		result.Unspents[1].Amount != 1234567887654321 ||
+		result.TotalAmount != 1234567888644321 {
+
+		t.Fatalf("unexpected amounts %+v", result)
+	}
+	if result.Unspents[1].Vout != 1 || result.Unspents[1].Height != 2490000 {
+		t.Fatalf("unexpected unspent %+v", result.Unspents[1])
+	}
+
+	_, err = client.ScanTxOutSet(context.Background(), ScanActionAbort, nil)
+	if err == nil {
+		t.Fatal("expected an error for the abort action")
+	}
+}
+
+func TestScanTxOutSetStatusAndAbort(t *testing.T) {
+	var mtx sync.Mutex
+	running := true
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		var action string
+		json.Unmarshal(req.Params[0], &action)
+		mtx.Lock()
+		defer mtx.Unlock()
+		switch action {
+		case "start":
+			if running {
+				return nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidParameter,
+					`Scan already in progress, use action "abort" or "status"`)
+			}
+			return json.RawMessage(`{"success":true,"unspents":[],"total_amount":0}`), nil
+		case "status":
+			if !running {
+				return nil, nil
+			}
+			return json.RawMessage(`{"progress":42.5}`), nil
+		case "abort":
+			aborted := running
+			running = false
+			return aborted, nil
+		}
+		t.Errorf("unexpected action %q", action)
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	if _, err := client.ScanTxOutSetStart(ctx, nil); err != ErrScanInProgress {
+		t.Fatalf("unexpected error %v", err)
+	}
+	progress, scanning, err := client.ScanTxOutSetStatus(ctx)
+	if err != nil || !scanning || progress != 42.5 {
+		t.Fatalf("ScanTxOutSetStatus = %v, %v, %v", progress, scanning, err)
+	}
+	aborted, err := client.ScanTxOutSetAbort(ctx)
+	if err != nil || !aborted {
+		t.Fatalf("ScanTxOutSetAbort = %v, %v", aborted, err)
+	}
+	if _, scanning, err := client.ScanTxOutSetStatus(ctx); err != nil || scanning {
+		t.Fatalf("ScanTxOutSetStatus after abort = %v, %v", scanning, err)
+	}
+	if aborted, err := client.ScanTxOutSetAbort(ctx); err != nil || aborted {
+		t.Fatalf("ScanTxOutSetAbort without scan = %v, %v", aborted, err)
+	}
+	if result, err := client.ScanTxOutSetStart(ctx, nil); err != nil || !result.Success {
+		t.Fatalf("ScanTxOutSetStart = %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"encoding/json"
+
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/txscript"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// These are the script types nodes report which txscript does not know.
+const (
+	// scriptTypeWitnessV1Taproot labels taproot outputs, which are version
+	// 1 witness programs of 32 bytes.
+	scriptTypeWitnessV1Taproot = "witness_v1_taproot"
+
+	// scriptTypeWitnessUnknown labels witness programs of the other
+	// versions above 0.
+	scriptTypeWitnessUnknown = "witness_unknown"
+)
+
+// ScriptClassification is the class of a script and the addresses it pays to,
+// as determined by ClassifyScript.
+type ScriptClassification struct {
+	// Type labels the class of the script like the decodescript command
+	// does, such as "pubkeyhash", "witness_v0_scripthash", "multisig" or
+	// "nonstandard".
+	Type string
+
+	// ReqSigs is the number of signatures needed to spend the script, 0
+	// for scripts which can not be spent or which are not understood.
+	ReqSigs int
+
+	// Addresses are the addresses the script pays to on the network passed
+	// to ClassifyScript.  A pay-to-pubkey script pays to its public key and
+	// a multisig script to each of its valid public keys, which are
+	// encoded as pay-to-pubkey-hash addresses.
+	Addresses []ltcutil.Address
+}
+
+// ClassifyScript classifies the passed script and extracts the addresses it
+// pays to on the passed network, without asking a node.  It labels the script
+// like DecodeScript, so the results of the two can be compared.
+//
+// Scripts which do not parse are labelled "nonstandard", like nodes do.  Taproot
+// and other witness programs of versions above 0 are labelled without
+// addresses, which nodes encode in bech32m.
+func ClassifyScript(script []byte, params *chaincfg.Params) *ScriptClassification {
+	class, addrs, reqSigs, err := txscript.ExtractPkScriptAddrs(script, params)
+	if err != nil {
+		return &ScriptClassification{Type: txscript.NonStandardTy.String()}
+	}
+	if class != txscript.NonStandardTy {
+		return &ScriptClassification{
+			Type:      class.String(),
+			ReqSigs:   reqSigs,
+			Addresses: addrs,
+		}
+	}
+
+	// txscript only knows witness programs of version 0, and null data
+	// scripts pushing up to 80 bytes in a single push, where nodes accept
+	// any pushes following OP_RETURN.
+	version, program, err := txscript.ExtractWitnessProgramInfo(script)
+	switch {
+	case err == nil && version == 1 && len(program) == 32:
+		return &ScriptClassification{Type: scriptTypeWitnessV1Taproot}
+
+	case err == nil && version != 0:
+		return &ScriptClassification{Type: scriptTypeWitnessUnknown}
+
+	case len(script) > 0 && script[0] == txscript.OP_RETURN &&
+		txscript.IsPushOnlyScript(script[1:]):
+
+		return &ScriptClassification{Type: txscript.NullDataTy.String()}
+	}
+	return &ScriptClassification{Type: txscript.NonStandardTy.String()}
+}
+
+// DecodeScriptResult models the data returned from the decodescript command.
+type DecodeScriptResult struct {
+	// Asm is the disassembly of the script, and Type its class, labelled
+	// like ClassifyScript labels it.
+	Asm  string
+	Type string
+
+	// ReqSigs is the number of signatures needed to spend the script, and
+	// Addresses the addresses it pays to.  Nodes since Litecoin Core 0.21
+	// report a single address, and neither the number of signatures nor
+	// the public keys of multisig scripts unless started with
+	// -deprecatedrpc=addresses, leaving ReqSigs 0.
+	ReqSigs   int32
+	Addresses []string
+
+	// P2SH is the address paying to the script as a redeem script.  It is
+	// empty for scripts which are pay-to-script-hash scripts already.
+	P2SH string
+
+	// Segwit describes the witness program paying to the script, which is
+	// P2WPKH for pay-to-pubkey and pay-to-pubkey-hash scripts, and P2WSH
+	// for multisig and nonstandard scripts.  It is nil for the other
+	// types, which can not be wrapped.
+	Segwit *DecodeScriptSegwit
+}
+
+// DecodeScriptSegwit models the segwit object of the decodescript result.
+type DecodeScriptSegwit struct {
+	// Hex is the hex encoded witness program and Asm its disassembly.
+	Hex string
+	Asm string
+
+	// Type, ReqSigs and Addresses describe the witness program like
+	// DecodeScriptResult describes the decoded script.
+	Type      string
+	ReqSigs   int32
+	Addresses []string
+
+	// P2SHSegwit is the address nesting the witness program in a
+	// pay-to-script-hash script.
+	P2SHSegwit string
+}
+
+// decodeScriptFields are the fields of the decodescript result which its
+// segwit object shares.
+type decodeScriptFields struct {
+	Asm       string   `json:"asm"`
+	Hex       string   `json:"hex"`
+	Type      string   `json:"type"`
+	ReqSigs   int32    `json:"reqSigs"`
+	Address   string   `json:"address"`
+	Addresses []string `json:"addresses"`
+}
+
+// addresses returns the addresses of older nodes, or the single address of
+// newer ones.
+func (f *decodeScriptFields) addresses() []string {
+	if len(f.Addresses) == 0 && f.Address != "" {
+		return []string{f.Address}
+	}
+	return f.Addresses
+}
+
+// UnmarshalJSON decodes the result of the decodescript command of older and
+// newer nodes alike.
+func (r *DecodeScriptResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		decodeScriptFields
+		P2SH   string              `json:"p2sh"`
+		Segwit *DecodeScriptSegwit `json:"segwit"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*r = DecodeScriptResult{
+		Asm:       result.Asm,
+		Type:      result.Type,
+		ReqSigs:   result.ReqSigs,
+		Addresses: result.addresses(),
+		P2SH:      result.P2SH,
+		Segwit:    result.Segwit,
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes the segwit object of the decodescript result of older
+// and newer nodes alike.
+func (s *DecodeScriptSegwit) UnmarshalJSON(data []byte) error {
+	var segwit struct {
+		decodeScriptFields
+		P2SHSegwit string `json:"p2sh-segwit"`
+	}
+	if err := json.Unmarshal(data, &segwit); err != nil {
+		return err
+	}
+
+	*s = DecodeScriptSegwit{
+		Hex:        segwit.Hex,
+		Asm:        segwit.Asm,
+		Type:       segwit.Type,
+		ReqSigs:    segwit.ReqSigs,
+		Addresses:  segwit.addresses(),
+		P2SHSegwit: segwit.P2SHSegwit,
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// PrevTx describes a previous output spent by a transaction to sign which the
+// server does not know about.
+type PrevTx struct {
+	// TxID and Vout identify the spent output.
+	TxID string
+	Vout uint32
+
+	// ScriptPubKey is the hex encoded script of the spent output.
+	ScriptPubKey string
+
+	// RedeemScript is the hex encoded redeem script of P2SH outputs and
+	// WitnessScript the witness script of P2WSH outputs.
+	RedeemScript  string
+	WitnessScript string
+
+	// Amount is the value of the spent output, which is required to sign
+	// segwit outputs.
+	Amount ltcutil.Amount
+}
+
+// MarshalJSON encodes the previous output as the server expects it, with the
+// amount in LTC.
+func (p PrevTx) MarshalJSON() ([]byte, error) {
+	prevTx := struct {
+		TxID          string      `json:"txid"`
+		Vout          uint32      `json:"vout"`
+		ScriptPubKey  string      `json:"scriptPubKey"`
+		RedeemScript  string      `json:"redeemScript,omitempty"`
+		WitnessScript string      `json:"witnessScript,omitempty"`
+		Amount        json.Number `json:"amount,omitempty"`
+	}{
+		TxID:          p.TxID,
+		Vout:          p.Vout,
+		ScriptPubKey:  p.ScriptPubKey,
+		RedeemScript:  p.RedeemScript,
+		WitnessScript: p.WitnessScript,
+	}
+	if p.Amount != 0 {
+		prevTx.Amount = formatAmount(p.Amount)
+	}
+	return json.Marshal(prevTx)
+}
+
+// SignRawTransactionInputError describes an input the server could not sign,
+// such as an input of a multisig output still missing signatures.
+type SignRawTransactionInputError struct {
+	// PreviousOutPoint is the output spent by the input.
+	PreviousOutPoint wire.OutPoint
+
+	// SignatureScript and Witness hold the signatures added so far.
+	SignatureScript []byte
+	Witness         wire.TxWitness
+
+	// Sequence is the sequence number of the input.
+	Sequence uint32
+
+	// Error is the reason reported by the server, such as "CHECK(MULTI)SIG
+	// failing with non-zero signature (possibly need more signatures)".
+	Error string
+}
+
+// UnmarshalJSON unmarshals an entry of the errors of a signing result,
+// decoding its hash and scripts.
+func (e *SignRawTransactionInputError) UnmarshalJSON(data []byte) error {
+	var inputErr struct {
+		TxID      string   `json:"txid"`
+		Vout      uint32   `json:"vout"`
+		ScriptSig string   `json:"scriptSig"`
+		Witness   []string `json:"witness"`
+		Sequence  uint32   `json:"sequence"`
+		Error     string   `json:"error"`
+	}
+	if err := json.Unmarshal(data, &inputErr); err != nil {
+		return err
+	}
+
+	hash, err := chainhash.NewHashFromStr(inputErr.TxID)
+	if err != nil {
+		return err
+	}
+	sigScript, err := hex.DecodeString(inputErr.ScriptSig)
+	if err != nil {
+		return err
+	}
+	var witness wire.TxWitness
+	for _, item := range inputErr.Witness {
+		decoded, err := hex.DecodeString(item)
+		if err != nil {
+			return err
+		}
+		witness = append(witness, decoded)
+	}
+
+	*e = SignRawTransactionInputError{
+		PreviousOutPoint: *wire.NewOutPoint(hash, inputErr.Vout),
+		SignatureScript:  sigScript,
+		Witness:          witness,
+		Sequence:         inputErr.Sequence,
+		Error:            inputErr.Error,
+	}
+	return nil
+}
+
+// ErrInvalidPrivateKey describes a private key passed to
+// SignRawTransactionWithKey or ImportPrivKey which is not a key in wallet
+// import format (WIF) of the network of the server.  The key itself is left
+// out of the error.
+type ErrInvalidPrivateKey struct {
+	// Index is the position of the key in the passed keys.
+	Index int
+
+	// Err describes what is wrong with the key.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *ErrInvalidPrivateKey) Error() string {
+	return fmt.Sprintf("private key %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error describing what is wrong with the key.
+func (e *ErrInvalidPrivateKey) Unwrap() error {
+	return e.Err
+}
+
+// bitcoinMainNetParams only holds the WIF prefix of Bitcoin mainnet keys, which
+// the chaincfg package of ltcd does not know, to recognize them.
+var bitcoinMainNetParams = &chaincfg.Params{PrivateKeyID: 0x80}
+
+// checkPrivateKeys returns an *ErrInvalidPrivateKey for the first of the passed
+// keys which is not a WIF key of the passed network, or of any Litecoin network
+// when it is nil.  Bitcoin mainnet keys, which a Litecoin node rejects as an
+// invalid private key encoding, are named as such.
+func checkPrivateKeys(privKeysWIF []string, net *chaincfg.Params) error {
+	nets := addressNets
+	if net != nil {
+		nets = []*chaincfg.Params{net}
+	}
+
+	for i, privKeyWIF := range privKeysWIF {
+		wif, err := ltcutil.DecodeWIF(privKeyWIF)
+		if err != nil {
+			return &ErrInvalidPrivateKey{Index: i, Err: err}
+		}
+		forNet := false
+		for _, net := range nets {
+			forNet = forNet || wif.IsForNet(net)
+		}
+		switch {
+		case forNet:
+			continue
+		case wif.IsForNet(bitcoinMainNetParams):
+			return &ErrInvalidPrivateKey{Index: i, Err: errors.New(
+				"key is a Bitcoin mainnet key")}
+		case net != nil:
+			return &ErrInvalidPrivateKey{Index: i, Err: fmt.Errorf(
+				"key is not for network %s", net.Name)}
+		}
+		return &ErrInvalidPrivateKey{Index: i, Err: errors.New(
+			"key is not for a Litecoin network")}
+	}
+	return nil
+}
+
+// receiveSignRawTransactionResult waits for the response promised by the
+// passed future and returns the signing result.
+func receiveSignRawTransactionResult(f chan *response) (*wire.MsgTx, bool, []SignRawTransactionInputError, error) {
+	res, err := receiveFuture(f)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	// Unmarshal as a signrawtransactionwithkey or
+	// signrawtransactionwithwallet result.
+	var signResult struct {
+		Hex      string                         `json:"hex"`
+		Complete bool                           `json:"complete"`
+		Errors   []SignRawTransactionInputError `json:"errors"`
+	}
+	if err := json.Unmarshal(res, &signResult); err != nil {
+		return nil, false, nil, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(signResult.Hex)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	// Deserialize the transaction and return it.
+	msgTx, err := deserializeTx(serializedTx)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return msgTx, signResult.Complete, signResult.Errors, nil
+}
+
+// signRawTransactionParams returns the parameters of the passed transaction
+// to sign, previous outputs and signature hash type, leaving out the trailing
+// ones which are not set.
+func signRawTransactionParams(tx *wire.MsgTx, privKeysWIF []string, withKeys bool,
+	prevTxs []PrevTx, hashType SigHashType) ([]interface{}, error) {
+
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return nil, err
+	}
+
+	params := []interface{}{hex.EncodeToString(buf.Bytes())}
+	if withKeys {
+		if privKeysWIF == nil {
+			privKeysWIF = []string{}
+		}
+		params = append(params, privKeysWIF)
+	}
+	switch {
+	case hashType != "":
+		params = append(params, prevTxs, hashType)
+	case prevTxs != nil:
+		params = append(params, prevTxs)
+	}
+	return params, nil
+}
+
+// FutureSignRawTransactionWithKeyResult is a future promise to deliver the
+// result of a SignRawTransactionWithKeyAsync RPC invocation (or an applicable
+// error).
+type FutureSignRawTransactionWithKeyResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// transaction with the signatures added, whether all of its inputs are signed
+// and the inputs which could not be signed.
+func (r FutureSignRawTransactionWithKeyResult) Receive() (*wire.MsgTx, bool, []SignRawTransactionInputError, error) {
+	return receiveSignRawTransactionResult(r)
+}
+
+// SignRawTransactionWithKeyAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SignRawTransactionWithKey for the blocking version and more details.
+func (c *Client) SignRawTransactionWithKeyAsync(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []PrevTx,
+	hashType SigHashType) FutureSignRawTransactionWithKeyResult {
+
+	if err := checkPrivateKeys(privKeysWIF, c.config.ChainParams); err != nil {
+		return newFutureError(err)
+	}
+	params, err := signRawTransactionParams(tx, privKeysWIF, true, prevTxs,
+		hashType)
+	if err != nil {
+		return newFutureError(err)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSignRawTransactionWithKeyResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithkey", rawParams))
+}
+
+// SignRawTransactionWithKey signs the inputs of the passed transaction with
+// the passed private keys in wallet import format (WIF) only.  It returns the
+// transaction with the signatures added, whether all of its inputs are signed
+// and the inputs which could not be signed, such as those of a multisig output
+// which still need signatures of other keys.  The previous outputs the server
+// does not know about must be passed in prevTxs, and an empty hashType selects
+// the default signature hash type.
+//
+// The keys are checked before they are sent, and an *ErrInvalidPrivateKey is
+// returned for keys which are not for the network of ConnConfig.ChainParams,
+// or of any Litecoin network when it is not set.  Testnet and regtest keys
+// share their prefix with Bitcoin testnet keys, so those can not be told
+// apart.
+//
+// Only Litecoin Core 0.17 and later support this function.
+func (c *Client) SignRawTransactionWithKey(ctx context.Context, tx *wire.MsgTx,
+	privKeysWIF []string, prevTxs []PrevTx,
+	hashType SigHashType) (*wire.MsgTx, bool, []SignRawTransactionInputError, error) {
+
+	return c.SignRawTransactionWithKeyAsync(ctx, tx, privKeysWIF, prevTxs,
+		hashType).Receive()
+}
+
+// FutureSignRawTransactionWithWalletResult is a future promise to deliver the
+// result of a SignRawTransactionWithWalletAsync RPC invocation (or an
+// applicable error).
+type FutureSignRawTransactionWithWalletResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// transaction with the signatures added, whether all of its inputs are signed
+// and the inputs which could not be signed.
+func (r FutureSignRawTransactionWithWalletResult) Receive() (*wire.MsgTx, bool, []SignRawTransactionInputError, error) {
+	return receiveSignRawTransactionResult(r)
+}
+
+// SignRawTransactionWithWalletAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See SignRawTransactionWithWallet for the blocking version and more details.
+func (c *Client) SignRawTransactionWithWalletAsync(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []PrevTx, hashType SigHashType) FutureSignRawTransactionWithWalletResult {
+
+	params, err := signRawTransactionParams(tx, nil, false, prevTxs, hashType)
+	if err != nil {
+		return newFutureError(err)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	return FutureSignRawTransactionWithWalletResult(c.RawRequestAsync(ctx,
+		"signrawtransactionwithwallet", rawParams))
+}
+
+// SignRawTransactionWithWallet signs the inputs of the passed transaction
+// with the keys of the wallet.  It returns the transaction with the signatures
+// added, whether all of its inputs are signed and the inputs which could not
+// be signed.  The previous outputs the wallet does not know about must be
+// passed in prevTxs, and an empty hashType selects the default signature hash
+// type.
+//
+// Only Litecoin Core 0.17 and later support this function.
+func (c *Client) SignRawTransactionWithWallet(ctx context.Context, tx *wire.MsgTx,
+	prevTxs []PrevTx, hashType SigHashType) (*wire.MsgTx, bool, []SignRawTransactionInputError, error) {
+
+	return c.SignRawTransactionWithWalletAsync(ctx, tx, prevTxs,
+		hashType).Receive()
+}
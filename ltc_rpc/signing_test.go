@@ -0,0 +1,252 @@
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+)
+
+// The keys below are WIF encodings with compressed public keys of the private
+// keys 1 and 2 on Litecoin mainnet, and of the private key 1 on Bitcoin mainnet
+// and testnet, which Litecoin testnet shares its prefix with.
+const (
+	ltcKey1 = "T33ydQRKp4FCW5LCLLUB7deioUMoveiwekdwUwyfRDeGZm76aUjV"
+	ltcKey2 = "T33ydQRKp4FCW5LCLLUB7deioUMoveiwekdwUwyfRDeGaFvPgwLX"
+	btcKey1 = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"
+	tltcKey = "cMahea7zqjxrtgAbB7LSGbcQUr1uX1ojuat9jZodMN87JcbXMTcA"
+)
+
+// multisigScript is the witness script of a 2-of-3 multisig of the public keys
+// of the private keys 1, 2 and 3, and multisigP2WSH the output script paying
+// to it.
+const (
+	multisigScript = "52210279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f8179821" +
+		"02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee52102" +
+		"f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f953ae"
+	multisigP2WSH = "002012c2ffbc6ec1cf5d746dfbd49b1063356212ea55f43023ffc0145934af20c572"
+)
+
+func TestSignRawTransactionWithKey(t *testing.T) {
+	prevHash := chainhash.Hash{7}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(149990000, p2wpkhScript))
+
+	// The node signed with the first key only, so the second signature of
+	// the 2-of-3 multisig is missing.  The signature is a placeholder of
+	// the size of a real one.
+	witnessScript, _ := hex.DecodeString(multisigScript)
+	signature := append(bytes.Repeat([]byte{0x30}, 71), 0x01)
+	signed := tx.Copy()
+	signed.TxIn[0].Witness = wire.TxWitness{nil, signature, witnessScript}
+	signedHex := hex.EncodeToString(serializeTx(t, signed))
+	reply := `{"hex":"` + signedHex + `","complete":false,"errors":[{` +
+		`"txid":"` + prevHash.String() + `","vout":0,` +
+		`"witness":["","` + hex.EncodeToString(signature) + `","` + multisigScript + `"],` +
+		`"scriptSig":"","sequence":4294967295,` +
+		`"error":"CHECK(MULTI)SIG failing with non-zero signature (possibly need more signatures)"}]}`
+
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+		return json.RawMessage(reply), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	prevTxs := []PrevTx{{
+		TxID:          prevHash.String(),
+		Vout:          0,
+		ScriptPubKey:  multisigP2WSH,
+		WitnessScript: multisigScript,
+		Amount:        150000000,
+	}}
+	result, complete, inputErrors, err := client.SignRawTransactionWithKey(
+		context.Background(), tx, []string{ltcKey1}, prevTxs, "")
+	if err != nil {
+		t.Fatalf("SignRawTransactionWithKey: %v", err)
+	}
+	if complete || result.WitnessHash() != signed.WitnessHash() {
+		t.Fatalf("unexpected result %v, complete %v", result.WitnessHash(),
+			complete)
+	}
+	if len(inputErrors) != 1 {
+		t.Fatalf("got %d input errors, want 1", len(inputErrors))
+	}
+	inputErr := inputErrors[0]
+	if inputErr.PreviousOutPoint != *wire.NewOutPoint(&prevHash, 0) ||
+		len(inputErr.SignatureScript) != 0 || len(inputErr.Witness) != 3 ||
+		!bytes.Equal(inputErr.Witness[1], signature) ||
+		!bytes.Equal(inputErr.Witness[2], witnessScript) ||
+		inputErr.Sequence != wire.MaxTxInSequenceNum ||
+		!strings.Contains(inputErr.Error, "need more signatures") {
+
+		t.Fatalf("unexpected input error %+v", inputErr)
+	}
+
+	// The second signer completes the transaction with the partially
+	// signed one.
+	if _, _, _, err := client.SignRawTransactionWithKey(context.Background(),
+		result, []string{ltcKey2}, prevTxs, SigHashAll); err != nil {
+
+		t.Fatalf("SignRawTransactionWithKey: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	prevTxsJSON := `[{"txid":"` + prevHash.String() + `","vout":0,` +
+		`"scriptPubKey":"` + multisigP2WSH + `","witnessScript":"` +
+		multisigScript + `","amount":1.5}]`
+	want := []string{
+		`signrawtransactionwithkey["` + hex.EncodeToString(serializeTx(t, tx)) +
+			`",["` + ltcKey1 + `"],` + prevTxsJSON + `]`,
+		`signrawtransactionwithkey["` + signedHex + `",["` + ltcKey2 + `"],` +
+			prevTxsJSON + `,"ALL"]`,
+	}
+	if len(params) != len(want) {
+		t.Fatalf("got requests %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+func TestSignRawTransactionWithKeyInvalidKeys(t *testing.T) {
+	reply := `{"hex":"` + hex.EncodeToString(serializeTx(t, newWitnessTx())) +
+		`","complete":true}`
+	var mtx sync.Mutex
+	var calls int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+		return json.RawMessage(reply), nil
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		net    *chaincfg.Params
+		keys   []string
+		index  int
+		reason string
+	}{
+		{"mainnet keys", nil, []string{ltcKey1, ltcKey2}, -1, ""},
+		{"testnet key", nil, []string{tltcKey}, -1, ""},
+		{"configured network", &chaincfg.MainNetParams, []string{ltcKey1}, -1, ""},
+		{"bitcoin key", nil, []string{ltcKey1, btcKey1}, 1, "Bitcoin mainnet"},
+		{"other network", &chaincfg.MainNetParams, []string{tltcKey}, 0, "network mainnet"},
+		{"malformed key", nil, []string{ltcKey1[:len(ltcKey1)-1] + "W"}, 0, ""},
+	}
+	for _, test := range tests {
+		config := testConnConfig(server)
+		config.ChainParams = test.net
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		mtx.Lock()
+		calls = 0
+		mtx.Unlock()
+
+		_, _, _, err = client.SignRawTransactionWithKey(context.Background(),
+			wire.NewMsgTx(2), test.keys, nil, "")
+		stopClient(client)
+		mtx.Lock()
+		sent := calls
+		mtx.Unlock()
+		if test.index < 0 {
+			if err != nil || sent != 1 {
+				t.Fatalf("%s: got error %v after %d calls", test.name, err,
+					sent)
+			}
+			continue
+		}
+
+		var keyErr *ErrInvalidPrivateKey
+		if !errors.As(err, &keyErr) || keyErr.Index != test.index ||
+			!strings.Contains(err.Error(), test.reason) {
+
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		for _, key := range test.keys {
+			if strings.Contains(err.Error(), key) {
+				t.Fatalf("%s: error %q holds the key", test.name, err)
+			}
+		}
+		if sent != 0 {
+			t.Fatalf("%s: invalid keys sent to the server", test.name)
+		}
+	}
+}
+
+func TestSignRawTransactionWithWallet(t *testing.T) {
+	signed := newWitnessTx()
+	signedHex := hex.EncodeToString(serializeTx(t, signed))
+
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+		return json.RawMessage(`{"hex":"` + signedHex + `","complete":true}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	tx := newWitnessTx()
+	tx.TxIn[0].Witness = nil
+	result, complete, inputErrors, err := client.SignRawTransactionWithWallet(
+		ctx, tx, nil, "")
+	if err != nil {
+		t.Fatalf("SignRawTransactionWithWallet: %v", err)
+	}
+	if !complete || inputErrors != nil ||
+		result.WitnessHash() != signed.WitnessHash() {
+
+		t.Fatalf("unexpected result %v, %v, %v", result.WitnessHash(),
+			complete, inputErrors)
+	}
+	if _, _, _, err := client.SignRawTransactionWithWallet(ctx, tx, nil,
+		SigHashAllAnyoneCanPay); err != nil {
+
+		t.Fatalf("SignRawTransactionWithWallet: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	txHex := hex.EncodeToString(serializeTx(t, tx))
+	want := []string{
+		`signrawtransactionwithwallet["` + txHex + `"]`,
+		`signrawtransactionwithwallet["` + txHex + `",null,"ALL|ANYONECANPAY"]`,
+	}
+	if len(params) != len(want) {
+		t.Fatalf("got requests %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent %s, want %s", i, params[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statsReservoirSize is the number of most recent latencies per method the
+// latency percentiles are computed from.
+const statsReservoirSize = 256
+
+// Stats is a snapshot of the statistics a client collects about the requests
+// it issued.
+type Stats struct {
+	// Methods holds the statistics of every method issued so far, keyed
+	// by method.
+	Methods map[string]MethodStats
+
+	// OrphanResponses is the number of responses from the server which
+	// did not belong to any pending request.
+	OrphanResponses uint64
+}
+
+// MethodStats holds the statistics of the requests issued for one method.
+type MethodStats struct {
+	// Requests is the number of requests which finished.
+	Requests uint64
+
+	// Errors is the number of requests which finished with an error.
+	Errors uint64
+
+	// P50 and P95 are the median and 95th percentile latency of the most
+	// recent requests.
+	P50 time.Duration
+	P95 time.Duration
+}
+
+// methodStats collects the statistics of one method.  The counters are
+// updated atomically, so only the latency reservoir is guarded by a mutex,
+// which is not shared with any other method.
+type methodStats struct {
+	requests uint64 // atomic, so must stay 64-bit aligned
+	errors   uint64 // atomic, so must stay 64-bit aligned
+
+	mtx       sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// record adds a finished request to the statistics.
+func (s *methodStats) record(duration time.Duration, err error) {
+	atomic.AddUint64(&s.requests, 1)
+	if err != nil {
+		atomic.AddUint64(&s.errors, 1)
+	}
+
+	s.mtx.Lock()
+	if len(s.latencies) < statsReservoirSize {
+		s.latencies = append(s.latencies, duration)
+	} else {
+		s.latencies[s.next] = duration
+		s.next = (s.next + 1) % statsReservoirSize
+	}
+	s.mtx.Unlock()
+}
+
+// snapshot returns the current statistics.
+func (s *methodStats) snapshot() MethodStats {
+	s.mtx.Lock()
+	latencies := append([]time.Duration(nil), s.latencies...)
+	s.mtx.Unlock()
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	return MethodStats{
+		Requests: atomic.LoadUint64(&s.requests),
+		Errors:   atomic.LoadUint64(&s.errors),
+		P50:      percentile(latencies, 0.50),
+		P95:      percentile(latencies, 0.95),
+	}
+}
+
+// percentile returns the passed percentile of the passed sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(p*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// statsCollector collects per-method statistics.  Its zero value is ready to
+// use.  Looking up the statistics of a method does not take any lock once the
+// method was seen before, so concurrent requests do not serialize on it.
+type statsCollector struct {
+	methods sync.Map // method -> *methodStats
+}
+
+// method returns the statistics of the passed method, creating them if
+// needed.
+func (s *statsCollector) method(method string) *methodStats {
+	if stats, ok := s.methods.Load(method); ok {
+		return stats.(*methodStats)
+	}
+	stats, _ := s.methods.LoadOrStore(method, new(methodStats))
+	return stats.(*methodStats)
+}
+
+// requestFinished records a finished request in the statistics and logs it
+// when it took longer than the configured SlowQueryThreshold.
+func (c *Client) requestFinished(method string, duration time.Duration, err error) {
+	c.stats.method(method).record(duration, err)
+
+	threshold := c.config.SlowQueryThreshold
+	if threshold > 0 && duration > threshold {
+		c.log.Warnf("Slow request [%s] took %v", method, duration)
+	}
+}
+
+// Stats returns a snapshot of the statistics the client collected about the
+// requests it issued.
+func (c *Client) Stats() Stats {
+	stats := Stats{
+		Methods:         make(map[string]MethodStats),
+		OrphanResponses: atomic.LoadUint64(&c.orphanResponses),
+	}
+	c.stats.methods.Range(func(method, s interface{}) bool {
+		stats.Methods[method.(string)] = s.(*methodStats).snapshot()
+		return true
+	})
+	return stats
+}
@@ -0,0 +1,107 @@
+package ltc_rpc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+)
+
+// recordingTransport passes requests on to the default transport and records
+// the authorization header of every request it sees.
+type recordingTransport struct {
+	mtx   sync.Mutex
+	auths []string
+}
+
+// RoundTrip records the request and sends it with the default transport.
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mtx.Lock()
+	r.auths = append(r.auths, req.Header.Get("Authorization"))
+	r.mtx.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRoundTripper(t *testing.T) {
+	var mtx sync.Mutex
+	var requests int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		requests++
+		mtx.Unlock()
+		return 100, nil
+	})
+	defer server.Close()
+
+	recorder := &recordingTransport{}
+	config := testConnConfig(server)
+	config.RoundTripper = recorder
+	config.HTTPPostWorkers = 4
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer stopClient(client)
+
+	const calls = 20
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetBlockCount(context.Background()); err != nil {
+				t.Errorf("GetBlockCount: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	recorder.mtx.Lock()
+	defer recorder.mtx.Unlock()
+	if len(recorder.auths) != calls || requests != calls {
+		t.Fatalf("round tripper saw %d and server %d requests, want %d",
+			len(recorder.auths), requests, calls)
+	}
+	for _, auth := range recorder.auths {
+		if auth == "" {
+			t.Fatal("request without authorization header")
+		}
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	config := &ConnConfig{Host: "localhost:8332", HTTPPostMode: true, DisableTLS: true}
+	httpClient, err := newHTTPClient(config)
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+	transport := httpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConnsPerHost != maxIdleConnsPerHost ||
+		transport.MaxConnsPerHost != 0 || transport.IdleConnTimeout != 0 {
+
+		t.Fatalf("unexpected default transport %+v", transport)
+	}
+
+	config.Transport = &TransportOptions{
+		MaxIdleConns:        50,
+		MaxConnsPerHost:     4,
+		IdleConnTimeout:     time.Minute,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+	httpClient, err = newHTTPClient(config)
+	if err != nil {
+		t.Fatalf("newHTTPClient: %v", err)
+	}
+	transport = httpClient.Transport.(*http.Transport)
+	if transport.MaxIdleConns != 50 ||
+		transport.MaxIdleConnsPerHost != maxIdleConnsPerHost ||
+		transport.MaxConnsPerHost != 4 ||
+		transport.IdleConnTimeout != time.Minute ||
+		transport.TLSHandshakeTimeout != 5*time.Second {
+
+		t.Fatalf("options not applied to transport %+v", transport)
+	}
+}
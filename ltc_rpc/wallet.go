@@ -0,0 +1,872 @@
+// Copyright (c) 2014-2017 The ltcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ltc_rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// ListUnspentResult models an unspent output of the data returned by the
+// listunspent command.  Unlike btcjson.ListUnspentResult, it keeps the amount
+// as sent by the node, so AmountLitoshi converts it exactly.
+type ListUnspentResult struct {
+	TxID          string      `json:"txid"`
+	Vout          uint32      `json:"vout"`
+	Address       string      `json:"address"`
+	Label         string      `json:"label,omitempty"`
+	ScriptPubKey  string      `json:"scriptPubKey"`
+	RedeemScript  string      `json:"redeemScript,omitempty"`
+	Amount        json.Number `json:"amount"`
+	Confirmations int64       `json:"confirmations"`
+	Spendable     bool        `json:"spendable"`
+	Solvable      bool        `json:"solvable"`
+	Safe          bool        `json:"safe"`
+}
+
+// AmountLitoshi returns the amount of the output in litoshis.
+func (r *ListUnspentResult) AmountLitoshi() (ltcutil.Amount, error) {
+	litoshi, err := ParseAmountSat(r.Amount)
+	if err != nil {
+		return 0, err
+	}
+	return ltcutil.Amount(litoshi), nil
+}
+
+// OutPoint returns the outpoint of the output.
+func (r *ListUnspentResult) OutPoint() (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(r.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewOutPoint(hash, r.Vout), nil
+}
+
+// FutureListUnspentResult is a future promise to deliver the result of a
+// ListUnspentMinMaxAddressesAsync RPC invocation (or an applicable error).
+type FutureListUnspentResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// unspent wallet transaction outputs returned by the RPC call.
+func (r FutureListUnspentResult) Receive() ([]ListUnspentResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listunspent results.
+	var unspent []ListUnspentResult
+	if err := json.Unmarshal(res, &unspent); err != nil {
+		return nil, err
+	}
+	return unspent, nil
+}
+
+// ListUnspentMinMaxAddressesAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ListUnspentMinMaxAddresses for the blocking version and more details.
+func (c *Client) ListUnspentMinMaxAddressesAsync(ctx context.Context, minConf, maxConf int, addrs []string) FutureListUnspentResult {
+	// The node takes an empty list as no filter.
+	if addrs == nil {
+		addrs = []string{}
+	}
+	cmd := btcjson.NewListUnspentCmd(&minConf, &maxConf, &addrs)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListUnspentMinMaxAddresses returns the unspent transaction outputs of the
+// wallet which have between minConf and maxConf confirmations and pay to any
+// of the passed addresses, or to any address when none are passed.  The
+// addresses are passed to the node as is, so any address it knows, MWEB
+// addresses included, may be used.
+func (c *Client) ListUnspentMinMaxAddresses(ctx context.Context, minConf, maxConf int, addrs []string) ([]ListUnspentResult, error) {
+	return c.ListUnspentMinMaxAddressesAsync(ctx, minConf, maxConf, addrs).Receive()
+}
+
+// FutureLockUnspentResult is a future promise to deliver the error result of a
+// LockUnspentAsync RPC invocation.
+type FutureLockUnspentResult chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// of locking or unlocking the unspent output(s).
+func (r FutureLockUnspentResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// LockUnspentAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See LockUnspent for the blocking version and more details.
+func (c *Client) LockUnspentAsync(ctx context.Context, unlock bool, outpoints []wire.OutPoint) FutureLockUnspentResult {
+	// Without outputs the list is sent as null, which the node takes as
+	// all outputs when unlocking.
+	var inputs []btcjson.TransactionInput
+	for _, outpoint := range outpoints {
+		inputs = append(inputs, btcjson.TransactionInput{
+			Txid: outpoint.Hash.String(),
+			Vout: outpoint.Index,
+		})
+	}
+	cmd := btcjson.NewLockUnspentCmd(unlock, inputs)
+	return c.sendCmd(ctx, cmd)
+}
+
+// LockUnspent locks the passed outputs, or unlocks them when unlock is true,
+// so coin selection of the wallet, such as the one of fundrawtransaction,
+// leaves them alone.  Unlocking without outputs unlocks all of them.  Locks
+// are held in memory and lost when the node restarts.
+func (c *Client) LockUnspent(ctx context.Context, unlock bool, outpoints []wire.OutPoint) error {
+	return c.LockUnspentAsync(ctx, unlock, outpoints).Receive()
+}
+
+// FutureListLockUnspentResult is a future promise to deliver the result of a
+// ListLockUnspentAsync RPC invocation (or an applicable error).
+type FutureListLockUnspentResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// locked outputs.
+func (r FutureListLockUnspentResult) Receive() ([]wire.OutPoint, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal as an array of transaction inputs.
+	var inputs []btcjson.TransactionInput
+	if err := json.Unmarshal(res, &inputs); err != nil {
+		return nil, err
+	}
+
+	// Create a slice of outpoints from the transaction input structs.
+	outpoints := make([]wire.OutPoint, 0, len(inputs))
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, err
+		}
+		outpoints = append(outpoints, *wire.NewOutPoint(hash, input.Vout))
+	}
+	return outpoints, nil
+}
+
+// ListLockUnspentAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function
+// on the returned instance.
+//
+// See ListLockUnspent for the blocking version and more details.
+func (c *Client) ListLockUnspentAsync(ctx context.Context) FutureListLockUnspentResult {
+	cmd := btcjson.NewListLockUnspentCmd()
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListLockUnspent returns the outputs locked with LockUnspent.
+func (c *Client) ListLockUnspent(ctx context.Context) ([]wire.OutPoint, error) {
+	return c.ListLockUnspentAsync(ctx).Receive()
+}
+
+// FundOpts holds the options of FundRawTransaction.  Options which are not set
+// are left to the node.
+type FundOpts struct {
+	// ChangeAddress is the address the change is sent to instead of a new
+	// address of the wallet.
+	ChangeAddress ltcutil.Address
+
+	// FeeRate is the fee rate in litoshis per virtual byte to pay instead
+	// of the rate the wallet estimates, such as the one returned by
+	// FeeRateLitoshiPerVByte.
+	FeeRate *int64
+
+	// SubtractFeeFromOutputs lists the indexes of the outputs the fee is
+	// deducted from, split evenly, instead of adding it to the inputs.
+	SubtractFeeFromOutputs []int
+
+	// IncludeWatching also selects inputs from watch-only addresses.
+	IncludeWatching *bool
+
+	// LockUnspents locks the selected inputs like LockUnspent, so they are
+	// not selected again until they are spent or unlocked.
+	LockUnspents *bool
+}
+
+// params returns the options as the JSON object the node expects.
+func (o *FundOpts) params() map[string]interface{} {
+	params := make(map[string]interface{})
+	if o.ChangeAddress != nil {
+		params["changeAddress"] = o.ChangeAddress.EncodeAddress()
+	}
+	if o.FeeRate != nil {
+		params["fee_rate"] = *o.FeeRate
+	}
+	if o.SubtractFeeFromOutputs != nil {
+		params["subtractFeeFromOutputs"] = o.SubtractFeeFromOutputs
+	}
+	if o.IncludeWatching != nil {
+		params["includeWatching"] = *o.IncludeWatching
+	}
+	if o.LockUnspents != nil {
+		params["lockUnspents"] = *o.LockUnspents
+	}
+	return params
+}
+
+// FundRawTransactionResult is the transaction funded by FundRawTransaction.
+type FundRawTransactionResult struct {
+	// Transaction is the funded transaction.  Its inputs are not signed.
+	Transaction *wire.MsgTx
+
+	// Fee is the fee the funded transaction pays.
+	Fee ltcutil.Amount
+
+	// ChangePosition is the index of the change output, or -1 when no
+	// change output was added.
+	ChangePosition int
+}
+
+// FutureFundRawTransactionResult is a future promise to deliver the result
+// of a FundRawTransactionAsync RPC invocation (or an applicable error).
+type FutureFundRawTransactionResult chan *response
+
+// Receive waits for the response promised by the future and returns the funded
+// transaction.
+func (r FutureFundRawTransactionResult) Receive() (*FundRawTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if isInsufficientFunds(err) {
+		return nil, &ErrInsufficientFunds{Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a fundrawtransaction result object.
+	var fundResult struct {
+		Hex       string      `json:"hex"`
+		Fee       json.Number `json:"fee"`
+		ChangePos int         `json:"changepos"`
+	}
+	if err := json.Unmarshal(res, &fundResult); err != nil {
+		return nil, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(fundResult.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deserialize the transaction.
+	msgTx, err := deserializeTx(serializedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := ParseAmountSat(fundResult.Fee)
+	if err != nil {
+		return nil, err
+	}
+	return &FundRawTransactionResult{
+		Transaction:    msgTx,
+		Fee:            ltcutil.Amount(fee),
+		ChangePosition: fundResult.ChangePos,
+	}, nil
+}
+
+// FundRawTransactionAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See FundRawTransaction for the blocking version and more details.
+func (c *Client) FundRawTransactionAsync(ctx context.Context, tx *wire.MsgTx, opts FundOpts) FutureFundRawTransactionResult {
+	// Serialize the transaction and convert to hex string.
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	if err := tx.Serialize(buf); err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson package does not know the command, so it is sent as a
+	// raw request.
+	params := []interface{}{hex.EncodeToString(buf.Bytes()), opts.params()}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureFundRawTransactionResult(c.RawRequestAsync(ctx,
+		"fundrawtransaction", rawParams))
+}
+
+// FundRawTransaction adds inputs from the wallet to the passed transaction
+// until they cover its outputs and the fee, adding a change output if needed.
+// The inputs the transaction already has are kept.  The funded transaction is
+// not signed.  The wallet leaves out change which would be dust and adds it
+// to the fee instead.
+//
+// An *ErrInsufficientFunds is returned when the wallet can not cover the
+// outputs.
+func (c *Client) FundRawTransaction(ctx context.Context, tx *wire.MsgTx, opts FundOpts) (*FundRawTransactionResult, error) {
+	return c.FundRawTransactionAsync(ctx, tx, opts).Receive()
+}
+
+// unmarshalAmount unmarshals a result holding an amount in LTC and converts it
+// to litoshis exactly.
+func unmarshalAmount(res []byte) (ltcutil.Amount, error) {
+	var amount json.Number
+	if err := decodeJSON(res, &amount); err != nil {
+		return 0, err
+	}
+	litoshi, err := ParseAmountSat(amount)
+	if err != nil {
+		return 0, err
+	}
+	return ltcutil.Amount(litoshi), nil
+}
+
+// SendToAddressOpts holds the options of SendToAddress.
+type SendToAddressOpts struct {
+	// Comment is stored in the wallet with the transaction.  It is not
+	// part of the transaction.
+	Comment string
+
+	// CommentTo names the recipient of the transaction and is stored in
+	// the wallet like Comment.
+	CommentTo string
+
+	// SubtractFeeFromAmount deducts the fee from the amount sent, so the
+	// recipient receives less than the amount instead of the wallet paying
+	// the fee on top.
+	SubtractFeeFromAmount bool
+}
+
+// FutureSendToAddressResult is a future promise to deliver the result of a
+// SendToAddressAsync RPC invocation (or an applicable error).
+type FutureSendToAddressResult chan *response
+
+// Receive waits for the response promised by the future and returns the hash
+// of the transaction sending the passed amount to the given address.
+func (r FutureSendToAddressResult) Receive() (*chainhash.Hash, error) {
+	res, err := receiveFuture(r)
+	if isInsufficientFunds(err) {
+		return nil, &ErrInsufficientFunds{Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var txHash string
+	err = json.Unmarshal(res, &txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return chainhash.NewHashFromStr(txHash)
+}
+
+// SendToAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SendToAddress for the blocking version and more details.
+func (c *Client) SendToAddressAsync(ctx context.Context, address ltcutil.Address, amount ltcutil.Amount, opts SendToAddressOpts) FutureSendToAddressResult {
+	// The btcjson command knows no subtractfeefromamount and would send
+	// the amount as a float64, so the call is sent as a raw request.
+	params := []interface{}{address.EncodeAddress(), formatAmount(amount)}
+	if opts.Comment != "" || opts.CommentTo != "" || opts.SubtractFeeFromAmount {
+		params = append(params, opts.Comment, opts.CommentTo)
+	}
+	if opts.SubtractFeeFromAmount {
+		params = append(params, true)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+	return FutureSendToAddressResult(c.RawRequestAsync(ctx,
+		"sendtoaddress", rawParams))
+}
+
+// SendToAddress sends the passed amount to the given address from the wallet
+// and returns the hash of the transaction.  The amount is sent to the node as
+// an exact decimal number, so it is not subject to float rounding.  The
+// address may be of any type DecodeAddress returns, MWEB addresses included.
+//
+// An *ErrInsufficientFunds is returned when the balance of the wallet does not
+// cover the amount and the fee.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) SendToAddress(ctx context.Context, address ltcutil.Address, amount ltcutil.Amount, opts SendToAddressOpts) (*chainhash.Hash, error) {
+	return c.SendToAddressAsync(ctx, address, amount, opts).Receive()
+}
+
+// FutureGetBalanceResult is a future promise to deliver the result of a
+// GetBalanceAsync, GetBalanceMinConfAsync or GetReceivedByAddressAsync RPC
+// invocation (or an applicable error).
+type FutureGetBalanceResult chan *response
+
+// Receive waits for the response promised by the future and returns the
+// amount returned by the server.
+func (r FutureGetBalanceResult) Receive() (ltcutil.Amount, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	return unmarshalAmount(res)
+}
+
+// GetBalanceAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBalance for the blocking version and more details.
+func (c *Client) GetBalanceAsync(ctx context.Context) FutureGetBalanceResult {
+	cmd := btcjson.NewGetBalanceCmd(nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetBalanceMinConfAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetBalanceMinConf for the blocking version and more details.
+func (c *Client) GetBalanceMinConfAsync(ctx context.Context, minConf int) FutureGetBalanceResult {
+	// Nodes require the dummy account "*" ahead of the confirmations.
+	cmd := btcjson.NewGetBalanceCmd(btcjson.String("*"), &minConf)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetBalance returns the available balance of the wallet, using the default
+// number of minimum confirmations.
+func (c *Client) GetBalance(ctx context.Context) (ltcutil.Amount, error) {
+	return c.GetBalanceAsync(ctx).Receive()
+}
+
+// GetBalanceMinConf returns the balance of the wallet counting only outputs
+// with at least the specified number of confirmations.
+func (c *Client) GetBalanceMinConf(ctx context.Context, minConf int) (ltcutil.Amount, error) {
+	return c.GetBalanceMinConfAsync(ctx, minConf).Receive()
+}
+
+// AddressType is the type of the addresses GetNewAddress returns.
+type AddressType string
+
+// Constants for the address types known to Litecoin Core.
+const (
+	// AddressTypeLegacy is a pay-to-pubkey-hash address, such as L....
+	AddressTypeLegacy AddressType = "legacy"
+
+	// AddressTypeP2SHSegwit is a pay-to-witness-pubkey-hash address nested
+	// in a pay-to-script-hash address, such as M....
+	AddressTypeP2SHSegwit AddressType = "p2sh-segwit"
+
+	// AddressTypeBech32 is a native pay-to-witness-pubkey-hash address,
+	// such as ltc1q....
+	AddressTypeBech32 AddressType = "bech32"
+
+	// AddressTypeMweb is an MWEB address, such as ltcmweb1q....  Only
+	// Litecoin Core 0.21.2 and later know it.
+	AddressTypeMweb AddressType = "mweb"
+)
+
+// FutureGetNewAddressResult is a future promise to deliver the result of a
+// GetNewAddressAsync RPC invocation (or an applicable error).
+type FutureGetNewAddressResult struct {
+	params   *chaincfg.Params
+	response chan *response
+}
+
+// Receive waits for the response promised by the future and returns a new
+// address.
+func (r FutureGetNewAddressResult) Receive() (ltcutil.Address, error) {
+	res, err := receiveFuture(r.response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a string.
+	var addr string
+	err = json.Unmarshal(res, &addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAddress(addr, r.params)
+}
+
+// GetNewAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetNewAddress for the blocking version and more details.
+func (c *Client) GetNewAddressAsync(ctx context.Context, label string, addressType AddressType) FutureGetNewAddressResult {
+	future := FutureGetNewAddressResult{params: c.config.ChainParams}
+
+	// The btcjson command knows no address type, so the call is sent as a
+	// raw request.
+	params := []interface{}{label}
+	if addressType != "" {
+		params = append(params, addressType)
+	}
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		future.response = newFutureError(err)
+		return future
+	}
+	future.response = c.RawRequestAsync(ctx, "getnewaddress", rawParams)
+	return future
+}
+
+// GetNewAddress returns a new address of the passed type of the wallet, stored
+// with the passed label, which may be empty.  An empty address type leaves it
+// to the -addresstype setting of the node.  The address is decoded for the
+// network of ConnConfig.ChainParams.  When it is not set, legacy addresses of
+// testnet and regtest wallets can not be told apart and are decoded for
+// testnet.
+func (c *Client) GetNewAddress(ctx context.Context, label string, addressType AddressType) (ltcutil.Address, error) {
+	return c.GetNewAddressAsync(ctx, label, addressType).Receive()
+}
+
+// GetReceivedByAddressAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetReceivedByAddress for the blocking version and more details.
+func (c *Client) GetReceivedByAddressAsync(ctx context.Context, address ltcutil.Address, minConf int) FutureGetBalanceResult {
+	cmd := btcjson.NewGetReceivedByAddressCmd(address.EncodeAddress(), &minConf)
+	return c.sendCmd(ctx, cmd)
+}
+
+// GetReceivedByAddress returns the total amount received by the passed address
+// of the wallet in transactions with at least the specified number of
+// confirmations.
+func (c *Client) GetReceivedByAddress(ctx context.Context, address ltcutil.Address, minConf int) (ltcutil.Amount, error) {
+	return c.GetReceivedByAddressAsync(ctx, address, minConf).Receive()
+}
+
+// ListTransactionsResult models an entry of the data returned by the
+// listtransactions command.  Unlike btcjson.ListTransactionsResult, it holds
+// the amounts in litoshis, read exactly.
+type ListTransactionsResult struct {
+	// TxID is the hash of the transaction.
+	TxID chainhash.Hash
+
+	// Address is the address the entry is for, and Label its label.  The
+	// address is empty for entries without one, such as moves.
+	Address string
+	Label   string
+
+	// Category is the kind of the entry, such as "send", "receive" or
+	// "generate".
+	Category string
+
+	// Amount is the amount of the entry, negative for sends.
+	Amount ltcutil.Amount
+
+	// Fee is the fee of the transaction, as a negative amount.  The wallet
+	// only reports it for sends, and it is nil otherwise.
+	Fee *ltcutil.Amount
+
+	// Vout is the index of the output the entry is for.
+	Vout uint32
+
+	// Confirmations is the number of confirmations of the transaction,
+	// which is negative for transactions conflicting with the block chain.
+	Confirmations int64
+
+	// BlockHash and BlockTime are the hash and time of the block holding
+	// the transaction.  They are empty for unconfirmed transactions.
+	BlockHash string
+	BlockTime int64
+
+	// Time and TimeReceived are when the transaction was first seen by
+	// the wallet, in seconds since the epoch.
+	Time         int64
+	TimeReceived int64
+
+	// Comment is the comment stored with the transaction, such as the one
+	// of SendToAddressOpts.
+	Comment string
+
+	// Abandoned reports whether a send was abandoned.
+	Abandoned bool
+}
+
+// UnmarshalJSON unmarshals an entry of the listtransactions result, converting
+// its amounts to litoshis exactly.
+func (r *ListTransactionsResult) UnmarshalJSON(data []byte) error {
+	var result struct {
+		TxID          string       `json:"txid"`
+		Address       string       `json:"address"`
+		Label         string       `json:"label"`
+		Category      string       `json:"category"`
+		Amount        json.Number  `json:"amount"`
+		Fee           *json.Number `json:"fee"`
+		Vout          uint32       `json:"vout"`
+		Confirmations int64        `json:"confirmations"`
+		BlockHash     string       `json:"blockhash"`
+		BlockTime     int64        `json:"blocktime"`
+		Time          int64        `json:"time"`
+		TimeReceived  int64        `json:"timereceived"`
+		Comment       string       `json:"comment"`
+		Abandoned     bool         `json:"abandoned"`
+	}
+	if err := decodeJSON(data, &result); err != nil {
+		return err
+	}
+
+	txHash, err := chainhash.NewHashFromStr(result.TxID)
+	if err != nil {
+		return err
+	}
+	amount, err := ParseAmountSat(result.Amount)
+	if err != nil {
+		return err
+	}
+	var fee *ltcutil.Amount
+	if result.Fee != nil {
+		litoshi, err := ParseAmountSat(*result.Fee)
+		if err != nil {
+			return err
+		}
+		fee = new(ltcutil.Amount)
+		*fee = ltcutil.Amount(litoshi)
+	}
+
+	*r = ListTransactionsResult{
+		TxID:          *txHash,
+		Address:       result.Address,
+		Label:         result.Label,
+		Category:      result.Category,
+		Amount:        ltcutil.Amount(amount),
+		Fee:           fee,
+		Vout:          result.Vout,
+		Confirmations: result.Confirmations,
+		BlockHash:     result.BlockHash,
+		BlockTime:     result.BlockTime,
+		Time:          result.Time,
+		TimeReceived:  result.TimeReceived,
+		Comment:       result.Comment,
+		Abandoned:     result.Abandoned,
+	}
+	return nil
+}
+
+// FutureListTransactionsResult is a future promise to deliver the result of a
+// ListTransactionsAsync or ListTransactionsCountFromAsync RPC invocation (or an
+// applicable error).
+type FutureListTransactionsResult chan *response
+
+// Receive waits for the response promised by the future and returns a list of
+// the most recent transactions of the wallet.
+func (r FutureListTransactionsResult) Receive() ([]ListTransactionsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of listtransaction result objects.
+	var transactions []ListTransactionsResult
+	err = json.Unmarshal(res, &transactions)
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// ListTransactionsAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See ListTransactions for the blocking version and more details.
+func (c *Client) ListTransactionsAsync(ctx context.Context) FutureListTransactionsResult {
+	cmd := btcjson.NewListTransactionsCmd(nil, nil, nil, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListTransactionsCountFromAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ListTransactionsCountFrom for the blocking version and more details.
+func (c *Client) ListTransactionsCountFromAsync(ctx context.Context, count, from int) FutureListTransactionsResult {
+	// Nodes require the dummy label "*" ahead of the count.
+	cmd := btcjson.NewListTransactionsCmd(btcjson.String("*"), &count, &from, nil)
+	return c.sendCmd(ctx, cmd)
+}
+
+// ListTransactions returns the 10 most recent transactions of the wallet.
+func (c *Client) ListTransactions(ctx context.Context) ([]ListTransactionsResult, error) {
+	return c.ListTransactionsAsync(ctx).Receive()
+}
+
+// ListTransactionsCountFrom returns at most count of the most recent
+// transactions of the wallet, skipping the first from transactions.
+func (c *Client) ListTransactionsCountFrom(ctx context.Context, count, from int) ([]ListTransactionsResult, error) {
+	return c.ListTransactionsCountFromAsync(ctx, count, from).Receive()
+}
+
+// ImportRescan selects whether an import rescans the block chain for
+// transactions of the imported address or key.  Descriptor wallets do not
+// support any of the imports taking it.
+type ImportRescan bool
+
+const (
+	// ImportNoRescan imports without rescanning, so the wallet only sees
+	// transactions of the import from then on.  Use it when importing
+	// many addresses and rescan once afterwards.
+	ImportNoRescan ImportRescan = false
+
+	// ImportRescanBlocking has the server rescan the whole block chain
+	// before it replies, which takes many minutes on mainnet.  The rescan
+	// is not canceled when the request times out or its context is
+	// canceled on the client side, the server finishes it regardless, so
+	// a timeout does not mean the import failed.
+	ImportRescanBlocking ImportRescan = true
+)
+
+// FutureImportResult is a future promise to deliver the result of an
+// ImportAddressAsync, ImportAddressScriptAsync, ImportPubKeyAsync,
+// ImportPrivKeyAsync or ImportPrivKeyStringAsync RPC invocation (or an
+// applicable error).
+type FutureImportResult chan *response
+
+// Receive waits for the response promised by the future and returns the result
+// of the import.
+func (r FutureImportResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// importAsync sends the passed import command with the passed parameters,
+// which are sent positionally.  Every parameter up to the last one is always
+// sent, so the label is never taken for the rescan flag and neither falls back
+// to a node default.
+func (c *Client) importAsync(ctx context.Context, method string, params ...interface{}) FutureImportResult {
+	rawParams, err := marshalParams(params)
+	if err != nil {
+		return newFutureError(err)
+	}
+
+	// The btcjson commands name the label an account, so the call is sent
+	// as a raw request.
+	return FutureImportResult(c.RawRequestAsync(ctx, method, rawParams))
+}
+
+// ImportAddressAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.  With ImportRescanBlocking, the Receive function only
+// returns once the rescan is done.
+//
+// See ImportAddress for the blocking version and more details.
+func (c *Client) ImportAddressAsync(ctx context.Context, address ltcutil.Address, label string, rescan ImportRescan) FutureImportResult {
+	return c.importAsync(ctx, "importaddress", address.EncodeAddress(),
+		label, rescan)
+}
+
+// ImportAddress imports the passed address into the wallet as watch-only,
+// stored with the passed label, which may be empty.
+//
+// See ImportRescanBlocking for the duration of the request with a rescan.
+// Use ImportAddressAsync with a context without deadline, or import with
+// ImportNoRescan and rescan once after importing many addresses.
+func (c *Client) ImportAddress(ctx context.Context, address ltcutil.Address, label string, rescan ImportRescan) error {
+	return c.ImportAddressAsync(ctx, address, label, rescan).Receive()
+}
+
+// ImportAddressScriptAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ImportAddressScript for the blocking version and more details.
+func (c *Client) ImportAddressScriptAsync(ctx context.Context, script []byte, label string, rescan ImportRescan, p2sh bool) FutureImportResult {
+	return c.importAsync(ctx, "importaddress", hex.EncodeToString(script),
+		label, rescan, p2sh)
+}
+
+// ImportAddressScript imports the passed raw output script into the wallet as
+// watch-only, like ImportAddress.  With p2sh set, the script is treated as a
+// redeem script and the P2SH address paying to it is watched as well.
+//
+// See ImportRescanBlocking for the duration of the request with a rescan.
+func (c *Client) ImportAddressScript(ctx context.Context, script []byte, label string, rescan ImportRescan, p2sh bool) error {
+	return c.ImportAddressScriptAsync(ctx, script, label, rescan, p2sh).Receive()
+}
+
+// ImportPubKeyAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ImportPubKey for the blocking version and more details.
+func (c *Client) ImportPubKeyAsync(ctx context.Context, pubKey []byte, label string, rescan ImportRescan) FutureImportResult {
+	return c.importAsync(ctx, "importpubkey", hex.EncodeToString(pubKey),
+		label, rescan)
+}
+
+// ImportPubKey imports the passed serialized public key into the wallet as
+// watch-only, stored with the passed label, which may be empty.  The outputs
+// paying to the key and to its P2PKH, P2WPKH and P2SH-P2WPKH addresses are
+// watched.
+//
+// See ImportRescanBlocking for the duration of the request with a rescan.
+func (c *Client) ImportPubKey(ctx context.Context, pubKey []byte, label string, rescan ImportRescan) error {
+	return c.ImportPubKeyAsync(ctx, pubKey, label, rescan).Receive()
+}
+
+// ImportPrivKeyAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See ImportPrivKey for the blocking version and more details.
+func (c *Client) ImportPrivKeyAsync(ctx context.Context, privKeyWIF *ltcutil.WIF, label string, rescan ImportRescan) FutureImportResult {
+	wif := ""
+	if privKeyWIF != nil {
+		wif = privKeyWIF.String()
+	}
+	return c.ImportPrivKeyStringAsync(ctx, wif, label, rescan)
+}
+
+// ImportPrivKey imports the passed private key into the wallet, stored with
+// the passed label, which may be empty.
+//
+// See ImportPrivKeyString for how the key is checked, and
+// ImportRescanBlocking for the duration of the request with a rescan.
+//
+// NOTE: This function requires the wallet to be unlocked.
+func (c *Client) ImportPrivKey(ctx context.Context, privKeyWIF *ltcutil.WIF, label string, rescan ImportRescan) error {
+	return c.ImportPrivKeyAsync(ctx, privKeyWIF, label, rescan).Receive()
+}
+
+// ImportPrivKeyStringAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See ImportPrivKeyString for the blocking version and more details.
+func (c *Client) ImportPrivKeyStringAsync(ctx context.Context, privKeyWIF string, label string, rescan ImportRescan) FutureImportResult {
+	if err := checkPrivateKeys([]string{privKeyWIF}, c.config.ChainParams); err != nil {
+		return newFutureError(err)
+	}
+	return c.importAsync(ctx, "importprivkey", privKeyWIF, label, rescan)
+}
+
+// ImportPrivKeyString imports the passed private key in wallet import format
+// (WIF) into the wallet, like ImportPrivKey.
+//
+// The key is checked before it is sent, and an *ErrInvalidPrivateKey is
+// returned for a key which is not for the network of ConnConfig.ChainParams,
+// or of any Litecoin network when it is not set, such as a Bitcoin key.
+func (c *Client) ImportPrivKeyString(ctx context.Context, privKeyWIF string, label string, rescan ImportRescan) error {
+	return c.ImportPrivKeyStringAsync(ctx, privKeyWIF, label, rescan).Receive()
+}
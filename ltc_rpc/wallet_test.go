@@ -0,0 +1,675 @@
+package ltc_rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ltcsuite/ltcd/btcjson"
+	"github.com/ltcsuite/ltcd/chaincfg"
+	"github.com/ltcsuite/ltcd/chaincfg/chainhash"
+	"github.com/ltcsuite/ltcd/wire"
+	"github.com/ltcsuite/ltcutil"
+)
+
+// listUnspentReply is a listunspent reply of a Litecoin Core node.
+const listUnspentReply = `[
+	{
+		"txid": "97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9",
+		"vout": 1,
+		"address": "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		"label": "hot",
+		"scriptPubKey": "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+		"amount": 0.00001,
+		"confirmations": 6,
+		"spendable": true,
+		"solvable": true,
+		"safe": true
+	},
+	{
+		"txid": "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		"vout": 0,
+		"address": "MJaRnao1s62a2zAKSkmG582KbLKianqb7v",
+		"scriptPubKey": "a914751e76e8199196d454941c45d1b3a323f1433bd687",
+		"redeemScript": "51",
+		"amount": 83999999.99999999,
+		"confirmations": 0,
+		"spendable": false,
+		"solvable": false,
+		"safe": false
+	}
+]`
+
+func TestListUnspentMinMaxAddresses(t *testing.T) {
+	var mtx sync.Mutex
+	var params [][]json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = append(params, req.Params)
+		mtx.Unlock()
+		return json.RawMessage(listUnspentReply), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	unspent, err := client.ListUnspentMinMaxAddresses(ctx, 1, 9999999, []string{
+		"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		mwebMainNetAddr,
+	})
+	if err != nil {
+		t.Fatalf("ListUnspentMinMaxAddresses: %v", err)
+	}
+	if len(unspent) != 2 {
+		t.Fatalf("%d unspent outputs, want 2", len(unspent))
+	}
+	first, second := unspent[0], unspent[1]
+	if first.Vout != 1 || first.Confirmations != 6 || !first.Spendable ||
+		first.Label != "hot" {
+
+		t.Fatalf("unexpected first output %+v", first)
+	}
+	if second.Spendable || second.RedeemScript != "51" {
+		t.Fatalf("unexpected second output %+v", second)
+	}
+	outpoint, err := first.OutPoint()
+	if err != nil || outpoint.Hash.String() != first.TxID || outpoint.Index != 1 {
+		t.Fatalf("OutPoint: got %v, %v", outpoint, err)
+	}
+
+	// The amounts convert to litoshis exactly, including the ones beyond
+	// the precision of a float64.
+	amounts := []ltcutil.Amount{1000, 8399999999999999}
+	for i, utxo := range unspent {
+		amount, err := utxo.AmountLitoshi()
+		if err != nil {
+			t.Fatalf("AmountLitoshi: %v", err)
+		}
+		if amount != amounts[i] {
+			t.Fatalf("output %d: amount %d, want %d", i, amount, amounts[i])
+		}
+	}
+
+	if _, err := client.ListUnspentMinMaxAddresses(ctx, 0, 10, nil); err != nil {
+		t.Fatalf("ListUnspentMinMaxAddresses: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`[1,9999999,["ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9","` + mwebMainNetAddr + `"]]`,
+		`[0,10,[]]`,
+	}
+	for i, p := range params {
+		if got, _ := json.Marshal(p); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestLockUnspent(t *testing.T) {
+	const (
+		txid1 = "97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9"
+		txid2 = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	)
+
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		switch req.Method {
+		case "lockunspent":
+			return true, nil
+		case "listlockunspent":
+			return json.RawMessage(`[{"txid":"` + txid1 + `","vout":1},` +
+				`{"txid":"` + txid2 + `","vout":0}]`), nil
+		}
+		return nil, btcjson.ErrRPCMethodNotFound
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	hash1, _ := chainhash.NewHashFromStr(txid1)
+	hash2, _ := chainhash.NewHashFromStr(txid2)
+	outpoints := []wire.OutPoint{*wire.NewOutPoint(hash1, 1),
+		*wire.NewOutPoint(hash2, 0)}
+	if err := client.LockUnspent(ctx, false, outpoints); err != nil {
+		t.Fatalf("LockUnspent: %v", err)
+	}
+	locked, err := client.ListLockUnspent(ctx)
+	if err != nil {
+		t.Fatalf("ListLockUnspent: %v", err)
+	}
+	if len(locked) != 2 || locked[0] != outpoints[0] || locked[1] != outpoints[1] {
+		t.Fatalf("got locked outputs %v, want %v", locked, outpoints)
+	}
+	if err := client.LockUnspent(ctx, true, nil); err != nil {
+		t.Fatalf("LockUnspent: %v", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`lockunspent[false,[{"txid":"` + txid1 + `","vout":1},{"txid":"` + txid2 + `","vout":0}]]`,
+		`listlockunspent[]`,
+		`lockunspent[true,null]`,
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got requests %v, want %v", requests, want)
+	}
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Fatalf("got request %s, want %s", requests[i], want[i])
+		}
+	}
+}
+
+func TestFundRawTransaction(t *testing.T) {
+	funded := newWitnessTx()
+	fundedHex := hex.EncodeToString(serializeTx(t, funded))
+
+	var mtx sync.Mutex
+	var params [][]json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = append(params, req.Params)
+		calls := len(params)
+		mtx.Unlock()
+		if req.Method != "fundrawtransaction" {
+			return nil, btcjson.ErrRPCMethodNotFound
+		}
+		if calls > 2 {
+			return nil, btcjson.NewRPCError(-4, "Insufficient funds")
+		}
+		return json.RawMessage(`{"hex":"` + fundedHex +
+			`","fee":0.00000226,"changepos":-1}`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxOut(wire.NewTxOut(150000000, p2wpkhScript))
+	changeAddr, err := DecodeAddress("ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	feeRate, includeWatching, lockUnspents := int64(2), false, true
+	result, err := client.FundRawTransaction(ctx, tx, FundOpts{
+		ChangeAddress:          changeAddr,
+		FeeRate:                &feeRate,
+		SubtractFeeFromOutputs: []int{0},
+		IncludeWatching:        &includeWatching,
+		LockUnspents:           &lockUnspents,
+	})
+	if err != nil {
+		t.Fatalf("FundRawTransaction: %v", err)
+	}
+	if result.Transaction.WitnessHash() != funded.WitnessHash() ||
+		result.Fee != 226 || result.ChangePosition != -1 {
+
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// Options which are not set are left out.
+	if _, err := client.FundRawTransaction(ctx, tx, FundOpts{}); err != nil {
+		t.Fatalf("FundRawTransaction: %v", err)
+	}
+
+	_, err = client.FundRawTransaction(ctx, tx, FundOpts{})
+	var insufficient *ErrInsufficientFunds
+	if !errors.As(err, &insufficient) {
+		t.Fatalf("got error %v, want ErrInsufficientFunds", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	txHex := hex.EncodeToString(serializeTx(t, tx))
+	want := []string{
+		`["` + txHex + `",{"changeAddress":"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",` +
+			`"fee_rate":2,"includeWatching":false,"lockUnspents":true,` +
+			`"subtractFeeFromOutputs":[0]}]`,
+		`["` + txHex + `",{}]`,
+	}
+	for i := range want {
+		if got, _ := json.Marshal(params[i]); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestSendToAddress(t *testing.T) {
+	const txHash = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	var mtx sync.Mutex
+	var params [][]json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = append(params, req.Params)
+		calls := len(params)
+		mtx.Unlock()
+		if calls > 5 {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCWalletInsufficientFunds,
+				"Insufficient funds")
+		}
+		return txHash, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress("ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	mwebAddr, err := DecodeAddress(mwebMainNetAddr, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+
+	// The amounts are sent exactly, neither rounded nor in exponent
+	// notation, from a single litoshi to the whole supply.
+	calls := []struct {
+		addr   ltcutil.Address
+		amount ltcutil.Amount
+		opts   SendToAddressOpts
+	}{
+		{addr, 1, SendToAddressOpts{}},
+		{addr, maxLitoshi, SendToAddressOpts{}},
+		{addr, maxLitoshi - 1, SendToAddressOpts{Comment: "payout"}},
+		{addr, 12345678, SendToAddressOpts{SubtractFeeFromAmount: true}},
+		{mwebAddr, 100000, SendToAddressOpts{}},
+	}
+	for _, call := range calls {
+		hash, err := client.SendToAddress(ctx, call.addr, call.amount,
+			call.opts)
+		if err != nil {
+			t.Fatalf("SendToAddress: %v", err)
+		}
+		if hash.String() != txHash {
+			t.Fatalf("unexpected hash %v", hash)
+		}
+	}
+
+	_, err = client.SendToAddress(ctx, addr, 1, SendToAddressOpts{})
+	var insufficient *ErrInsufficientFunds
+	if !errors.As(err, &insufficient) {
+		t.Fatalf("got error %v, want ErrInsufficientFunds", err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	const segwitAddr = `"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9"`
+	want := []string{
+		`[` + segwitAddr + `,0.00000001]`,
+		`[` + segwitAddr + `,84000000]`,
+		`[` + segwitAddr + `,83999999.99999999,"payout",""]`,
+		`[` + segwitAddr + `,0.12345678,"","",true]`,
+		`["` + mwebMainNetAddr + `",0.001]`,
+	}
+	for i := range want {
+		if got, _ := json.Marshal(params[i]); string(got) != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestGetBalance(t *testing.T) {
+	var mtx sync.Mutex
+	var requests []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		requests = append(requests, req.Method+string(p))
+		mtx.Unlock()
+		if req.Method == "getreceivedbyaddress" {
+			return json.RawMessage(`0.00000001`), nil
+		}
+		return json.RawMessage(`83999999.99999999`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	// The amounts are converted to litoshis exactly, although a float64
+	// can not hold them.
+	const want = maxLitoshi - 1
+	balance, err := client.GetBalance(ctx)
+	if err != nil || balance != want {
+		t.Fatalf("GetBalance = %d, %v", balance, err)
+	}
+	balance, err = client.GetBalanceMinConf(ctx, 6)
+	if err != nil || balance != want {
+		t.Fatalf("GetBalanceMinConf = %d, %v", balance, err)
+	}
+	addr, err := DecodeAddress("LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	received, err := client.GetReceivedByAddress(ctx, addr, 1)
+	if err != nil || received != 1 {
+		t.Fatalf("GetReceivedByAddress = %d, %v", received, err)
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	wantRequests := []string{
+		`getbalance[]`,
+		`getbalance["*",6]`,
+		`getreceivedbyaddress["LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",1]`,
+	}
+	for i := range wantRequests {
+		if requests[i] != wantRequests[i] {
+			t.Errorf("call %d sent %s, want %s", i, requests[i],
+				wantRequests[i])
+		}
+	}
+}
+
+func TestGetNewAddress(t *testing.T) {
+	replies := []string{
+		"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		"tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7klfsuq0",
+		"LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",
+		mwebMainNetAddr,
+	}
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		defer mtx.Unlock()
+		reply := replies[len(params)]
+		params = append(params, string(p))
+		return reply, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	calls := []struct {
+		addressType AddressType
+		net         *chaincfg.Params
+	}{
+		{AddressTypeBech32, &chaincfg.MainNetParams},
+		{AddressTypeBech32, &chaincfg.TestNet4Params},
+		{"", &chaincfg.MainNetParams},
+		{AddressTypeMweb, &chaincfg.MainNetParams},
+	}
+	for i, call := range calls {
+		addr, err := client.GetNewAddress(context.Background(), "deposits",
+			call.addressType)
+		if err != nil {
+			t.Fatalf("GetNewAddress: %v", err)
+		}
+		if addr.EncodeAddress() != replies[i] || !addr.IsForNet(call.net) {
+			t.Fatalf("address %d: got %v for %s", i, addr, call.net.Name)
+		}
+		if _, isMweb := addr.(*AddressMweb); isMweb !=
+			(call.addressType == AddressTypeMweb) {
+
+			t.Fatalf("address %d: got %T", i, addr)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`["deposits","bech32"]`,
+		`["deposits","bech32"]`,
+		`["deposits"]`,
+		`["deposits","mweb"]`,
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent params %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+func TestGetNewAddressRegtest(t *testing.T) {
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		return regtestMiningAddr, nil
+	})
+	defer server.Close()
+
+	// The rltc address of a regtest node is decoded for the configured
+	// network, and rejected by a client configured for mainnet.
+	tests := []struct {
+		net     *chaincfg.Params
+		wantErr bool
+	}{
+		{&RegressionNetParams, false},
+		{&chaincfg.MainNetParams, true},
+	}
+	for _, test := range tests {
+		config := testConnConfig(server)
+		config.ChainParams = test.net
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		addr, err := client.GetNewAddress(context.Background(), "",
+			AddressTypeBech32)
+		stopClient(client)
+		if test.wantErr {
+			if err == nil {
+				t.Fatalf("%s: expected an error, got %v", test.net.Name,
+					addr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: GetNewAddress: %v", test.net.Name, err)
+		}
+		if addr.EncodeAddress() != regtestMiningAddr ||
+			!addr.IsForNet(&RegressionNetParams) {
+
+			t.Fatalf("%s: got %v", test.net.Name, addr)
+		}
+	}
+}
+
+func TestListTransactions(t *testing.T) {
+	const (
+		txid1 = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+		txid2 = "97ddfbbae6be97fd6cdf3e7ca13232a3afff2353e29badfab7f73011edd4ced9"
+	)
+	var mtx sync.Mutex
+	var params []json.RawMessage
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		params = req.Params
+		mtx.Unlock()
+		return json.RawMessage(`[{
+			"address": "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+			"category": "receive",
+			"amount": 0.00000001,
+			"label": "deposits",
+			"vout": 0,
+			"confirmations": 3,
+			"blockhash": "12a765e31ffd4059bada1e25190f6e98c99d9714d334efa41a195a7e7e04bfe2",
+			"blocktime": 1317972665,
+			"txid": "` + txid1 + `",
+			"time": 1317972665,
+			"timereceived": 1317972665
+		}, {
+			"address": "LVuDpNCSSj6pQ7t9Pv6d6sUkLKoqDEVUnJ",
+			"category": "send",
+			"amount": -83999999.99999999,
+			"vout": 1,
+			"fee": -0.00000226,
+			"confirmations": 0,
+			"txid": "` + txid2 + `",
+			"time": 1317972700,
+			"timereceived": 1317972700,
+			"comment": "payout",
+			"abandoned": false
+		}]`), nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+
+	transactions, err := client.ListTransactionsCountFrom(context.Background(), 50, 100)
+	if err != nil {
+		t.Fatalf("ListTransactionsCountFrom: %v", err)
+	}
+	mtx.Lock()
+	if got, _ := json.Marshal(params); string(got) != `["*",50,100]` {
+		t.Fatalf("sent params %s", got)
+	}
+	mtx.Unlock()
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+
+	receive, send := transactions[0], transactions[1]
+	if receive.TxID.String() != txid1 || receive.Category != "receive" ||
+		receive.Amount != 1 || receive.Fee != nil ||
+		receive.Label != "deposits" || receive.Confirmations != 3 ||
+		receive.BlockTime != 1317972665 {
+
+		t.Fatalf("unexpected receive %+v", receive)
+	}
+	if send.TxID.String() != txid2 || send.Category != "send" ||
+		send.Amount != -(maxLitoshi-1) || send.Fee == nil ||
+		*send.Fee != -226 || send.Vout != 1 || send.Comment != "payout" {
+
+		t.Fatalf("unexpected send %+v", send)
+	}
+}
+
+func TestImport(t *testing.T) {
+	var mtx sync.Mutex
+	var params []string
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		p, _ := json.Marshal(req.Params)
+		mtx.Lock()
+		params = append(params, req.Method+string(p))
+		mtx.Unlock()
+		return nil, nil
+	})
+	defer server.Close()
+	client := newTestClient(t, server)
+	defer stopClient(client)
+	ctx := context.Background()
+
+	addr, err := DecodeAddress("ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+		&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("DecodeAddress: %v", err)
+	}
+	if err := client.ImportAddress(ctx, addr, "", ImportNoRescan); err != nil {
+		t.Fatalf("ImportAddress: %v", err)
+	}
+	if err := client.ImportAddressAsync(ctx, addr, "deposits",
+		ImportRescanBlocking).Receive(); err != nil {
+
+		t.Fatalf("ImportAddressAsync: %v", err)
+	}
+	script, _ := hex.DecodeString(multisigScript)
+	if err := client.ImportAddressScript(ctx, script, "cold", ImportNoRescan,
+		true); err != nil {
+
+		t.Fatalf("ImportAddressScript: %v", err)
+	}
+	pubKey, _ := hex.DecodeString(
+		"0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	if err := client.ImportPubKey(ctx, pubKey, "", ImportRescanBlocking); err != nil {
+		t.Fatalf("ImportPubKey: %v", err)
+	}
+	wif, err := ltcutil.DecodeWIF(ltcKey1)
+	if err != nil {
+		t.Fatalf("DecodeWIF: %v", err)
+	}
+	if err := client.ImportPrivKey(ctx, wif, "hot", ImportNoRescan); err != nil {
+		t.Fatalf("ImportPrivKey: %v", err)
+	}
+	if err := client.ImportPrivKeyString(ctx, tltcKey, "", ImportNoRescan); err != nil {
+		t.Fatalf("ImportPrivKeyString: %v", err)
+	}
+
+	// The label is always sent, even when empty, so the rescan flag is never
+	// taken for it.
+	mtx.Lock()
+	defer mtx.Unlock()
+	want := []string{
+		`importaddress["ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9","",false]`,
+		`importaddress["ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9","deposits",true]`,
+		`importaddress["` + multisigScript + `","cold",false,true]`,
+		`importpubkey["0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798","",true]`,
+		`importprivkey["` + ltcKey1 + `","hot",false]`,
+		`importprivkey["` + tltcKey + `","",false]`,
+	}
+	if len(params) != len(want) {
+		t.Fatalf("got requests %v, want %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("call %d sent %s, want %s", i, params[i], want[i])
+		}
+	}
+}
+
+func TestImportPrivKeyInvalidKey(t *testing.T) {
+	var mtx sync.Mutex
+	var calls int
+	server := newTestServer(t, func(req *testRequest) (interface{}, *btcjson.RPCError) {
+		mtx.Lock()
+		calls++
+		mtx.Unlock()
+		return nil, nil
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		net  *chaincfg.Params
+		key  string
+	}{
+		{"bitcoin key", nil, btcKey1},
+		{"other network", &chaincfg.MainNetParams, tltcKey},
+		{"malformed key", nil, ltcKey1[:len(ltcKey1)-1] + "W"},
+	}
+	for _, test := range tests {
+		config := testConnConfig(server)
+		config.ChainParams = test.net
+		client, err := New(config)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		err = client.ImportPrivKeyString(context.Background(), test.key, "",
+			ImportNoRescan)
+		stopClient(client)
+
+		var keyErr *ErrInvalidPrivateKey
+		if !errors.As(err, &keyErr) {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if strings.Contains(err.Error(), test.key) {
+			t.Fatalf("%s: error %q holds the key", test.name, err)
+		}
+	}
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if calls != 0 {
+		t.Fatalf("invalid keys sent to the server %d times", calls)
+	}
+}